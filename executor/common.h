@@ -156,6 +156,16 @@ static uint64 current_time_ms(void)
 }
 #endif
 
+#if SYZ_EXECUTOR
+static uint64 current_time_us(void)
+{
+	struct timespec ts;
+	if (clock_gettime(CLOCK_MONOTONIC, &ts))
+		fail("clock_gettime failed");
+	return (uint64)ts.tv_sec * 1000000 + (uint64)ts.tv_nsec / 1000;
+}
+#endif
+
 #if SYZ_EXECUTOR || SYZ_SANDBOX_ANDROID_UNTRUSTED_APP || SYZ_USE_TMP_DIR
 #include <stdlib.h>
 #include <sys/stat.h>
@@ -377,6 +387,161 @@ static uint16 csum_inet_digest(struct csum_inet* csum)
 {
 	return ~csum->acc;
 }
+
+struct csum_crc32 {
+	uint32 acc;
+};
+
+static void csum_crc32_init(struct csum_crc32* csum)
+{
+	csum->acc = 0xffffffff;
+}
+
+static void csum_crc32_update(struct csum_crc32* csum, const uint8* data, size_t length)
+{
+	// Bitwise CRC-32 (IEEE 802.3, reflected polynomial), the same as zlib crc32.
+	size_t i;
+	for (i = 0; i < length; i++) {
+		csum->acc ^= data[i];
+		int bit;
+		for (bit = 0; bit < 8; bit++)
+			csum->acc = (csum->acc >> 1) ^ (0xedb88320 & -(csum->acc & 1));
+	}
+}
+
+static uint32 csum_crc32_digest(struct csum_crc32* csum)
+{
+	return ~csum->acc;
+}
+
+struct csum_xor {
+	uint8 acc[8];
+	uint64 size;
+	uint64 pos;
+};
+
+static void csum_xor_init(struct csum_xor* csum, uint64 size)
+{
+	memset(csum->acc, 0, sizeof(csum->acc));
+	csum->size = size;
+	csum->pos = 0;
+}
+
+static void csum_xor_update(struct csum_xor* csum, const uint8* data, size_t length)
+{
+	// XOR of consecutive words of the checksum size, the last word is zero-padded.
+	size_t i;
+	for (i = 0; i < length; i++, csum->pos++)
+		csum->acc[csum->pos % csum->size] ^= data[i];
+}
+
+static uint64 csum_xor_digest(struct csum_xor* csum)
+{
+	// The accumulated bytes are the checksum as it is stored in memory (native byte order).
+	switch (csum->size) {
+	case 1:
+		return csum->acc[0];
+	case 2: {
+		uint16 v;
+		memcpy(&v, csum->acc, sizeof(v));
+		return v;
+	}
+	case 4: {
+		uint32 v;
+		memcpy(&v, csum->acc, sizeof(v));
+		return v;
+	}
+	default: {
+		uint64 v;
+		memcpy(&v, csum->acc, sizeof(v));
+		return v;
+	}
+	}
+}
+
+struct csum_fnv1a {
+	uint64 acc;
+	uint64 size;
+};
+
+static void csum_fnv1a_init(struct csum_fnv1a* csum, uint64 size)
+{
+	csum->acc = size == 4 ? 0x811c9dc5 : 0xcbf29ce484222325ull;
+	csum->size = size;
+}
+
+static void csum_fnv1a_update(struct csum_fnv1a* csum, const uint8* data, size_t length)
+{
+	// FNV-1a with 32-bit or 64-bit parameters depending on the hash size.
+	size_t i;
+	for (i = 0; i < length; i++) {
+		csum->acc ^= data[i];
+		if (csum->size == 4)
+			csum->acc = (uint32)(csum->acc * 0x01000193);
+		else
+			csum->acc *= 0x100000001b3ull;
+	}
+}
+
+static uint64 csum_fnv1a_digest(struct csum_fnv1a* csum)
+{
+	return csum->acc;
+}
+
+struct csum_add {
+	uint64 acc;
+	uint64 size;
+};
+
+static void csum_add_init(struct csum_add* csum, uint64 size)
+{
+	csum->acc = 0;
+	csum->size = size;
+}
+
+static void csum_add_update(struct csum_add* csum, const uint8* data, size_t length)
+{
+	// Sum of all bytes modulo 2^(8*size).
+	size_t i;
+	for (i = 0; i < length; i++)
+		csum->acc += data[i];
+}
+
+static uint64 csum_add_digest(struct csum_add* csum)
+{
+	if (csum->size == 8)
+		return csum->acc;
+	return csum->acc & ((1ull << (csum->size * 8)) - 1);
+}
+#endif
+
+#if SYZ_EXECUTOR || SYZ_USE_CHILD_PID
+#if GOOS_freebsd || GOOS_linux || GOOS_netbsd || GOOS_openbsd || GOOS_test
+#include <unistd.h>
+#endif
+
+// syz_child_pid returns pid of a child process that values of child_pid type refer to.
+// The child is spawned on first use and sleeps until the parent exits.
+// If the child can't be spawned, own pid is used; on OSes without fork it's 0.
+static int syz_child_pid(void)
+{
+#if GOOS_freebsd || GOOS_linux || GOOS_netbsd || GOOS_openbsd || GOOS_test
+	static int child_pid;
+	if (child_pid != 0)
+		return child_pid;
+	int parent = getpid();
+	int pid = fork();
+	if (pid == 0) {
+		while (getppid() == parent)
+			sleep(1);
+		_exit(0);
+	}
+	child_pid = pid > 0 ? pid : parent;
+	return child_pid;
+#else
+	return 0;
+#endif
+}
 #endif
 
 #if SYZ_EXECUTOR || __NR_syz_execute_func
@@ -26,6 +26,14 @@ static uint64 current_time_ms()
 }
 #endif
 
+#if SYZ_EXECUTOR
+static uint64 current_time_us()
+{
+	// Resolution is limited to milliseconds, which is enough for profiling of slow calls.
+	return GetTickCount64() * 1000;
+}
+#endif
+
 #if SYZ_EXECUTOR || SYZ_THREADED || SYZ_REPEAT && SYZ_EXECUTOR_USES_FORK_SERVER
 static void sleep_ms(uint64 ms)
 {
@@ -5,7 +5,7 @@
 
 #if GOARCH_amd64
 #define GOARCH "amd64"
-#define SYZ_REVISION "1b67f82f23d2ba92433b4f6bc3458ce1478d960c"
+#define SYZ_REVISION "36d91780ba2457e80f03ed044e607a8bbd3f6afa"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 1
 #define SYZ_EXECUTOR_USES_SHMEM 0
 #define SYZ_PAGE_SIZE 4096
@@ -20,7 +20,7 @@
 
 #if GOARCH_amd64
 #define GOARCH "amd64"
-#define SYZ_REVISION "873616249e74ae100e93afb6a14e4fef92b3acc4"
+#define SYZ_REVISION "bea096a9d6c11f042e2c66c1ac8b9e89fe085216"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 1
 #define SYZ_EXECUTOR_USES_SHMEM 1
 #define SYZ_PAGE_SIZE 4096
@@ -35,7 +35,7 @@
 
 #if GOARCH_amd64
 #define GOARCH "amd64"
-#define SYZ_REVISION "020338cc7e797e47ecbbe68691c0954e831070f4"
+#define SYZ_REVISION "17afa527dfcf285df4859e73af02d87e1e6e2b9c"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 0
 #define SYZ_EXECUTOR_USES_SHMEM 0
 #define SYZ_PAGE_SIZE 4096
@@ -45,7 +45,7 @@
 
 #if GOARCH_arm64
 #define GOARCH "arm64"
-#define SYZ_REVISION "6bfd73c3be80297b5e5295deeb2b1cdc93cda43b"
+#define SYZ_REVISION "1ecb4c304d5818f419d7cf4c4b0c397f70f0f4ea"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 0
 #define SYZ_EXECUTOR_USES_SHMEM 0
 #define SYZ_PAGE_SIZE 4096
@@ -60,7 +60,7 @@
 
 #if GOARCH_386
 #define GOARCH "386"
-#define SYZ_REVISION "0a0e33691ab05cdb4188e6ed357107f6b910fafb"
+#define SYZ_REVISION "5399332e59acb0ed881e7c0478cbfeca1cff058f"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 1
 #define SYZ_EXECUTOR_USES_SHMEM 1
 #define SYZ_PAGE_SIZE 4096
@@ -70,7 +70,7 @@
 
 #if GOARCH_amd64
 #define GOARCH "amd64"
-#define SYZ_REVISION "a17fc9ffd2d2e5fae6122fcc2f7b8307394fe83d"
+#define SYZ_REVISION "a816f7ca2028e951d4d43ada0a4ae6182583ca10"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 1
 #define SYZ_EXECUTOR_USES_SHMEM 1
 #define SYZ_PAGE_SIZE 4096
@@ -80,7 +80,7 @@
 
 #if GOARCH_arm
 #define GOARCH "arm"
-#define SYZ_REVISION "4fb2c502fa3c67c71fe60ddf2550873f4335a914"
+#define SYZ_REVISION "8ab9e079cf40d72b0a0ae1f15184817fb1e45b12"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 1
 #define SYZ_EXECUTOR_USES_SHMEM 1
 #define SYZ_PAGE_SIZE 4096
@@ -90,7 +90,7 @@
 
 #if GOARCH_arm64
 #define GOARCH "arm64"
-#define SYZ_REVISION "4b7009c8009f4d05ae3478b07945151611372941"
+#define SYZ_REVISION "6a99aea6931fd5ddde5c4b41c6027d842f35ddbb"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 1
 #define SYZ_EXECUTOR_USES_SHMEM 1
 #define SYZ_PAGE_SIZE 4096
@@ -100,7 +100,7 @@
 
 #if GOARCH_ppc64le
 #define GOARCH "ppc64le"
-#define SYZ_REVISION "1a16c369fcafc67ece8515c18e01226cccf49276"
+#define SYZ_REVISION "60e7543a5ca529152b69f551eea86c211c9685a7"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 1
 #define SYZ_EXECUTOR_USES_SHMEM 1
 #define SYZ_PAGE_SIZE 4096
@@ -115,7 +115,7 @@
 
 #if GOARCH_amd64
 #define GOARCH "amd64"
-#define SYZ_REVISION "ac2fb81fe359721cebd06da0bf1c893a787fc700"
+#define SYZ_REVISION "486bbadce6e6583040acb81dd258538d1e7b619f"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 1
 #define SYZ_EXECUTOR_USES_SHMEM 1
 #define SYZ_PAGE_SIZE 4096
@@ -130,7 +130,7 @@
 
 #if GOARCH_amd64
 #define GOARCH "amd64"
-#define SYZ_REVISION "9323c36f6354363bbc8628c16d604d46362805d5"
+#define SYZ_REVISION "a47589bd771beea2100f76ff985f91117d78a270"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 1
 #define SYZ_EXECUTOR_USES_SHMEM 1
 #define SYZ_PAGE_SIZE 4096
@@ -145,7 +145,7 @@
 
 #if GOARCH_32_fork_shmem
 #define GOARCH "32_fork_shmem"
-#define SYZ_REVISION "002e760d34d155bf4b1506dd1876d07e80626bca"
+#define SYZ_REVISION "0e55b61e6b29a351deb0ac19dfe25e8737223ff2"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 1
 #define SYZ_EXECUTOR_USES_SHMEM 1
 #define SYZ_PAGE_SIZE 4096
@@ -155,7 +155,7 @@
 
 #if GOARCH_32_shmem
 #define GOARCH "32_shmem"
-#define SYZ_REVISION "1fadf937f1d8e4a6e7ae08515295632f4878ea72"
+#define SYZ_REVISION "e244ccef54fe13202b806a1934349c62d4e49ff7"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 0
 #define SYZ_EXECUTOR_USES_SHMEM 1
 #define SYZ_PAGE_SIZE 8192
@@ -165,7 +165,7 @@
 
 #if GOARCH_64
 #define GOARCH "64"
-#define SYZ_REVISION "f2ea17f08041673e28f3c64109373c828a27d585"
+#define SYZ_REVISION "ac6ea8e6d4fa6b8524a6aac84b6a4a4824784244"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 0
 #define SYZ_EXECUTOR_USES_SHMEM 0
 #define SYZ_PAGE_SIZE 4096
@@ -175,7 +175,7 @@
 
 #if GOARCH_64_fork
 #define GOARCH "64_fork"
-#define SYZ_REVISION "dffeac0922d7804bbc534a7a20c77b30232ebc4d"
+#define SYZ_REVISION "0f66e9a158828701fc788859aa83a24b2008a40d"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 1
 #define SYZ_EXECUTOR_USES_SHMEM 0
 #define SYZ_PAGE_SIZE 8192
@@ -190,7 +190,7 @@
 
 #if GOARCH_arm
 #define GOARCH "arm"
-#define SYZ_REVISION "e310afc1c6f271074c53522dc18d6a86e8f2553f"
+#define SYZ_REVISION "0977dd080deb413de35cb9551074eac406be9b0d"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 0
 #define SYZ_EXECUTOR_USES_SHMEM 0
 #define SYZ_PAGE_SIZE 4096
@@ -205,7 +205,7 @@
 
 #if GOARCH_amd64
 #define GOARCH "amd64"
-#define SYZ_REVISION "7f58e6832b5d8674b8a77505e6eb0fa213781c23"
+#define SYZ_REVISION "a476f4c77acb8ed6f4c9f866616afdf1c2f8975e"
 #define SYZ_EXECUTOR_USES_FORK_SERVER 0
 #define SYZ_EXECUTOR_USES_SHMEM 0
 #define SYZ_PAGE_SIZE 4096
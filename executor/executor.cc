@@ -125,6 +125,9 @@ static bool flag_dedup_cover;
 static bool flag_threaded;
 static bool flag_collide;
 
+// If true, then executor should measure execution time of calls.
+static bool flag_profile_calls;
+
 // If true, then executor should write the comparisons data to fuzzer.
 static bool flag_collect_comps;
 
@@ -142,11 +145,13 @@ const int kMaxInput = 2 << 20;
 const uint64 instr_eof = -1;
 const uint64 instr_copyin = -2;
 const uint64 instr_copyout = -3;
+const uint64 instr_guard = -4;
 
 const uint64 arg_const = 0;
 const uint64 arg_result = 1;
 const uint64 arg_data = 2;
 const uint64 arg_csum = 3;
+const uint64 arg_child_pid = 4;
 
 const uint64 binary_format_native = 0;
 const uint64 binary_format_bigendian = 1;
@@ -166,6 +171,10 @@ static char input_data[kMaxInput];
 
 // Checksum kinds.
 static const uint64 arg_csum_inet = 0;
+static const uint64 arg_csum_crc32 = 1;
+static const uint64 arg_csum_xor = 2;
+static const uint64 arg_csum_fnv1a = 3;
+static const uint64 arg_csum_add = 4;
 
 // Checksum chunk kinds.
 static const uint64 arg_csum_chunk_data = 0;
@@ -177,6 +186,8 @@ struct call_t {
 	const char* name;
 	int sys_nr;
 	syscall_t call;
+	int retries; // how many times to re-execute the call if it fails
+	int group; // atomic group of the call (0 if none)
 };
 
 struct cover_t {
@@ -202,6 +213,8 @@ struct thread_t {
 	long res;
 	uint32 reserrno;
 	bool fault_injected;
+	uint64 start_us;
+	uint64 end_us;
 	cover_t cov;
 };
 
@@ -217,6 +230,11 @@ struct res_t {
 
 static res_t results[kMaxCommands];
 
+// Guard pages made inaccessible by the current program.
+const int kMaxGuardPages = 64;
+static char* guard_pages[kMaxGuardPages];
+static int num_guard_pages;
+
 const uint64 kInMagic = 0xbadc0ffeebadface;
 const uint32 kOutMagic = 0xbadf00d;
 
@@ -258,6 +276,7 @@ struct call_reply {
 	uint32 call_num;
 	uint32 reserrno;
 	uint32 flags;
+	uint32 duration_us;
 	uint32 signal_size;
 	uint32 cover_size;
 	uint32 comps_size;
@@ -286,7 +305,7 @@ struct kcov_comparison_t {
 	bool operator<(const struct kcov_comparison_t& other) const;
 };
 
-static thread_t* schedule_call(int call_index, int call_num, bool colliding, uint64 copyout_index, uint64 num_args, uint64* args, uint64* pos);
+static thread_t* schedule_call(int call_index, int call_num, bool colliding, uint64 copyout_index, uint64 num_args, uint64* args, uint64* pos, thread_t* prefer);
 static void handle_completion(thread_t* th);
 static void copyout_call_results(thread_t* th);
 static void write_call_output(thread_t* th, bool finished);
@@ -301,7 +320,10 @@ static uint64 read_result(uint64** input_posp);
 static uint64 swap(uint64 v, uint64 size, uint64 bf);
 static void copyin(char* addr, uint64 val, uint64 size, uint64 bf, uint64 bf_off, uint64 bf_len);
 static bool copyout(char* addr, uint64 size, uint64* res);
+static void guard_page(char* addr);
+static void unguard_pages();
 static void setup_control_pipes();
+static void setup_funcptrs();
 
 #include "syscalls.h"
 
@@ -335,6 +357,7 @@ int main(int argc, char** argv)
 	start_time_ms = current_time_ms();
 
 	os_init(argc, argv, (void*)SYZ_DATA_OFFSET, SYZ_NUM_PAGES * SYZ_PAGE_SIZE);
+	setup_funcptrs();
 
 #if SYZ_EXECUTOR_USES_SHMEM
 	if (mmap(&input_data[0], kMaxInput, PROT_READ, MAP_PRIVATE | MAP_FIXED, kInFd, 0) != &input_data[0])
@@ -497,14 +520,15 @@ void receive_execute()
 	flag_collect_comps = req.exec_flags & (1 << 3);
 	flag_threaded = req.exec_flags & (1 << 4);
 	flag_collide = req.exec_flags & (1 << 5);
+	flag_profile_calls = req.exec_flags & (1 << 6);
 	flag_fault_call = req.fault_call;
 	flag_fault_nth = req.fault_nth;
 	if (!flag_threaded)
 		flag_collide = false;
-	debug("[%llums] exec opts: procid=%llu threaded=%d collide=%d cover=%d comps=%d dedup=%d fault=%d/%d/%d prog=%llu\n",
+	debug("[%llums] exec opts: procid=%llu threaded=%d collide=%d cover=%d comps=%d dedup=%d fault=%d/%d/%d profile=%d prog=%llu\n",
 	      current_time_ms() - start_time_ms, procid, flag_threaded, flag_collide,
 	      flag_collect_cover, flag_collect_comps, flag_dedup_cover, flag_inject_fault,
-	      flag_fault_call, flag_fault_nth, req.prog_size);
+	      flag_fault_call, flag_fault_nth, flag_profile_calls, req.prog_size);
 	if (SYZ_EXECUTOR_USES_SHMEM) {
 		if (req.prog_size)
 			fail("need_prog: no program");
@@ -558,6 +582,7 @@ void execute_one()
 	write_output(0); // Number of executed syscalls (updated later).
 #endif
 	uint64 start = current_time_ms();
+	unguard_pages();
 
 retry:
 	uint64* input_pos = (uint64*)input_data;
@@ -570,6 +595,8 @@ retry:
 	}
 
 	int call_index = 0;
+	int prev_group = 0;
+	int broken_group = 0;
 	for (;;) {
 		uint64 call_num = read_input(&input_pos);
 		if (call_num == instr_eof)
@@ -592,6 +619,13 @@ retry:
 				copyin(addr, val, size, bf, 0, 0);
 				break;
 			}
+			case arg_child_pid: {
+				uint64 meta = read_input(&input_pos);
+				uint64 size = meta & 0xff;
+				uint64 bf = meta >> 8;
+				copyin(addr, syz_child_pid(), size, bf, 0, 0);
+				break;
+			}
 			case arg_data: {
 				uint64 size = read_input(&input_pos);
 				size &= ~(1ull << 63); // readable flag
@@ -643,6 +677,70 @@ retry:
 					copyin(csum_addr, csum_value, 2, binary_format_native, 0, 0);
 					break;
 				}
+				case arg_csum_crc32:
+				case arg_csum_xor: {
+					if (csum_kind == arg_csum_crc32 && size != 4)
+						fail("crc32 checksum must be 4 bytes, not %llu", size);
+					if (csum_kind == arg_csum_xor && size != 1 && size != 2 && size != 4 && size != 8)
+						fail("xor checksum must be 1, 2, 4 or 8 bytes, not %llu", size);
+					debug_verbose("calculating checksum for %p\n", csum_addr);
+					struct csum_crc32 crc;
+					csum_crc32_init(&crc);
+					struct csum_xor x;
+					csum_xor_init(&x, size);
+					uint64 chunks_num = read_input(&input_pos);
+					uint64 chunk;
+					for (chunk = 0; chunk < chunks_num; chunk++) {
+						uint64 chunk_kind = read_input(&input_pos);
+						uint64 chunk_value = read_input(&input_pos);
+						uint64 chunk_size = read_input(&input_pos);
+						if (chunk_kind != arg_csum_chunk_data)
+							fail("bad checksum chunk kind %llu", chunk_kind);
+						debug_verbose("#%lld: data chunk, addr: %llx, size: %llu\n",
+							      chunk, chunk_value, chunk_size);
+						if (csum_kind == arg_csum_crc32) {
+							NONFAILING(csum_crc32_update(&crc, (const uint8*)chunk_value, chunk_size));
+						} else {
+							NONFAILING(csum_xor_update(&x, (const uint8*)chunk_value, chunk_size));
+						}
+					}
+					uint64 csum_value = csum_kind == arg_csum_crc32 ? csum_crc32_digest(&crc) : csum_xor_digest(&x);
+					debug_verbose("writing checksum %llx to %p\n", csum_value, csum_addr);
+					copyin(csum_addr, csum_value, size, binary_format_native, 0, 0);
+					break;
+				}
+				case arg_csum_fnv1a:
+				case arg_csum_add: {
+					if (csum_kind == arg_csum_fnv1a && size != 4 && size != 8)
+						fail("fnv1a hash must be 4 or 8 bytes, not %llu", size);
+					if (csum_kind == arg_csum_add && size != 1 && size != 2 && size != 4 && size != 8)
+						fail("add hash must be 1, 2, 4 or 8 bytes, not %llu", size);
+					debug_verbose("calculating hash for %p\n", csum_addr);
+					struct csum_fnv1a fnv;
+					csum_fnv1a_init(&fnv, size);
+					struct csum_add add;
+					csum_add_init(&add, size);
+					uint64 chunks_num = read_input(&input_pos);
+					uint64 chunk;
+					for (chunk = 0; chunk < chunks_num; chunk++) {
+						uint64 chunk_kind = read_input(&input_pos);
+						uint64 chunk_value = read_input(&input_pos);
+						uint64 chunk_size = read_input(&input_pos);
+						if (chunk_kind != arg_csum_chunk_data)
+							fail("bad hash chunk kind %llu", chunk_kind);
+						debug_verbose("#%lld: data chunk, addr: %llx, size: %llu\n",
+							      chunk, chunk_value, chunk_size);
+						if (csum_kind == arg_csum_fnv1a) {
+							NONFAILING(csum_fnv1a_update(&fnv, (const uint8*)chunk_value, chunk_size));
+						} else {
+							NONFAILING(csum_add_update(&add, (const uint8*)chunk_value, chunk_size));
+						}
+					}
+					uint64 csum_value = csum_kind == arg_csum_fnv1a ? csum_fnv1a_digest(&fnv) : csum_add_digest(&add);
+					debug_verbose("writing hash %llx to %p\n", csum_value, csum_addr);
+					copyin(csum_addr, csum_value, size, binary_format_native, 0, 0);
+					break;
+				}
 				default:
 					fail("bad checksum kind %llu", csum_kind);
 				}
@@ -656,10 +754,14 @@ retry:
 		if (call_num == instr_copyout) {
 			read_input(&input_pos); // index
 			read_input(&input_pos); // addr
-			read_input(&input_pos); // size
+			read_input(&input_pos); // meta
 			// The copyout will happen when/if the call completes.
 			continue;
 		}
+		if (call_num == instr_guard) {
+			guard_page((char*)read_input(&input_pos));
+			continue;
+		}
 
 		// Normal syscall.
 		if (call_num >= ARRAY_SIZE(syscalls))
@@ -673,18 +775,29 @@ retry:
 			args[i] = read_arg(&input_pos);
 		for (uint64 i = num_args; i < kMaxArgs; i++)
 			args[i] = 0;
+		const call_t* call = &syscalls[call_num];
+		// Adjacent calls of the same atomic group are executed on the same thread,
+		// unless one of the previous members has not completed in time.
+		if (call->group != prev_group)
+			broken_group = 0;
+		thread_t* group_th = call->group && call->group == prev_group && call->group != broken_group ? last_scheduled : 0;
+		prev_group = call->group;
 		thread_t* th = schedule_call(call_index++, call_num, colliding, copyout_index,
-					     num_args, args, input_pos);
+					     num_args, args, input_pos, group_th);
 
-		if (colliding && (call_index % 2) == 0) {
+		if (colliding && (call_index % 2) == 0 && !call->group) {
 			// Don't wait for every other call.
 			// We already have results from the previous execution.
 		} else if (flag_threaded) {
 			// Wait for call completion.
 			// Note: sys knows about this 25ms timeout when it generates timespec/timeval values.
 			const uint64 timeout_ms = flag_debug ? 1000 : 45;
-			if (event_timedwait(&th->done, timeout_ms))
+			if (event_timedwait(&th->done, timeout_ms)) {
 				handle_completion(th);
+			} else if (call->group) {
+				// The group member is blocked, the rest of the group is scheduled as usual.
+				broken_group = call->group;
+			}
 			// Check if any of previous calls have completed.
 			for (int i = 0; i < kMaxThreads; i++) {
 				th = &threads[i];
@@ -739,11 +852,19 @@ retry:
 	}
 }
 
-thread_t* schedule_call(int call_index, int call_num, bool colliding, uint64 copyout_index, uint64 num_args, uint64* args, uint64* pos)
+thread_t* schedule_call(int call_index, int call_num, bool colliding, uint64 copyout_index, uint64 num_args, uint64* args, uint64* pos, thread_t* prefer)
 {
 	// Find a spare thread to execute the call.
-	int i;
-	for (i = 0; i < kMaxThreads; i++) {
+	// The preferred thread (if any) is used only if it has completed its previous call.
+	if (prefer && (!event_isset(&prefer->done) || event_isset(&prefer->ready)))
+		prefer = 0;
+	int i = 0;
+	if (prefer) {
+		i = prefer->id;
+		if (prefer->executing)
+			handle_completion(prefer);
+	}
+	for (; !prefer && i < kMaxThreads; i++) {
 		thread_t* th = &threads[i];
 		if (!th->created)
 			thread_create(th, i);
@@ -850,11 +971,13 @@ void copyout_call_results(thread_t* th)
 			if (index >= kMaxCommands)
 				fail("result idx %lld overflows kMaxCommands", index);
 			char* addr = (char*)read_input(&th->copyout_pos);
-			uint64 size = read_input(&th->copyout_pos);
+			uint64 meta = read_input(&th->copyout_pos);
+			uint64 size = meta & 0xff;
+			uint64 bf = meta >> 8;
 			uint64 val = 0;
 			if (copyout(addr, size, &val)) {
 				results[index].executed = true;
-				results[index].val = val;
+				results[index].val = swap(val, size, bf);
 			}
 			debug_verbose("copyout 0x%llx from %p\n", val, addr);
 			break;
@@ -876,11 +999,16 @@ void write_call_output(thread_t* th, bool finished)
 		call_flags |= call_flag_finished |
 			      (th->fault_injected ? call_flag_fault_injected : 0);
 	}
+	// For calls that are still running report the time they have been running so far.
+	uint32 duration_us = 0;
+	if (flag_profile_calls)
+		duration_us = (finished ? th->end_us : current_time_us()) - th->start_us;
 #if SYZ_EXECUTOR_USES_SHMEM
 	write_output(th->call_index);
 	write_output(th->call_num);
 	write_output(reserrno);
 	write_output(call_flags);
+	write_output(duration_us);
 	uint32* signal_count_pos = write_output(0); // filled in later
 	uint32* cover_count_pos = write_output(0); // filled in later
 	uint32* comps_count_pos = write_output(0); // filled in later
@@ -909,8 +1037,8 @@ void write_call_output(thread_t* th, bool finished)
 		else
 			write_coverage_signal<uint32>(&th->cov, signal_count_pos, cover_count_pos);
 	}
-	debug_verbose("out #%u: index=%u num=%u errno=%d finished=%d blocked=%d sig=%u cover=%u comps=%u\n",
-		      completed, th->call_index, th->call_num, reserrno, finished, blocked,
+	debug_verbose("out #%u: index=%u num=%u errno=%d finished=%d blocked=%d duration=%uus sig=%u cover=%u comps=%u\n",
+		      completed, th->call_index, th->call_num, reserrno, finished, blocked, duration_us,
 		      *signal_count_pos, *cover_count_pos, *comps_count_pos);
 	completed++;
 	write_completed(completed);
@@ -923,6 +1051,7 @@ void write_call_output(thread_t* th, bool finished)
 	reply.call_num = th->call_num;
 	reply.reserrno = reserrno;
 	reply.flags = call_flags;
+	reply.duration_us = duration_us;
 	reply.signal_size = 0;
 	reply.cover_size = 0;
 	reply.comps_size = 0;
@@ -945,6 +1074,7 @@ void write_extra_output()
 	write_output(-1); // call num
 	write_output(999); // errno
 	write_output(0); // call flags
+	write_output(0); // duration
 	uint32* signal_count_pos = write_output(0); // filled in later
 	uint32* cover_count_pos = write_output(0); // filled in later
 	write_output(0); // comps_count_pos
@@ -1005,11 +1135,25 @@ void execute_call(thread_t* th)
 		fail_fd = inject_fault(flag_fault_nth);
 	}
 
-	if (flag_cover)
-		cover_reset(&th->cov);
-	errno = 0;
-	th->res = execute_syscall(call, th->args);
-	th->reserrno = errno;
+	// Don't retry calls with injected faults, the fault is injected only into the first attempt.
+	int retries = call->retries;
+	if (flag_inject_fault && th->call_index == flag_fault_call)
+		retries = 0;
+	if (flag_profile_calls)
+		th->start_us = current_time_us();
+	for (int attempt = 0;; attempt++) {
+		if (flag_cover)
+			cover_reset(&th->cov);
+		errno = 0;
+		th->res = execute_syscall(call, th->args);
+		th->reserrno = errno;
+		if (th->res != -1 || attempt >= retries)
+			break;
+		debug("#%d [%llums] <- %s=-1 errno=%d, retrying\n",
+		      th->id, current_time_ms() - start_time_ms, call->name, th->reserrno);
+	}
+	if (flag_profile_calls)
+		th->end_us = current_time_us();
 	if (th->res == -1 && th->reserrno == 0)
 		th->reserrno = EINVAL; // our syz syscalls may misbehave
 	if (flag_cover) {
@@ -1144,6 +1288,75 @@ bool copyout(char* addr, uint64 size, uint64* res)
 	return ok;
 }
 
+static bool protect_page(char* addr, bool accessible)
+{
+#if GOOS_windows
+	DWORD old;
+	return VirtualProtect(addr, SYZ_PAGE_SIZE, accessible ? PAGE_EXECUTE_READWRITE : PAGE_NOACCESS, &old);
+#elif GOOS_fuchsia
+	// Guard pages are not supported, the poisoned slack is still there.
+	return false;
+#else
+	return mprotect(addr, SYZ_PAGE_SIZE, accessible ? PROT_READ | PROT_WRITE : PROT_NONE) == 0;
+#endif
+}
+
+void guard_page(char* addr)
+{
+	for (int i = 0; i < num_guard_pages; i++) {
+		if (guard_pages[i] == addr)
+			return;
+	}
+	if (num_guard_pages == kMaxGuardPages)
+		return;
+	debug_verbose("guard page %p\n", addr);
+	if (protect_page(addr, false))
+		guard_pages[num_guard_pages++] = addr;
+}
+
+// unguard_pages makes guard pages of the previous program accessible again,
+// this matters only if programs are executed in the same process.
+void unguard_pages()
+{
+	for (int i = 0; i < num_guard_pages; i++)
+		protect_page(guard_pages[i], true);
+	num_guard_pages = 0;
+}
+
+// setup_funcptrs maps the page with function stubs that values of funcptr type point to
+// (see prog.Target.FuncPtr). The stubs return 0, 1 and -1, respectively, and are 16 bytes apart.
+void setup_funcptrs()
+{
+#if GOOS_linux || GOOS_test || GOOS_freebsd || GOOS_netbsd || GOOS_openbsd
+	char* page = (char*)SYZ_DATA_OFFSET + SYZ_NUM_PAGES * SYZ_PAGE_SIZE;
+	if (mmap(page, SYZ_PAGE_SIZE, PROT_READ | PROT_WRITE | PROT_EXEC, MAP_ANON | MAP_PRIVATE | MAP_FIXED, -1, 0) != page)
+		fail("mmap of funcptr page failed");
+#if GOARCH_amd64
+	static const unsigned char stubs[][16] = {
+	    {0x31, 0xc0, 0xc3}, // xor %eax, %eax; ret
+	    {0xb8, 0x01, 0x00, 0x00, 0x00, 0xc3}, // mov $1, %eax; ret
+	    {0x48, 0x83, 0xc8, 0xff, 0xc3}, // or $-1, %rax; ret
+	};
+	memcpy(page, stubs, sizeof(stubs));
+#elif GOARCH_386
+	static const unsigned char stubs[][16] = {
+	    {0x31, 0xc0, 0xc3}, // xor %eax, %eax; ret
+	    {0xb8, 0x01, 0x00, 0x00, 0x00, 0xc3}, // mov $1, %eax; ret
+	    {0x83, 0xc8, 0xff, 0xc3}, // or $-1, %eax; ret
+	};
+	memcpy(page, stubs, sizeof(stubs));
+#elif GOARCH_arm64
+	static const unsigned char stubs[][16] = {
+	    {0x00, 0x00, 0x80, 0xd2, 0xc0, 0x03, 0x5f, 0xd6}, // mov x0, #0; ret
+	    {0x20, 0x00, 0x80, 0xd2, 0xc0, 0x03, 0x5f, 0xd6}, // mov x0, #1; ret
+	    {0x00, 0x00, 0x80, 0x92, 0xc0, 0x03, 0x5f, 0xd6}, // mov x0, #-1; ret
+	};
+	memcpy(page, stubs, sizeof(stubs));
+#endif
+	// On other architectures the page stays filled with zeros.
+#endif
+}
+
 uint64 read_arg(uint64** input_posp)
 {
 	uint64 typ = read_input(input_posp);
@@ -1160,9 +1373,16 @@ uint64 read_arg(uint64** input_posp)
 	case arg_result: {
 		uint64 meta = read_input(input_posp);
 		uint64 bf = meta >> 8;
-		if (bf != binary_format_native)
+		if (bf != binary_format_native && bf != binary_format_bigendian)
 			fail("bad result argument format %llu", bf);
-		return read_result(input_posp);
+		return swap(read_result(input_posp), meta & 0xff, bf);
+	}
+	case arg_child_pid: {
+		uint64 meta = read_input(input_posp);
+		uint64 bf = meta >> 8;
+		if (bf != binary_format_native && bf != binary_format_bigendian)
+			fail("bad argument binary format %llu", bf);
+		return swap(syz_child_pid(), meta & 0xff, bf);
 	}
 	default:
 		fail("bad argument type %llu", typ);
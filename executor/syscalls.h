@@ -14800,10 +14800,31 @@ const call_t syscalls[] = {
     {"test$array0", 0},
     {"test$array1", 0},
     {"test$array2", 0},
+    {"test$arrayindex0", 0},
+    {"test$arrayindex1", 0},
+    {"test$atomic0", 0, 0, 0, 1},
+    {"test$atomic1", 0, 0, 0, 1},
     {"test$auto0", 0},
+    {"test$be_res0", 0},
+    {"test$be_res1", 0},
     {"test$bf0", 0},
     {"test$bf1", 0},
+    {"test$bf2", 0},
     {"test$blob0", 0},
+    {"test$child_pid", 0},
+    {"test$choice0", 0},
+    {"test$choice1", 0},
+    {"test$compat0", 0},
+    {"test$compat1", 0},
+    {"test$compat2", 0},
+    {"test$cq_reap", 0},
+    {"test$cq_reap2", 0},
+    {"test$cq_setup", 0},
+    {"test$cq_submit0", 0},
+    {"test$cq_submit1", 0},
+    {"test$cq_submit2", 0},
+    {"test$cq_use", 0},
+    {"test$csum_crc32", 0},
     {"test$csum_encode", 0},
     {"test$csum_ipv4", 0},
     {"test$csum_ipv4_tcp", 0},
@@ -14811,13 +14832,37 @@ const call_t syscalls[] = {
     {"test$csum_ipv6_icmp", 0},
     {"test$csum_ipv6_tcp", 0},
     {"test$csum_ipv6_udp", 0},
+    {"test$csum_xor", 0},
+    {"test$derive0", 0},
+    {"test$derive1", 0},
     {"test$end0", 0},
     {"test$end1", 0},
+    {"test$end2", 0},
+    {"test$event0", 0},
+    {"test$event1", 0},
+    {"test$event2", 0},
     {"test$excessive_args1", 0},
     {"test$excessive_args2", 0},
     {"test$excessive_fields1", 0},
+    {"test$exhaustive0", 0},
+    {"test$flagindex", 0},
+    {"test$footer", 0},
+    {"test$format", 0},
+    {"test$guard0", 0},
+    {"test$guard1", 0},
+    {"test$hash_add", 0},
+    {"test$hash_fnv1a", 0},
     {"test$hint_data", 0},
+    {"test$hook", 0},
     {"test$int", 0},
+    {"test$int_buckets", 0},
+    {"test$int_pow2", 0},
+    {"test$int_relations0", 0},
+    {"test$int_relations1", 0},
+    {"test$inv0", 0},
+    {"test$inv1", 0},
+    {"test$inv2", 0},
+    {"test$inv3", 0},
     {"test$length0", 0},
     {"test$length1", 0},
     {"test$length10", 0},
@@ -14842,6 +14887,14 @@ const call_t syscalls[] = {
     {"test$length28", 0},
     {"test$length29", 0},
     {"test$length3", 0},
+    {"test$length30", 0},
+    {"test$length31", 0},
+    {"test$length32", 0},
+    {"test$length33", 0},
+    {"test$length34", 0},
+    {"test$length35", 0},
+    {"test$length36", 0},
+    {"test$length37", 0},
     {"test$length4", 0},
     {"test$length5", 0},
     {"test$length6", 0},
@@ -14850,31 +14903,80 @@ const call_t syscalls[] = {
     {"test$length9", 0},
     {"test$missing_resource", 0},
     {"test$missing_struct", 0},
+    {"test$mutate_weight", 0},
+    {"test$nlattr", 0},
+    {"test$ns_obj0", 0},
+    {"test$ns_obj1", 0},
+    {"test$ns_switch", 0},
+    {"test$omittable", 0},
     {"test$opt0", 0},
     {"test$opt1", 0},
     {"test$opt2", 0},
     {"test$opt3", 0},
+    {"test$overlap0", 0},
+    {"test$overlap1", 0},
+    {"test$overlay", 0},
+    {"test$parity", 0},
+    {"test$promote", 0},
+    {"test$ptr_chain", 0},
     {"test$recur0", 0},
     {"test$recur1", 0},
     {"test$recur2", 0},
+    {"test$refcnt0", 0},
+    {"test$refcnt1", 0},
+    {"test$refcnt2", 0},
+    {"test$refcnt3", 0},
     {"test$regression0", 0},
     {"test$regression1", 0},
     {"test$regression2", 0},
+    {"test$request_mask0", 0},
+    {"test$request_mask1", 0},
+    {"test$request_mask2", 0},
+    {"test$requires0", 0},
+    {"test$requires1", 0},
+    {"test$requires2", 0},
     {"test$res0", 0},
     {"test$res1", 0},
+    {"test$res10", 0},
     {"test$res2", 0},
+    {"test$res3", 0},
+    {"test$res4", 0},
+    {"test$res5", 0},
+    {"test$res6", 0},
+    {"test$res7", 0},
+    {"test$res8", 0, 0, 3},
+    {"test$res9", 0},
+    {"test$ring", 0},
+    {"test$serialized", 0},
+    {"test$shared_setup", 0},
+    {"test$shared_use", 0},
+    {"test$slot0", 0},
+    {"test$sockaddr", 0},
+    {"test$sparse0", 0},
+    {"test$sparse1", 0},
     {"test$str0", 0},
     {"test$struct", 0},
+    {"test$subkind0", 0},
+    {"test$subkind1", 0},
+    {"test$subkind2", 0},
     {"test$syz_union3", 0},
     {"test$syz_union4", 0},
+    {"test$tagged", 0},
     {"test$text_x86_16", 0},
     {"test$text_x86_32", 0},
     {"test$text_x86_64", 0},
     {"test$text_x86_real", 0},
+    {"test$txn_begin", 0},
+    {"test$txn_end", 0},
+    {"test$txn_obj0", 0},
+    {"test$txn_obj1", 0},
     {"test$type_confusion1", 0},
     {"test$union0", 0},
     {"test$union1", 0},
     {"test$union2", 0},
+    {"test$union_weights", 0},
+    {"test$unique", 0},
+    {"test$versioned", 0},
     {"test$vma0", 0},
     {"unsupported$0", 0},
     {"unsupported$1", 0},
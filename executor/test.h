@@ -194,6 +194,184 @@ static int test_csum_inet_acc()
 	return 0;
 }
 
+static int test_csum_crc32()
+{
+	struct csum_crc32_test {
+		const char* data;
+		size_t length;
+		uint32 csum;
+	};
+	struct csum_crc32_test tests[] = {
+	    {
+		// 0
+		"",
+		0,
+		0x00000000,
+	    },
+	    {
+		// 1
+		"123456789",
+		9,
+		0xcbf43926,
+	    },
+	    {
+		// 2
+		"The quick brown fox jumps over the lazy dog",
+		43,
+		0x414fa339,
+	    },
+	    {
+		// 3
+		"\x00\x00\x00\x00",
+		4,
+		0x2144df1c,
+	    },
+	    {
+		// 4
+		"\xff\xff\xff\xff",
+		4,
+		0xffffffff,
+	    }};
+
+	for (unsigned i = 0; i < ARRAY_SIZE(tests); i++) {
+		struct csum_crc32 csum;
+		csum_crc32_init(&csum);
+		csum_crc32_update(&csum, (const uint8*)tests[i].data, tests[i].length);
+		if (csum_crc32_digest(&csum) != tests[i].csum) {
+			fprintf(stderr, "bad crc32 in test #%u, want: %x, got: %x\n", i, tests[i].csum, csum_crc32_digest(&csum));
+			return 1;
+		}
+	}
+
+	return 0;
+}
+
+static int test_csum_crc32_acc()
+{
+	uint8 buffer[128];
+
+	int test;
+	for (test = 0; test < 256; test++) {
+		int size = rand_int_range(1, 128);
+		int step = rand_int_range(1, 16);
+
+		int i;
+		for (i = 0; i < size; i++)
+			buffer[i] = rand_int_range(0, 255);
+
+		struct csum_crc32 csum_acc;
+		csum_crc32_init(&csum_acc);
+
+		for (i = 0; i < size / step; i++)
+			csum_crc32_update(&csum_acc, &buffer[i * step], step);
+		if (size % step != 0)
+			csum_crc32_update(&csum_acc, &buffer[size - size % step], size % step);
+
+		struct csum_crc32 csum;
+		csum_crc32_init(&csum);
+		csum_crc32_update(&csum, &buffer[0], size);
+
+		if (csum_crc32_digest(&csum_acc) != csum_crc32_digest(&csum))
+			return 1;
+	}
+	return 0;
+}
+
+static int test_csum_xor()
+{
+	const uint8 data[] = {0x01, 0x02, 0x03, 0x04, 0x10, 0x20, 0x30, 0x40, 0xff};
+	struct csum_xor_test {
+		uint64 size;
+		uint64 csum;
+	};
+	uint16 csum2;
+	memcpy(&csum2, "\xdd\x66", 2);
+	uint32 csum4;
+	memcpy(&csum4, "\xee\x22\x33\x44", 4);
+	uint64 csum8;
+	memcpy(&csum8, "\xfe\x02\x03\x04\x10\x20\x30\x40", 8);
+	struct csum_xor_test tests[] = {
+	    {1, 0x01 ^ 0x02 ^ 0x03 ^ 0x04 ^ 0x10 ^ 0x20 ^ 0x30 ^ 0x40 ^ 0xff},
+	    {2, csum2},
+	    {4, csum4},
+	    {8, csum8},
+	};
+
+	for (unsigned i = 0; i < ARRAY_SIZE(tests); i++) {
+		struct csum_xor csum;
+		csum_xor_init(&csum, tests[i].size);
+		// Split the data to check that word position is preserved across updates.
+		csum_xor_update(&csum, &data[0], 3);
+		csum_xor_update(&csum, &data[3], sizeof(data) - 3);
+		if (csum_xor_digest(&csum) != tests[i].csum) {
+			fprintf(stderr, "bad xor checksum in test #%u, want: %llx, got: %llx\n",
+				i, tests[i].csum, csum_xor_digest(&csum));
+			return 1;
+		}
+	}
+
+	return 0;
+}
+
+static int test_csum_fnv1a()
+{
+	struct csum_fnv1a_test {
+		const char* data;
+		size_t length;
+		uint64 size;
+		uint64 csum;
+	};
+	struct csum_fnv1a_test tests[] = {
+	    {"", 0, 4, 0x811c9dc5},
+	    {"a", 1, 4, 0xe40c292c},
+	    {"foobar", 6, 4, 0xbf9cf968},
+	    {"", 0, 8, 0xcbf29ce484222325ull},
+	    {"a", 1, 8, 0xaf63dc4c8601ec8cull},
+	    {"foobar", 6, 8, 0x85944171f73967e8ull},
+	};
+
+	for (unsigned i = 0; i < ARRAY_SIZE(tests); i++) {
+		struct csum_fnv1a csum;
+		csum_fnv1a_init(&csum, tests[i].size);
+		csum_fnv1a_update(&csum, (const uint8*)tests[i].data, tests[i].length);
+		if (csum_fnv1a_digest(&csum) != tests[i].csum) {
+			fprintf(stderr, "bad fnv1a hash in test #%u, want: %llx, got: %llx\n",
+				i, tests[i].csum, csum_fnv1a_digest(&csum));
+			return 1;
+		}
+	}
+
+	return 0;
+}
+
+static int test_csum_add()
+{
+	const char* data = "The quick brown fox jumps over the lazy dog";
+	struct csum_add_test {
+		uint64 size;
+		uint64 csum;
+	};
+	struct csum_add_test tests[] = {
+	    {1, 0xd9},
+	    {2, 0xfd9},
+	    {4, 0xfd9},
+	    {8, 0xfd9},
+	};
+
+	for (unsigned i = 0; i < ARRAY_SIZE(tests); i++) {
+		struct csum_add csum;
+		csum_add_init(&csum, tests[i].size);
+		csum_add_update(&csum, (const uint8*)data, strlen(data));
+		if (csum_add_digest(&csum) != tests[i].csum) {
+			fprintf(stderr, "bad add hash in test #%u, want: %llx, got: %llx\n",
+				i, tests[i].csum, csum_add_digest(&csum));
+			return 1;
+		}
+	}
+
+	return 0;
+}
+
 static struct {
 	const char* name;
 	int (*f)();
@@ -201,6 +379,11 @@ static struct {
     {"test_copyin", test_copyin},
     {"test_csum_inet", test_csum_inet},
     {"test_csum_inet_acc", test_csum_inet_acc},
+    {"test_csum_crc32", test_csum_crc32},
+    {"test_csum_crc32_acc", test_csum_crc32_acc},
+    {"test_csum_xor", test_csum_xor},
+    {"test_csum_fnv1a", test_csum_fnv1a},
+    {"test_csum_add", test_csum_add},
 #if GOOS_linux && GOARCH_amd64
     {"test_kvm", test_kvm},
 #endif
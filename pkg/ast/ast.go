@@ -79,6 +79,7 @@ type Resource struct {
 	Name   *Ident
 	Base   *Type
 	Values []*Int
+	Attrs  []*Type
 }
 
 func (n *Resource) Info() (Pos, string, string) {
@@ -92,6 +93,7 @@ type Call struct {
 	NR       uint64
 	Args     []*Field
 	Ret      *Type
+	Attrs    []*Type
 }
 
 func (n *Call) Info() (Pos, string, string) {
@@ -149,6 +151,79 @@ func (n *TypeDef) Info() (Pos, string, string) {
 	return n.Pos, "type", n.Name.Name
 }
 
+// Macro is a named set of top-level declarations parametrized by Args.
+// Body is instantiated with concrete arguments by Expand nodes (see ExpandMacros).
+type Macro struct {
+	Pos  Pos
+	Name *Ident
+	Args []*Ident
+	Body []Node
+}
+
+func (n *Macro) Info() (Pos, string, string) {
+	return n.Pos, "macro", n.Name.Name
+}
+
+// RegBlock is a block of named hardware registers that is lowered to a packed struct
+// (each register with named bits becomes a group of bitfields).
+type RegBlock struct {
+	Pos      Pos
+	Name     *Ident
+	Regs     []*Register
+	Attrs    []*Type
+	Comments []*Comment
+}
+
+func (n *RegBlock) Info() (Pos, string, string) {
+	return n.Pos, "regblock", n.Name.Name
+}
+
+// Register is a register of a RegBlock, optionally split into named bits.
+type Register struct {
+	Pos      Pos
+	Name     *Ident
+	Type     *Type
+	Bits     []*RegBits
+	Comments []*Comment
+}
+
+func (n *Register) Info() (Pos, string, string) {
+	return n.Pos, "register", n.Name.Name
+}
+
+// RegBits is a named group of Width bits of a Register.
+type RegBits struct {
+	Pos   Pos
+	Name  *Ident
+	Width *Int
+}
+
+func (n *RegBits) Info() (Pos, string, string) {
+	return n.Pos, "register bits", n.Name.Name
+}
+
+type Expand struct {
+	Pos  Pos
+	Name *Ident
+	Args []*Type
+}
+
+func (n *Expand) Info() (Pos, string, string) {
+	return n.Pos, "macro expansion", n.Name.Name
+}
+
+// StaticAssert is a compile-time check of the size of a struct or union:
+// static_assert(sizeof(Name) == Size).
+type StaticAssert struct {
+	Pos  Pos
+	Name *Ident
+	Size *Int
+}
+
+func (n *StaticAssert) Info() (Pos, string, string) {
+	return n.Pos, "static_assert", n.Name.Name
+}
+
 // Not top-level AST nodes:
 
 type Ident struct {
@@ -216,6 +291,7 @@ type Field struct {
 	Pos      Pos
 	Name     *Ident
 	Type     *Type
+	Attrs    []*Type
 	NewBlock bool // separated from previous fields by a new line
 	Comments []*Comment
 }
@@ -52,6 +52,7 @@ func (n *Resource) Clone() Node {
 		Name:   n.Name.Clone().(*Ident),
 		Base:   n.Base.Clone().(*Type),
 		Values: cloneInts(n.Values),
+		Attrs:  cloneTypes(n.Attrs),
 	}
 }
 
@@ -77,6 +78,75 @@ func (n *TypeDef) Clone() Node {
 	}
 }
 
+func (n *Macro) Clone() Node {
+	var args []*Ident
+	for _, v := range n.Args {
+		args = append(args, v.Clone().(*Ident))
+	}
+	var body []Node
+	for _, n1 := range n.Body {
+		body = append(body, n1.Clone())
+	}
+	return &Macro{
+		Pos:  n.Pos,
+		Name: n.Name.Clone().(*Ident),
+		Args: args,
+		Body: body,
+	}
+}
+
+func (n *RegBlock) Clone() Node {
+	var regs []*Register
+	for _, r := range n.Regs {
+		regs = append(regs, r.Clone().(*Register))
+	}
+	return &RegBlock{
+		Pos:      n.Pos,
+		Name:     n.Name.Clone().(*Ident),
+		Regs:     regs,
+		Attrs:    cloneTypes(n.Attrs),
+		Comments: cloneComments(n.Comments),
+	}
+}
+
+func (n *Register) Clone() Node {
+	var bits []*RegBits
+	for _, b := range n.Bits {
+		bits = append(bits, b.Clone().(*RegBits))
+	}
+	return &Register{
+		Pos:      n.Pos,
+		Name:     n.Name.Clone().(*Ident),
+		Type:     n.Type.Clone().(*Type),
+		Bits:     bits,
+		Comments: cloneComments(n.Comments),
+	}
+}
+
+func (n *RegBits) Clone() Node {
+	return &RegBits{
+		Pos:   n.Pos,
+		Name:  n.Name.Clone().(*Ident),
+		Width: n.Width.Clone().(*Int),
+	}
+}
+
+func (n *Expand) Clone() Node {
+	return &Expand{
+		Pos:  n.Pos,
+		Name: n.Name.Clone().(*Ident),
+		Args: cloneTypes(n.Args),
+	}
+}
+
+func (n *StaticAssert) Clone() Node {
+	return &StaticAssert{
+		Pos:  n.Pos,
+		Name: n.Name.Clone().(*Ident),
+		Size: n.Size.Clone().(*Int),
+	}
+}
+
 func (n *Call) Clone() Node {
 	var ret *Type
 	if n.Ret != nil {
@@ -89,6 +159,7 @@ func (n *Call) Clone() Node {
 		NR:       n.NR,
 		Args:     cloneFields(n.Args),
 		Ret:      ret,
+		Attrs:    cloneTypes(n.Attrs),
 	}
 }
 
@@ -169,6 +240,7 @@ func (n *Field) Clone() Node {
 		Pos:      n.Pos,
 		Name:     n.Name.Clone().(*Ident),
 		Type:     n.Type.Clone().(*Type),
+		Attrs:    cloneTypes(n.Attrs),
 		NewBlock: n.NewBlock,
 		Comments: cloneComments(n.Comments),
 	}
@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"strings"
 )
 
 func Format(desc *Description) []byte {
@@ -79,6 +80,9 @@ func (res *Resource) serialize(w io.Writer) {
 	for i, v := range res.Values {
 		fmt.Fprintf(w, "%v%v", comma(i, ": "), fmtInt(v))
 	}
+	if attrs := fmtTypeList(res.Attrs); attrs != "" {
+		fmt.Fprintf(w, " %v", attrs)
+	}
 	fmt.Fprintf(w, "\n")
 }
 
@@ -92,6 +96,66 @@ func (typedef *TypeDef) serialize(w io.Writer) {
 	}
 }
 
+func (m *Macro) serialize(w io.Writer) {
+	fmt.Fprintf(w, "macro %v%v {\n", m.Name.Name, fmtIdentList(m.Args))
+	body := new(bytes.Buffer)
+	for _, n := range m.Body {
+		n.(serializer).serialize(body)
+	}
+	for _, line := range bytes.SplitAfter(body.Bytes(), []byte{'\n'}) {
+		if len(line) > 1 {
+			fmt.Fprintf(w, "\t")
+		}
+		w.Write(line)
+	}
+	fmt.Fprintf(w, "}\n")
+}
+
+func (blk *RegBlock) serialize(w io.Writer) {
+	fmt.Fprintf(w, "regblock %v {\n", blk.Name.Name)
+	var names []string
+	for _, r := range blk.Regs {
+		names = append(names, r.Name.Name)
+	}
+	maxTabs := alignTabs(names)
+	for _, r := range blk.Regs {
+		for _, com := range r.Comments {
+			fmt.Fprintf(w, "#%v\n", com.Text)
+		}
+		fmt.Fprintf(w, "\t%v\t%v%v", r.Name.Name, padTabs(r.Name.Name, maxTabs), fmtType(r.Type))
+		if len(r.Bits) == 0 {
+			fmt.Fprintf(w, "\n")
+			continue
+		}
+		fmt.Fprintf(w, " {\n")
+		var bitNames []string
+		for _, b := range r.Bits {
+			bitNames = append(bitNames, b.Name.Name)
+		}
+		bitTabs := alignTabs(bitNames)
+		for _, b := range r.Bits {
+			fmt.Fprintf(w, "\t\t%v\t%v%v\n", b.Name.Name, padTabs(b.Name.Name, bitTabs), fmtInt(b.Width))
+		}
+		fmt.Fprintf(w, "\t}\n")
+	}
+	for _, com := range blk.Comments {
+		fmt.Fprintf(w, "#%v\n", com.Text)
+	}
+	fmt.Fprintf(w, "}")
+	if attrs := fmtTypeList(blk.Attrs); attrs != "" {
+		fmt.Fprintf(w, " %v", attrs)
+	}
+	fmt.Fprintf(w, "\n")
+}
+
+func (e *Expand) serialize(w io.Writer) {
+	fmt.Fprintf(w, "expand %v%v\n", e.Name.Name, fmtTypeList(e.Args))
+}
+
+func (a *StaticAssert) serialize(w io.Writer) {
+	fmt.Fprintf(w, "static_assert(sizeof(%v) == %v)\n", a.Name.Name, fmtInt(a.Size))
+}
+
 func (c *Call) serialize(w io.Writer) {
 	fmt.Fprintf(w, "%v(", c.Name.Name)
 	for i, a := range c.Args {
@@ -101,7 +165,7 @@ func (c *Call) serialize(w io.Writer) {
 	if c.Ret != nil {
 		fmt.Fprintf(w, " %v", fmtType(c.Ret))
 	}
-	fmt.Fprintf(w, "\n")
+	fmt.Fprintf(w, "%v\n", fmtFieldAttrs(c.Attrs))
 }
 
 func (str *Struct) serialize(w io.Writer) {
@@ -110,15 +174,11 @@ func (str *Struct) serialize(w io.Writer) {
 		opening, closing = '[', ']'
 	}
 	fmt.Fprintf(w, "%v %c\n", str.Name.Name, opening)
-	// Align all field types to the same column.
-	const tabWidth = 8
-	maxTabs := 0
+	var names []string
 	for _, f := range str.Fields {
-		tabs := (len(f.Name.Name) + tabWidth) / tabWidth
-		if maxTabs < tabs {
-			maxTabs = tabs
-		}
+		names = append(names, f.Name.Name)
 	}
+	maxTabs := alignTabs(names)
 	for _, f := range str.Fields {
 		if f.NewBlock {
 			fmt.Fprintf(w, "\n")
@@ -126,11 +186,8 @@ func (str *Struct) serialize(w io.Writer) {
 		for _, com := range f.Comments {
 			fmt.Fprintf(w, "#%v\n", com.Text)
 		}
-		fmt.Fprintf(w, "\t%v\t", f.Name.Name)
-		for tabs := len(f.Name.Name)/tabWidth + 1; tabs < maxTabs; tabs++ {
-			fmt.Fprintf(w, "\t")
-		}
-		fmt.Fprintf(w, "%v\n", fmtType(f.Type))
+		fmt.Fprintf(w, "\t%v\t%v", f.Name.Name, padTabs(f.Name.Name, maxTabs))
+		fmt.Fprintf(w, "%v%v\n", fmtType(f.Type), fmtFieldAttrs(f.Attrs))
 	}
 	for _, com := range str.Comments {
 		fmt.Fprintf(w, "#%v\n", com.Text)
@@ -142,6 +199,25 @@ func (str *Struct) serialize(w io.Writer) {
 	fmt.Fprintf(w, "\n")
 }
 
+const tabWidth = 8
+
+// alignTabs returns number of tabs needed to align types that follow names to the same column.
+func alignTabs(names []string) int {
+	maxTabs := 0
+	for _, name := range names {
+		tabs := (len(name) + tabWidth) / tabWidth
+		if maxTabs < tabs {
+			maxTabs = tabs
+		}
+	}
+	return maxTabs
+}
+
+// padTabs returns tabs that need to be added after name and a tab to get to the column maxTabs.
+func padTabs(name string, maxTabs int) string {
+	return strings.Repeat("\t", maxTabs-len(name)/tabWidth-1)
+}
+
 func (flags *IntFlags) serialize(w io.Writer) {
 	fmt.Fprintf(w, "%v = ", flags.Name.Name)
 	for i, v := range flags.Values {
@@ -159,7 +235,20 @@ func (flags *StrFlags) serialize(w io.Writer) {
 }
 
 func fmtField(f *Field) string {
-	return fmt.Sprintf("%v %v", f.Name.Name, fmtType(f.Type))
+	return fmt.Sprintf("%v %v%v", f.Name.Name, fmtType(f.Type), fmtFieldAttrs(f.Attrs))
+}
+
+func fmtFieldAttrs(attrs []*Type) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	w := new(bytes.Buffer)
+	fmt.Fprintf(w, " (")
+	for i, t := range attrs {
+		fmt.Fprintf(w, "%v%v", comma(i, ""), fmtType(t))
+	}
+	fmt.Fprintf(w, ")")
+	return w.String()
 }
 
 func (n *Type) serialize(w io.Writer) {
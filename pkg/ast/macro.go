@@ -0,0 +1,333 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExpandMacros returns a copy of desc where macro definitions are removed
+// and macro expansions are replaced with declarations from the macro bodies.
+// Macro parameters are substituted in types, integers and identifiers
+// (as a whole or as a part separated by '$', e.g. ioctl$NAME).
+// Names of resources, structs, unions, flags and types declared in the macro body
+// that don't depend on the parameters are private to the expansion,
+// they are renamed to name$macro$args, so they can't clash with other declarations.
+// All expanded nodes get position of the top-level expansion, so that any errors
+// in the expanded declarations point to the expansion site.
+// Returns nil if there were any errors.
+func ExpandMacros(desc *Description, eh ErrorHandler) *Description {
+	if eh == nil {
+		eh = LoggingHandler
+	}
+	ctx := &expander{
+		eh:     eh,
+		macros: make(map[string]*Macro),
+	}
+	for _, n := range desc.Nodes {
+		if m, ok := n.(*Macro); ok {
+			ctx.addMacro(m)
+		}
+	}
+	res := &Description{}
+	for _, n := range desc.Nodes {
+		switch n1 := n.(type) {
+		case *Macro:
+		case *Expand:
+			res.Nodes = append(res.Nodes, ctx.expand(n1, n1.Pos, nil)...)
+		default:
+			res.Nodes = append(res.Nodes, n.Clone())
+		}
+	}
+	if ctx.errors != 0 {
+		return nil
+	}
+	return res
+}
+
+type expander struct {
+	eh     ErrorHandler
+	errors int
+	macros map[string]*Macro
+}
+
+func (ctx *expander) error(pos Pos, msg string, args ...interface{}) {
+	ctx.errors++
+	ctx.eh(pos, fmt.Sprintf(msg, args...))
+}
+
+func (ctx *expander) addMacro(m *Macro) {
+	name := m.Name.Name
+	if prev := ctx.macros[name]; prev != nil {
+		ctx.error(m.Pos, "macro %v redeclared, previously declared at %v", name, prev.Pos)
+		return
+	}
+	params := make(map[string]bool)
+	for _, arg := range m.Args {
+		if params[arg.Name] {
+			ctx.error(arg.Pos, "duplicate macro %v parameter %v", name, arg.Name)
+		}
+		params[arg.Name] = true
+	}
+	for _, n := range m.Body {
+		switch n.(type) {
+		case *NewLine, *Comment, *Resource, *Call, *Struct, *RegBlock, *IntFlags, *StrFlags, *TypeDef, *Expand:
+		default:
+			pos, typ, _ := n.Info()
+			ctx.error(pos, "%v is not allowed in macro %v", typ, name)
+		}
+	}
+	ctx.macros[name] = m
+}
+
+// expand returns declarations produced by expansion e.
+// pos is position of the top-level expansion, stack is the chain of the enclosing macros.
+func (ctx *expander) expand(e *Expand, pos Pos, stack []string) []Node {
+	name := e.Name.Name
+	m := ctx.macros[name]
+	if m == nil {
+		ctx.error(pos, "unknown macro %v", name)
+		return nil
+	}
+	if len(e.Args) != len(m.Args) {
+		ctx.error(pos, "macro %v needs %v arguments instead of %v", name, len(m.Args), len(e.Args))
+		return nil
+	}
+	for _, prev := range stack {
+		if prev == name {
+			ctx.error(pos, "macro expansion loop: %v", strings.Join(append(stack, name), " -> "))
+			return nil
+		}
+	}
+	stack = append(stack, name)
+	s := &substituter{
+		ctx:     ctx,
+		pos:     pos,
+		args:    make(map[string]*Type),
+		private: make(map[string]string),
+	}
+	for i, param := range m.Args {
+		s.args[param.Name] = e.Args[i]
+	}
+	for _, n := range m.Body {
+		if ident := declName(n); ident != nil && !s.hasParams(ident.Name) {
+			s.private[ident.Name] = privateName(ident.Name, name, e.Args)
+		}
+	}
+	var res []Node
+	for _, n := range m.Body {
+		switch n.(type) {
+		case *NewLine, *Comment:
+			continue
+		}
+		n = n.Clone()
+		s.walk(n)
+		if ident := declName(n); ident != nil && s.private[ident.Name] != "" {
+			ident.Name = s.private[ident.Name]
+		}
+		if c, ok := n.(*Call); ok {
+			c.CallName = callName(c.Name.Name)
+		}
+		setPos(n, pos)
+		if e1, ok := n.(*Expand); ok {
+			res = append(res, ctx.expand(e1, pos, stack)...)
+			continue
+		}
+		res = append(res, n)
+	}
+	return res
+}
+
+// declName returns name of the type-like declaration n (calls are not included).
+func declName(n Node) *Ident {
+	switch n1 := n.(type) {
+	case *Resource:
+		return n1.Name
+	case *Struct:
+		return n1.Name
+	case *RegBlock:
+		return n1.Name
+	case *IntFlags:
+		return n1.Name
+	case *StrFlags:
+		return n1.Name
+	case *TypeDef:
+		return n1.Name
+	}
+	return nil
+}
+
+// privateName returns name for a macro-private declaration derived from the macro arguments.
+func privateName(name, macro string, args []*Type) string {
+	res := name + "$" + macro
+	for _, arg := range args {
+		res += "$" + strings.Map(func(r rune) rune {
+			if r == '_' || r == '$' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+				return r
+			}
+			return '_'
+		}, fmtType(arg))
+	}
+	return res
+}
+
+type substituter struct {
+	ctx     *expander
+	pos     Pos
+	args    map[string]*Type
+	private map[string]string
+}
+
+func (s *substituter) hasParams(name string) bool {
+	for _, part := range strings.Split(name, "$") {
+		if s.args[part] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *substituter) walk(n Node) {
+	switch n1 := n.(type) {
+	case *Type:
+		s.substType(n1)
+		return
+	case *Ident:
+		n1.Name = s.substName(n1.Name)
+	case *Int:
+		if arg := s.args[n1.Ident]; arg != nil {
+			switch {
+			case arg.Ident != "" && !arg.HasColon && len(arg.Args) == 0:
+				n1.Ident = arg.Ident
+			case arg.Ident == "" && !arg.HasString && !arg.HasColon && len(arg.Args) == 0:
+				n1.Ident = ""
+				n1.Value, n1.ValueFmt = arg.Value, arg.ValueFmt
+			default:
+				s.ctx.error(s.pos, "macro argument %v for %v must be an integer or identifier",
+					fmtType(arg), n1.Ident)
+			}
+		}
+	}
+	n.Walk(s.walk)
+}
+
+func (s *substituter) substType(t *Type) {
+	if arg := s.args[t.Ident]; arg != nil {
+		origArgs := t.Args
+		if len(origArgs) != 0 && len(arg.Args) != 0 {
+			s.ctx.error(s.pos, "both macro parameter %v and its usage have sub-arguments", t.Ident)
+			return
+		}
+		// Don't substitute anything in the argument itself, it comes from the expansion scope.
+		*t = *arg.Clone().(*Type)
+		if len(origArgs) != 0 {
+			t.Args = origArgs
+			for _, arg := range origArgs {
+				s.substType(arg)
+			}
+		}
+		return
+	}
+	if private := s.private[t.Ident]; private != "" {
+		t.Ident = private
+	} else {
+		t.Ident = s.substName(t.Ident)
+	}
+	if arg := s.args[t.Ident2]; arg != nil {
+		switch {
+		case arg.Ident != "" && !arg.HasColon && len(arg.Args) == 0:
+			t.Ident2 = arg.Ident
+		case arg.Ident == "" && !arg.HasString && !arg.HasColon && len(arg.Args) == 0:
+			t.Ident2 = ""
+			t.Value2, t.Value2Fmt = arg.Value, arg.ValueFmt
+		default:
+			s.ctx.error(s.pos, "macro argument %v for %v must be an integer or identifier",
+				fmtType(arg), t.Ident2)
+		}
+	}
+	for _, arg := range t.Args {
+		s.substType(arg)
+	}
+}
+
+// substName substitutes parameters in '$'-separated parts of identifier name.
+func (s *substituter) substName(name string) string {
+	if name == "" {
+		return name
+	}
+	parts := strings.Split(name, "$")
+	for i, part := range parts {
+		arg := s.args[part]
+		if arg == nil {
+			continue
+		}
+		if arg.Ident == "" || arg.HasColon || len(arg.Args) != 0 {
+			s.ctx.error(s.pos, "macro argument %v is used in name %v and must be an identifier",
+				fmtType(arg), name)
+			continue
+		}
+		parts[i] = arg.Ident
+	}
+	return strings.Join(parts, "$")
+}
+
+func setPos(n Node, pos Pos) {
+	n.Walk(Recursive(func(n1 Node) {
+		setPos1(n1, pos)
+	}))
+	setPos1(n, pos)
+}
+
+func setPos1(n Node, pos Pos) {
+	switch n1 := n.(type) {
+	case *NewLine:
+		n1.Pos = pos
+	case *Comment:
+		n1.Pos = pos
+	case *Include:
+		n1.Pos = pos
+	case *Incdir:
+		n1.Pos = pos
+	case *Define:
+		n1.Pos = pos
+	case *Resource:
+		n1.Pos = pos
+	case *Call:
+		n1.Pos = pos
+	case *StaticAssert:
+		n1.Pos = pos
+	case *Struct:
+		n1.Pos = pos
+	case *IntFlags:
+		n1.Pos = pos
+	case *StrFlags:
+		n1.Pos = pos
+	case *TypeDef:
+		n1.Pos = pos
+	case *RegBlock:
+		n1.Pos = pos
+	case *Register:
+		n1.Pos = pos
+	case *RegBits:
+		n1.Pos = pos
+	case *Macro:
+		n1.Pos = pos
+	case *Expand:
+		n1.Pos = pos
+	case *Ident:
+		n1.Pos = pos
+	case *String:
+		n1.Pos = pos
+	case *Int:
+		n1.Pos = pos
+	case *Type:
+		n1.Pos = pos
+		n1.Pos2 = pos
+	case *Field:
+		n1.Pos = pos
+	default:
+		panic(fmt.Sprintf("unknown node %#v", n))
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandMacros(t *testing.T) {
+	const input = `
+macro dev[NAME, DEV, FLAGS] {
+	resource fd$NAME[fd]
+
+	# Private declarations don't clash with the identically named declarations of other expansions.
+	params {
+		f0	flags[dev_flags, int32]
+		f1	int32[0:FLAGS]
+	}
+
+	dev_flags = FLAGS, 0x1
+
+	openat$NAME(fd const[AT_FDCWD], file ptr[in, string[DEV]]) fd$NAME
+	ioctl$NAME(fd fd$NAME, cmd const[FLAGS], arg ptr[in, params])
+	expand close[fd$NAME]
+}
+
+macro close[FD] {
+	close$FD(fd FD)
+}
+
+params {
+	f0	int8
+}
+
+expand dev[foo, "/dev/foo", 2]
+expand dev[bar, "/dev/bar", FOO]
+`
+	const want = `
+params {
+	f0	int8
+}
+
+resource fd$foo[fd]
+params$dev$foo$__dev_foo_$2 {
+	f0	flags[dev_flags$dev$foo$__dev_foo_$2, int32]
+	f1	int32[0:2]
+}
+dev_flags$dev$foo$__dev_foo_$2 = 2, 0x1
+openat$foo(fd const[AT_FDCWD], file ptr[in, string["/dev/foo"]]) fd$foo
+ioctl$foo(fd fd$foo, cmd const[2], arg ptr[in, params$dev$foo$__dev_foo_$2])
+close$fd$foo(fd fd$foo)
+resource fd$bar[fd]
+params$dev$bar$__dev_bar_$FOO {
+	f0	flags[dev_flags$dev$bar$__dev_bar_$FOO, int32]
+	f1	int32[0:FOO]
+}
+dev_flags$dev$bar$__dev_bar_$FOO = FOO, 0x1
+openat$bar(fd const[AT_FDCWD], file ptr[in, string["/dev/bar"]]) fd$bar
+ioctl$bar(fd fd$bar, cmd const[FOO], arg ptr[in, params$dev$bar$__dev_bar_$FOO])
+close$fd$bar(fd fd$bar)
+`
+	eh := func(pos Pos, msg string) {
+		t.Fatalf("%v: %v", pos, msg)
+	}
+	desc := Parse([]byte(input), "input", eh)
+	if desc == nil {
+		t.Fatalf("parsing failed")
+	}
+	if got := string(Format(desc)); got != input {
+		t.Fatalf("formatting changed macros:\n%v", got)
+	}
+	expanded := ExpandMacros(desc, eh)
+	if got := strings.TrimSpace(string(Format(expanded))); got != strings.TrimSpace(want) {
+		t.Fatalf("bad expansion:\n%v\nwant:\n%v", got, want)
+	}
+	for _, n := range expanded.Nodes {
+		if pos, _, name := n.Info(); strings.HasSuffix(name, "bar") && pos.Line != 27 {
+			t.Fatalf("expanded %v has position %v, want the expansion site", name, pos)
+		}
+	}
+}
+
+func TestExpandMacrosErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{
+			"expand foo[a]",
+			"input:1:8: unknown macro foo",
+		},
+		{
+			"macro foo[A] {\n\tfoo$A()\n}\nexpand foo[a, b]",
+			"input:4:8: macro foo needs 1 arguments instead of 2",
+		},
+		{
+			"macro foo[A] {\n\tfoo$A()\n}\nexpand foo[\"a\"]",
+			"input:4:8: macro argument \"a\" is used in name foo$A and must be an identifier",
+		},
+		{
+			"macro foo[A] {\n\tfoo(a int32[0:A])\n}\nexpand foo[int32[1]]",
+			"input:4:8: macro argument int32[1] for A must be an integer or identifier",
+		},
+		{
+			"macro foo[A] {\n\texpand bar[A]\n}\nmacro bar[B] {\n\texpand foo[B]\n}\nexpand foo[a]",
+			"input:7:8: macro expansion loop: foo -> bar -> foo",
+		},
+		{
+			"macro foo[A, A] {\n\tfoo$A()\n}",
+			"input:1:14: duplicate macro foo parameter A",
+		},
+		{
+			"macro foo {\n\tinclude <foo.h>\n}",
+			"input:2:2: include is not allowed in macro foo",
+		},
+		{
+			"macro foo {\n\tfoo()\n}\nmacro foo {\n\tbar()\n}",
+			"input:4:7: macro foo redeclared, previously declared at input:1:7",
+		},
+	}
+	for i, test := range tests {
+		desc := Parse([]byte(test.input), "input", func(pos Pos, msg string) {
+			t.Fatalf("#%v: %v: %v", i, pos, msg)
+		})
+		var errors []string
+		res := ExpandMacros(desc, func(pos Pos, msg string) {
+			errors = append(errors, pos.String()+": "+msg)
+		})
+		if res != nil {
+			t.Errorf("#%v: expansion succeeded", i)
+		}
+		if len(errors) != 1 || errors[0] != test.err {
+			t.Errorf("#%v: got errors %q, want %q", i, errors, test.err)
+		}
+	}
+}
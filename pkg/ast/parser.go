@@ -23,16 +23,17 @@ func Parse(data []byte, filename string, errorHandler ErrorHandler) *Description
 		if decl == nil {
 			continue
 		}
-		// Add new lines around structs, remove duplicate new lines.
+		// Add new lines around structs and macros, remove duplicate new lines.
 		if _, ok := decl.(*NewLine); ok && prevNewLine {
 			continue
 		}
-		if str, ok := decl.(*Struct); ok && !prevNewLine && !prevComment {
-			top = append(top, &NewLine{Pos: str.Pos})
+		pos, _, _ := decl.Info()
+		if isBlock(decl) && !prevNewLine && !prevComment {
+			top = append(top, &NewLine{Pos: pos})
 		}
 		top = append(top, decl)
-		if str, ok := decl.(*Struct); ok {
-			decl = &NewLine{Pos: str.Pos}
+		if isBlock(decl) {
+			decl = &NewLine{Pos: pos}
 			top = append(top, decl)
 		}
 		_, prevNewLine = decl.(*NewLine)
@@ -47,6 +48,14 @@ func Parse(data []byte, filename string, errorHandler ErrorHandler) *Description
 	return &Description{top}
 }
 
+func isBlock(decl Node) bool {
+	switch decl.(type) {
+	case *Struct, *Macro, *RegBlock:
+		return true
+	}
+	return false
+}
+
 func ParseGlob(glob string, errorHandler ErrorHandler) *Description {
 	if errorHandler == nil {
 		errorHandler = LoggingHandler
@@ -131,6 +140,18 @@ func (p *parser) parseTop() Node {
 		if name.Name == "type" {
 			return p.parseTypeDef()
 		}
+		if name.Name == "macro" && p.tok == tokIdent {
+			return p.parseMacro()
+		}
+		if name.Name == "expand" && p.tok == tokIdent {
+			return p.parseExpand()
+		}
+		if name.Name == "regblock" && p.tok == tokIdent {
+			return p.parseRegBlock()
+		}
+		if name.Name == "static_assert" && p.tok == tokLParen {
+			return p.parseStaticAssert(name)
+		}
 		switch p.tok {
 		case tokLParen:
 			return p.parseCall(name)
@@ -240,11 +261,13 @@ func (p *parser) parseResource() *Resource {
 			values = append(values, p.parseInt())
 		}
 	}
+	attrs := p.parseTypeList()
 	return &Resource{
 		Pos:    pos0,
 		Name:   name,
 		Base:   base,
 		Values: values,
+		Attrs:  attrs,
 	}
 }
 
@@ -282,6 +305,112 @@ func (p *parser) parseTypeDef() *TypeDef {
 	}
 }
 
+func (p *parser) parseMacro() *Macro {
+	pos0 := p.pos
+	name := p.parseIdent()
+	var args []*Ident
+	if p.tryConsume(tokLBrack) {
+		args = append(args, p.parseIdent())
+		for p.tryConsume(tokComma) {
+			args = append(args, p.parseIdent())
+		}
+		p.consume(tokRBrack)
+	}
+	p.consume(tokLBrace)
+	p.consume(tokNewLine)
+	var body []Node
+	for !p.tryConsume(tokRBrace) {
+		if p.tok == tokEOF {
+			p.expect(tokRBrace)
+		}
+		body = append(body, p.parseTop())
+		p.consume(tokNewLine)
+	}
+	return &Macro{
+		Pos:  pos0,
+		Name: name,
+		Args: args,
+		Body: body,
+	}
+}
+
+func (p *parser) parseExpand() *Expand {
+	pos0 := p.pos
+	name := p.parseIdent()
+	return &Expand{
+		Pos:  pos0,
+		Name: name,
+		Args: p.parseTypeList(),
+	}
+}
+
+func (p *parser) parseRegBlock() *RegBlock {
+	pos0 := p.pos
+	blk := &RegBlock{
+		Pos:  pos0,
+		Name: p.parseIdent(),
+	}
+	p.consume(tokLBrace)
+	p.consume(tokNewLine)
+	for {
+		for p.tryConsume(tokNewLine) {
+		}
+		comments := p.parseCommentBlock()
+		if p.tryConsume(tokRBrace) {
+			blk.Comments = comments
+			break
+		}
+		name := p.parseIdent()
+		reg := &Register{
+			Pos:      name.Pos,
+			Name:     name,
+			Type:     p.parseType(),
+			Comments: comments,
+		}
+		if p.tryConsume(tokLBrace) {
+			p.consume(tokNewLine)
+			for !p.tryConsume(tokRBrace) {
+				name := p.parseIdent()
+				reg.Bits = append(reg.Bits, &RegBits{
+					Pos:   name.Pos,
+					Name:  name,
+					Width: p.parseInt(),
+				})
+				p.consume(tokNewLine)
+			}
+		}
+		blk.Regs = append(blk.Regs, reg)
+		p.consume(tokNewLine)
+	}
+	if p.tryConsume(tokLBrack) {
+		blk.Attrs = append(blk.Attrs, p.parseType())
+		for p.tryConsume(tokComma) {
+			blk.Attrs = append(blk.Attrs, p.parseType())
+		}
+		p.consume(tokRBrack)
+	}
+	return blk
+}
+
+func (p *parser) parseStaticAssert(name *Ident) *StaticAssert {
+	p.consume(tokLParen)
+	if sizeof := p.parseIdent(); sizeof.Name != "sizeof" {
+		p.s.Error(sizeof.Pos, "unexpected %v, expecting sizeof", sizeof.Name)
+		panic(errSkipLine)
+	}
+	p.consume(tokLParen)
+	a := &StaticAssert{
+		Pos:  name.Pos,
+		Name: p.parseIdent(),
+	}
+	p.consume(tokRParen)
+	p.consume(tokEq)
+	p.consume(tokEq)
+	a.Size = p.parseInt()
+	p.consume(tokRParen)
+	return a
+}
+
 func (p *parser) parseCall(name *Ident) *Call {
 	c := &Call{
 		Pos:      name.Pos,
@@ -295,9 +424,16 @@ func (p *parser) parseCall(name *Ident) *Call {
 		p.tryConsume(tokComma)
 	}
 	p.consume(tokRParen)
-	if p.tok != tokNewLine {
+	if p.tok != tokNewLine && p.tok != tokLParen {
 		c.Ret = p.parseType()
 	}
+	if p.tryConsume(tokLParen) {
+		c.Attrs = append(c.Attrs, p.parseType())
+		for p.tryConsume(tokComma) {
+			c.Attrs = append(c.Attrs, p.parseType())
+		}
+		p.consume(tokRParen)
+	}
 	return c
 }
 
@@ -398,11 +534,19 @@ func (p *parser) parseCommentBlock() []*Comment {
 
 func (p *parser) parseField() *Field {
 	name := p.parseIdent()
-	return &Field{
+	fld := &Field{
 		Pos:  name.Pos,
 		Name: name,
 		Type: p.parseType(),
 	}
+	if p.tryConsume(tokLParen) {
+		fld.Attrs = append(fld.Attrs, p.parseType())
+		for p.tryConsume(tokComma) {
+			fld.Attrs = append(fld.Attrs, p.parseType())
+		}
+		p.consume(tokRParen)
+	}
+	return fld
 }
 
 func (p *parser) parseType() *Type {
@@ -424,6 +568,11 @@ func (p *parser) parseType() *Type {
 		p.expect(tokInt, tokIdent, tokString)
 	}
 	p.next()
+	for arg.Ident != "" && p.tryConsume(tokDot) {
+		p.expect(tokIdent)
+		arg.Ident += "." + p.lit
+		p.next()
+	}
 	if allowColon && p.tryConsume(tokColon) {
 		arg.HasColon = true
 		arg.Pos2 = p.pos
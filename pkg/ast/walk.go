@@ -45,6 +45,9 @@ func (n *Resource) Walk(cb func(Node)) {
 	for _, v := range n.Values {
 		cb(v)
 	}
+	for _, a := range n.Attrs {
+		cb(a)
+	}
 }
 
 func (n *TypeDef) Walk(cb func(Node)) {
@@ -60,6 +63,57 @@ func (n *TypeDef) Walk(cb func(Node)) {
 	}
 }
 
+func (n *Macro) Walk(cb func(Node)) {
+	cb(n.Name)
+	for _, a := range n.Args {
+		cb(a)
+	}
+	for _, n1 := range n.Body {
+		cb(n1)
+	}
+}
+
+func (n *RegBlock) Walk(cb func(Node)) {
+	cb(n.Name)
+	for _, r := range n.Regs {
+		cb(r)
+	}
+	for _, a := range n.Attrs {
+		cb(a)
+	}
+	for _, c := range n.Comments {
+		cb(c)
+	}
+}
+
+func (n *Register) Walk(cb func(Node)) {
+	cb(n.Name)
+	cb(n.Type)
+	for _, b := range n.Bits {
+		cb(b)
+	}
+	for _, c := range n.Comments {
+		cb(c)
+	}
+}
+
+func (n *RegBits) Walk(cb func(Node)) {
+	cb(n.Name)
+	cb(n.Width)
+}
+
+func (n *Expand) Walk(cb func(Node)) {
+	cb(n.Name)
+	for _, a := range n.Args {
+		cb(a)
+	}
+}
+
+func (n *StaticAssert) Walk(cb func(Node)) {
+	cb(n.Name)
+	cb(n.Size)
+}
+
 func (n *Call) Walk(cb func(Node)) {
 	cb(n.Name)
 	for _, f := range n.Args {
@@ -68,6 +122,9 @@ func (n *Call) Walk(cb func(Node)) {
 	if n.Ret != nil {
 		cb(n.Ret)
 	}
+	for _, a := range n.Attrs {
+		cb(a)
+	}
 }
 
 func (n *Struct) Walk(cb func(Node)) {
@@ -106,6 +163,9 @@ func (n *Type) Walk(cb func(Node)) {
 func (n *Field) Walk(cb func(Node)) {
 	cb(n.Name)
 	cb(n.Type)
+	for _, a := range n.Attrs {
+		cb(a)
+	}
 	for _, c := range n.Comments {
 		cb(c)
 	}
@@ -0,0 +1,92 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/syzkaller/prog"
+)
+
+// ChangedCalls returns sorted names of syscalls in cur that are not present in prev
+// or are described differently, including changes in any of the structs and unions
+// reachable from the syscall arguments. The result can be used to focus generation
+// on recently changed descriptions (see prog.ChoiceTable.SetCallWeights).
+// Both prev and cur are expected to be compiled for the same target.
+func ChangedCalls(prev, cur *Prog) []string {
+	prevDigests := callDigests(prev)
+	var res []string
+	for name, digest := range callDigests(cur) {
+		if prevDigests[name] != digest {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// callDigests returns textual descriptions of all syscalls in prg
+// together with definitions of all structs and unions they refer to.
+func callDigests(prg *Prog) map[string]string {
+	descs := make(map[prog.StructKey]*prog.StructDesc)
+	for _, s := range prg.StructDescs {
+		descs[s.Key] = s.Desc
+	}
+	res := make(map[string]string)
+	for _, c := range prg.Syscalls {
+		structs := make(map[string]string)
+		var rec func(t prog.Type)
+		rec = func(t prog.Type) {
+			switch a := t.(type) {
+			case *prog.PtrType:
+				rec(a.Type)
+			case *prog.ArrayType:
+				rec(a.Type)
+			case *prog.StructType:
+				recStruct(structs, descs, a.Key, rec)
+			case *prog.UnionType:
+				recStruct(structs, descs, a.Key, rec)
+			}
+		}
+		for _, a := range c.Args {
+			rec(a)
+		}
+		if c.Ret != nil {
+			rec(c.Ret)
+		}
+		digest := []string{fmt.Sprintf("%v %v %v", c.Signature(), c.CallName, c.NR)}
+		for _, s := range structs {
+			digest = append(digest, s)
+		}
+		sort.Strings(digest[1:])
+		res[c.Name] = strings.Join(digest, "\n")
+	}
+	return res
+}
+
+func recStruct(structs map[string]string, descs map[prog.StructKey]*prog.StructDesc,
+	key prog.StructKey, rec func(t prog.Type)) {
+	id := fmt.Sprintf("%v/%v", key.Name, key.Dir)
+	if _, ok := structs[id]; ok {
+		return
+	}
+	structs[id] = ""
+	desc := descs[key]
+	if desc == nil {
+		return
+	}
+	var fields []string
+	for _, f := range desc.Fields {
+		fields = append(fields, fmt.Sprintf("%v %v", f.FieldName(), prog.TypeSignature(f)))
+		rec(f)
+	}
+	size := "varlen"
+	if !desc.Varlen() {
+		size = fmt.Sprint(desc.Size())
+	}
+	structs[id] = fmt.Sprintf("%v size=%v align=%v {%v}",
+		id, size, desc.AlignAttr, strings.Join(fields, "; "))
+}
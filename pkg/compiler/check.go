@@ -21,17 +21,90 @@ func (comp *compiler) typecheck() {
 	comp.checkFields()
 	comp.checkTypedefs()
 	comp.checkTypes()
+	comp.checkStaticAssertNames()
+	comp.checkCallRequires()
+	comp.checkCallCompletes()
+	comp.checkScopes()
+	comp.checkNamespaces()
 }
 
 func (comp *compiler) check() {
 	comp.checkTypeValues()
+	comp.checkFlagsWidth()
 	comp.checkAttributeValues()
 	comp.checkUnused()
 	comp.checkRecursion()
+	comp.checkResourceCompatibility()
+	comp.checkResourceRanges()
 	comp.checkLenTargets()
+	comp.checkRingIndices()
+	comp.checkRelPtrs()
+	comp.checkArrayIndices()
+	comp.checkIntBuckets()
+	comp.checkResourceEffects()
+	comp.checkResourceEvents()
+	comp.checkValidBits()
+	comp.checkByValueResources()
+	comp.checkInitFields()
+	comp.checkByteOrderMarks()
+	comp.checkCountedArrays()
+	comp.checkFormatStrings()
+	comp.checkSubkindFlags()
+	comp.checkRequestMasks()
+	comp.checkOverlappingPointers()
+	comp.checkGuardedPointers()
+	comp.checkPtrDepths()
+	comp.checkSharedRegions()
+	comp.checkSerializedFormats()
+	comp.checkExhaustiveFlags()
+	comp.checkUniqueFields()
+	comp.checkCookies()
+	comp.checkIntRelations()
+	comp.checkPromotableConsts()
+	comp.checkLenDims()
+	comp.checkTaggedUnions()
+	comp.checkOptionWeights()
+	comp.checkVersionedUnions()
+	comp.checkOmittableArgs()
 	comp.checkConstructors()
 	comp.checkVarlens()
 	comp.checkDupConsts()
+	if comp.opts.StrictABI {
+		comp.checkStrictABI()
+	}
+	if comp.opts.ForbidIncomplete {
+		comp.checkIncomplete()
+	}
+}
+
+// checkIncomplete reports all declarations and fields marked with incomplete attribute.
+func (comp *compiler) checkIncomplete() {
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Resource:
+			comp.checkIncompleteAttrs(n.Attrs, "resource "+n.Name.Name)
+		case *ast.Struct:
+			_, typ, name := n.Info()
+			comp.checkIncompleteAttrs(n.Attrs, typ+" "+name)
+			for _, f := range n.Fields {
+				comp.checkIncompleteAttrs(f.Attrs, fmt.Sprintf("field %v of %v %v", f.Name.Name, typ, name))
+			}
+		case *ast.Call:
+			comp.checkIncompleteAttrs(n.Attrs, "syscall "+n.Name.Name)
+			for _, arg := range n.Args {
+				comp.checkIncompleteAttrs(arg.Attrs,
+					fmt.Sprintf("argument %v of syscall %v", arg.Name.Name, n.Name.Name))
+			}
+		}
+	}
+}
+
+func (comp *compiler) checkIncompleteAttrs(attrs []*ast.Type, what string) {
+	for _, attr := range attrs {
+		if attr.Ident == attrIncomplete {
+			comp.error(attr.Pos, "%v is marked %v", what, attrIncomplete)
+		}
+	}
 }
 
 func (comp *compiler) checkDirectives() {
@@ -145,6 +218,224 @@ func (comp *compiler) checkNames() {
 	}
 }
 
+// checkCallRequires checks that requires attributes of syscalls refer to existing syscalls
+// and that syscalls don't require each other cyclically.
+func (comp *compiler) checkCallRequires() {
+	calls := make(map[string]*ast.Call)
+	var order []string
+	for _, decl := range comp.desc.Nodes {
+		if n, ok := decl.(*ast.Call); ok && calls[n.Name.Name] == nil {
+			calls[n.Name.Name] = n
+			order = append(order, n.Name.Name)
+		}
+	}
+	requires := make(map[string][]string)
+	for _, name := range order {
+		n := calls[name]
+		seen := make(map[string]bool)
+		for _, arg := range callAttrCalls(n, "requires") {
+			switch {
+			case calls[arg.Ident] == nil:
+				comp.error(arg.Pos, "syscall %v requires unknown syscall %v", name, arg.Ident)
+			case seen[arg.Ident]:
+				comp.error(arg.Pos, "syscall %v requires syscall %v more than once", name, arg.Ident)
+			default:
+				requires[name] = append(requires[name], arg.Ident)
+			}
+			seen[arg.Ident] = true
+		}
+	}
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	var visit func(path []string)
+	visit = func(path []string) {
+		name := path[len(path)-1]
+		switch state[name] {
+		case visited:
+			return
+		case visiting:
+			for i, elem := range path[:len(path)-1] {
+				if elem == name {
+					n := calls[path[len(path)-2]]
+					comp.error(n.Pos, "syscalls have cyclic requires attributes: %v",
+						strings.Join(path[i:], " -> "))
+					break
+				}
+			}
+			return
+		}
+		state[name] = visiting
+		for _, req := range requires[name] {
+			visit(append(path, req))
+		}
+		state[name] = visited
+	}
+	for _, name := range order {
+		visit([]string{name})
+	}
+}
+
+// checkCallCompletes checks that completes attributes of syscalls refer to existing syscalls
+// that are not completion calls themselves.
+func (comp *compiler) checkCallCompletes() {
+	calls := make(map[string]*ast.Call)
+	for _, decl := range comp.desc.Nodes {
+		if n, ok := decl.(*ast.Call); ok && calls[n.Name.Name] == nil {
+			calls[n.Name.Name] = n
+		}
+	}
+	for _, decl := range comp.desc.Nodes {
+		n, ok := decl.(*ast.Call)
+		if !ok {
+			continue
+		}
+		name := n.Name.Name
+		seen := make(map[string]bool)
+		for _, arg := range callAttrCalls(n, "completes") {
+			sub := calls[arg.Ident]
+			switch {
+			case sub == nil:
+				comp.error(arg.Pos, "syscall %v completes unknown syscall %v", name, arg.Ident)
+			case seen[arg.Ident]:
+				comp.error(arg.Pos, "syscall %v completes syscall %v more than once", name, arg.Ident)
+			case len(callAttrCalls(sub, "completes")) != 0:
+				comp.error(arg.Pos, "syscall %v completes syscall %v that is a completion syscall itself",
+					name, arg.Ident)
+			}
+			seen[arg.Ident] = true
+		}
+	}
+}
+
+// checkScopes checks that every scope of scoped resources is begun and ended by some syscalls,
+// and that scopes begun or ended by syscalls have scoped resources.
+func (comp *compiler) checkScopes() {
+	begins := make(map[string]bool)
+	ends := make(map[string]bool)
+	resources := make(map[string]bool)
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Resource:
+			if scope := resourceScope(n); scope != "" {
+				resources[scope] = true
+			}
+		case *ast.Call:
+			for _, attr := range n.Attrs {
+				switch scope := callScopeAttr(attr); {
+				case scope == "":
+				case attr.Ident == "scope_begin":
+					begins[scope] = true
+				default:
+					ends[scope] = true
+				}
+			}
+		}
+	}
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Resource:
+			scope := resourceScope(n)
+			if scope == "" {
+				continue
+			}
+			if !begins[scope] {
+				comp.error(n.Pos, "resource %v is scoped to %v, but no syscall begins scope %v",
+					n.Name.Name, scope, scope)
+			} else if !ends[scope] {
+				comp.error(n.Pos, "resource %v is scoped to %v, but no syscall ends scope %v",
+					n.Name.Name, scope, scope)
+			}
+		case *ast.Call:
+			for _, attr := range n.Attrs {
+				if scope := callScopeAttr(attr); scope != "" && !resources[scope] {
+					comp.error(attr.Pos, "syscall %v has %v attribute, but no resources are scoped to %v",
+						n.Name.Name, attr.Ident, scope)
+				}
+			}
+		}
+	}
+}
+
+// checkNamespaces checks that every namespace of namespaced resources is switched by some syscalls,
+// and that namespaces switched by syscalls have namespaced resources.
+func (comp *compiler) checkNamespaces() {
+	switches := make(map[string]bool)
+	resources := make(map[string]bool)
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Resource:
+			if ns := resourceNamespace(n); ns != "" {
+				resources[ns] = true
+			}
+		case *ast.Call:
+			if ns := callNamespaceAttr(n); ns != nil {
+				switches[ns.Ident] = true
+			}
+		}
+	}
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Resource:
+			if ns := resourceNamespace(n); ns != "" && !switches[ns] {
+				comp.error(n.Pos, "resource %v is in namespace %v, but no syscall switches namespace %v",
+					n.Name.Name, ns, ns)
+			}
+		case *ast.Call:
+			if ns := callNamespaceAttr(n); ns != nil && !resources[ns.Ident] {
+				comp.error(ns.Pos, "syscall %v has setns attribute, but no resources are in namespace %v",
+					n.Name.Name, ns.Ident)
+			}
+		}
+	}
+}
+
+// callNamespaceAttr returns the namespace argument of a well-formed setns syscall attribute, if any.
+func callNamespaceAttr(n *ast.Call) *ast.Type {
+	for _, attr := range n.Attrs {
+		if attr.Ident == "setns" && len(attr.Args) == 1 && isScopeName(attr.Args[0]) {
+			return attr.Args[0]
+		}
+	}
+	return nil
+}
+
+// callScopeAttr returns the scope name of a well-formed scope_begin or scope_end syscall attribute.
+func callScopeAttr(attr *ast.Type) string {
+	if (attr.Ident == "scope_begin" || attr.Ident == "scope_end") &&
+		len(attr.Args) == 1 && isScopeName(attr.Args[0]) {
+		return attr.Args[0].Ident
+	}
+	return ""
+}
+
+// callAttrCalls returns arguments of the syscall attributes ident (requires or completes)
+// that look like syscall names.
+func callAttrCalls(n *ast.Call, ident string) []*ast.Type {
+	var res []*ast.Type
+	for _, attr := range n.Attrs {
+		if attr.Ident != ident {
+			continue
+		}
+		for _, arg := range attr.Args {
+			if arg.Ident != "" && !arg.HasString && !arg.HasColon && len(arg.Args) == 0 {
+				res = append(res, arg)
+			}
+		}
+	}
+	return res
+}
+
+func (comp *compiler) checkStaticAssertNames() {
+	for _, decl := range comp.desc.Nodes {
+		if n, ok := decl.(*ast.StaticAssert); ok && comp.structs[n.Name.Name] == nil {
+			comp.error(n.Name.Pos, "static_assert refers to unknown struct or union %v", n.Name.Name)
+		}
+	}
+}
+
 func (comp *compiler) checkFields() {
 	const maxArgs = 9 // executor does not support more
 	for _, decl := range comp.desc.Nodes {
@@ -160,6 +451,7 @@ func (comp *compiler) checkFields() {
 		case *ast.Call:
 			name := n.Name.Name
 			comp.checkFieldGroup(n.Args, "argument", "syscall "+name)
+			comp.parseCallAttrs(n)
 			if len(n.Args) > maxArgs {
 				comp.error(n.Pos, "syscall %v has %v arguments, allowed maximum is %v",
 					name, len(n.Args), maxArgs)
@@ -186,6 +478,7 @@ func (comp *compiler) checkFieldGroup(fields []*ast.Field, what, ctx string) {
 			comp.error(f.Pos, "duplicate %v %v in %v", what, fn, ctx)
 		}
 		existing[fn] = true
+		comp.parseFieldAttrs(f)
 	}
 }
 
@@ -230,6 +523,7 @@ func (comp *compiler) checkTypes() {
 		switch n := decl.(type) {
 		case *ast.Resource:
 			comp.checkType(checkCtx{}, n.Base, checkIsResourceBase)
+			comp.checkResourceAttrs(n)
 		case *ast.Struct:
 			comp.checkStruct(checkCtx{}, n)
 		case *ast.Call:
@@ -243,138 +537,2671 @@ func (comp *compiler) checkTypes() {
 	}
 }
 
-func (comp *compiler) checkTypeValues() {
+func (comp *compiler) checkResourceAttrs(n *ast.Resource) {
+	seen := make(map[string]bool)
+	for _, arg := range comp.parseResourceAttrs(n) {
+		if arg.Ident == "" || arg.HasColon || len(arg.Args) != 0 {
+			comp.error(arg.Pos, "compatible_with argument must be a resource name")
+			continue
+		}
+		if comp.resources[arg.Ident] == nil {
+			comp.error(arg.Pos, "compatible_with refers to unknown resource %v", arg.Ident)
+			continue
+		}
+		if arg.Ident == n.Name.Name {
+			comp.error(arg.Pos, "resource %v is compatible_with itself", arg.Ident)
+			continue
+		}
+		if seen[arg.Ident] {
+			comp.error(arg.Pos, "duplicate compatible_with resource %v", arg.Ident)
+			continue
+		}
+		seen[arg.Ident] = true
+	}
+}
+
+func (comp *compiler) checkTypeValues() {
+	for _, decl := range comp.desc.Nodes {
+		switch decl.(type) {
+		case *ast.Call, *ast.Struct, *ast.Resource, *ast.TypeDef:
+			comp.foreachType(decl, func(t *ast.Type, desc *typeDesc,
+				args []*ast.Type, base prog.IntTypeCommon) {
+				if desc.CheckConsts != nil {
+					desc.CheckConsts(comp, t, args, base)
+				}
+				for i, arg := range args {
+					if check := desc.Args[i].Type.CheckConsts; check != nil {
+						check(comp, arg)
+					}
+				}
+			})
+		}
+	}
+}
+
+// checkFlagsWidth checks that all flags values combined fit into the field they are used for
+// (into the bitfield for bitfield flags), otherwise the high bits are silently dropped.
+func (comp *compiler) checkFlagsWidth() {
+	for _, decl := range comp.desc.Nodes {
+		switch decl.(type) {
+		case *ast.Call, *ast.Struct, *ast.TypeDef:
+			comp.foreachType(decl, func(t *ast.Type, desc *typeDesc,
+				args []*ast.Type, base prog.IntTypeCommon) {
+				if desc != typeFlags {
+					return
+				}
+				f := comp.intFlags[args[0].Ident]
+				if f == nil || len(t.Args) < 2 || t.Args[1].Ident == "intptr" {
+					// Width of intptr depends on arch, values that don't fit on 32-bit arches
+					// can still be meaningful on 64-bit arches.
+					return
+				}
+				bits := base.TypeSize * 8
+				if base.BitfieldLen != 0 {
+					bits = base.BitfieldLen
+				}
+				// Negative values are checked separately, OR with them would hide the other values.
+				var combined uint64
+				fits := true
+				for _, v := range genIntArray(f.Values) {
+					combined |= v
+					fits = fits && valueFitsBits(v, bits)
+				}
+				if !fits {
+					comp.error(t.Pos, "flags %v (defined at %v) with combined value %#x don't fit into %v bits",
+						f.Name.Name, f.Pos, combined, bits)
+				}
+			})
+		}
+	}
+}
+
+func (comp *compiler) checkAttributeValues() {
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Struct:
+			for _, attr := range n.Attrs {
+				if attr.Ident == "size" {
+					_, typ, name := n.Info()
+					if comp.structIsVarlen(n.Name.Name) {
+						comp.error(attr.Pos, "varlen %v %v has size attribute",
+							typ, name)
+					}
+					sz := attr.Args[0].Value
+					if sz == 0 || sz > 1<<20 {
+						comp.error(attr.Args[0].Pos, "size attribute has bad value %v"+
+							", expect [1, 1<<20]", sz)
+					}
+				}
+			}
+		}
+	}
+}
+
+func (comp *compiler) checkLenTargets() {
+	warned := make(map[string]bool)
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Call:
+			for _, arg := range n.Args {
+				checked := make(map[string]bool)
+				comp.checkLenType(arg.Type, arg.Name.Name, n.Args, nil, nil, checked, warned, true)
+			}
+		}
+	}
+}
+
+func (comp *compiler) checkRingIndices() {
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Struct:
+			comp.checkRingIndex(n)
+		}
+	}
+}
+
+func (comp *compiler) checkRingIndex(n *ast.Struct) {
+	// Each ring needs exactly one head and one tail index and both of them
+	// must refer to an array field of the same struct.
+	heads := make(map[string]*ast.Field)
+	tails := make(map[string]*ast.Field)
+	var rings []string
+	for _, f := range n.Fields {
+		desc, args, _ := comp.getArgsBase(f.Type, "", prog.DirIn, false)
+		if desc != typeRingIndex {
+			continue
+		}
+		if n.IsUnion {
+			comp.error(f.Pos, "%v can't be union field", f.Type.Ident)
+			continue
+		}
+		ring := args[0].Ident
+		indices := heads
+		if f.Type.Ident == "ringtail" {
+			indices = tails
+		}
+		if indices[ring] != nil {
+			comp.error(f.Pos, "duplicate %v for ring %v", f.Type.Ident, ring)
+			continue
+		}
+		if heads[ring] == nil && tails[ring] == nil {
+			rings = append(rings, ring)
+		}
+		indices[ring] = f
+		var target *ast.Field
+		for _, fld := range n.Fields {
+			if fld.Name.Name == ring {
+				target = fld
+				break
+			}
+		}
+		if target == nil {
+			comp.error(f.Pos, "%v target %v does not exist", f.Type.Ident, ring)
+			continue
+		}
+		if target == f {
+			comp.error(f.Pos, "%v target %v refer to itself", f.Type.Ident, ring)
+			continue
+		}
+		if comp.getTypeDesc(target.Type) != typeArray {
+			comp.error(f.Pos, "%v target %v is not an array", f.Type.Ident, ring)
+		}
+	}
+	for _, ring := range rings {
+		if heads[ring] == nil {
+			comp.error(tails[ring].Pos, "ring %v has ringtail but no ringhead", ring)
+		}
+		if tails[ring] == nil {
+			comp.error(heads[ring].Pos, "ring %v has ringhead but no ringtail", ring)
+		}
+	}
+}
+
+func (comp *compiler) checkRelPtrs() {
+	for _, decl := range comp.desc.Nodes {
+		n, ok := decl.(*ast.Struct)
+		if !ok {
+			continue
+		}
+		for _, f := range n.Fields {
+			desc, args, _ := comp.getArgsBase(f.Type, "", prog.DirIn, false)
+			if desc != typeRelPtr {
+				continue
+			}
+			if n.IsUnion {
+				comp.error(f.Pos, "relptr can't be union field")
+				continue
+			}
+			pointee := args[0].Ident
+			if pointee == f.Name.Name {
+				comp.error(f.Pos, "relptr target %v refer to itself", pointee)
+				continue
+			}
+			found := false
+			for _, fld := range n.Fields {
+				if fld.Name.Name == pointee {
+					found = true
+					break
+				}
+			}
+			if !found {
+				comp.error(f.Pos, "relptr target %v does not exist", pointee)
+			}
+		}
+	}
+}
+
+// checkArrayIndices checks that arrayindex fields are fields of structs used as array elements,
+// otherwise there is no element index to fill in.
+func (comp *compiler) checkArrayIndices() {
+	elems := make(map[string]bool)
+	for _, decl := range comp.desc.Nodes {
+		switch decl.(type) {
+		case *ast.Call, *ast.Struct:
+			comp.foreachType(decl, func(t *ast.Type, desc *typeDesc,
+				args []*ast.Type, _ prog.IntTypeCommon) {
+				if desc == typeArray {
+					elems[args[0].Ident] = true
+				}
+			})
+		}
+	}
+	for _, decl := range comp.desc.Nodes {
+		n, ok := decl.(*ast.Struct)
+		if !ok {
+			continue
+		}
+		for _, f := range n.Fields {
+			if comp.getTypeDesc(f.Type) != typeArrayIndex {
+				continue
+			}
+			if n.IsUnion {
+				comp.error(f.Pos, "arrayindex can't be union field")
+				continue
+			}
+			if !elems[n.Name.Name] {
+				comp.error(f.Pos, "arrayindex field %v of struct %v that is not used as array element",
+					f.Name.Name, n.Name.Name)
+			}
+		}
+	}
+}
+
+// arrayIndexValues returns the arrayindex field of struct name with the smallest number
+// of distinct values and the number of values, or 0 if the struct does not have
+// arrayindex fields with less than 2^64 values.
+func (comp *compiler) arrayIndexValues(name string) (string, uint64) {
+	s := comp.structs[name]
+	if s == nil || s.IsUnion {
+		return "", 0
+	}
+	field, values := "", uint64(0)
+	for _, f := range s.Fields {
+		if comp.getTypeDesc(f.Type) != typeArrayIndex || len(f.Type.Args) == 0 {
+			continue
+		}
+		base := f.Type.Args[len(f.Type.Args)-1]
+		bits, _ := comp.parseIntType(base.Ident)
+		bits *= 8
+		if base.Value2 != 0 {
+			bits = base.Value2
+		}
+		if bits < 64 && (values == 0 || 1<<bits < values) {
+			field, values = f.Name.Name, 1<<bits
+		}
+	}
+	return field, values
+}
+
+func (comp *compiler) checkIntBuckets() {
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Call:
+			for _, arg := range n.Args {
+				comp.checkFieldBuckets(arg, true)
+			}
+		case *ast.Struct:
+			for _, f := range n.Fields {
+				comp.checkFieldBuckets(f, false)
+			}
+		}
+	}
+}
+
+func (comp *compiler) checkFieldBuckets(f *ast.Field, isArg bool) {
+	buckets := comp.parseFieldAttrs(f).buckets
+	if len(buckets) == 0 {
+		return
+	}
+	desc, args, base := comp.getArgsBase(f.Type, f.Name.Name, prog.DirIn, isArg)
+	if desc != typeInt || len(args) != 0 {
+		comp.error(f.Pos, "bucket attribute of %v can be used only with plain int types, not %v",
+			f.Name.Name, f.Type.Ident)
+		return
+	}
+	it := typeInt.Gen(comp, f.Type, args, base).(*prog.IntType)
+	bits := it.BitfieldLength()
+	if bits == 0 {
+		bits = it.TypeSize * 8
+	}
+	for _, b := range buckets {
+		if !valueFitsBits(b.Begin, bits) || !valueFitsBits(b.End, bits) {
+			comp.error(f.Pos, "bucket [%v:%v] of %v does not fit into %v bits",
+				b.Begin, b.End, f.Name.Name, bits)
+			return
+		}
+	}
+}
+
+func (comp *compiler) checkResourceEffects() {
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Call:
+			var transforms, derives *ast.Field
+			for _, arg := range n.Args {
+				attrs := comp.parseFieldAttrs(arg)
+				if attrs.derives {
+					comp.checkDerives(n, arg, derives)
+					if derives == nil {
+						derives = arg
+					}
+				}
+				effect := attrs.effect
+				if effect == prog.ResourceUse {
+					continue
+				}
+				attr := resourceEffectAttr(effect)
+				if desc, _, _ := comp.getArgsBase(arg.Type, arg.Name.Name, prog.DirIn, true); desc != typeResource {
+					comp.error(arg.Pos, "%v attribute of %v can be used only with resources, not %v",
+						attr, arg.Name.Name, arg.Type.Ident)
+					continue
+				}
+				if (effect == prog.ResourceAcquire || effect == prog.ResourceRelease) &&
+					!isRefcounted(comp.resources[arg.Type.Ident]) {
+					comp.error(arg.Pos, "%v attribute of %v can be used only with refcounted resources,"+
+						" %v is not refcounted", attr, arg.Name.Name, arg.Type.Ident)
+					continue
+				}
+				if effect != prog.ResourceTransform {
+					continue
+				}
+				if transforms != nil {
+					comp.error(arg.Pos, "call %v has several transforms arguments: %v and %v",
+						n.Name.Name, transforms.Name.Name, arg.Name.Name)
+					continue
+				}
+				transforms = arg
+				retResource := false
+				if n.Ret != nil {
+					desc, _, _ := comp.getArgsBase(n.Ret, "ret", prog.DirOut, true)
+					retResource = desc == typeResource
+				}
+				if !retResource {
+					comp.error(arg.Pos, "call %v with transforms attribute of %v must return a resource",
+						n.Name.Name, arg.Name.Name)
+				}
+			}
+		case *ast.Struct:
+			for _, f := range n.Fields {
+				attrs := comp.parseFieldAttrs(f)
+				if attrs.effect != prog.ResourceUse {
+					comp.error(f.Pos, "resource lifetime attributes can be used only with syscall arguments")
+				}
+				if attrs.derives {
+					comp.error(f.Pos, "derives attribute can be used only with syscall arguments")
+				}
+			}
+		}
+	}
+}
+
+func (comp *compiler) checkResourceEvents() {
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Call:
+			var nonblock *ast.Field
+			for _, arg := range n.Args {
+				attrs := comp.parseFieldAttrs(arg)
+				if attrs.event != prog.EventNone {
+					comp.checkEventArg(arg, attrs.event)
+				}
+				if attrs.hasNonblock {
+					comp.checkNonblock(n, arg, nonblock)
+					if nonblock == nil {
+						nonblock = arg
+					}
+				}
+			}
+		case *ast.Struct:
+			for _, f := range n.Fields {
+				attrs := comp.parseFieldAttrs(f)
+				if attrs.event != prog.EventNone {
+					comp.error(f.Pos, "%v attribute can be used only with syscall arguments",
+						resourceEventAttr(attrs.event))
+				}
+				if attrs.hasNonblock {
+					comp.error(f.Pos, "nonblock attribute can be used only with syscall arguments")
+				}
+			}
+		}
+	}
+}
+
+// checkEventArg checks signals/waits attribute of syscall argument arg.
+func (comp *compiler) checkEventArg(arg *ast.Field, event prog.ResourceEvent) {
+	attr := resourceEventAttr(event)
+	if desc, _, _ := comp.getArgsBase(arg.Type, arg.Name.Name, prog.DirIn, true); desc != typeResource {
+		comp.error(arg.Pos, "%v attribute of %v can be used only with resources, not %v",
+			attr, arg.Name.Name, arg.Type.Ident)
+		return
+	}
+	if !isEvent(comp.resources[arg.Type.Ident]) {
+		comp.error(arg.Pos, "%v attribute of %v can be used only with event resources,"+
+			" %v is not an event resource", attr, arg.Name.Name, arg.Type.Ident)
+	}
+}
+
+// checkNonblock checks nonblock attribute of syscall argument arg,
+// prev is the previous argument of the call with the attribute, if any.
+func (comp *compiler) checkNonblock(n *ast.Call, arg, prev *ast.Field) {
+	desc, _, _ := comp.getArgsBase(arg.Type, arg.Name.Name, prog.DirIn, true)
+	if desc != typeInt && desc != typeFlags {
+		comp.error(arg.Pos, "nonblock attribute of %v can be used only with int or flags types, not %v",
+			arg.Name.Name, arg.Type.Ident)
+		return
+	}
+	if prev != nil {
+		comp.error(arg.Pos, "call %v has several nonblock arguments: %v and %v",
+			n.Name.Name, prev.Name.Name, arg.Name.Name)
+		return
+	}
+	retEvent := false
+	if n.Ret != nil {
+		desc, _, _ := comp.getArgsBase(n.Ret, "ret", prog.DirOut, true)
+		retEvent = desc == typeResource && isEvent(comp.resources[n.Ret.Ident])
+	}
+	if !retEvent {
+		comp.error(arg.Pos, "call %v with nonblock attribute of %v must return an event resource",
+			n.Name.Name, arg.Name.Name)
+	}
+}
+
+// checkDerives checks derives attribute of syscall argument arg,
+// prev is the previous argument of the call with the attribute, if any.
+func (comp *compiler) checkDerives(n *ast.Call, arg, prev *ast.Field) {
+	if desc, _, _ := comp.getArgsBase(arg.Type, arg.Name.Name, prog.DirIn, true); desc != typeResource {
+		comp.error(arg.Pos, "derives attribute of %v can be used only with resources, not %v",
+			arg.Name.Name, arg.Type.Ident)
+		return
+	}
+	if prev != nil {
+		comp.error(arg.Pos, "call %v has several derives arguments: %v and %v",
+			n.Name.Name, prev.Name.Name, arg.Name.Name)
+		return
+	}
+	retResource := false
+	if n.Ret != nil {
+		desc, _, _ := comp.getArgsBase(n.Ret, "ret", prog.DirOut, true)
+		retResource = desc == typeResource
+	}
+	if !retResource {
+		comp.error(arg.Pos, "call %v with derives attribute of %v must return a resource",
+			n.Name.Name, arg.Name.Name)
+	}
+}
+
+func (comp *compiler) checkValidBits() {
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Call:
+			comp.checkValidBitFields(n.Args, true)
+		case *ast.Struct:
+			comp.checkValidBitFields(n.Fields, false)
+		}
+	}
+}
+
+// checkValidBitFields checks that valid_bit attributes are used with resources
+// and the bit fits into the resource.
+func (comp *compiler) checkValidBitFields(fields []*ast.Field, isArg bool) {
+	for _, f := range fields {
+		mask := comp.parseFieldAttrs(f).validMask
+		if mask == 0 {
+			continue
+		}
+		if desc, _, _ := comp.getArgsBase(f.Type, f.Name.Name, prog.DirIn, isArg); desc != typeResource {
+			comp.error(f.Pos, "valid_bit attribute of %v can be used only with resources, not %v",
+				f.Name.Name, f.Type.Ident)
+			continue
+		}
+		if size := comp.genType(f.Type, f.Name.Name, prog.DirIn, isArg).Size(); mask>>(size*8) != 0 {
+			comp.error(f.Pos, "valid_bit attribute of %v doesn't fit into %v-byte resource %v",
+				f.Name.Name, size, f.Type.Ident)
+		}
+	}
+}
+
+// checkByValueResources checks that by_value attribute is used only with resources.
+func (comp *compiler) checkByValueResources() {
+	for _, decl := range comp.desc.Nodes {
+		var fields []*ast.Field
+		isArg := false
+		switch n := decl.(type) {
+		case *ast.Call:
+			fields, isArg = n.Args, true
+		case *ast.Struct:
+			fields = n.Fields
+		}
+		for _, f := range fields {
+			if comp.parseFieldAttrs(f).invalidPct == 0 {
+				continue
+			}
+			if desc, _, _ := comp.getArgsBase(f.Type, f.Name.Name, prog.DirIn, isArg); desc != typeResource {
+				comp.error(f.Pos, "by_value attribute of %v can be used only with resources, not %v",
+					f.Name.Name, f.Type.Ident)
+			}
+		}
+	}
+}
+
+// checkInitFields checks that init attributes are used only with struct fields
+// and not with resources (an initialized resource would become an input of the call).
+func (comp *compiler) checkInitFields() {
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Call:
+			for _, arg := range n.Args {
+				if comp.parseFieldAttrs(arg).init {
+					comp.error(arg.Pos, "init attribute can be used only with struct fields")
+				}
+			}
+		case *ast.Struct:
+			for _, f := range n.Fields {
+				if !comp.parseFieldAttrs(f).init {
+					continue
+				}
+				if desc, _, _ := comp.getArgsBase(f.Type, f.Name.Name, prog.DirOut, false); desc == typeResource {
+					comp.error(f.Pos, "init attribute of %v can't be used with resource %v",
+						f.Name.Name, f.Type.Ident)
+				}
+			}
+		}
+	}
+}
+
+func (comp *compiler) checkByteOrderMarks() {
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Call:
+			for _, arg := range n.Args {
+				if comp.parseFieldAttrs(arg).byteOrder != nil {
+					comp.error(arg.Pos, "byte_order attribute can be used only with struct fields")
+				}
+			}
+		case *ast.Struct:
+			var mark *ast.Field
+			for _, f := range n.Fields {
+				values := comp.parseFieldAttrs(f).byteOrder
+				if values == nil {
+					continue
+				}
+				if n.IsUnion {
+					comp.error(f.Pos, "byte_order attribute can be used only with struct fields")
+					continue
+				}
+				if mark != nil {
+					comp.error(f.Pos, "struct %v has several byte_order fields %v and %v",
+						n.Name.Name, mark.Name.Name, f.Name.Name)
+					continue
+				}
+				mark = f
+				desc, args, base := comp.getArgsBase(f.Type, f.Name.Name, prog.DirIn, false)
+				if desc != typeInt || len(args) != 0 {
+					comp.error(f.Pos, "byte_order attribute of %v can be used only with plain int types, not %v",
+						f.Name.Name, f.Type.Ident)
+					continue
+				}
+				it := typeInt.Gen(comp, f.Type, args, base).(*prog.IntType)
+				bits := it.BitfieldLength()
+				if bits == 0 {
+					bits = it.TypeSize * 8
+				}
+				if !valueFitsBits(values[0], bits) || !valueFitsBits(values[1], bits) {
+					comp.error(f.Pos, "byte_order values of %v do not fit into %v bits", f.Name.Name, bits)
+				}
+			}
+		}
+	}
+}
+
+func (comp *compiler) checkCountedArrays() {
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Call:
+			comp.checkCountedArrayFields(n.Args, true)
+		case *ast.Struct:
+			comp.checkCountedArrayFields(n.Fields, false)
+		}
+	}
+}
+
+func (comp *compiler) checkCountedArrayFields(fields []*ast.Field, isArg bool) {
+	for _, f := range fields {
+		count := comp.parseFieldAttrs(f).count
+		if count == "" {
+			continue
+		}
+		arr := f.Type
+		if comp.getTypeDesc(arr) == typePtr {
+			arr = arr.Args[1]
+		}
+		if comp.getTypeDesc(arr) != typeArray || len(arr.Args) != 1 {
+			comp.error(f.Pos, "count attribute of %v can be used only with variable-length arrays"+
+				" or pointers to them, not %v", f.Name.Name, f.Type.Ident)
+			continue
+		}
+		if elem := arr.Args[0]; comp.getTypeDesc(elem) == typeInt && len(elem.Args) == 0 {
+			if size, _ := comp.parseIntType(elem.Ident); size == 1 {
+				comp.error(f.Pos, "count attribute of %v can't be used with byte arrays", f.Name.Name)
+				continue
+			}
+		}
+		var target *ast.Field
+		for _, f1 := range fields {
+			if f1 != f && f1.Name.Name == count {
+				target = f1
+			}
+		}
+		if target == nil {
+			comp.error(f.Pos, "count attribute of %v refers to unknown field %v", f.Name.Name, count)
+			continue
+		}
+		if desc, _, _ := comp.getArgsBase(target.Type, target.Name.Name, prog.DirIn, isArg); desc != typeResource {
+			comp.error(f.Pos, "count attribute of %v refers to %v of type %v, which is not a resource",
+				f.Name.Name, count, target.Type.Ident)
+		}
+	}
+}
+
+// checkFormatStrings checks that format_args attributes are used with strings that refer to arrays
+// of unions with specifier attributes, and that specifiers match types of the union options.
+func (comp *compiler) checkFormatStrings() {
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Call:
+			comp.checkFormatStringFields(n.Args)
+			comp.checkNoSpecifiers(n.Args)
+		case *ast.Struct:
+			comp.checkFormatStringFields(n.Fields)
+			if n.IsUnion {
+				comp.checkSpecifiers(n)
+			} else {
+				comp.checkNoSpecifiers(n.Fields)
+			}
+		}
+	}
+}
+
+func (comp *compiler) checkFormatStringFields(fields []*ast.Field) {
+	for _, f := range fields {
+		ref := comp.parseFieldAttrs(f).formatArgs
+		if ref == "" {
+			continue
+		}
+		str := f.Type
+		if comp.getTypeDesc(str) == typePtr {
+			str = str.Args[1]
+		}
+		if comp.getTypeDesc(str) != typeString || len(str.Args) != 0 {
+			comp.error(f.Pos, "format_args attribute of %v can be used only with strings without values"+
+				" or pointers to them, not %v", f.Name.Name, f.Type.Ident)
+			continue
+		}
+		var target *ast.Field
+		for _, f1 := range fields {
+			if f1 != f && f1.Name.Name == ref {
+				target = f1
+			}
+		}
+		if target == nil {
+			comp.error(f.Pos, "format_args attribute of %v refers to unknown field %v", f.Name.Name, ref)
+			continue
+		}
+		arr := target.Type
+		if comp.getTypeDesc(arr) == typePtr {
+			arr = arr.Args[1]
+		}
+		var union *ast.Struct
+		if comp.getTypeDesc(arr) == typeArray {
+			if s := comp.structs[arr.Args[0].Ident]; s != nil && s.IsUnion {
+				union = s
+			}
+		}
+		if union == nil || len(union.Fields) == 0 || comp.parseFieldAttrs(union.Fields[0]).specifier == "" {
+			comp.error(f.Pos, "format_args attribute of %v refers to %v of type %v,"+
+				" which is not an array of unions with specifier attributes or a pointer to it",
+				f.Name.Name, ref, target.Type.Ident)
+		}
+	}
+}
+
+// checkSpecifiers checks that either all or none of the options of union n have specifier attributes,
+// and that %s is used only with pointers and integer conversions only with non-pointers.
+func (comp *compiler) checkSpecifiers(n *ast.Struct) {
+	specified := 0
+	for _, f := range n.Fields {
+		spec := comp.parseFieldAttrs(f).specifier
+		if spec == "" {
+			continue
+		}
+		specified++
+		conv := spec[len(spec)-1]
+		isPtr := comp.getTypeDesc(f.Type) == typePtr
+		if conv == 's' && !isPtr || conv != 's' && conv != 'p' && isPtr {
+			comp.error(f.Pos, "specifier %q of %v does not match its type %v", spec, f.Name.Name, f.Type.Ident)
+		}
+	}
+	if specified != 0 && specified != len(n.Fields) {
+		comp.error(n.Pos, "only %v out of %v options of union %v have specifier attributes",
+			specified, len(n.Fields), n.Name.Name)
+	}
+}
+
+func (comp *compiler) checkNoSpecifiers(fields []*ast.Field) {
+	for _, f := range fields {
+		if comp.parseFieldAttrs(f).specifier != "" {
+			comp.error(f.Pos, "specifier attribute can be used only with union options")
+		}
+	}
+}
+
+func (comp *compiler) checkSubkindFlags() {
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Call:
+			comp.checkSubkindFlagsFields(n.Args, true)
+		case *ast.Struct:
+			comp.checkSubkindFlagsFields(n.Fields, false)
+		}
+	}
+}
+
+func (comp *compiler) checkSubkindFlagsFields(fields []*ast.Field, isArg bool) {
+	for _, f := range fields {
+		attrs := comp.parseFieldAttrs(f)
+		if attrs.subkindField == "" {
+			continue
+		}
+		if comp.getTypeDesc(f.Type) != typeFlags {
+			comp.error(f.Pos, "subkind attribute of %v can be used only with flags, not %v",
+				f.Name.Name, f.Type.Ident)
+			continue
+		}
+		var target *ast.Field
+		for _, f1 := range fields {
+			if f1 != f && f1.Name.Name == attrs.subkindField {
+				target = f1
+			}
+		}
+		if target == nil {
+			comp.error(f.Pos, "subkind attribute of %v refers to unknown field %v",
+				f.Name.Name, attrs.subkindField)
+			continue
+		}
+		if desc, _, _ := comp.getArgsBase(target.Type, target.Name.Name, prog.DirIn, isArg); desc != typeResource {
+			comp.error(f.Pos, "subkind attribute of %v refers to %v of type %v, which is not a resource",
+				f.Name.Name, attrs.subkindField, target.Type.Ident)
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, k := range attrs.subkinds {
+			if seen[k.Ident] {
+				comp.error(k.Pos, "duplicate subkind %v in subkind attribute of %v", k.Ident, f.Name.Name)
+				continue
+			}
+			seen[k.Ident] = true
+			if comp.resources[k.Ident] == nil {
+				comp.error(k.Pos, "subkind attribute of %v refers to unknown resource %v", f.Name.Name, k.Ident)
+				continue
+			}
+			if !comp.isResourceSubkind(k.Ident, target.Type.Ident) {
+				comp.error(k.Pos, "resource %v is not a subkind of %v", k.Ident, target.Type.Ident)
+				continue
+			}
+			if flags := k.Args[0]; comp.intFlags[flags.Ident] == nil {
+				comp.error(flags.Pos, "unknown flags %v", flags.Ident)
+			}
+		}
+	}
+}
+
+func (comp *compiler) checkRequestMasks() {
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Call:
+			comp.checkRequestMaskFields(n.Args, true)
+			for _, arg := range n.Args {
+				if comp.parseFieldAttrs(arg).hasRequested {
+					comp.error(arg.Pos, "requested_by attribute can be used only with struct fields")
+				}
+			}
+		case *ast.Struct:
+			comp.checkRequestMaskFields(n.Fields, false)
+			for _, f := range n.Fields {
+				attrs := comp.parseFieldAttrs(f)
+				if !attrs.hasRequested {
+					continue
+				}
+				if n.IsUnion {
+					comp.error(f.Pos, "requested_by attribute can be used only with struct fields")
+				} else if attrs.requestedBy == 0 {
+					comp.error(f.Pos, "requested_by attribute of %v does not have any mask bits", f.Name.Name)
+				}
+			}
+		}
+	}
+}
+
+func (comp *compiler) checkRequestMaskFields(fields []*ast.Field, isArg bool) {
+	for _, f := range fields {
+		attrs := comp.parseFieldAttrs(f)
+		if attrs.requestMask == "" {
+			continue
+		}
+		desc := comp.getTypeDesc(f.Type)
+		if desc != typeInt && desc != typeFlags {
+			comp.error(f.Pos, "request_mask attribute of %v can be used only with int or flags types, not %v",
+				f.Name.Name, f.Type.Ident)
+			continue
+		}
+		var target *ast.Field
+		for _, f1 := range fields {
+			if f1 != f && f1.Name.Name == attrs.requestMask {
+				target = f1
+			}
+		}
+		if target == nil {
+			comp.error(f.Pos, "request_mask attribute of %v refers to unknown field %v",
+				f.Name.Name, attrs.requestMask)
+			continue
+		}
+		mask := comp.requestedMask(target.Type)
+		if mask == 0 {
+			comp.error(f.Pos, "request_mask attribute of %v refers to %v of type %v,"+
+				" which is not a struct with requested_by fields or a pointer to it",
+				f.Name.Name, attrs.requestMask, target.Type.Ident)
+			continue
+		}
+		if desc != typeFlags {
+			continue
+		}
+		flags := comp.intFlags[f.Type.Args[0].Ident]
+		if flags == nil {
+			continue
+		}
+		for _, v := range flags.Values {
+			if v.Value&^mask != 0 {
+				comp.error(f.Pos, "flags %v value 0x%x of %v requests fields beyond %v",
+					flags.Name.Name, v.Value, f.Name.Name, attrs.requestMask)
+				break
+			}
+		}
+	}
+}
+
+// requestedMask returns union of requested_by attributes of fields of struct t
+// (or of the struct t points to), or 0 if t is not a struct with such fields.
+func (comp *compiler) requestedMask(t *ast.Type) uint64 {
+	if comp.getTypeDesc(t) == typePtr && len(t.Args) == 2 {
+		t = t.Args[1]
+	}
+	s := comp.structs[t.Ident]
+	if s == nil || s.IsUnion {
+		return 0
+	}
+	var mask uint64
+	for _, f := range s.Fields {
+		mask |= comp.parseFieldAttrs(f).requestedBy
+	}
+	return mask
+}
+
+// isResourceSubkind returns true if resource name is base or is derived from base.
+func (comp *compiler) isResourceSubkind(name, base string) bool {
+	for r := comp.resources[name]; r != nil; r = comp.resources[r.Base.Ident] {
+		if r.Name.Name == base {
+			return true
+		}
+	}
+	return false
+}
+
+func (comp *compiler) checkOverlappingPointers() {
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Call:
+			comp.checkOverlappingPointerFields(n.Args, false)
+		case *ast.Struct:
+			comp.checkOverlappingPointerFields(n.Fields, n.IsUnion)
+		}
+	}
+}
+
+func (comp *compiler) checkOverlappingPointerFields(fields []*ast.Field, isUnion bool) {
+	for _, f := range fields {
+		overlap := comp.parseFieldAttrs(f).overlap
+		if overlap == "" {
+			continue
+		}
+		if isUnion {
+			comp.error(f.Pos, "overlap attribute of %v can't be used in unions", f.Name.Name)
+			continue
+		}
+		if comp.getTypeDesc(f.Type) != typePtr {
+			comp.error(f.Pos, "overlap attribute of %v can be used only with pointers, not %v",
+				f.Name.Name, f.Type.Ident)
+			continue
+		}
+		var target *ast.Field
+		for _, f1 := range fields {
+			if f1 != f && f1.Name.Name == overlap {
+				target = f1
+			}
+		}
+		if target == nil {
+			comp.error(f.Pos, "overlap attribute of %v refers to unknown field %v", f.Name.Name, overlap)
+			continue
+		}
+		if comp.getTypeDesc(target.Type) != typePtr {
+			comp.error(f.Pos, "overlap attribute of %v refers to %v of type %v, which is not a pointer",
+				f.Name.Name, overlap, target.Type.Ident)
+			continue
+		}
+		if comp.parseFieldAttrs(target).overlap != "" {
+			comp.error(f.Pos, "overlap attribute of %v refers to %v, which overlaps another field",
+				f.Name.Name, overlap)
+		}
+	}
+}
+
+func (comp *compiler) checkGuardedPointers() {
+	for _, decl := range comp.desc.Nodes {
+		var fields []*ast.Field
+		switch n := decl.(type) {
+		case *ast.Call:
+			fields = n.Args
+		case *ast.Struct:
+			fields = n.Fields
+		}
+		for _, f := range fields {
+			if !comp.parseFieldAttrs(f).guard {
+				continue
+			}
+			if comp.getTypeDesc(f.Type) != typePtr {
+				comp.error(f.Pos, "guard attribute of %v can be used only with pointers, not %v",
+					f.Name.Name, f.Type.Ident)
+			}
+		}
+	}
+}
+
+// checkPtrDepths checks that depth attribute is used with pointer chains
+// that have at least the specified number of levels.
+func (comp *compiler) checkPtrDepths() {
+	for _, decl := range comp.desc.Nodes {
+		var fields []*ast.Field
+		switch n := decl.(type) {
+		case *ast.Call:
+			fields = n.Args
+		case *ast.Struct:
+			fields = n.Fields
+		}
+		for _, f := range fields {
+			depth := comp.parseFieldAttrs(f).ptrDepth
+			if depth == 0 {
+				continue
+			}
+			levels := uint64(0)
+			for t := f.Type; comp.getTypeDesc(t) == typePtr && len(t.Args) >= 2; t = t.Args[1] {
+				levels++
+			}
+			if levels == 0 {
+				comp.error(f.Pos, "depth attribute of %v can be used only with pointers, not %v",
+					f.Name.Name, f.Type.Ident)
+				continue
+			}
+			if depth > levels {
+				comp.error(f.Pos, "depth attribute of %v is %v, but the pointer chain has only %v levels",
+					f.Name.Name, depth, levels)
+			}
+		}
+	}
+}
+
+// checkSharedRegions checks that shared attributes are used only with vma syscall arguments
+// that set up shared regions and pointer syscall arguments that access them, and that every
+// accessed region is set up by some call.
+func (comp *compiler) checkSharedRegions() {
+	setup := make(map[string]bool)
+	var users []*ast.Field
+	var userNames []string
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Call:
+			for _, arg := range n.Args {
+				name := comp.parseFieldAttrs(arg).shared
+				if name == "" {
+					continue
+				}
+				switch comp.getTypeDesc(arg.Type) {
+				case typeVMA:
+					setup[name] = true
+				case typePtr:
+					users = append(users, arg)
+					userNames = append(userNames, name)
+				default:
+					comp.error(arg.Pos, "shared attribute of %v can be used only with vma and pointers, not %v",
+						arg.Name.Name, arg.Type.Ident)
+				}
+			}
+		case *ast.Struct:
+			for _, f := range n.Fields {
+				if comp.parseFieldAttrs(f).shared != "" {
+					comp.error(f.Pos, "shared attribute can be used only with syscall arguments")
+				}
+			}
+		}
+	}
+	for i, arg := range users {
+		if !setup[userNames[i]] {
+			comp.error(arg.Pos, "shared region %v of %v is not set up by any vma syscall argument",
+				userNames[i], arg.Name.Name)
+		}
+	}
+}
+
+// checkSerializedFormats checks that formats of serialized buffers consist only of plain data:
+// their values are serialized into bytes by prog, so there is nobody to fill in pointers,
+// resources and other values that are known only during execution.
+func (comp *compiler) checkSerializedFormats() {
+	checked := make(map[string]bool)
+	for _, decl := range comp.desc.Nodes {
+		switch decl.(type) {
+		case *ast.Call, *ast.Struct:
+			comp.foreachType(decl, func(t *ast.Type, desc *typeDesc,
+				args []*ast.Type, base prog.IntTypeCommon) {
+				if desc == typeSerialized {
+					comp.checkSerializedFormat(args[0], checked)
+				}
+			})
+		}
+	}
+}
+
+func (comp *compiler) checkSerializedFormat(t *ast.Type, checked map[string]bool) {
+	desc, args, _ := comp.getArgsBase(t, "", prog.DirIn, false)
+	switch desc {
+	case typePtr, typeVMA, typeResource, typeProc, typeCsum, typeHash, typeFuncPtr, typeChildPid:
+		comp.error(t.Pos, "%v can't be used in serialized formats", t.Ident)
+		return
+	case typeStruct:
+		if checked[t.Ident] {
+			return
+		}
+		checked[t.Ident] = true
+		for _, f := range comp.structs[t.Ident].Fields {
+			comp.checkSerializedFormat(f.Type, checked)
+		}
+		return
+	}
+	for i, arg := range args {
+		if desc.Args[i].Type == typeArgType {
+			comp.checkSerializedFormat(arg, checked)
+		}
+	}
+}
+
+func (comp *compiler) checkExhaustiveFlags() {
+	for _, decl := range comp.desc.Nodes {
+		var fields []*ast.Field
+		switch n := decl.(type) {
+		case *ast.Call:
+			fields = n.Args
+		case *ast.Struct:
+			fields = n.Fields
+		}
+		for _, f := range fields {
+			if !comp.parseFieldAttrs(f).exhaustive {
+				continue
+			}
+			if comp.getTypeDesc(f.Type) != typeFlags {
+				comp.error(f.Pos, "exhaustive attribute of %v can be used only with flags, not %v",
+					f.Name.Name, f.Type.Ident)
+				continue
+			}
+			name := f.Type.Args[0].Ident
+			if n := len(comp.intFlags[name].Values); n > maxExhaustiveValues {
+				comp.error(f.Pos, "exhaustive attribute of %v can be used only with flags"+
+					" with at most %v values, %v has %v values", f.Name.Name, maxExhaustiveValues, name, n)
+			}
+		}
+	}
+}
+
+func (comp *compiler) checkUniqueFields() {
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Call:
+			for _, arg := range n.Args {
+				if comp.parseFieldAttrs(arg).unique {
+					comp.error(arg.Pos, "unique attribute of %v can be used only in structs", arg.Name.Name)
+				}
+			}
+		case *ast.Struct:
+			for _, f := range n.Fields {
+				if !comp.parseFieldAttrs(f).unique {
+					continue
+				}
+				if n.IsUnion {
+					comp.error(f.Pos, "unique attribute of %v can be used only in structs", f.Name.Name)
+					continue
+				}
+				if comp.getTypeDesc(f.Type) != typeInt {
+					comp.error(f.Pos, "unique attribute of %v can be used only with int types, not %v",
+						f.Name.Name, f.Type.Ident)
+				}
+			}
+		}
+	}
+}
+
+// checkCookies checks that cookie attributes are used with int types and refer to int fields
+// of the same size in completion structs.
+func (comp *compiler) checkCookies() {
+	for _, decl := range comp.desc.Nodes {
+		var fields []*ast.Field
+		switch n := decl.(type) {
+		case *ast.Call:
+			fields = n.Args
+		case *ast.Struct:
+			fields = n.Fields
+		}
+		for _, f := range fields {
+			cookie := comp.parseFieldAttrs(f).cookie
+			if cookie == "" {
+				continue
+			}
+			if comp.getTypeDesc(f.Type) != typeInt {
+				comp.error(f.Pos, "cookie attribute of %v can be used only with int types, not %v",
+					f.Name.Name, f.Type.Ident)
+				continue
+			}
+			parts := strings.SplitN(cookie, ".", 2)
+			name, field := parts[0], parts[1]
+			s := comp.structs[name]
+			if s == nil || s.IsUnion {
+				comp.error(f.Pos, "cookie attribute of %v refers to unknown struct %v", f.Name.Name, name)
+				continue
+			}
+			var target *ast.Field
+			for _, f1 := range s.Fields {
+				if f1.Name.Name == field {
+					target = f1
+				}
+			}
+			if target == nil {
+				comp.error(f.Pos, "cookie attribute of %v refers to unknown field %v", f.Name.Name, cookie)
+				continue
+			}
+			size, _ := comp.parseIntType(f.Type.Ident)
+			if comp.getTypeDesc(target.Type) != typeInt {
+				comp.error(f.Pos, "cookie attribute of %v refers to %v of type %v, which is not an int",
+					f.Name.Name, cookie, target.Type.Ident)
+			} else if size1, _ := comp.parseIntType(target.Type.Ident); size1 != size {
+				comp.error(f.Pos, "cookie attribute of %v refers to %v of size %v, but %v has size %v",
+					f.Name.Name, cookie, size1, f.Name.Name, size)
+			}
+		}
+	}
+}
+
+// checkIntRelations checks that lt, le, eq and ne attributes relate int fields to sibling int fields
+// and that the relations can be satisfied with the ranges of the fields.
+func (comp *compiler) checkIntRelations() {
+	for _, decl := range comp.desc.Nodes {
+		var fields []*ast.Field
+		isArg, isUnion := false, false
+		switch n := decl.(type) {
+		case *ast.Call:
+			fields, isArg = n.Args, true
+		case *ast.Struct:
+			fields, isUnion = n.Fields, n.IsUnion
+		}
+		for _, f := range fields {
+			attrs := comp.parseFieldAttrs(f)
+			if attrs.relation == prog.IntRelNone {
+				continue
+			}
+			attr := intRelationAttr(attrs.relation)
+			if isUnion {
+				comp.error(f.Pos, "%v attribute can be used only with struct fields and syscall arguments", attr)
+				continue
+			}
+			if comp.getTypeDesc(f.Type) != typeInt {
+				comp.error(f.Pos, "%v attribute of %v can be used only with int types, not %v",
+					attr, f.Name.Name, f.Type.Ident)
+				continue
+			}
+			var target *ast.Field
+			for _, f1 := range fields {
+				if f1 != f && f1.Name.Name == attrs.relationField {
+					target = f1
+				}
+			}
+			if target == nil {
+				comp.error(f.Pos, "%v attribute of %v refers to unknown field %v",
+					attr, f.Name.Name, attrs.relationField)
+				continue
+			}
+			if comp.getTypeDesc(target.Type) != typeInt {
+				comp.error(f.Pos, "%v attribute of %v refers to %v of type %v, which is not an int",
+					attr, f.Name.Name, target.Name.Name, target.Type.Ident)
+				continue
+			}
+			begin, end, ok := comp.intFieldRange(f, attr, isArg)
+			begin1, end1, ok1 := comp.intFieldRange(target, attr, isArg)
+			if !ok || !ok1 {
+				continue
+			}
+			var sat bool
+			switch attrs.relation {
+			case prog.IntRelLess:
+				sat = begin < end1
+			case prog.IntRelLessEqual:
+				sat = begin <= end1
+			case prog.IntRelEqual:
+				sat = begin <= end1 && begin1 <= end
+			case prog.IntRelNotEqual:
+				sat = begin != end || begin1 != end1 || begin != begin1
+			}
+			if !sat {
+				comp.error(f.Pos, "%v attribute of %v can't be satisfied: %v is in [%v:%v], %v is in [%v:%v]",
+					attr, f.Name.Name, f.Name.Name, begin, end, target.Name.Name, begin1, end1)
+			}
+		}
+	}
+}
+
+// intFieldRange returns the range of values of int field f used in relation attribute attr.
+func (comp *compiler) intFieldRange(f *ast.Field, attr string, isArg bool) (uint64, uint64, bool) {
+	_, args, base := comp.getArgsBase(f.Type, f.Name.Name, prog.DirIn, isArg)
+	it := typeInt.Gen(comp, f.Type, args, base).(*prog.IntType)
+	if it.Kind == prog.IntRange {
+		if it.RangeBegin > it.RangeEnd {
+			comp.error(f.Pos, "%v attribute can't be used with %v of signed range [%v:%v]",
+				attr, f.Name.Name, int64(it.RangeBegin), int64(it.RangeEnd))
+			return 0, 0, false
+		}
+		return it.RangeBegin, it.RangeEnd, true
+	}
+	bits := it.BitfieldLength()
+	if bits == 0 {
+		bits = it.TypeSize * 8
+	}
+	if bits >= 64 {
+		return 0, ^uint64(0), true
+	}
+	return 0, 1<<bits - 1, true
+}
+
+// checkPromotableConsts checks that promotable attribute is used only with const types.
+func (comp *compiler) checkPromotableConsts() {
+	for _, decl := range comp.desc.Nodes {
+		var fields []*ast.Field
+		switch n := decl.(type) {
+		case *ast.Call:
+			fields = n.Args
+		case *ast.Struct:
+			fields = n.Fields
+		}
+		for _, f := range fields {
+			if comp.parseFieldAttrs(f).promotable && comp.getTypeDesc(f.Type) != typeConst {
+				comp.error(f.Pos, "promotable attribute of %v can be used only with const types, not %v",
+					f.Name.Name, f.Type.Ident)
+			}
+		}
+	}
+}
+
+// uniqueFieldValues returns the unique field (see unique attribute) of struct name
+// with the smallest number of distinct values and the number of values,
+// or 0 if the struct does not have unique fields with less than 2^64 values.
+func (comp *compiler) uniqueFieldValues(name string) (string, uint64) {
+	s := comp.structs[name]
+	if s == nil || s.IsUnion {
+		return "", 0
+	}
+	field, values := "", uint64(0)
+	for _, f := range s.Fields {
+		if !comp.parseFieldAttrs(f).unique || comp.getTypeDesc(f.Type) != typeInt {
+			continue
+		}
+		var n uint64
+		if r := f.Type.Args; len(r) != 0 {
+			n = 1
+			if r[0].HasColon {
+				n = r[0].Value2 - r[0].Value + 1
+			}
+		} else {
+			bits, _ := comp.parseIntType(f.Type.Ident)
+			bits *= 8
+			if f.Type.Value2 != 0 {
+				bits = f.Type.Value2
+			}
+			if bits < 64 {
+				n = 1 << bits
+			}
+		}
+		if n != 0 && (values == 0 || n < values) {
+			field, values = f.Name.Name, n
+		}
+	}
+	return field, values
+}
+
+func (comp *compiler) checkLenDims() {
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Call:
+			comp.checkLenDimFields(n.Args)
+		case *ast.Struct:
+			comp.checkLenDimFields(n.Fields)
+		}
+	}
+}
+
+// checkLenDimFields checks that len fields with dim attribute refer to a sibling field
+// that is a (pointer to) nested array with enough dimensions.
+func (comp *compiler) checkLenDimFields(fields []*ast.Field) {
+	for _, f := range fields {
+		dim := comp.parseFieldAttrs(f).dim
+		if dim == 0 {
+			continue
+		}
+		if f.Type.Ident != "len" {
+			comp.error(f.Pos, "dim attribute of %v can be used only with len, not %v",
+				f.Name.Name, f.Type.Ident)
+			continue
+		}
+		if len(f.Type.Args[0].Args) != 0 {
+			comp.error(f.Pos, "dim attribute of %v can't be used with len expression", f.Name.Name)
+			continue
+		}
+		name := f.Type.Args[0].Ident
+		var target *ast.Field
+		for _, f1 := range fields {
+			if f1 != f && f1.Name.Name == name {
+				target = f1
+			}
+		}
+		if target == nil {
+			comp.error(f.Pos, "dim attribute of %v requires len target %v to be a sibling field",
+				f.Name.Name, name)
+			continue
+		}
+		t := target.Type
+		for comp.getTypeDesc(t) == typePtr {
+			t = t.Args[1]
+		}
+		dims := uint64(0)
+		for ; comp.getTypeDesc(t) == typeArray; t = t.Args[0] {
+			dims++
+		}
+		if dims <= dim {
+			comp.error(f.Pos, "dim attribute of %v refers to dimension %v of %v, which has %v dimensions",
+				f.Name.Name, dim, name, dims)
+		}
+	}
+}
+
+func (comp *compiler) checkLenType(t *ast.Type, name string, fields []*ast.Field,
+	parents []string, scopes [][]*ast.Field, checked, warned map[string]bool, isArg bool) {
+	desc := comp.getTypeDesc(t)
+	if desc == typeStruct {
+		s := comp.structs[t.Ident]
+		// Prune recursion, can happen even on correct tree via opt pointers.
+		if checked[s.Name.Name] {
+			return
+		}
+		checked[s.Name.Name] = true
+		parentName := s.Name.Name
+		if pos := strings.IndexByte(parentName, '['); pos != -1 {
+			// For template parents name is "struct_name[ARG1, ARG2]", strip the part after '['.
+			parentName = parentName[:pos]
+		}
+		parents = append(parents, parentName)
+		if !s.IsUnion {
+			fields = s.Fields
+			scopes = append(scopes, s.Fields)
+		}
+		for _, fld := range s.Fields {
+			comp.checkLenType(fld.Type, fld.Name.Name, fields, parents, scopes, checked, warned, false)
+		}
+		warned[parentName] = true
+		return
+	}
+	_, args, _ := comp.getArgsBase(t, "", prog.DirIn, isArg)
+	for i, arg := range args {
+		argDesc := desc.Args[i]
+		if argDesc.Type == typeArgLenTarget || argDesc.Type == typeArgLenExpr {
+			for _, target := range lenExprTargets(arg) {
+				if t.Ident == "bytesize_inclusive" && comp.isLenParentTarget(target.Ident, parents) {
+					comp.error(target.Pos, "%v target %v includes the length field, use bytesize",
+						t.Ident, target.Ident)
+					continue
+				}
+				if (desc == typeLen || desc == typeUnitSize) && strings.IndexByte(target.Ident, '.') != -1 {
+					comp.checkLenTargetPath(t, name, target.Ident, scopes)
+				} else {
+					comp.checkLenTarget(t, name, target.Ident, fields, parents, warned)
+				}
+			}
+		} else if argDesc.Type == typeArgType {
+			comp.checkLenType(arg, name, fields, parents, scopes, checked, warned, argDesc.IsArg)
+		}
+	}
+}
+
+// isLenParentTarget returns true if len target refers to one of the enclosing structs.
+func (comp *compiler) isLenParentTarget(target string, parents []string) bool {
+	if target == "parent" || strings.HasSuffix(target, ".parent") {
+		return true
+	}
+	for _, parent := range parents {
+		if target == parent {
+			return true
+		}
+	}
+	return false
+}
+
+func (comp *compiler) checkLenTarget(t *ast.Type, name, target string, fields []*ast.Field,
+	parents []string, warned map[string]bool) {
+	if target == name {
+		comp.error(t.Pos, "%v target %v refer to itself", t.Ident, target)
+		return
+	}
+	if target == "parent" {
+		if len(parents) == 0 {
+			comp.error(t.Pos, "%v target %v does not exist", t.Ident, target)
+		}
+		return
+	}
+	for _, fld := range fields {
+		if target != fld.Name.Name {
+			continue
+		}
+		if fld.Type == t {
+			comp.error(t.Pos, "%v target %v refer to itself", t.Ident, target)
+		}
+		if t.Ident == "len" {
+			inner := fld.Type
+			// Without parents the target is a syscall argument, len syscall arguments
+			// don't have the base type argument (e.g. a len[b], b len[a]).
+			desc, args, _ := comp.getArgsBase(inner, "", prog.DirIn, len(parents) == 0)
+			for desc == typePtr {
+				if desc != typePtr {
+					break
+				}
+				inner = args[1]
+				desc, args, _ = comp.getArgsBase(inner, "", prog.DirIn, false)
+			}
+			if desc == typeArray && comp.isVarlen(args[0]) {
+				// We can reach the same struct multiple times starting from different
+				// syscall arguments. Warn only once.
+				if len(parents) == 0 || !warned[parents[len(parents)-1]] {
+					comp.warning(t.Pos, WarnLenTarget, "len target %v refer to an array with"+
+						" variable-size elements (do you mean bytesize?)", target)
+				}
+			}
+		}
+		return
+	}
+	for _, parent := range parents {
+		if target == parent {
+			return
+		}
+	}
+	comp.error(t.Pos, "%v target %v does not exist", t.Ident, target)
+}
+
+// checkLenCycles checks that len fields of generated descriptions don't target each other
+// in a cycle through a variable-length target (e.g. f1 len[f2] and f2 ptr[in, array[bytesize[f1, int32]]]).
+// Cycles of len fields with static-size targets are fine: sizes of the targets don't depend
+// on the len values, so prog resolves them.
+func (comp *compiler) checkLenCycles(prg *Prog) {
+	checked := make(map[string]bool)
+	for _, s := range prg.StructDescs {
+		n := comp.structNodes[s.Desc]
+		if checked[s.Key.Name] || n == nil || n.IsUnion {
+			continue
+		}
+		checked[s.Key.Name] = true
+		comp.checkFieldsLenCycles(s.Key.Name, s.Desc.Fields, n.Fields)
+	}
+	calls := make(map[string]*ast.Call)
+	for _, decl := range comp.desc.Nodes {
+		if n, ok := decl.(*ast.Call); ok {
+			calls[n.Name.Name] = n
+		}
+	}
+	for _, c := range prg.Syscalls {
+		if n := calls[c.Name]; n != nil {
+			comp.checkFieldsLenCycles(c.Name, c.Args, n.Args)
+		}
+	}
+}
+
+// checkUnitSizes checks that unitsize lengths of static-size targets are whole numbers of units.
+// Sizes of variable-length targets are rounded down to whole units when programs are generated.
+func (comp *compiler) checkUnitSizes(prg *Prog) {
+	descs := make(map[prog.StructKey]*prog.StructDesc)
+	for _, s := range prg.StructDescs {
+		descs[s.Key] = s.Desc
+	}
+	checked := make(map[string]bool)
+	for _, s := range prg.StructDescs {
+		n := comp.structNodes[s.Desc]
+		if checked[s.Key.Name] || n == nil || n.IsUnion {
+			continue
+		}
+		checked[s.Key.Name] = true
+		comp.checkFieldsUnitSizes(s.Desc.Fields, n.Fields, descs)
+	}
+	calls := make(map[string]*ast.Call)
+	for _, decl := range comp.desc.Nodes {
+		if n, ok := decl.(*ast.Call); ok {
+			calls[n.Name.Name] = n
+		}
+	}
+	for _, c := range prg.Syscalls {
+		if n := calls[c.Name]; n != nil {
+			comp.checkFieldsUnitSizes(c.Args, n.Args, descs)
+		}
+	}
+}
+
+func (comp *compiler) checkFieldsUnitSizes(fields []prog.Type, nodes []*ast.Field,
+	descs map[prog.StructKey]*prog.StructDesc) {
+	types := make(map[string]prog.Type)
+	for _, f := range fields {
+		types[f.FieldName()] = f
+	}
+	for _, fld := range nodes {
+		if fld.Type.Ident != "unitsize" || len(fld.Type.Args) < 2 {
+			continue
+		}
+		target, unit := fld.Type.Args[0], fld.Type.Args[1].Value
+		typ := types[target.Ident]
+		if typ == nil || len(target.Args) != 0 || unit == 0 {
+			// Length expressions, paths and parents are not checked.
+			continue
+		}
+		if ptr, ok := typ.(*prog.PtrType); ok {
+			typ = ptr.Type
+		}
+		var size uint64
+		switch t := typ.(type) {
+		case *prog.VmaType:
+			continue
+		case *prog.StructType:
+			if descs[t.Key].Varlen() {
+				continue
+			}
+			size = descs[t.Key].Size()
+		case *prog.UnionType:
+			if descs[t.Key].Varlen() {
+				continue
+			}
+			size = descs[t.Key].Size()
+		default:
+			if t.Varlen() {
+				continue
+			}
+			size = t.Size()
+		}
+		if size%unit != 0 {
+			comp.error(fld.Pos, "unitsize target %v of size %v is not a multiple of unit %v",
+				target.Ident, size, unit)
+		}
+	}
+}
+
+// checkTaggedUnions checks that options of unions of tagged records (tagged_record template)
+// have different tags, otherwise the tag does not identify the record type.
+func (comp *compiler) checkTaggedUnions() {
+	for _, decl := range comp.desc.Nodes {
+		n, ok := decl.(*ast.Struct)
+		if !ok || !n.IsUnion {
+			continue
+		}
+		tags := make(map[uint64]string)
+		for _, f := range n.Fields {
+			if !strings.HasPrefix(f.Type.Ident, "tagged_record[") {
+				continue
+			}
+			record := comp.structs[f.Type.Ident]
+			if record == nil {
+				continue
+			}
+			tag := record.Fields[0].Type.Args[0].Value
+			if prev, ok := tags[tag]; ok {
+				comp.error(f.Pos, "union %v options %v and %v have the same tag %v",
+					n.Name.Name, prev, f.Name.Name, tag)
+				continue
+			}
+			tags[tag] = f.Name.Name
+		}
+	}
+}
+
+// checkOptionWeights checks that weight attributes are used only with union options
+// and that at least one option of a union can be chosen.
+func (comp *compiler) checkOptionWeights() {
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Call:
+			for _, arg := range n.Args {
+				if comp.parseFieldAttrs(arg).hasWeight {
+					comp.error(arg.Pos, "weight attribute can be used only with union options")
+				}
+			}
+		case *ast.Struct:
+			if !n.IsUnion {
+				for _, f := range n.Fields {
+					if comp.parseFieldAttrs(f).hasWeight {
+						comp.error(f.Pos, "weight attribute can be used only with union options")
+					}
+				}
+				continue
+			}
+			if weights := comp.genOptionWeights(n.Fields); weights != nil {
+				var total uint64
+				for _, w := range weights {
+					total += w
+				}
+				if total == 0 {
+					comp.error(n.Pos, "all options of union %v have zero weight", n.Name.Name)
+				}
+			}
+		}
+	}
+}
+
+// checkVersionedUnions checks that options of versioned unions are structs with the version field
+// and have different versions, and that version attributes are used only with such options.
+func (comp *compiler) checkVersionedUnions() {
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Call:
+			for _, arg := range n.Args {
+				if comp.parseFieldAttrs(arg).hasVersion {
+					comp.error(arg.Pos, "version attribute can be used only with options of versioned unions")
+				}
+			}
+		case *ast.Struct:
+			versionField := ""
+			if n.IsUnion {
+				_, _, versionField, _ = comp.parseUnionAttrs(n)
+			}
+			if versionField == "" {
+				for _, f := range n.Fields {
+					if comp.parseFieldAttrs(f).hasVersion {
+						comp.error(f.Pos, "version attribute can be used only with options of versioned unions")
+					}
+				}
+				continue
+			}
+			versions := make(map[uint64]string)
+			for _, f := range n.Fields {
+				attrs := comp.parseFieldAttrs(f)
+				if !attrs.hasVersion {
+					comp.error(f.Pos, "option %v of versioned union %v does not have version attribute",
+						f.Name.Name, n.Name.Name)
+					continue
+				}
+				if prev, ok := versions[attrs.version]; ok {
+					comp.error(f.Pos, "union %v options %v and %v have the same version %v",
+						n.Name.Name, prev, f.Name.Name, attrs.version)
+					continue
+				}
+				versions[attrs.version] = f.Name.Name
+				comp.checkVersionField(n, f, versionField)
+			}
+		}
+	}
+}
+
+func (comp *compiler) checkVersionField(n *ast.Struct, f *ast.Field, versionField string) {
+	s := comp.structs[f.Type.Ident]
+	if s == nil || s.IsUnion {
+		comp.error(f.Pos, "option %v of versioned union %v is not a struct", f.Name.Name, n.Name.Name)
+		return
+	}
+	for _, fld := range s.Fields {
+		if fld.Name.Name != versionField {
+			continue
+		}
+		if comp.getTypeDesc(fld.Type) != typeInt || fld.Type.HasColon || len(fld.Type.Args) != 0 {
+			comp.error(fld.Pos, "version field %v of struct %v must be a plain integer",
+				versionField, s.Name.Name)
+		}
+		return
+	}
+	comp.error(f.Pos, "struct %v of option %v of versioned union %v does not have version field %v",
+		s.Name.Name, f.Name.Name, n.Name.Name, versionField)
+}
+
+// checkOmittableArgs checks that omittable attributes are used only with trailing syscall arguments
+// and that omittable arguments are not referenced by len arguments (the len would refer to
+// a missing argument when the argument is omitted).
+func (comp *compiler) checkOmittableArgs() {
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Call:
+			omittable := make(map[string]bool)
+			for i, arg := range n.Args {
+				if !comp.parseFieldAttrs(arg).omittable {
+					continue
+				}
+				omittable[arg.Name.Name] = true
+				if i+1 < len(n.Args) && !comp.parseFieldAttrs(n.Args[i+1]).omittable {
+					comp.error(arg.Pos, "omittable argument %v of %v is followed by non-omittable argument %v",
+						arg.Name.Name, n.Name.Name, n.Args[i+1].Name.Name)
+				}
+			}
+			if len(omittable) == 0 {
+				continue
+			}
+			for _, arg := range n.Args {
+				comp.foreachSubType(arg.Type, true, func(t *ast.Type, desc *typeDesc,
+					args []*ast.Type, base prog.IntTypeCommon) {
+					if desc != typeLen && desc != typeUnitSize || len(args) == 0 {
+						return
+					}
+					for _, target := range lenExprTargets(args[0]) {
+						if name := strings.Split(target.Ident, ".")[0]; omittable[name] {
+							comp.error(t.Pos, "%v of %v refers to omittable argument %v",
+								t.Ident, arg.Name.Name, name)
+						}
+					}
+				})
+			}
+		case *ast.Struct:
+			for _, f := range n.Fields {
+				if comp.parseFieldAttrs(f).omittable {
+					comp.error(f.Pos, "omittable attribute can be used only with syscall arguments")
+				}
+			}
+		}
+	}
+}
+
+// checkArgSizes checks that the total size of data referenced by every syscall argument
+// (including pointees) does not exceed the maximum argument size.
+// Arrays and buffers without explicit upper bounds are accounted with the minimal size,
+// recursion via pointers is not followed.
+func (comp *compiler) checkArgSizes(prg *Prog) {
+	limit := comp.opts.MaxArgSize
+	if limit == 0 {
+		limit = comp.target.MaxArgSize
+	}
+	if limit == 0 {
+		return
+	}
+	descs := make(map[prog.StructKey]*prog.StructDesc)
+	for _, s := range prg.StructDescs {
+		descs[s.Key] = s.Desc
+	}
+	calls := make(map[string]*ast.Call)
+	for _, decl := range comp.desc.Nodes {
+		if n, ok := decl.(*ast.Call); ok {
+			calls[n.Name.Name] = n
+		}
+	}
+	ctx := &argSizeCtx{
+		descs:    descs,
+		sizes:    make(map[prog.StructKey]uint64),
+		visiting: make(map[prog.StructKey]bool),
+	}
+	for _, c := range prg.Syscalls {
+		n := calls[c.Name]
+		if n == nil {
+			continue
+		}
+		for i, arg := range c.Args {
+			if size := ctx.maxSize(arg); size > limit {
+				comp.error(n.Args[i].Pos, "argument %v of %v can take up to %v bytes, maximum is %v",
+					arg.FieldName(), c.Name, size, limit)
+			}
+		}
+	}
+}
+
+// checkResourceUsage checks that every resource has at least one producer and at least one
+// consumer among the compiled syscalls (see Options.StrictResources). Syscalls that can't be
+// used because some of their required input resources can't be (transitively) produced
+// are not considered. Resources with value ranges don't need producers.
+func (comp *compiler) checkResourceUsage(prg *Prog) {
+	descs := make(map[prog.StructKey]*prog.StructDesc)
+	for _, s := range prg.StructDescs {
+		descs[s.Key] = s.Desc
+	}
+	resources := make(map[string]*prog.ResourceDesc)
+	for _, res := range prg.Resources {
+		resources[res.Name] = res
+	}
+	inputs := make(map[*prog.Syscall][]*prog.ResourceDesc)
+	required := make(map[*prog.Syscall][]*prog.ResourceDesc)
+	outputs := make(map[*prog.Syscall][]*prog.ResourceDesc)
+	for _, c := range prg.Syscalls {
+		seen := make(map[prog.StructKey]bool)
+		var rec func(t prog.Type)
+		rec = func(t prog.Type) {
+			switch a := t.(type) {
+			case *prog.ResourceType:
+				res := resources[a.TypeName]
+				if a.Dir() != prog.DirOut {
+					inputs[c] = append(inputs[c], res)
+					if !a.IsOptional && !res.HasRange {
+						required[c] = append(required[c], res)
+					}
+				}
+				if a.Dir() != prog.DirIn {
+					outputs[c] = append(outputs[c], res)
+				}
+			case *prog.PtrType:
+				rec(a.Type)
+			case *prog.ArrayType:
+				rec(a.Type)
+			case *prog.StructType:
+				if !seen[a.Key] {
+					seen[a.Key] = true
+					for _, f := range descs[a.Key].Fields {
+						rec(f)
+					}
+				}
+			case *prog.UnionType:
+				if !seen[a.Key] {
+					seen[a.Key] = true
+					for _, f := range descs[a.Key].Fields {
+						rec(f)
+					}
+				}
+			}
+		}
+		for _, a := range c.Args {
+			rec(a)
+		}
+		if c.Ret != nil {
+			rec(c.Ret)
+		}
+	}
+	// Producing a resource also produces all its base resources (e.g. sock is also fd).
+	produced := make(map[string]bool)
+	canProduce := func(res *prog.ResourceDesc) bool {
+		if produced[res.Name] {
+			return true
+		}
+		for _, name := range res.Compatible {
+			if produced[name] {
+				return true
+			}
+		}
+		return false
+	}
+	enabled := make(map[*prog.Syscall]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, c := range prg.Syscalls {
+			if enabled[c] {
+				continue
+			}
+			ready := true
+			for _, res := range required[c] {
+				ready = ready && canProduce(res)
+			}
+			if !ready {
+				continue
+			}
+			enabled[c] = true
+			changed = true
+			for _, res := range outputs[c] {
+				for _, kind := range res.Kind {
+					produced[kind] = true
+				}
+			}
+		}
+	}
+	// Consuming a resource also consumes all more specialized resources (e.g. fd consumes sock).
+	consumed := make(map[string]bool)
+	for c := range enabled {
+		for _, res := range inputs[c] {
+			consumed[res.Name] = true
+		}
+	}
+	for _, res := range prg.Resources {
+		pos := comp.resources[res.Name].Pos
+		if !res.HasRange && !canProduce(res) {
+			comp.error(pos, "resource %v has no producer"+
+				" (no enabled syscall returns it or has it as output argument/field)", res.Name)
+		}
+		isConsumed := false
+		for _, name := range res.Kind {
+			isConsumed = isConsumed || consumed[name]
+		}
+		for _, name := range res.Compatible {
+			isConsumed = isConsumed || consumed[name]
+		}
+		if !isConsumed {
+			comp.error(pos, "resource %v has no consumer"+
+				" (no enabled syscall has it as input argument/field)", res.Name)
+		}
+	}
+}
+
+// checkPureGetters warns about calls that look like pure getters: they don't have any
+// input arguments except for consts, all their pointers point to output scalars,
+// and they neither consume nor produce resources. Such calls are unlikely to produce
+// interesting coverage, so maintainers may want to review whether they are worth fuzzing.
+// This is only a heuristic, calls with side_effects or nocover attributes are not reported.
+func (comp *compiler) checkPureGetters(prg *Prog) {
+	descs := make(map[prog.StructKey]*prog.StructDesc)
+	for _, s := range prg.StructDescs {
+		descs[s.Key] = s.Desc
+	}
+	calls := make(map[string]*ast.Call)
+	for _, decl := range comp.desc.Nodes {
+		if n, ok := decl.(*ast.Call); ok {
+			calls[n.Name.Name] = n
+		}
+	}
+	for _, c := range prg.Syscalls {
+		n := calls[c.Name]
+		if n == nil || c.NoCover || hasSideEffects(n) {
+			continue
+		}
+		if _, ok := c.Ret.(*prog.ResourceType); ok {
+			continue
+		}
+		// Calls without outputs (e.g. pause or munlockall) are not getters.
+		getter, outputs := true, 0
+		for _, arg := range c.Args {
+			switch a := arg.(type) {
+			case *prog.ConstType:
+			case *prog.PtrType:
+				getter = getter && isOutputScalar(a.Type, descs, make(map[prog.StructKey]bool))
+				outputs++
+			default:
+				getter = false
+			}
+		}
+		if getter && outputs != 0 {
+			comp.warning(n.Pos, WarnPureGetter, "syscall %v looks like a pure getter"+
+				" (only output scalars, no resources), add side_effects attribute if it's not", c.Name)
+		}
+	}
+}
+
+// checkUnreachableOptions warns about options of unions selected by a tag (tagged records
+// and versioned unions) with tags that don't fit into the tag field. Such options can never
+// be selected by the tag value the kernel sees, which usually means that the tag is wrong.
+func (comp *compiler) checkUnreachableOptions(prg *Prog) {
+	descs := make(map[prog.StructKey]*prog.StructDesc)
+	for _, s := range prg.StructDescs {
+		descs[s.Key] = s.Desc
+	}
+	checked := make(map[string]bool)
+	for _, s := range prg.StructDescs {
+		n := comp.structs[strings.TrimSuffix(s.Key.Name, compatSuffix)]
+		if n == nil || !n.IsUnion || checked[s.Key.Name] {
+			continue
+		}
+		checked[s.Key.Name] = true
+		fields := make(map[string]*ast.Field)
+		for _, f := range n.Fields {
+			fields[f.Name.Name] = f
+		}
+		for i, opt := range s.Desc.Fields {
+			typ, ok := opt.(*prog.StructType)
+			if !ok || fields[opt.FieldName()] == nil {
+				continue
+			}
+			desc := descs[typ.Key]
+			tag, what := prog.Type(nil), "tag"
+			var val uint64
+			if s.Desc.VersionField != "" {
+				for _, fld := range desc.Fields {
+					if fld.FieldName() == s.Desc.VersionField {
+						tag, val = fld, s.Desc.Versions[i]
+					}
+				}
+				what = "version"
+			} else if strings.HasPrefix(typ.Key.Name, "tagged_record[") {
+				if c, ok := desc.Fields[0].(*prog.ConstType); ok {
+					tag, val = c, c.Val
+				}
+			}
+			if tag == nil {
+				continue
+			}
+			bits := tag.Size() * 8
+			if tag.BitfieldLength() != 0 {
+				bits = tag.BitfieldLength()
+			}
+			if !valueFitsBits(val, bits) {
+				comp.warning(fields[opt.FieldName()].Pos, WarnUnreachable,
+					"option %v of union %v is unreachable: %v %v does not fit into %v-bit field %v",
+					opt.FieldName(), n.Name.Name, what, val, bits, tag.FieldName())
+			}
+		}
+	}
+}
+
+// checkOverlayUnions checks that options of overlay unions are structs of bitfields
+// that fit into a single backing integer of the same size for all options.
+func (comp *compiler) checkOverlayUnions(prg *Prog) {
+	descs := make(map[prog.StructKey]*prog.StructDesc)
+	for _, s := range prg.StructDescs {
+		descs[s.Key] = s.Desc
+	}
+	checked := make(map[string]bool)
+	for _, s := range prg.StructDescs {
+		n := comp.structs[strings.TrimSuffix(s.Key.Name, compatSuffix)]
+		if n == nil || !s.Desc.Overlay || checked[s.Key.Name] {
+			continue
+		}
+		checked[s.Key.Name] = true
+		fields := make(map[string]*ast.Field)
+		for _, f := range n.Fields {
+			fields[f.Name.Name] = f
+		}
+		first, firstSize := "", uint64(0)
+		for _, opt := range s.Desc.Fields {
+			f := fields[opt.FieldName()]
+			size, ok := comp.overlaySize(n, f, opt, descs)
+			if !ok {
+				continue
+			}
+			if first == "" {
+				first, firstSize = opt.FieldName(), size
+				continue
+			}
+			if size != firstSize {
+				comp.error(f.Pos, "option %v of overlay union %v has %v-byte backing integer,"+
+					" but option %v has %v-byte backing integer",
+					opt.FieldName(), n.Name.Name, size, first, firstSize)
+			}
+		}
+	}
+}
+
+// overlaySize returns size of the backing integer of option opt (declared by field f) of overlay union n,
+// or reports an error and returns false if opt is not a view of a single backing integer.
+func (comp *compiler) overlaySize(n *ast.Struct, f *ast.Field, opt prog.Type,
+	descs map[prog.StructKey]*prog.StructDesc) (uint64, bool) {
+	typ, ok := opt.(*prog.StructType)
+	if !ok || len(descs[typ.Key].Fields) == 0 {
+		comp.error(f.Pos, "option %v of overlay union %v is not a struct of bitfields",
+			opt.FieldName(), n.Name.Name)
+		return 0, false
+	}
+	desc := descs[typ.Key]
+	bits := uint64(0)
+	for _, fld := range desc.Fields {
+		if prog.IsPad(fld) {
+			continue
+		}
+		if fld.BitfieldLength() == 0 {
+			comp.error(f.Pos, "option %v of overlay union %v is not a struct of bitfields:"+
+				" field %v is not a bitfield", opt.FieldName(), n.Name.Name, fld.FieldName())
+			return 0, false
+		}
+		bits += fld.BitfieldLength()
+	}
+	size := desc.Fields[0].Size()
+	if bits > size*8 {
+		comp.error(f.Pos, "bitfields of option %v of overlay union %v take %v bits,"+
+			" but the backing integer has only %v", opt.FieldName(), n.Name.Name, bits, size*8)
+		return 0, false
+	}
+	if groups := (&prog.StructType{StructDesc: desc}).Bitfields(); len(groups) != 1 || desc.Size() != size {
+		comp.error(f.Pos, "bitfields of option %v of overlay union %v don't form a single backing integer",
+			opt.FieldName(), n.Name.Name)
+		return 0, false
+	}
+	return size, true
+}
+
+// isOutputScalar returns true if t is an output type that consists only of scalars.
+func isOutputScalar(t prog.Type, descs map[prog.StructKey]*prog.StructDesc, visited map[prog.StructKey]bool) bool {
+	if prog.IsPad(t) {
+		return true
+	}
+	if typeDir(t) != prog.DirOut {
+		return false
+	}
+	switch a := t.(type) {
+	case *prog.IntType, *prog.FlagsType, *prog.LenType, *prog.ConstType,
+		*prog.ProcType, *prog.CsumType, *prog.BufferType:
+		return true
+	case *prog.ArrayType:
+		return isOutputScalar(a.Type, descs, visited)
+	case *prog.StructType:
+		return areOutputScalars(a.Key, descs, visited)
+	case *prog.UnionType:
+		return areOutputScalars(a.Key, descs, visited)
+	}
+	return false
+}
+
+func areOutputScalars(key prog.StructKey, descs map[prog.StructKey]*prog.StructDesc,
+	visited map[prog.StructKey]bool) bool {
+	if visited[key] {
+		return true
+	}
+	visited[key] = true
+	for _, f := range descs[key].Fields {
+		if !isOutputScalar(f, descs, visited) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkStructDirs warns about structs and unions that have different layouts in different
+// directions. Each direction of a struct gets own fields, directions of the fields are expected
+// to differ (e.g. fields with init attribute are inout inside of out structs), but types and sizes
+// of the fields must be the same, otherwise the kernel sees different structs depending
+// on how the struct is passed.
+func (comp *compiler) checkStructDirs(prg *Prog) {
+	descs := make(map[prog.StructKey]*prog.StructDesc)
+	for _, s := range prg.StructDescs {
+		descs[s.Key] = s.Desc
+	}
+	structs := make(map[string]*ast.Struct)
+	for _, decl := range comp.desc.Nodes {
+		if n, ok := decl.(*ast.Struct); ok {
+			structs[n.Name.Name] = n
+		}
+	}
+	// StructDescs are sorted by name and then by direction.
+	for i := 1; i < len(prg.StructDescs); i++ {
+		s0, s1 := prg.StructDescs[i-1], prg.StructDescs[i]
+		if s0.Key.Name != s1.Key.Name {
+			continue
+		}
+		diff := structDirDiff(descs, s0.Desc, s1.Desc, s0.Key.Dir, s1.Key.Dir)
+		n := structs[s0.Key.Name]
+		if diff == "" || n == nil {
+			continue
+		}
+		_, typ, _ := n.Info()
+		comp.warning(n.Pos, WarnStructDir, "%v %v has different layouts in directions %v and %v: %v",
+			typ, s0.Key.Name, s0.Key.Dir, s1.Key.Dir, diff)
+	}
+}
+
+// structDirDiff returns description of the first layout difference between directions dir0
+// and dir1 of a struct, or an empty string if the layouts are the same.
+func structDirDiff(descs map[prog.StructKey]*prog.StructDesc, desc0, desc1 *prog.StructDesc,
+	dir0, dir1 prog.Dir) string {
+	if len(desc0.Fields) != len(desc1.Fields) {
+		return fmt.Sprintf("%v fields in %v, but %v fields in %v",
+			len(desc0.Fields), dir0, len(desc1.Fields), dir1)
+	}
+	for i, f0 := range desc0.Fields {
+		f1 := desc1.Fields[i]
+		l0, l1 := fieldLayout(descs, f0), fieldLayout(descs, f1)
+		if f0.FieldName() != f1.FieldName() || l0 != l1 {
+			return fmt.Sprintf("field %v is %v in %v, but field %v is %v in %v",
+				f0.FieldName(), l0, dir0, f1.FieldName(), l1, dir1)
+		}
+	}
+	if desc0.Varlen() != desc1.Varlen() || desc0.TypeSize != desc1.TypeSize {
+		return fmt.Sprintf("%v in %v, but %v in %v",
+			sizeStr(desc0.Varlen(), desc0.TypeSize), dir0, sizeStr(desc1.Varlen(), desc1.TypeSize), dir1)
+	}
+	return ""
+}
+
+// fieldLayout returns a string describing type, size and bitfield position of field t,
+// but not its direction.
+func fieldLayout(descs map[prog.StructKey]*prog.StructDesc, t prog.Type) string {
+	var key prog.StructKey
+	switch a := t.(type) {
+	case *prog.StructType:
+		key = a.Key
+	case *prog.UnionType:
+		key = a.Key
+	default:
+		size := uint64(0)
+		if !t.Varlen() {
+			size = t.Size()
+		}
+		res := fmt.Sprintf("%v of %v", layoutTypeName(t), sizeStr(t.Varlen(), size))
+		if t.BitfieldLength() != 0 {
+			res += fmt.Sprintf(" at bits %v:%v", t.BitfieldOffset(), t.BitfieldOffset()+t.BitfieldLength())
+		}
+		return res
+	}
+	return fmt.Sprintf("%v of %v", key.Name, sizeStr(descs[key].Varlen(), descs[key].TypeSize))
+}
+
+// layoutTypeName returns name of type t, struct descriptions are not yet linked to struct types.
+func layoutTypeName(t prog.Type) string {
+	switch a := t.(type) {
+	case *prog.StructType:
+		return a.Key.Name
+	case *prog.UnionType:
+		return a.Key.Name
+	case *prog.ArrayType:
+		return fmt.Sprintf("array[%v]", layoutTypeName(a.Type))
+	case *prog.PtrType:
+		return fmt.Sprintf("ptr[%v]", layoutTypeName(a.Type))
+	}
+	return t.String()
+}
+
+func sizeStr(varlen bool, size uint64) string {
+	if varlen {
+		return "variable size"
+	}
+	return fmt.Sprintf("size %v", size)
+}
+
+// checkPtrDirs checks that directions of all types inside of pointees (up to nested pointers)
+// match directions of the pointees, otherwise e.g. a resource inside of ptr[in, ...] would be
+// treated as produced by the call. The only exception are fields with init attribute,
+// which are inout inside of out pointees.
+func (comp *compiler) checkPtrDirs(prg *Prog) {
+	descs := make(map[prog.StructKey]*prog.StructDesc)
+	for _, s := range prg.StructDescs {
+		descs[s.Key] = s.Desc
+	}
+	calls := make(map[string]*ast.Call)
+	for _, decl := range comp.desc.Nodes {
+		if n, ok := decl.(*ast.Call); ok {
+			calls[n.Name.Name] = n
+		}
+	}
+	for _, c := range prg.Syscalls {
+		n := calls[c.Name]
+		if n == nil {
+			continue
+		}
+		for i, arg := range c.Args {
+			ctx := &ptrDirCtx{
+				comp:    comp,
+				descs:   descs,
+				pos:     n.Args[i].Pos,
+				visited: make(map[prog.StructKey]bool),
+			}
+			ctx.check(arg, prog.DirIn, c.Name+":"+arg.FieldName())
+		}
+	}
+}
+
+type ptrDirCtx struct {
+	comp    *compiler
+	descs   map[prog.StructKey]*prog.StructDesc
+	pos     ast.Pos
+	visited map[prog.StructKey]bool
+}
+
+func (ctx *ptrDirCtx) check(t prog.Type, dir prog.Dir, path string) {
+	if ptr, ok := t.(*prog.PtrType); ok {
+		// Pointers themselves and paddings are always in.
+		ctx.check(ptr.Type, typeDir(ptr.Type), path)
+		return
+	}
+	if prog.IsPad(t) {
+		return
+	}
+	if tdir := typeDir(t); tdir != dir && (dir != prog.DirOut || tdir != prog.DirInOut) {
+		ctx.comp.warning(ctx.pos, WarnPtrDir, "%v has direction %v inside of %v pointee",
+			path, tdir, dir)
+	}
+	// Report each conflict once, the nested types are expected to be consistent with t.
+	dir = typeDir(t)
+	switch a := t.(type) {
+	case *prog.ArrayType:
+		ctx.check(a.Type, dir, path)
+	case *prog.StructType:
+		ctx.checkFields(a.Key, dir, path)
+	case *prog.UnionType:
+		ctx.checkFields(a.Key, dir, path)
+	}
+}
+
+// typeDir returns direction of t, struct descriptions are not yet linked to struct types.
+func typeDir(t prog.Type) prog.Dir {
+	switch a := t.(type) {
+	case *prog.StructType:
+		return a.Key.Dir
+	case *prog.UnionType:
+		return a.Key.Dir
+	}
+	return t.Dir()
+}
+
+func (ctx *ptrDirCtx) checkFields(key prog.StructKey, dir prog.Dir, path string) {
+	desc := ctx.descs[key]
+	if desc == nil || ctx.visited[key] {
+		return
+	}
+	ctx.visited[key] = true
+	for _, f := range desc.Fields {
+		ctx.check(f, dir, path+"."+f.FieldName())
+	}
+}
+
+type argSizeCtx struct {
+	descs    map[prog.StructKey]*prog.StructDesc
+	sizes    map[prog.StructKey]uint64
+	visiting map[prog.StructKey]bool
+}
+
+func (ctx *argSizeCtx) maxSize(t prog.Type) uint64 {
+	switch a := t.(type) {
+	case *prog.PtrType:
+		return satAdd(a.Size(), ctx.maxSize(a.Type))
+	case *prog.ArrayType:
+		count := uint64(0)
+		if a.Kind == prog.ArrayRangeLen {
+			count = a.RangeEnd
+		}
+		return satMul(count, ctx.maxSize(a.Type))
+	case *prog.BufferType:
+		switch a.Kind {
+		case prog.BufferBlobRange:
+			return a.RangeEnd
+		case prog.BufferString, prog.BufferFilename:
+			size := a.TypeSize
+			for _, v := range a.Values {
+				if size < uint64(len(v)) {
+					size = uint64(len(v))
+				}
+			}
+			return size
+		}
+		return a.TypeSize
+	case *prog.StructType:
+		return ctx.maxStructSize(a.Key, false)
+	case *prog.UnionType:
+		return ctx.maxStructSize(a.Key, true)
+	default:
+		return t.Size()
+	}
+}
+
+func (ctx *argSizeCtx) maxStructSize(key prog.StructKey, union bool) uint64 {
+	if size, ok := ctx.sizes[key]; ok {
+		return size
+	}
+	if ctx.visiting[key] {
+		return 0
+	}
+	desc := ctx.descs[key]
+	if desc == nil {
+		return 0
+	}
+	ctx.visiting[key] = true
+	size := uint64(0)
+	for _, f := range desc.Fields {
+		fsize := ctx.maxSize(f)
+		if !union {
+			size = satAdd(size, fsize)
+		} else if size < fsize {
+			size = fsize
+		}
+	}
+	delete(ctx.visiting, key)
+	ctx.sizes[key] = size
+	return size
+}
+
+func satAdd(a, b uint64) uint64 {
+	if a+b < a {
+		return ^uint64(0)
+	}
+	return a + b
+}
+
+func satMul(a, b uint64) uint64 {
+	if a != 0 && a*b/a != b {
+		return ^uint64(0)
+	}
+	return a * b
+}
+
+// checkGenericBuffers warns about syscalls where the fraction of input bytes passed
+// in generic byte buffers (buffer[in], array[int8] and similar, i.e. buffers without
+// any structure or encoding) is at least Options.GenericBuffers. Such arguments are
+// hard to fuzz efficiently, the warnings are meant to guide improvement of descriptions.
+func (comp *compiler) checkGenericBuffers(prg *Prog) {
+	descs := make(map[prog.StructKey]*prog.StructDesc)
+	for _, s := range prg.StructDescs {
+		descs[s.Key] = s.Desc
+	}
+	calls := make(map[string]*ast.Call)
+	for _, decl := range comp.desc.Nodes {
+		if n, ok := decl.(*ast.Call); ok {
+			calls[n.Name.Name] = n
+		}
+	}
+	ctx := &genericBufCtx{
+		descs:    descs,
+		sizes:    make(map[prog.StructKey]genericBufSize),
+		visiting: make(map[prog.StructKey]bool),
+	}
+	for _, c := range prg.Syscalls {
+		n := calls[c.Name]
+		if n == nil {
+			continue
+		}
+		var total genericBufSize
+		var args []string
+		for _, arg := range c.Args {
+			size := ctx.size(arg)
+			total.total += size.total
+			total.generic += size.generic
+			if size.generic != 0 {
+				args = append(args, arg.FieldName())
+			}
+		}
+		if total.generic == 0 || total.generic < comp.opts.GenericBuffers*total.total {
+			continue
+		}
+		comp.warning(n.Pos, WarnGenericBuffers, "syscall %v passes %.0f of %.0f input bytes (%.0f%%)"+
+			" in generic buffers: %v", c.Name, total.generic, total.total,
+			100*total.generic/total.total, strings.Join(args, ", "))
+	}
+}
+
+// genericBufSize is the estimated number of input bytes of a type (total)
+// and how many of them are in generic buffers (generic).
+type genericBufSize struct {
+	total   float64
+	generic float64
+}
+
+// Variable-length buffers and arrays without an upper bound are counted
+// with the typical maximum length used during generation (see randBufLen/randArrayLen).
+const (
+	genericBufVarlenBytes = 256
+	genericBufVarlenElems = 10
+)
+
+type genericBufCtx struct {
+	descs    map[prog.StructKey]*prog.StructDesc
+	sizes    map[prog.StructKey]genericBufSize
+	visiting map[prog.StructKey]bool
+}
+
+func (ctx *genericBufCtx) size(t prog.Type) genericBufSize {
+	if prog.IsPad(t) || typeDir(t) == prog.DirOut {
+		return genericBufSize{}
+	}
+	switch a := t.(type) {
+	case *prog.PtrType:
+		return ctx.size(a.Type)
+	case *prog.VmaType:
+		return genericBufSize{}
+	case *prog.ArrayType:
+		count := float64(a.RangeBegin)
+		if a.Kind == prog.ArrayRandLen {
+			count = genericBufVarlenElems
+		} else if a.RangeBegin != a.RangeEnd {
+			count = float64(a.RangeEnd)
+		}
+		elem := ctx.size(a.Type)
+		return genericBufSize{elem.total * count, elem.generic * count}
+	case *prog.BufferType:
+		size := float64(a.TypeSize)
+		switch {
+		case a.Kind == prog.BufferBlobRange:
+			size = float64(a.RangeEnd)
+		case a.Varlen():
+			size = genericBufVarlenBytes
+		}
+		if a.Kind == prog.BufferBlobRand || a.Kind == prog.BufferBlobRange {
+			return genericBufSize{size, size}
+		}
+		return genericBufSize{size, 0}
+	case *prog.StructType:
+		return ctx.structSize(a.Key, false)
+	case *prog.UnionType:
+		return ctx.structSize(a.Key, true)
+	default:
+		return genericBufSize{float64(t.Size()), 0}
+	}
+}
+
+// structSize returns size of struct/union key, size of a union is the average of its options.
+func (ctx *genericBufCtx) structSize(key prog.StructKey, union bool) genericBufSize {
+	if size, ok := ctx.sizes[key]; ok {
+		return size
+	}
+	desc := ctx.descs[key]
+	if desc == nil || ctx.visiting[key] {
+		return genericBufSize{}
+	}
+	ctx.visiting[key] = true
+	var size genericBufSize
+	for _, f := range desc.Fields {
+		fsize := ctx.size(f)
+		size.total += fsize.total
+		size.generic += fsize.generic
+	}
+	if union && len(desc.Fields) != 0 {
+		size.total /= float64(len(desc.Fields))
+		size.generic /= float64(len(desc.Fields))
+	}
+	delete(ctx.visiting, key)
+	ctx.sizes[key] = size
+	return size
+}
+
+// checkStaticAsserts checks sizes of generated structs and unions against static_assert declarations.
+// Asserts for structs that are not generated (e.g. used only by unsupported syscalls) are ignored.
+func (comp *compiler) checkStaticAsserts(prg *Prog) {
+	descs := make(map[string]*prog.StructDesc)
+	for _, s := range prg.StructDescs {
+		descs[s.Key.Name] = s.Desc
+	}
 	for _, decl := range comp.desc.Nodes {
-		switch decl.(type) {
-		case *ast.Call, *ast.Struct, *ast.Resource, *ast.TypeDef:
-			comp.foreachType(decl, func(t *ast.Type, desc *typeDesc,
-				args []*ast.Type, base prog.IntTypeCommon) {
-				if desc.CheckConsts != nil {
-					desc.CheckConsts(comp, t, args, base)
-				}
-				for i, arg := range args {
-					if check := desc.Args[i].Type.CheckConsts; check != nil {
-						check(comp, arg)
-					}
-				}
-			})
+		n, ok := decl.(*ast.StaticAssert)
+		if !ok {
+			continue
+		}
+		if _, typ, name := n.Info(); comp.unsupported[typ+" "+name+" == "+n.Size.Ident] {
+			continue
+		}
+		desc := descs[n.Name.Name]
+		if desc == nil {
+			continue
+		}
+		s := comp.structs[n.Name.Name]
+		_, typ, name := s.Info()
+		if desc.Varlen() {
+			comp.error(n.Pos, "static_assert on variable-length %v %v declared at %v", typ, name, s.Pos)
+			continue
+		}
+		if size := desc.Size(); size != n.Size.Value {
+			comp.error(n.Pos, "static_assert failed: size of %v %v declared at %v is %v, expected %v",
+				typ, name, s.Pos, size, n.Size.Value)
 		}
 	}
 }
 
-func (comp *compiler) checkAttributeValues() {
-	for _, decl := range comp.desc.Nodes {
-		switch n := decl.(type) {
-		case *ast.Struct:
-			for _, attr := range n.Attrs {
-				if attr.Ident == "size" {
-					_, typ, name := n.Info()
-					if comp.structIsVarlen(n.Name.Name) {
-						comp.error(attr.Pos, "varlen %v %v has size attribute",
-							typ, name)
-					}
-					sz := attr.Args[0].Value
-					if sz == 0 || sz > 1<<20 {
-						comp.error(attr.Args[0].Pos, "size attribute has bad value %v"+
-							", expect [1, 1<<20]", sz)
-					}
-				}
+func (comp *compiler) checkFieldsLenCycles(parent string, fields []prog.Type, nodes []*ast.Field) {
+	targets := make(map[string][]string)
+	varlen := make(map[string]bool)
+	for _, f := range fields {
+		typ := f
+		for {
+			ptr, ok := typ.(*prog.PtrType)
+			if !ok {
+				break
 			}
+			typ = ptr.Type
+		}
+		// Elements of variable-length arrays are len fields targeting the same fields.
+		if arr, ok := typ.(*prog.ArrayType); ok && typ.Varlen() {
+			typ = arr.Type
+			varlen[f.FieldName()] = true
+		}
+		if t, ok := typ.(*prog.LenType); ok {
+			targets[f.FieldName()] = t.Targets()
 		}
 	}
-}
-
-func (comp *compiler) checkLenTargets() {
-	warned := make(map[string]bool)
-	for _, decl := range comp.desc.Nodes {
-		switch n := decl.(type) {
-		case *ast.Call:
-			for _, arg := range n.Args {
-				checked := make(map[string]bool)
-				comp.checkLenType(arg.Type, arg.Name.Name, n.Args, nil, checked, warned, true)
+	reported := make(map[string]bool)
+	for _, fld := range nodes {
+		name := fld.Name.Name
+		if reported[name] {
+			continue
+		}
+		path := findLenCycle([]string{name}, targets, reported, make(map[string]bool))
+		if path == nil {
+			continue
+		}
+		static := true
+		for _, f := range path {
+			if varlen[f] {
+				static = false
 			}
 		}
+		if static {
+			continue
+		}
+		var chain []string
+		for _, f := range append(path, name) {
+			reported[f] = true
+			chain = append(chain, parent+"."+f)
+		}
+		comp.error(fld.Pos, "len target cycle: %v", strings.Join(chain, " -> "))
 	}
 }
 
-func (comp *compiler) checkLenType(t *ast.Type, name string, fields []*ast.Field,
-	parents []string, checked, warned map[string]bool, isArg bool) {
-	desc := comp.getTypeDesc(t)
-	if desc == typeStruct {
-		s := comp.structs[t.Ident]
-		// Prune recursion, can happen even on correct tree via opt pointers.
-		if checked[s.Name.Name] {
-			return
-		}
-		checked[s.Name.Name] = true
-		parentName := s.Name.Name
-		if pos := strings.IndexByte(parentName, '['); pos != -1 {
-			// For template parents name is "struct_name[ARG1, ARG2]", strip the part after '['.
-			parentName = parentName[:pos]
+// findLenCycle returns a path of len targets that starts with path and leads back to path[0],
+// or nil if there is no such path. Len fields that refer to themselves are not cycles.
+func findLenCycle(path []string, targets map[string][]string, reported, visited map[string]bool) []string {
+	for _, next := range targets[path[len(path)-1]] {
+		if reported[next] {
+			continue
 		}
-		parents = append(parents, parentName)
-		if !s.IsUnion {
-			fields = s.Fields
+		if next == path[0] {
+			if len(path) > 1 {
+				return path
+			}
+			continue
 		}
-		for _, fld := range s.Fields {
-			comp.checkLenType(fld.Type, fld.Name.Name, fields, parents, checked, warned, false)
+		if visited[next] {
+			continue
 		}
-		warned[parentName] = true
-		return
-	}
-	_, args, _ := comp.getArgsBase(t, "", prog.DirIn, isArg)
-	for i, arg := range args {
-		argDesc := desc.Args[i]
-		if argDesc.Type == typeArgLenTarget {
-			comp.checkLenTarget(t, name, arg.Ident, fields, parents, warned)
-		} else if argDesc.Type == typeArgType {
-			comp.checkLenType(arg, name, fields, parents, checked, warned, argDesc.IsArg)
+		visited[next] = true
+		if cycle := findLenCycle(append(path, next), targets, reported, visited); cycle != nil {
+			return cycle
 		}
 	}
+	return nil
 }
 
-func (comp *compiler) checkLenTarget(t *ast.Type, name, target string, fields []*ast.Field,
-	parents []string, warned map[string]bool) {
-	if target == name {
-		comp.error(t.Pos, "%v target %v refer to itself", t.Ident, target)
-		return
-	}
-	if target == "parent" {
-		if len(parents) == 0 {
-			comp.error(t.Pos, "%v target %v does not exist", t.Ident, target)
+// checkLenTargetPath checks len targets of the form parent.parent.field.
+// scopes contains fields of the enclosing non-union structs, innermost last.
+// Each parent element moves one struct up, the last element names a field
+// of the reached struct (or the struct itself if it's parent).
+func (comp *compiler) checkLenTargetPath(t *ast.Type, name, target string, scopes [][]*ast.Field) {
+	elems := strings.Split(target, ".")
+	for _, elem := range elems[:len(elems)-1] {
+		if elem != "parent" {
+			comp.error(t.Pos, "%v target %v: only parent can be used before the last path element, not %v",
+				t.Ident, target, elem)
+			return
 		}
+	}
+	level := len(scopes) - len(elems)
+	last := elems[len(elems)-1]
+	if level < 0 {
+		comp.error(t.Pos, "%v target %v goes beyond the outermost struct", t.Ident, target)
 		return
 	}
-	for _, fld := range fields {
-		if target != fld.Name.Name {
-			continue
-		}
-		if fld.Type == t {
-			comp.error(t.Pos, "%v target %v refer to itself", t.Ident, target)
-		}
-		if t.Ident == "len" {
-			inner := fld.Type
-			desc, args, _ := comp.getArgsBase(inner, "", prog.DirIn, false)
-			for desc == typePtr {
-				if desc != typePtr {
-					break
-				}
-				inner = args[1]
-				desc, args, _ = comp.getArgsBase(inner, "", prog.DirIn, false)
-			}
-			if desc == typeArray && comp.isVarlen(args[0]) {
-				// We can reach the same struct multiple times starting from different
-				// syscall arguments. Warn only once.
-				if len(parents) == 0 || !warned[parents[len(parents)-1]] {
-					comp.warning(t.Pos, "len target %v refer to an array with"+
-						" variable-size elements (do you mean bytesize?)", target)
-				}
-			}
-		}
+	if last == "parent" {
 		return
 	}
-	for _, parent := range parents {
-		if target == parent {
+	for _, fld := range scopes[level] {
+		if fld.Name.Name == last {
 			return
 		}
 	}
@@ -398,7 +3225,7 @@ func CollectUnused(desc *ast.Description, target *targets.Target, eh ast.ErrorHa
 func (comp *compiler) collectUnused() []ast.Node {
 	var unused []ast.Node
 
-	comp.used, _, _ = comp.collectUsed(false)
+	comp.used, comp.usedFlags, _ = comp.collectUsed(false)
 	structs, flags, strflags := comp.collectUsed(true)
 	_, _, _ = structs, flags, strflags
 
@@ -443,6 +3270,7 @@ func (comp *compiler) collectUsed(all bool) (structs, flags, strflags map[string
 			}
 			for _, arg := range n.Args {
 				comp.collectUsedType(structs, flags, strflags, arg.Type, true)
+				collectSubkindFlags(flags, arg)
 			}
 			if n.Ret != nil {
 				comp.collectUsedType(structs, flags, strflags, n.Ret, true)
@@ -470,10 +3298,11 @@ func (comp *compiler) collectUsedType(structs, flags, strflags map[string]bool,
 		s := comp.structs[t.Ident]
 		for _, fld := range s.Fields {
 			comp.collectUsedType(structs, flags, strflags, fld.Type, false)
+			collectSubkindFlags(flags, fld)
 		}
 		return
 	}
-	if desc == typeFlags {
+	if desc == typeFlags || desc == typeFlagIndex {
 		flags[t.Args[0].Ident] = true
 		return
 	}
@@ -491,6 +3320,20 @@ func (comp *compiler) collectUsedType(structs, flags, strflags map[string]bool,
 	}
 }
 
+// collectSubkindFlags marks flags referenced in subkind attribute of field f as used.
+func collectSubkindFlags(flags map[string]bool, f *ast.Field) {
+	for _, attr := range f.Attrs {
+		if attr.Ident != "subkind" || len(attr.Args) == 0 {
+			continue
+		}
+		for _, k := range attr.Args[1:] {
+			if len(k.Args) == 1 {
+				flags[k.Args[0].Ident] = true
+			}
+		}
+	}
+}
+
 func (comp *compiler) checkUnused() {
 	for _, n := range comp.collectUnused() {
 		pos, typ, name := n.Info()
@@ -521,7 +3364,7 @@ func (comp *compiler) checkConstructors() {
 		switch n := decl.(type) {
 		case *ast.Resource:
 			name := n.Name.Name
-			if !ctors[name] && comp.used[name] {
+			if !ctors[name] && comp.used[name] && !comp.hasResourceRange(n) {
 				comp.error(n.Pos, "resource %v can't be created"+
 					" (never mentioned as a syscall return value or output argument/field)",
 					name)
@@ -602,6 +3445,61 @@ func (comp *compiler) checkResourceRecursion(n *ast.Resource) {
 	}
 }
 
+// checkResourceCompatibility checks that resources declared as compatible_with
+// each other have the same underlying base type.
+func (comp *compiler) checkResourceCompatibility() {
+	for _, decl := range comp.desc.Nodes {
+		n, ok := decl.(*ast.Resource)
+		if !ok {
+			continue
+		}
+		compatible := comp.parseResourceAttrs(n)
+		if len(compatible) == 0 {
+			continue
+		}
+		base := comp.genResourceBase(n)
+		for _, arg := range compatible {
+			base1 := comp.genResourceBase(comp.resources[arg.Ident])
+			if base == nil || base1 == nil {
+				continue
+			}
+			if base.Size() != base1.Size() || base.Format() != base1.Format() {
+				comp.error(arg.Pos, "resource %v is compatible_with %v, but their base types %v and %v differ",
+					n.Name.Name, arg.Ident, base.Name(), base1.Name())
+			}
+		}
+	}
+}
+
+// hasResourceRange returns if values of resource n are described by a range of the base type.
+func (comp *compiler) hasResourceRange(n *ast.Resource) bool {
+	base, ok := comp.genResourceBase(n).(*prog.IntType)
+	return ok && base.Kind == prog.IntRange
+}
+
+// checkResourceRanges checks that value ranges of index-like resources fit into the base type.
+func (comp *compiler) checkResourceRanges() {
+	for _, decl := range comp.desc.Nodes {
+		n, ok := decl.(*ast.Resource)
+		if !ok || comp.resources[n.Base.Ident] != nil || len(n.Base.Args) == 0 {
+			continue
+		}
+		arg := n.Base.Args[0]
+		base, ok := comp.genResourceBase(n).(*prog.IntType)
+		if !ok {
+			continue
+		}
+		if arg.Value > arg.Value2 {
+			comp.error(arg.Pos, "resource %v has bad range [%v:%v]", n.Name.Name, int64(arg.Value), int64(arg.Value2))
+			continue
+		}
+		if bits := base.Size() * 8; bits < 64 && arg.Value2 >= 1<<bits {
+			comp.error(arg.Pos, "resource %v range [%v:%v] doesn't fit into %v bits",
+				n.Name.Name, arg.Value, arg.Value2, bits)
+		}
+	}
+}
+
 type pathElem struct {
 	Pos    ast.Pos
 	Struct string
@@ -646,8 +3544,8 @@ func (comp *compiler) recurseField(checked map[string]bool, t *ast.Type, path []
 		return
 	}
 	_, args, base := comp.getArgsBase(t, "", prog.DirIn, false)
-	if desc == typePtr && base.IsOptional {
-		return // optional pointers prune recursion
+	if desc == typePtr && base.IsOptional || desc == typeSerialized {
+		return // optional pointers and serialized buffers prune recursion
 	}
 	for i, arg := range args {
 		if desc.Args[i].Type == typeArgType {
@@ -661,8 +3559,11 @@ func (comp *compiler) checkStruct(ctx checkCtx, n *ast.Struct) {
 	if !n.IsUnion {
 		flags |= checkIsStruct
 	}
-	for _, f := range n.Fields {
+	for i, f := range n.Fields {
 		comp.checkType(ctx, f.Type, flags)
+		if f.Type.Ident == "footer" && flags&checkIsStruct != 0 && i != len(n.Fields)-1 {
+			comp.error(f.Pos, "footer %v is not the last field of struct %v", f.Name.Name, n.Name.Name)
+		}
 	}
 	for _, attr := range n.Attrs {
 		if unexpected, _, ok := checkTypeKind(attr, kindIdent); !ok {
@@ -677,7 +3578,10 @@ func (comp *compiler) checkStruct(ctx checkCtx, n *ast.Struct) {
 	if n.IsUnion {
 		comp.parseUnionAttrs(n)
 	} else {
-		comp.parseStructAttrs(n)
+		_, size, _ := comp.parseStructAttrs(n)
+		if size != sizeUnassigned && len(n.Fields) != 0 && n.Fields[len(n.Fields)-1].Type.Ident == "footer" {
+			comp.error(n.Pos, "struct %v with footer can't have size attribute", n.Name.Name)
+		}
 	}
 }
 
@@ -715,6 +3619,22 @@ func (comp *compiler) checkType(ctx checkCtx, t *ast.Type, flags checkFlags) {
 		}
 		return
 	}
+	if desc == typeChoice {
+		err0 := comp.errors
+		comp.replaceChoice(ctx, t, flags)
+		if err0 == comp.errors {
+			comp.checkType(ctx, t, flags)
+		}
+		return
+	}
+	if desc == typeSparse {
+		err0 := comp.errors
+		comp.replaceSparse(ctx, t)
+		if err0 == comp.errors {
+			comp.checkType(ctx, t, flags)
+		}
+		return
+	}
 	err0 := comp.errors
 	comp.checkTypeBasic(t, desc, flags)
 	if err0 != comp.errors {
@@ -759,6 +3679,10 @@ func (comp *compiler) checkTypeBasic(t *ast.Type, desc *typeDesc, flags checkFla
 		comp.error(t.Pos, "%v can't be type alias target", t.Ident)
 		return
 	}
+	if desc == typeFooter && flags&(checkIsStruct|checkIsArg) == 0 {
+		comp.error(t.Pos, "footer can be used only as the last field of a struct")
+		return
+	}
 	if flags&checkIsResourceBase != 0 &&
 		(desc.CanBeResourceBase == nil || !desc.CanBeResourceBase(comp, t)) {
 		comp.error(t.Pos, "%v can't be resource base (int types can)", t.Ident)
@@ -882,6 +3806,106 @@ func (comp *compiler) replaceTypedef(ctx *checkCtx, t *ast.Type, flags checkFlag
 	}
 }
 
+// replaceChoice replaces choice[A, B, ...] with a reference to an implicitly declared varlen union
+// with A, B, ... options (named after the option types), so the chosen alternative determines the size.
+func (comp *compiler) replaceChoice(ctx checkCtx, t *ast.Type, flags checkFlags) {
+	if flags&checkIsArg == 0 || flags&checkIsRet != 0 {
+		comp.error(t.Pos, "choice can be used only as syscall argument")
+		return
+	}
+	if t.HasColon {
+		comp.error(t.Pos2, "unexpected ':'")
+		return
+	}
+	if len(t.Args) < 2 {
+		comp.error(t.Pos, "choice needs at least 2 alternatives, got %v", len(t.Args))
+		return
+	}
+	name := ast.SerializeNode(t)
+	if comp.structs[name] == nil {
+		union := &ast.Struct{
+			Pos:     t.Pos,
+			Name:    &ast.Ident{Pos: t.Pos, Name: name},
+			IsUnion: true,
+			Attrs:   []*ast.Type{{Pos: t.Pos, Ident: "varlen"}},
+		}
+		types := make(map[string]bool)
+		fields := make(map[string]bool)
+		for i, alt := range t.Args {
+			if alt.Ident == "opt" {
+				comp.error(alt.Pos, "choice can't be marked as opt")
+				return
+			}
+			typ := ast.SerializeNode(alt)
+			if types[typ] {
+				comp.error(alt.Pos, "duplicate choice alternative %v", typ)
+				return
+			}
+			types[typ] = true
+			fieldName := alt.Ident
+			if fieldName == "" || strings.Contains(fieldName, "$") || fields[fieldName] {
+				fieldName = fmt.Sprintf("alt%v", i)
+			}
+			fields[fieldName] = true
+			union.Fields = append(union.Fields, &ast.Field{
+				Pos:  alt.Pos,
+				Name: &ast.Ident{Pos: alt.Pos, Name: fieldName},
+				Type: alt,
+			})
+		}
+		comp.checkStruct(ctx, union)
+		comp.desc.Nodes = append(comp.desc.Nodes, union)
+		comp.structs[name] = union
+	}
+	*t = ast.Type{
+		Pos:   t.Pos,
+		Ident: name,
+	}
+}
+
+// replaceSparse replaces sparse[INDEX, VALUE, SIZE] with array[ENTRY, SIZE],
+// where ENTRY is an implicitly declared struct with index and value fields.
+// The struct is shared by all sparse arrays with the same index and value types.
+func (comp *compiler) replaceSparse(ctx checkCtx, t *ast.Type) {
+	if t.HasColon {
+		comp.error(t.Pos2, "unexpected ':'")
+		return
+	}
+	if len(t.Args) != 2 && len(t.Args) != 3 {
+		comp.error(t.Pos, "wrong number of arguments for type sparse, expect index type, value type, [size]")
+		return
+	}
+	index, value := t.Args[0], t.Args[1]
+	if comp.getTypeDesc(index) != typeInt || index.HasColon {
+		comp.error(index.Pos, "sparse index must be an integer type, got %v", ast.SerializeNode(index))
+		return
+	}
+	name := ast.SerializeNode(&ast.Type{Ident: t.Ident, Args: t.Args[:2]})
+	if comp.structs[name] == nil {
+		entry := &ast.Struct{
+			Pos:  t.Pos,
+			Name: &ast.Ident{Pos: t.Pos, Name: name},
+			Fields: []*ast.Field{
+				{Pos: index.Pos, Name: &ast.Ident{Pos: index.Pos, Name: "index"}, Type: index},
+				{Pos: value.Pos, Name: &ast.Ident{Pos: value.Pos, Name: "value"}, Type: value},
+			},
+		}
+		comp.checkStruct(ctx, entry)
+		comp.desc.Nodes = append(comp.desc.Nodes, entry)
+		comp.structs[name] = entry
+		comp.sparse[name] = true
+	}
+	args := []*ast.Type{{Pos: t.Pos, Ident: name}}
+	if len(t.Args) == 3 {
+		args = append(args, t.Args[2])
+	}
+	*t = ast.Type{
+		Pos:   t.Pos,
+		Ident: "array",
+		Args:  args,
+	}
+}
+
 func (comp *compiler) instantiate(templ ast.Node, params []*ast.Ident, args []*ast.Type) bool {
 	if len(params) == 0 {
 		return true
@@ -1042,7 +4066,7 @@ func (comp *compiler) checkVarlen(n *ast.Struct) {
 	// Non-varlen unions can't have varlen fields.
 	// Non-packed structs can't have varlen fields in the middle.
 	if n.IsUnion {
-		if varlen, _ := comp.parseUnionAttrs(n); varlen {
+		if varlen, _, _, _ := comp.parseUnionAttrs(n); varlen {
 			return
 		}
 	} else {
@@ -1050,8 +4074,13 @@ func (comp *compiler) checkVarlen(n *ast.Struct) {
 			return
 		}
 	}
+	last := len(n.Fields) - 1
+	if !n.IsUnion && last > 0 && n.Fields[last].Type.Ident == "footer" {
+		// Footer is placed right after the variable size field.
+		last--
+	}
 	for i, f := range n.Fields {
-		if !n.IsUnion && i == len(n.Fields)-1 {
+		if !n.IsUnion && i == last {
 			break
 		}
 		if comp.isVarlen(f.Type) {
@@ -1125,3 +4154,107 @@ func (comp *compiler) checkDupConstsCall(n *ast.Call, dups map[string]map[string
 	comp.error(n.Pos, "call %v: duplicate const %v, previously used in call %v at %v",
 		n.Name.Name, constArgID, dup.name, dup.pos)
 }
+
+// checkStrictABI does checks enabled by Options.StrictABI that don't depend on struct layout.
+// Implicit padding is detected during generation in walkStruct.
+func (comp *compiler) checkStrictABI() {
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Call:
+			comp.foreachType(n, comp.checkStrictValues)
+			for _, arg := range n.Args {
+				comp.checkStrictInts(arg.Type, true, false)
+			}
+		case *ast.Struct:
+			comp.foreachType(n, comp.checkStrictValues)
+			for _, f := range n.Fields {
+				comp.checkStrictInts(f.Type, false, true)
+			}
+			if n.IsUnion {
+				break
+			}
+			explicit := false
+			for _, attr := range n.Attrs {
+				if attr.Ident == "packed" || strings.HasPrefix(attr.Ident, "align_") {
+					explicit = true
+				}
+			}
+			if !explicit {
+				comp.error(n.Pos, "struct %v does not have explicit packed or align attribute",
+					n.Name.Name)
+			}
+		}
+	}
+}
+
+func (comp *compiler) checkStrictValues(t *ast.Type, desc *typeDesc, args []*ast.Type, base prog.IntTypeCommon) {
+	var values []uint64
+	bits := base.TypeSize * 8
+	if base.BitfieldLen != 0 {
+		bits = base.BitfieldLen
+	}
+	switch desc {
+	case typeConst, typeFooter:
+		values = append(values, args[0].Value)
+	case typeFlags:
+		if f := comp.intFlags[args[0].Ident]; f != nil {
+			values = genIntArray(f.Values)
+		}
+	case typeInt:
+		size, _ := comp.parseIntType(t.Ident)
+		bits = size * 8
+		if t.HasColon {
+			bits = t.Value2
+		}
+		if len(args) != 0 {
+			values = append(values, args[0].Value, args[0].Value2)
+		}
+	default:
+		return
+	}
+	for _, v := range values {
+		if !valueFitsBits(v, bits) {
+			comp.error(t.Pos, "value %v of %v does not fit into %v bits", v, t.Ident, bits)
+			return
+		}
+	}
+}
+
+// checkStrictInts checks integer types stored in memory (struct fields and pointee types
+// of syscall arguments): they must have fixed size and explicit endianness.
+// Syscall arguments themselves are passed in registers, so they are not checked.
+func (comp *compiler) checkStrictInts(t *ast.Type, isArg, inMemory bool) {
+	desc := comp.getTypeDesc(t)
+	args, _ := removeOpt(t)
+	if inMemory {
+		var base *ast.Type
+		if desc == typeInt {
+			base = t
+		} else if desc.NeedBase && len(args) != 0 {
+			base = args[len(args)-1]
+		}
+		if base != nil {
+			size, be := comp.parseIntType(base.Ident)
+			if base.Ident == "intptr" {
+				comp.error(base.Pos, "intptr has arch-dependent size")
+			} else if size > 1 && !be && !strings.HasSuffix(base.Ident, "le") {
+				comp.error(base.Pos, "%v does not have explicit endianness, use %vle or %vbe",
+					base.Ident, base.Ident, base.Ident)
+			}
+		}
+	}
+	desc, args, _ = comp.getArgsBase(t, "", prog.DirIn, isArg)
+	for i, arg := range args {
+		if desc.Args[i].Type == typeArgType {
+			comp.checkStrictInts(arg, desc.Args[i].IsArg, true)
+		}
+	}
+}
+
+// valueFitsBits returns true if v can be represented as either signed or unsigned integer of size bits.
+func valueFitsBits(v, bits uint64) bool {
+	if bits >= 64 {
+		return true
+	}
+	return v < 1<<bits || int64(v) < 0 && int64(v) >= -(1<<(bits-1))
+}
@@ -7,6 +7,8 @@ package compiler
 
 import (
 	"fmt"
+	"path"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -19,30 +21,119 @@ import (
 // Overview of compilation process:
 // 1. ast.Parse on text file does tokenization and builds AST.
 //    This step catches basic syntax errors. AST contains full debug info.
-// 2. ExtractConsts as AST returns set of constant identifiers.
+// 2. ast.ExpandMacros replaces macro expansions with declarations from macro bodies
+//    and lowerRegBlocks replaces register blocks with packed structs
+//    (done as the first step of Compile).
+// 3. ExtractConsts as AST returns set of constant identifiers.
 //    This step also does verification of include/incdir/define AST nodes.
-// 3. User translates constants to values.
-// 4. Compile on AST and const values does the rest of the work and returns Prog
+// 4. User translates constants to values.
+// 5. Compile on AST and const values does the rest of the work and returns Prog
 //    containing generated prog objects.
-// 4.1. assignSyscallNumbers: uses consts to assign syscall numbers.
+// 5.1. assignSyscallNumbers: uses consts to assign syscall numbers.
 //      This step also detects unsupported syscalls and discards no longer
 //      needed AST nodes (inlcude, define, comments, etc).
-// 4.2. patchConsts: patches Int nodes referring to consts with corresponding values.
+// 5.2. patchConsts: patches Int nodes referring to consts with corresponding values.
 //      Also detects unsupported syscalls, structs, resources due to missing consts.
-// 4.3. check: does extensive semantical checks of AST.
-// 4.4. gen: generates prog objects from AST.
+// 5.3. check: does extensive semantical checks of AST.
+// 5.4. gen: generates prog objects from AST.
 
 // Prog is description compilation result.
 type Prog struct {
 	Resources   []*prog.ResourceDesc
 	Syscalls    []*prog.Syscall
 	StructDescs []*prog.KeyedStruct
+	Flags       []prog.FlagDesc
 	// Set of unsupported syscalls/flags.
 	Unsupported map[string]bool
+	// Filled in if Options.Stats is set.
+	Stats *Stats
+	// Filled in if Options.Metadata is set.
+	Metadata []*CallMetadata
+	// Filled in if Options.Complexity is set.
+	Complexity []*SubsystemComplexity
+	// Filled in if Options.Hash is set.
+	Hash string
+	// Filled in if Options.Ctypes is set.
+	Ctypes []byte
+	// Filled in if Options.TypeDump is set.
+	TypeDump []byte
+	// Filled in if Options.SourceMap is set.
+	SourceMap *SourceMap
+	// Filled in if Options.InterfaceOnly is set.
+	Interface *Interface
 	// Returned if consts was nil.
 	fileConsts map[string]*ConstInfo
 }
 
+// Options control optional compilation modes, the zero value corresponds to the default mode.
+type Options struct {
+	// StrictABI makes implicit layout decisions errors. In this mode structs must have explicit
+	// packed or align_N attribute and must not need implicit padding. Integers stored in memory
+	// (struct fields and pointee types) must not be intptr (its width depends on arch) and
+	// integers wider than 1 byte must have explicit endianness (le or be suffix).
+	// Values that would be silently truncated are errors as well: const/flags/range values
+	// must fit into their base types and len fields must fit the largest length of their
+	// bounded sibling target fields.
+	StrictABI bool
+	// Stats fills in Prog.Stats with statistics about the compiled descriptions.
+	Stats bool
+	// Metadata fills in Prog.Metadata with descriptions of the compiled calls.
+	Metadata bool
+	// Complexity fills in Prog.Complexity with complexity metrics of descriptions of subsystems.
+	// Calls are grouped into subsystems by the longest matching prefix of their names
+	// in Subsystems, or by names of their description files (see SubsystemComplexity).
+	Complexity bool
+	Subsystems []string
+	// Hash fills in Prog.Hash with a stable hash of the compiled descriptions and consts,
+	// it can be used to check that different components use the same descriptions.
+	Hash bool
+	// Ctypes fills in Prog.Ctypes with Python ctypes definitions of the compiled structs and unions.
+	Ctypes bool
+	// TypeDump fills in Prog.TypeDump with a text dump of type descriptors, arguments and base types
+	// that were chosen for all types of the compiled declarations (useful for debugging descriptions).
+	TypeDump bool
+	// SourceMap fills in Prog.SourceMap with positions of description constructs
+	// that the compiled types were generated from.
+	SourceMap bool
+	// MaxArgSize overrides targets.Target.MaxArgSize: maximum total size in bytes
+	// of data referenced by a single syscall argument (0 means the target limit).
+	MaxArgSize uint64
+	// IncludeCalls is a list of glob patterns (as in path.Match) over call names (e.g. "ioctl$KVM*").
+	// If not empty, only calls that match at least one of the patterns are compiled.
+	// Calls that match any of ExcludeCalls patterns are not compiled.
+	// Calls that require resources that none of the remaining calls can create are not compiled
+	// as well (see unusableCalls). Resources and structs that are not used by the remaining calls
+	// are pruned from the result.
+	IncludeCalls []string
+	ExcludeCalls []string
+	// RetainedConsts are consts that are intentionally kept in const files (e.g. used only
+	// by the executor), they are not reported as unused.
+	RetainedConsts map[string]bool
+	// ConstPositions are positions of consts in const files (see DeserializeConstsGlobPos),
+	// warnings about unused consts are reported at these positions.
+	ConstPositions map[string]ast.Pos
+	// ForbidIncomplete makes declarations marked with incomplete attribute errors.
+	// All such declarations are reported, this is meant to be used in CI before release.
+	ForbidIncomplete bool
+	// StrictResources makes resources that don't have at least one producer and one consumer
+	// among the compiled syscalls errors (see checkResourceUsage), this is meant to be used in CI.
+	StrictResources bool
+	// GenericBuffers, if positive, enables warnings about syscalls where at least this fraction
+	// (0 to 1) of input bytes is passed in generic byte buffers (see checkGenericBuffers).
+	GenericBuffers float64
+	// InterfaceOnly fills in only Prog.Interface and Prog.Unsupported,
+	// syscalls, structs and other types are checked, but not generated.
+	InterfaceOnly bool
+	// Diagnostics, if set, is called for every error and warning (see Diagnostic).
+	Diagnostics func(d *Diagnostic)
+}
+
+// SerializeBinary serializes the compiled descriptions into compact binary form
+// that can be loaded with prog.DeserializeDescriptions.
+func (prg *Prog) SerializeBinary() []byte {
+	return prog.SerializeDescriptions(prg.Resources, prg.Syscalls, prg.StructDescs)
+}
+
 func createCompiler(desc *ast.Description, target *targets.Target, eh ast.ErrorHandler) *compiler {
 	if eh == nil {
 		eh = ast.LoggingHandler
@@ -58,11 +149,14 @@ func createCompiler(desc *ast.Description, target *targets.Target, eh ast.ErrorH
 		structs:      make(map[string]*ast.Struct),
 		intFlags:     make(map[string]*ast.IntFlags),
 		strFlags:     make(map[string]*ast.StrFlags),
+		sparse:       make(map[string]bool),
 		used:         make(map[string]bool),
 		usedTypedefs: make(map[string]bool),
+		usedConsts:   make(map[string]bool),
 		structDescs:  make(map[prog.StructKey]*prog.StructDesc),
 		structNodes:  make(map[*prog.StructDesc]*ast.Struct),
 		structVarlen: make(map[string]bool),
+		strictLayout: make(map[*ast.Struct]bool),
 	}
 	for name, n := range builtinTypedefs {
 		comp.typedefs[name] = n
@@ -76,7 +170,70 @@ func createCompiler(desc *ast.Description, target *targets.Target, eh ast.ErrorH
 
 // Compile compiles sys description.
 func Compile(desc *ast.Description, consts map[string]uint64, target *targets.Target, eh ast.ErrorHandler) *Prog {
+	return CompileOpts(desc, consts, target, eh, Options{})
+}
+
+// CompileOpts is the same as Compile, but allows to enable optional compilation modes.
+func CompileOpts(desc *ast.Description, consts map[string]uint64, target *targets.Target,
+	eh ast.ErrorHandler, opts Options) *Prog {
+	if consts != nil && (len(opts.IncludeCalls) != 0 || len(opts.ExcludeCalls) != 0) {
+		opts.ExcludeCalls = append(unusableCalls(desc, consts, target, opts), opts.ExcludeCalls...)
+	}
+	return compile(desc, consts, target, eh, opts)
+}
+
+// unusableCalls returns names of calls that remain after IncludeCalls/ExcludeCalls filtering,
+// but can't be used because resources they require can't be created by the remaining calls.
+// The calls are determined by compiling the remaining calls and disabling calls the same way
+// prog.Target.TransitivelyEnabledCalls does at runtime.
+func unusableCalls(desc *ast.Description, consts map[string]uint64, target *targets.Target,
+	opts Options) []string {
+	// Errors are reported by the main compilation.
+	prg := compile(desc, consts, target, func(pos ast.Pos, msg string) {}, Options{
+		IncludeCalls: opts.IncludeCalls,
+		ExcludeCalls: opts.ExcludeCalls,
+		MaxArgSize:   opts.MaxArgSize,
+	})
+	if prg == nil {
+		return nil
+	}
+	t := &prog.Target{
+		OS:         target.OS,
+		Arch:       target.Arch,
+		PtrSize:    target.PtrSize,
+		PageSize:   target.PageSize,
+		NumPages:   target.NumPages,
+		DataOffset: target.DataOffset,
+		Syscalls:   prg.Syscalls,
+		Resources:  prg.Resources,
+		Structs:    prg.StructDescs,
+	}
+	prog.InitUnregisteredTarget(t, func(*prog.Target) {})
+	enabled := make(map[*prog.Syscall]bool)
+	for _, c := range t.Syscalls {
+		enabled[c] = true
+	}
+	_, disabled := t.TransitivelyEnabledCalls(enabled)
+	var names []string
+	for c := range disabled {
+		names = append(names, c.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func compile(desc *ast.Description, consts map[string]uint64, target *targets.Target,
+	eh ast.ErrorHandler, opts Options) *Prog {
 	comp := createCompiler(desc.Clone(), target, eh)
+	comp.opts = opts
+	comp.phase = PhaseMacros
+	comp.expandMacros()
+	if comp.errors != 0 {
+		return nil
+	}
+	// Erroneous registers are dropped, so typecheck can proceed to report more errors.
+	comp.lowerRegBlocks()
+	comp.phase = PhaseTypecheck
 	comp.typecheck()
 	// The subsequent, more complex, checks expect basic validity of the tree,
 	// in particular corrent number of type arguments. If there were errors,
@@ -84,6 +241,7 @@ func Compile(desc *ast.Description, consts map[string]uint64, target *targets.Ta
 	if comp.errors != 0 {
 		return nil
 	}
+	comp.phase = PhaseConsts
 	if consts == nil {
 		fileConsts := comp.extractConsts()
 		if comp.errors != 0 {
@@ -95,6 +253,11 @@ func Compile(desc *ast.Description, consts map[string]uint64, target *targets.Ta
 		comp.assignSyscallNumbers(consts)
 	}
 	comp.patchConsts(consts)
+	comp.checkUnusedConsts(consts)
+	comp.filterCalls()
+	comp.filterRequiringCalls()
+	comp.checkZeroConsts()
+	comp.phase = PhaseCheck
 	comp.check()
 	if comp.errors != 0 {
 		return nil
@@ -102,16 +265,68 @@ func Compile(desc *ast.Description, consts map[string]uint64, target *targets.Ta
 	for _, w := range comp.warnings {
 		eh(w.pos, w.msg)
 	}
+	nwarnings := len(comp.warnings)
+	comp.phase = PhaseGen
+	if opts.InterfaceOnly {
+		return &Prog{
+			Interface:   comp.genInterface(consts),
+			Unsupported: comp.unsupported,
+		}
+	}
+	if opts.SourceMap {
+		comp.typePos = make(map[prog.Type]ast.Pos)
+	}
 	syscalls := comp.genSyscalls()
 	prg := &Prog{
 		Resources:   comp.genResources(),
 		Syscalls:    syscalls,
 		StructDescs: comp.genStructDescs(syscalls),
+		Flags:       comp.genFlags(),
 		Unsupported: comp.unsupported,
 	}
+	comp.checkLenCycles(prg)
+	comp.checkArgSizes(prg)
+	comp.checkStaticAsserts(prg)
+	comp.checkOverlayUnions(prg)
+	comp.checkUnitSizes(prg)
+	if opts.StrictResources {
+		comp.checkResourceUsage(prg)
+	}
 	if comp.errors != 0 {
 		return nil
 	}
+	// Direction, pure getter and reachability checks produce only warnings.
+	comp.checkPtrDirs(prg)
+	comp.checkPureGetters(prg)
+	comp.checkUnreachableOptions(prg)
+	comp.checkStructDirs(prg)
+	if opts.GenericBuffers > 0 {
+		comp.checkGenericBuffers(prg)
+	}
+	for _, w := range comp.warnings[nwarnings:] {
+		eh(w.pos, w.msg)
+	}
+	if opts.Stats {
+		prg.Stats = comp.genStats(prg)
+	}
+	if opts.Metadata {
+		prg.Metadata = comp.genMetadata(prg)
+	}
+	if opts.Complexity {
+		prg.Complexity = comp.genComplexity(prg, opts.Subsystems)
+	}
+	if opts.Hash {
+		prg.Hash = prg.genHash(consts)
+	}
+	if opts.Ctypes {
+		prg.Ctypes = comp.genCtypes(prg)
+	}
+	if opts.TypeDump {
+		prg.TypeDump = comp.genTypeDump()
+	}
+	if opts.SourceMap {
+		prg.SourceMap = comp.genSourceMap(prg)
+	}
 	return prg
 }
 
@@ -119,9 +334,12 @@ type compiler struct {
 	desc     *ast.Description
 	target   *targets.Target
 	eh       ast.ErrorHandler
+	opts     Options
+	phase    string // current compilation phase, used as category of errors
 	errors   int
 	warnings []warn
 	ptrSize  uint64
+	compat   bool // generating a compat syscall, see genSyscall
 
 	unsupported  map[string]bool
 	resources    map[string]*ast.Resource
@@ -129,12 +347,17 @@ type compiler struct {
 	structs      map[string]*ast.Struct
 	intFlags     map[string]*ast.IntFlags
 	strFlags     map[string]*ast.StrFlags
+	sparse       map[string]bool // implicit entry structs of sparse arrays, see replaceSparse
 	used         map[string]bool // contains used structs/resources
+	usedFlags    map[string]bool
 	usedTypedefs map[string]bool
+	usedConsts   map[string]bool
 
 	structDescs  map[prog.StructKey]*prog.StructDesc
 	structNodes  map[*prog.StructDesc]*ast.Struct
 	structVarlen map[string]bool
+	strictLayout map[*ast.Struct]bool
+	typePos      map[prog.Type]ast.Pos // filled in only if Options.SourceMap is set
 }
 
 type warn struct {
@@ -142,13 +365,225 @@ type warn struct {
 	msg string
 }
 
+func (comp *compiler) expandMacros() {
+	desc := ast.ExpandMacros(comp.desc, func(pos ast.Pos, msg string) {
+		comp.error(pos, "%v", msg)
+	})
+	if desc != nil {
+		comp.desc = desc
+	}
+}
+
+// lowerRegBlocks replaces register blocks with packed structs: registers without bits
+// become fields of the register type, bits of a register become bitfields of the register type
+// named REG_BITS followed by a zero REG_reserved bitfield for the remaining bits of the register.
+func (comp *compiler) lowerRegBlocks() {
+	for i, decl := range comp.desc.Nodes {
+		blk, ok := decl.(*ast.RegBlock)
+		if !ok {
+			continue
+		}
+		str := &ast.Struct{
+			Pos:      blk.Pos,
+			Name:     blk.Name,
+			Attrs:    append([]*ast.Type{{Pos: blk.Pos, Ident: "packed"}}, blk.Attrs...),
+			Comments: blk.Comments,
+		}
+		for _, reg := range blk.Regs {
+			str.Fields = append(str.Fields, comp.lowerRegister(reg)...)
+		}
+		comp.desc.Nodes[i] = str
+	}
+}
+
+func (comp *compiler) lowerRegister(reg *ast.Register) []*ast.Field {
+	if len(reg.Bits) == 0 {
+		return []*ast.Field{{
+			Pos:      reg.Pos,
+			Name:     reg.Name,
+			Type:     reg.Type,
+			Comments: reg.Comments,
+		}}
+	}
+	t := reg.Type
+	if !arrayContains(typeArgBase.Type.Names, t.Ident) || t.HasColon || len(t.Args) != 0 {
+		comp.error(t.Pos, "register %v with bits must have an int type without arguments", reg.Name.Name)
+		return nil
+	}
+	size, _ := comp.parseIntType(t.Ident)
+	bitfield := func(pos ast.Pos, width uint64) *ast.Type {
+		return &ast.Type{
+			Pos:      pos,
+			Ident:    t.Ident,
+			HasColon: true,
+			Pos2:     pos,
+			Value2:   width,
+		}
+	}
+	var fields []*ast.Field
+	used := uint64(0)
+	for _, bits := range reg.Bits {
+		if bits.Width.Ident != "" {
+			comp.error(bits.Width.Pos, "register bits %v width must be a literal", bits.Name.Name)
+			return nil
+		}
+		used += bits.Width.Value
+		fields = append(fields, &ast.Field{
+			Pos:  bits.Pos,
+			Name: &ast.Ident{Pos: bits.Name.Pos, Name: reg.Name.Name + "_" + bits.Name.Name},
+			Type: bitfield(bits.Width.Pos, bits.Width.Value),
+		})
+	}
+	if used > size*8 {
+		comp.error(reg.Pos, "bits of register %v take %v bits, but the register has only %v",
+			reg.Name.Name, used, size*8)
+		return nil
+	}
+	if used < size*8 {
+		fields = append(fields, &ast.Field{
+			Pos:  reg.Pos,
+			Name: &ast.Ident{Pos: reg.Pos, Name: reg.Name.Name + "_reserved"},
+			Type: &ast.Type{
+				Pos:   reg.Pos,
+				Ident: "const",
+				Args: []*ast.Type{
+					{Pos: reg.Pos, Value: 0},
+					bitfield(reg.Pos, size*8-used),
+				},
+			},
+		})
+	}
+	fields[0].Comments = reg.Comments
+	return fields
+}
+
 func (comp *compiler) error(pos ast.Pos, msg string, args ...interface{}) {
 	comp.errors++
-	comp.eh(pos, fmt.Sprintf(msg, args...))
+	msg = fmt.Sprintf(msg, args...)
+	comp.eh(pos, msg)
+	comp.diagnostic(SeverityError, comp.phase, pos, msg)
 }
 
-func (comp *compiler) warning(pos ast.Pos, msg string, args ...interface{}) {
-	comp.warnings = append(comp.warnings, warn{pos, fmt.Sprintf(msg, args...)})
+// warning records a warning, warnings are passed to the error handler only if compilation succeeds,
+// but Options.Diagnostics receives them immediately.
+func (comp *compiler) warning(pos ast.Pos, category, msg string, args ...interface{}) {
+	msg = fmt.Sprintf(msg, args...)
+	comp.warnings = append(comp.warnings, warn{pos, msg})
+	comp.diagnostic(SeverityWarning, category, pos, msg)
+}
+
+// filterCalls discards calls that don't match IncludeCalls/ExcludeCalls options.
+// Discarded calls are marked the same way as unsupported calls, so resources and structs
+// used only by them are not considered used and are not generated.
+func (comp *compiler) filterCalls() {
+	include, exclude := comp.opts.IncludeCalls, comp.opts.ExcludeCalls
+	if len(include) == 0 && len(exclude) == 0 {
+		return
+	}
+	for _, pattern := range append(append([]string{}, include...), exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			comp.error(ast.Pos{}, "bad call name pattern %q: %v", pattern, err)
+			return
+		}
+	}
+	for _, decl := range comp.desc.Nodes {
+		c, ok := decl.(*ast.Call)
+		if !ok {
+			continue
+		}
+		if comp.callFilteredOut(c.Name.Name) {
+			c.NR = ^uint64(0) // mark as unused to not generate it
+		}
+	}
+}
+
+// callFilteredOut returns true if the syscall is discarded by IncludeCalls/ExcludeCalls options.
+func (comp *compiler) callFilteredOut(name string) bool {
+	include, exclude := comp.opts.IncludeCalls, comp.opts.ExcludeCalls
+	return len(include) != 0 && !matchCallName(include, name) || matchCallName(exclude, name)
+}
+
+// filterRequiringCalls marks syscalls that require (see requires attribute) unused syscalls
+// as unused as well, so that they are not generated without the required syscalls.
+// Similarly, completion syscalls (see completes attribute) are marked as unused
+// if all syscalls they complete are unused.
+func (comp *compiler) filterRequiringCalls() {
+	calls := make(map[string]*ast.Call)
+	for _, decl := range comp.desc.Nodes {
+		if c, ok := decl.(*ast.Call); ok {
+			calls[c.Name.Name] = c
+		}
+	}
+	// Calls that are discarded because they require calls discarded by IncludeCalls/ExcludeCalls,
+	// they are not reported as unsupported.
+	filtered := make(map[string]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, decl := range comp.desc.Nodes {
+			c, ok := decl.(*ast.Call)
+			if !ok || c.NR == ^uint64(0) {
+				continue
+			}
+			for _, arg := range callAttrCalls(c, "requires") {
+				req := calls[arg.Ident]
+				if req == nil || req.NR != ^uint64(0) {
+					continue
+				}
+				c.NR = ^uint64(0)
+				changed = true
+				name := "syscall " + c.Name.Name
+				if filtered[req.Name.Name] || comp.callFilteredOut(req.Name.Name) {
+					filtered[c.Name.Name] = true
+				} else if !comp.unsupported[name] {
+					comp.unsupported[name] = true
+					comp.warning(c.Pos, WarnUnsupported,
+						"unsupported syscall: %v due to unsupported required syscall %v",
+						c.Name.Name, req.Name.Name)
+				}
+				break
+			}
+			if c.NR == ^uint64(0) {
+				continue
+			}
+			subs := callAttrCalls(c, "completes")
+			if len(subs) == 0 {
+				continue
+			}
+			unused, allFiltered := true, true
+			for _, arg := range subs {
+				sub := calls[arg.Ident]
+				if sub == nil || sub.NR != ^uint64(0) {
+					unused = false
+					break
+				}
+				if !filtered[sub.Name.Name] && !comp.callFilteredOut(sub.Name.Name) {
+					allFiltered = false
+				}
+			}
+			if !unused {
+				continue
+			}
+			c.NR = ^uint64(0)
+			changed = true
+			name := "syscall " + c.Name.Name
+			if allFiltered {
+				filtered[c.Name.Name] = true
+			} else if !comp.unsupported[name] {
+				comp.unsupported[name] = true
+				comp.warning(c.Pos, WarnUnsupported,
+					"unsupported syscall: %v due to unsupported completed syscalls", c.Name.Name)
+			}
+		}
+	}
+}
+
+func matchCallName(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
 }
 
 func (comp *compiler) structIsVarlen(name string) bool {
@@ -157,7 +592,7 @@ func (comp *compiler) structIsVarlen(name string) bool {
 	}
 	s := comp.structs[name]
 	if s.IsUnion {
-		if varlen, _ := comp.parseUnionAttrs(s); varlen {
+		if varlen, _, _, _ := comp.parseUnionAttrs(s); varlen {
 			comp.structVarlen[name] = true
 			return true
 		}
@@ -174,9 +609,14 @@ func (comp *compiler) structIsVarlen(name string) bool {
 	return varlen
 }
 
-func (comp *compiler) parseUnionAttrs(n *ast.Struct) (varlen bool, size uint64) {
+func (comp *compiler) parseUnionAttrs(n *ast.Struct) (varlen bool, size uint64, versionField string, overlay bool) {
 	size = sizeUnassigned
+	seen := make(map[string]bool)
 	for _, attr := range n.Attrs {
+		if seen[attr.Ident] {
+			comp.error(attr.Pos, "duplicate %v attribute of union %v", attr.Ident, n.Name.Name)
+		}
+		seen[attr.Ident] = true
 		switch attr.Ident {
 		case "varlen":
 			if len(attr.Args) != 0 {
@@ -185,6 +625,24 @@ func (comp *compiler) parseUnionAttrs(n *ast.Struct) (varlen bool, size uint64)
 			varlen = true
 		case "size":
 			size = comp.parseSizeAttr(attr)
+		case "versioned":
+			if len(attr.Args) != 1 {
+				comp.error(attr.Pos, "%v attribute is expected to have 1 argument", attr.Ident)
+				continue
+			}
+			fld := attr.Args[0]
+			if fld.Ident == "" || fld.HasString || fld.HasColon || fld.Ident2 != "" || len(fld.Args) != 0 {
+				comp.error(fld.Pos, "%v attribute argument must be a field name", attr.Ident)
+				continue
+			}
+			versionField = fld.Ident
+		case "overlay":
+			if len(attr.Args) != 0 {
+				comp.error(attr.Pos, "%v attribute has args", attr.Ident)
+			}
+			overlay = true
+		case attrIncomplete:
+			comp.parseIncompleteAttr(attr)
 		default:
 			comp.error(attr.Pos, "unknown union %v attribute %v",
 				n.Name.Name, attr.Ident)
@@ -195,7 +653,15 @@ func (comp *compiler) parseUnionAttrs(n *ast.Struct) (varlen bool, size uint64)
 
 func (comp *compiler) parseStructAttrs(n *ast.Struct) (packed bool, size, align uint64) {
 	size = sizeUnassigned
+	seen := make(map[string]bool)
+	var aligns []*ast.Type
 	for _, attr := range n.Attrs {
+		if seen[attr.Ident] {
+			comp.error(attr.Pos, "duplicate %v attribute of struct %v", attr.Ident, n.Name.Name)
+		} else if strings.HasPrefix(attr.Ident, "align_") {
+			aligns = append(aligns, attr)
+		}
+		seen[attr.Ident] = true
 		switch {
 		case attr.Ident == "packed":
 			if len(attr.Args) != 0 {
@@ -224,14 +690,780 @@ func (comp *compiler) parseStructAttrs(n *ast.Struct) (packed bool, size, align
 			align = a
 		case attr.Ident == "size":
 			size = comp.parseSizeAttr(attr)
+		case attr.Ident == attrIncomplete:
+			comp.parseIncompleteAttr(attr)
 		default:
 			comp.error(attr.Pos, "unknown struct %v attribute %v",
 				n.Name.Name, attr.Ident)
 		}
 	}
+	if len(aligns) > 1 {
+		// Otherwise the last alignment silently wins.
+		var names []string
+		for _, attr := range aligns {
+			names = append(names, attr.Ident)
+		}
+		comp.error(aligns[1].Pos, "struct %v has conflicting attributes %v",
+			n.Name.Name, strings.Join(names, ", "))
+	}
+	return
+}
+
+func (comp *compiler) parseResourceAttrs(n *ast.Resource) (compatible []*ast.Type) {
+	for _, attr := range n.Attrs {
+		switch attr.Ident {
+		case "compatible_with":
+			if len(attr.Args) == 0 {
+				comp.error(attr.Pos, "%v attribute is expected to have arguments", attr.Ident)
+			}
+			compatible = append(compatible, attr.Args...)
+		case "refcounted", "event":
+			if len(attr.Args) != 0 {
+				comp.error(attr.Pos, "%v attribute has args", attr.Ident)
+			}
+		case "scoped":
+			if len(attr.Args) != 1 {
+				comp.error(attr.Pos, "%v attribute is expected to have 1 argument", attr.Ident)
+				continue
+			}
+			if arg := attr.Args[0]; !isScopeName(arg) {
+				comp.error(arg.Pos, "%v attribute argument must be a scope name", attr.Ident)
+			}
+		case "namespace":
+			if len(attr.Args) != 1 {
+				comp.error(attr.Pos, "%v attribute is expected to have 1 argument", attr.Ident)
+				continue
+			}
+			if arg := attr.Args[0]; !isScopeName(arg) {
+				comp.error(arg.Pos, "%v attribute argument must be a namespace name", attr.Ident)
+			}
+		case attrIncomplete:
+			comp.parseIncompleteAttr(attr)
+		default:
+			comp.error(attr.Pos, "unknown resource %v attribute %v",
+				n.Name.Name, attr.Ident)
+		}
+	}
+	return
+}
+
+func isRefcounted(n *ast.Resource) bool {
+	for _, attr := range n.Attrs {
+		if attr.Ident == "refcounted" {
+			return true
+		}
+	}
+	return false
+}
+
+func isEvent(n *ast.Resource) bool {
+	for _, attr := range n.Attrs {
+		if attr.Ident == "event" {
+			return true
+		}
+	}
+	return false
+}
+
+// resourceScope returns name of the scope of the resource (see scoped attribute),
+// or an empty string if the resource is not scoped.
+func resourceScope(n *ast.Resource) string {
+	for _, attr := range n.Attrs {
+		if attr.Ident == "scoped" && len(attr.Args) == 1 && isScopeName(attr.Args[0]) {
+			return attr.Args[0].Ident
+		}
+	}
+	return ""
+}
+
+// resourceNamespace returns name of the namespace of the resource (see namespace attribute),
+// or an empty string if the resource is global.
+func resourceNamespace(n *ast.Resource) string {
+	for _, attr := range n.Attrs {
+		if attr.Ident == "namespace" && len(attr.Args) == 1 && isScopeName(attr.Args[0]) {
+			return attr.Args[0].Ident
+		}
+	}
+	return ""
+}
+
+func isScopeName(t *ast.Type) bool {
+	return t.Ident != "" && !t.HasString && !t.HasColon && t.Ident2 == "" && len(t.Args) == 0
+}
+
+// hasSideEffects returns true if the call is marked with side_effects attribute,
+// i.e. it is not a pure getter even if it looks like one (see checkPureGetters).
+func hasSideEffects(n *ast.Call) bool {
+	for _, attr := range n.Attrs {
+		if attr.Ident == "side_effects" {
+			return true
+		}
+	}
+	return false
+}
+
+// Number of retries of a failed call with retry attribute without arguments,
+// and the maximum number of retries that can be requested explicitly.
+const (
+	defaultCallRetries = 3
+	maxCallRetries     = 10
+)
+
+func (comp *compiler) parseCallAttrs(n *ast.Call) (retries int, group string, compat, noCover bool,
+	requires, completes []string, scopeBegin, scopeEnd, setNamespace string) {
+	seen := make(map[string]bool)
+	for _, attr := range n.Attrs {
+		if seen[attr.Ident] {
+			comp.error(attr.Pos, "duplicate %v attribute of syscall %v", attr.Ident, n.Name.Name)
+		}
+		seen[attr.Ident] = true
+		switch attr.Ident {
+		case "retry":
+			if len(attr.Args) == 0 {
+				retries = defaultCallRetries
+				continue
+			}
+			if len(attr.Args) != 1 {
+				comp.error(attr.Pos, "%v attribute is expected to have at most 1 argument", attr.Ident)
+				continue
+			}
+			r := attr.Args[0]
+			if r.Ident != "" || r.HasString || r.HasColon || len(r.Args) != 0 {
+				comp.error(r.Pos, "%v attribute argument must be an integer", attr.Ident)
+				continue
+			}
+			if r.Value == 0 || r.Value > maxCallRetries {
+				comp.error(r.Pos, "%v attribute value %v is out of range [1:%v]",
+					attr.Ident, r.Value, maxCallRetries)
+				continue
+			}
+			retries = int(r.Value)
+		case "atomic":
+			if len(attr.Args) != 1 {
+				comp.error(attr.Pos, "%v attribute is expected to have 1 argument", attr.Ident)
+				continue
+			}
+			g := attr.Args[0]
+			if g.Ident == "" || g.HasString || g.HasColon || g.Ident2 != "" || len(g.Args) != 0 {
+				comp.error(g.Pos, "%v attribute argument must be a group name", attr.Ident)
+				continue
+			}
+			group = g.Ident
+		case "compat":
+			if len(attr.Args) != 0 {
+				comp.error(attr.Pos, "%v attribute has args", attr.Ident)
+				continue
+			}
+			compat = true
+		case "nocover":
+			if len(attr.Args) != 0 {
+				comp.error(attr.Pos, "%v attribute has args", attr.Ident)
+				continue
+			}
+			noCover = true
+		case "requires", "completes":
+			if len(attr.Args) == 0 {
+				comp.error(attr.Pos, "%v attribute is expected to have at least 1 argument", attr.Ident)
+				continue
+			}
+			for _, arg := range attr.Args {
+				if arg.Ident == "" || arg.HasString || arg.HasColon || len(arg.Args) != 0 {
+					comp.error(arg.Pos, "%v attribute argument must be a syscall name", attr.Ident)
+					continue
+				}
+				if attr.Ident == "requires" {
+					requires = append(requires, arg.Ident)
+				} else {
+					completes = append(completes, arg.Ident)
+				}
+			}
+		case "scope_begin", "scope_end":
+			if len(attr.Args) != 1 {
+				comp.error(attr.Pos, "%v attribute is expected to have 1 argument", attr.Ident)
+				continue
+			}
+			scope := attr.Args[0]
+			if !isScopeName(scope) {
+				comp.error(scope.Pos, "%v attribute argument must be a scope name", attr.Ident)
+				continue
+			}
+			if attr.Ident == "scope_begin" {
+				scopeBegin = scope.Ident
+			} else {
+				scopeEnd = scope.Ident
+			}
+			if scopeBegin == scopeEnd {
+				comp.error(attr.Pos, "syscall %v both begins and ends scope %v", n.Name.Name, scope.Ident)
+			}
+		case "setns":
+			if len(attr.Args) != 1 {
+				comp.error(attr.Pos, "%v attribute is expected to have 1 argument", attr.Ident)
+				continue
+			}
+			ns := attr.Args[0]
+			if !isScopeName(ns) {
+				comp.error(ns.Pos, "%v attribute argument must be a namespace name", attr.Ident)
+				continue
+			}
+			setNamespace = ns.Ident
+		case "side_effects":
+			if len(attr.Args) != 0 {
+				comp.error(attr.Pos, "%v attribute has args", attr.Ident)
+				continue
+			}
+		case attrIncomplete:
+			comp.parseIncompleteAttr(attr)
+		default:
+			comp.error(attr.Pos, "unknown syscall %v attribute %v", n.Name.Name, attr.Ident)
+		}
+	}
 	return
 }
 
+// Maximum weight in mutate field attribute.
+const (
+	maxMutateWeight = 1000
+	// Maximum weight in weight union option attribute.
+	maxOptionWeight = 1000
+	maxBucketWeight = 1 << 16
+	// Maximum offset in overlap field attribute.
+	maxOverlapOffset = 4 << 10
+	// Maximum array dimension in dim field attribute.
+	maxLenDim = 7
+	// Maximum number of flags values for exhaustive field attribute.
+	maxExhaustiveValues = 64
+	// Maximum pointer chain length in depth field attribute.
+	maxPtrDepth = 8
+)
+
+// fieldAttrs holds parsed attributes of a struct field or a syscall argument.
+type fieldAttrs struct {
+	mutateWeight  uint64
+	buckets       []prog.IntBucket
+	effect        prog.ResourceEffect
+	count         string
+	overlap       string
+	overlapOffset uint64
+	dim           uint64
+	validMask     uint64
+	invalidPct    uint64 // percentage of invalid values of a resource looked up by value
+	init          bool
+	byteOrder     []uint64 // big-endian and little-endian mark values
+	guard         bool
+	exhaustive    bool
+	subkindField  string
+	subkinds      []*ast.Type // resource subkinds with names of their flags as arguments
+	weight        uint64
+	hasWeight     bool
+	version       uint64
+	hasVersion    bool
+	omittable     bool
+	unique        bool
+	cookie        string // completion struct field echoing the value as STRUCT.FIELD
+	promotable    bool
+	derives       bool
+	deriveOffset  uint64
+	ptrDepth      uint64
+	shared        string // name of the shared region of vma or pointer syscall arguments
+	formatArgs    string
+	specifier     string
+	event         prog.ResourceEvent
+	nonblock      uint64
+	hasNonblock   bool
+	requestMask   string
+	requestedBy   uint64
+	hasRequested  bool
+	relation      prog.IntRelation
+	relationField string
+}
+
+// formatSpecifierRe matches printf format specifiers supported by specifier attribute.
+var formatSpecifierRe = regexp.MustCompile(`^%[-+ #0]*[0-9]*(\.[0-9]*)?(hh|h|ll|l|z|t|j)?[diouxXcsp]$`)
+
+// resourceEffectAttrs maps resource lifetime attributes of syscall arguments to their effects.
+var resourceEffectAttrs = map[string]prog.ResourceEffect{
+	"consumes_and_invalidates": prog.ResourceInvalidate,
+	"transforms":               prog.ResourceTransform,
+	"acquires":                 prog.ResourceAcquire,
+	"releases":                 prog.ResourceRelease,
+}
+
+// resourceEventAttrs maps roles of syscall arguments in event resource semantics to their events.
+var resourceEventAttrs = map[string]prog.ResourceEvent{
+	"signals": prog.EventSignal,
+	"waits":   prog.EventWait,
+}
+
+// intRelationAttrs maps relation attributes of int fields to their relations.
+var intRelationAttrs = map[string]prog.IntRelation{
+	"lt": prog.IntRelLess,
+	"le": prog.IntRelLessEqual,
+	"eq": prog.IntRelEqual,
+	"ne": prog.IntRelNotEqual,
+}
+
+func resourceEffectAttr(effect prog.ResourceEffect) string {
+	for name, effect1 := range resourceEffectAttrs {
+		if effect1 == effect {
+			return name
+		}
+	}
+	panic(fmt.Sprintf("unknown resource effect %v", effect))
+}
+
+func intRelationAttr(rel prog.IntRelation) string {
+	for name, rel1 := range intRelationAttrs {
+		if rel1 == rel {
+			return name
+		}
+	}
+	panic(fmt.Sprintf("unknown int relation %v", rel))
+}
+
+func resourceEventAttr(event prog.ResourceEvent) string {
+	for name, event1 := range resourceEventAttrs {
+		if event1 == event {
+			return name
+		}
+	}
+	panic(fmt.Sprintf("unknown resource event %v", event))
+}
+
+func (comp *compiler) parseFieldAttrs(f *ast.Field) (attrs fieldAttrs) {
+	seen := make(map[string]bool)
+	var bucketWeights uint64
+	for _, attr := range f.Attrs {
+		if seen[attr.Ident] && attr.Ident != "bucket" {
+			comp.error(attr.Pos, "duplicate %v attribute of %v", attr.Ident, f.Name.Name)
+		}
+		seen[attr.Ident] = true
+		switch attr.Ident {
+		case "bucket":
+			if len(attr.Args) != 2 {
+				comp.error(attr.Pos, "%v attribute is expected to have 2 arguments", attr.Ident)
+				continue
+			}
+			rng, w := attr.Args[0], attr.Args[1]
+			if rng.Ident != "" || rng.HasString || rng.Ident2 != "" || len(rng.Args) != 0 {
+				comp.error(rng.Pos, "%v attribute range must be an integer or integer range", attr.Ident)
+				continue
+			}
+			if w.Ident != "" || w.HasString || w.HasColon || len(w.Args) != 0 {
+				comp.error(w.Pos, "%v attribute weight must be an integer", attr.Ident)
+				continue
+			}
+			begin, end := rng.Value, rng.Value
+			if rng.HasColon {
+				end = rng.Value2
+			}
+			if begin > end {
+				comp.error(rng.Pos, "bad %v attribute range [%v:%v]", attr.Ident, begin, end)
+				continue
+			}
+			if w.Value > maxBucketWeight {
+				comp.error(w.Pos, "%v attribute weight %v is too large, maximum is %v",
+					attr.Ident, w.Value, maxBucketWeight)
+				continue
+			}
+			bucketWeights += w.Value
+			attrs.buckets = append(attrs.buckets, prog.IntBucket{Begin: begin, End: end, Weight: w.Value})
+		case "mutate":
+			if len(attr.Args) != 1 {
+				comp.error(attr.Pos, "%v attribute is expected to have 1 argument", attr.Ident)
+				continue
+			}
+			w := attr.Args[0]
+			if w.Ident != "" || w.HasString || w.HasColon || len(w.Args) != 0 {
+				comp.error(w.Pos, "%v attribute weight must be an integer", attr.Ident)
+				continue
+			}
+			if w.Value > maxMutateWeight {
+				comp.error(w.Pos, "%v attribute weight %v is too large, maximum is %v",
+					attr.Ident, w.Value, maxMutateWeight)
+				continue
+			}
+			attrs.mutateWeight = w.Value
+			if attrs.mutateWeight == 0 {
+				attrs.mutateWeight = prog.MutateWeightNone
+			}
+		case "weight":
+			if len(attr.Args) != 1 {
+				comp.error(attr.Pos, "%v attribute is expected to have 1 argument", attr.Ident)
+				continue
+			}
+			w := attr.Args[0]
+			if w.Ident != "" || w.HasString || w.HasColon || len(w.Args) != 0 {
+				comp.error(w.Pos, "%v attribute weight must be an integer", attr.Ident)
+				continue
+			}
+			if w.ValueFmt == ast.IntFmtNeg {
+				comp.error(w.Pos, "%v attribute weight %v is negative", attr.Ident, int64(w.Value))
+				continue
+			}
+			if w.Value > maxOptionWeight {
+				comp.error(w.Pos, "%v attribute weight %v is too large, maximum is %v",
+					attr.Ident, w.Value, maxOptionWeight)
+				continue
+			}
+			attrs.weight = w.Value
+			attrs.hasWeight = true
+		case "version":
+			if len(attr.Args) != 1 {
+				comp.error(attr.Pos, "%v attribute is expected to have 1 argument", attr.Ident)
+				continue
+			}
+			v := attr.Args[0]
+			if v.Ident != "" || v.HasString || v.HasColon || len(v.Args) != 0 {
+				comp.error(v.Pos, "%v attribute argument must be an integer", attr.Ident)
+				continue
+			}
+			attrs.version = v.Value
+			attrs.hasVersion = true
+		case "consumes_and_invalidates", "transforms", "acquires", "releases":
+			if len(attr.Args) != 0 {
+				comp.error(attr.Pos, "%v attribute has args", attr.Ident)
+				continue
+			}
+			if attrs.effect != prog.ResourceUse {
+				names := []string{resourceEffectAttr(attrs.effect), attr.Ident}
+				sort.Strings(names)
+				comp.error(attr.Pos, "%v has both %v and %v attributes", f.Name.Name, names[0], names[1])
+				continue
+			}
+			attrs.effect = resourceEffectAttrs[attr.Ident]
+		case "signals", "waits":
+			if len(attr.Args) != 0 {
+				comp.error(attr.Pos, "%v attribute has args", attr.Ident)
+				continue
+			}
+			if attrs.event != prog.EventNone {
+				comp.error(attr.Pos, "%v has both signals and waits attributes", f.Name.Name)
+				continue
+			}
+			attrs.event = resourceEventAttrs[attr.Ident]
+		case "nonblock":
+			if len(attr.Args) != 1 {
+				comp.error(attr.Pos, "%v attribute is expected to have 1 argument", attr.Ident)
+				continue
+			}
+			v := attr.Args[0]
+			if v.HasString || v.HasColon || v.Ident2 != "" || len(v.Args) != 0 {
+				comp.error(v.Pos, "%v attribute argument must be an integer or a const", attr.Ident)
+				continue
+			}
+			// Consts are patched in place by patchConsts.
+			attrs.nonblock = v.Value
+			attrs.hasNonblock = true
+		case "count":
+			if len(attr.Args) != 1 {
+				comp.error(attr.Pos, "%v attribute is expected to have 1 argument", attr.Ident)
+				continue
+			}
+			n := attr.Args[0]
+			if n.Ident == "" || n.HasString || n.HasColon || n.Ident2 != "" || len(n.Args) != 0 {
+				comp.error(n.Pos, "%v attribute argument must be a field name", attr.Ident)
+				continue
+			}
+			attrs.count = n.Ident
+		case "format_args":
+			if len(attr.Args) != 1 {
+				comp.error(attr.Pos, "%v attribute is expected to have 1 argument", attr.Ident)
+				continue
+			}
+			n := attr.Args[0]
+			if n.Ident == "" || n.HasString || n.HasColon || n.Ident2 != "" || len(n.Args) != 0 {
+				comp.error(n.Pos, "%v attribute argument must be a field name", attr.Ident)
+				continue
+			}
+			attrs.formatArgs = n.Ident
+		case "specifier":
+			if len(attr.Args) != 1 {
+				comp.error(attr.Pos, "%v attribute is expected to have 1 argument", attr.Ident)
+				continue
+			}
+			s := attr.Args[0]
+			if !s.HasString || len(s.Args) != 0 {
+				comp.error(s.Pos, "%v attribute argument must be a string", attr.Ident)
+				continue
+			}
+			if !formatSpecifierRe.MatchString(s.String) {
+				// Width and precision given as * consume additional arguments, so they are not supported.
+				comp.error(s.Pos, "%v attribute value %q is not a single format specifier"+
+					" with optional numeric width and precision", attr.Ident, s.String)
+				continue
+			}
+			attrs.specifier = s.String
+		case "request_mask":
+			if len(attr.Args) != 1 {
+				comp.error(attr.Pos, "%v attribute is expected to have 1 argument", attr.Ident)
+				continue
+			}
+			n := attr.Args[0]
+			if n.Ident == "" || n.HasString || n.HasColon || n.Ident2 != "" || len(n.Args) != 0 {
+				comp.error(n.Pos, "%v attribute argument must be a field name", attr.Ident)
+				continue
+			}
+			attrs.requestMask = n.Ident
+		case "lt", "le", "eq", "ne":
+			if len(attr.Args) != 1 {
+				comp.error(attr.Pos, "%v attribute is expected to have 1 argument", attr.Ident)
+				continue
+			}
+			n := attr.Args[0]
+			if n.Ident == "" || n.HasString || n.HasColon || n.Ident2 != "" || len(n.Args) != 0 {
+				comp.error(n.Pos, "%v attribute argument must be a field name", attr.Ident)
+				continue
+			}
+			if attrs.relation != prog.IntRelNone {
+				names := []string{intRelationAttr(attrs.relation), attr.Ident}
+				sort.Strings(names)
+				comp.error(attr.Pos, "%v has both %v and %v attributes", f.Name.Name, names[0], names[1])
+				continue
+			}
+			attrs.relation = intRelationAttrs[attr.Ident]
+			attrs.relationField = n.Ident
+		case "requested_by":
+			if len(attr.Args) == 0 {
+				comp.error(attr.Pos, "%v attribute is expected to have at least 1 argument", attr.Ident)
+				continue
+			}
+			var bits uint64
+			for _, v := range attr.Args {
+				if v.HasString || v.HasColon || v.Ident2 != "" || len(v.Args) != 0 {
+					comp.error(v.Pos, "%v attribute arguments must be integers or consts", attr.Ident)
+					bits = 0
+					break
+				}
+				// Consts are patched in place by patchConsts.
+				bits |= v.Value
+			}
+			attrs.requestedBy = bits
+			attrs.hasRequested = true
+		case "overlap":
+			if len(attr.Args) != 2 {
+				comp.error(attr.Pos, "%v attribute is expected to have 2 arguments", attr.Ident)
+				continue
+			}
+			n, off := attr.Args[0], attr.Args[1]
+			if n.Ident == "" || n.HasString || n.HasColon || n.Ident2 != "" || len(n.Args) != 0 {
+				comp.error(n.Pos, "%v attribute argument must be a field name", attr.Ident)
+				continue
+			}
+			if off.Ident != "" || off.HasString || off.HasColon || len(off.Args) != 0 {
+				comp.error(off.Pos, "%v attribute offset must be an integer", attr.Ident)
+				continue
+			}
+			if off.Value > maxOverlapOffset {
+				comp.error(off.Pos, "%v attribute offset %v is too large, maximum is %v",
+					attr.Ident, off.Value, maxOverlapOffset)
+				continue
+			}
+			attrs.overlap = n.Ident
+			attrs.overlapOffset = off.Value
+		case "dim":
+			if len(attr.Args) != 1 {
+				comp.error(attr.Pos, "%v attribute is expected to have 1 argument", attr.Ident)
+				continue
+			}
+			d := attr.Args[0]
+			if d.Ident != "" || d.HasString || d.HasColon || len(d.Args) != 0 {
+				comp.error(d.Pos, "%v attribute argument must be an integer", attr.Ident)
+				continue
+			}
+			if d.Value > maxLenDim {
+				comp.error(d.Pos, "%v attribute value %v is too large, maximum is %v",
+					attr.Ident, d.Value, maxLenDim)
+				continue
+			}
+			attrs.dim = d.Value
+		case "valid_bit":
+			if len(attr.Args) != 1 {
+				comp.error(attr.Pos, "%v attribute is expected to have 1 argument", attr.Ident)
+				continue
+			}
+			b := attr.Args[0]
+			if b.Ident != "" || b.HasString || b.HasColon || len(b.Args) != 0 {
+				comp.error(b.Pos, "%v attribute argument must be an integer", attr.Ident)
+				continue
+			}
+			if b.Value > 63 {
+				comp.error(b.Pos, "%v attribute value %v is too large, maximum is 63", attr.Ident, b.Value)
+				continue
+			}
+			attrs.validMask = 1 << b.Value
+		case "by_value":
+			if len(attr.Args) != 1 {
+				comp.error(attr.Pos, "%v attribute is expected to have 1 argument", attr.Ident)
+				continue
+			}
+			p := attr.Args[0]
+			if p.Ident != "" || p.HasString || p.HasColon || len(p.Args) != 0 {
+				comp.error(p.Pos, "%v attribute argument must be an integer", attr.Ident)
+				continue
+			}
+			if p.Value == 0 || p.Value > 100 {
+				comp.error(p.Pos, "%v attribute value %v is out of range [1:100]", attr.Ident, p.Value)
+				continue
+			}
+			attrs.invalidPct = p.Value
+		case "derives":
+			if len(attr.Args) != 1 {
+				comp.error(attr.Pos, "%v attribute is expected to have 1 argument", attr.Ident)
+				continue
+			}
+			off := attr.Args[0]
+			if off.Ident != "" || off.HasString || off.HasColon || len(off.Args) != 0 {
+				comp.error(off.Pos, "%v attribute offset must be an integer", attr.Ident)
+				continue
+			}
+			attrs.derives = true
+			attrs.deriveOffset = off.Value
+		case "init":
+			if len(attr.Args) != 0 {
+				comp.error(attr.Pos, "%v attribute has args", attr.Ident)
+				continue
+			}
+			attrs.init = true
+		case "depth":
+			if len(attr.Args) != 1 {
+				comp.error(attr.Pos, "%v attribute is expected to have 1 argument", attr.Ident)
+				continue
+			}
+			d := attr.Args[0]
+			if d.Ident != "" || d.HasString || d.HasColon || len(d.Args) != 0 {
+				comp.error(d.Pos, "%v attribute argument must be an integer", attr.Ident)
+				continue
+			}
+			if d.Value == 0 || d.Value > maxPtrDepth {
+				comp.error(d.Pos, "%v attribute value %v is out of range [1:%v]",
+					attr.Ident, d.Value, maxPtrDepth)
+				continue
+			}
+			attrs.ptrDepth = d.Value
+		case "shared":
+			if len(attr.Args) != 1 {
+				comp.error(attr.Pos, "%v attribute is expected to have 1 argument", attr.Ident)
+				continue
+			}
+			n := attr.Args[0]
+			if n.Ident == "" || n.HasString || n.HasColon || n.Ident2 != "" || len(n.Args) != 0 {
+				comp.error(n.Pos, "%v attribute argument must be a region name", attr.Ident)
+				continue
+			}
+			attrs.shared = n.Ident
+		case "guard":
+			if len(attr.Args) != 0 {
+				comp.error(attr.Pos, "%v attribute has args", attr.Ident)
+				continue
+			}
+			attrs.guard = true
+		case "exhaustive":
+			if len(attr.Args) != 0 {
+				comp.error(attr.Pos, "%v attribute has args", attr.Ident)
+				continue
+			}
+			attrs.exhaustive = true
+		case "omittable":
+			if len(attr.Args) != 0 {
+				comp.error(attr.Pos, "%v attribute has args", attr.Ident)
+				continue
+			}
+			attrs.omittable = true
+		case "unique":
+			if len(attr.Args) != 0 {
+				comp.error(attr.Pos, "%v attribute has args", attr.Ident)
+				continue
+			}
+			attrs.unique = true
+		case "cookie":
+			if len(attr.Args) != 2 {
+				comp.error(attr.Pos, "%v attribute is expected to have 2 arguments", attr.Ident)
+				continue
+			}
+			n, f := attr.Args[0], attr.Args[1]
+			if n.Ident == "" || n.HasString || n.HasColon || n.Ident2 != "" || len(n.Args) != 0 ||
+				f.Ident == "" || f.HasString || f.HasColon || f.Ident2 != "" || len(f.Args) != 0 {
+				comp.error(attr.Pos, "%v attribute arguments must be a struct and a field name", attr.Ident)
+				continue
+			}
+			attrs.cookie = n.Ident + "." + f.Ident
+		case "promotable":
+			if len(attr.Args) != 0 {
+				comp.error(attr.Pos, "%v attribute has args", attr.Ident)
+				continue
+			}
+			attrs.promotable = true
+		case "byte_order":
+			if len(attr.Args) != 2 {
+				comp.error(attr.Pos, "%v attribute is expected to have 2 arguments", attr.Ident)
+				continue
+			}
+			be, le := attr.Args[0], attr.Args[1]
+			if be.Ident != "" || be.HasString || be.HasColon || len(be.Args) != 0 ||
+				le.Ident != "" || le.HasString || le.HasColon || len(le.Args) != 0 {
+				comp.error(attr.Pos, "%v attribute arguments must be integers", attr.Ident)
+				continue
+			}
+			if be.Value == le.Value {
+				comp.error(attr.Pos, "%v attribute has equal big-endian and little-endian values", attr.Ident)
+				continue
+			}
+			attrs.byteOrder = []uint64{be.Value, le.Value}
+		case "subkind":
+			if len(attr.Args) < 2 {
+				comp.error(attr.Pos, "%v attribute is expected to have at least 2 arguments", attr.Ident)
+				continue
+			}
+			res := attr.Args[0]
+			if res.Ident == "" || res.HasString || res.HasColon || res.Ident2 != "" || len(res.Args) != 0 {
+				comp.error(res.Pos, "%v attribute argument must be a field name", attr.Ident)
+				continue
+			}
+			var subkinds []*ast.Type
+			for _, k := range attr.Args[1:] {
+				if k.Ident == "" || k.HasString || k.HasColon || k.Ident2 != "" || len(k.Args) != 1 ||
+					k.Args[0].Ident == "" || k.Args[0].HasColon || len(k.Args[0].Args) != 0 {
+					comp.error(k.Pos, "%v attribute subkinds must be of the form resource[flags]", attr.Ident)
+					subkinds = nil
+					break
+				}
+				subkinds = append(subkinds, k)
+			}
+			if subkinds != nil {
+				attrs.subkindField = res.Ident
+				attrs.subkinds = subkinds
+			}
+		case attrIncomplete:
+			comp.parseIncompleteAttr(attr)
+		default:
+			comp.error(attr.Pos, "unknown %v attribute %v", f.Name.Name, attr.Ident)
+		}
+	}
+	if len(attrs.buckets) != 0 && attrs.byteOrder != nil {
+		comp.error(f.Pos, "%v has both bucket and byte_order attributes", f.Name.Name)
+		attrs.byteOrder = nil
+	}
+	if attrs.overlap != "" && attrs.guard {
+		comp.error(f.Pos, "%v has both overlap and guard attributes", f.Name.Name)
+		attrs.guard = false
+	}
+	if len(attrs.buckets) != 0 && bucketWeights == 0 {
+		comp.error(f.Pos, "bucket weights of %v sum to 0", f.Name.Name)
+		attrs.buckets = nil
+	}
+	return
+}
+
+// attrIncomplete marks declarations that are still under development (e.g. have placeholder fields).
+// It does not affect compilation unless Options.ForbidIncomplete is set, see checkIncomplete.
+const attrIncomplete = "incomplete"
+
+func (comp *compiler) parseIncompleteAttr(attr *ast.Type) {
+	if len(attr.Args) != 0 {
+		comp.error(attr.Pos, "%v attribute has args", attr.Ident)
+	}
+}
+
 func (comp *compiler) parseSizeAttr(attr *ast.Type) uint64 {
 	if len(attr.Args) != 1 {
 		comp.error(attr.Pos, "%v attribute is expected to have 1 argument", attr.Ident)
@@ -331,7 +1563,8 @@ func removeOpt(t *ast.Type) ([]*ast.Type, *ast.Type) {
 
 func (comp *compiler) parseIntType(name string) (size uint64, bigEndian bool) {
 	be := strings.HasSuffix(name, "be")
-	if be {
+	if be || strings.HasSuffix(name, "le") {
+		// All supported targets are little-endian, so the le suffix does not change the format.
 		name = name[:len(name)-len("be")]
 	}
 	size = comp.ptrSize
@@ -5,16 +5,19 @@ package compiler
 
 import (
 	"bytes"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 
 	"github.com/google/syzkaller/pkg/ast"
 	"github.com/google/syzkaller/pkg/serializer"
+	"github.com/google/syzkaller/prog"
 	"github.com/google/syzkaller/sys/targets"
 )
 
@@ -161,6 +164,9 @@ func TestWarnings(t *testing.T) {
 	t.Parallel()
 	consts := map[string]uint64{
 		"SYS_foo": 1,
+		"ZERO":    0,
+		"ZERO2":   0,
+		"ONE":     1,
 	}
 	for _, arch := range []string{"32_shmem", "64"} {
 		target := targets.List["test"][arch]
@@ -187,50 +193,1600 @@ func TestWarnings(t *testing.T) {
 	}
 }
 
+func TestStrictABI(t *testing.T) {
+	t.Parallel()
+	consts := map[string]uint64{
+		"SYS_foo": 1,
+	}
+	for _, arch := range []string{"32_shmem", "64"} {
+		target := targets.List["test"][arch]
+		t.Run(arch, func(t *testing.T) {
+			t.Parallel()
+			em := ast.NewErrorMatcher(t, filepath.Join("testdata", "strict.txt"))
+			desc := ast.Parse(em.Data, "strict.txt", em.ErrorHandler)
+			if desc == nil {
+				em.DumpErrors(t)
+				t.Fatalf("parsing failed")
+			}
+			// Strict checks must not affect the default mode.
+			if p := Compile(desc, consts, target, em.ErrorHandler); p == nil {
+				em.DumpErrors(t)
+				t.Fatalf("compilation failed")
+			}
+			CompileOpts(desc, consts, target, em.ErrorHandler, Options{StrictABI: true})
+			em.Check(t)
+		})
+	}
+}
+
+func TestForbidIncomplete(t *testing.T) {
+	t.Parallel()
+	consts := map[string]uint64{
+		"SYS_foo": 1,
+	}
+	target := targets.List["test"]["64"]
+	em := ast.NewErrorMatcher(t, filepath.Join("testdata", "incomplete.txt"))
+	desc := ast.Parse(em.Data, "incomplete.txt", em.ErrorHandler)
+	if desc == nil {
+		em.DumpErrors(t)
+		t.Fatalf("parsing failed")
+	}
+	// Incomplete declarations must be accepted in the default mode.
+	if p := Compile(desc, consts, target, em.ErrorHandler); p == nil {
+		em.DumpErrors(t)
+		t.Fatalf("compilation failed")
+	}
+	CompileOpts(desc, consts, target, em.ErrorHandler, Options{ForbidIncomplete: true})
+	em.Check(t)
+}
+
+// TestStrictABILayout tests strict ABI errors that depend on struct layout,
+// they are reported only during generation if there are no other errors.
+func TestStrictABILayout(t *testing.T) {
+	t.Parallel()
+	const input = `
+foo(a ptr[in, s0], b ptr[out, s0], c ptr[in, s1], d ptr[in, s2], e ptr[in, s3])
+s0 {
+	f0	int8
+	f1	int32le
+} [align_4]
+s1 {
+	f0	int32le
+	f1	int16le
+} [align_8]
+s2 {
+	f0	int32le
+	f1	int8
+} [packed]
+s3 {
+	f0	len[f4, int8]
+	f1	bytesize[f5, int8]
+	f2	len[f6, int8]
+	f3	bitsize[f8, int16le:8]
+	f4	array[int8, 0:300]
+	f5	array[int32le, 128]
+	f6	array[int8]
+	f7	len[f8, int8:4]
+	f8	array[int8, 32]
+	f9	bytesize[f8, int8:6]
+} [packed]
+`
+	var errors []string
+	eh := func(pos ast.Pos, msg string) {
+		errors = append(errors, msg)
+	}
+	desc := ast.Parse([]byte(input), "input", eh)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	target := targets.List["test"]["64"]
+	if p := Compile(desc, map[string]uint64{"SYS_foo": 1}, target, eh); p == nil {
+		t.Fatalf("compilation in default mode failed: %v", errors)
+	}
+	if p := CompileOpts(desc, map[string]uint64{"SYS_foo": 1}, target, eh, Options{StrictABI: true}); p != nil {
+		t.Fatal("compilation in strict mode succeeded")
+	}
+	want := []string{
+		"struct s0 has implicit padding of 3 bytes before field f1",
+		"length of f4 can be up to 300 which does not fit into 8 bits",
+		"length of f5 can be up to 512 which does not fit into 8 bits",
+		"length of f8 can be up to 256 which does not fit into 8 bits",
+		"length of f8 can be up to 32 which does not fit into 4 bits",
+	}
+	if !reflect.DeepEqual(errors, want) {
+		t.Fatalf("got errors: %q\nwant: %q", errors, want)
+	}
+}
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+	const input = `
+resource fd[int32]
+foo(a ptr[in, s0], b ptr[out, s0], c ptr[in, u0]) fd
+s0 {
+	f0	int8
+	f1	int32
+	f2	array[int16]
+}
+u0 [
+	f0	buffer[in]
+	f1	s1
+]
+s1 {
+	f0	len[parent, int32]
+	f1	fd
+}
+`
+	desc := ast.Parse([]byte(input), "input", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	target := targets.List["test"]["64"]
+	consts := map[string]uint64{"SYS_foo": 1}
+	if p := Compile(desc, consts, target, nil); p == nil || p.Stats != nil {
+		t.Fatalf("stats are filled in without Options.Stats: %+v", p)
+	}
+	p := CompileOpts(desc, consts, target, nil, Options{Stats: true})
+	if p == nil {
+		t.Fatal("failed to compile")
+	}
+	want := &Stats{
+		Calls:     1,
+		Resources: 1,
+		Structs:   2,
+		Unions:    1,
+		Types: map[string]int{
+			"PtrType":      4,
+			"StructType":   3,
+			"UnionType":    1,
+			"IntType":      3,
+			"ArrayType":    1,
+			"BufferType":   1,
+			"LenType":      1,
+			"ResourceType": 2,
+		},
+	}
+	if !reflect.DeepEqual(p.Stats, want) {
+		t.Fatalf("got stats: %+v\nwant: %+v", p.Stats, want)
+	}
+}
+
+func TestComplexity(t *testing.T) {
+	t.Parallel()
+	const input = `
+resource fd[int32]
+resource sock[fd]
+
+open() fd
+close(fd fd)
+socket(a ptr[in, s0]) sock
+sock_send(fd sock, b ptr[in, u0])
+
+s0 {
+	f0	int32
+	f1	ptr[in, s1]
+}
+
+s1 {
+	f0	array[int8]
+}
+
+u0 [
+	f0	int32
+	f1	s1
+] [varlen]
+`
+	desc := ast.Parse([]byte(input), "input.txt", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	target := targets.List["test"]["64"]
+	consts := map[string]uint64{"SYS_open": 1, "SYS_close": 2, "SYS_socket": 3, "SYS_sock_send": 4}
+	p := CompileOpts(desc, consts, target, nil, Options{Complexity: true, Subsystems: []string{"so", "sock"}})
+	if p == nil {
+		t.Fatal("failed to compile")
+	}
+	want := []*SubsystemComplexity{
+		{
+			Subsystem:   "input",
+			Calls:       2,
+			AvgArgDepth: 1,
+			MaxArgDepth: 1,
+			Consumes:    1,
+			Produces:    1,
+			AvgFanIn:    2, // open and socket
+			AvgFanOut:   1, // close
+		},
+		{
+			Subsystem:   "sock",
+			Calls:       2,
+			AvgArgDepth: 10.0 / 3, // ptr[in, s0] is 5, sock is 1, ptr[in, u0] is 4
+			MaxArgDepth: 5,
+			Structs:     2,
+			Unions:      1,
+			Consumes:    1,
+			Produces:    1,
+			AvgFanIn:    1, // socket
+			AvgFanOut:   2, // close and sock_send
+		},
+	}
+	if !reflect.DeepEqual(p.Complexity, want) {
+		for _, sub := range p.Complexity {
+			t.Errorf("got: %+v", *sub)
+		}
+		t.Fatal("wrong complexity metrics")
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	t.Parallel()
+	const input = `
+resource fd[int32]
+resource sock[fd]
+
+# Opens a socket.
+# Returns the socket fd.
+socket(domain int32, len len[addr], addr ptr[in, array[int8]]) sock
+
+bind(fd sock, addr ptr[inout, s0])
+
+close(fd fd, opt fd[opt])
+s0 {
+	f0	fd
+	f1	ptr[out, sock]
+}
+`
+	desc := ast.Parse([]byte(input), "input", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	target := targets.List["test"]["64"]
+	consts := map[string]uint64{"SYS_socket": 1, "SYS_bind": 2, "SYS_close": 3}
+	p := CompileOpts(desc, consts, target, nil, Options{Metadata: true})
+	if p == nil {
+		t.Fatal("failed to compile")
+	}
+	data, err := json.MarshalIndent(p.Metadata, "", "\t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `[
+	{
+		"name": "bind",
+		"call_name": "bind",
+		"args": [
+			{
+				"name": "fd",
+				"type": "sock"
+			},
+			{
+				"name": "addr",
+				"type": "ptr[inout, s0]"
+			}
+		],
+		"produces": [
+			"fd",
+			"sock"
+		],
+		"consumes": [
+			"fd",
+			"sock"
+		],
+		"structs": [
+			"s0/inout"
+		],
+		"consts": [
+			{
+				"name": "SYS_bind",
+				"value": 2
+			}
+		]
+	},
+	{
+		"name": "close",
+		"call_name": "close",
+		"args": [
+			{
+				"name": "fd",
+				"type": "fd"
+			},
+			{
+				"name": "opt",
+				"type": "fd[opt]"
+			}
+		],
+		"consumes": [
+			"fd"
+		],
+		"consts": [
+			{
+				"name": "SYS_close",
+				"value": 3
+			}
+		]
+	},
+	{
+		"name": "socket",
+		"call_name": "socket",
+		"doc": "Opens a socket.\nReturns the socket fd.",
+		"args": [
+			{
+				"name": "domain",
+				"type": "int32"
+			},
+			{
+				"name": "len",
+				"type": "len[addr]"
+			},
+			{
+				"name": "addr",
+				"type": "ptr[in, array[int8]]"
+			}
+		],
+		"ret": "sock",
+		"produces": [
+			"sock"
+		],
+		"consts": [
+			{
+				"name": "SYS_socket",
+				"value": 1
+			}
+		]
+	}
+]`
+	if got := string(data); got != want {
+		t.Fatalf("got metadata:\n%v\nwant:\n%v", got, want)
+	}
+}
+
+func TestCallConsts(t *testing.T) {
+	t.Parallel()
+	const input = `
+resource fd[int32]: FD_NONE
+resource sock[fd]: SOCK_NONE
+resource ev[int32] [event]
+
+foo(a sock, b ptr[in, s0], c flags[foo_flags], d int32[MIN:MAX])
+bar(a fd, b const[BAR])
+baz() sock
+qux(a int32 (nonblock[NONBLOCK])) ev
+wait(a ev (waits))
+
+foo_flags = FLAG0, FLAG1, 0x4
+u0_flags = FLAG1, FLAG2
+
+s0 {
+	f0	tmpl[CTMPL]
+	f1	array[u0, 2]
+	f2	int32 (requested_by[RMASK])
+} [size[SIZE]]
+
+u0 [
+	f0	ptr[in, array[const[UOPT, int16]]]
+	f1	flags[u0_flags, int8]
+]
+
+type tmpl[VAL] {
+	f0	const[VAL, int32]
+}
+`
+	desc := ast.Parse([]byte(input), "input", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	target := targets.List["test"]["64"]
+	consts := map[string]uint64{
+		"SYS_foo": 1, "SYS_bar": 2, "SYS_baz": 3, "FD_NONE": 4, "SOCK_NONE": 5, "FLAG0": 6, "FLAG1": 7,
+		"FLAG2": 8, "MIN": 9, "MAX": 10, "BAR": 11, "SIZE": 32, "UOPT": 12, "CTMPL": 13,
+		"SYS_qux": 14, "SYS_wait": 15, "NONBLOCK": 16, "RMASK": 17,
+	}
+	p := CompileOpts(desc, consts, target, nil, Options{Metadata: true})
+	if p == nil {
+		t.Fatal("failed to compile")
+	}
+	want := map[string][]ConstMetadata{
+		"bar": {{"BAR", 11}, {"FD_NONE", 4}, {"SYS_bar", 2}},
+		"baz": {{"FD_NONE", 4}, {"SOCK_NONE", 5}, {"SYS_baz", 3}},
+		"foo": {{"CTMPL", 13}, {"FD_NONE", 4}, {"FLAG0", 6}, {"FLAG1", 7}, {"FLAG2", 8}, {"MAX", 10},
+			{"MIN", 9}, {"RMASK", 17}, {"SIZE", 32}, {"SOCK_NONE", 5}, {"SYS_foo", 1}, {"UOPT", 12}},
+		"qux":  {{"NONBLOCK", 16}, {"SYS_qux", 14}},
+		"wait": {{"SYS_wait", 15}},
+	}
+	for _, meta := range p.Metadata {
+		if !reflect.DeepEqual(meta.Consts, want[meta.Name]) {
+			t.Errorf("call %v: got consts %+v, want %+v", meta.Name, meta.Consts, want[meta.Name])
+		}
+	}
+}
+
+func TestMinimalConsts(t *testing.T) {
+	t.Parallel()
+	const input = `
+resource fd[int32]: FD_NONE
+foo(a fd, b ptr[in, s0])
+bar(a const[BAR]) fd
+baz(a flags[baz_flags])
+baz_flags = FLAG0, FLAG1
+s0 {
+	f0	array[int8, LEN]
+	f1	u0
+}
+u0 [
+	f0	const[UOPT, int32]
+	f1	int32[MIN:MAX]
+]
+`
+	desc := ast.Parse([]byte(input), "input", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	target := targets.List["test"]["64"]
+	consts := map[string]uint64{
+		"SYS_foo": 1, "SYS_bar": 2, "SYS_baz": 3, "FD_NONE": 4, "BAR": 5, "FLAG0": 6, "FLAG1": 7,
+		"LEN": 8, "UOPT": 9, "MIN": 10, "MAX": 11,
+	}
+	p := CompileOpts(desc, consts, target, nil, Options{Metadata: true})
+	if p == nil {
+		t.Fatal("failed to compile")
+	}
+	calls := []string{"bar", "foo"}
+	data, err := p.MinimalConsts(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# AUTOGENERATED FILE
+BAR = 5
+FD_NONE = 4
+LEN = 8
+MAX = 11
+MIN = 10
+SYS_bar = 2
+SYS_foo = 1
+UOPT = 9
+`
+	if string(data) != want {
+		t.Fatalf("got consts:\n%s\nwant:\n%s", data, want)
+	}
+	// The calls must compile with only the minimal consts
+	// (other calls are unsupported, but they are filtered out anyway).
+	minimal := DeserializeConsts(data, "consts", nil)
+	eh := func(pos ast.Pos, msg string) {
+		if !strings.HasPrefix(msg, "unsupported syscall: baz") {
+			t.Errorf("%v: %v", pos, msg)
+		}
+	}
+	p1 := CompileOpts(desc.Clone(), minimal, target, eh, Options{IncludeCalls: calls, Metadata: true})
+	if p1 == nil {
+		t.Fatal("failed to compile with minimal consts")
+	}
+	var got []string
+	for _, c := range p1.Syscalls {
+		got = append(got, c.Name)
+	}
+	if !reflect.DeepEqual(got, calls) {
+		t.Fatalf("compiled calls %v with minimal consts, want %v", got, calls)
+	}
+	if _, err := p.MinimalConsts([]string{"foo", "qux"}); err == nil {
+		t.Fatalf("no error for unknown call")
+	}
+}
+
+func TestChangedCalls(t *testing.T) {
+	t.Parallel()
+	const prev = `
+foo(a ptr[in, s0])
+bar(a ptr[in, s1])
+baz(a int32)
+qux(a int32)
+s0 {
+	f0	int32
+}
+s1 {
+	f0	ptr[in, s0]
+	f1	int8
+}
+`
+	const cur = `
+foo(a ptr[in, s0])
+bar(a ptr[in, s1])
+baz(a int32)
+qux(a int64)
+new(a ptr[in, s1])
+s0 {
+	f0	int32
+}
+s1 {
+	f0	ptr[in, s0]
+	f1	int16
+}
+`
+	target := targets.List["test"]["64"]
+	compile := func(text string) *Prog {
+		consts := map[string]uint64{"SYS_foo": 1, "SYS_bar": 2, "SYS_baz": 3, "SYS_qux": 4}
+		if strings.Contains(text, "new(") {
+			consts["SYS_new"] = 5
+		}
+		desc := ast.Parse([]byte(text), "input", nil)
+		if desc == nil {
+			t.Fatal("failed to parse")
+		}
+		p := Compile(desc, consts, target, func(pos ast.Pos, msg string) {
+			t.Fatalf("%v: %v", pos, msg)
+		})
+		if p == nil {
+			t.Fatal("failed to compile")
+		}
+		return p
+	}
+	p0, p1 := compile(prev), compile(cur)
+	if got := ChangedCalls(p0, compile(prev)); len(got) != 0 {
+		t.Fatalf("unchanged descriptions have changed calls %q", got)
+	}
+	want := []string{"bar", "new", "qux"}
+	if got := ChangedCalls(p0, p1); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got changed calls %q, want %q", got, want)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+	const common = `
+resource fd[int32]: -1
+dup(fd fd) fd
+close(fd fd)
+s0 {
+	f0	int32
+	f1	flags[f0, int8]
+}
+f0 = FLAG0, FLAG1
+`
+	const base = `
+open(a ptr[in, s0]) fd
+`
+	const addon = `
+resource fd_vendor[int32] [compatible_with[fd]]
+vendor_open(a ptr[in, s0]) fd_vendor
+vendor_ioctl(fd fd, a ptr[inout, s1])
+s1 {
+	f0	ptr[in, s0]
+	f1	int64
+}
+`
+	target := targets.List["test"]["64"]
+	compile := func(text string) *Prog {
+		consts := make(map[string]uint64)
+		for i, call := range []string{"open", "dup", "close", "vendor_open", "vendor_ioctl"} {
+			if strings.Contains(text, "\n"+call+"(") {
+				consts["SYS_"+call] = uint64(i + 1)
+			}
+		}
+		for i, flag := range []string{"FLAG0", "FLAG1"} {
+			if strings.Contains(text, flag) {
+				consts[flag] = uint64(i + 1)
+			}
+		}
+		desc := ast.Parse([]byte(text), "input", nil)
+		if desc == nil {
+			t.Fatal("failed to parse")
+		}
+		p := Compile(desc, consts, target, func(pos ast.Pos, msg string) {
+			t.Fatalf("%v: %v", pos, msg)
+		})
+		if p == nil {
+			t.Fatal("failed to compile")
+		}
+		return p
+	}
+	merged := Merge(compile(common+base), compile(common+addon), func(pos ast.Pos, msg string) {
+		t.Fatal(msg)
+	})
+	whole := compile(common + base + addon)
+	if !reflect.DeepEqual(merged.Resources, whole.Resources) {
+		t.Errorf("merged resources differ")
+	}
+	if !reflect.DeepEqual(merged.Syscalls, whole.Syscalls) {
+		t.Errorf("merged syscalls differ")
+	}
+	if !reflect.DeepEqual(merged.StructDescs, whole.StructDescs) {
+		t.Errorf("merged structs differ")
+	}
+	if !reflect.DeepEqual(merged.Flags, whole.Flags) {
+		t.Errorf("merged flags differ")
+	}
+
+	const conflicting = `
+resource fd[int64]
+close(fd fd, flags int32)
+open(a ptr[in, s0]) fd
+s0 {
+	f0	int64
+	f1	flags[f0, int8]
+}
+f0 = FLAG0
+`
+	var errors []string
+	res := Merge(compile(common+base), compile(conflicting), func(pos ast.Pos, msg string) {
+		errors = append(errors, msg)
+	})
+	if res != nil {
+		t.Fatalf("merge of conflicting descriptions succeeded")
+	}
+	want := []string{
+		"resource fd is declared differently in the merged descriptions",
+		"struct s0 is declared differently in the merged descriptions",
+		"flags f0 is declared differently in the merged descriptions",
+		"syscall close is declared differently in the merged descriptions",
+		"syscall open is declared differently in the merged descriptions",
+	}
+	if !reflect.DeepEqual(errors, want) {
+		t.Fatalf("got errors:\n%v\nwant:\n%v", strings.Join(errors, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	t.Parallel()
+	const input = `
+foo(a int32)
+bar(a ptr[in, s0])
+s0 {
+	f0	int32[0:BAR]
+}
+baz(a ptr[in, s1])
+s1 {
+	f0	int8
+} [size[0]]
+`
+	desc := ast.Parse([]byte(input), "input", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	target := targets.List["test"]["64"]
+	consts := map[string]uint64{"SYS_foo": 1, "SYS_baz": 3, "BAR": 1, "QUX": 2}
+	var got []Diagnostic
+	opts := Options{
+		Diagnostics: func(d *Diagnostic) {
+			got = append(got, *d)
+		},
+	}
+	if p := CompileOpts(desc, consts, target, func(pos ast.Pos, msg string) {}, opts); p != nil {
+		t.Fatal("compilation succeeded")
+	}
+	// Warnings are reported even if compilation fails.
+	want := []Diagnostic{
+		{SeverityWarning, WarnUnsupported, "input", 3, 1, "unsupported syscall: bar due to missing const SYS_bar"},
+		{SeverityWarning, WarnUnusedConst, "", 0, 0, "unused const QUX"},
+		{SeverityError, PhaseCheck, "input", 10, 9, "size attribute has bad value 0, expect [1, 1<<20]"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got diagnostics:\n%+v\nwant:\n%+v", got, want)
+	}
+}
+
+func TestLenCycles(t *testing.T) {
+	t.Parallel()
+	const input = `
+foo(a len[b], b bytesize[c], c ptr[in, array[len[a, int32]]])
+bar(a ptr[in, s0], b ptr[in, s1], c ptr[in, s2], d ptr[in, s3])
+baz(a len[b], b len[a], c len[a])
+s0 {
+	f0	len[f1, int32]
+	f1	bytesize[f2, int32]
+	f2	ptr[in, array[len[f0, int32]]]
+	f3	len[f0, int32]
+}
+s1 {
+	f0	len[f1, int32]
+	f1	len[f0, int32]
+}
+s2 {
+	f0	len[max[f2, f1], int32]
+	f1	bytesize[min[f2, f0], int32]
+	f2	array[bytesize[f1, int8]]
+}
+s3 {
+	f0	len[f1, int32]
+	f1	bytesize[f2, int32]
+	f2	ptr[in, len[f0, int32]]
+}
+`
+	var errors []string
+	eh := func(pos ast.Pos, msg string) {
+		errors = append(errors, fmt.Sprintf("%v: %v", pos.Line, msg))
+	}
+	desc := ast.Parse([]byte(input), "input", eh)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	consts := map[string]uint64{"SYS_foo": 1, "SYS_bar": 2, "SYS_baz": 3}
+	if p := Compile(desc, consts, targets.List["test"]["64"], eh); p != nil {
+		t.Fatal("compilation succeeded")
+	}
+	// Cycles of s1, s3 and baz have only static-size targets.
+	want := []string{
+		"6: len target cycle: s0.f0 -> s0.f1 -> s0.f2 -> s0.f0",
+		"16: len target cycle: s2.f0 -> s2.f2 -> s2.f1 -> s2.f0",
+		"2: len target cycle: foo.a -> foo.b -> foo.c -> foo.a",
+	}
+	if !reflect.DeepEqual(errors, want) {
+		t.Fatalf("got errors: %q\nwant: %q", errors, want)
+	}
+}
+
+func TestPtrDirs(t *testing.T) {
+	t.Parallel()
+	const input = `
+resource fd[int32]
+foo() fd
+bar(a ptr[in, s0], b ptr[out, s1])
+s0 {
+	f0	fd
+	f1	ptr[out, fd]
+}
+s1 {
+	f0	int32 (init)
+	f1	array[fd]
+}
+`
+	desc := ast.Parse([]byte(input), "input", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	var warnings []string
+	eh := func(pos ast.Pos, msg string) {
+		warnings = append(warnings, fmt.Sprintf("%v: %v", pos.Line, msg))
+	}
+	target := targets.List["test"]["64"]
+	consts := map[string]uint64{"SYS_foo": 1, "SYS_bar": 2}
+	p := Compile(desc, consts, target, eh)
+	if p == nil || len(warnings) != 0 {
+		t.Fatalf("compilation failed or produced warnings: %q", warnings)
+	}
+	// Descriptions produced by the compiler are consistent,
+	// so break direction of the resource inside of the input pointee.
+	for _, s := range p.StructDescs {
+		if s.Key.Name == "s0" {
+			s.Desc.Fields[0].(*prog.ResourceType).ArgDir = prog.DirOut
+		}
+	}
+	comp := createCompiler(desc, target, eh)
+	comp.checkPtrDirs(p)
+	for _, w := range comp.warnings {
+		eh(w.pos, w.msg)
+	}
+	want := []string{"4: bar:a.f0 has direction out inside of in pointee"}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Fatalf("got warnings: %q\nwant: %q", warnings, want)
+	}
+}
+
+func TestStructDirs(t *testing.T) {
+	t.Parallel()
+	const input = `
+foo(a ptr[in, s0], b ptr[out, s0], c ptr[inout, s0])
+s0 {
+	f0	int32
+	f1	int16	(init)
+}
+`
+	desc := ast.Parse([]byte(input), "input", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	var warnings []string
+	eh := func(pos ast.Pos, msg string) {
+		warnings = append(warnings, fmt.Sprintf("%v: %v", pos.Line, msg))
+	}
+	target := targets.List["test"]["64"]
+	consts := map[string]uint64{"SYS_foo": 1}
+	p := Compile(desc, consts, target, eh)
+	if p == nil || len(warnings) != 0 {
+		t.Fatalf("compilation failed or produced warnings: %q", warnings)
+	}
+	// Descriptions produced by the compiler have the same layout in all directions,
+	// so make the field const in the out direction.
+	for _, s := range p.StructDescs {
+		if s.Key.Name == "s0" && s.Key.Dir == prog.DirOut {
+			f0 := s.Desc.Fields[0].(*prog.IntType)
+			s.Desc.Fields[0] = &prog.ConstType{IntTypeCommon: f0.IntTypeCommon, Val: 1}
+		}
+	}
+	comp := createCompiler(desc, target, eh)
+	comp.checkStructDirs(p)
+	for _, w := range comp.warnings {
+		eh(w.pos, w.msg)
+	}
+	want := []string{
+		"3: struct s0 has different layouts in directions in and out: " +
+			"field f0 is int32 of size 4 in in, but field f0 is const[1, int32] of size 4 in out",
+		"3: struct s0 has different layouts in directions out and inout: " +
+			"field f0 is const[1, int32] of size 4 in out, but field f0 is int32 of size 4 in inout",
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Fatalf("got warnings: %q\nwant: %q", warnings, want)
+	}
+}
+
+func TestArgSizes(t *testing.T) {
+	t.Parallel()
+	const input = `
+foo(a ptr[in, array[int64, 0:1000000]], b ptr[in, s0], c ptr[in, array[int8]], d ptr[in, u0])
+s0 {
+	f0	int32
+	f1	array[int16, 10]
+	f2	ptr[in, s1]
+}
+s1 {
+	f0	ptr[in, s1, opt]
+	f1	array[int32, 4]
+}
+u0 [
+	f0	int64
+	f1	array[int8, 0:200]
+] [varlen]
+`
+	desc := ast.Parse([]byte(input), "input", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	consts := map[string]uint64{"SYS_foo": 1}
+	tests := []struct {
+		limit uint64
+		want  []string
+	}{
+		{
+			0,
+			[]string{
+				"2:5: argument a of foo can take up to 8000008 bytes, maximum is 2097152",
+			},
+		},
+		{
+			100,
+			[]string{
+				"2:5: argument a of foo can take up to 8000008 bytes, maximum is 100",
+				"2:80: argument d of foo can take up to 208 bytes, maximum is 100",
+			},
+		},
+		{
+			10 << 20,
+			nil,
+		},
+	}
+	for _, test := range tests {
+		var errors []string
+		eh := func(pos ast.Pos, msg string) {
+			errors = append(errors, fmt.Sprintf("%v:%v: %v", pos.Line, pos.Col, msg))
+		}
+		p := CompileOpts(desc, consts, targets.List["test"]["64"], eh, Options{MaxArgSize: test.limit})
+		if (p == nil) != (len(test.want) != 0) {
+			t.Fatalf("limit %v: compilation result %v, errors: %q", test.limit, p != nil, errors)
+		}
+		if !reflect.DeepEqual(errors, test.want) {
+			t.Fatalf("limit %v: got errors: %q\nwant: %q", test.limit, errors, test.want)
+		}
+	}
+}
+
+func TestStaticAsserts(t *testing.T) {
+	t.Parallel()
+	const input = `
+foo(a ptr[in, s0], b ptr[in, u0], c ptr[in, s1], d ptr[in, s2])
+s0 {
+	f0	int32
+	f1	int8
+}
+u0 [
+	f0	int16
+	f1	int64
+]
+s1 {
+	f0	array[int8]
+}
+s2 {
+	f0	int16
+}
+static_assert(sizeof(s0) == 8)
+static_assert(sizeof(s0) == S0_SIZE)
+static_assert(sizeof(u0) == 4)
+static_assert(sizeof(s1) == 1)
+static_assert(sizeof(s2) == S2_SIZE)
+`
+	desc := ast.Parse([]byte(input), "input", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	consts := map[string]uint64{"SYS_foo": 1, "S2_SIZE": 2}
+	var errors []string
+	eh := func(pos ast.Pos, msg string) {
+		errors = append(errors, fmt.Sprintf("%v:%v: %v", pos.Line, pos.Col, msg))
+	}
+	if p := Compile(desc, consts, targets.List["test"]["64"], eh); p != nil {
+		t.Fatalf("compilation succeeded")
+	}
+	want := []string{
+		"18:1: unsupported static_assert: s0 due to missing const S0_SIZE",
+		"19:1: static_assert failed: size of union u0 declared at input:7:1 is 8, expected 4",
+		"20:1: static_assert on variable-length struct s1 declared at input:11:1",
+	}
+	if !reflect.DeepEqual(errors, want) {
+		t.Fatalf("got errors: %q\nwant: %q", errors, want)
+	}
+}
+
+func TestSizeAttrOverflow(t *testing.T) {
+	t.Parallel()
+	const input = `
+foo(a ptr[in, s0], b ptr[in, u0], c ptr[in, s3], d ptr[in, s4])
+s0 {
+	f0	array[s1, 4]
+} [size[8]]
+s1 {
+	f0	int32
+}
+u0 [
+	f0	array[s1, 4]
+	f1	int8
+] [size[8]]
+s2 {
+	f0	int32
+	f1	int8
+}
+s3 {
+	f0	int32
+	f1	array[s2, 2]
+	f2	int32
+} [size[16]]
+s4 {
+	f0	int8
+	f1	int8
+	f2	int32
+} [size[5]]
+`
+	desc := ast.Parse([]byte(input), "input", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	var errors []string
+	eh := func(pos ast.Pos, msg string) {
+		errors = append(errors, fmt.Sprintf("%v:%v: %v", pos.Line, pos.Col, msg))
+	}
+	if p := Compile(desc, map[string]uint64{"SYS_foo": 1}, targets.List["test"]["64"], eh); p != nil {
+		t.Fatalf("compilation succeeded")
+	}
+	want := []string{
+		"4:2: field f0 at offset 0 of size 16 does not fit into struct s0" +
+			" with size attribute 8 declared at input:3:1 (struct size is 16)",
+		"10:2: field f0 of size 16 does not fit into union u0 with size attribute 8 declared at input:9:1",
+		"19:2: field f1 at offset 4 of size 16 does not fit into struct s3" +
+			" with size attribute 16 declared at input:17:1 (struct size is 24)",
+		"25:2: field f2 at offset 4 of size 4 does not fit into struct s4" +
+			" with size attribute 5 declared at input:22:1 (struct size is 8)",
+	}
+	if !reflect.DeepEqual(errors, want) {
+		t.Fatalf("got errors:\n%v\nwant:\n%v", strings.Join(errors, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestParityBits(t *testing.T) {
+	t.Parallel()
+	const input = `
+foo(a ptr[in, s0])
+s0 {
+	f0	int8:4
+	f1	parity[even, 0:3, int8:1]
+	f2	parity[odd, 4:5, int8:1]
+	f3	int8:2
+}
+`
+	desc := ast.Parse([]byte(input), "input", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	var errors []string
+	eh := func(pos ast.Pos, msg string) {
+		errors = append(errors, fmt.Sprintf("%v:%v: %v", pos.Line, pos.Col, msg))
+	}
+	if p := Compile(desc, map[string]uint64{"SYS_foo": 1}, targets.List["test"]["64"], eh); p != nil {
+		t.Fatalf("compilation succeeded")
+	}
+	want := []string{
+		"6:2: parity field f2 at bit 5 is computed over bits [4:5] including itself",
+	}
+	if !reflect.DeepEqual(errors, want) {
+		t.Fatalf("got errors:\n%v\nwant:\n%v", strings.Join(errors, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestOverlayUnions(t *testing.T) {
+	t.Parallel()
+	const input = `
+foo(a ptr[in, u0], b ptr[in, u1], c ptr[in, u2])
+u0 [
+	f0	s0
+	f1	s1
+] [overlay]
+u1 [
+	f0	s0
+	f1	s2
+	f2	int32
+] [overlay]
+u2 [
+	f0	s3
+	f1	s4
+	f2	s5
+] [overlay]
+s0 {
+	f0	int32:4
+	f1	const[1, int32:28]
+}
+s1 {
+	f0	flags[fl, int32:8]
+	f1	int32:8
+}
+s2 {
+	f0	int16:4
+	f1	int16:12
+}
+s3 {
+	f0	int8:4
+	f1	int8:5
+}
+s4 {
+	f0	int8:4
+	f1	int32
+}
+s5 {
+	f0	int16:4
+	f1	int16be:4
+}
+fl = 1, 2
+`
+	desc := ast.Parse([]byte(input), "input", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	var errors []string
+	eh := func(pos ast.Pos, msg string) {
+		errors = append(errors, fmt.Sprintf("%v:%v: %v", pos.Line, pos.Col, msg))
+	}
+	if p := Compile(desc, map[string]uint64{"SYS_foo": 1}, targets.List["test"]["64"], eh); p != nil {
+		t.Fatalf("compilation succeeded")
+	}
+	want := []string{
+		"9:2: option f1 of overlay union u1 has 2-byte backing integer, but option f0 has 4-byte backing integer",
+		"10:2: option f2 of overlay union u1 is not a struct of bitfields",
+		"13:2: bitfields of option f0 of overlay union u2 take 9 bits, but the backing integer has only 8",
+		"14:2: option f1 of overlay union u2 is not a struct of bitfields: field f1 is not a bitfield",
+		"15:2: bitfields of option f2 of overlay union u2 don't form a single backing integer",
+	}
+	if !reflect.DeepEqual(errors, want) {
+		t.Fatalf("got errors:\n%v\nwant:\n%v", strings.Join(errors, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestUnitSizes(t *testing.T) {
+	t.Parallel()
+	const input = `
+foo(a ptr[in, s0], b ptr[in, array[int8, 100]], c unitsize[b, 512], d ptr[in, array[int8]], e unitsize[d, 512])
+s0 {
+	f0	unitsize[f1, 512, int16]
+	f1	array[int8, 1024]
+	f2	unitsize[f3, 512, int16]
+	f3	s1
+}
+s1 {
+	f0	array[int32, 100]
+}
+`
+	desc := ast.Parse([]byte(input), "input", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	var errors []string
+	eh := func(pos ast.Pos, msg string) {
+		errors = append(errors, fmt.Sprintf("%v:%v: %v", pos.Line, pos.Col, msg))
+	}
+	if p := Compile(desc, map[string]uint64{"SYS_foo": 1}, targets.List["test"]["64"], eh); p != nil {
+		t.Fatalf("compilation succeeded")
+	}
+	want := []string{
+		"6:2: unitsize target f3 of size 400 is not a multiple of unit 512",
+		"2:49: unitsize target b of size 100 is not a multiple of unit 512",
+	}
+	if !reflect.DeepEqual(errors, want) {
+		t.Fatalf("got errors:\n%v\nwant:\n%v", strings.Join(errors, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestUnusedConsts(t *testing.T) {
+	t.Parallel()
+	const input = `
+define DEF0	CONST1 | CONST2
+define DEF1	CONST3
+foo(a const[DEF0], b flags[flags0])
+bar(a const[CONST4], b int32[CONST5:CONST6])
+flags0 = CONST7, CONST8
+`
+	const constFile = `
+SYS_foo = 1
+# bar is unsupported, but its consts are still considered used.
+CONST1 = 1
+CONST2 = 2
+CONST3 = 3
+CONST5 = 5
+CONST6 = 6
+CONST7 = 7
+DEF0 = 3
+DEF1 = 3
+UNUSED0 = 0
+UNUSED1 = 0
+UNUSED2 = 0
+`
+	var warnings []string
+	eh := func(pos ast.Pos, msg string) {
+		warnings = append(warnings, fmt.Sprintf("%v: %v", pos, msg))
+	}
+	desc := ast.Parse([]byte(input), "input", eh)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	positions := make(map[string]ast.Pos)
+	consts := deserializeConsts([]byte(constFile), "input_64.const", eh, positions)
+	opts := Options{
+		RetainedConsts: map[string]bool{"UNUSED1": true},
+		ConstPositions: positions,
+	}
+	if p := CompileOpts(desc, consts, targets.List["test"]["64"], eh, opts); p == nil {
+		t.Fatalf("compilation failed: %q", warnings)
+	}
+	want := []string{
+		"input:5:1: unsupported syscall: bar due to missing const SYS_bar",
+		"input_64.const:11:1: unused const DEF1",
+		"input_64.const:12:1: unused const UNUSED0",
+		"input_64.const:14:1: unused const UNUSED2",
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Fatalf("got warnings: %q\nwant: %q", warnings, want)
+	}
+}
+
+func TestFilterCalls(t *testing.T) {
+	t.Parallel()
+	const input = `
+resource r0[int32]
+resource r1[r0]
+resource r2[int32]
+resource r3[int32] [compatible_with[r2]]
+foo$0() r1
+foo$1(a r0)
+bar$0() r2
+bar$1(a ptr[in, s0])
+baz(a ptr[in, s1])
+qux(a r2) r0
+quux() r3
+s0 {
+	f0	r2
+}
+s1 {
+	f0	int32
+}
+`
+	desc := ast.Parse([]byte(input), "input", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	consts := map[string]uint64{"SYS_foo": 1, "SYS_bar": 2, "SYS_baz": 3, "SYS_qux": 4, "SYS_quux": 5}
+	tests := []struct {
+		include   []string
+		exclude   []string
+		calls     []string
+		resources []string
+		structs   []string
+	}{
+		{
+			calls:     []string{"bar$0", "bar$1", "baz", "foo$0", "foo$1", "quux", "qux"},
+			resources: []string{"r0", "r1", "r2", "r3"},
+			structs:   []string{"s0", "s1"},
+		},
+		{
+			include:   []string{"foo$*"},
+			calls:     []string{"foo$0", "foo$1"},
+			resources: []string{"r0", "r1"},
+		},
+		{
+			// Consumers of resources without producers are discarded.
+			include: []string{"foo$*", "ba?$1"},
+			exclude: []string{"foo$0"},
+		},
+		{
+			include:   []string{"foo$1", "bar$*"},
+			calls:     []string{"bar$0", "bar$1"},
+			resources: []string{"r2"},
+			structs:   []string{"s0"},
+		},
+		{
+			// Producers are computed transitively.
+			include:   []string{"foo$1", "bar$0", "qux"},
+			calls:     []string{"bar$0", "foo$1", "qux"},
+			resources: []string{"r0", "r2"},
+		},
+		{
+			include: []string{"foo$1", "qux"},
+		},
+		{
+			// Compatible resources can be used instead of each other.
+			include:   []string{"bar$1", "quux"},
+			calls:     []string{"bar$1", "quux"},
+			resources: []string{"r2", "r3"},
+			structs:   []string{"s0"},
+		},
+		{
+			exclude:   []string{"bar*"},
+			calls:     []string{"baz", "foo$0", "foo$1", "quux", "qux"},
+			resources: []string{"r0", "r1", "r2", "r3"},
+			structs:   []string{"s1"},
+		},
+	}
+	for i, test := range tests {
+		opts := Options{IncludeCalls: test.include, ExcludeCalls: test.exclude}
+		p := CompileOpts(desc, consts, targets.List["test"]["64"], nil, opts)
+		if p == nil {
+			t.Fatalf("#%v: compilation failed", i)
+		}
+		var calls, resources, structs []string
+		for _, c := range p.Syscalls {
+			calls = append(calls, c.Name)
+		}
+		for _, res := range p.Resources {
+			resources = append(resources, res.Name)
+		}
+		for _, s := range p.StructDescs {
+			structs = append(structs, s.Key.Name)
+		}
+		if !reflect.DeepEqual(calls, test.calls) ||
+			!reflect.DeepEqual(resources, test.resources) ||
+			!reflect.DeepEqual(structs, test.structs) {
+			t.Errorf("#%v: got calls %q, resources %q, structs %q\nwant calls %q, resources %q, structs %q",
+				i, calls, resources, structs, test.calls, test.resources, test.structs)
+		}
+	}
+	eh := func(pos ast.Pos, msg string) {}
+	if CompileOpts(desc, consts, targets.List["test"]["64"], eh, Options{IncludeCalls: []string{"foo["}}) != nil {
+		t.Fatalf("compilation with bad pattern succeeded")
+	}
+}
+
+func TestRequiredCalls(t *testing.T) {
+	t.Parallel()
+	const input = `
+foo$0(a int32)
+foo$1(a int32) (requires[foo$0])
+bar$0(a int32)
+bar$1(a int32) (requires[bar$0])
+bar$2(a int32) (requires[foo$0, bar$1])
+baz(a int32) (requires[foo$1])
+`
+	desc := ast.Parse([]byte(input), "input", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	tests := []struct {
+		consts   map[string]uint64
+		exclude  []string
+		calls    []string
+		warnings []string
+	}{
+		{
+			consts: map[string]uint64{"SYS_foo": 1, "SYS_bar": 2, "SYS_baz": 3},
+			calls:  []string{"bar$0", "bar$1", "bar$2", "baz", "foo$0", "foo$1"},
+		},
+		{
+			// Calls that require unsupported calls are unsupported as well.
+			consts: map[string]uint64{"SYS_bar": 2, "SYS_baz": 3},
+			calls:  []string{"bar$0", "bar$1"},
+			warnings: []string{
+				"unsupported syscall: foo due to missing const SYS_foo",
+				"unsupported syscall: bar$2 due to unsupported required syscall foo$0",
+				"unsupported syscall: baz due to unsupported required syscall foo$1",
+			},
+		},
+		{
+			// Calls that require filtered out calls are filtered out silently.
+			consts:  map[string]uint64{"SYS_foo": 1, "SYS_bar": 2, "SYS_baz": 3},
+			exclude: []string{"bar$0"},
+			calls:   []string{"baz", "foo$0", "foo$1"},
+		},
+	}
+	for i, test := range tests {
+		var warnings []string
+		eh := func(pos ast.Pos, msg string) {
+			warnings = append(warnings, msg)
+		}
+		opts := Options{ExcludeCalls: test.exclude}
+		p := CompileOpts(desc, test.consts, targets.List["test"]["64"], eh, opts)
+		if p == nil {
+			t.Fatalf("#%v: compilation failed: %q", i, warnings)
+		}
+		var calls []string
+		for _, c := range p.Syscalls {
+			calls = append(calls, c.Name)
+		}
+		if !reflect.DeepEqual(calls, test.calls) || !reflect.DeepEqual(warnings, test.warnings) {
+			t.Errorf("#%v: got calls %q, warnings %q\nwant calls %q, warnings %q",
+				i, calls, warnings, test.calls, test.warnings)
+		}
+	}
+}
+
+func TestCompletionCalls(t *testing.T) {
+	t.Parallel()
+	const input = `
+foo$0(a int32)
+foo$1(a int32)
+qux(a int32)
+bar(a int32) (completes[foo$0, qux])
+baz(a int32) (completes[qux])
+`
+	desc := ast.Parse([]byte(input), "input", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	tests := []struct {
+		consts    map[string]uint64
+		exclude   []string
+		completes map[string][]string
+		warnings  []string
+	}{
+		{
+			consts: map[string]uint64{"SYS_foo": 1, "SYS_qux": 2, "SYS_bar": 3, "SYS_baz": 4},
+			completes: map[string][]string{
+				"bar":   {"foo$0", "qux"},
+				"baz":   {"qux"},
+				"foo$0": nil,
+				"foo$1": nil,
+				"qux":   nil,
+			},
+		},
+		{
+			// Calls that complete only unsupported calls are unsupported as well,
+			// unsupported calls are removed from completes of the remaining calls.
+			consts: map[string]uint64{"SYS_foo": 1, "SYS_bar": 3, "SYS_baz": 4},
+			completes: map[string][]string{
+				"bar":   {"foo$0"},
+				"foo$0": nil,
+				"foo$1": nil,
+			},
+			warnings: []string{
+				"unsupported syscall: qux due to missing const SYS_qux",
+				"unsupported syscall: baz due to unsupported completed syscalls",
+			},
+		},
+		{
+			// Calls that complete only filtered out calls are filtered out silently.
+			consts:  map[string]uint64{"SYS_foo": 1, "SYS_qux": 2, "SYS_bar": 3, "SYS_baz": 4},
+			exclude: []string{"qux"},
+			completes: map[string][]string{
+				"bar":   {"foo$0"},
+				"foo$0": nil,
+				"foo$1": nil,
+			},
+		},
+	}
+	for i, test := range tests {
+		var warnings []string
+		eh := func(pos ast.Pos, msg string) {
+			warnings = append(warnings, msg)
+		}
+		opts := Options{ExcludeCalls: test.exclude}
+		p := CompileOpts(desc, test.consts, targets.List["test"]["64"], eh, opts)
+		if p == nil {
+			t.Fatalf("#%v: compilation failed: %q", i, warnings)
+		}
+		completes := make(map[string][]string)
+		for _, c := range p.Syscalls {
+			completes[c.Name] = c.Completes
+		}
+		if !reflect.DeepEqual(completes, test.completes) || !reflect.DeepEqual(warnings, test.warnings) {
+			t.Errorf("#%v: got completes %q, warnings %q\nwant completes %q, warnings %q",
+				i, completes, warnings, test.completes, test.warnings)
+		}
+	}
+}
+
 func TestFuzz(t *testing.T) {
 	t.Parallel()
-	inputs := []string{
-		"d~^gB̉`i\u007f?\xb0.",
-		"da[",
-		"define\x98define(define\x98define\x98define\x98define\x98define)define\tdefin",
-		"resource g[g]",
-		`t[
-l	t
-]`,
-		`t()D[0]
-type D[e]l`,
+	inputs := []string{
+		"d~^gB̉`i\u007f?\xb0.",
+		"da[",
+		"define\x98define(define\x98define\x98define\x98define\x98define)define\tdefin",
+		"resource g[g]",
+		`t[
+l	t
+]`,
+		`t()D[0]
+type D[e]l`,
+	}
+	consts := map[string]uint64{"A": 1, "B": 2, "C": 3, "SYS_A": 4, "SYS_B": 5, "SYS_C": 6}
+	eh := func(pos ast.Pos, msg string) {
+		t.Logf("%v: %v", pos, msg)
+	}
+	for _, data := range inputs {
+		desc := ast.Parse([]byte(data), "", eh)
+		if desc != nil {
+			Compile(desc, consts, targets.List["test"]["64"], eh)
+		}
+	}
+}
+
+func TestAlign(t *testing.T) {
+	t.Parallel()
+	const input = `
+foo$0(a ptr[in, s0])
+s0 {
+	f0	int8
+	f1	int16
+}
+
+foo$1(a ptr[in, s1])
+s1 {
+	f0	ptr[in, s2, opt]
+}
+s2 {
+	f1	s1
+	f2	array[s1, 2]
+	f3	array[array[s1, 2], 2]
+}
+	`
+	desc := ast.Parse([]byte(input), "input", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	p := Compile(desc, map[string]uint64{"SYS_foo": 1}, targets.List["test"]["64"], nil)
+	if p == nil {
+		t.Fatal("failed to compile")
+	}
+	got := p.StructDescs[0].Desc
+	t.Logf("got: %#v", got)
+}
+
+func TestRegBlocks(t *testing.T) {
+	t.Parallel()
+	const input = `
+foo(a ptr[in, regs])
+regblock regs {
+	ctrl	int32 {
+		enable	1
+		mode	3
+	}
+	status	int16be {
+		ready	1
+		error	15
+	}
+	data	int8
+}
+`
+	desc := ast.Parse([]byte(input), "input", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	p := Compile(desc, map[string]uint64{"SYS_foo": 1}, targets.List["test"]["64"], nil)
+	if p == nil {
+		t.Fatal("failed to compile")
+	}
+	var str *prog.StructDesc
+	for _, s := range p.StructDescs {
+		if s.Key.Name == "regs" {
+			str = s.Desc
+		}
+	}
+	var got []string
+	for _, f := range str.Fields {
+		got = append(got, fmt.Sprintf("%v:%v:%v:%v", f.FieldName(), f.Size(),
+			f.BitfieldOffset(), f.BitfieldLength()))
+	}
+	want := []string{
+		"ctrl_enable:4:0:1",
+		"ctrl_mode:4:1:3",
+		"ctrl_reserved:4:4:28",
+		"status_ready:2:0:1",
+		"status_error:2:1:15",
+		"data:1:0:0",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got fields: %q\nwant: %q", got, want)
+	}
+	if size := str.Size(); size != 7 {
+		t.Fatalf("got size %v, want 7", size)
+	}
+}
+
+func TestIntptr(t *testing.T) {
+	t.Parallel()
+	const input = `
+foo(a ptr[in, s0], b intptr, c len[a])
+s0 {
+	f0	int8
+	f1	intptr
+	f2	len[parent, intptr]
+	f3	intptr:4
+	f4	int32:4
+	f5	const[1, intptr]
+}
+`
+	desc := ast.Parse([]byte(input), "input", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
 	}
-	consts := map[string]uint64{"A": 1, "B": 2, "C": 3, "SYS_A": 4, "SYS_B": 5, "SYS_C": 6}
-	eh := func(pos ast.Pos, msg string) {
-		t.Logf("%v: %v", pos, msg)
+	type Field struct {
+		Name   string
+		Size   uint64
+		Middle bool
 	}
-	for _, data := range inputs {
-		desc := ast.Parse([]byte(data), "", eh)
-		if desc != nil {
-			Compile(desc, consts, targets.List["test"]["64"], eh)
+	tests := []struct {
+		arch   string
+		size   uint64
+		fields []Field
+	}{
+		{
+			arch: "64",
+			size: 48,
+			fields: []Field{
+				{"f0", 1, false}, {"", 7, false}, {"f1", 8, false}, {"f2", 8, false},
+				// intptr and int32 bitfields have different sizes and are not merged.
+				{"f3", 8, false}, {"f4", 4, false}, {"", 4, false}, {"f5", 8, false},
+			},
+		},
+		{
+			arch: "32_shmem",
+			size: 20,
+			fields: []Field{
+				{"f0", 1, false}, {"", 3, false}, {"f1", 4, false}, {"f2", 4, false},
+				{"f3", 4, true}, {"f4", 4, false}, {"f5", 4, false},
+			},
+		},
+	}
+	for _, test := range tests {
+		target := targets.List["test"][test.arch]
+		p := Compile(desc, map[string]uint64{"SYS_foo": 1}, target, nil)
+		if p == nil {
+			t.Fatalf("%v: failed to compile", test.arch)
+		}
+		for _, arg := range p.Syscalls[0].Args[1:] {
+			if arg.Size() != target.PtrSize {
+				t.Errorf("%v: arg %v has size %v, want %v", test.arch, arg.FieldName(), arg.Size(), target.PtrSize)
+			}
+		}
+		s := p.StructDescs[0].Desc
+		if s.TypeSize != test.size {
+			t.Errorf("%v: struct size %v, want %v", test.arch, s.TypeSize, test.size)
+		}
+		var fields []Field
+		for _, f := range s.Fields {
+			fields = append(fields, Field{f.FieldName(), f.Size(), f.BitfieldMiddle()})
+		}
+		if !reflect.DeepEqual(fields, test.fields) {
+			t.Errorf("%v: got fields:\n%+v\nwant:\n%+v", test.arch, fields, test.fields)
 		}
 	}
 }
 
-func TestAlign(t *testing.T) {
+func TestCompat(t *testing.T) {
 	t.Parallel()
 	const input = `
-foo$0(a ptr[in, s0])
+foo$native(a ptr[in, s0], b intptr)
+foo$compat(a ptr[in, s0], b intptr) (compat)
 s0 {
 	f0	int8
-	f1	int16
+	f1	ptr[in, s1]
+	f2	intptr
+	f3	int32
+	f4	s1
 }
-
-foo$1(a ptr[in, s1])
 s1 {
-	f0	ptr[in, s2, opt]
-}
-s2 {
-	f1	s1
-	f2	array[s1, 2]
-	f3	array[array[s1, 2], 2]
-}
-	`
+	f0	intptr
+	f1	int16
+} [align_ptr]
+`
 	desc := ast.Parse([]byte(input), "input", nil)
 	if desc == nil {
 		t.Fatal("failed to parse")
@@ -239,8 +1795,80 @@ s2 {
 	if p == nil {
 		t.Fatal("failed to compile")
 	}
-	got := p.StructDescs[0].Desc
-	t.Logf("got: %#v", got)
+	type Field struct {
+		Name string
+		Size uint64
+	}
+	tests := []struct {
+		call    string
+		ptrSize uint64
+		structs map[string][]Field
+	}{
+		{
+			call:    "foo$native",
+			ptrSize: 8,
+			structs: map[string][]Field{
+				"s0": {{"f0", 1}, {"", 7}, {"f1", 8}, {"f2", 8}, {"f3", 4}, {"", 4}, {"f4", 16}},
+				"s1": {{"f0", 8}, {"f1", 2}, {"", 6}},
+			},
+		},
+		{
+			call:    "foo$compat",
+			ptrSize: 4,
+			structs: map[string][]Field{
+				"s0:compat": {{"f0", 1}, {"", 3}, {"f1", 4}, {"f2", 4}, {"f3", 4}, {"f4", 8}},
+				"s1:compat": {{"f0", 4}, {"f1", 2}, {"", 2}},
+			},
+		},
+	}
+	calls := make(map[string]*prog.Syscall)
+	for _, c := range p.Syscalls {
+		calls[c.Name] = c
+	}
+	structs := make(map[string]*prog.StructDesc)
+	for _, s := range p.StructDescs {
+		structs[s.Key.Name] = s.Desc
+	}
+	if len(structs) != 4 {
+		t.Errorf("got %v structs, want 4", len(structs))
+	}
+	for _, test := range tests {
+		c := calls[test.call]
+		if c.Compat != (test.ptrSize == 4) {
+			t.Errorf("%v: compat %v", test.call, c.Compat)
+		}
+		for _, arg := range c.Args {
+			if arg.Size() != test.ptrSize {
+				t.Errorf("%v: arg %v has size %v, want %v", test.call, arg.FieldName(), arg.Size(), test.ptrSize)
+			}
+		}
+		for name, want := range test.structs {
+			s := structs[name]
+			if s == nil {
+				t.Errorf("%v: no struct %v", test.call, name)
+				continue
+			}
+			var fields []Field
+			size := uint64(0)
+			for _, f := range s.Fields {
+				var fsize uint64
+				if st, ok := f.(*prog.StructType); ok {
+					// Descriptions of inner structs are detached by the compiler.
+					fsize = structs[st.Key.Name].TypeSize
+				} else {
+					fsize = f.Size()
+				}
+				fields = append(fields, Field{f.FieldName(), fsize})
+				size += fsize
+			}
+			if s.TypeSize != size {
+				t.Errorf("%v: struct %v size %v, want %v", test.call, name, s.TypeSize, size)
+			}
+			if !reflect.DeepEqual(fields, want) {
+				t.Errorf("%v: struct %v got fields:\n%+v\nwant:\n%+v", test.call, name, fields, want)
+			}
+		}
+	}
 }
 
 func TestCollectUnusedError(t *testing.T) {
@@ -329,3 +1957,512 @@ func TestCollectUnused(t *testing.T) {
 		}
 	}
 }
+
+func TestSerializeBinary(t *testing.T) {
+	t.Parallel()
+	eh := func(pos ast.Pos, msg string) {
+		t.Logf("%v: %v", pos, msg)
+	}
+	for _, arch := range []string{"32_shmem", "64"} {
+		target := targets.List["test"][arch]
+		path := filepath.Join("..", "..", "sys", "test")
+		desc := ast.ParseGlob(filepath.Join(path, "*.txt"), eh)
+		consts := DeserializeConstsGlob(filepath.Join(path, "*_"+arch+".const"), eh)
+		if desc == nil || consts == nil {
+			t.Fatalf("failed to parse descriptions")
+		}
+		p := Compile(desc, consts, target, eh)
+		if p == nil {
+			t.Fatalf("failed to compile")
+		}
+		if err := p.CheckRoundTrip(); err != nil {
+			t.Fatal(err)
+		}
+		data := p.SerializeBinary()
+		for _, bad := range [][]byte{
+			data[:len(data)-1],
+			append(append([]byte{}, data...), 0),
+			[]byte("SYZDESC\xff\x00"),
+			data[1:],
+		} {
+			if _, _, _, err := prog.DeserializeDescriptions(bad); err == nil {
+				t.Fatalf("deserialized bad data %q", bad[:10])
+			}
+		}
+	}
+}
+
+func TestRoundTripDiff(t *testing.T) {
+	t.Parallel()
+	type node struct {
+		Name string
+		Next *node
+		Args []prog.Type
+		vals map[string]uint64
+	}
+	makeNodes := func() *node {
+		n := &node{
+			Name: "n0",
+			Args: []prog.Type{
+				&prog.IntType{IntTypeCommon: prog.IntTypeCommon{BitfieldLen: 3}},
+				&prog.LenType{Buf: "a"},
+			},
+			vals: map[string]uint64{"a": 1},
+		}
+		n.Next = &node{Name: "n1", Next: n}
+		return n
+	}
+	tests := []struct {
+		change func(n *node)
+		diff   string
+	}{
+		{
+			change: func(n *node) {},
+			diff:   "",
+		},
+		{
+			change: func(n *node) { n.Next.Name = "n2" },
+			diff:   `.Next.Name: "n1" != "n2"`,
+		},
+		{
+			change: func(n *node) { n.Args[0].(*prog.IntType).BitfieldLen = 0 },
+			diff:   ".Args[0].IntTypeCommon.BitfieldLen: 3 != 0",
+		},
+		{
+			change: func(n *node) { n.Args[1] = &prog.ConstType{} },
+			diff:   ".Args[1]: *prog.LenType != *prog.ConstType",
+		},
+		{
+			change: func(n *node) { n.Args = n.Args[:1] },
+			diff:   ".Args: len 2 != len 1",
+		},
+		{
+			change: func(n *node) { n.Args[1].(*prog.LenType).Expr = &prog.LenExpr{} },
+			diff:   ".Args[1].Expr: nil != non-nil",
+		},
+		{
+			change: func(n *node) { n.vals["a"] = 2 },
+			diff:   `.vals["a"]: 1 != 2`,
+		},
+	}
+	for i, test := range tests {
+		n1, n2 := makeNodes(), makeNodes()
+		test.change(n2)
+		if diff := firstDiff(reflect.ValueOf(n1), reflect.ValueOf(n2)); diff != test.diff {
+			t.Errorf("test #%v: got diff %q, want %q", i, diff, test.diff)
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	t.Parallel()
+	const input = `
+resource fd[int32]
+foo(a ptr[in, s0], b flags[f]) fd
+bar(a fd)
+s0 {
+	f0	int32
+	f1	len[f0, int8]
+}
+f = A, B
+`
+	target := targets.List["test"]["64"]
+	consts := map[string]uint64{"SYS_foo": 1, "SYS_bar": 2, "A": 1, "B": 2}
+	eh := func(pos ast.Pos, msg string) {
+		t.Logf("%v: %v", pos, msg)
+	}
+	compile := func(input string, consts map[string]uint64) string {
+		desc := ast.Parse([]byte(input), "input", nil)
+		if desc == nil {
+			t.Fatalf("failed to parse:\n%v", input)
+		}
+		p := CompileOpts(desc, consts, target, eh, Options{Hash: true})
+		if p == nil {
+			t.Fatalf("failed to compile:\n%v", input)
+		}
+		if p.Hash == "" {
+			t.Fatalf("empty hash")
+		}
+		return p.Hash
+	}
+	hash := compile(input, consts)
+	if hash1 := compile(input, consts); hash1 != hash {
+		t.Fatalf("hash is not stable: %v vs %v", hash, hash1)
+	}
+	// Order of declarations does not matter.
+	reordered := "f = A, B\nbar(a fd)\n" + strings.Replace(strings.Replace(input,
+		"f = A, B\n", "", 1), "bar(a fd)\n", "", 1)
+	if hash1 := compile(reordered, consts); hash1 != hash {
+		t.Fatalf("hash depends on order of declarations")
+	}
+	for i, change := range [][2]string{
+		{"f0\tint32", "f0\tint64"},
+		{"len[f0, int8]", "bytesize[f0, int8]"},
+		{"bar(a fd)", "bar(a fd) (nocover)"},
+		{"resource fd[int32]", "resource fd[int32]: 1"},
+		{"f = A, B", "f = A"},
+	} {
+		changed := strings.Replace(input, change[0], change[1], 1)
+		if changed == input {
+			t.Fatalf("change %v does not apply", i)
+		}
+		if compile(changed, consts) == hash {
+			t.Errorf("change %q -> %q does not change hash", change[0], change[1])
+		}
+	}
+	consts1 := make(map[string]uint64)
+	for name, val := range consts {
+		consts1[name] = val
+	}
+	consts1["B"] = 4
+	if compile(input, consts1) == hash {
+		t.Errorf("const value change does not change hash")
+	}
+}
+
+func TestCtypes(t *testing.T) {
+	t.Parallel()
+	const input = `
+foo(a ptr[in, s0], b ptr[in, u0], c ptr[in, s$2])
+s0 {
+	f0	int8
+	f1	int32be
+	f2	int16:3
+	f3	int16:5
+	f4	int16:9
+	f5	array[s1, 2]
+	f6	ptr[in, int8]
+	f7	array[int8]
+}
+s1 {
+	f0	int64
+} [packed]
+u0 [
+	f0	int32
+	f1	array[int8, 3]
+] [size[8]]
+s$2 {
+	f0	int16
+	f1	u0
+}
+`
+	desc := ast.Parse([]byte(input), "input", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	eh := func(pos ast.Pos, msg string) {
+		t.Logf("%v: %v", pos, msg)
+	}
+	consts := map[string]uint64{"SYS_foo": 1}
+	p := CompileOpts(desc, consts, targets.List["test"]["64"], eh, Options{Ctypes: true})
+	if p == nil {
+		t.Fatal("failed to compile")
+	}
+	const want = `# AUTOGENERATED FILE
+# ctypes definitions of test/64 structs and unions.
+
+import ctypes
+
+
+class u0(ctypes.Union):
+    _pack_ = 1
+    _fields_ = [
+        ("f0", ctypes.c_uint32),
+        ("f1", ctypes.c_uint8 * 3),
+        ("_pad0", ctypes.c_uint8 * 8),
+    ]
+
+
+class s_2(ctypes.Structure):
+    _pack_ = 1
+    _fields_ = [
+        ("f0", ctypes.c_uint16),
+        ("_pad0", ctypes.c_uint8 * 2),
+        ("f1", u0),
+    ]
+
+
+class s1(ctypes.Structure):
+    _pack_ = 1
+    _fields_ = [
+        ("f0", ctypes.c_uint64),
+    ]
+
+
+# s0 has variable length.
+class s0(ctypes.Structure):
+    _pack_ = 1
+    _fields_ = [
+        ("f0", ctypes.c_uint8),
+        ("_pad0", ctypes.c_uint8 * 3),
+        ("f1", ctypes.c_uint32.__ctype_be__),
+        ("f2", ctypes.c_uint16, 3),
+        ("f3", ctypes.c_uint16, 5),
+        ("_sep0", ctypes.c_uint8 * 0),
+        ("f4", ctypes.c_uint16, 9),
+        ("f5", s1 * 2),
+        ("_pad1", ctypes.c_uint8 * 4),
+        ("f6", ctypes.c_uint64),
+        ("f7", ctypes.c_uint8 * 0),  # variable length
+    ]
+`
+	if got := string(p.Ctypes); got != want {
+		t.Fatalf("got:\n%v\nwant:\n%v", got, want)
+	}
+}
+
+func TestTypeDump(t *testing.T) {
+	t.Parallel()
+	const input = `
+resource r0[int32be]
+type t0 int16[0:10]
+foo(a ptr[in, s0], b flags[f0]) r0
+s0 {
+	f0	t0
+	f1	array[int8, 2]
+	f2	int8:3
+	f3	const[1, int64]
+}
+f0 = 1, 2
+`
+	desc := ast.Parse([]byte(input), "input", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	eh := func(pos ast.Pos, msg string) {
+		t.Logf("%v: %v", pos, msg)
+	}
+	consts := map[string]uint64{"SYS_foo": 1}
+	p := CompileOpts(desc, consts, targets.List["test"]["64"], eh, Options{TypeDump: true})
+	if p == nil {
+		t.Fatal("failed to compile")
+	}
+	const want = `# type descriptors of test/64 declarations.
+
+input:2:1: resource r0
+input:2:13: 	base int32be: desc=int8|int16|int32|int64|int16be|int32be|int64be|int16le|int32le|int64le|intptr args=[]
+
+input:4:1: call foo
+input:4:7: 	a ptr[in, s0]: desc=ptr|ptr64 args=[in, s0]
+input:4:15: 		s0: desc=struct args=[]
+input:4:22: 	b flags[f0]: desc=flags args=[f0] base=[size=8 format=native]
+input:4:33: 	ret r0: desc=resource args=[]
+
+input:5:1: struct s0
+input:6:5: 	f0 int16[0:10]: desc=int8|int16|int32|int64|int16be|int32be|int64be|int16le|int32le|int64le|intptr args=[0:10]
+input:7:5: 	f1 array[int8, 2]: desc=array args=[int8, 2]
+input:7:11: 		int8: desc=int8|int16|int32|int64|int16be|int32be|int64be|int16le|int32le|int64le|intptr args=[]
+input:8:5: 	f2 int8:3: desc=int8|int16|int32|int64|int16be|int32be|int64be|int16le|int32le|int64le|intptr args=[]
+input:9:5: 	f3 const[1, int64]: desc=const args=[1] base=[size=8 format=native]
+`
+	if got := string(p.TypeDump); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSourceMap(t *testing.T) {
+	t.Parallel()
+	const input = `
+resource r0[int32]
+type t0 int16[0:10]
+foo(a ptr[in, s0], b ptr[out, array[r0]]) r0
+s0 {
+	f0	t0
+	f1	int8
+	f2	u0
+}
+u0 [
+	f0	int32
+	f1	array[int8, 2]
+]
+`
+	desc := ast.Parse([]byte(input), "input", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	eh := func(pos ast.Pos, msg string) {
+		t.Logf("%v: %v", pos, msg)
+	}
+	consts := map[string]uint64{"SYS_foo": 1}
+	p := CompileOpts(desc, consts, targets.List["test"]["64"], eh, Options{SourceMap: true})
+	if p == nil {
+		t.Fatal("failed to compile")
+	}
+	const want = `foo.a ptr input:4:7
+foo.a* s0 input:4:15
+foo.b ptr input:4:22
+foo.b* array input:4:31
+foo.b*[] r0 input:4:37
+foo.ret r0 input:4:43
+s0/in s0 input:5:1
+s0/in.f0 int16 input:6:5
+s0/in.f1 int8 input:7:5
+s0/in.f2 u0 input:8:5
+u0/in u0 input:10:1
+u0/in.f0 int32 input:11:5
+u0/in.f1 array input:12:5
+`
+	got := ""
+	for _, e := range p.SourceMap.Entries {
+		got += fmt.Sprintf("%v %v %v\n", e.Path, e.Type, e.Pos)
+	}
+	if got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if pos := p.SourceMap.Types[p.Syscalls[0].Args[0]]; pos.Line != 4 || pos.Col != 7 {
+		t.Fatalf("wrong position of foo.a: %v", pos)
+	}
+}
+
+func TestInterfaceOnly(t *testing.T) {
+	t.Parallel()
+	const input = `
+foo$0(a fd_foo, b flags[foo_flags]) fd_bar
+foo$1(a ptr[in, s0])
+resource fd[int32]: -1
+resource fd_foo[fd]: FOO_NONE
+resource fd_bar[fd_foo]
+foo_flags = FOO_A, FOO_B
+s0 {
+	f0	const[FOO_C, int32]
+}
+`
+	consts := map[string]uint64{
+		"SYS_foo":  1,
+		"FOO_NONE": 0xf,
+		"FOO_A":    1,
+		"FOO_B":    2,
+		"FOO_C":    3,
+	}
+	target := targets.List["test"]["64"]
+	eh := func(pos ast.Pos, msg string) {
+		t.Logf("%v: %v", pos, msg)
+	}
+	desc := ast.Parse([]byte(input), "input", eh)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	p := CompileOpts(desc, consts, target, eh, Options{InterfaceOnly: true})
+	if p == nil {
+		t.Fatal("failed to compile")
+	}
+	if len(p.Syscalls) != 0 || len(p.StructDescs) != 0 {
+		t.Fatalf("generated %v syscalls and %v structs", len(p.Syscalls), len(p.StructDescs))
+	}
+	want := &Interface{
+		Version: InterfaceVersion,
+		Resources: []*InterfaceResource{
+			{Name: "fd", Kind: []string{"fd"}, Values: []uint64{0xffffffffffffffff}},
+			{Name: "fd_bar", Kind: []string{"fd", "fd_foo", "fd_bar"}, Values: []uint64{0xffffffffffffffff, 0xf}},
+			{Name: "fd_foo", Kind: []string{"fd", "fd_foo"}, Values: []uint64{0xffffffffffffffff, 0xf}},
+		},
+		Consts: consts,
+	}
+	if !reflect.DeepEqual(p.Interface, want) {
+		t.Fatalf("got interface:\n%s\nwant:\n%s", p.Interface.Serialize(), want.Serialize())
+	}
+	data := p.Interface.Serialize()
+	if data1 := p.Interface.Serialize(); !bytes.Equal(data, data1) {
+		t.Fatalf("serialization is not stable:\n%s\n%s", data, data1)
+	}
+	iface, err := DeserializeInterface(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(iface, want) {
+		t.Fatalf("deserialized interface differs:\n%s", iface.Serialize())
+	}
+	iface.Version++
+	if _, err := DeserializeInterface(iface.Serialize()); err == nil {
+		t.Fatal("deserialized interface with bad version")
+	}
+}
+
+func TestStrictResources(t *testing.T) {
+	t.Parallel()
+	consts := map[string]uint64{
+		"SYS_foo": 1,
+	}
+	target := targets.List["test"]["64"]
+	em := ast.NewErrorMatcher(t, filepath.Join("testdata", "resources.txt"))
+	desc := ast.Parse(em.Data, "resources.txt", em.ErrorHandler)
+	if desc == nil {
+		em.DumpErrors(t)
+		t.Fatalf("parsing failed")
+	}
+	opts := Options{ExcludeCalls: []string{"foo$excluded*"}}
+	// Dead resources must be accepted in the default mode.
+	if p := CompileOpts(desc, consts, target, em.ErrorHandler, opts); p == nil {
+		em.DumpErrors(t)
+		t.Fatalf("compilation failed")
+	}
+	opts.StrictResources = true
+	CompileOpts(desc, consts, target, em.ErrorHandler, opts)
+	em.Check(t)
+}
+
+func TestGenericBuffers(t *testing.T) {
+	t.Parallel()
+	const input = `
+foo$0(a ptr[in, array[int8]], b int32)
+foo$1(a ptr[in, s0])
+foo$2(a ptr[out, array[int8]], b int32)
+foo$3(a ptr[in, string], b int64)
+foo$4(a ptr[in, u0], b ptr[in, s1])
+s0 {
+	f0	int32
+	f1	array[int8, 4]
+}
+s1 {
+	f0	int32
+	f1	int32
+	f2	array[int8, 4]
+}
+u0 [
+	f0	array[int8, 8]
+	f1	int64
+]
+`
+	desc := ast.Parse([]byte(input), "input", nil)
+	if desc == nil {
+		t.Fatal("failed to parse")
+	}
+	tests := []struct {
+		threshold float64
+		warnings  []string
+	}{
+		{
+			threshold: 0.5,
+			warnings: []string{
+				"2: syscall foo$0 passes 256 of 260 input bytes (98%) in generic buffers: a",
+				"3: syscall foo$1 passes 4 of 8 input bytes (50%) in generic buffers: a",
+			},
+		},
+		{
+			// Size of a union is the average size of its options.
+			threshold: 0.4,
+			warnings: []string{
+				"2: syscall foo$0 passes 256 of 260 input bytes (98%) in generic buffers: a",
+				"3: syscall foo$1 passes 4 of 8 input bytes (50%) in generic buffers: a",
+				"6: syscall foo$4 passes 8 of 20 input bytes (40%) in generic buffers: a, b",
+			},
+		},
+		{
+			threshold: 0,
+		},
+	}
+	for i, test := range tests {
+		var warnings []string
+		eh := func(pos ast.Pos, msg string) {
+			warnings = append(warnings, fmt.Sprintf("%v: %v", pos.Line, msg))
+		}
+		consts := map[string]uint64{"SYS_foo": 1}
+		opts := Options{GenericBuffers: test.threshold}
+		if p := CompileOpts(desc, consts, targets.List["test"]["64"], eh, opts); p == nil {
+			t.Fatalf("#%v: compilation failed: %q", i, warnings)
+		}
+		if !reflect.DeepEqual(warnings, test.warnings) {
+			t.Errorf("#%v: got warnings: %q\nwant: %q", i, warnings, test.warnings)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/syzkaller/prog"
+)
+
+// SubsystemComplexity describes complexity of descriptions of a subsystem.
+// The subsystem of a call is the longest of Options.Subsystems that is a prefix of the call name,
+// or the name of the description file of the call without extension if none of them match.
+// Depth of an argument is 1 for scalar types plus 1 for every pointer, array, struct and union
+// that contains it (recursive structs are followed once), AvgArgDepth and MaxArgDepth are computed
+// over all arguments of the calls. Structs and Unions are numbers of distinct structs and unions
+// reachable from the calls. Consumes and Produces are numbers of distinct resources used and created
+// by the calls. Fan-in of a resource is the number of calls (of all subsystems) that create it
+// or a more specialized resource, fan-out is the number of calls that accept it as an argument,
+// AvgFanIn is averaged over consumed resources and AvgFanOut over produced resources.
+type SubsystemComplexity struct {
+	Subsystem   string  `json:"subsystem"`
+	Calls       int     `json:"calls"`
+	AvgArgDepth float64 `json:"avg_arg_depth"`
+	MaxArgDepth int     `json:"max_arg_depth"`
+	Structs     int     `json:"structs"`
+	Unions      int     `json:"unions"`
+	Consumes    int     `json:"consumes"`
+	Produces    int     `json:"produces"`
+	AvgFanIn    float64 `json:"avg_fan_in"`
+	AvgFanOut   float64 `json:"avg_fan_out"`
+}
+
+// complexityWalker collects types reachable from an argument.
+type complexityWalker struct {
+	descs    map[prog.StructKey]*prog.StructDesc
+	visiting map[string]bool
+	structs  map[string]bool
+	unions   map[string]bool
+	consumes map[string]bool
+	produces map[string]bool
+}
+
+// walk returns depth of t and notes structs, unions and resources reachable from t.
+func (w *complexityWalker) walk(t prog.Type) int {
+	switch a := t.(type) {
+	case *prog.PtrType:
+		return 1 + w.walk(a.Type)
+	case *prog.ArrayType:
+		return 1 + w.walk(a.Type)
+	case *prog.ResourceType:
+		if a.Dir() != prog.DirOut {
+			w.consumes[a.Name()] = true
+		}
+		if a.Dir() != prog.DirIn {
+			w.produces[a.Name()] = true
+		}
+		return 1
+	case *prog.StructType:
+		w.structs[a.Key.Name] = true
+		return w.walkFields(a.Key)
+	case *prog.UnionType:
+		w.unions[a.Key.Name] = true
+		return w.walkFields(a.Key)
+	default:
+		return 1
+	}
+}
+
+func (w *complexityWalker) walkFields(key prog.StructKey) int {
+	id := key.Name + "/" + key.Dir.String()
+	if w.visiting[id] {
+		return 1
+	}
+	w.visiting[id] = true
+	defer delete(w.visiting, id)
+	depth := 0
+	for _, f := range w.descs[key].Fields {
+		if d := w.walk(f); d > depth {
+			depth = d
+		}
+	}
+	return 1 + depth
+}
+
+func (comp *compiler) genComplexity(prg *Prog, prefixes []string) []*SubsystemComplexity {
+	files := make(map[string]string)
+	for _, n := range comp.desc.Nodes {
+		pos, typ, name := n.Info()
+		if typ == "syscall" {
+			files[name] = strings.TrimSuffix(filepath.Base(pos.File), filepath.Ext(pos.File))
+		}
+	}
+	descs := make(map[prog.StructKey]*prog.StructDesc)
+	for _, s := range prg.StructDescs {
+		descs[s.Key] = s.Desc
+	}
+	type subsystem struct {
+		*SubsystemComplexity
+		args     int
+		depth    int
+		walker   *complexityWalker
+		consumes map[string]bool
+		produces map[string]bool
+	}
+	subsystems := make(map[string]*subsystem)
+	// Resources consumed and produced by every call (for fan-in and fan-out).
+	consumes := make(map[*prog.Syscall]map[string]bool)
+	produces := make(map[*prog.Syscall]map[string]bool)
+	for _, c := range prg.Syscalls {
+		name := callSubsystem(c.Name, files[c.Name], prefixes)
+		sub := subsystems[name]
+		if sub == nil {
+			sub = &subsystem{
+				SubsystemComplexity: &SubsystemComplexity{Subsystem: name},
+				walker: &complexityWalker{
+					descs:    descs,
+					visiting: make(map[string]bool),
+					structs:  make(map[string]bool),
+					unions:   make(map[string]bool),
+				},
+				consumes: make(map[string]bool),
+				produces: make(map[string]bool),
+			}
+			subsystems[name] = sub
+		}
+		sub.Calls++
+		w := sub.walker
+		w.consumes, w.produces = make(map[string]bool), make(map[string]bool)
+		for _, arg := range c.Args {
+			d := w.walk(arg)
+			sub.args++
+			sub.depth += d
+			if d > sub.MaxArgDepth {
+				sub.MaxArgDepth = d
+			}
+		}
+		if c.Ret != nil {
+			w.walk(c.Ret)
+		}
+		consumes[c], produces[c] = w.consumes, w.produces
+		for res := range w.consumes {
+			sub.consumes[res] = true
+		}
+		for res := range w.produces {
+			sub.produces[res] = true
+		}
+	}
+	resources := make(map[string]*prog.ResourceDesc)
+	for _, res := range prg.Resources {
+		resources[res.Name] = res
+	}
+	fanIn := func(name string) int {
+		n := 0
+		for _, produced := range produces {
+			for res := range produced {
+				if resourceKindOf(resources[res], name) {
+					n++
+					break
+				}
+			}
+		}
+		return n
+	}
+	fanOut := func(name string) int {
+		n := 0
+		for _, consumed := range consumes {
+			for res := range consumed {
+				if resourceKindOf(resources[name], res) {
+					n++
+					break
+				}
+			}
+		}
+		return n
+	}
+	var res []*SubsystemComplexity
+	for _, sub := range subsystems {
+		if sub.args != 0 {
+			sub.AvgArgDepth = float64(sub.depth) / float64(sub.args)
+		}
+		sub.Structs = len(sub.walker.structs)
+		sub.Unions = len(sub.walker.unions)
+		sub.Consumes = len(sub.consumes)
+		sub.Produces = len(sub.produces)
+		total := 0
+		for name := range sub.consumes {
+			total += fanIn(name)
+		}
+		if sub.Consumes != 0 {
+			sub.AvgFanIn = float64(total) / float64(sub.Consumes)
+		}
+		total = 0
+		for name := range sub.produces {
+			total += fanOut(name)
+		}
+		if sub.Produces != 0 {
+			sub.AvgFanOut = float64(total) / float64(sub.Produces)
+		}
+		res = append(res, sub.SubsystemComplexity)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Subsystem < res[j].Subsystem
+	})
+	return res
+}
+
+// callSubsystem returns the longest of prefixes that is a prefix of the call name,
+// or file (the description file name) if none of them match.
+func callSubsystem(call, file string, prefixes []string) string {
+	name := ""
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(call, prefix) && len(prefix) > len(name) {
+			name = prefix
+		}
+	}
+	if name == "" {
+		return file
+	}
+	return name
+}
+
+// resourceKindOf returns true if res is the resource kind or a more specialized resource.
+func resourceKindOf(res *prog.ResourceDesc, kind string) bool {
+	if res == nil {
+		return false
+	}
+	for _, k := range res.Kind {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
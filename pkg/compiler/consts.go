@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -92,6 +93,23 @@ func (comp *compiler) extractConsts() map[string]*ConstInfo {
 					info.consts[attr.Args[0].Ident] = true
 				}
 			}
+			for _, fld := range n.Fields {
+				for _, v := range constAttrArgs(fld) {
+					if v.Ident != "" {
+						info := getConstInfo(infos, v.Pos)
+						info.consts[v.Ident] = true
+					}
+				}
+			}
+		case *ast.Call:
+			for _, arg := range n.Args {
+				for _, v := range constAttrArgs(arg) {
+					if v.Ident != "" {
+						info := getConstInfo(infos, v.Pos)
+						info.consts[v.Ident] = true
+					}
+				}
+			}
 		}
 	}
 
@@ -145,6 +163,7 @@ func (comp *compiler) assignSyscallNumbers(consts map[string]uint64) {
 			continue
 		}
 		str := comp.target.SyscallPrefix + c.CallName
+		comp.usedConsts[str] = true
 		nr, ok := consts[str]
 		if ok {
 			c.NR = nr
@@ -154,7 +173,7 @@ func (comp *compiler) assignSyscallNumbers(consts map[string]uint64) {
 		name := "syscall " + c.CallName
 		if !comp.unsupported[name] {
 			comp.unsupported[name] = true
-			comp.warning(c.Pos, "unsupported syscall: %v due to missing const %v",
+			comp.warning(c.Pos, WarnUnsupported, "unsupported syscall: %v due to missing const %v",
 				c.CallName, str)
 		}
 	}
@@ -175,6 +194,17 @@ func (comp *compiler) patchConsts(consts map[string]uint64) {
 				}
 			}
 			n.Values = values
+		case *ast.StaticAssert:
+			n := decl.(*ast.StaticAssert)
+			missing := ""
+			if !comp.patchIntConst(&n.Size.Value, &n.Size.Ident, consts, &missing) {
+				pos, typ, name := n.Info()
+				if id := typ + " " + name + " == " + missing; !comp.unsupported[id] {
+					comp.unsupported[id] = true
+					comp.warning(pos, WarnUnsupported, "unsupported %v: %v due to missing const %v",
+						typ, name, missing)
+				}
+			}
 		case *ast.Resource, *ast.Struct, *ast.Call, *ast.TypeDef:
 			// Walk whole tree and replace consts in Type's and Int's.
 			missing := ""
@@ -202,6 +232,18 @@ func (comp *compiler) patchConsts(consts map[string]uint64) {
 						comp.patchIntConst(&sz.Value, &sz.Ident, consts, &missing)
 					}
 				}
+				for _, fld := range n.Fields {
+					for _, v := range constAttrArgs(fld) {
+						comp.patchIntConst(&v.Value, &v.Ident, consts, &missing)
+					}
+				}
+			}
+			if n, ok := decl.(*ast.Call); ok {
+				for _, arg := range n.Args {
+					for _, v := range constAttrArgs(arg) {
+						comp.patchIntConst(&v.Value, &v.Ident, consts, &missing)
+					}
+				}
 			}
 			if missing == "" {
 				continue
@@ -214,7 +256,7 @@ func (comp *compiler) patchConsts(consts map[string]uint64) {
 			pos, typ, name := decl.Info()
 			if id := typ + " " + name; !comp.unsupported[id] {
 				comp.unsupported[id] = true
-				comp.warning(pos, "unsupported %v: %v due to missing const %v",
+				comp.warning(pos, WarnUnsupported, "unsupported %v: %v due to missing const %v",
 					typ, name, missing)
 			}
 			if c, ok := decl.(*ast.Call); ok {
@@ -224,10 +266,26 @@ func (comp *compiler) patchConsts(consts map[string]uint64) {
 	}
 }
 
+// constAttrArgs returns arguments of field attributes that can be consts
+// (nonblock and requested_by).
+func constAttrArgs(fld *ast.Field) []*ast.Type {
+	var args []*ast.Type
+	for _, attr := range fld.Attrs {
+		switch {
+		case attr.Ident == "nonblock" && len(attr.Args) == 1:
+			args = append(args, attr.Args[0])
+		case attr.Ident == "requested_by":
+			args = append(args, attr.Args...)
+		}
+	}
+	return args
+}
+
 func (comp *compiler) patchIntConst(val *uint64, id *string, consts map[string]uint64, missing *string) bool {
 	if *id == "" {
 		return true
 	}
+	comp.usedConsts[*id] = true
 	v, ok := consts[*id]
 	if !ok {
 		if missing != nil && *missing == "" {
@@ -238,6 +296,92 @@ func (comp *compiler) patchIntConst(val *uint64, id *string, consts map[string]u
 	return ok
 }
 
+// checkUnusedConsts produces a warning about consts that are present in consts map,
+// but are not referenced by descriptions. References from unsupported syscalls/structs/etc
+// still count since they can be supported on other arches.
+func (comp *compiler) checkUnusedConsts(consts map[string]uint64) {
+	for _, decl := range comp.desc.Nodes {
+		if n, ok := decl.(*ast.Define); ok {
+			// Defines can refer to other consts.
+			if n.Value.Ident != "" {
+				comp.usedConsts[n.Value.Ident] = true
+			}
+			for _, id := range cexprIdentRe.FindAllString(n.Value.CExpr, -1) {
+				comp.usedConsts[id] = true
+			}
+		}
+	}
+	var unused []string
+	for name := range consts {
+		if !comp.usedConsts[name] && !comp.opts.RetainedConsts[name] {
+			unused = append(unused, name)
+		}
+	}
+	if len(unused) == 0 {
+		return
+	}
+	sort.Strings(unused)
+	for _, name := range unused {
+		comp.warning(comp.opts.ConstPositions[name], WarnUnusedConst, "unused const %v", name)
+	}
+}
+
+// checkZeroConsts produces warnings about flags and ranges that refer to consts,
+// but resolve only to zero values. This usually means that the consts
+// were not properly extracted for the target arch.
+func (comp *compiler) checkZeroConsts() {
+	structs, flags, _ := comp.collectUsed(false)
+	for _, decl := range comp.desc.Nodes {
+		pos, typ, name := decl.Info()
+		switch n := decl.(type) {
+		case *ast.IntFlags:
+			if !flags[name] || len(n.Values) == 0 {
+				continue
+			}
+			zero, named := true, false
+			for _, v := range n.Values {
+				zero = zero && v.Value == 0
+				named = named || v.Ident != ""
+			}
+			if zero && named {
+				comp.warning(pos, WarnZeroConsts, "flags %v resolve only to zero values", name)
+			}
+		case *ast.Call:
+			if n.NR == ^uint64(0) {
+				continue
+			}
+			comp.checkZeroRanges(decl)
+		case *ast.Resource, *ast.Struct:
+			if !structs[name] || comp.unsupported[typ+" "+name] {
+				continue
+			}
+			comp.checkZeroRanges(decl)
+		}
+	}
+}
+
+func (comp *compiler) checkZeroRanges(decl ast.Node) {
+	comp.foreachType(decl, func(t *ast.Type, desc *typeDesc, args []*ast.Type, _ prog.IntTypeCommon) {
+		for i, arg := range args {
+			if desc.Args[i].Type.Kind != kindInt || !arg.HasColon ||
+				arg.Ident == "" && arg.Ident2 == "" || arg.Value != 0 || arg.Value2 != 0 {
+				continue
+			}
+			begin, end := arg.Ident, arg.Ident2
+			if begin == "" {
+				begin = "0"
+			}
+			if end == "" {
+				end = "0"
+			}
+			comp.warning(arg.Pos, WarnZeroConsts, "range [%v:%v] of %v resolves only to zero values",
+				begin, end, t.Ident)
+		}
+	})
+}
+
+var cexprIdentRe = regexp.MustCompile("[a-zA-Z_][a-zA-Z0-9_]*")
+
 func SerializeConsts(consts map[string]uint64, undeclared map[string]bool) []byte {
 	type nameValuePair struct {
 		declared bool
@@ -268,10 +412,17 @@ func SerializeConsts(consts map[string]uint64, undeclared map[string]bool) []byt
 }
 
 func DeserializeConsts(data []byte, file string, eh ast.ErrorHandler) map[string]uint64 {
+	return deserializeConsts(data, file, eh, nil)
+}
+
+// deserializeConsts is DeserializeConsts that also records positions of the consts in positions
+// (if not nil and the const is not there yet).
+func deserializeConsts(data []byte, file string, eh ast.ErrorHandler, positions map[string]ast.Pos) map[string]uint64 {
 	consts := make(map[string]uint64)
 	pos := ast.Pos{
 		File: file,
 		Line: 1,
+		Col:  1,
 	}
 	ok := true
 	s := bufio.NewScanner(bytes.NewReader(data))
@@ -299,6 +450,9 @@ func DeserializeConsts(data []byte, file string, eh ast.ErrorHandler) map[string
 			continue
 		}
 		consts[name] = val
+		if _, ok := positions[name]; positions != nil && !ok {
+			positions[name] = pos
+		}
 	}
 	if err := s.Err(); err != nil {
 		eh(pos, fmt.Sprintf("failed to parse: %v", err))
@@ -311,26 +465,34 @@ func DeserializeConsts(data []byte, file string, eh ast.ErrorHandler) map[string
 }
 
 func DeserializeConstsGlob(glob string, eh ast.ErrorHandler) map[string]uint64 {
+	consts, _ := DeserializeConstsGlobPos(glob, eh)
+	return consts
+}
+
+// DeserializeConstsGlobPos is DeserializeConstsGlob that also returns positions of the consts
+// in the const files (the first file that contains a const), see Options.ConstPositions.
+func DeserializeConstsGlobPos(glob string, eh ast.ErrorHandler) (map[string]uint64, map[string]ast.Pos) {
 	if eh == nil {
 		eh = ast.LoggingHandler
 	}
 	files, err := filepath.Glob(glob)
 	if err != nil {
 		eh(ast.Pos{}, fmt.Sprintf("failed to find const files: %v", err))
-		return nil
+		return nil, nil
 	}
 	if len(files) == 0 {
 		eh(ast.Pos{}, fmt.Sprintf("no const files matched by glob %q", glob))
-		return nil
+		return nil, nil
 	}
 	consts := make(map[string]uint64)
+	positions := make(map[string]ast.Pos)
 	for _, f := range files {
 		data, err := ioutil.ReadFile(f)
 		if err != nil {
 			eh(ast.Pos{}, fmt.Sprintf("failed to read const file: %v", err))
-			return nil
+			return nil, nil
 		}
-		consts1 := DeserializeConsts(data, filepath.Base(f), eh)
+		consts1 := deserializeConsts(data, filepath.Base(f), eh, positions)
 		if consts1 == nil {
 			consts = nil
 		}
@@ -339,11 +501,11 @@ func DeserializeConstsGlob(glob string, eh ast.ErrorHandler) map[string]uint64 {
 				if old, ok := consts[n]; ok && old != v {
 					eh(ast.Pos{}, fmt.Sprintf(
 						"different values for const %q: %v vs %v", n, v, old))
-					return nil
+					return nil, nil
 				}
 				consts[n] = v
 			}
 		}
 	}
-	return consts
+	return consts, positions
 }
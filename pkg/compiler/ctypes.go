@@ -0,0 +1,209 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/syzkaller/prog"
+)
+
+// genCtypes returns Python source with ctypes definitions of the compiled structs and unions.
+// Structs become ctypes.Structure's and unions become ctypes.Union's. Compiled structs already
+// contain explicit padding, so all classes use _pack_ = 1 and reproduce the compiled layout.
+// Bitfields become ctypes bitfield tuples (ctypes groups them the same way as markBitfields).
+// Pointers and resources are represented with integers of the corresponding size,
+// integers formatted as strings (fmt type) are represented with char arrays.
+// Variable-length buffers and arrays can't be expressed in ctypes, they become zero-size
+// byte arrays (so offsets of fields after a variable-length field in the middle of a struct
+// are not meaningful). Structs with several directions share the same class.
+func (comp *compiler) genCtypes(prg *Prog) []byte {
+	descs := make(map[string]*prog.StructDesc)
+	var names []string
+	for _, s := range prg.StructDescs {
+		if descs[s.Key.Name] == nil {
+			descs[s.Key.Name] = s.Desc
+			names = append(names, s.Key.Name)
+		}
+	}
+	sort.Strings(names)
+	classes := make(map[string]string)
+	used := make(map[string]bool)
+	for _, name := range names {
+		class := ctypesName(name)
+		for i := 0; used[class]; i++ {
+			class = fmt.Sprintf("%v_%v", ctypesName(name), i)
+		}
+		used[class] = true
+		classes[name] = class
+	}
+	ctx := &ctypesGen{
+		comp:    comp,
+		buf:     new(bytes.Buffer),
+		descs:   descs,
+		classes: classes,
+		emitted: make(map[string]bool),
+	}
+	fmt.Fprintf(ctx.buf, "# AUTOGENERATED FILE\n")
+	fmt.Fprintf(ctx.buf, "# ctypes definitions of %v/%v structs and unions.\n\n",
+		comp.target.OS, comp.target.Arch)
+	fmt.Fprintf(ctx.buf, "import ctypes\n")
+	for _, name := range names {
+		ctx.emit(name)
+	}
+	return ctx.buf.Bytes()
+}
+
+type ctypesGen struct {
+	comp    *compiler
+	buf     *bytes.Buffer
+	descs   map[string]*prog.StructDesc
+	classes map[string]string
+	emitted map[string]bool
+}
+
+// emit writes out the class for the struct/union name after classes it depends on.
+func (ctx *ctypesGen) emit(name string) {
+	if ctx.emitted[name] {
+		return
+	}
+	ctx.emitted[name] = true
+	desc := ctx.descs[name]
+	for _, f := range desc.Fields {
+		ctx.emitDeps(f)
+	}
+	base := "Structure"
+	if n := ctx.comp.structs[strings.TrimSuffix(name, compatSuffix)]; n != nil && n.IsUnion {
+		base = "Union"
+	}
+	fmt.Fprintf(ctx.buf, "\n\n")
+	if desc.IsVarlen {
+		fmt.Fprintf(ctx.buf, "# %v has variable length.\n", name)
+	}
+	fmt.Fprintf(ctx.buf, "class %v(ctypes.%v):\n", ctx.classes[name], base)
+	fmt.Fprintf(ctx.buf, "    _pack_ = 1\n")
+	if len(desc.Fields) == 0 {
+		fmt.Fprintf(ctx.buf, "    _fields_ = []\n")
+		return
+	}
+	fmt.Fprintf(ctx.buf, "    _fields_ = [\n")
+	pad, sep := 0, 0
+	for i, f := range desc.Fields {
+		fieldName := f.FieldName()
+		if prog.IsPad(f) {
+			fieldName = fmt.Sprintf("_pad%v", pad)
+			pad++
+		} else if fieldName == "" {
+			fieldName = fmt.Sprintf("_field%v", i)
+		}
+		typ, varlen := ctx.typ(f)
+		if isBitfield(f) {
+			typ = fmt.Sprintf("%v, %v", typ, f.BitfieldLength())
+		}
+		comment := ""
+		if varlen {
+			comment = "  # variable length"
+		}
+		fmt.Fprintf(ctx.buf, "        (%q, %v),%v\n", fieldName, typ, comment)
+		if isBitfield(f) && !f.BitfieldMiddle() && i+1 < len(desc.Fields) && isBitfield(desc.Fields[i+1]) {
+			// ctypes would put the next bitfield into the same storage unit if it fits,
+			// an empty field forces a new unit as markBitfields does.
+			fmt.Fprintf(ctx.buf, "        (%q, ctypes.c_uint8 * 0),\n", fmt.Sprintf("_sep%v", sep))
+			sep++
+		}
+	}
+	if base == "Union" && !desc.IsVarlen {
+		// Unions with size attribute are larger than all of their options.
+		size := uint64(0)
+		for _, f := range desc.Fields {
+			if sz := ctx.size(f); size < sz {
+				size = sz
+			}
+		}
+		if size < desc.Size() {
+			fmt.Fprintf(ctx.buf, "        (%q, ctypes.c_uint8 * %v),\n", "_pad0", desc.Size())
+		}
+	}
+	fmt.Fprintf(ctx.buf, "    ]\n")
+}
+
+func isBitfield(t prog.Type) bool {
+	switch t.(type) {
+	case *prog.StructType, *prog.UnionType:
+		// Descriptions of these types are detached, they can't be bitfields anyway.
+		return false
+	}
+	return t.BitfieldLength() != 0
+}
+
+// size returns size of the fixed-size type t.
+func (ctx *ctypesGen) size(t prog.Type) uint64 {
+	switch typ := t.(type) {
+	case *prog.StructType:
+		return ctx.descs[typ.Key.Name].Size()
+	case *prog.UnionType:
+		return ctx.descs[typ.Key.Name].Size()
+	}
+	return t.Size()
+}
+
+func (ctx *ctypesGen) emitDeps(t prog.Type) {
+	switch typ := t.(type) {
+	case *prog.StructType:
+		ctx.emit(typ.Key.Name)
+	case *prog.UnionType:
+		ctx.emit(typ.Key.Name)
+	case *prog.ArrayType:
+		ctx.emitDeps(typ.Type)
+	}
+}
+
+// typ returns ctypes type for t and whether t has variable length.
+func (ctx *ctypesGen) typ(t prog.Type) (string, bool) {
+	switch typ := t.(type) {
+	case *prog.StructType:
+		return ctx.classes[typ.Key.Name], ctx.descs[typ.Key.Name].IsVarlen
+	case *prog.UnionType:
+		return ctx.classes[typ.Key.Name], ctx.descs[typ.Key.Name].IsVarlen
+	case *prog.ArrayType:
+		if typ.Varlen() {
+			return "ctypes.c_uint8 * 0", true
+		}
+		elem, _ := ctx.typ(typ.Type)
+		return fmt.Sprintf("%v * %v", elem, typ.RangeBegin), false
+	case *prog.BufferType:
+		if typ.Varlen() {
+			return "ctypes.c_uint8 * 0", true
+		}
+		return fmt.Sprintf("ctypes.c_uint8 * %v", typ.Size()), false
+	case *prog.ConstType:
+		if typ.IsPad {
+			return fmt.Sprintf("ctypes.c_uint8 * %v", typ.Size()), false
+		}
+	}
+	switch t.Format() {
+	case prog.FormatStrDec, prog.FormatStrHex, prog.FormatStrOct:
+		return fmt.Sprintf("ctypes.c_char * %v", t.Size()), false
+	case prog.FormatBigEndian:
+		if t.Size() != 1 {
+			return fmt.Sprintf("ctypes.c_uint%v.__ctype_be__", t.Size()*8), false
+		}
+	}
+	return fmt.Sprintf("ctypes.c_uint%v", t.Size()*8), false
+}
+
+// ctypesName converts struct name (which can contain '$', template arguments
+// and compat suffix) to a Python identifier.
+func ctypesName(name string) string {
+	res := []byte(name)
+	for i, c := range res {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			res[i] = '_'
+		}
+	}
+	return strings.TrimRight(string(res), "_")
+}
@@ -0,0 +1,64 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"github.com/google/syzkaller/pkg/ast"
+)
+
+// Diagnostic is a structured form of a compiler error or warning,
+// it is passed to Options.Diagnostics in addition to the error handler.
+// Category of an error is the compilation phase that produced it;
+// category of a warning is one of the Warn* constants.
+type Diagnostic struct {
+	Severity string `json:"severity"`
+	Category string `json:"category"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Col      int    `json:"col,omitempty"`
+	Message  string `json:"message"`
+}
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Compilation phases used as error categories
+// (PhaseParse is not produced by the compiler, it's meant for errors reported by ast.Parse).
+const (
+	PhaseParse     = "parse"
+	PhaseMacros    = "macros"
+	PhaseTypecheck = "typecheck"
+	PhaseConsts    = "consts"
+	PhaseCheck     = "check"
+	PhaseGen       = "gen"
+)
+
+// Warning categories.
+const (
+	WarnUnsupported    = "unsupported"     // syscall/type/flag is disabled because of a missing const
+	WarnUnusedConst    = "unused_const"    // const files contain consts not used by descriptions
+	WarnLenTarget      = "len_target"      // len of an array with variable-size elements
+	WarnPtrDir         = "ptr_dir"         // direction of a type does not match direction of the enclosing pointee
+	WarnZeroConsts     = "zero_consts"     // flags or range refer to consts, but resolve only to zero values
+	WarnPureGetter     = "pure_getter"     // call has only output scalars and does not use resources
+	WarnGenericBuffers = "generic_buffers" // call passes most of its input in generic byte buffers
+	WarnUnreachable    = "unreachable"     // union option can never be selected by its tag
+	WarnStructDir      = "struct_dir"      // struct has different layouts in different directions
+)
+
+func (comp *compiler) diagnostic(severity, category string, pos ast.Pos, msg string) {
+	if comp.opts.Diagnostics == nil {
+		return
+	}
+	comp.opts.Diagnostics(&Diagnostic{
+		Severity: severity,
+		Category: category,
+		File:     pos.File,
+		Line:     pos.Line,
+		Col:      pos.Col,
+		Message:  msg,
+	})
+}
@@ -6,6 +6,7 @@ package compiler
 import (
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/google/syzkaller/pkg/ast"
 	"github.com/google/syzkaller/prog"
@@ -27,24 +28,95 @@ func (comp *compiler) genResources() []*prog.ResourceDesc {
 	return resources
 }
 
+func (comp *compiler) genFlags() []prog.FlagDesc {
+	var flags []prog.FlagDesc
+	for name, n := range comp.intFlags {
+		if !comp.usedFlags[name] {
+			continue
+		}
+		f := prog.FlagDesc{Name: name}
+		for _, v := range n.Values {
+			if v.Ident != "" {
+				f.Values = append(f.Values, v.Ident)
+			}
+		}
+		if len(f.Values) != 0 {
+			flags = append(flags, f)
+		}
+	}
+	sort.Slice(flags, func(i, j int) bool {
+		return flags[i].Name < flags[j].Name
+	})
+	return flags
+}
+
 func (comp *compiler) genResource(n *ast.Resource) *prog.ResourceDesc {
 	res := &prog.ResourceDesc{
-		Name: n.Name.Name,
+		Name:       n.Name.Name,
+		Refcounted: isRefcounted(n),
+		Event:      isEvent(n),
+	}
+	res.Compatible = comp.genResourceCompatible(n)
+	res.Type = comp.genResourceBase(n)
+	if base, ok := res.Type.(*prog.IntType); ok && base.Kind == prog.IntRange {
+		res.HasRange = true
+		res.RangeBegin, res.RangeEnd = base.RangeBegin, base.RangeEnd
 	}
-	var base *ast.Type
 	for n != nil {
 		res.Values = append(genIntArray(n.Values), res.Values...)
 		res.Kind = append([]string{n.Name.Name}, res.Kind...)
-		base = n.Base
+		if res.Scope == "" {
+			// Resources derived from scoped resources are scoped as well.
+			res.Scope = resourceScope(n)
+		}
+		if res.Namespace == "" {
+			res.Namespace = resourceNamespace(n)
+		}
 		n = comp.resources[n.Base.Ident]
 	}
 	if len(res.Values) == 0 {
 		res.Values = []uint64{0}
 	}
-	res.Type = comp.genType(base, "", prog.DirIn, false)
 	return res
 }
 
+// genResourceBase returns the underlying int type of the resource,
+// or nil for recursive resources (they are reported by checkRecursion).
+func (comp *compiler) genResourceBase(n *ast.Resource) prog.Type {
+	seen := make(map[*ast.Resource]bool)
+	for comp.resources[n.Base.Ident] != nil {
+		if seen[n] {
+			return nil
+		}
+		seen[n] = true
+		n = comp.resources[n.Base.Ident]
+	}
+	return comp.genType(n.Base, "", prog.DirIn, false)
+}
+
+// genResourceCompatible returns names of used resources that are declared
+// compatible_with n or that n is declared compatible_with.
+func (comp *compiler) genResourceCompatible(n *ast.Resource) []string {
+	var compatible []string
+	for _, arg := range comp.parseResourceAttrs(n) {
+		if comp.used[arg.Ident] {
+			compatible = append(compatible, arg.Ident)
+		}
+	}
+	for name, n1 := range comp.resources {
+		if !comp.used[name] {
+			continue
+		}
+		for _, arg := range comp.parseResourceAttrs(n1) {
+			if arg.Ident == n.Name.Name && !arrayContains(compatible, name) {
+				compatible = append(compatible, name)
+			}
+		}
+	}
+	sort.Strings(compatible)
+	return compatible
+}
+
 func (comp *compiler) genSyscalls() []*prog.Syscall {
 	var calls []*prog.Syscall
 	callArgs := make(map[string]int)
@@ -55,11 +127,24 @@ func (comp *compiler) genSyscalls() []*prog.Syscall {
 			}
 		}
 	}
+	generated := make(map[string]bool)
 	for _, decl := range comp.desc.Nodes {
 		if n, ok := decl.(*ast.Call); ok && n.NR != ^uint64(0) {
 			calls = append(calls, comp.genSyscall(n, callArgs[n.CallName]))
+			generated[n.Name.Name] = true
 		}
 	}
+	for _, c := range calls {
+		// Some of submitting calls may be unsupported, completion calls without
+		// any supported submitting calls are discarded by filterRequiringCalls.
+		var completes []string
+		for _, name := range c.Completes {
+			if generated[name] {
+				completes = append(completes, name)
+			}
+		}
+		c.Completes = completes
+	}
 	sort.Slice(calls, func(i, j int) bool {
 		return calls[i].Name < calls[j].Name
 	})
@@ -67,17 +152,77 @@ func (comp *compiler) genSyscalls() []*prog.Syscall {
 }
 
 func (comp *compiler) genSyscall(n *ast.Call, maxArgs int) *prog.Syscall {
+	retries, group, compat, noCover, requires, completes, scopeBegin, scopeEnd, setNamespace := comp.parseCallAttrs(n)
+	if compat && comp.target.PtrSize != compatPtrSize {
+		// Arguments of compat syscalls use 32-bit layout, structs reachable from them
+		// get separate descriptions, so that the same struct can be used by native calls as well.
+		comp.compat, comp.ptrSize = true, compatPtrSize
+		defer func() {
+			comp.compat, comp.ptrSize = false, comp.target.PtrSize
+		}()
+	}
 	var ret prog.Type
 	if n.Ret != nil {
 		ret = comp.genType(n.Ret, "ret", prog.DirOut, true)
 	}
+	nonblockArg, nonblockFlag := comp.nonblockArg(n)
 	return &prog.Syscall{
-		Name:        n.Name.Name,
-		CallName:    n.CallName,
-		NR:          n.NR,
-		MissingArgs: maxArgs - len(n.Args),
-		Args:        comp.genFieldArray(n.Args, prog.DirIn, true),
-		Ret:         ret,
+		Name:          n.Name.Name,
+		CallName:      n.CallName,
+		NR:            n.NR,
+		MissingArgs:   maxArgs - len(n.Args),
+		Args:          comp.genFieldArray(n.Args, prog.DirIn, true),
+		Ret:           ret,
+		Retries:       retries,
+		Group:         group,
+		Compat:        compat,
+		NoCover:       noCover,
+		Requires:      requires,
+		Completes:     completes,
+		ScopeBegin:    scopeBegin,
+		ScopeEnd:      scopeEnd,
+		SetNamespace:  setNamespace,
+		OmittableArgs: comp.omittableArgs(n),
+		NonblockArg:   nonblockArg,
+		NonblockFlag:  nonblockFlag,
+	}
+}
+
+// nonblockArg returns index and value of the argument of the call with nonblock attribute, if any.
+func (comp *compiler) nonblockArg(n *ast.Call) (int, uint64) {
+	for i, arg := range n.Args {
+		if attrs := comp.parseFieldAttrs(arg); attrs.hasNonblock {
+			return i, attrs.nonblock
+		}
+	}
+	return 0, 0
+}
+
+// omittableArgs returns the number of trailing arguments of the call with omittable attribute.
+func (comp *compiler) omittableArgs(n *ast.Call) int {
+	count := 0
+	for i := len(n.Args) - 1; i >= 0 && comp.parseFieldAttrs(n.Args[i]).omittable; i-- {
+		count++
+	}
+	return count
+}
+
+const (
+	compatPtrSize = 4
+	// compatSuffix is appended to names of struct keys with compat layout.
+	// It can't appear in description identifiers, so it can't clash with native structs.
+	compatSuffix = ":compat"
+)
+
+// setStructLayout switches pointer size to the one used by layout of the struct with the given key,
+// the returned function restores the previous pointer size.
+func (comp *compiler) setStructLayout(key prog.StructKey) func() {
+	saved := comp.ptrSize
+	if strings.HasSuffix(key.Name, compatSuffix) {
+		comp.ptrSize = compatPtrSize
+	}
+	return func() {
+		comp.ptrSize = saved
 	}
 }
 
@@ -147,7 +292,7 @@ func (ctx *structGen) check(key prog.StructKey, descp **prog.StructDesc) bool {
 			return false
 		}
 	}
-	if ctx.comp.used[key.Name] {
+	if ctx.comp.used[ctx.comp.structNodes[desc].Name.Name] {
 		ctx.structs = append(ctx.structs, &prog.KeyedStruct{
 			Key:  key,
 			Desc: desc,
@@ -160,6 +305,10 @@ func (ctx *structGen) walk(t0 prog.Type) {
 	switch t := t0.(type) {
 	case *prog.PtrType:
 		ctx.walk(t.Type)
+	case *prog.BufferType:
+		if t.Elem != nil {
+			ctx.walk(t.Elem)
+		}
 	case *prog.ArrayType:
 		ctx.walkArray(t)
 	case *prog.StructType:
@@ -191,6 +340,7 @@ func (ctx *structGen) walkStruct(t *prog.StructType) {
 		return
 	}
 	comp := ctx.comp
+	defer comp.setStructLayout(t.Key)()
 	structNode := comp.structNodes[t.StructDesc]
 	// Add paddings, calculate size, mark bitfields.
 	varlen := false
@@ -200,9 +350,17 @@ func (ctx *structGen) walkStruct(t *prog.StructType) {
 		}
 	}
 	comp.markBitfields(t.Fields)
+	comp.checkParityBits(structNode, t.Fields)
 	packed, sizeAttr, alignAttr := comp.parseStructAttrs(structNode)
 	t.Fields = comp.addAlignment(t.Fields, varlen, packed, alignAttr)
 	t.AlignAttr = alignAttr
+	if comp.opts.StrictABI && !comp.strictLayout[structNode] {
+		comp.strictLayout[structNode] = true
+		if !packed {
+			comp.checkStrictPadding(structNode, t.Fields, alignAttr)
+		}
+		comp.checkStrictLens(structNode, t.Fields)
+	}
 	t.TypeSize = 0
 	if !varlen {
 		for _, f := range t.Fields {
@@ -212,11 +370,8 @@ func (ctx *structGen) walkStruct(t *prog.StructType) {
 		}
 		if sizeAttr != sizeUnassigned {
 			if t.TypeSize > sizeAttr {
-				comp.error(structNode.Pos, "struct %v has size attribute %v"+
-					" which is less than struct size %v",
-					structNode.Name.Name, sizeAttr, t.TypeSize)
-			}
-			if pad := sizeAttr - t.TypeSize; pad != 0 {
+				comp.checkStructOverflow(structNode, t.Fields, sizeAttr, t.TypeSize)
+			} else if pad := sizeAttr - t.TypeSize; pad != 0 {
 				t.Fields = append(t.Fields, genPad(pad))
 			}
 			t.TypeSize = sizeAttr
@@ -224,21 +379,56 @@ func (ctx *structGen) walkStruct(t *prog.StructType) {
 	}
 }
 
+// checkStructOverflow reports the first field of struct n that does not fit
+// into the size attribute of the struct. fields are the generated fields
+// including paddings, size is their total size.
+func (comp *compiler) checkStructOverflow(n *ast.Struct, fields []prog.Type, sizeAttr, size uint64) {
+	nodes := make(map[string]*ast.Field)
+	for _, f := range n.Fields {
+		nodes[f.Name.Name] = f
+	}
+	offset := uint64(0)
+	for _, f := range fields {
+		if f.BitfieldMiddle() {
+			continue
+		}
+		if offset+f.Size() > sizeAttr {
+			if node := nodes[f.FieldName()]; node != nil && !prog.IsPad(f) {
+				comp.error(node.Pos, "field %v at offset %v of size %v does not fit into struct %v"+
+					" with size attribute %v declared at %v (struct size is %v)",
+					node.Name.Name, offset, f.Size(), n.Name.Name, sizeAttr, n.Pos, size)
+				return
+			}
+			break
+		}
+		offset += f.Size()
+	}
+	comp.error(n.Pos, "struct %v has size attribute %v which is less than struct size %v",
+		n.Name.Name, sizeAttr, size)
+}
+
 func (ctx *structGen) walkUnion(t *prog.UnionType) {
 	if !ctx.check(t.Key, &t.StructDesc) {
 		return
 	}
 	comp := ctx.comp
+	defer comp.setStructLayout(t.Key)()
 	structNode := comp.structNodes[t.StructDesc]
-	varlen, sizeAttr := comp.parseUnionAttrs(structNode)
+	varlen, sizeAttr, _, _ := comp.parseUnionAttrs(structNode)
 	t.TypeSize = 0
 	if !varlen {
 		for _, fld := range t.Fields {
 			sz := fld.Size()
 			if sizeAttr != sizeUnassigned && sz > sizeAttr {
-				comp.error(structNode.Pos, "union %v has size attribute %v"+
-					" which is less than field %v size %v",
-					structNode.Name.Name, sizeAttr, fld.Name(), sz)
+				pos := structNode.Pos
+				for _, f := range structNode.Fields {
+					if f.Name.Name == fld.FieldName() {
+						pos = f.Pos
+					}
+				}
+				comp.error(pos, "field %v of size %v does not fit into union %v"+
+					" with size attribute %v declared at %v",
+					fld.FieldName(), sz, structNode.Name.Name, sizeAttr, structNode.Pos)
 			}
 			if t.TypeSize < sz {
 				t.TypeSize = sz
@@ -259,6 +449,54 @@ func (comp *compiler) genStructDesc(res *prog.StructDesc, n *ast.Struct, dir pro
 		TypeCommon: common,
 		Fields:     comp.genFieldArray(n.Fields, dir, false),
 	}
+	if !n.IsUnion {
+		for _, f := range n.Fields {
+			attrs := comp.parseFieldAttrs(f)
+			if attrs.unique {
+				res.UniqueFields = append(res.UniqueFields, f.Name.Name)
+			}
+			if attrs.requestedBy != 0 {
+				res.RequestedFields = append(res.RequestedFields, f.Name.Name)
+				res.RequestedBy = append(res.RequestedBy, attrs.requestedBy)
+			}
+		}
+	}
+	if n.IsUnion {
+		res.OptionWeights = comp.genOptionWeights(n.Fields)
+		if comp.parseFieldAttrs(n.Fields[0]).specifier != "" {
+			for _, f := range n.Fields {
+				res.Specifiers = append(res.Specifiers, comp.parseFieldAttrs(f).specifier)
+			}
+		}
+		_, _, versionField, overlay := comp.parseUnionAttrs(n)
+		if versionField != "" {
+			res.VersionField = versionField
+			for _, f := range n.Fields {
+				res.Versions = append(res.Versions, comp.parseFieldAttrs(f).version)
+			}
+		}
+		res.Overlay = overlay
+	}
+}
+
+// genOptionWeights returns weights of union options,
+// or nil if none of the options has weight attribute.
+func (comp *compiler) genOptionWeights(fields []*ast.Field) []uint64 {
+	var weights []uint64
+	weighted := false
+	for _, f := range fields {
+		attrs := comp.parseFieldAttrs(f)
+		w := uint64(1)
+		if attrs.hasWeight {
+			w = attrs.weight
+			weighted = true
+		}
+		weights = append(weights, w)
+	}
+	if !weighted {
+		return nil
+	}
+	return weights
 }
 
 func (comp *compiler) markBitfields(fields []prog.Type) {
@@ -272,6 +510,7 @@ func (comp *compiler) markBitfields(fields []prog.Type) {
 		if i == len(fields)-1 || // Last bitfield in a group, if last field of the struct...
 			fields[i+1].BitfieldLength() == 0 || // or next field is not a bitfield...
 			f.Size() != fields[i+1].Size() || // or next field is of different size...
+			f.Format() != fields[i+1].Format() || // or next field has different endianness...
 			bfOffset+fields[i+1].BitfieldLength() > f.Size()*8 { // or next field does not fit into the current group.
 			middle, bfOffset = false, 0
 		}
@@ -279,6 +518,20 @@ func (comp *compiler) markBitfields(fields []prog.Type) {
 	}
 }
 
+// checkParityBits checks that parity bitfields of struct n are not computed over themselves.
+func (comp *compiler) checkParityBits(n *ast.Struct, fields []prog.Type) {
+	for i, f := range fields {
+		typ, ok := f.(*prog.IntType)
+		if !ok || typ.Kind != prog.IntParity {
+			continue
+		}
+		if off := typ.BitfieldOffset(); off >= typ.ParityBegin && off <= typ.ParityEnd {
+			comp.error(n.Fields[i].Pos, "parity field %v at bit %v is computed over bits [%v:%v] including itself",
+				typ.FieldName(), off, typ.ParityBegin, typ.ParityEnd)
+		}
+	}
+}
+
 func setBitfieldOffset(t0 prog.Type, offset uint64, middle bool) {
 	switch t := t0.(type) {
 	case *prog.IntType:
@@ -298,11 +551,13 @@ func setBitfieldOffset(t0 prog.Type, offset uint64, middle bool) {
 
 func (comp *compiler) addAlignment(fields []prog.Type, varlen, packed bool, alignAttr uint64) []prog.Type {
 	var newFields []prog.Type
+	// Footer is always the last field, so there is no padding after it.
+	footer := len(fields) != 0 && isFooter(fields[len(fields)-1])
 	if packed {
 		// If a struct is packed, statically sized and has explicitly set alignment,
 		// add a padding at the end.
 		newFields = fields
-		if !varlen && alignAttr != 0 {
+		if !varlen && alignAttr != 0 && !footer {
 			size := uint64(0)
 			for _, f := range fields {
 				if !f.BitfieldMiddle() {
@@ -317,6 +572,11 @@ func (comp *compiler) addAlignment(fields []prog.Type, varlen, packed bool, alig
 	}
 	var align, off uint64
 	for i, f := range fields {
+		if isFooter(f) {
+			// Footer immediately follows the previous field (which can have variable size).
+			newFields = append(newFields, f)
+			continue
+		}
 		if i == 0 || !fields[i-1].BitfieldMiddle() {
 			a := comp.typeAlign(f)
 			if align < a {
@@ -330,17 +590,17 @@ func (comp *compiler) addAlignment(fields []prog.Type, varlen, packed bool, alig
 			}
 		}
 		newFields = append(newFields, f)
-		if !f.BitfieldMiddle() && (i != len(fields)-1 || !f.Varlen()) {
+		if !f.BitfieldMiddle() && !f.Varlen() {
 			// Increase offset if the current field is not a bitfield
-			// or it's the last bitfield in a set, except when it's
-			// the last field in a struct and has variable length.
+			// or it's the last bitfield in a set, except when it has
+			// variable length (it's the last field in a struct or precedes the footer).
 			off += f.Size()
 		}
 	}
 	if alignAttr != 0 {
 		align = alignAttr
 	}
-	if align != 0 && off%align != 0 && !varlen {
+	if align != 0 && off%align != 0 && !varlen && !footer {
 		pad := align - off%align
 		off += pad
 		newFields = append(newFields, genPad(pad))
@@ -388,6 +648,100 @@ func (comp *compiler) typeAlign(t0 prog.Type) uint64 {
 	}
 }
 
+// checkStrictPadding reports implicit paddings added by addAlignment to a non-packed struct.
+// Trailing padding is allowed if the struct has explicit alignment attribute.
+func (comp *compiler) checkStrictPadding(n *ast.Struct, fields []prog.Type, alignAttr uint64) {
+	for i, f := range fields {
+		if !prog.IsPad(f) {
+			continue
+		}
+		if i == len(fields)-1 {
+			if alignAttr == 0 {
+				comp.error(n.Pos, "struct %v has implicit trailing padding of %v bytes",
+					n.Name.Name, f.Size())
+			}
+			continue
+		}
+		comp.error(n.Pos, "struct %v has implicit padding of %v bytes before field %v",
+			n.Name.Name, f.Size(), fields[i+1].FieldName())
+	}
+}
+
+// checkStrictLens reports len fields of struct n that are too narrow for the largest length
+// of their target field, such lengths would be silently truncated.
+// Only targets that are sibling fields of bounded size are checked.
+func (comp *compiler) checkStrictLens(n *ast.Struct, fields []prog.Type) {
+	siblings := make(map[string]prog.Type)
+	for _, f := range fields {
+		siblings[f.FieldName()] = f
+	}
+	for _, f := range fields {
+		lenType, ok := f.(*prog.LenType)
+		if !ok || lenType.Expr != nil || lenType.Inclusive {
+			continue
+		}
+		target := siblings[lenType.Buf]
+		if target == nil {
+			continue
+		}
+		max, ok := maxLength(lenType, target)
+		if !ok {
+			continue
+		}
+		bits := lenType.BitfieldLength()
+		if bits == 0 {
+			bits = lenType.Size() * 8
+		}
+		if valueFitsBits(max, bits) {
+			continue
+		}
+		for _, fld := range n.Fields {
+			if fld.Name.Name == f.FieldName() {
+				comp.error(fld.Pos, "length of %v can be up to %v which does not fit into %v bits",
+					lenType.Buf, max, bits)
+			}
+		}
+	}
+}
+
+// maxLength returns the largest value of len type t with target field target.
+// Returns false if the size of target is not bounded.
+func maxLength(t *prog.LenType, target prog.Type) (uint64, bool) {
+	var bytes uint64
+	switch typ := target.(type) {
+	case *prog.ArrayType:
+		if typ.Kind != prog.ArrayRangeLen {
+			return 0, false
+		}
+		if t.BitSize == 0 {
+			return typ.RangeEnd, true
+		}
+		if typ.Type.Varlen() {
+			return 0, false
+		}
+		bytes = typ.RangeEnd * typ.Type.Size()
+	case *prog.BufferType:
+		if typ.Kind != prog.BufferBlobRange {
+			return 0, false
+		}
+		bytes = typ.RangeEnd
+	default:
+		if target.Varlen() {
+			return 0, false
+		}
+		bytes = target.Size()
+	}
+	if t.BitSize == 0 {
+		return bytes, true
+	}
+	return bytes * 8 / t.BitSize, true
+}
+
+func isFooter(t prog.Type) bool {
+	c, ok := t.(*prog.ConstType)
+	return ok && c.IsFooter
+}
+
 func genPad(size uint64) prog.Type {
 	return &prog.ConstType{
 		IntTypeCommon: genIntCommon(genCommon("pad", "", size, prog.DirIn, false), 0, false),
@@ -396,7 +750,107 @@ func genPad(size uint64) prog.Type {
 }
 
 func (comp *compiler) genField(f *ast.Field, dir prog.Dir, isArg bool) prog.Type {
-	return comp.genType(f.Type, f.Name.Name, dir, isArg)
+	attrs := comp.parseFieldAttrs(f)
+	if attrs.init && dir == prog.DirOut {
+		// The caller must initialize the field even in otherwise output structs.
+		dir = prog.DirInOut
+	}
+	desc, args, base := comp.getArgsBase(f.Type, f.Name.Name, dir, isArg)
+	base.MutateWeight = attrs.mutateWeight
+	t := comp.genTypeBase(f.Type, desc, args, base)
+	if len(attrs.buckets) != 0 {
+		t.(*prog.IntType).Buckets = attrs.buckets
+	}
+	if attrs.promotable {
+		t.(*prog.ConstType).Promotable = true
+	}
+	if attrs.byteOrder != nil {
+		// Generation and mutation pick only one of the marks.
+		it := t.(*prog.IntType)
+		it.ByteOrderMark = true
+		it.BigEndianMark = attrs.byteOrder[0]
+		for _, v := range attrs.byteOrder {
+			it.Buckets = append(it.Buckets, prog.IntBucket{Begin: v, End: v, Weight: 1})
+		}
+	}
+	if attrs.effect != prog.ResourceUse {
+		t.(*prog.ResourceType).Effect = attrs.effect
+	}
+	if attrs.event != prog.EventNone {
+		t.(*prog.ResourceType).Event = attrs.event
+	}
+	if attrs.validMask != 0 {
+		t.(*prog.ResourceType).ValidMask = attrs.validMask
+	}
+	if attrs.invalidPct != 0 {
+		t.(*prog.ResourceType).InvalidPercent = attrs.invalidPct
+	}
+	if attrs.derives {
+		t.(*prog.ResourceType).Derives = true
+		t.(*prog.ResourceType).DeriveOffset = attrs.deriveOffset
+	}
+	if attrs.formatArgs != "" {
+		buf, ok := t.(*prog.BufferType)
+		if !ok {
+			buf = t.(*prog.PtrType).Type.(*prog.BufferType)
+		}
+		buf.FormatArgs = attrs.formatArgs
+	}
+	if attrs.count != "" {
+		arr, ok := t.(*prog.ArrayType)
+		if !ok {
+			arr = t.(*prog.PtrType).Type.(*prog.ArrayType)
+		}
+		arr.CountField = attrs.count
+	}
+	if attrs.cookie != "" {
+		t.(*prog.IntType).Cookie = attrs.cookie
+	}
+	if attrs.relation != prog.IntRelNone {
+		t.(*prog.IntType).Relation = attrs.relation
+		t.(*prog.IntType).RelationField = attrs.relationField
+	}
+	if attrs.requestMask != "" {
+		switch typ := t.(type) {
+		case *prog.IntType:
+			typ.RequestMaskField = attrs.requestMask
+		case *prog.FlagsType:
+			typ.RequestMaskField = attrs.requestMask
+		}
+	}
+	if attrs.overlap != "" {
+		ptr := t.(*prog.PtrType)
+		ptr.OverlapField = attrs.overlap
+		ptr.OverlapOffset = attrs.overlapOffset
+	}
+	if attrs.guard {
+		t.(*prog.PtrType).Guard = true
+	}
+	if attrs.ptrDepth != 0 {
+		t.(*prog.PtrType).Depth = int(attrs.ptrDepth)
+	}
+	if attrs.shared != "" {
+		switch typ := t.(type) {
+		case *prog.VmaType:
+			typ.Shared = attrs.shared
+		case *prog.PtrType:
+			typ.Shared = attrs.shared
+		}
+	}
+	if attrs.dim != 0 {
+		t.(*prog.LenType).Dim = attrs.dim
+	}
+	// Flags with all values unsupported are generated as ints.
+	if flags, ok := t.(*prog.FlagsType); ok {
+		flags.Exhaustive = attrs.exhaustive
+		flags.SubkindField = attrs.subkindField
+		for _, k := range attrs.subkinds {
+			if vals := genIntArray(comp.intFlags[k.Args[0].Ident].Values); len(vals) != 0 {
+				flags.Subkinds = append(flags.Subkinds, prog.SubkindVals{Kind: k.Ident, Vals: vals})
+			}
+		}
+	}
+	return t
 }
 
 func (comp *compiler) genFieldArray(fields []*ast.Field, dir prog.Dir, isArg bool) []prog.Type {
@@ -409,11 +863,19 @@ func (comp *compiler) genFieldArray(fields []*ast.Field, dir prog.Dir, isArg boo
 
 func (comp *compiler) genType(t *ast.Type, field string, dir prog.Dir, isArg bool) prog.Type {
 	desc, args, base := comp.getArgsBase(t, field, dir, isArg)
+	return comp.genTypeBase(t, desc, args, base)
+}
+
+func (comp *compiler) genTypeBase(t *ast.Type, desc *typeDesc, args []*ast.Type, base prog.IntTypeCommon) prog.Type {
 	if desc.Gen == nil {
-		panic(fmt.Sprintf("no gen for %v %#v", field, t))
+		panic(fmt.Sprintf("no gen for %v %#v", base.FldName, t))
 	}
 	base.IsVarlen = desc.Varlen != nil && desc.Varlen(comp, t, args)
-	return desc.Gen(comp, t, args, base)
+	typ := desc.Gen(comp, t, args, base)
+	if comp.typePos != nil {
+		comp.typePos[typ] = t.Pos
+	}
+	return typ
 }
 
 func genCommon(name, field string, size uint64, dir prog.Dir, opt bool) prog.TypeCommon {
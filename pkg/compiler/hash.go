@@ -0,0 +1,37 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/google/syzkaller/pkg/hash"
+)
+
+// genHash returns a stable hash of the compiled descriptions: resources, syscalls, structs
+// (in the form of SerializeBinary), flags and all consts the descriptions were compiled with.
+// All parts are already sorted, so compiling the same inputs gives the same hash,
+// while any change to the generated descriptions changes it.
+func (prg *Prog) genHash(consts map[string]uint64) string {
+	buf := new(bytes.Buffer)
+	buf.Write(prg.SerializeBinary())
+	for _, f := range prg.Flags {
+		fmt.Fprintf(buf, "flag %q", f.Name)
+		for _, v := range f.Values {
+			fmt.Fprintf(buf, " %q", v)
+		}
+		buf.WriteByte('\n')
+	}
+	var names []string
+	for name := range consts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(buf, "const %q %v\n", name, consts[name])
+	}
+	return hash.String(buf.Bytes())
+}
@@ -0,0 +1,72 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// InterfaceVersion is the version of the Interface format.
+// It must be incremented on any incompatible change of the format.
+const InterfaceVersion = 1
+
+// Interface is the part of descriptions that is needed by external tools that don't care
+// about argument layout (e.g. stub generators for other languages): resources and resolved
+// values of consts referenced by descriptions. It is filled in if Options.InterfaceOnly is set.
+type Interface struct {
+	Version   int                  `json:"version"`
+	Resources []*InterfaceResource `json:"resources"`
+	Consts    map[string]uint64    `json:"consts"`
+}
+
+// InterfaceResource describes a resource, Kind and Values are the same as in prog.ResourceDesc.
+type InterfaceResource struct {
+	Name   string   `json:"name"`
+	Kind   []string `json:"kind"`
+	Values []uint64 `json:"values"`
+}
+
+// Serialize returns the interface in JSON format.
+// Resources and consts are sorted by name, so the output is stable.
+func (iface *Interface) Serialize() []byte {
+	data, err := json.MarshalIndent(iface, "", "\t")
+	if err != nil {
+		panic(fmt.Sprintf("failed to marshal interface: %v", err))
+	}
+	return append(data, '\n')
+}
+
+// DeserializeInterface parses interface serialized with Interface.Serialize.
+func DeserializeInterface(data []byte) (*Interface, error) {
+	iface := new(Interface)
+	if err := json.Unmarshal(data, iface); err != nil {
+		return nil, fmt.Errorf("failed to parse interface: %v", err)
+	}
+	if iface.Version != InterfaceVersion {
+		return nil, fmt.Errorf("bad interface version %v, expect %v", iface.Version, InterfaceVersion)
+	}
+	return iface, nil
+}
+
+func (comp *compiler) genInterface(consts map[string]uint64) *Interface {
+	iface := &Interface{
+		Version:   InterfaceVersion,
+		Resources: []*InterfaceResource{},
+		Consts:    make(map[string]uint64),
+	}
+	for _, res := range comp.genResources() {
+		iface.Resources = append(iface.Resources, &InterfaceResource{
+			Name:   res.Name,
+			Kind:   res.Kind,
+			Values: res.Values,
+		})
+	}
+	for name := range comp.usedConsts {
+		if v, ok := consts[name]; ok {
+			iface.Consts[name] = v
+		}
+	}
+	return iface
+}
@@ -0,0 +1,149 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/google/syzkaller/pkg/ast"
+	"github.com/google/syzkaller/prog"
+)
+
+// Merge combines descriptions base and addon that were compiled separately for the same target
+// (e.g. core descriptions and a vendor add-on) as if they were compiled together.
+// Identical resources, structs, unions, flags and syscalls present in both are shared
+// (the base copy is used). Resources that differ only in the set of compatible resources
+// are shared too, the merged resource is compatible with resources of both descriptions.
+// Any other difference in declarations with the same name is a conflict.
+// A syscall or flags supported in one of the descriptions is not unsupported in the result.
+// Conflicts are reported to eh, Merge returns nil if there were any.
+// Stats of the result are not filled in, metadata is filled in if it is present in any of the inputs.
+// Inputs are not modified, but the result shares types with them.
+func Merge(base, addon *Prog, eh ast.ErrorHandler) *Prog {
+	if eh == nil {
+		eh = ast.LoggingHandler
+	}
+	errors := 0
+	conflict := func(what, name string) {
+		errors++
+		eh(ast.Pos{}, what+" "+name+" is declared differently in the merged descriptions")
+	}
+	res := &Prog{
+		Unsupported: make(map[string]bool),
+	}
+
+	resources := make(map[string]*prog.ResourceDesc)
+	for _, r := range base.Resources {
+		r1 := *r
+		if r.Compatible != nil {
+			r1.Compatible = append([]string{}, r.Compatible...)
+		}
+		resources[r.Name] = &r1
+		res.Resources = append(res.Resources, &r1)
+	}
+	for _, r := range addon.Resources {
+		prev := resources[r.Name]
+		if prev == nil {
+			res.Resources = append(res.Resources, r)
+			continue
+		}
+		r1 := *r
+		r1.Compatible = prev.Compatible
+		if !reflect.DeepEqual(prev, &r1) {
+			conflict("resource", r.Name)
+			continue
+		}
+		for _, name := range r.Compatible {
+			if !arrayContains(prev.Compatible, name) {
+				prev.Compatible = append(prev.Compatible, name)
+			}
+		}
+		sort.Strings(prev.Compatible)
+	}
+	sort.Slice(res.Resources, func(i, j int) bool {
+		return res.Resources[i].Name < res.Resources[j].Name
+	})
+
+	structs := make(map[prog.StructKey]*prog.StructDesc)
+	for _, s := range base.StructDescs {
+		structs[s.Key] = s.Desc
+		res.StructDescs = append(res.StructDescs, s)
+	}
+	for _, s := range addon.StructDescs {
+		if prev, ok := structs[s.Key]; !ok {
+			res.StructDescs = append(res.StructDescs, s)
+		} else if !reflect.DeepEqual(prev, s.Desc) {
+			conflict("struct", s.Key.Name)
+		}
+	}
+	sort.Slice(res.StructDescs, func(i, j int) bool {
+		si, sj := res.StructDescs[i], res.StructDescs[j]
+		if si.Key.Name != sj.Key.Name {
+			return si.Key.Name < sj.Key.Name
+		}
+		return si.Key.Dir < sj.Key.Dir
+	})
+
+	flags := make(map[string]prog.FlagDesc)
+	for _, f := range base.Flags {
+		flags[f.Name] = f
+		res.Flags = append(res.Flags, f)
+	}
+	for _, f := range addon.Flags {
+		if prev, ok := flags[f.Name]; !ok {
+			flags[f.Name] = f
+			res.Flags = append(res.Flags, f)
+		} else if !reflect.DeepEqual(prev, f) {
+			conflict("flags", f.Name)
+		}
+	}
+	sort.Slice(res.Flags, func(i, j int) bool {
+		return res.Flags[i].Name < res.Flags[j].Name
+	})
+
+	calls := make(map[string]*prog.Syscall)
+	for _, c := range base.Syscalls {
+		calls[c.Name] = c
+		res.Syscalls = append(res.Syscalls, c)
+	}
+	for _, c := range addon.Syscalls {
+		if prev := calls[c.Name]; prev == nil {
+			calls[c.Name] = c
+			res.Syscalls = append(res.Syscalls, c)
+		} else if !reflect.DeepEqual(prev, c) {
+			conflict("syscall", c.Name)
+		}
+	}
+	sort.Slice(res.Syscalls, func(i, j int) bool {
+		return res.Syscalls[i].Name < res.Syscalls[j].Name
+	})
+
+	for _, p := range []*Prog{base, addon} {
+		for name := range p.Unsupported {
+			if calls[name] == nil {
+				if _, ok := flags[name]; !ok {
+					res.Unsupported[name] = true
+				}
+			}
+		}
+	}
+
+	if base.Metadata != nil || addon.Metadata != nil {
+		seen := make(map[string]bool)
+		for _, meta := range append(append([]*CallMetadata{}, base.Metadata...), addon.Metadata...) {
+			if !seen[meta.Name] {
+				seen[meta.Name] = true
+				res.Metadata = append(res.Metadata, meta)
+			}
+		}
+		sort.Slice(res.Metadata, func(i, j int) bool {
+			return res.Metadata[i].Name < res.Metadata[j].Name
+		})
+	}
+	if errors != 0 {
+		return nil
+	}
+	return res
+}
@@ -0,0 +1,241 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/ast"
+	"github.com/google/syzkaller/prog"
+)
+
+// CallMetadata describes a compiled syscall for external catalogs.
+// Doc is the comment block immediately preceding the call in descriptions.
+// Produces/Consumes are sorted names of resources created/used by the call
+// (optional resources are not considered as consumed).
+// Structs are sorted structs and unions reachable from the call in the form name/dir
+// (see prog.Target.CallStructs).
+// Consts are named consts referenced by the call and all types reachable from it
+// (see callConsts), sorted by name.
+type CallMetadata struct {
+	Name     string          `json:"name"`
+	CallName string          `json:"call_name"`
+	Doc      string          `json:"doc,omitempty"`
+	Args     []ArgMetadata   `json:"args"`
+	Ret      string          `json:"ret,omitempty"`
+	Produces []string        `json:"produces,omitempty"`
+	Consumes []string        `json:"consumes,omitempty"`
+	Structs  []string        `json:"structs,omitempty"`
+	Consts   []ConstMetadata `json:"consts,omitempty"`
+}
+
+type ArgMetadata struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type ConstMetadata struct {
+	Name  string `json:"name"`
+	Value uint64 `json:"value"`
+}
+
+func (comp *compiler) genMetadata(prg *Prog) []*CallMetadata {
+	docs := make(map[string]string)
+	calls := make(map[string]*ast.Call)
+	var comments []string
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Comment:
+			comments = append(comments, strings.TrimSpace(n.Text))
+			continue
+		case *ast.Call:
+			docs[n.Name.Name] = strings.Join(comments, "\n")
+			calls[n.Name.Name] = n
+		}
+		comments = nil
+	}
+	descs := make(map[prog.StructKey]*prog.StructDesc)
+	for _, s := range prg.StructDescs {
+		descs[s.Key] = s.Desc
+	}
+	var res []*CallMetadata
+	for _, c := range prg.Syscalls {
+		meta := &CallMetadata{
+			Name:     c.Name,
+			CallName: c.CallName,
+			Doc:      docs[c.Name],
+			Args:     []ArgMetadata{},
+		}
+		for _, a := range c.Args {
+			meta.Args = append(meta.Args, ArgMetadata{a.FieldName(), prog.ArgSignature(a)})
+		}
+		produces, consumes, structs := make(map[string]bool), make(map[string]bool), make(map[string]bool)
+		seen := make(map[prog.StructKey]bool)
+		var rec func(t prog.Type)
+		rec = func(t prog.Type) {
+			switch a := t.(type) {
+			case *prog.ResourceType:
+				if a.Dir() != prog.DirIn {
+					produces[a.TypeName] = true
+				}
+				if a.Dir() != prog.DirOut && !a.IsOptional {
+					consumes[a.TypeName] = true
+				}
+			case *prog.PtrType:
+				rec(a.Type)
+			case *prog.ArrayType:
+				rec(a.Type)
+			case *prog.StructType:
+				if !seen[a.Key] {
+					seen[a.Key] = true
+					structs[fmt.Sprintf("%v/%v", a.Key.Name, a.Key.Dir)] = true
+					for _, f := range descs[a.Key].Fields {
+						rec(f)
+					}
+				}
+			case *prog.UnionType:
+				if !seen[a.Key] {
+					seen[a.Key] = true
+					structs[fmt.Sprintf("%v/%v", a.Key.Name, a.Key.Dir)] = true
+					for _, f := range descs[a.Key].Fields {
+						rec(f)
+					}
+				}
+			}
+		}
+		for _, a := range c.Args {
+			rec(a)
+		}
+		if c.Ret != nil {
+			meta.Ret = prog.ArgSignature(c.Ret)
+			rec(c.Ret)
+		}
+		meta.Produces = toArray(produces)
+		meta.Consumes = toArray(consumes)
+		meta.Structs = toArray(structs)
+		meta.Consts = comp.callConsts(calls[c.Name])
+		res = append(res, meta)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+// MinimalConsts returns contents of a const file (in the format of SerializeConsts) with exactly
+// the consts referenced by the given calls and all types transitively reachable from them
+// (see CallMetadata.Consts), e.g. to ship a trimmed subset of descriptions for the arch of prg.
+// It requires Options.Metadata. All calls must be compiled: consts of unsupported calls
+// are missing for the arch, so it's an error to ask for them (as well as for unknown calls).
+func (prg *Prog) MinimalConsts(calls []string) ([]byte, error) {
+	if prg.Metadata == nil {
+		return nil, fmt.Errorf("minimal consts require call metadata")
+	}
+	metas := make(map[string]*CallMetadata)
+	for _, meta := range prg.Metadata {
+		metas[meta.Name] = meta
+	}
+	consts := make(map[string]uint64)
+	var missing []string
+	for _, name := range calls {
+		meta := metas[name]
+		if meta == nil {
+			missing = append(missing, name)
+			continue
+		}
+		for _, c := range meta.Consts {
+			consts[c.Name] = c.Value
+		}
+	}
+	if len(missing) != 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("calls are unknown or unsupported: %v", strings.Join(missing, ", "))
+	}
+	return SerializeConsts(consts, nil), nil
+}
+
+// callConsts returns named consts referenced by call n: the syscall number and consts
+// used in arguments of all types transitively reachable from the call (ranges, const/len
+// values, flags, resource special values, struct size attributes and field attributes),
+// including types reachable only through union options and arrays.
+func (comp *compiler) callConsts(n *ast.Call) []ConstMetadata {
+	consts := make(map[string]uint64)
+	if comp.target.SyscallNumbers && !strings.HasPrefix(n.CallName, "syz_") {
+		consts[comp.target.SyscallPrefix+n.CallName] = n.NR
+	}
+	structs, flags, strflags := make(map[string]bool), make(map[string]bool), make(map[string]bool)
+	for _, arg := range n.Args {
+		comp.collectUsedType(structs, flags, strflags, arg.Type, true)
+		collectSubkindFlags(flags, arg)
+	}
+	if n.Ret != nil {
+		comp.collectUsedType(structs, flags, strflags, n.Ret, true)
+	}
+	decls := []ast.Node{n}
+	for _, arg := range n.Args {
+		for _, v := range constAttrArgs(arg) {
+			if v.Ident != "" {
+				consts[v.Ident] = v.Value
+			}
+		}
+	}
+	for name := range structs {
+		if s := comp.structs[name]; s != nil {
+			decls = append(decls, s)
+		} else if r := comp.resources[name]; r != nil {
+			decls = append(decls, r)
+			for _, v := range r.Values {
+				if v.Ident != "" {
+					consts[v.Ident] = v.Value
+				}
+			}
+		}
+	}
+	for _, decl := range decls {
+		comp.foreachType(decl, func(_ *ast.Type, desc *typeDesc, args []*ast.Type, _ prog.IntTypeCommon) {
+			for i, arg := range args {
+				if desc.Args[i].Type.Kind != kindInt {
+					continue
+				}
+				if arg.Ident != "" {
+					consts[arg.Ident] = arg.Value
+				}
+				if arg.Ident2 != "" {
+					consts[arg.Ident2] = arg.Value2
+				}
+			}
+		})
+		if s, ok := decl.(*ast.Struct); ok {
+			for _, attr := range s.Attrs {
+				if attr.Ident == "size" && attr.Args[0].Ident != "" {
+					consts[attr.Args[0].Ident] = attr.Args[0].Value
+				}
+			}
+			for _, fld := range s.Fields {
+				for _, v := range constAttrArgs(fld) {
+					if v.Ident != "" {
+						consts[v.Ident] = v.Value
+					}
+				}
+			}
+		}
+	}
+	for name := range flags {
+		for _, v := range comp.intFlags[name].Values {
+			if v.Ident != "" {
+				consts[v.Ident] = v.Value
+			}
+		}
+	}
+	var res []ConstMetadata
+	for name, v := range consts {
+		res = append(res, ConstMetadata{name, v})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
@@ -0,0 +1,176 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/google/syzkaller/prog"
+)
+
+// CheckRoundTrip serializes the compiled descriptions into binary form (see SerializeBinary),
+// deserializes them back and compares the result with the compiled descriptions.
+// It returns an error describing the first difference, e.g. a field that is lost by the encoder,
+// with path to the differing node (e.g. "syscall foo$bar: .Args[1].IntTypeCommon.BitfieldLen: 3 != 0").
+func (prg *Prog) CheckRoundTrip() error {
+	resources, syscalls, structs, err := prog.DeserializeDescriptions(prg.SerializeBinary())
+	if err != nil {
+		return fmt.Errorf("failed to deserialize descriptions: %v", err)
+	}
+	if len(resources) != len(prg.Resources) {
+		return fmt.Errorf("got %v resources, want %v", len(resources), len(prg.Resources))
+	}
+	for i, res := range prg.Resources {
+		if diff := firstDiff(reflect.ValueOf(res), reflect.ValueOf(resources[i])); diff != "" {
+			return fmt.Errorf("resource %v: %v", res.Name, diff)
+		}
+	}
+	if len(syscalls) != len(prg.Syscalls) {
+		return fmt.Errorf("got %v syscalls, want %v", len(syscalls), len(prg.Syscalls))
+	}
+	for i, c := range prg.Syscalls {
+		if diff := firstDiff(reflect.ValueOf(c), reflect.ValueOf(syscalls[i])); diff != "" {
+			return fmt.Errorf("syscall %v: %v", c.Name, diff)
+		}
+	}
+	if len(structs) != len(prg.StructDescs) {
+		return fmt.Errorf("got %v structs, want %v", len(structs), len(prg.StructDescs))
+	}
+	for i, s := range prg.StructDescs {
+		if diff := firstDiff(reflect.ValueOf(s), reflect.ValueOf(structs[i])); diff != "" {
+			return fmt.Errorf("struct %v/%v: %v", s.Key.Name, s.Key.Dir, diff)
+		}
+	}
+	return nil
+}
+
+// firstDiff returns path to the first node that differs in v1 and v2 with the differing values,
+// or an empty string if v1 and v2 are deeply equal (in the sense of reflect.DeepEqual).
+func firstDiff(v1, v2 reflect.Value) string {
+	d := &differ{visited: make(map[[2]uintptr]bool)}
+	d.diff("", v1, v2)
+	return d.res
+}
+
+type differ struct {
+	visited map[[2]uintptr]bool // pairs of pointers that are already compared (for cycles)
+	res     string
+}
+
+func (d *differ) diff(path string, v1, v2 reflect.Value) bool {
+	if v1.IsValid() != v2.IsValid() {
+		return d.report(path, "%v != %v", v1.IsValid(), v2.IsValid())
+	}
+	if !v1.IsValid() {
+		return false
+	}
+	if v1.Type() != v2.Type() {
+		return d.report(path, "%v != %v", v1.Type(), v2.Type())
+	}
+	switch v1.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v1.IsNil() || v2.IsNil() {
+			if v1.IsNil() != v2.IsNil() {
+				return d.report(path, "%v != %v", nilString(v1), nilString(v2))
+			}
+			return false
+		}
+		if v1.Kind() == reflect.Ptr {
+			key := [2]uintptr{v1.Pointer(), v2.Pointer()}
+			if d.visited[key] {
+				return false
+			}
+			d.visited[key] = true
+		}
+		return d.diff(path, v1.Elem(), v2.Elem())
+	case reflect.Struct:
+		for i := 0; i < v1.NumField(); i++ {
+			if d.diff(path+"."+v1.Type().Field(i).Name, v1.Field(i), v2.Field(i)) {
+				return true
+			}
+		}
+		return false
+	case reflect.Slice, reflect.Array:
+		if v1.Kind() == reflect.Slice && v1.IsNil() != v2.IsNil() {
+			return d.report(path, "%v != %v", nilString(v1), nilString(v2))
+		}
+		if v1.Len() != v2.Len() {
+			return d.report(path, "len %v != len %v", v1.Len(), v2.Len())
+		}
+		for i := 0; i < v1.Len(); i++ {
+			if d.diff(fmt.Sprintf("%v[%v]", path, i), v1.Index(i), v2.Index(i)) {
+				return true
+			}
+		}
+		return false
+	case reflect.Map:
+		if v1.IsNil() != v2.IsNil() {
+			return d.report(path, "%v != %v", nilString(v1), nilString(v2))
+		}
+		if v1.Len() != v2.Len() {
+			return d.report(path, "len %v != len %v", v1.Len(), v2.Len())
+		}
+		keys := v1.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return scalarString(keys[i]) < scalarString(keys[j])
+		})
+		for _, key := range keys {
+			elem := fmt.Sprintf("%v[%v]", path, scalarString(key))
+			if !v2.MapIndex(key).IsValid() {
+				return d.report(elem, "present != missing")
+			}
+			if d.diff(elem, v1.MapIndex(key), v2.MapIndex(key)) {
+				return true
+			}
+		}
+		return false
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		if v1.Pointer() != v2.Pointer() {
+			return d.report(path, "%v != %v", v1.Pointer(), v2.Pointer())
+		}
+		return false
+	default:
+		if s1, s2 := scalarString(v1), scalarString(v2); s1 != s2 {
+			return d.report(path, "%v != %v", s1, s2)
+		}
+		return false
+	}
+}
+
+func (d *differ) report(path, msg string, args ...interface{}) bool {
+	d.res = fmt.Sprintf("%v: %v", path, fmt.Sprintf(msg, args...))
+	return true
+}
+
+func nilString(v reflect.Value) string {
+	if v.IsNil() {
+		return "nil"
+	}
+	if v.Kind() == reflect.Interface {
+		return v.Elem().Type().String()
+	}
+	return "non-nil"
+}
+
+// scalarString formats a value of basic kind (it works for unexported struct fields as well).
+func scalarString(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Bool:
+		return fmt.Sprint(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprint(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return fmt.Sprint(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return fmt.Sprint(v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		return fmt.Sprint(v.Complex())
+	case reflect.String:
+		return fmt.Sprintf("%q", v.String())
+	default:
+		return v.Type().String()
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/google/syzkaller/pkg/ast"
+	"github.com/google/syzkaller/prog"
+)
+
+// SourceMap links compiled types to positions of description constructs they were generated from.
+type SourceMap struct {
+	// Types maps types of syscall arguments, return values and struct/union fields
+	// (including types nested in pointers and arrays) to positions of the ast types.
+	// Implicit types added by the compiler (e.g. padding) are not present.
+	Types map[prog.Type]ast.Pos `json:"-"`
+	// Structs maps compiled structs and unions to positions of their declarations.
+	Structs map[*prog.StructDesc]ast.Pos `json:"-"`
+	// Entries is a serializable form of Types and Structs sorted by path.
+	Entries []*SourceMapEntry `json:"entries"`
+}
+
+// SourceMapEntry links a compiled type identified by path to a position in descriptions.
+// Path starts with a call name or a struct/union name in the form name/dir
+// followed by dot-separated argument/field names, pointees are denoted with "*"
+// and array elements with "[]" (e.g. "open.file*" or "iovec/in.base*[]").
+type SourceMapEntry struct {
+	Path string  `json:"path"`
+	Type string  `json:"type"`
+	Pos  ast.Pos `json:"pos"`
+}
+
+func (comp *compiler) genSourceMap(prg *Prog) *SourceMap {
+	sm := &SourceMap{
+		Types:   comp.typePos,
+		Structs: make(map[*prog.StructDesc]ast.Pos),
+	}
+	for _, c := range prg.Syscalls {
+		for _, arg := range c.Args {
+			sm.addType(c.Name+"."+arg.FieldName(), arg)
+		}
+		if c.Ret != nil {
+			sm.addType(c.Name+".ret", c.Ret)
+		}
+	}
+	for _, s := range prg.StructDescs {
+		n := comp.structNodes[s.Desc]
+		if n == nil {
+			continue
+		}
+		name := fmt.Sprintf("%v/%v", s.Key.Name, s.Key.Dir)
+		sm.Structs[s.Desc] = n.Pos
+		sm.Entries = append(sm.Entries, &SourceMapEntry{Path: name, Type: s.Key.Name, Pos: n.Pos})
+		for _, f := range s.Desc.Fields {
+			sm.addType(name+"."+f.FieldName(), f)
+		}
+	}
+	sort.Slice(sm.Entries, func(i, j int) bool {
+		return sm.Entries[i].Path < sm.Entries[j].Path
+	})
+	return sm
+}
+
+func (sm *SourceMap) addType(path string, t prog.Type) {
+	pos, ok := sm.Types[t]
+	if !ok {
+		return
+	}
+	var name string
+	switch typ := t.(type) {
+	case *prog.StructType:
+		// Descs are detached from struct types at this point, see genStructDescs.
+		name = typ.Key.Name
+	case *prog.UnionType:
+		name = typ.Key.Name
+	default:
+		name = t.Name()
+	}
+	sm.Entries = append(sm.Entries, &SourceMapEntry{Path: path, Type: name, Pos: pos})
+	switch typ := t.(type) {
+	case *prog.PtrType:
+		sm.addType(path+"*", typ.Type)
+	case *prog.ArrayType:
+		sm.addType(path+"[]", typ.Type)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/syzkaller/prog"
+)
+
+// Stats describes breadth of compiled descriptions.
+// Types is a histogram of type kinds (e.g. "IntType", "BufferType") over all syscall arguments,
+// return values, struct fields, union options and pointer/array elements reachable from syscalls.
+// Every struct/union is accounted once regardless of the number of its uses and directions.
+type Stats struct {
+	Calls     int            `json:"calls"`
+	Resources int            `json:"resources"`
+	Structs   int            `json:"structs"`
+	Unions    int            `json:"unions"`
+	Types     map[string]int `json:"types"`
+}
+
+func (comp *compiler) genStats(prg *Prog) *Stats {
+	stats := &Stats{
+		Calls:     len(prg.Syscalls),
+		Resources: len(prg.Resources),
+		Types:     make(map[string]int),
+	}
+	descs := make(map[prog.StructKey]*prog.StructDesc)
+	for _, s := range prg.StructDescs {
+		descs[s.Key] = s.Desc
+	}
+	seen := make(map[string]bool)
+	var rec func(t prog.Type)
+	rec = func(t prog.Type) {
+		if prog.IsPad(t) {
+			return
+		}
+		stats.Types[strings.TrimPrefix(fmt.Sprintf("%T", t), "*prog.")]++
+		var key prog.StructKey
+		switch a := t.(type) {
+		case *prog.PtrType:
+			rec(a.Type)
+			return
+		case *prog.ArrayType:
+			rec(a.Type)
+			return
+		case *prog.StructType:
+			key = a.Key
+			if !seen[key.Name] {
+				stats.Structs++
+			}
+		case *prog.UnionType:
+			key = a.Key
+			if !seen[key.Name] {
+				stats.Unions++
+			}
+		default:
+			return
+		}
+		if seen[key.Name] {
+			return
+		}
+		seen[key.Name] = true
+		for _, f := range descs[key].Fields {
+			rec(f)
+		}
+	}
+	for _, c := range prg.Syscalls {
+		for _, a := range c.Args {
+			rec(a)
+		}
+		if c.Ret != nil {
+			rec(c.Ret)
+		}
+	}
+	return stats
+}
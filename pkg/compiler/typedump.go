@@ -0,0 +1,99 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/ast"
+	"github.com/google/syzkaller/prog"
+)
+
+// genTypeDump returns a text dump of type descriptors chosen for all types of the compiled
+// calls, resources, structs and unions (after typedefs and templates are resolved).
+// Each type is printed on a separate line with its source position, the type itself,
+// the descriptor chosen by getArgsBase, the remaining type arguments and the base int type
+// (for types that need base type).
+// Types that are arguments of other types are indented under them.
+func (comp *compiler) genTypeDump() []byte {
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "# type descriptors of %v/%v declarations.\n", comp.target.OS, comp.target.Arch)
+	for _, decl := range comp.desc.Nodes {
+		switch n := decl.(type) {
+		case *ast.Call:
+			if n.NR == ^uint64(0) {
+				continue
+			}
+			fmt.Fprintf(buf, "\n%v: call %v\n", n.Pos, n.Name.Name)
+			for _, arg := range n.Args {
+				comp.dumpType(buf, arg.Name.Name, arg.Type, true, 1)
+			}
+			if n.Ret != nil {
+				comp.dumpType(buf, "ret", n.Ret, true, 1)
+			}
+		case *ast.Resource:
+			if !comp.used[n.Name.Name] {
+				continue
+			}
+			fmt.Fprintf(buf, "\n%v: resource %v\n", n.Pos, n.Name.Name)
+			comp.dumpType(buf, "base", n.Base, false, 1)
+		case *ast.Struct:
+			if !comp.used[n.Name.Name] {
+				continue
+			}
+			_, typ, name := n.Info()
+			fmt.Fprintf(buf, "\n%v: %v %v\n", n.Pos, typ, name)
+			for _, f := range n.Fields {
+				comp.dumpType(buf, f.Name.Name, f.Type, false, 1)
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+func (comp *compiler) dumpType(buf *bytes.Buffer, name string, t *ast.Type, isArg bool, depth int) {
+	desc, args, base := comp.getArgsBase(t, "", prog.DirIn, isArg)
+	descName := strings.Join(desc.Names, "|")
+	switch desc {
+	case typeStruct:
+		descName = "struct"
+	case typeResource:
+		descName = "resource"
+	}
+	if name != "" {
+		name += " "
+	}
+	var argStrs []string
+	for _, arg := range args {
+		argStrs = append(argStrs, ast.SerializeNode(arg))
+	}
+	fmt.Fprintf(buf, "%v: %v%v%v: desc=%v args=[%v]", t.Pos, strings.Repeat("\t", depth),
+		name, ast.SerializeNode(t), descName, strings.Join(argStrs, ", "))
+	if base.IsOptional {
+		fmt.Fprintf(buf, " opt")
+	}
+	if desc.NeedBase {
+		fmt.Fprintf(buf, " base=[size=%v format=%v", base.TypeSize, dumpFormats[base.ArgFormat])
+		if base.BitfieldLen != 0 {
+			fmt.Fprintf(buf, " bitfield=%v", base.BitfieldLen)
+		}
+		fmt.Fprintf(buf, "]")
+	}
+	fmt.Fprintf(buf, "\n")
+	for i, arg := range args {
+		if desc.Args[i].Type == typeArgType {
+			comp.dumpType(buf, "", arg, desc.Args[i].IsArg, depth+1)
+		}
+	}
+}
+
+var dumpFormats = map[prog.BinaryFormat]string{
+	prog.FormatNative:    "native",
+	prog.FormatBigEndian: "be",
+	prog.FormatStrDec:    "strdec",
+	prog.FormatStrHex:    "strhex",
+	prog.FormatStrOct:    "stroct",
+}
@@ -5,6 +5,7 @@ package compiler
 
 import (
 	"fmt"
+	"math/bits"
 	"strconv"
 
 	"github.com/google/syzkaller/pkg/ast"
@@ -71,12 +72,10 @@ var typeInt = &typeDesc{
 	OptArgs:      1,
 	Args:         []namedArg{{Name: "range", Type: typeArgIntRange}},
 	CanBeResourceBase: func(comp *compiler, t *ast.Type) bool {
-		// Big-endian resources can always be converted to non-big-endian,
-		// since we will always revert bytes during copyout and during copyin,
-		// so the result is the same as not reverting at all.
-		// Big-endian resources are also not implemented and don't have tests.
-		_, be := comp.parseIntType(t.Ident)
-		return !be
+		// Big-endian resources are stored in big-endian byte order in memory,
+		// copyout converts them to native values and copyin converts them back.
+		// Fields typed as such resource inherit the resource format.
+		return true
 	},
 	Check: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) {
 		typeArgBase.Type.Check(comp, t)
@@ -132,6 +131,32 @@ var typeVoid = &typeDesc{
 	},
 }
 
+// Maximum size of reserved type.
+const maxReservedSize = 1 << 20
+
+var typeReserved = &typeDesc{
+	Names:     []string{"reserved"},
+	CantBeOpt: true,
+	Args:      []namedArg{{Name: "size", Type: typeArgInt}},
+	CheckConsts: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) {
+		if size := args[0].Value; size == 0 || size > maxReservedSize {
+			comp.error(args[0].Pos, "reserved size %v is out of range, expect [1, %v]",
+				size, maxReservedSize)
+		}
+	},
+	Gen: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) prog.Type {
+		// Reserved bytes are lowered to a constant string of zeros: such strings
+		// are never mutated and are written out explicitly as any other data.
+		base.TypeSize = args[0].Value
+		return &prog.BufferType{
+			TypeCommon: base.TypeCommon,
+			Kind:       prog.BufferString,
+			Values:     []string{string(make([]byte, args[0].Value))},
+			NoZ:        true,
+		}
+	},
+}
+
 var typeArray = &typeDesc{
 	Names:        []string{"array"},
 	CanBeTypedef: true,
@@ -142,6 +167,38 @@ var typeArray = &typeDesc{
 		if len(args) > 1 && args[1].Value == 0 && args[1].Value2 == 0 {
 			comp.error(args[1].Pos, "arrays of size 0 are not supported")
 		}
+		if field, values := comp.uniqueFieldValues(args[0].Ident); len(args) > 1 && values != 0 {
+			size := args[1].Value
+			if args[1].HasColon {
+				size = args[1].Value2
+			}
+			if size > values {
+				comp.error(args[1].Pos, "array of %v can have at most %v elements with unique %v, got %v",
+					args[0].Ident, values, field, size)
+			}
+		}
+		if field, values := comp.arrayIndexValues(args[0].Ident); len(args) > 1 && values != 0 {
+			size := args[1].Value
+			if args[1].HasColon {
+				size = args[1].Value2
+			}
+			if size > values {
+				comp.error(args[1].Pos, "array of %v can have at most %v elements with arrayindex %v, got %v",
+					args[0].Ident, values, field, size)
+			}
+		}
+		if len(args) > 1 && comp.sparse[args[0].Ident] {
+			index := comp.structs[args[0].Ident].Fields[0].Type
+			size := args[1].Value
+			if args[1].HasColon {
+				size = args[1].Value2
+			}
+			if len(index.Args) != 0 && index.Args[0].HasColon &&
+				size > index.Args[0].Value2-index.Args[0].Value+1 {
+				comp.error(args[1].Pos, "sparse array can have at most %v entries with index %v, got %v",
+					index.Args[0].Value2-index.Args[0].Value+1, ast.SerializeNode(index), size)
+			}
+		}
 	},
 	Varlen: func(comp *compiler, t *ast.Type, args []*ast.Type) bool {
 		if comp.isZeroSize(args[0]) {
@@ -188,20 +245,21 @@ var typeArray = &typeDesc{
 			Kind:       kind,
 			RangeBegin: begin,
 			RangeEnd:   end,
+			Sparse:     comp.sparse[args[0].Ident],
 		}
 	},
 }
 
 var typeLen = &typeDesc{
-	Names:       []string{"len", "bytesize", "bytesize2", "bytesize4", "bytesize8", "bitsize"},
+	Names:       []string{"len", "bytesize", "bytesize2", "bytesize4", "bytesize8", "bitsize", "bytesize_inclusive"},
 	CanBeArgRet: canBeArg,
 	CantBeOpt:   true,
 	NeedBase:    true,
-	Args:        []namedArg{{Name: "len target", Type: typeArgLenTarget}},
+	Args:        []namedArg{{Name: "len target", Type: typeArgLenExpr}},
 	Gen: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) prog.Type {
 		var bitSize uint64
 		switch t.Ident {
-		case "bytesize":
+		case "bytesize", "bytesize_inclusive":
 			bitSize = 8
 		case "bytesize2", "bytesize4", "bytesize8":
 			byteSize, _ := strconv.ParseUint(t.Ident[8:], 10, 8)
@@ -209,12 +267,66 @@ var typeLen = &typeDesc{
 		case "bitsize":
 			bitSize = 1
 		}
-		return &prog.LenType{
+		typ := &prog.LenType{
 			IntTypeCommon: base,
-			Buf:           args[0].Ident,
 			BitSize:       bitSize,
+			Inclusive:     t.Ident == "bytesize_inclusive",
+		}
+		if len(args[0].Args) != 0 {
+			typ.Expr = genLenExpr(args[0])
+		} else {
+			typ.Buf = args[0].Ident
+		}
+		return typ
+	},
+}
+
+// maxLenUnit is the maximum unit of unitsize lengths in bytes.
+const maxLenUnit = 1 << 20
+
+var typeUnitSize = &typeDesc{
+	Names:       []string{"unitsize"},
+	CanBeArgRet: canBeArg,
+	CantBeOpt:   true,
+	NeedBase:    true,
+	Args: []namedArg{
+		{Name: "len target", Type: typeArgLenExpr},
+		{Name: "unit", Type: typeArgInt},
+	},
+	CheckConsts: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) {
+		if unit := args[1].Value; unit == 0 || unit > maxLenUnit {
+			comp.error(args[1].Pos, "unitsize unit %v is out of range [1, %v]", unit, maxLenUnit)
 		}
 	},
+	Gen: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) prog.Type {
+		typ := &prog.LenType{
+			IntTypeCommon: base,
+			BitSize:       args[1].Value * 8,
+		}
+		if len(args[0].Args) != 0 {
+			typ.Expr = genLenExpr(args[0])
+		} else {
+			typ.Buf = args[0].Ident
+		}
+		return typ
+	},
+}
+
+func genLenExpr(t *ast.Type) *prog.LenExpr {
+	switch {
+	case t.Ident == "":
+		return &prog.LenExpr{Op: prog.LenExprConst, Val: t.Value}
+	case len(t.Args) == 0:
+		return &prog.LenExpr{Op: prog.LenExprTarget, Buf: t.Ident}
+	}
+	expr := &prog.LenExpr{Op: prog.LenExprMax}
+	if t.Ident == "min" {
+		expr.Op = prog.LenExprMin
+	}
+	for _, arg := range t.Args {
+		expr.Args = append(expr.Args, genLenExpr(arg))
+	}
+	return expr
 }
 
 var typeConst = &typeDesc{
@@ -232,10 +344,73 @@ var typeConst = &typeDesc{
 	},
 }
 
+var typeFooter = &typeDesc{
+	Names:     []string{"footer"},
+	CantBeOpt: true,
+	NeedBase:  true,
+	Args:      []namedArg{{Name: "value", Type: typeArgInt}},
+	Gen: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) prog.Type {
+		return &prog.ConstType{
+			IntTypeCommon: base,
+			Val:           args[0].Value,
+			IsFooter:      true,
+		}
+	},
+}
+
 var typeArgLenTarget = &typeArg{
 	Kind: kindIdent,
 }
 
+// typeArgLenExpr is a len target or an expression over len targets and ints,
+// e.g. max[a, min[b, 16]].
+var typeArgLenExpr = &typeArg{
+	Kind:    kindIdent,
+	MaxArgs: 2,
+	Check:   checkLenExpr,
+}
+
+func checkLenExpr(comp *compiler, t *ast.Type) {
+	if len(t.Args) == 0 {
+		return
+	}
+	if t.Ident != "max" && t.Ident != "min" {
+		comp.error(t.Pos, "unknown len expression %v, expect max or min", t.Ident)
+		return
+	}
+	if len(t.Args) != 2 {
+		comp.error(t.Pos, "len expression %v needs 2 arguments, got %v", t.Ident, len(t.Args))
+		return
+	}
+	for _, arg := range t.Args {
+		switch {
+		case arg.HasString:
+			comp.error(arg.Pos, "unexpected string %q in len expression %v", arg.String, t.Ident)
+		case arg.HasColon:
+			comp.error(arg.Pos2, "unexpected ':'")
+		case arg.Ident == "" && len(arg.Args) != 0:
+			comp.error(arg.Pos, "int %v in len expression %v has subargs", arg.Value, t.Ident)
+		default:
+			checkLenExpr(comp, arg)
+		}
+	}
+}
+
+// lenExprTargets returns all len targets referenced by len target or expression t.
+func lenExprTargets(t *ast.Type) []*ast.Type {
+	if t.Ident == "" {
+		return nil
+	}
+	if len(t.Args) == 0 {
+		return []*ast.Type{t}
+	}
+	var targets []*ast.Type
+	for _, arg := range t.Args {
+		targets = append(targets, lenExprTargets(arg)...)
+	}
+	return targets
+}
+
 var typeFlags = &typeDesc{
 	Names:        []string{"flags"},
 	CanBeArgRet:  canBeArg,
@@ -282,6 +457,85 @@ var typeArgFlags = &typeArg{
 	},
 }
 
+var typeFlagIndex = &typeDesc{
+	Names:       []string{"flagindex"},
+	CanBeArgRet: canBeArg,
+	CantBeOpt:   true,
+	NeedBase:    true,
+	Args:        []namedArg{{Name: "flags", Type: typeArgFlags}},
+	CheckConsts: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) {
+		size := base.TypeSize * 8
+		if base.BitfieldLen != 0 {
+			size = base.BitfieldLen
+		}
+		for _, v := range comp.intFlags[args[0].Ident].Values {
+			if v.Value == 0 || v.Value&(v.Value-1) != 0 {
+				comp.error(args[0].Pos, "flagindex flags %v value 0x%x is not a single bit",
+					args[0].Ident, v.Value)
+				continue
+			}
+			if idx := uint64(bits.TrailingZeros64(v.Value)); size < 64 && idx >= 1<<size {
+				comp.error(args[0].Pos, "flagindex flags %v bit index %v does not fit into %v bits",
+					args[0].Ident, idx, size)
+			}
+		}
+	},
+	Gen: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) prog.Type {
+		name := args[0].Ident
+		f := comp.intFlags[name]
+		if len(f.Values) == 0 {
+			// We can get this if all values are unsupported consts.
+			return &prog.IntType{
+				IntTypeCommon: base,
+				Kind:          prog.IntPlain,
+			}
+		}
+		var indices []uint64
+		for _, v := range genIntArray(f.Values) {
+			indices = append(indices, uint64(bits.TrailingZeros64(v)))
+		}
+		return &prog.IntType{
+			IntTypeCommon: base,
+			Kind:          prog.IntFlagIndex,
+			Flags:         name,
+			FlagIndices:   indices,
+		}
+	},
+}
+
+var typeIntPow2 = &typeDesc{
+	Names:       []string{"int_pow2"},
+	CanBeArgRet: canBeArg,
+	CantBeOpt:   true,
+	NeedBase:    true,
+	Args:        []namedArg{{Name: "range", Type: typeArgIntRange}},
+	CheckConsts: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) {
+		begin, end := args[0].Value, args[0].Value
+		if args[0].HasColon {
+			end = args[0].Value2
+		}
+		if begin == 0 || begin&(begin-1) != 0 || end == 0 || end&(end-1) != 0 {
+			comp.error(args[0].Pos, "int_pow2 range [%v:%v] boundaries must be powers of 2", begin, end)
+			return
+		}
+		size := base.TypeSize * 8
+		if base.BitfieldLen != 0 {
+			size = base.BitfieldLen
+		}
+		if size < 64 && end >= 1<<size {
+			comp.error(args[0].Pos, "int_pow2 range [%v:%v] does not fit into %v bits", begin, end, size)
+		}
+	},
+	Gen: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) prog.Type {
+		return &prog.IntType{
+			IntTypeCommon: base,
+			Kind:          prog.IntPow2,
+			RangeBegin:    args[0].Value,
+			RangeEnd:      args[0].Value2,
+		}
+	},
+}
+
 var typeFileoff = &typeDesc{
 	Names:       []string{"fileoff"},
 	CanBeArgRet: canBeArg,
@@ -295,6 +549,116 @@ var typeFileoff = &typeDesc{
 	},
 }
 
+var typeRingIndex = &typeDesc{
+	Names:     []string{"ringhead", "ringtail"},
+	CantBeOpt: true,
+	NeedBase:  true,
+	Args:      []namedArg{{Name: "ring", Type: typeArgRingTarget}},
+	Gen: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) prog.Type {
+		kind := prog.IntRingHead
+		if t.Ident == "ringtail" {
+			kind = prog.IntRingTail
+		}
+		return &prog.IntType{
+			IntTypeCommon: base,
+			Kind:          kind,
+			Ring:          args[0].Ident,
+		}
+	},
+}
+
+var typeArgRingTarget = &typeArg{
+	Kind: kindIdent,
+}
+
+var typeRelPtr = &typeDesc{
+	Names:     []string{"relptr"},
+	CantBeOpt: true,
+	NeedBase:  true,
+	OptArgs:   1,
+	Args: []namedArg{
+		{Name: "pointee", Type: typeArgRelPtrTarget},
+		{Name: "base", Type: typeArgRelPtrBase},
+	},
+	Gen: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) prog.Type {
+		return &prog.IntType{
+			IntTypeCommon: base,
+			Kind:          prog.IntRelPtr,
+			RelPtr:        args[0].Ident,
+			RelSelf:       len(args) > 1 && args[1].Ident == "self",
+		}
+	},
+}
+
+var typeArrayIndex = &typeDesc{
+	Names:     []string{"arrayindex"},
+	CantBeOpt: true,
+	NeedBase:  true,
+	Gen: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) prog.Type {
+		return &prog.IntType{
+			IntTypeCommon: base,
+			Kind:          prog.IntArrayIndex,
+		}
+	},
+}
+
+var typeParity = &typeDesc{
+	Names:     []string{"parity"},
+	CantBeOpt: true,
+	NeedBase:  true,
+	Args: []namedArg{
+		{Name: "kind", Type: typeArgParityKind},
+		{Name: "bits", Type: typeArgParityBits},
+	},
+	Check: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) {
+		if base.BitfieldLen != 1 {
+			comp.error(t.Pos, "parity must be a 1-bit bitfield")
+			return
+		}
+		if bits := args[1]; bits.Value2 >= base.TypeSize*8 {
+			comp.error(bits.Pos, "parity bits [%v:%v] don't fit into %v-bit backing integer",
+				bits.Value, bits.Value2, base.TypeSize*8)
+		}
+	},
+	Gen: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) prog.Type {
+		return &prog.IntType{
+			IntTypeCommon: base,
+			Kind:          prog.IntParity,
+			ParityOdd:     args[0].Ident == "odd",
+			ParityBegin:   args[1].Value,
+			ParityEnd:     args[1].Value2,
+		}
+	},
+}
+
+var typeArgParityKind = &typeArg{
+	Kind:  kindIdent,
+	Names: []string{"even", "odd"},
+}
+
+// Bits of the backing integer that parity is computed over.
+var typeArgParityBits = &typeArg{
+	Kind:       kindInt,
+	AllowColon: true,
+	CheckConsts: func(comp *compiler, t *ast.Type) {
+		if !t.HasColon {
+			t.Value2 = t.Value
+		}
+		if t.Value > t.Value2 || t.Value2 > 63 {
+			comp.error(t.Pos, "bad parity bits [%v:%v]", t.Value, t.Value2)
+		}
+	},
+}
+
+var typeArgRelPtrTarget = &typeArg{
+	Kind: kindIdent,
+}
+
+var typeArgRelPtrBase = &typeArg{
+	Kind:  kindIdent,
+	Names: []string{"parent", "self"},
+}
+
 var typeVMA = &typeDesc{
 	Names:       []string{"vma", "vma64"},
 	CanBeArgRet: canBeArg,
@@ -317,6 +681,34 @@ var typeVMA = &typeDesc{
 	},
 }
 
+var typeFuncPtr = &typeDesc{
+	Names:       []string{"funcptr", "funcptr64"},
+	CanBeArgRet: canBeArg,
+	Gen: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) prog.Type {
+		base.TypeSize = comp.ptrSize
+		if t.Ident == "funcptr64" {
+			base.TypeSize = 8
+		}
+		return &prog.IntType{
+			IntTypeCommon: base,
+			Kind:          prog.IntFuncPtr,
+		}
+	},
+}
+
+var typeChildPid = &typeDesc{
+	Names:       []string{"child_pid"},
+	CanBeArgRet: canBeArg,
+	CantBeOpt:   true,
+	Gen: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) prog.Type {
+		base.TypeSize = 4
+		return &prog.IntType{
+			IntTypeCommon: base,
+			Kind:          prog.IntChildPid,
+		}
+	},
+}
+
 var typeCsum = &typeDesc{
 	Names:     []string{"csum"},
 	NeedBase:  true,
@@ -331,6 +723,9 @@ var typeCsum = &typeDesc{
 		if len(args) > 2 && genCsumKind(args[1]) != prog.CsumPseudo {
 			comp.error(args[2].Pos, "only pseudo csum can have proto")
 		}
+		if genCsumKind(args[1]) == prog.CsumCrc32 && base.TypeSize != 4 {
+			comp.error(args[1].Pos, "crc32 csum must be 4 bytes, not %v", base.TypeSize)
+		}
 	},
 	Gen: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) prog.Type {
 		var proto uint64
@@ -348,7 +743,7 @@ var typeCsum = &typeDesc{
 
 var typeArgCsumType = &typeArg{
 	Kind:  kindIdent,
-	Names: []string{"inet", "pseudo"},
+	Names: []string{"inet", "pseudo", "crc32", "xor"},
 }
 
 func genCsumKind(t *ast.Type) prog.CsumKind {
@@ -357,11 +752,53 @@ func genCsumKind(t *ast.Type) prog.CsumKind {
 		return prog.CsumInet
 	case "pseudo":
 		return prog.CsumPseudo
+	case "crc32":
+		return prog.CsumCrc32
+	case "xor":
+		return prog.CsumXor
 	default:
 		panic(fmt.Sprintf("unknown csum kind %q", t.Ident))
 	}
 }
 
+var typeHash = &typeDesc{
+	Names:     []string{"hash"},
+	NeedBase:  true,
+	CantBeOpt: true,
+	Args: []namedArg{
+		{Name: "algorithm", Type: typeArgHashAlgo},
+		{Name: "hash target", Type: typeArgLenTarget},
+	},
+	Check: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) {
+		if genHashKind(args[0]) == prog.CsumFnv1a && base.TypeSize != 4 && base.TypeSize != 8 {
+			comp.error(args[0].Pos, "fnv1a hash must be 4 or 8 bytes, not %v", base.TypeSize)
+		}
+	},
+	Gen: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) prog.Type {
+		return &prog.CsumType{
+			IntTypeCommon: base,
+			Buf:           args[1].Ident,
+			Kind:          genHashKind(args[0]),
+		}
+	},
+}
+
+var typeArgHashAlgo = &typeArg{
+	Kind:  kindIdent,
+	Names: []string{"fnv1a", "add"},
+}
+
+func genHashKind(t *ast.Type) prog.CsumKind {
+	switch t.Ident {
+	case "fnv1a":
+		return prog.CsumFnv1a
+	case "add":
+		return prog.CsumAdd
+	default:
+		panic(fmt.Sprintf("unknown hash algorithm %q", t.Ident))
+	}
+}
+
 var typeProc = &typeDesc{
 	Names:        []string{"proc"},
 	CanBeArgRet:  canBeArg,
@@ -379,6 +816,9 @@ var typeProc = &typeDesc{
 			return
 		}
 		size := base.TypeSize * 8
+		if base.BitfieldLen != 0 {
+			size = base.BitfieldLen
+		}
 		max := uint64(1) << size
 		if size == 64 {
 			max = ^uint64(0)
@@ -416,6 +856,23 @@ var typeText = &typeDesc{
 	},
 }
 
+var typeSerialized = &typeDesc{
+	Names:     []string{"serialized"},
+	CantBeOpt: true,
+	Args:      []namedArg{{Name: "format", Type: typeArgType}},
+	Varlen: func(comp *compiler, t *ast.Type, args []*ast.Type) bool {
+		return true
+	},
+	Gen: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) prog.Type {
+		base.TypeSize = 0
+		return &prog.BufferType{
+			TypeCommon: base.TypeCommon,
+			Kind:       prog.BufferSerialized,
+			Elem:       comp.genType(args[0], "", base.ArgDir, false),
+		}
+	},
+}
+
 var typeArgTextType = &typeArg{
 	Kind:  kindIdent,
 	Names: []string{"target", "x86_real", "x86_16", "x86_32", "x86_64", "arm64"},
@@ -607,7 +1064,7 @@ var typeFmt = &typeDesc{
 	Check: func(comp *compiler, t *ast.Type, args []*ast.Type, base prog.IntTypeCommon) {
 		desc, _, _ := comp.getArgsBase(args[1], "", base.TypeCommon.ArgDir, true)
 		switch desc {
-		case typeResource, typeInt, typeLen, typeFlags, typeProc:
+		case typeResource, typeInt, typeLen, typeUnitSize, typeFlags, typeProc:
 		default:
 			comp.error(t.Pos, "bad fmt value %v, expect an integer", args[1].Ident)
 			return
@@ -729,6 +1186,9 @@ func init() {
 			Name: t.Ident,
 			Dir:  base.ArgDir,
 		}
+		if comp.compat {
+			key.Name += compatSuffix
+		}
 		desc := comp.structDescs[key]
 		if desc == nil {
 			// Need to assign to structDescs before calling genStructDesc to break recursion.
@@ -813,7 +1273,8 @@ var typeArgSizeRange = &typeArg{
 var typeArgBase = namedArg{
 	Name: "base type",
 	Type: &typeArg{
-		Names:      []string{"int8", "int16", "int32", "int64", "int16be", "int32be", "int64be", "intptr"},
+		Names: []string{"int8", "int16", "int32", "int64", "int16be", "int32be", "int64be",
+			"int16le", "int32le", "int64le", "intptr"},
 		AllowColon: true,
 		Check: func(comp *compiler, t *ast.Type) {
 			if t.HasColon {
@@ -838,6 +1299,20 @@ var typeArgBase = namedArg{
 	},
 }
 
+// choice[A, B, ...] is an argument that is one of the alternative types.
+// It's replaced with an implicit varlen union of the alternatives during typecheck (see replaceChoice),
+// so it does not need any of the typeDesc callbacks.
+var typeChoice = &typeDesc{
+	Names: []string{"choice"},
+}
+
+// sparse[INDEX, VALUE, SIZE] is an array of (index, value) entries with unique ascending indices.
+// It's replaced with an array of an implicit entry struct during typecheck (see replaceSparse),
+// so it does not need any of the typeDesc callbacks.
+var typeSparse = &typeDesc{
+	Names: []string{"sparse"},
+}
+
 var (
 	builtinTypes    = make(map[string]*typeDesc)
 	builtinTypedefs = make(map[string]*ast.TypeDef)
@@ -866,6 +1341,39 @@ type optional[T] [
 	val	T
 	void	void
 ] [varlen]
+
+type tagged_record[TAG, BASE, PAYLOAD] {
+	tag	const[TAG, BASE]
+	payload	PAYLOAD
+}
+
+type sockaddr_family[FAMILY, ADDR] tagged_record[FAMILY, int16, ADDR]
+
+type nlattr_t[TYPE, PAYLOAD] {
+	nla_len		bytesize_inclusive[body, int16]
+	body		nlattr_body_t[TYPE, PAYLOAD]
+} [packed, align_4]
+
+type nlattr_body_t[TYPE, PAYLOAD] {
+	nla_type	TYPE
+	payload		PAYLOAD
+} [packed]
+
+type nlattr[TYPE, PAYLOAD] nlattr_t[const[TYPE, int16], PAYLOAD]
+
+type nlattr_nested_t[TYPE, PAYLOAD] {
+	nla_len		bytesize_inclusive[body, int16]
+	body		nlattr_nested_body_t[TYPE, PAYLOAD]
+} [packed, align_4]
+
+type nlattr_nested_body_t[TYPE, PAYLOAD] {
+	nla_type		TYPE
+	NLA_F_NET_BYTEORDER	const[0, int16:1]
+	NLA_F_NESTED		const[1, int16:1]
+	payload			PAYLOAD
+} [packed]
+
+type nlattr_nested[TYPE, PAYLOAD] nlattr_nested_t[const[TYPE, int16:14], PAYLOAD]
 `
 
 func init() {
@@ -873,18 +1381,33 @@ func init() {
 		typeInt,
 		typePtr,
 		typeVoid,
+		typeReserved,
 		typeArray,
 		typeLen,
+		typeUnitSize,
 		typeConst,
+		typeFooter,
 		typeFlags,
+		typeFlagIndex,
+		typeIntPow2,
 		typeFileoff,
+		typeRingIndex,
+		typeRelPtr,
+		typeArrayIndex,
+		typeParity,
 		typeVMA,
+		typeFuncPtr,
+		typeChildPid,
 		typeCsum,
+		typeHash,
 		typeProc,
 		typeText,
+		typeSerialized,
 		typeBuffer,
 		typeString,
 		typeFmt,
+		typeChoice,
+		typeSparse,
 	}
 	for _, desc := range builtins {
 		for _, name := range desc.Names {
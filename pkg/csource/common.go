@@ -67,13 +67,14 @@ func createCommonHeader(p, mmapProg *prog.Prog, replacements map[string]string,
 
 func defineList(p, mmapProg *prog.Prog, opts Options) (defines []string) {
 	sysTarget := targets.Get(p.Target.OS, p.Target.Arch)
-	bitmasks, csums := prog.RequiredFeatures(p)
+	bitmasks, csums, childPids := prog.RequiredFeatures(p)
 	enabled := map[string]bool{
 		"GOOS_" + p.Target.OS:               true,
 		"GOARCH_" + p.Target.Arch:           true,
 		"HOSTGOOS_" + runtime.GOOS:          true,
 		"SYZ_USE_BITMASKS":                  bitmasks,
 		"SYZ_USE_CHECKSUMS":                 csums,
+		"SYZ_USE_CHILD_PID":                 childPids,
 		"SYZ_SANDBOX_NONE":                  opts.Sandbox == sandboxNone,
 		"SYZ_SANDBOX_SETUID":                opts.Sandbox == sandboxSetuid,
 		"SYZ_SANDBOX_NAMESPACE":             opts.Sandbox == sandboxNamespace,
@@ -61,7 +61,7 @@ func Write(p *prog.Prog, opts Options) ([]byte, error) {
 	replacements := map[string]string{
 		"PROCS":           fmt.Sprint(opts.Procs),
 		"REPEAT_TIMES":    fmt.Sprint(opts.RepeatTimes),
-		"NUM_CALLS":       fmt.Sprint(len(p.Calls)),
+		"NUM_CALLS":       fmt.Sprint(len(calls)),
 		"MMAP_DATA":       strings.Join(mmapCalls, ""),
 		"SYSCALL_DEFINES": ctx.generateSyscallDefines(),
 		"SANDBOX_FUNC":    sandboxFunc,
@@ -159,19 +159,31 @@ func (ctx *context) generateProgCalls(p *prog.Prog, trace bool) ([]string, []uin
 	if err != nil {
 		return nil, nil, err
 	}
-	calls, vars := ctx.generateCalls(decoded, trace)
+	// Printed args are collected after serialization since call hooks can change the program.
+	calls, vars := ctx.generateCalls(decoded, ctx.printedArgs(p), trace)
 	return calls, vars, nil
 }
 
-func (ctx *context) generateCalls(p prog.ExecProg, trace bool) ([]string, []uint64) {
+func (ctx *context) generateCalls(p prog.ExecProg, printed [][]*printedArg, trace bool) ([]string, []uint64) {
 	var calls []string
 	csumSeq := 0
 	for ci, call := range p.Calls {
 		w := new(bytes.Buffer)
 		// Copyin.
+		callPrinted := ctx.checkPrintedArgs(printed[ci], call)
 		for _, copyin := range call.Copyin {
+			if arg := findPrintedArg(callPrinted, copyin.Addr); arg != nil {
+				if !arg.emitted {
+					ctx.emitPrintedArg(w, arg)
+					arg.emitted = true
+				}
+				continue
+			}
 			ctx.copyin(w, &csumSeq, copyin)
 		}
+		for _, addr := range call.Guards {
+			ctx.guard(w, addr)
+		}
 
 		if ctx.opts.Fault && ctx.opts.FaultCall == ci {
 			// Note: these files are also hardcoded in pkg/host/host_linux.go.
@@ -201,6 +213,11 @@ func (ctx *context) generateCalls(p prog.ExecProg, trace bool) ([]string, []uint
 		if resCopyout || argCopyout {
 			ctx.copyout(w, call, resCopyout)
 		}
+		if ci != 0 && call.Meta.Group != "" && call.Meta.Group == p.Calls[ci-1].Meta.Group {
+			// Adjacent calls of the same atomic group are executed back-to-back by the same thread.
+			calls[len(calls)-1] += w.String()
+			continue
+		}
 		calls = append(calls, w.String())
 	}
 	return calls, p.Vars
@@ -209,21 +226,24 @@ func (ctx *context) generateCalls(p prog.ExecProg, trace bool) ([]string, []uint
 func (ctx *context) emitCall(w *bytes.Buffer, call prog.ExecCall, ci int, haveCopyout, trace bool) {
 	callName := call.Meta.CallName
 	native := ctx.sysTarget.SyscallNumbers && !strings.HasPrefix(callName, "syz_")
-	fmt.Fprintf(w, "\t")
-	if haveCopyout || trace {
-		fmt.Fprintf(w, "res = ")
+	cast := ""
+	if !native && !strings.HasPrefix(callName, "syz_") {
+		// Potentially we casted a function returning int to a function returning long.
+		// So instead of long -1 we can get 0x00000000ffffffff. Sign extend it to long.
+		cast = "(long)(int)"
 	}
-	ctx.emitCallName(w, call, native)
+	buf := new(bytes.Buffer)
+	ctx.emitCallName(buf, call, native)
 	for ai, arg := range call.Args {
 		if native || ai > 0 {
-			fmt.Fprintf(w, ", ")
+			fmt.Fprintf(buf, ", ")
 		}
 		switch arg := arg.(type) {
 		case prog.ExecArgConst:
 			if arg.Format != prog.FormatNative && arg.Format != prog.FormatBigEndian {
 				panic("sring format in syscall argument")
 			}
-			fmt.Fprintf(w, "%v", ctx.constArgToStr(arg, true))
+			fmt.Fprintf(buf, "%v", ctx.constArgToStr(arg, true))
 		case prog.ExecArgResult:
 			if arg.Format != prog.FormatNative && arg.Format != prog.FormatBigEndian {
 				panic("sring format in syscall argument")
@@ -234,25 +254,42 @@ func (ctx *context) emitCall(w *bytes.Buffer, call prog.ExecCall, ci int, haveCo
 				// and take 2 slots without the cast, which would be wrong.
 				val = "(long)" + val
 			}
-			fmt.Fprintf(w, "%v", val)
+			fmt.Fprintf(buf, "%v", val)
+		case prog.ExecArgChildPid:
+			if arg.Format != prog.FormatNative && arg.Format != prog.FormatBigEndian {
+				panic("sring format in syscall argument")
+			}
+			fmt.Fprintf(buf, "%v", ctx.childPidArgToStr(arg))
 		default:
 			panic(fmt.Sprintf("unknown arg type: %+v", arg))
 		}
 	}
-	for i := 0; i < call.Meta.MissingArgs; i++ {
+	// Omitted trailing args are zero-filled the same way executor does.
+	for i := 0; i < call.Meta.MissingArgs+len(call.Meta.Args)-len(call.Args); i++ {
 		if native || len(call.Args) != 0 {
-			fmt.Fprintf(w, ", ")
+			fmt.Fprintf(buf, ", ")
+		}
+		fmt.Fprintf(buf, "0")
+	}
+	fmt.Fprintf(buf, ")")
+	haveRes := haveCopyout || trace
+	// Don't retry calls with injected faults, the fault is injected only into the first attempt.
+	if retries := call.Meta.Retries; retries != 0 && !(ctx.opts.Fault && ctx.opts.FaultCall == ci) {
+		fmt.Fprintf(w, "\tfor (int i = 0; i <= %v; i++) {\n", retries)
+		if haveRes {
+			fmt.Fprintf(w, "\t\tres = %s;\n", buf.Bytes())
+			fmt.Fprintf(w, "\t\tif (%vres != -1)\n", cast)
+		} else {
+			fmt.Fprintf(w, "\t\tif (%v%s != -1)\n", cast, buf.Bytes())
 		}
-		fmt.Fprintf(w, "0")
+		fmt.Fprintf(w, "\t\t\tbreak;\n")
+		fmt.Fprintf(w, "\t}\n")
+	} else if haveRes {
+		fmt.Fprintf(w, "\tres = %s;\n", buf.Bytes())
+	} else {
+		fmt.Fprintf(w, "\t%s;\n", buf.Bytes())
 	}
-	fmt.Fprintf(w, ");\n")
 	if trace {
-		cast := ""
-		if !native && !strings.HasPrefix(callName, "syz_") {
-			// Potentially we casted a function returning int to a function returning long.
-			// So instead of long -1 we can get 0x00000000ffffffff. Sign extend it to long.
-			cast = "(long)(int)"
-		}
 		fmt.Fprintf(w, "\tfprintf(stderr, \"### call=%v errno=%%u\\n\", %vres == -1 ? errno : 0);\n", ci, cast)
 	}
 }
@@ -264,7 +301,7 @@ func (ctx *context) emitCallName(w *bytes.Buffer, call prog.ExecCall, native boo
 	} else if strings.HasPrefix(callName, "syz_") {
 		fmt.Fprintf(w, "%v(", callName)
 	} else {
-		args := strings.Repeat(",long", len(call.Args))
+		args := strings.Repeat(",long", len(call.Meta.Args))
 		if args != "" {
 			args = args[1:]
 		}
@@ -293,6 +330,32 @@ func (ctx *context) generateCsumInet(w *bytes.Buffer, addr uint64, arg prog.Exec
 		addr, csumSeq)
 }
 
+func (ctx *context) generateCsumData(w *bytes.Buffer, addr uint64, arg prog.ExecArgCsum, csumSeq int) {
+	var kind string
+	initArgs := fmt.Sprintf(", %d", arg.Size)
+	switch arg.Kind {
+	case prog.ExecArgCsumCrc32:
+		kind, initArgs = "crc32", ""
+	case prog.ExecArgCsumXor:
+		kind = "xor"
+	case prog.ExecArgCsumFnv1a:
+		kind = "fnv1a"
+	case prog.ExecArgCsumAdd:
+		kind = "add"
+	}
+	fmt.Fprintf(w, "\tstruct csum_%v csum_%d;\n", kind, csumSeq)
+	fmt.Fprintf(w, "\tcsum_%v_init(&csum_%d%v);\n", kind, csumSeq, initArgs)
+	for _, chunk := range arg.Chunks {
+		if chunk.Kind != prog.ExecArgCsumChunkData {
+			panic(fmt.Sprintf("unknown %v checksum chunk kind %v", kind, chunk.Kind))
+		}
+		fmt.Fprintf(w, "\tNONFAILING(csum_%v_update(&csum_%d, (const uint8*)0x%x, %d));\n",
+			kind, csumSeq, chunk.Value, chunk.Size)
+	}
+	fmt.Fprintf(w, "\tNONFAILING(*(uint%d*)0x%x = csum_%v_digest(&csum_%d));\n",
+		arg.Size*8, addr, kind, csumSeq)
+}
+
 func (ctx *context) copyin(w *bytes.Buffer, csumSeq *int, copyin prog.ExecCopyin) {
 	switch arg := copyin.Arg.(type) {
 	case prog.ExecArgConst:
@@ -312,6 +375,8 @@ func (ctx *context) copyin(w *bytes.Buffer, csumSeq *int, copyin prog.ExecCopyin
 		}
 	case prog.ExecArgResult:
 		ctx.copyinVal(w, copyin.Addr, arg.Size, ctx.resultArgToStr(arg), arg.Format)
+	case prog.ExecArgChildPid:
+		ctx.copyinVal(w, copyin.Addr, arg.Size, ctx.childPidArgToStr(arg), arg.Format)
 	case prog.ExecArgData:
 		fmt.Fprintf(w, "\tNONFAILING(memcpy((void*)0x%x, \"%s\", %v));\n",
 			copyin.Addr, toCString(arg.Data, arg.Readable), len(arg.Data))
@@ -320,6 +385,9 @@ func (ctx *context) copyin(w *bytes.Buffer, csumSeq *int, copyin prog.ExecCopyin
 		case prog.ExecArgCsumInet:
 			*csumSeq++
 			ctx.generateCsumInet(w, copyin.Addr, arg, *csumSeq)
+		case prog.ExecArgCsumCrc32, prog.ExecArgCsumXor, prog.ExecArgCsumFnv1a, prog.ExecArgCsumAdd:
+			*csumSeq++
+			ctx.generateCsumData(w, copyin.Addr, arg, *csumSeq)
 		default:
 			panic(fmt.Sprintf("unknown csum kind %v", arg.Kind))
 		}
@@ -328,6 +396,17 @@ func (ctx *context) copyin(w *bytes.Buffer, csumSeq *int, copyin prog.ExecCopyin
 	}
 }
 
+func (ctx *context) guard(w *bytes.Buffer, addr uint64) {
+	mprotect := ctx.target.SyscallMap["mprotect"]
+	if !ctx.sysTarget.SyscallNumbers || mprotect == nil {
+		// Guard pages are not supported, but the slack is still poisoned by copyin.
+		return
+	}
+	ctx.calls[mprotect.CallName] = mprotect.NR
+	fmt.Fprintf(w, "\tsyscall(%v%v, 0x%xul, 0x%xul, 0ul);\n",
+		ctx.sysTarget.SyscallPrefix, mprotect.CallName, addr, ctx.target.PageSize)
+}
+
 func (ctx *context) copyinVal(w *bytes.Buffer, addr, size uint64, val string, bf prog.BinaryFormat) {
 	switch bf {
 	case prog.FormatNative, prog.FormatBigEndian:
@@ -374,8 +453,11 @@ func (ctx *context) copyout(w *bytes.Buffer, call prog.ExecCall, resCopyout bool
 		fmt.Fprintf(w, "\t\tr[%v] = res;\n", call.Index)
 	}
 	for _, copyout := range call.Copyout {
-		fmt.Fprintf(w, "\t\tNONFAILING(r[%v] = *(uint%v*)0x%x);\n",
-			copyout.Index, copyout.Size*8, copyout.Addr)
+		val := fmt.Sprintf("*(uint%v*)0x%x", copyout.Size*8, copyout.Addr)
+		if copyout.Format == prog.FormatBigEndian {
+			val = fmt.Sprintf("be%vtoh(%v)", copyout.Size*8, val)
+		}
+		fmt.Fprintf(w, "\t\tNONFAILING(r[%v] = %v);\n", copyout.Index, val)
 	}
 	if copyoutMultiple {
 		fmt.Fprintf(w, "\t}\n")
@@ -414,6 +496,13 @@ func (ctx *context) resultArgToStr(arg prog.ExecArgResult) string {
 	return res
 }
 
+func (ctx *context) childPidArgToStr(arg prog.ExecArgChildPid) string {
+	if arg.Format == prog.FormatBigEndian {
+		return fmt.Sprintf("htobe%v(syz_child_pid())", arg.Size*8)
+	}
+	return "syz_child_pid()"
+}
+
 func (ctx *context) postProcess(result []byte) []byte {
 	// Remove NONFAILING, debug, fail, etc calls.
 	if !ctx.opts.HandleSegv {
@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -107,3 +108,82 @@ func testOne(t *testing.T, p *prog.Prog, opts Options) {
 	}
 	defer os.Remove(bin)
 }
+
+func init() {
+	RegisterPrinter("test", prog.StructKey{Name: "syz_align0", Dir: prog.DirIn}, printAlign0)
+}
+
+func printAlign0(arg prog.Arg, addr uint64) (string, bool) {
+	var vals []string
+	for _, field := range arg.(*prog.GroupArg).Inner {
+		if !prog.IsPad(field.Type()) {
+			vals = append(vals, fmt.Sprintf("0x%x", field.(*prog.ConstArg).Val))
+		}
+	}
+	if vals[0] == "0x0" {
+		// Exercise the fallback.
+		return "", false
+	}
+	return fmt.Sprintf("{\n"+
+		"\tstruct { uint16 f0; uint32 f1; uint8 f2; uint16 f3; uint64 f4; } s = {%v};\n"+
+		"\tNONFAILING(memcpy((void*)0x%x, &s, sizeof(s)));\n"+
+		"}\n", strings.Join(vals, ", "), addr), true
+}
+
+func TestPrinters(t *testing.T) {
+	target, err := prog.GetTarget("test", "64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := target.Deserialize([]byte(
+		"test$align0(&(0x7f0000000000)={0x1, 0x2, 0x3, 0x4, 0x5})\n"+
+			"test$align0(&(0x7f0000000100)={0x0, 0x2, 0x3, 0x4, 0x5})\n"), prog.Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := Write(p, Options{Procs: 1, Sandbox: "none"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "s = {0x1, 0x2, 0x3, 0x4, 0x5};") ||
+		!strings.Contains(string(src), "memcpy((void*)0x20000000, &s, sizeof(s));") {
+		t.Fatalf("no printed struct in the program:\n%s", src)
+	}
+	// The second struct is not handled by the printer.
+	if !strings.Contains(string(src), "*(uint16_t*)0x20000100 = 0;") ||
+		strings.Contains(string(src), "*(uint16_t*)0x20000000") {
+		t.Fatalf("wrong default stores in the program:\n%s", src)
+	}
+	if _, err := exec.LookPath(targets.Get(target.OS, target.Arch).CCompiler); err != nil {
+		t.Skipf("no target compiler")
+	}
+	bin, err := Build(target, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(bin)
+}
+
+func TestAtomicGroups(t *testing.T) {
+	target, err := prog.GetTarget("test", "64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := target.Deserialize([]byte(
+		"r0 = test$atomic0()\n"+
+			"test$atomic1(r0)\n"+
+			"test$res0()\n"+
+			"test$atomic1(r0)\n"), prog.Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := Write(p, Options{Threaded: true, Procs: 1, Sandbox: "none"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first two calls are executed in one case, the last call starts a new group.
+	if !strings.Contains(string(src), "case 2:") || strings.Contains(string(src), "case 3:") ||
+		!strings.Contains(string(src), "call < 3;") {
+		t.Fatalf("atomic group is not merged:\n%s", src)
+	}
+}
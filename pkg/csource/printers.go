@@ -0,0 +1,126 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package csource
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/google/syzkaller/prog"
+)
+
+// Printer returns C code that stores value of the struct or union arg in memory at addr
+// (e.g. a named struct initializer with field assignments followed by memcpy to addr).
+// The code is a sequence of statements, include directives are hoisted to the top of the program.
+// If the printer can't handle the value, it returns false and the default per-field
+// stores are emitted.
+type Printer func(arg prog.Arg, addr uint64) (code string, ok bool)
+
+var printers = make(map[string]map[prog.StructKey]Printer)
+
+// RegisterPrinter registers printer for the struct or union with the given key on OS.
+// Printers should be registered during initialization, registration is not synchronized with Write.
+func RegisterPrinter(OS string, key prog.StructKey, printer Printer) {
+	if printers[OS] == nil {
+		printers[OS] = make(map[prog.StructKey]Printer)
+	}
+	if printers[OS][key] != nil {
+		panic(fmt.Sprintf("printer for %v/%v is already registered", OS, key.Name))
+	}
+	printers[OS][key] = printer
+}
+
+// printedArg is a memory range initialized by a custom printer.
+type printedArg struct {
+	addr    uint64
+	size    uint64
+	code    string
+	emitted bool
+}
+
+// printedArgs returns outermost struct/union args of every call of p that have custom printers.
+func (ctx *context) printedArgs(p *prog.Prog) [][]*printedArg {
+	res := make([][]*printedArg, len(p.Calls))
+	osPrinters := printers[p.Target.OS]
+	if len(osPrinters) == 0 {
+		return res
+	}
+	for ci, c := range p.Calls {
+		prog.ForeachArg(c, func(arg prog.Arg, argCtx *prog.ArgCtx) {
+			if argCtx.Base == nil || arg.Type().Dir() == prog.DirOut {
+				return
+			}
+			var key prog.StructKey
+			switch typ := arg.Type().(type) {
+			case *prog.StructType:
+				key = typ.Key
+			case *prog.UnionType:
+				key = typ.Key
+			default:
+				return
+			}
+			printer := osPrinters[key]
+			if printer == nil {
+				return
+			}
+			addr := ctx.target.PhysicalAddr(argCtx.Base) + argCtx.Offset
+			code, ok := printer(arg, addr)
+			if !ok {
+				return
+			}
+			res[ci] = append(res[ci], &printedArg{
+				addr: addr,
+				size: arg.Size(),
+				code: code,
+			})
+			argCtx.Stop = true
+		})
+	}
+	return res
+}
+
+// checkPrintedArgs drops printed args that cover copyins that can't be expressed
+// as plain values (results, checksums and per-proc values).
+func (ctx *context) checkPrintedArgs(args []*printedArg, call prog.ExecCall) []*printedArg {
+	var res []*printedArg
+	for _, arg := range args {
+		ok := true
+		for _, copyin := range call.Copyin {
+			if copyin.Addr < arg.addr || copyin.Addr >= arg.addr+arg.size {
+				continue
+			}
+			switch a := copyin.Arg.(type) {
+			case prog.ExecArgConst:
+				ok = ok && (a.PidStride == 0 || ctx.opts.Procs <= 1)
+			case prog.ExecArgData:
+			default:
+				ok = false
+			}
+		}
+		if ok {
+			res = append(res, arg)
+		}
+	}
+	return res
+}
+
+func (ctx *context) emitPrintedArg(w *bytes.Buffer, arg *printedArg) {
+	for _, line := range strings.Split(strings.TrimRight(arg.code, "\n"), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			line = "\t" + line
+		}
+		fmt.Fprintf(w, "%v\n", line)
+	}
+}
+
+// findPrintedArg returns printed arg that covers addr, or nil.
+func findPrintedArg(args []*printedArg, addr uint64) *printedArg {
+	for _, arg := range args {
+		if addr >= arg.addr && addr < arg.addr+arg.size {
+			return arg
+		}
+	}
+	return nil
+}
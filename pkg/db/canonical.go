@@ -0,0 +1,96 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package db
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/prog"
+)
+
+// CanonicalizeStats describes the effect of CanonicalizeCorpus.
+type CanonicalizeStats struct {
+	Records    int // number of records before canonicalization
+	Duplicates int // number of records dropped as duplicates
+	Broken     int // number of records that failed to deserialize (they are left as is)
+	Size       int // total size of programs before canonicalization
+	NewSize    int // total size of programs after canonicalization
+}
+
+// CanonicalizeCorpus replaces all programs in corpus database filename with their
+// canonical form (see prog.Prog.Canonical) with the version header (see prog.ProgVersion)
+// and keeps only one record per canonical form.
+// Of several records with the same canonical form, the one with the smallest Seq is kept
+// (with its Seq). Records that fail to deserialize are not changed.
+// The database is rewritten on disk only if it changes.
+func CanonicalizeCorpus(filename string, target *prog.Target) (*CanonicalizeStats, error) {
+	db, err := Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+	stats := &CanonicalizeStats{Records: len(db.Records)}
+	type canonRec struct {
+		key string // key of the original record
+		val []byte
+		seq uint64
+	}
+	canon := make(map[string]canonRec)
+	broken := make(map[string]bool)
+	var keys []string
+	for key := range db.Records {
+		keys = append(keys, key)
+	}
+	// Sort keys to choose the same representative regardless of map order.
+	sort.Strings(keys)
+	for _, key := range keys {
+		rec := db.Records[key]
+		stats.Size += len(rec.Val)
+		p, err := target.Deserialize(rec.Val, prog.NonStrict)
+		if err != nil {
+			broken[key] = true
+			stats.Broken++
+			stats.NewSize += len(rec.Val)
+			continue
+		}
+		val := prog.AddVersionHeader(p.Canonical())
+		sig := hash.String(val)
+		if prev, ok := canon[sig]; ok {
+			stats.Duplicates++
+			if prev.seq <= rec.Seq {
+				continue
+			}
+		} else {
+			stats.NewSize += len(val)
+		}
+		canon[sig] = canonRec{key, val, rec.Seq}
+	}
+	changed := false
+	for _, key := range keys {
+		if broken[key] {
+			continue
+		}
+		if rec, ok := canon[key]; ok && rec.key == key && bytes.Equal(rec.val, db.Records[key].Val) {
+			continue
+		}
+		db.Delete(key)
+		changed = true
+	}
+	for sig, rec := range canon {
+		if _, ok := db.Records[sig]; ok {
+			continue
+		}
+		db.Save(sig, rec.val, rec.seq)
+		changed = true
+	}
+	if !changed {
+		return stats, nil
+	}
+	if err := db.compact(); err != nil {
+		return nil, fmt.Errorf("failed to save database: %v", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package db
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/prog"
+	_ "github.com/google/syzkaller/sys"
+)
+
+func TestCanonicalizeCorpus(t *testing.T) {
+	target, err := prog.GetTarget("test", "64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn := tempFile(t)
+	defer os.Remove(fn)
+	progs := []struct {
+		text string
+		seq  uint64
+	}{
+		{"# syz-prog-version: 1\nr5 = test$res0()\ntest$res1(r5)\n", 3},
+		{"r0 = test$res0()\ntest$res1(r0)\n", 5},
+		{"test$align0(&(0x7f0000000000)={0x10001, 0x100000002, 0x103, 0x4, 0x5})\n", 2},
+		{"test$align0(&(0x7f0000000000)={0x1, 0x2, 0x3, 0x4, 0x5})\n", 7},
+		{"test$int(0x1ff, 0x2, 0x3, 0x4, 0x5)\n", 1},
+		{"broken$prog()\n", 4},
+	}
+	var records []Record
+	for _, p := range progs {
+		records = append(records, Record{Val: []byte(p.text), Seq: p.seq})
+	}
+	if err := Create(fn, 3, records); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := CanonicalizeCorpus(fn, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantStats := &CanonicalizeStats{
+		Records:    6,
+		Duplicates: 2,
+		Broken:     1,
+		Size:       stats.Size,
+		NewSize:    stats.NewSize,
+	}
+	if !reflect.DeepEqual(stats, wantStats) || stats.NewSize >= stats.Size {
+		t.Fatalf("bad stats: %+v", stats)
+	}
+	want := make(map[string]Record)
+	// Programs are stored with the version header, broken programs are left as is.
+	for _, rec := range []Record{
+		{Val: prog.AddVersionHeader([]byte("r0 = test$res0()\ntest$res1(r0)\n")), Seq: 3},
+		{Val: prog.AddVersionHeader([]byte("test$align0(&(0x7f0000000000)={0x1, 0x2, 0x3, 0x4, 0x5})\n")), Seq: 2},
+		{Val: prog.AddVersionHeader([]byte("test$int(0x1ff, 0x2, 0x3, 0x4, 0x5)\n")), Seq: 1},
+		{Val: []byte("broken$prog()\n"), Seq: 4},
+	} {
+		want[hash.String(rec.Val)] = rec
+	}
+	db, err := Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Version != 3 {
+		t.Fatalf("database version changed to %v", db.Version)
+	}
+	if !reflect.DeepEqual(db.Records, want) {
+		t.Fatalf("bad records after canonicalization:\n%+v\nwant:\n%+v", db.Records, want)
+	}
+	// The second run must not change anything.
+	stats, err = CanonicalizeCorpus(fn, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Records != 4 || stats.Duplicates != 0 || stats.Size != stats.NewSize {
+		t.Fatalf("bad stats after the second run: %+v", stats)
+	}
+}
@@ -53,6 +53,7 @@ const (
 	FlagCollectComps                       // collect KCOV comparisons
 	FlagThreaded                           // use multiple threads to mitigate blocked syscalls
 	FlagCollide                            // collide syscalls to provoke data races
+	FlagProfileCalls                       // measure execution time of calls (see CallInfo.Duration)
 )
 
 type ExecOpts struct {
@@ -89,6 +90,9 @@ type CallInfo struct {
 	// if dedup == false, then cov effectively contains a trace, otherwise duplicates are removed
 	Comps prog.CompMap // per-call comparison operands
 	Errno int          // call errno (0 if the call was successful)
+	// Duration is the call execution time, filled if FlagProfileCalls is set.
+	// For calls that are not finished it is the time the call was running so far.
+	Duration time.Duration
 }
 
 type ProgInfo struct {
@@ -343,6 +347,7 @@ func (env *Env) parseOutput(p *prog.Prog) (*ProgInfo, error) {
 			}
 			inf.Errno = int(reply.errno)
 			inf.Flags = CallFlags(reply.flags)
+			inf.Duration = time.Duration(reply.durationUs) * time.Microsecond
 		} else {
 			extraParts = append(extraParts, CallInfo{})
 			inf = &extraParts[len(extraParts)-1]
@@ -512,6 +517,7 @@ type callReply struct {
 	num        uint32 // syscall number (for cross-checking)
 	errno      uint32
 	flags      uint32 // see CallFlags
+	durationUs uint32 // call execution time in microseconds
 	signalSize uint32
 	coverSize  uint32
 	compsSize  uint32
@@ -0,0 +1,113 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package ipc
+
+import (
+	"time"
+
+	"github.com/google/syzkaller/prog"
+)
+
+// ProfileBuckets is the number of buckets in CallHistogram.
+// Bucket 0 counts calls that took less than 1us, bucket i counts calls that took
+// [2^(i-1), 2^i) microseconds, the last bucket also counts all calls that took longer
+// (i.e. it starts at ~8.4s).
+const ProfileBuckets = 25
+
+// CallHistogram is the distribution of execution time of a single syscall.
+type CallHistogram struct {
+	Buckets [ProfileBuckets]uint64
+	Total   time.Duration // total execution time of all calls
+}
+
+// CallProfile maps syscall names (prog.Syscall.Name) to histograms of their execution time
+// collected with FlagProfileCalls. It is not safe for concurrent use.
+type CallProfile map[string]*CallHistogram
+
+// Add adds execution time of all executed calls of p to the profile.
+// Calls that did not finish are accounted with the time they were running
+// before the program was terminated.
+func (prof CallProfile) Add(p *prog.Prog, info *ProgInfo) {
+	for i, inf := range info.Calls {
+		if inf.Flags&CallExecuted == 0 {
+			continue
+		}
+		prof.AddCall(p.Calls[i].Meta.Name, inf.Duration)
+	}
+}
+
+// AddCall adds a single execution of syscall name that took d.
+func (prof CallProfile) AddCall(name string, d time.Duration) {
+	hist := prof[name]
+	if hist == nil {
+		hist = new(CallHistogram)
+		prof[name] = hist
+	}
+	hist.Buckets[ProfileBucket(d)]++
+	hist.Total += d
+}
+
+// Merge adds all executions from prof1 to prof.
+func (prof CallProfile) Merge(prof1 CallProfile) {
+	for name, hist1 := range prof1 {
+		hist := prof[name]
+		if hist == nil {
+			hist = new(CallHistogram)
+			prof[name] = hist
+		}
+		for i, n := range hist1.Buckets {
+			hist.Buckets[i] += n
+		}
+		hist.Total += hist1.Total
+	}
+}
+
+// ProfileBucket returns index of the histogram bucket for duration d.
+func ProfileBucket(d time.Duration) int {
+	bucket := 0
+	for us := d / time.Microsecond; us != 0 && bucket < ProfileBuckets-1; us >>= 1 {
+		bucket++
+	}
+	return bucket
+}
+
+// ProfileBucketLimit returns the upper (exclusive) bound of durations in bucket i,
+// or 0 for the last bucket which is not bounded.
+func ProfileBucketLimit(i int) time.Duration {
+	if i >= ProfileBuckets-1 {
+		return 0
+	}
+	return time.Microsecond << uint(i)
+}
+
+// Count returns the total number of executions in the histogram.
+func (hist *CallHistogram) Count() uint64 {
+	count := uint64(0)
+	for _, n := range hist.Buckets {
+		count += n
+	}
+	return count
+}
+
+// Quantile returns upper bound of the bucket that contains q-quantile (0 < q <= 1)
+// of execution time, or 0 if the quantile falls into the last unbounded bucket
+// or the histogram is empty.
+func (hist *CallHistogram) Quantile(q float64) time.Duration {
+	count := hist.Count()
+	if count == 0 {
+		return 0
+	}
+	rank := uint64(q*float64(count) + 0.5)
+	if rank == 0 {
+		rank = 1
+	}
+	seen := uint64(0)
+	for i, n := range hist.Buckets {
+		seen += n
+		if seen >= rank {
+			return ProfileBucketLimit(i)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,81 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package ipc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProfileBucket(t *testing.T) {
+	tests := []struct {
+		d      time.Duration
+		bucket int
+	}{
+		{0, 0},
+		{999 * time.Nanosecond, 0},
+		{time.Microsecond, 1},
+		{2 * time.Microsecond, 2},
+		{3 * time.Microsecond, 2},
+		{4 * time.Microsecond, 3},
+		{time.Millisecond, 10},
+		{time.Second, 20},
+		{time.Hour, ProfileBuckets - 1},
+	}
+	for _, test := range tests {
+		bucket := ProfileBucket(test.d)
+		if bucket != test.bucket {
+			t.Errorf("duration %v: got bucket %v, want %v", test.d, bucket, test.bucket)
+			continue
+		}
+		if limit := ProfileBucketLimit(bucket); limit != 0 && test.d >= limit {
+			t.Errorf("duration %v: bucket %v limit %v is too low", test.d, bucket, limit)
+		}
+		if bucket != 0 && test.d < ProfileBucketLimit(bucket-1) {
+			t.Errorf("duration %v: bucket %v is too high", test.d, bucket)
+		}
+	}
+}
+
+func TestCallProfile(t *testing.T) {
+	prof := make(CallProfile)
+	for i := 0; i < 98; i++ {
+		prof.AddCall("foo", 10*time.Microsecond)
+	}
+	prof1 := make(CallProfile)
+	prof1.AddCall("foo", 5*time.Millisecond)
+	prof1.AddCall("foo", time.Minute)
+	prof1.AddCall("bar", 0)
+	prof.Merge(prof1)
+	if len(prof) != 2 {
+		t.Fatalf("got %v calls, want 2", len(prof))
+	}
+	foo := prof["foo"]
+	if count := foo.Count(); count != 100 {
+		t.Errorf("got count %v, want 100", count)
+	}
+	if total := foo.Total; total != time.Minute+5*time.Millisecond+980*time.Microsecond {
+		t.Errorf("got total %v", total)
+	}
+	for _, test := range []struct {
+		q     float64
+		limit time.Duration
+	}{
+		{0.01, 16 * time.Microsecond},
+		{0.5, 16 * time.Microsecond},
+		{0.98, 16 * time.Microsecond},
+		{0.99, 8192 * time.Microsecond},
+		{1, 0},
+	} {
+		if limit := foo.Quantile(test.q); limit != test.limit {
+			t.Errorf("quantile %v: got %v, want %v", test.q, limit, test.limit)
+		}
+	}
+	if count := prof["bar"].Count(); count != 1 {
+		t.Errorf("got bar count %v, want 1", count)
+	}
+	if q := new(CallHistogram).Quantile(0.5); q != 0 {
+		t.Errorf("got quantile %v for empty histogram", q)
+	}
+}
@@ -69,6 +69,9 @@ type Config struct {
 	Cover bool `json:"cover"`
 	// Reproduce, localize and minimize crashers (default: true).
 	Reproduce bool `json:"reproduce"`
+	// Measure execution time of calls and show per-call histograms on the web page (default: false).
+	// Has small runtime overhead.
+	ProfileCalls bool `json:"profile_calls"`
 
 	EnabledSyscalls  []string `json:"enable_syscalls,omitempty"`
 	DisabledSyscalls []string `json:"disable_syscalls,omitempty"`
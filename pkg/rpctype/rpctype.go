@@ -35,6 +35,7 @@ type ConnectRes struct {
 	AllSandboxes     bool
 	CheckResult      *CheckArgs
 	MemoryLeakFrames [][]byte
+	ProfileCalls     bool
 }
 
 type CheckArgs struct {
@@ -60,6 +61,7 @@ type PollArgs struct {
 	NeedCandidates bool
 	MaxSignal      signal.Serial
 	Stats          map[string]uint64
+	CallProfile    ipc.CallProfile
 }
 
 type PollRes struct {
@@ -27,6 +27,8 @@ const (
 	bitsPerUint64   = 8 * 8
 	memAllocL0Mem   = memAllocL0Size * memAllocGranule * bitsPerUint64
 	memAllocL1Size  = memAllocMaxMem / memAllocL0Mem
+	// Alignment of objects allocated with allocGuarded (enough for any kernel struct).
+	memAllocGuardAlign = 8
 )
 
 func newMemAlloc(totalMemSize uint64) *memAlloc {
@@ -58,14 +60,7 @@ func (ma *memAlloc) alloc(r *randGen, size0 uint64) uint64 {
 	size := (size0 + memAllocGranule - 1) / memAllocGranule
 	end := ma.size - size
 	for start := uint64(0); start < end; start++ {
-		empty := true
-		for i := uint64(0); i < size; i++ {
-			if ma.get(start + i) {
-				empty = false
-				break
-			}
-		}
-		if empty {
+		if ma.empty(start, size) {
 			start0 := start * memAllocGranule
 			ma.noteAlloc(start0, size0)
 			return start0
@@ -75,6 +70,47 @@ func (ma *memAlloc) alloc(r *randGen, size0 uint64) uint64 {
 	return ma.alloc(r, size0)
 }
 
+// allocGuarded allocates an object that ends as close to a page end as alignment permits,
+// the next page is reserved as well, so that it can be used as a guard page for the object.
+func (ma *memAlloc) allocGuarded(r *randGen, size0, pageSize uint64) uint64 {
+	if size0 == 0 {
+		size0 = 1
+	}
+	mem := ma.size * memAllocGranule
+	first := (size0 + pageSize - 1) / pageSize * pageSize
+	if first+pageSize > mem {
+		// There is no place for the guard page.
+		return ma.alloc(r, size0)
+	}
+	for pageEnd := first; pageEnd+pageSize <= mem; pageEnd += pageSize {
+		start0 := (pageEnd - size0) &^ (memAllocGuardAlign - 1)
+		start := start0 / memAllocGranule
+		if ma.empty(start, (pageEnd+pageSize)/memAllocGranule-start) {
+			ma.noteAlloc(start0, pageEnd+pageSize-start0)
+			return start0
+		}
+	}
+	ma.bankruptcy()
+	return ma.allocGuarded(r, size0, pageSize)
+}
+
+// guardPage returns address of the guard page of a guarded object at addr of the given size,
+// ok is false if the page is outside of the program memory.
+func (target *Target) guardPage(addr, size uint64) (page uint64, ok bool) {
+	page = (addr + size + target.PageSize - 1) &^ (target.PageSize - 1)
+	return page, page+target.PageSize <= target.NumPages*target.PageSize
+}
+
+// empty returns true if size granules starting from start are not allocated.
+func (ma *memAlloc) empty(start, size uint64) bool {
+	for i := uint64(0); i < size; i++ {
+		if ma.get(start + i) {
+			return false
+		}
+	}
+	return true
+}
+
 func (ma *memAlloc) bankruptcy() {
 	for i1 := uint64(0); i1 < ma.size/(memAllocL0Size*bitsPerUint64); i1++ {
 		if ma.mem[i1] == nil {
@@ -56,6 +56,39 @@ func TestMemAlloc(t *testing.T) {
 	}
 }
 
+func TestMemAllocGuarded(t *testing.T) {
+	t.Parallel()
+	const pageSize = 4 << 10
+	ma := newMemAlloc(16 << 20)
+	ma.noteAlloc(0, 64)
+	tests := []struct {
+		size uint64
+		addr uint64
+	}{
+		// Ends at the end of the first page, the second page is the guard page.
+		{100, pageSize - 104},
+		{pageSize, 2 * pageSize},
+		// Can't start in the guard page of the previous object.
+		{pageSize + 1, 6*pageSize - pageSize - 8},
+		{0, 8*pageSize - 8},
+	}
+	for i, test := range tests {
+		addr := ma.allocGuarded(nil, test.size, pageSize)
+		t.Logf("#%v: allocGuarded(%v) = %v", i, test.size, addr)
+		if addr != test.addr {
+			t.Fatalf("bad result %v, want %v", addr, test.addr)
+		}
+	}
+	// Guard pages are not given out to other objects.
+	addr := ma.alloc(nil, 64)
+	for addr < pageSize {
+		addr = ma.alloc(nil, 64)
+	}
+	if addr != 4*pageSize {
+		t.Fatalf("bad result %v, guard page is allocated", addr)
+	}
+}
+
 func TestVmaAlloc(t *testing.T) {
 	t.Parallel()
 	target, err := GetTarget("test", "64")
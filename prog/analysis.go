@@ -10,6 +10,7 @@ package prog
 
 import (
 	"fmt"
+	"sort"
 )
 
 type state struct {
@@ -17,7 +18,14 @@ type state struct {
 	ct        *ChoiceTable
 	files     map[string]bool
 	resources map[string][]*ResultArg
+	refs      map[*ResultArg]int // additional references to refcounted resources
+	signals   map[*ResultArg]int // pending signals of event resources
 	strings   map[string]bool
+	calls     map[*Syscall]bool          // calls present in the program (before the analyzed call)
+	submits   map[*Syscall]int           // pending submissions of asynchronous operations (see completions.go)
+	reserved  map[*Syscall]int           // submissions reserved by generated completion calls
+	scopes    map[string][]scope         // open scopes of scoped resources (innermost last)
+	cookies   map[string]map[uint64]bool // used cookies per completion field (see cookies.go)
 	ma        *memAlloc
 	va        *vmaAlloc
 }
@@ -32,6 +40,7 @@ func analyze(ct *ChoiceTable, p *Prog, c *Call) *state {
 		}
 		s.analyzeImpl(c1, resources)
 	}
+	s.reserveSubmissions(p, c)
 	return s
 }
 
@@ -41,7 +50,14 @@ func newState(target *Target, ct *ChoiceTable) *state {
 		ct:        ct,
 		files:     make(map[string]bool),
 		resources: make(map[string][]*ResultArg),
+		refs:      make(map[*ResultArg]int),
+		signals:   make(map[*ResultArg]int),
 		strings:   make(map[string]bool),
+		calls:     make(map[*Syscall]bool),
+		submits:   make(map[*Syscall]int),
+		reserved:  make(map[*Syscall]int),
+		scopes:    make(map[string][]scope),
+		cookies:   make(map[string]map[uint64]bool),
 		ma:        newMemAlloc(target.NumPages * target.PageSize),
 		va:        newVmaAlloc(target.NumPages),
 	}
@@ -53,6 +69,16 @@ func (s *state) analyze(c *Call) {
 }
 
 func (s *state) analyzeImpl(c *Call, resources bool) {
+	if resources {
+		s.calls[c.Meta] = true
+		s.applyCompletion(c.Meta)
+		if name := c.Meta.ScopeBegin; name != "" {
+			s.scopes[name] = append(s.scopes[name], nil)
+		}
+		if name := c.Meta.SetNamespace; name != "" {
+			s.switchNamespace(name)
+		}
+	}
 	ForeachArg(c, func(arg Arg, _ *ArgCtx) {
 		switch a := arg.(type) {
 		case *PointerArg:
@@ -60,17 +86,32 @@ func (s *state) analyzeImpl(c *Call, resources bool) {
 			case a.IsSpecial():
 			case a.VmaSize != 0:
 				s.va.noteAlloc(a.Address/s.target.PageSize, a.VmaSize/s.target.PageSize)
+			case a.Type().(*PtrType).Guard:
+				// Don't allocate anything in the guard page.
+				size := a.Res.Size()
+				if page, ok := s.target.guardPage(a.Address, size); ok {
+					size = page + s.target.PageSize - a.Address
+				}
+				s.ma.noteAlloc(a.Address, size)
 			default:
 				s.ma.noteAlloc(a.Address, a.Res.Size())
 			}
 		}
 		switch typ := arg.Type().(type) {
+		case *IntType:
+			if typ.Cookie != "" && typ.Dir() != DirOut {
+				s.noteCookie(typ, arg.(*ConstArg).Val)
+			}
 		case *ResourceType:
 			a := arg.(*ResultArg)
-			if resources && typ.Dir() != DirIn {
+			if resources && typ.Dir() != DirIn && s.noteScopedResource(a) {
 				s.resources[typ.Desc.Name] = append(s.resources[typ.Desc.Name], a)
 				// TODO: negative PIDs and add them as well (that's process groups).
 			}
+			if resources && a.Res != nil {
+				s.applyResourceEffect(typ.Effect, a.Res)
+				s.applyResourceEvent(typ.Event, a.Res)
+			}
 		case *BufferType:
 			a := arg.(*DataArg)
 			if typ.Dir() != DirOut && len(a.Data()) != 0 {
@@ -95,6 +136,68 @@ func (s *state) analyzeImpl(c *Call, resources bool) {
 			}
 		}
 	})
+	if resources && c.Meta.ScopeEnd != "" {
+		s.endScope(c.Meta.ScopeEnd)
+	}
+}
+
+// applyResourceEffect updates availability of resource res passed to a call with the given effect.
+// Resources that may be invalid stay available for subsequent calls (acquires may have succeeded).
+func (s *state) applyResourceEffect(effect ResourceEffect, res *ResultArg) {
+	if resourceTransition(effect, ResourceLive, s.refs, res) == ResourceInvalid {
+		s.invalidateResource(res)
+	}
+}
+
+// resourceTransition returns status of resource res with status st after res is passed to a call
+// with the given effect and updates the number of outstanding acquired references in refs.
+// Release of a resource with outstanding references drops one reference and makes the resource
+// ResourceMaybeInvalid, release of the last reference invalidates it.
+func resourceTransition(effect ResourceEffect, st ResourceStatus, refs map[*ResultArg]int,
+	res *ResultArg) ResourceStatus {
+	switch effect {
+	case ResourceUse:
+		return st
+	case ResourceAcquire:
+		refs[res]++
+		return st
+	case ResourceRelease:
+		if refs[res] != 0 {
+			refs[res]--
+			return ResourceMaybeInvalid
+		}
+	}
+	return ResourceInvalid
+}
+
+// applyResourceEvent updates pending signals of event resource res passed to a call with the given event.
+func (s *state) applyResourceEvent(event ResourceEvent, res *ResultArg) {
+	switch event {
+	case EventSignal:
+		s.signals[res]++
+	case EventWait:
+		if s.signals[res] != 0 {
+			s.signals[res]--
+		}
+	}
+}
+
+// invalidateResource removes res from the set of resources available for subsequent calls.
+func (s *state) invalidateResource(res *ResultArg) {
+	name := res.Type().(*ResourceType).Desc.Name
+	all := s.resources[name]
+	for i, res1 := range all {
+		if res1 != res {
+			continue
+		}
+		if len(all) == 1 {
+			// Resource lists in s.resources are expected to be non-empty.
+			delete(s.resources, name)
+		} else {
+			s.resources[name] = append(all[:i:i], all[i+1:]...)
+		}
+		break
+	}
 }
 
 type ArgCtx struct {
@@ -155,7 +258,7 @@ func foreachArgImpl(arg Arg, ctx ArgCtx, f func(Arg, *ArgCtx)) {
 	}
 }
 
-func RequiredFeatures(p *Prog) (bitmasks, csums bool) {
+func RequiredFeatures(p *Prog) (bitmasks, csums, childPids bool) {
 	for _, c := range p.Calls {
 		ForeachArg(c, func(arg Arg, _ *ArgCtx) {
 			if a, ok := arg.(*ConstArg); ok {
@@ -163,14 +266,183 @@ func RequiredFeatures(p *Prog) (bitmasks, csums bool) {
 					bitmasks = true
 				}
 			}
-			if _, ok := arg.Type().(*CsumType); ok {
+			switch typ := arg.Type().(type) {
+			case *CsumType:
 				csums = true
+			case *IntType:
+				if typ.Kind == IntChildPid {
+					childPids = true
+				}
 			}
 		})
 	}
 	return
 }
 
+// ResourceFootprint returns the net number of resources of each kind (ResourceDesc.Name)
+// that remain alive after the program: resources produced by the calls minus resources
+// invalidated by subsequent calls (consumes_and_invalidates and transforms arguments,
+// or releases of the last reference to refcounted resources).
+// Resources are accounted under their own kind, e.g. a sock is not counted as fd.
+// Kinds with zero net number are omitted.
+func (p *Prog) ResourceFootprint() map[string]int {
+	footprint := make(map[string]int)
+	invalidated := make(map[*ResultArg]bool)
+	refs := make(map[*ResultArg]int)
+	for _, c := range p.Calls {
+		ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+			typ, ok := arg.Type().(*ResourceType)
+			if !ok {
+				return
+			}
+			a := arg.(*ResultArg)
+			// Resources that may be invalid are still counted.
+			if a.Res != nil && !invalidated[a.Res] &&
+				resourceTransition(typ.Effect, ResourceLive, refs, a.Res) == ResourceInvalid {
+				invalidated[a.Res] = true
+				footprint[a.Res.Type().(*ResourceType).Desc.Name]--
+			}
+			if typ.Dir() != DirIn {
+				footprint[typ.Desc.Name]++
+			}
+		})
+	}
+	for name, n := range footprint {
+		if n == 0 {
+			delete(footprint, name)
+		}
+	}
+	return footprint
+}
+
+// ResourceStatus describes validity of a resource at some point of a program.
+type ResourceStatus int
+
+const (
+	// The resource is valid and can be used by subsequent calls.
+	ResourceLive ResourceStatus = iota
+	// The resource was released while references acquired by preceding calls were outstanding.
+	// Acquire calls can fail, so whether the resource is still valid is known only at runtime,
+	// the resource is neither known to be live nor known to be invalidated.
+	ResourceMaybeInvalid
+	// The resource was invalidated by a call (consumes_and_invalidates and transforms arguments,
+	// or release of the last reference to a refcounted resource).
+	ResourceInvalid
+)
+
+// ResourceSnapshot describes resource state of a program after a call.
+type ResourceSnapshot struct {
+	// Produced are resources created by the call (return value and output arguments).
+	Produced []*ResultArg
+	// Used are resources passed to the call (special values are not included).
+	Used []*ResultArg
+	// Invalidated are resources invalidated by the call.
+	Invalidated []*ResultArg
+	// Stale are resources passed to the call after they were invalidated by preceding calls.
+	Stale []*ResultArg
+	// Status contains status of all resources created by the call and preceding calls.
+	Status map[*ResultArg]ResourceStatus
+
+	order map[*ResultArg]int
+}
+
+// Live returns resources that are known to be valid after the call.
+func (s *ResourceSnapshot) Live() []*ResultArg {
+	var live []*ResultArg
+	for res, status := range s.Status {
+		if status == ResourceLive {
+			live = append(live, res)
+		}
+	}
+	sort.Slice(live, func(i, j int) bool {
+		return s.order[live[i]] < s.order[live[j]]
+	})
+	return live
+}
+
+// SimulateResources applies resource semantics of the calls (see ResourceEffect) to the program
+// without executing it and returns resource state after each call.
+// The effects are assumed to take place regardless of call results. Releases of refcounted
+// resources with outstanding acquired references make the resources ResourceMaybeInvalid
+// (acquires may fail), such resources are not considered stale when used by subsequent calls.
+func (p *Prog) SimulateResources() []*ResourceSnapshot {
+	status := make(map[*ResultArg]ResourceStatus)
+	order := make(map[*ResultArg]int)
+	refs := make(map[*ResultArg]int)
+	var snapshots []*ResourceSnapshot
+	for _, c := range p.Calls {
+		snapshot := &ResourceSnapshot{}
+		ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+			typ, ok := arg.Type().(*ResourceType)
+			if !ok {
+				return
+			}
+			a := arg.(*ResultArg)
+			if a.Res != nil {
+				snapshot.Used = append(snapshot.Used, a.Res)
+				if status[a.Res] == ResourceInvalid {
+					snapshot.Stale = append(snapshot.Stale, a.Res)
+				} else {
+					status[a.Res] = resourceTransition(typ.Effect, status[a.Res], refs, a.Res)
+					if status[a.Res] == ResourceInvalid {
+						snapshot.Invalidated = append(snapshot.Invalidated, a.Res)
+					}
+				}
+			}
+			if typ.Dir() != DirIn {
+				status[a] = ResourceLive
+				order[a] = len(order)
+				snapshot.Produced = append(snapshot.Produced, a)
+			}
+		})
+		snapshot.Status = make(map[*ResultArg]ResourceStatus, len(status))
+		for res, st := range status {
+			snapshot.Status[res] = st
+		}
+		snapshot.order = order
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots
+}
+
+// ExtractCall returns a new program that consists of call idx of p and the calls that
+// transitively produce resources used by it (according to SimulateResources), in the original
+// order. The call is the last call of the returned program. Calls that only affect
+// the call in other ways (e.g. through files or memory) are not included.
+func (p *Prog) ExtractCall(idx int) *Prog {
+	snapshots := p.SimulateResources()
+	producers := make(map[*ResultArg]int)
+	for i, snapshot := range snapshots[:idx] {
+		for _, res := range snapshot.Produced {
+			producers[res] = i
+		}
+	}
+	keep := make([]bool, len(p.Calls))
+	keep[idx] = true
+	for i := idx; i >= 0; i-- {
+		if !keep[i] {
+			continue
+		}
+		for _, res := range snapshots[i].Used {
+			if producer, ok := producers[res]; ok {
+				keep[producer] = true
+			}
+		}
+	}
+	p1 := p.Clone()
+	for i := len(p1.Calls) - 1; i >= 0; i-- {
+		if !keep[i] {
+			p1.removeCall(i)
+		}
+	}
+	p1.markNonblockingEvents()
+	p1.placeSharedRegions()
+	p1.enforceScopes()
+	p1.enforceNamespaces()
+	p1.debugValidate()
+	return p1
+}
+
 type CallFlags int
 
 const (
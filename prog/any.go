@@ -184,6 +184,23 @@ func (target *Target) isComplexPtr(arg *PointerArg) bool {
 			}
 		}
 	})
+	// Squashing would turn child pids into plain ints that executor does not substitute.
+	return res && !containsChildPid(arg)
+}
+
+func containsChildPid(arg *PointerArg) bool {
+	res := false
+	ForeachSubArg(arg.Res, func(a1 Arg, ctx *ArgCtx) {
+		switch typ := a1.Type().(type) {
+		case *IntType:
+			if typ.Kind == IntChildPid {
+				res = true
+				ctx.Stop = true
+			}
+		case *PtrType:
+			ctx.Stop = true
+		}
+	})
 	return res
 }
 
@@ -346,12 +363,18 @@ func (target *Target) squashGroup(arg *GroupArg, elems *[]Arg) {
 			bfOff := fld.Type().BitfieldOffset()
 			// Note: we can have a ResultArg here as well,
 			// but it is unsupported at the moment.
-			v, bf := target.squashedValue(fld.(*ConstArg))
-			if bf != FormatNative {
+			// Bitfields are combined in native byte order and the whole group
+			// is converted to big-endian afterwards, this is what executor does.
+			v, _ := fld.(*ConstArg).Value()
+			bf := fld.Type().Format()
+			if bf != FormatNative && bf != FormatBigEndian {
 				panic(fmt.Sprintf("bitfield has bad format %v", bf))
 			}
 			bitfield |= (v & ((1 << bfLen) - 1)) << bfOff
 			if !fld.Type().BitfieldMiddle() {
+				if bf == FormatBigEndian {
+					bitfield = swapInt(bitfield, int(fld.Size()))
+				}
 				elem := target.ensureDataElem(elems)
 				for i := uint64(0); i < fld.Size(); i++ {
 					elem.data = append(elem.Data(), byte(bitfield))
@@ -47,6 +47,10 @@ func TestSquash(t *testing.T) {
 			`foo$any0(&(0x7f0000000000)={0x11, 0x11223344, 0x2233, 0x1122334455667788, {0x1, 0x7, 0x1, 0x1, 0x1bc, 0x4}, [{0x0, @res32=0x0, 0x0, @i8=0x44, "aabb"}, {0x0, @res64=0x1, 0x0, @i32=0x11223344, "1122334455667788"}]})`,
 			`foo$any0(&(0x7f0000000000)=ANY=[@ANYBLOB="1100000044332211223300000000000088776655443322113d0079230000000000000000", @ANYRES32=0x0, @ANYBLOB="00000000000000000000000044aabb000000000000000000", @ANYRES64=0x1, @ANYBLOB="000000000000000044332211112233445566778800000000"])`,
 		},
+		{
+			`test$bf2(&(0x7f0000000000)={{0x2, 0x1f, 0x800101}, {0x2, 0x1f, 0x800101}, 0x1, 0x3, "aa"})`,
+			`test$bf2(&(0x7f0000000000)=ANY=[@ANYBLOB="fa010180800101fa11000003aa"])`,
+		},
 	}
 	for i, test := range tests {
 		t.Run(fmt.Sprint(i), func(t *testing.T) {
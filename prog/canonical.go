@@ -0,0 +1,47 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+// Canonical returns canonical text form of the program, so that programs that differ only
+// in aspects that don't affect execution have the same canonical form
+// (useful for corpus deduplication and diffing of programs).
+// Serialize already numbers resources in the order of definition regardless of the numbering
+// in the original text and represents flags by the integer value of the combined flags
+// (so different orderings of the same flags have the same form). On top of that Canonical
+// truncates values of integers in memory to the width of the field, since executor
+// stores only these bits anyway. The program itself is not changed.
+// Flags are the only option sets where order of the options does not affect execution.
+// Elements of arrays (e.g. netlink attributes or tagged records) are not sorted even if
+// the kernel may treat them as a set: elements are laid out in memory in program order
+// and the order is generally visible to the kernel (e.g. the last of duplicate netlink
+// attributes wins), so sorting them would change execution of the program.
+func (p *Prog) Canonical() []byte {
+	p1 := p.Clone()
+	for _, c := range p1.Calls {
+		ForeachArg(c, func(arg Arg, ctx *ArgCtx) {
+			a, ok := arg.(*ConstArg)
+			if !ok || ctx.Base == nil {
+				return
+			}
+			typ := a.Type()
+			switch typ.(type) {
+			case *IntType, *FlagsType:
+			default:
+				return
+			}
+			if format := typ.Format(); format != FormatNative && format != FormatBigEndian {
+				// Values are formatted as strings in full.
+				return
+			}
+			width := typ.BitfieldLength()
+			if width == 0 {
+				width = typ.Size() * 8
+			}
+			if width < 64 {
+				a.Val &= 1<<width - 1
+			}
+		})
+	}
+	return p1.Serialize()
+}
@@ -0,0 +1,140 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	tests := []struct {
+		prog0 string
+		prog1 string
+	}{
+		// Resource numbering.
+		{
+			"r5 = test$res0()\nr2 = test$res0()\ntest$res1(r2)\ntest$res1(r5)",
+			"r0 = test$res0()\nr1 = test$res0()\ntest$res1(r1)\ntest$res1(r0)",
+		},
+		// Values wider than the field in memory.
+		{
+			"test$align0(&(0x7f0000000000)={0x10001, 0x100000002, 0x103, 0x4, 0x5})",
+			"test$align0(&(0x7f0000000000)={0x1, 0x2, 0x3, 0x4, 0x5})",
+		},
+		{
+			"test$bf0(&(0x7f0000000000)={0xfff, 0x42, 0x42, 0x7f, 0x42, 0x42, 0x42, 0x42})",
+			"test$bf0(&(0x7f0000000000)={0x3ff, 0x42, 0x42, 0x3f, 0x42, 0x42, 0x42, 0x42})",
+		},
+		// Syscall arguments are passed as is.
+		{
+			"test$int(0x1ff, 0x2, 0x3, 0x4, 0x5)",
+			"test$int(0x1ff, 0x2, 0x3, 0x4, 0x5)",
+		},
+	}
+	for i, test := range tests {
+		p0, err := target.Deserialize([]byte(test.prog0), Strict)
+		if err != nil {
+			t.Fatalf("#%v: failed to deserialize %q: %v", i, test.prog0, err)
+		}
+		p1, err := target.Deserialize([]byte(test.prog1), Strict)
+		if err != nil {
+			t.Fatalf("#%v: failed to deserialize %q: %v", i, test.prog1, err)
+		}
+		c0, c1 := p0.Canonical(), p1.Canonical()
+		if !bytes.Equal(c0, c1) {
+			t.Errorf("#%v: canonical forms differ:\n%s\n%s", i, c0, c1)
+		}
+		canon, err := target.Deserialize(c0, Strict)
+		if err != nil {
+			t.Fatalf("#%v: failed to deserialize canonical form %q: %v", i, c0, err)
+		}
+		exec0, exec1, exec := canonicalExec(t, p0), canonicalExec(t, p1), canonicalExec(t, canon)
+		if !reflect.DeepEqual(exec0, exec1) || !reflect.DeepEqual(exec0, exec) {
+			t.Errorf("#%v: programs execute differently:\n%s\n%s\n%s", i, test.prog0, test.prog1, c0)
+		}
+	}
+}
+
+func TestCanonicalArrayOrder(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	// Order of array elements is visible to the kernel, so it's preserved.
+	const (
+		prog0 = "test$tagged(0x2, &(0x7f0000000000)=[@f0={0x1, 0x5}, @f1={0x2, \"01\"}])"
+		prog1 = "test$tagged(0x2, &(0x7f0000000000)=[@f1={0x2, \"01\"}, @f0={0x1, 0x5}])"
+	)
+	p0, err := target.Deserialize([]byte(prog0), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, err := target.Deserialize([]byte(prog1), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c0, c1 := p0.Canonical(), p1.Canonical(); bytes.Equal(c0, c1) {
+		t.Fatalf("canonical forms of different orders are equal:\n%s", c0)
+	}
+	if reflect.DeepEqual(canonicalExec(t, p0), canonicalExec(t, p1)) {
+		t.Fatalf("different orders execute the same way")
+	}
+}
+
+func TestCanonicalRandom(t *testing.T) {
+	testEachTargetRandom(t, func(t *testing.T, target *Target, rs rand.Source, iters int) {
+		for i := 0; i < iters; i++ {
+			p := target.Generate(rs, 10, nil)
+			data := p.Serialize()
+			canon := p.Canonical()
+			if !bytes.Equal(data, p.Serialize()) {
+				t.Fatalf("program changed by Canonical:\n%s\n%s", data, p.Serialize())
+			}
+			p1, err := target.Deserialize(canon, NonStrict)
+			if err != nil {
+				t.Fatalf("failed to deserialize canonical form: %v\n%s", err, canon)
+			}
+			if canon1 := p1.Canonical(); !bytes.Equal(canon, canon1) {
+				t.Fatalf("canonical form is not stable:\n%s\n%s", canon, canon1)
+			}
+			// The canonical form must execute exactly as the original program.
+			if exec, exec1 := canonicalExec(t, p), canonicalExec(t, p1); !reflect.DeepEqual(exec, exec1) {
+				t.Fatalf("canonical form executes differently:\n%s\n%s", data, canon)
+			}
+			p.Mutate(rs, 10, nil, nil)
+		}
+	})
+}
+
+// canonicalExec returns decoded exec encoding of p with values of copyin integers truncated
+// the same way executor does when storing them to memory.
+func canonicalExec(t *testing.T, p *Prog) ExecProg {
+	buf := make([]byte, ExecBufferSize)
+	n, err := p.SerializeForExec(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec, err := p.Target.DeserializeExec(buf[:n])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range exec.Calls {
+		for i, copyin := range c.Copyin {
+			arg, ok := copyin.Arg.(ExecArgConst)
+			if !ok || arg.Format != FormatNative && arg.Format != FormatBigEndian {
+				continue
+			}
+			width := arg.BitfieldLength
+			if width == 0 {
+				width = arg.Size * 8
+			}
+			if width < 64 {
+				arg.Value &= 1<<width - 1
+			}
+			c.Copyin[i].Arg = arg
+		}
+	}
+	return exec
+}
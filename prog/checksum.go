@@ -33,7 +33,8 @@ func calcChecksumsCall(c *Call) (map[Arg]CsumInfo, map[Arg]struct{}) {
 	ForeachArg(c, func(arg Arg, _ *ArgCtx) {
 		if typ, ok := arg.Type().(*CsumType); ok {
 			switch typ.Kind {
-			case CsumInet:
+			case CsumInet, CsumCrc32, CsumXor, CsumFnv1a, CsumAdd:
+				// All of these are calculated over a single region.
 				inetCsumFields = append(inetCsumFields, arg)
 			case CsumPseudo:
 				pseudoCsumFields = append(pseudoCsumFields, arg)
@@ -60,13 +61,13 @@ func calcChecksumsCall(c *Call) (map[Arg]CsumInfo, map[Arg]struct{}) {
 	csumMap := make(map[Arg]CsumInfo)
 	csumUses := make(map[Arg]struct{})
 
-	// Calculate generic inet checksums.
+	// Calculate generic inet, crc32 and xor checksums and hashes.
 	for _, arg := range inetCsumFields {
 		typ, _ := arg.Type().(*CsumType)
 		csummedArg := findCsummedArg(arg, typ, parentsMap)
 		csumUses[csummedArg] = struct{}{}
 		chunk := CsumChunk{CsumChunkArg, csummedArg, 0, 0}
-		csumMap[arg] = CsumInfo{Kind: CsumInet, Chunks: []CsumChunk{chunk}}
+		csumMap[arg] = CsumInfo{Kind: typ.Kind, Chunks: []CsumChunk{chunk}}
 	}
 
 	// No need to continue if there are no pseudo csum fields.
@@ -120,6 +121,14 @@ func findCsummedArg(arg Arg, typ *CsumType, parentsMap map[Arg]Arg) Arg {
 		}
 		panic(fmt.Sprintf("parent for %v is not in parents map", typ.Name()))
 	} else {
+		if parent, ok := parentsMap[arg]; ok {
+			// Explicit region: a sibling field of the csum field.
+			for _, field := range parent.(*GroupArg).Inner {
+				if field != arg && typ.Buf == field.Type().FieldName() {
+					return field
+				}
+			}
+		}
 		for parent := parentsMap[arg]; parent != nil; parent = parentsMap[parent] {
 			if typ.Buf == parent.Type().Name() {
 				return parent
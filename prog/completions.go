@@ -0,0 +1,100 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+// Completion calls (see Syscall.Completes) deliver results of asynchronous operations submitted
+// by other calls (e.g. io_uring submission and completion queues), including resources created
+// by the operations. Each submission can be completed only once, and operations can complete
+// in any order: a completion call consumes a pending submission of any of the calls it completes,
+// not necessarily the oldest one. Programs are kept consistent so that each completion call
+// is preceded by a pending submission: generation precedes completion calls without a pending
+// submission with a submitting call (if there are any enabled), and minimization and mutation
+// don't remove submissions that are consumed by the remaining completion calls.
+
+// applyCompletion updates pending submissions with call meta: a completion call consumes
+// a pending submission, and any call is a pending submission for subsequent completion calls.
+func (target *Target) applyCompletion(meta *Syscall, pending map[*Syscall]int) {
+	if sub := target.pendingSubmission(meta, pending); sub != nil {
+		pending[sub]--
+	}
+	pending[meta]++
+}
+
+// pendingSubmission returns a call completed by meta that has a pending submission,
+// or nil if there is no such call.
+func (target *Target) pendingSubmission(meta *Syscall, pending map[*Syscall]int) *Syscall {
+	for _, name := range meta.Completes {
+		if sub := target.SyscallMap[name]; pending[sub] > 0 {
+			return sub
+		}
+	}
+	return nil
+}
+
+// reserveSubmissions removes from pending submissions of s the submissions that are consumed
+// by completion calls in p starting from call c, so that completion calls inserted before c
+// don't leave the subsequent completion calls without pending submissions.
+func (s *state) reserveSubmissions(p *Prog, c *Call) {
+	if c == nil {
+		return
+	}
+	pending := make(map[*Syscall]int)
+	for sub, n := range s.submits {
+		pending[sub] = n
+	}
+	after := false
+	for _, c1 := range p.Calls {
+		after = after || c1 == c
+		if !after {
+			continue
+		}
+		if sub := s.target.pendingSubmission(c1.Meta, pending); sub != nil && pending[sub]-1 < s.submits[sub] {
+			s.submits[sub] = pending[sub] - 1
+		}
+		s.target.applyCompletion(c1.Meta, pending)
+	}
+}
+
+// applyCompletion updates pending submissions of s with call meta and releases a submission
+// reserved by meta when it was generated.
+func (s *state) applyCompletion(meta *Syscall) {
+	for _, name := range meta.Completes {
+		if sub := s.target.SyscallMap[name]; s.reserved[sub] > 0 {
+			s.reserved[sub]--
+			break
+		}
+	}
+	s.target.applyCompletion(meta, s.submits)
+}
+
+// generateSubmission appends to calls a random enabled call completed by meta (preceded by calls
+// it requires) if meta is a completion call and there is no pending submission for it.
+// Generated calls are not analyzed into s until they are inserted into the program, so the
+// consumed submission is reserved in s to prevent other completion calls generated
+// in the meantime from consuming it as well.
+func (r *randGen) generateSubmission(s *state, meta *Syscall, calls []*Call) []*Call {
+	if len(meta.Completes) == 0 {
+		return calls
+	}
+	for _, name := range meta.Completes {
+		if sub := r.target.SyscallMap[name]; s.submits[sub] > s.reserved[sub] {
+			s.reserved[sub]++
+			return calls
+		}
+	}
+	var subs []*Syscall
+	for _, name := range meta.Completes {
+		sub := r.target.SyscallMap[name]
+		if s.ct == nil || s.ct.enabled[sub] {
+			subs = append(subs, sub)
+		}
+	}
+	if len(subs) == 0 {
+		return calls
+	}
+	sub := subs[r.Intn(len(subs))]
+	s.reserved[sub]++
+	calls = r.generateRequiredCalls(s, sub, calls)
+	return r.generateCallWithArgs(s, sub, calls)
+}
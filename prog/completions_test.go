@@ -0,0 +1,58 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"testing"
+)
+
+func TestCompletions(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	enabled := make(map[*Syscall]bool)
+	for _, name := range []string{"test$cq_setup", "test$cq_submit0", "test$cq_submit1",
+		"test$cq_reap", "test$cq_use", "test$requires0"} {
+		enabled[target.SyscallMap[name]] = true
+	}
+	ct := target.BuildChoiceTable(nil, enabled)
+	reap := target.SyscallMap["test$cq_reap"]
+	submits := make(map[string]int)
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 10, ct)
+		if i%2 == 0 {
+			p.Mutate(rs, 10, ct, nil)
+		}
+		pending := make(map[*Syscall]int)
+		for _, c := range p.Calls {
+			if c.Meta == reap {
+				sub := target.pendingSubmission(reap, pending)
+				if sub == nil {
+					t.Fatalf("completion without a pending submission:\n%s", p.Serialize())
+				}
+				submits[sub.Name]++
+			}
+			target.applyCompletion(c.Meta, pending)
+		}
+	}
+	if submits["test$cq_submit0"] == 0 || submits["test$cq_submit1"] == 0 {
+		t.Fatalf("not all submissions are completed: %v", submits)
+	}
+}
+
+func TestCompletionsDisabled(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	reap := target.SyscallMap["test$cq_reap"]
+	enabled := map[*Syscall]bool{
+		target.SyscallMap["test$cq_setup"]: true,
+		reap:                               true,
+	}
+	_, disabled := target.TransitivelyEnabledCalls(enabled)
+	if disabled[reap] == "" {
+		t.Fatalf("completion call without submitting calls is enabled")
+	}
+	enabled[target.SyscallMap["test$cq_submit0"]] = true
+	_, disabled = target.TransitivelyEnabledCalls(enabled)
+	if disabled[reap] != "" {
+		t.Fatalf("completion call is disabled: %v", disabled[reap])
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+// Cookies are caller values passed in requests of asynchronous operations that the kernel echoes
+// in completions of the operations (see IntType.Cookie). Generation and mutation keep cookies
+// of the same completion field distinct in the whole program, so that a completion can be
+// matched to the request it belongs to even if several requests are in flight.
+
+// Cookies returns cookies of requests in p: for each completion struct field (in the STRUCT.FIELD
+// form) it maps cookie values to indices of calls that pass them.
+func (p *Prog) Cookies() map[string]map[uint64]int {
+	res := make(map[string]map[uint64]int)
+	for i, c := range p.Calls {
+		ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+			typ, ok := arg.Type().(*IntType)
+			if !ok || typ.Cookie == "" || typ.Dir() == DirOut {
+				return
+			}
+			if res[typ.Cookie] == nil {
+				res[typ.Cookie] = make(map[uint64]int)
+			}
+			res[typ.Cookie][arg.(*ConstArg).Val] = i
+		})
+	}
+	return res
+}
+
+// noteCookie marks value val of cookie field typ as used in s.
+func (s *state) noteCookie(typ *IntType, val uint64) {
+	if s.cookies[typ.Cookie] == nil {
+		s.cookies[typ.Cookie] = make(map[uint64]bool)
+	}
+	s.cookies[typ.Cookie][val] = true
+}
+
+// cookie returns a random value of cookie field typ that is not used by other requests
+// in the program (unless all values of the field range are used) and marks it as used.
+func (r *randGen) cookie(s *state, typ *IntType) uint64 {
+	lo, hi := uniqueValueRange(typ)
+	v := r.Uint64()
+	if hi-lo != ^uint64(0) {
+		v = lo + v%(hi-lo+1)
+	}
+	for n := uint64(0); s.cookies[typ.Cookie][v] && n != hi-lo; n++ {
+		if v == hi {
+			v = lo
+		} else {
+			v++
+		}
+	}
+	s.noteCookie(typ, v)
+	return v
+}
@@ -0,0 +1,39 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"testing"
+)
+
+func TestCookies(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{
+		target.SyscallMap["test$cq_setup"]:   true,
+		target.SyscallMap["test$cq_submit2"]: true,
+		target.SyscallMap["test$cq_reap2"]:   true,
+	})
+	// Each request must have a distinct cookie, so that all of them are returned by Cookies.
+	check := func(p *Prog) {
+		requests := 0
+		for _, c := range p.Calls {
+			ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+				if typ, ok := arg.Type().(*IntType); ok && typ.Cookie != "" {
+					requests++
+				}
+			})
+		}
+		if got := len(p.Cookies()["cq_cookie_entry.user_data"]); got != requests {
+			t.Fatalf("got %v distinct cookies for %v requests:\n%s", got, requests, p.Serialize())
+		}
+	}
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 10, ct)
+		check(p)
+		for j := 0; j < 10; j++ {
+			p.Mutate(rs, 20, ct, nil)
+			check(p)
+		}
+	}
+}
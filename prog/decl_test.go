@@ -4,6 +4,7 @@
 package prog
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -14,7 +15,8 @@ func TestResourceCtors(t *testing.T) {
 	}
 	testEachTarget(t, func(t *testing.T, target *Target) {
 		for _, res := range target.Resources {
-			if len(target.calcResourceCtors(res.Kind, true)) == 0 {
+			// Resources with value ranges don't need ctors.
+			if !res.HasRange && len(target.calcResourceCtors(res.Kind, true)) == 0 {
 				t.Errorf("resource %v can't be created", res.Name)
 			}
 		}
@@ -109,3 +111,240 @@ func TestClockGettime(t *testing.T) {
 			len(calls), len(trans), len(disabled))
 	}
 }
+
+func TestReachableCalls(t *testing.T) {
+	t.Parallel()
+	target, err := GetTarget("linux", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := target.resourceMap["sock_alg"]
+	contains := func(calls []*Syscall, name string) bool {
+		for _, c := range calls {
+			if c.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+	direct := target.ReachableCalls(res, 1)
+	for _, name := range []string{"bind$alg", "accept$alg", "setsockopt$ALG_SET_KEY", "close"} {
+		if !contains(direct, name) {
+			t.Errorf("direct consumers of %v don't contain %v", res.Name, name)
+		}
+	}
+	for _, name := range []string{"socket$alg", "sendmsg$alg", "read$alg"} {
+		if contains(direct, name) {
+			t.Errorf("direct consumers of %v contain %v", res.Name, name)
+		}
+	}
+	all := target.ReachableCalls(res, 0)
+	for _, name := range []string{"sendmsg$alg", "read$alg"} {
+		if !contains(all, name) {
+			t.Errorf("calls reachable from %v don't contain %v", res.Name, name)
+		}
+	}
+	for _, c := range direct {
+		if !contains(all, c.Name) {
+			t.Errorf("call %v is a direct consumer, but is not reachable", c.Name)
+		}
+	}
+}
+
+func TestCompatibleResources(t *testing.T) {
+	t.Parallel()
+	target, err := GetTarget("test", "64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !target.isCompatibleResource("syz_compat0", "syz_compat1") ||
+		!target.isCompatibleResource("syz_compat1", "syz_compat0") {
+		t.Errorf("syz_compat0 and syz_compat1 are not compatible")
+	}
+	if target.isCompatibleResource("syz_compat0", "syz_res") {
+		t.Errorf("syz_compat0 is compatible with syz_res")
+	}
+	for _, pair := range [][2]string{{"syz_compat0", "test$compat1"}, {"syz_compat1", "test$compat0"}} {
+		found := false
+		for _, c := range target.resourceCtors[pair[0]] {
+			found = found || c.Name == pair[1]
+		}
+		if !found {
+			t.Errorf("%v is not a ctor of %v", pair[1], pair[0])
+		}
+	}
+	// Only test$compat0 is enabled, but it's enough to create both resources.
+	enabled := map[*Syscall]bool{
+		target.SyscallMap["test$compat0"]: true,
+		target.SyscallMap["test$compat2"]: true,
+	}
+	_, disabled := target.TransitivelyEnabledCalls(enabled)
+	if len(disabled) != 0 {
+		t.Errorf("disabled calls: %v", disabled)
+	}
+}
+
+func TestTransitivelyEnabledRequiredCalls(t *testing.T) {
+	target, err := GetTarget("test", "64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// test$requires2 requires test$requires1, which is not enabled.
+	enabled := map[*Syscall]bool{
+		target.SyscallMap["test$requires0"]: true,
+		target.SyscallMap["test$requires2"]: true,
+	}
+	supported, disabled := target.TransitivelyEnabledCalls(enabled)
+	if len(supported) != 1 || !supported[target.SyscallMap["test$requires0"]] {
+		t.Errorf("supported calls: %v", supported)
+	}
+	if len(disabled) != 1 || disabled[target.SyscallMap["test$requires2"]] == "" {
+		t.Errorf("disabled calls: %v", disabled)
+	}
+	enabled[target.SyscallMap["test$requires1"]] = true
+	if _, disabled := target.TransitivelyEnabledCalls(enabled); len(disabled) != 0 {
+		t.Errorf("disabled calls: %v", disabled)
+	}
+}
+
+func TestForeachStructResource(t *testing.T) {
+	target, err := GetTarget("test", "64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ptr := target.SyscallMap["test$res3"].Args[0].(*PtrType)
+	var got []string
+	ForeachStructResource(ptr.Type.(*StructType).StructDesc, func(path []string, typ *ResourceType) {
+		got = append(got, fmt.Sprintf("%v:%v:%v", strings.Join(path, "."), typ.Desc.Name, typ.Dir()))
+	})
+	want := []string{
+		"f0:fd:inout",
+		"f2.r0:syz_res:inout",
+		"f2.u0.fd:fd:inout",
+		"f3.[]:syz_res:inout",
+		"f4.*.r0:syz_res:out",
+		"f4.*.u0.fd:fd:out",
+		// f5 points to the in version of the struct, its own f5 is not entered again.
+		"f5.*.f0:fd:in",
+		"f5.*.f2.r0:syz_res:in",
+		"f5.*.f2.u0.fd:fd:in",
+		"f5.*.f3.[]:syz_res:in",
+		"f5.*.f4.*.r0:syz_res:out",
+		"f5.*.f4.*.u0.fd:fd:out",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("wrong resource fields:\ngot:\n%v\nwant:\n%v",
+			strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCallStructs(t *testing.T) {
+	target, err := GetTarget("test", "64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	structs := target.CallStructs()
+	var got []string
+	for _, key := range structs[target.SyscallMap["test$res3"]] {
+		got = append(got, fmt.Sprintf("%v:%v", key.Name, key.Dir))
+	}
+	want := []string{
+		"syz_res_fields:in",
+		"syz_res_fields:inout",
+		"syz_res_fields_inner:in",
+		"syz_res_fields_inner:out",
+		"syz_res_fields_inner:inout",
+		"syz_res_fields_union:in",
+		"syz_res_fields_union:out",
+		"syz_res_fields_union:inout",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("wrong structs:\ngot:\n%v\nwant:\n%v",
+			strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if keys := structs[target.SyscallMap["test$res0"]]; len(keys) != 0 {
+		t.Fatalf("test$res0 has structs: %+v", keys)
+	}
+}
+
+func TestStructCalls(t *testing.T) {
+	target, err := GetTarget("test", "64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := target.StructCalls()
+	tests := map[StructKey][]string{
+		// Referenced via recursive structs.
+		{"syz_recur_0", DirIn}:    {"test$recur0", "test$recur1", "test$recur2"},
+		{"syz_recur_0", DirInOut}: {"test$recur0"},
+		{"syz_recur_2", DirIn}:    {"test$recur1", "test$recur2"},
+		{"syz_recur_2_0", DirIn}:  {"test$recur1", "test$recur2"},
+		// Referenced via a struct field and via a pointer.
+		{"syz_res_fields_inner", DirOut}: {"test$res3"},
+		{"no_such_struct", DirIn}:        nil,
+	}
+	for key, want := range tests {
+		var got []string
+		for _, c := range calls[key] {
+			got = append(got, c.Name)
+		}
+		if strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("wrong calls for %v:%v:\ngot:  %v\nwant: %v", key.Name, key.Dir, got, want)
+		}
+	}
+	// Every call must be listed for all structs reachable from it.
+	for c, keys := range target.CallStructs() {
+		for _, key := range keys {
+			found := false
+			for _, c1 := range calls[key] {
+				found = found || c1 == c
+			}
+			if !found {
+				t.Fatalf("%v is missing in calls of %v:%v", c.Name, key.Name, key.Dir)
+			}
+		}
+	}
+}
+
+func TestStructBitfields(t *testing.T) {
+	target, err := GetTarget("test", "64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	format := func(groups []BitfieldGroup) string {
+		var res []string
+		for _, g := range groups {
+			var members []string
+			for _, m := range g.Members {
+				members = append(members, fmt.Sprintf("%v:%v:%v:%v", m.Name, m.Offset, m.Length, m.Last))
+			}
+			res = append(res, fmt.Sprintf("%v{%v}", g.Size, strings.Join(members, " ")))
+		}
+		return strings.Join(res, "\n")
+	}
+	bitfields := target.StructBitfields()
+	tests := map[string][]string{
+		"syz_bf_struct0": {
+			"2{f0:0:10:true}",
+			"2{f2:0:5:false f3:5:6:true}",
+			"4{f4:0:15:true}",
+			// Different endianness starts a new group.
+			"2{f5:0:11:true}",
+			"2{f6:0:11:true}",
+		},
+		"syz_bf_struct2": {
+			"8{f0:0:4:false f1:4:8:false f2:12:12:false f3:24:20:false f4:44:16:true}",
+		},
+		"syz_bf_struct4": {
+			"4{mode:0:3:false count:3:5:false region:8:24:true}",
+		},
+	}
+	for name, want := range tests {
+		if got := format(bitfields[name]); got != strings.Join(want, "\n") {
+			t.Errorf("wrong bitfields of %v:\ngot:\n%v\nwant:\n%v", name, got, strings.Join(want, "\n"))
+		}
+	}
+	if groups, ok := bitfields["syz_bf_struct1"]; ok {
+		t.Errorf("syz_bf_struct1 has bitfields: %v", format(groups))
+	}
+}
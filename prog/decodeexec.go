@@ -18,6 +18,7 @@ type ExecCall struct {
 	Args    []ExecArg
 	Copyin  []ExecCopyin
 	Copyout []ExecCopyout
+	Guards  []uint64 // addresses of guard pages
 }
 
 type ExecCopyin struct {
@@ -26,9 +27,10 @@ type ExecCopyin struct {
 }
 
 type ExecCopyout struct {
-	Index uint64
-	Addr  uint64
-	Size  uint64
+	Index  uint64
+	Addr   uint64
+	Size   uint64
+	Format BinaryFormat
 }
 
 type ExecArg interface{} // one of ExecArg*
@@ -51,6 +53,11 @@ type ExecArgResult struct {
 	Default uint64
 }
 
+type ExecArgChildPid struct {
+	Size   uint64
+	Format BinaryFormat
+}
+
 type ExecArgData struct {
 	Data     []byte
 	Readable bool
@@ -104,11 +111,16 @@ func (dec *execDecoder) parse() {
 				Addr: dec.read(),
 				Arg:  dec.readArg(),
 			})
+		case execInstrGuard:
+			dec.commitCall()
+			dec.call.Guards = append(dec.call.Guards, dec.read())
 		case execInstrCopyout:
+			index, addr, meta := dec.read(), dec.read(), dec.read()
 			dec.call.Copyout = append(dec.call.Copyout, ExecCopyout{
-				Index: dec.read(),
-				Addr:  dec.read(),
-				Size:  dec.read(),
+				Index:  index,
+				Addr:   addr,
+				Size:   meta & 0xff,
+				Format: BinaryFormat((meta >> 8) & 0xff),
 			})
 		default:
 			dec.commitCall()
@@ -120,7 +132,7 @@ func (dec *execDecoder) parse() {
 			dec.call.Index = dec.read()
 			for i := dec.read(); i > 0; i-- {
 				switch arg := dec.readArg(); arg.(type) {
-				case ExecArgConst, ExecArgResult:
+				case ExecArgConst, ExecArgResult, ExecArgChildPid:
 					dec.call.Args = append(dec.call.Args, arg)
 				default:
 					dec.setErr(fmt.Errorf("bad call arg %+v", arg))
@@ -161,6 +173,12 @@ func (dec *execDecoder) readArg() ExecArg {
 		}
 		dec.vars[arg.Index] = arg.Default
 		return arg
+	case execArgChildPid:
+		meta := dec.read()
+		return ExecArgChildPid{
+			Size:   meta & 0xff,
+			Format: BinaryFormat((meta >> 8) & 0xff),
+		}
 	case execArgData:
 		flags := dec.read()
 		size := flags & ^execArgDataReadable
@@ -172,7 +190,7 @@ func (dec *execDecoder) readArg() ExecArg {
 	case execArgCsum:
 		size := dec.read()
 		switch kind := dec.read(); kind {
-		case ExecArgCsumInet:
+		case ExecArgCsumInet, ExecArgCsumCrc32, ExecArgCsumXor, ExecArgCsumFnv1a, ExecArgCsumAdd:
 			chunks := make([]ExecCsumChunk, dec.read())
 			for i := range chunks {
 				chunks[i] = ExecCsumChunk{
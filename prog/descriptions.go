@@ -0,0 +1,656 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// This file does serialization of compiled descriptions into a compact binary form
+// that can be loaded without re-parsing and re-compiling the textual descriptions.
+// The format is: magic, format version, then resources, syscalls and structs.
+// All integers are uvarint-encoded, strings and slices are prefixed with length.
+// Every type is prefixed with a tag denoting its kind. Struct and union types
+// refer to their descriptions by StructKey, the descriptions themselves are written
+// out once in the structs section (in the same detached form as generated Go code).
+
+package prog
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+const (
+	descMagic = "SYZDESC"
+	// DescriptionsVersion must be bumped on any change to the encoding or to the set
+	// of serialized fields, so that stale blobs are rejected rather than misinterpreted.
+	DescriptionsVersion = 43
+)
+
+const (
+	descTypeNil = uint64(iota)
+	descTypeResource
+	descTypeConst
+	descTypeInt
+	descTypeFlags
+	descTypeLen
+	descTypeProc
+	descTypeCsum
+	descTypeVma
+	descTypeBuffer
+	descTypeArray
+	descTypePtr
+	descTypeStruct
+	descTypeUnion
+)
+
+// SerializeDescriptions serializes compiled descriptions into compact binary form.
+// StructType/UnionType descriptions must be detached (StructDesc is not used),
+// descriptions of all referenced structs must be present in structs.
+func SerializeDescriptions(resources []*ResourceDesc, syscalls []*Syscall, structs []*KeyedStruct) []byte {
+	e := &descEncoder{buf: []byte(descMagic)}
+	e.uint(DescriptionsVersion)
+	e.uint(uint64(len(resources)))
+	for _, res := range resources {
+		e.string(res.Name)
+		e.typ(res.Type)
+		e.strings(res.Kind)
+		e.uints(res.Values)
+		e.strings(res.Compatible)
+		e.bool(res.HasRange)
+		e.uint(res.RangeBegin)
+		e.uint(res.RangeEnd)
+		e.bool(res.Refcounted)
+		e.bool(res.Event)
+		e.string(res.Scope)
+		e.string(res.Namespace)
+	}
+	e.uint(uint64(len(syscalls)))
+	for _, c := range syscalls {
+		e.uint(c.NR)
+		e.string(c.Name)
+		e.string(c.CallName)
+		e.uint(uint64(c.MissingArgs))
+		e.types(c.Args)
+		e.typ(c.Ret)
+		e.uint(uint64(c.Retries))
+		e.string(c.Group)
+		e.bool(c.Compat)
+		e.bool(c.NoCover)
+		e.strings(c.Requires)
+		e.strings(c.Completes)
+		e.string(c.ScopeBegin)
+		e.string(c.ScopeEnd)
+		e.string(c.SetNamespace)
+		e.uint(uint64(c.OmittableArgs))
+		e.uint(uint64(c.NonblockArg))
+		e.uint(c.NonblockFlag)
+	}
+	e.uint(uint64(len(structs)))
+	for _, s := range structs {
+		e.key(s.Key)
+		e.common(&s.Desc.TypeCommon)
+		e.types(s.Desc.Fields)
+		e.uint(s.Desc.AlignAttr)
+		e.uints(s.Desc.OptionWeights)
+		e.string(s.Desc.VersionField)
+		e.uints(s.Desc.Versions)
+		e.strings(s.Desc.UniqueFields)
+		e.strings(s.Desc.RequestedFields)
+		e.uints(s.Desc.RequestedBy)
+		e.strings(s.Desc.Specifiers)
+		e.bool(s.Desc.Overlay)
+	}
+	return e.buf
+}
+
+// DeserializeDescriptions loads descriptions serialized with SerializeDescriptions.
+// The result has the same form as generated Go code: struct/union and resource types
+// are linked to the returned structs and resources by name during target initialization.
+func DeserializeDescriptions(data []byte) (resources []*ResourceDesc, syscalls []*Syscall,
+	structs []*KeyedStruct, err error) {
+	if !strings.HasPrefix(string(data), descMagic) {
+		return nil, nil, nil, fmt.Errorf("bad descriptions magic")
+	}
+	d := &descDecoder{
+		data:      data[len(descMagic):],
+		resources: make(map[string]*ResourceDesc),
+	}
+	if ver := d.uint(); d.err == nil && ver != DescriptionsVersion {
+		return nil, nil, nil, fmt.Errorf("descriptions version %v, want %v", ver, DescriptionsVersion)
+	}
+	for i, n := 0, d.len(); i < n; i++ {
+		res := &ResourceDesc{
+			Name:       d.string(),
+			Type:       d.typ(),
+			Kind:       d.strings(),
+			Values:     d.uints(),
+			Compatible: d.strings(),
+			HasRange:   d.bool(),
+			RangeBegin: d.uint(),
+			RangeEnd:   d.uint(),
+			Refcounted: d.bool(),
+			Event:      d.bool(),
+			Scope:      d.string(),
+			Namespace:  d.string(),
+		}
+		d.resources[res.Name] = res
+		resources = append(resources, res)
+	}
+	for i, n := 0, d.len(); i < n; i++ {
+		syscalls = append(syscalls, &Syscall{
+			NR:            d.uint(),
+			Name:          d.string(),
+			CallName:      d.string(),
+			MissingArgs:   int(d.uint()),
+			Args:          d.types(),
+			Ret:           d.typ(),
+			Retries:       int(d.uint()),
+			Group:         d.string(),
+			Compat:        d.bool(),
+			NoCover:       d.bool(),
+			Requires:      d.strings(),
+			Completes:     d.strings(),
+			ScopeBegin:    d.string(),
+			ScopeEnd:      d.string(),
+			SetNamespace:  d.string(),
+			OmittableArgs: int(d.uint()),
+			NonblockArg:   int(d.uint()),
+			NonblockFlag:  d.uint(),
+		})
+	}
+	for i, n := 0, d.len(); i < n; i++ {
+		structs = append(structs, &KeyedStruct{
+			Key: d.key(),
+			Desc: &StructDesc{
+				TypeCommon:      d.common(),
+				Fields:          d.types(),
+				AlignAttr:       d.uint(),
+				OptionWeights:   d.uints(),
+				VersionField:    d.string(),
+				Versions:        d.uints(),
+				UniqueFields:    d.strings(),
+				RequestedFields: d.strings(),
+				RequestedBy:     d.uints(),
+				Specifiers:      d.strings(),
+				Overlay:         d.bool(),
+			},
+		})
+	}
+	if d.err == nil && len(d.data) != 0 {
+		d.err = fmt.Errorf("%v trailing bytes", len(d.data))
+	}
+	if d.err != nil {
+		return nil, nil, nil, fmt.Errorf("bad descriptions: %v", d.err)
+	}
+	return resources, syscalls, structs, nil
+}
+
+type descEncoder struct {
+	buf []byte
+}
+
+func (e *descEncoder) uint(v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	e.buf = append(e.buf, tmp[:n]...)
+}
+
+func (e *descEncoder) bool(v bool) {
+	if v {
+		e.uint(1)
+	} else {
+		e.uint(0)
+	}
+}
+
+func (e *descEncoder) string(v string) {
+	e.uint(uint64(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+func (e *descEncoder) strings(v []string) {
+	e.uint(uint64(len(v)))
+	for _, s := range v {
+		e.string(s)
+	}
+}
+
+func (e *descEncoder) uints(v []uint64) {
+	e.uint(uint64(len(v)))
+	for _, x := range v {
+		e.uint(x)
+	}
+}
+
+func (e *descEncoder) key(key StructKey) {
+	e.string(key.Name)
+	e.uint(uint64(key.Dir))
+}
+
+func (e *descEncoder) common(t *TypeCommon) {
+	e.string(t.TypeName)
+	e.string(t.FldName)
+	e.uint(t.TypeSize)
+	e.uint(uint64(t.ArgDir))
+	e.bool(t.IsOptional)
+	e.bool(t.IsVarlen)
+	e.uint(t.MutateWeight)
+}
+
+func (e *descEncoder) intCommon(t *IntTypeCommon) {
+	e.common(&t.TypeCommon)
+	e.uint(uint64(t.ArgFormat))
+	e.uint(t.BitfieldOff)
+	e.uint(t.BitfieldLen)
+	e.bool(t.BitfieldMdl)
+	e.string(t.RequestMaskField)
+}
+
+func (e *descEncoder) lenExpr(expr *LenExpr) {
+	e.bool(expr != nil)
+	if expr == nil {
+		return
+	}
+	e.uint(uint64(expr.Op))
+	e.string(expr.Buf)
+	e.uint(expr.Val)
+	e.uint(uint64(len(expr.Args)))
+	for _, arg := range expr.Args {
+		e.lenExpr(arg)
+	}
+}
+
+func (e *descEncoder) types(types []Type) {
+	e.uint(uint64(len(types)))
+	for _, t := range types {
+		e.typ(t)
+	}
+}
+
+func (e *descEncoder) typ(t0 Type) {
+	switch t := t0.(type) {
+	case nil:
+		e.uint(descTypeNil)
+	case *ResourceType:
+		e.uint(descTypeResource)
+		e.common(&t.TypeCommon)
+		e.uint(uint64(t.ArgFormat))
+		e.uint(uint64(t.Effect))
+		e.uint(t.ValidMask)
+		e.bool(t.Derives)
+		e.uint(t.DeriveOffset)
+		e.uint(uint64(t.Event))
+		e.uint(t.InvalidPercent)
+	case *ConstType:
+		e.uint(descTypeConst)
+		e.intCommon(&t.IntTypeCommon)
+		e.uint(t.Val)
+		e.bool(t.IsPad)
+		e.bool(t.IsFooter)
+		e.bool(t.Promotable)
+	case *IntType:
+		e.uint(descTypeInt)
+		e.intCommon(&t.IntTypeCommon)
+		e.uint(uint64(t.Kind))
+		e.uint(t.RangeBegin)
+		e.uint(t.RangeEnd)
+		e.string(t.Ring)
+		e.string(t.RelPtr)
+		e.bool(t.RelSelf)
+		e.string(t.Flags)
+		e.uints(t.FlagIndices)
+		e.uint(uint64(len(t.Buckets)))
+		for _, b := range t.Buckets {
+			e.uint(b.Begin)
+			e.uint(b.End)
+			e.uint(b.Weight)
+		}
+		e.bool(t.ByteOrderMark)
+		e.uint(t.BigEndianMark)
+		e.bool(t.ParityOdd)
+		e.uint(t.ParityBegin)
+		e.uint(t.ParityEnd)
+		e.string(t.Cookie)
+		e.uint(uint64(t.Relation))
+		e.string(t.RelationField)
+	case *FlagsType:
+		e.uint(descTypeFlags)
+		e.intCommon(&t.IntTypeCommon)
+		e.uints(t.Vals)
+		e.bool(t.BitMask)
+		e.string(t.SubkindField)
+		e.uint(uint64(len(t.Subkinds)))
+		for _, s := range t.Subkinds {
+			e.string(s.Kind)
+			e.uints(s.Vals)
+		}
+		e.bool(t.Exhaustive)
+	case *LenType:
+		e.uint(descTypeLen)
+		e.intCommon(&t.IntTypeCommon)
+		e.uint(t.BitSize)
+		e.string(t.Buf)
+		e.lenExpr(t.Expr)
+		e.uint(t.Dim)
+		e.bool(t.Inclusive)
+	case *ProcType:
+		e.uint(descTypeProc)
+		e.intCommon(&t.IntTypeCommon)
+		e.uint(t.ValuesStart)
+		e.uint(t.ValuesPerProc)
+	case *CsumType:
+		e.uint(descTypeCsum)
+		e.intCommon(&t.IntTypeCommon)
+		e.uint(uint64(t.Kind))
+		e.string(t.Buf)
+		e.uint(t.Protocol)
+	case *VmaType:
+		e.uint(descTypeVma)
+		e.common(&t.TypeCommon)
+		e.uint(t.RangeBegin)
+		e.uint(t.RangeEnd)
+		e.string(t.Shared)
+	case *BufferType:
+		e.uint(descTypeBuffer)
+		e.common(&t.TypeCommon)
+		e.uint(uint64(t.Kind))
+		e.uint(t.RangeBegin)
+		e.uint(t.RangeEnd)
+		e.uint(uint64(t.Text))
+		e.string(t.SubKind)
+		e.strings(t.Values)
+		e.bool(t.NoZ)
+		e.typ(t.Elem)
+		e.string(t.FormatArgs)
+	case *ArrayType:
+		e.uint(descTypeArray)
+		e.common(&t.TypeCommon)
+		e.typ(t.Type)
+		e.uint(uint64(t.Kind))
+		e.uint(t.RangeBegin)
+		e.uint(t.RangeEnd)
+		e.string(t.CountField)
+		e.bool(t.Sparse)
+	case *PtrType:
+		e.uint(descTypePtr)
+		e.common(&t.TypeCommon)
+		e.typ(t.Type)
+		e.string(t.OverlapField)
+		e.uint(t.OverlapOffset)
+		e.bool(t.Guard)
+		e.uint(uint64(t.Depth))
+		e.string(t.Shared)
+	case *StructType:
+		e.uint(descTypeStruct)
+		e.key(t.Key)
+		e.string(t.FldName)
+	case *UnionType:
+		e.uint(descTypeUnion)
+		e.key(t.Key)
+		e.string(t.FldName)
+	default:
+		panic(fmt.Sprintf("unknown type %#v", t0))
+	}
+}
+
+type descDecoder struct {
+	data      []byte
+	err       error
+	resources map[string]*ResourceDesc // to check that resource types refer to known resources
+}
+
+func (d *descDecoder) uint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.err = fmt.Errorf("truncated data")
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+// len reads a length prefix and checks that it does not exceed the remaining data
+// (every element takes at least 1 byte), which protects from huge allocations.
+func (d *descDecoder) len() int {
+	n := d.uint()
+	if d.err == nil && n > uint64(len(d.data)) {
+		d.err = fmt.Errorf("bad length %v, %v bytes left", n, len(d.data))
+	}
+	if d.err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+func (d *descDecoder) bool() bool {
+	return d.uint() != 0
+}
+
+func (d *descDecoder) string() string {
+	n := d.len()
+	v := string(d.data[:n])
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *descDecoder) strings() []string {
+	var v []string
+	for i, n := 0, d.len(); i < n; i++ {
+		v = append(v, d.string())
+	}
+	return v
+}
+
+func (d *descDecoder) uints() []uint64 {
+	var v []uint64
+	for i, n := 0, d.len(); i < n; i++ {
+		v = append(v, d.uint())
+	}
+	return v
+}
+
+func (d *descDecoder) key() StructKey {
+	return StructKey{
+		Name: d.string(),
+		Dir:  Dir(d.uint()),
+	}
+}
+
+func (d *descDecoder) common() TypeCommon {
+	return TypeCommon{
+		TypeName:     d.string(),
+		FldName:      d.string(),
+		TypeSize:     d.uint(),
+		ArgDir:       Dir(d.uint()),
+		IsOptional:   d.bool(),
+		IsVarlen:     d.bool(),
+		MutateWeight: d.uint(),
+	}
+}
+
+func (d *descDecoder) intCommon() IntTypeCommon {
+	return IntTypeCommon{
+		TypeCommon:       d.common(),
+		ArgFormat:        BinaryFormat(d.uint()),
+		BitfieldOff:      d.uint(),
+		BitfieldLen:      d.uint(),
+		BitfieldMdl:      d.bool(),
+		RequestMaskField: d.string(),
+	}
+}
+
+func (d *descDecoder) lenExpr() *LenExpr {
+	if !d.bool() {
+		return nil
+	}
+	expr := &LenExpr{
+		Op:  LenExprOp(d.uint()),
+		Buf: d.string(),
+		Val: d.uint(),
+	}
+	for i, n := 0, d.len(); i < n; i++ {
+		expr.Args = append(expr.Args, d.lenExpr())
+	}
+	return expr
+}
+
+func (d *descDecoder) types() []Type {
+	var v []Type
+	for i, n := 0, d.len(); i < n; i++ {
+		v = append(v, d.typ())
+	}
+	return v
+}
+
+func (d *descDecoder) typ() Type {
+	tag := d.uint()
+	if d.err != nil {
+		return nil
+	}
+	switch tag {
+	case descTypeNil:
+		return nil
+	case descTypeResource:
+		t := &ResourceType{
+			TypeCommon:     d.common(),
+			ArgFormat:      BinaryFormat(d.uint()),
+			Effect:         ResourceEffect(d.uint()),
+			ValidMask:      d.uint(),
+			Derives:        d.bool(),
+			DeriveOffset:   d.uint(),
+			Event:          ResourceEvent(d.uint()),
+			InvalidPercent: d.uint(),
+		}
+		if d.resources[t.TypeName] == nil && d.err == nil {
+			d.err = fmt.Errorf("unknown resource %v", t.TypeName)
+		}
+		return t
+	case descTypeConst:
+		return &ConstType{
+			IntTypeCommon: d.intCommon(),
+			Val:           d.uint(),
+			IsPad:         d.bool(),
+			IsFooter:      d.bool(),
+			Promotable:    d.bool(),
+		}
+	case descTypeInt:
+		t := &IntType{
+			IntTypeCommon: d.intCommon(),
+			Kind:          IntKind(d.uint()),
+			RangeBegin:    d.uint(),
+			RangeEnd:      d.uint(),
+			Ring:          d.string(),
+			RelPtr:        d.string(),
+			RelSelf:       d.bool(),
+			Flags:         d.string(),
+			FlagIndices:   d.uints(),
+		}
+		for i, n := 0, d.len(); i < n; i++ {
+			t.Buckets = append(t.Buckets, IntBucket{
+				Begin:  d.uint(),
+				End:    d.uint(),
+				Weight: d.uint(),
+			})
+		}
+		t.ByteOrderMark = d.bool()
+		t.BigEndianMark = d.uint()
+		t.ParityOdd = d.bool()
+		t.ParityBegin = d.uint()
+		t.ParityEnd = d.uint()
+		t.Cookie = d.string()
+		t.Relation = IntRelation(d.uint())
+		t.RelationField = d.string()
+		return t
+	case descTypeFlags:
+		t := &FlagsType{
+			IntTypeCommon: d.intCommon(),
+			Vals:          d.uints(),
+			BitMask:       d.bool(),
+			SubkindField:  d.string(),
+		}
+		for i, n := 0, d.len(); i < n; i++ {
+			t.Subkinds = append(t.Subkinds, SubkindVals{
+				Kind: d.string(),
+				Vals: d.uints(),
+			})
+		}
+		t.Exhaustive = d.bool()
+		return t
+	case descTypeLen:
+		return &LenType{
+			IntTypeCommon: d.intCommon(),
+			BitSize:       d.uint(),
+			Buf:           d.string(),
+			Expr:          d.lenExpr(),
+			Dim:           d.uint(),
+			Inclusive:     d.bool(),
+		}
+	case descTypeProc:
+		return &ProcType{
+			IntTypeCommon: d.intCommon(),
+			ValuesStart:   d.uint(),
+			ValuesPerProc: d.uint(),
+		}
+	case descTypeCsum:
+		return &CsumType{
+			IntTypeCommon: d.intCommon(),
+			Kind:          CsumKind(d.uint()),
+			Buf:           d.string(),
+			Protocol:      d.uint(),
+		}
+	case descTypeVma:
+		return &VmaType{
+			TypeCommon: d.common(),
+			RangeBegin: d.uint(),
+			RangeEnd:   d.uint(),
+			Shared:     d.string(),
+		}
+	case descTypeBuffer:
+		return &BufferType{
+			TypeCommon: d.common(),
+			Kind:       BufferKind(d.uint()),
+			RangeBegin: d.uint(),
+			RangeEnd:   d.uint(),
+			Text:       TextKind(d.uint()),
+			SubKind:    d.string(),
+			Values:     d.strings(),
+			NoZ:        d.bool(),
+			Elem:       d.typ(),
+			FormatArgs: d.string(),
+		}
+	case descTypeArray:
+		return &ArrayType{
+			TypeCommon: d.common(),
+			Type:       d.typ(),
+			Kind:       ArrayKind(d.uint()),
+			RangeBegin: d.uint(),
+			RangeEnd:   d.uint(),
+			CountField: d.string(),
+			Sparse:     d.bool(),
+		}
+	case descTypePtr:
+		return &PtrType{
+			TypeCommon:    d.common(),
+			Type:          d.typ(),
+			OverlapField:  d.string(),
+			OverlapOffset: d.uint(),
+			Guard:         d.bool(),
+			Depth:         int(d.uint()),
+			Shared:        d.string(),
+		}
+	case descTypeStruct:
+		return &StructType{
+			Key:     d.key(),
+			FldName: d.string(),
+		}
+	case descTypeUnion:
+		return &UnionType{
+			Key:     d.key(),
+			FldName: d.string(),
+		}
+	default:
+		d.err = fmt.Errorf("unknown type tag %v", tag)
+		return nil
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Dictionary is a set of user-supplied magic values (file magics, ioctl numbers, etc)
+// that are used during program generation in addition to the values derived from types.
+// Integer entries are used for integer arguments that can hold them,
+// blob entries are used for buffer arguments, and blob entries of size 1/2/4/8 are also
+// used for integer arguments of the same size (in the byte order of the argument).
+// Set it for generation with ChoiceTable.SetDictionary.
+type Dictionary struct {
+	ints  []uint64
+	blobs [][]byte
+}
+
+// A dictionary value is used for one out of dictionaryProb suitable arguments (if any value matches).
+const dictionaryProb = 10
+
+// ParseDictionary parses dictionary in the AFL/libFuzzer format:
+//
+//	# comment
+//	kw1="\x7fELF"
+//	"text"
+//
+// Entries are quoted strings with \\, \" and \xNN escapes, optionally preceded
+// by a name and =. Additionally, an entry can be an integer (e.g. ioctl=0xc0045002).
+func ParseDictionary(data []byte) (*Dictionary, error) {
+	dict := new(Dictionary)
+	for i, line := range bytes.Split(data, []byte{'\n'}) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		if err := dict.parseEntry(line); err != nil {
+			return nil, fmt.Errorf("dictionary line #%v: %v", i+1, err)
+		}
+	}
+	return dict, nil
+}
+
+func (dict *Dictionary) parseEntry(line []byte) error {
+	if line[0] != '"' {
+		if eq := bytes.IndexByte(line, '='); eq != -1 {
+			line = bytes.TrimSpace(line[eq+1:])
+		}
+	}
+	if len(line) == 0 {
+		return fmt.Errorf("empty entry")
+	}
+	if line[0] != '"' {
+		v, err := strconv.ParseUint(string(line), 0, 64)
+		if err != nil {
+			iv, err1 := strconv.ParseInt(string(line), 0, 64)
+			if err1 != nil {
+				return fmt.Errorf("bad integer entry %q: %v", line, err)
+			}
+			v = uint64(iv)
+		}
+		dict.AddInt(v)
+		return nil
+	}
+	if len(line) < 2 || line[len(line)-1] != '"' {
+		return fmt.Errorf("unterminated string %q", line)
+	}
+	var blob []byte
+	for i := 1; i < len(line)-1; i++ {
+		c := line[i]
+		if c != '\\' {
+			blob = append(blob, c)
+			continue
+		}
+		i++
+		if i == len(line)-1 {
+			return fmt.Errorf("bad escape sequence in %q", line)
+		}
+		switch line[i] {
+		case '\\', '"':
+			blob = append(blob, line[i])
+		case 'x':
+			if i+2 > len(line)-2 {
+				return fmt.Errorf("bad escape sequence in %q", line)
+			}
+			v, err := strconv.ParseUint(string(line[i+1:i+3]), 16, 8)
+			if err != nil {
+				return fmt.Errorf("bad escape sequence in %q", line)
+			}
+			blob = append(blob, byte(v))
+			i += 2
+		default:
+			return fmt.Errorf("bad escape sequence in %q", line)
+		}
+	}
+	if len(blob) == 0 {
+		return fmt.Errorf("empty entry")
+	}
+	dict.AddBlob(blob)
+	return nil
+}
+
+// AddInt adds an integer value to the dictionary.
+func (dict *Dictionary) AddInt(v uint64) {
+	dict.ints = append(dict.ints, v)
+}
+
+// AddBlob adds a binary value to the dictionary.
+func (dict *Dictionary) AddBlob(data []byte) {
+	dict.blobs = append(dict.blobs, append([]byte{}, data...))
+}
+
+// Merge adds all entries of other to the dictionary.
+func (dict *Dictionary) Merge(other *Dictionary) {
+	dict.ints = append(dict.ints, other.ints...)
+	dict.blobs = append(dict.blobs, other.blobs...)
+}
+
+// Len returns number of entries in the dictionary.
+func (dict *Dictionary) Len() int {
+	return len(dict.ints) + len(dict.blobs)
+}
+
+// Serialize returns the dictionary in the format accepted by ParseDictionary.
+func (dict *Dictionary) Serialize() []byte {
+	buf := new(bytes.Buffer)
+	for _, v := range dict.ints {
+		fmt.Fprintf(buf, "0x%x\n", v)
+	}
+	for _, blob := range dict.blobs {
+		buf.WriteByte('"')
+		for _, c := range blob {
+			switch {
+			case c == '\\' || c == '"':
+				buf.WriteByte('\\')
+				buf.WriteByte(c)
+			case c >= 0x20 && c < 0x7f:
+				buf.WriteByte(c)
+			default:
+				fmt.Fprintf(buf, "\\x%02x", c)
+			}
+		}
+		buf.WriteString("\"\n")
+	}
+	return buf.Bytes()
+}
+
+// ConstDictionary returns a dictionary of magic values used in the descriptions:
+// values of const types and flags. Each value is added as a blob of the size of the type
+// that uses it in the byte order of the type, so that it's used for integer arguments
+// of the same size only. Values of fmt types are added as 8-byte blobs.
+// Duplicate blobs are removed, blobs are sorted by size and then by contents.
+func (target *Target) ConstDictionary() *Dictionary {
+	seen := make(map[string]bool)
+	add := func(t Type, v uint64) {
+		var order binary.ByteOrder = binary.LittleEndian
+		size := t.Size()
+		switch t.Format() {
+		case FormatNative:
+		case FormatBigEndian:
+			order = binary.BigEndian
+		default:
+			size = 8
+		}
+		blob := make([]byte, size)
+		switch size {
+		case 1:
+			blob[0] = byte(v)
+		case 2:
+			order.PutUint16(blob, uint16(v))
+		case 4:
+			order.PutUint32(blob, uint32(v))
+		case 8:
+			order.PutUint64(blob, v)
+		default:
+			return
+		}
+		seen[string(blob)] = true
+	}
+	for _, meta := range target.Syscalls {
+		ForeachType(meta, func(t0 Type) {
+			switch t := t0.(type) {
+			case *ConstType:
+				if !t.IsPad {
+					add(t, t.Val)
+				}
+			case *FlagsType:
+				for _, v := range t.Vals {
+					add(t, v)
+				}
+			}
+		})
+	}
+	dict := new(Dictionary)
+	for blob := range seen {
+		dict.blobs = append(dict.blobs, []byte(blob))
+	}
+	sort.Slice(dict.blobs, func(i, j int) bool {
+		if len(dict.blobs[i]) != len(dict.blobs[j]) {
+			return len(dict.blobs[i]) < len(dict.blobs[j])
+		}
+		return bytes.Compare(dict.blobs[i], dict.blobs[j]) < 0
+	})
+	return dict
+}
+
+// intValue returns a random dictionary value that fits into integer type t,
+// or false if there are no such values.
+func (dict *Dictionary) intValue(r *randGen, t *IntType) (uint64, bool) {
+	bits := t.Size() * 8
+	if t.BitfieldLength() != 0 {
+		bits = t.BitfieldLength()
+	}
+	if t.Format() != FormatNative && t.Format() != FormatBigEndian {
+		// Size of fmt values is the size of the string representation.
+		bits = 64
+	}
+	var vals []uint64
+	add := func(v uint64) {
+		if t.Kind == IntRange && (int64(v) < int64(t.RangeBegin) || int64(v) > int64(t.RangeEnd)) {
+			return
+		}
+		vals = append(vals, v)
+	}
+	for _, v := range dict.ints {
+		if bits == 64 || v>>bits == 0 {
+			add(v)
+		}
+	}
+	for _, blob := range dict.blobs {
+		if uint64(len(blob))*8 != bits {
+			continue
+		}
+		// Values are stored in the target byte order during execution, so decode the blob
+		// in the same order to get the same bytes in memory.
+		var order binary.ByteOrder = binary.LittleEndian
+		if t.Format() == FormatBigEndian {
+			order = binary.BigEndian
+		}
+		switch len(blob) {
+		case 1:
+			add(uint64(blob[0]))
+		case 2:
+			add(uint64(order.Uint16(blob)))
+		case 4:
+			add(uint64(order.Uint32(blob)))
+		case 8:
+			add(order.Uint64(blob))
+		}
+	}
+	if len(vals) == 0 {
+		return 0, false
+	}
+	return vals[r.Intn(len(vals))], true
+}
+
+// useDict decides if the current argument should be generated from the choice table dictionary.
+func (s *state) useDict(r *randGen) bool {
+	return s.ct != nil && s.ct.dict != nil && r.oneOf(dictionaryProb)
+}
+
+// blobValue returns a random dictionary blob with size in [minLen, maxLen],
+// or nil if there are no such blobs.
+func (dict *Dictionary) blobValue(r *randGen, minLen, maxLen uint64) []byte {
+	var blobs [][]byte
+	for _, blob := range dict.blobs {
+		if uint64(len(blob)) >= minLen && uint64(len(blob)) <= maxLen {
+			blobs = append(blobs, blob)
+		}
+	}
+	if len(blobs) == 0 {
+		return nil
+	}
+	return append([]byte{}, blobs[r.Intn(len(blobs))]...)
+}
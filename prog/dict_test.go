@@ -0,0 +1,125 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestParseDictionary(t *testing.T) {
+	dict, err := ParseDictionary([]byte(`
+# comment
+kw1="\x7fELF"
+"foo\\bar\"baz"
+  kw2@1="text"
+ioctl=0xc0045002
+-1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantInts := []uint64{0xc0045002, ^uint64(0)}
+	wantBlobs := [][]byte{[]byte("\x7fELF"), []byte(`foo\bar"baz`), []byte("text")}
+	if !reflect.DeepEqual(dict.ints, wantInts) {
+		t.Errorf("got ints %x, want %x", dict.ints, wantInts)
+	}
+	if !reflect.DeepEqual(dict.blobs, wantBlobs) {
+		t.Errorf("got blobs %q, want %q", dict.blobs, wantBlobs)
+	}
+	for _, bad := range []string{
+		`"foo`,
+		`""`,
+		`name=`,
+		`"\x1"`,
+		`"\xzz"`,
+		`"\n"`,
+		`"foo\"`,
+		`0xzz`,
+	} {
+		if _, err := ParseDictionary([]byte(bad)); err == nil {
+			t.Errorf("parsed bad dictionary %q", bad)
+		}
+	}
+}
+
+func TestDictionary(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	ct := target.BuildChoiceTable(nil, nil)
+	dict := new(Dictionary)
+	dict.AddInt(0x1bad1dea)
+	dict.AddBlob([]byte("\xde\xad"))
+	dict.AddBlob([]byte("syzdictmagic"))
+	ct.SetDictionary(dict)
+	var ints, blobs, shorts int
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 10, ct)
+		for _, c := range p.Calls {
+			ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+				switch a := arg.(type) {
+				case *ConstArg:
+					typ, ok := a.Type().(*IntType)
+					if !ok {
+						return
+					}
+					if a.Val == 0x1bad1dea {
+						ints++
+						if typ.Size() < 4 {
+							t.Fatalf("dictionary value is used for %v", typ.Name())
+						}
+					}
+					if a.Val == 0xadde && typ.Size() == 2 && typ.Format() == FormatNative {
+						shorts++
+					}
+				case *DataArg:
+					if a.Type().Dir() != DirOut && bytes.Contains(a.Data(), []byte("syzdictmagic")) {
+						blobs++
+					}
+				}
+			})
+		}
+	}
+	if ints == 0 || blobs == 0 || shorts == 0 {
+		t.Fatalf("dictionary values are not used: ints=%v blobs=%v shorts=%v", ints, blobs, shorts)
+	}
+}
+
+func TestConstDictionary(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	dict := target.ConstDictionary()
+	if len(dict.ints) != 0 {
+		t.Fatalf("got int entries %x", dict.ints)
+	}
+	seen := make(map[string]bool)
+	for i, blob := range dict.blobs {
+		if seen[string(blob)] {
+			t.Fatalf("duplicate blob %x", blob)
+		}
+		seen[string(blob)] = true
+		if i != 0 {
+			prev := dict.blobs[i-1]
+			if len(prev) > len(blob) || len(prev) == len(blob) && bytes.Compare(prev, blob) > 0 {
+				t.Fatalf("blobs are not sorted: %x before %x", prev, blob)
+			}
+		}
+	}
+	for _, want := range []string{
+		"\x42\x00",                         // const[0x42, int16:5]
+		"\x42\x00\x00\x00",                 // const[0x42, int32]
+		"\x00\x00\x00\x42",                 // const[0x42, int32be]
+		"\x42\x00\x00\x00\x00\x00\x00\x00", // const[0x42] syscall argument
+	} {
+		if !seen[want] {
+			t.Errorf("no blob %x in the dictionary", want)
+		}
+	}
+	dict1, err := ParseDictionary(dict.Serialize())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dict1.blobs, dict.blobs) {
+		t.Fatalf("serialized dictionary is parsed differently")
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Distance returns structural distance between programs p and p1 in the range [0, 1],
+// 0 means that the programs have the same structure (see StructureHash),
+// 1 means that the programs don't have any similar calls.
+// The distance is edit distance over the call sequences normalized by the length of
+// the longer program: insertion and removal of a call cost 1, substitution of a call
+// costs 1 minus similarity of argument shapes of the calls (for calls of different syscalls
+// the cost is at least 0.5). Argument shapes consist of types of all (transitively) reachable
+// arguments, chosen union options, nil/non-nil pointers and resource references.
+// Concrete values of integers, data and addresses are ignored.
+// The result is deterministic and symmetric. The complexity is O(N*M) call comparisons,
+// each comparison is linear in the number of arguments of the calls.
+func (p *Prog) Distance(p1 *Prog) float64 {
+	n, m := len(p.Calls), len(p1.Calls)
+	if n == 0 && m == 0 {
+		return 0
+	}
+	shapes, shapes1 := callShapes(p), callShapes(p1)
+	// Standard dynamic programming over two rows of the edit distance matrix.
+	prev := make([]float64, m+1)
+	cur := make([]float64, m+1)
+	for j := range prev {
+		prev[j] = float64(j)
+	}
+	for i := 1; i <= n; i++ {
+		cur[0] = float64(i)
+		for j := 1; j <= m; j++ {
+			d := prev[j-1] + substitutionCost(p.Calls[i-1], p1.Calls[j-1], shapes[i-1], shapes1[j-1])
+			if d1 := prev[j] + 1; d1 < d {
+				d = d1
+			}
+			if d1 := cur[j-1] + 1; d1 < d {
+				d = d1
+			}
+			cur[j] = d
+		}
+		prev, cur = cur, prev
+	}
+	max := n
+	if m > max {
+		max = m
+	}
+	return prev[m] / float64(max)
+}
+
+func substitutionCost(c, c1 *Call, shape, shape1 []string) float64 {
+	sim := shapeSimilarity(shape, shape1)
+	if c.Meta != c1.Meta {
+		return 1 - sim/2
+	}
+	return 1 - sim
+}
+
+// shapeSimilarity returns similarity of 2 sorted shapes in the range [0, 1]:
+// number of common elements (with multiplicity) divided by the size of the union.
+func shapeSimilarity(shape, shape1 []string) float64 {
+	if len(shape) == 0 && len(shape1) == 0 {
+		return 1
+	}
+	common := 0
+	for i, j := 0, 0; i < len(shape) && j < len(shape1); {
+		switch {
+		case shape[i] == shape1[j]:
+			common++
+			i++
+			j++
+		case shape[i] < shape1[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return float64(common) / float64(len(shape)+len(shape1)-common)
+}
+
+// callShapes returns sorted argument shapes of all calls of p.
+func callShapes(p *Prog) [][]string {
+	shapes := make([][]string, len(p.Calls))
+	for i, c := range p.Calls {
+		var shape []string
+		ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+			shape = append(shape, argShape(arg))
+			if a, ok := arg.(*UnionArg); ok {
+				// Union type and chosen option are separate elements, so that calls
+				// with different options are still similar.
+				shape = append(shape, "=:"+a.Option.Type().FieldName())
+			}
+		})
+		sort.Strings(shape)
+		shapes[i] = shape
+	}
+	return shapes
+}
+
+func argShape(arg Arg) string {
+	name := arg.Type().Name()
+	switch a := arg.(type) {
+	case *ConstArg:
+		return "c:" + name
+	case *PointerArg:
+		switch {
+		case a.IsSpecial():
+			return "n:" + name
+		case a.Res == nil:
+			return "v:" + name
+		default:
+			return "&:" + name
+		}
+	case *DataArg:
+		return "d:" + name
+	case *GroupArg:
+		return "{:" + name
+	case *UnionArg:
+		return "@:" + name
+	case *ResultArg:
+		if a.Res != nil {
+			// References a resource produced by a previous call.
+			return "r<:" + name
+		}
+		return "r:" + name
+	default:
+		panic(fmt.Sprintf("unknown arg %#v", arg))
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	tests := []struct {
+		prog0 string
+		prog1 string
+		min   float64
+		max   float64
+	}{
+		// Values are ignored.
+		{
+			"test$int(0x1, 0x2, 0x3, 0x4, 0x5)",
+			"test$int(0x0, 0xff, 0x0, 0x0, 0xffffffffffffffff)",
+			0, 0,
+		},
+		{
+			"",
+			"",
+			0, 0,
+		},
+		{
+			"test$res0()",
+			"",
+			1, 1,
+		},
+		// One call out of 4 is removed.
+		{
+			"r0 = test$res0()\ntest$res1(r0)\ntest$int(0x1, 0x2, 0x3, 0x4, 0x5)\ntest$res1(r0)",
+			"r0 = test$res0()\ntest$res1(r0)\ntest$res1(r0)",
+			0.25, 0.25,
+		},
+		// Different union options are closer than different calls.
+		{
+			"test$syz_union4(@f1=0x1)",
+			"test$syz_union4(@f2=0x1)",
+			0.1, 0.9,
+		},
+		{
+			"test$res1(0xffff)",
+			"test$int(0x1, 0x2, 0x3, 0x4, 0x5)",
+			0.5, 1,
+		},
+		// Resource topology.
+		{
+			"r0 = test$res0()\ntest$res1(r0)",
+			"r0 = test$res0()\ntest$res1(0xffff)",
+			0.1, 0.5,
+		},
+	}
+	for i, test := range tests {
+		p0, err := target.Deserialize([]byte(test.prog0), Strict)
+		if err != nil {
+			t.Fatalf("#%v: failed to deserialize %q: %v", i, test.prog0, err)
+		}
+		p1, err := target.Deserialize([]byte(test.prog1), Strict)
+		if err != nil {
+			t.Fatalf("#%v: failed to deserialize %q: %v", i, test.prog1, err)
+		}
+		d0, d1 := p0.Distance(p1), p1.Distance(p0)
+		if d0 != d1 {
+			t.Fatalf("#%v: distance is not symmetric: %v vs %v", i, d0, d1)
+		}
+		if d0 < test.min || d0 > test.max {
+			t.Fatalf("#%v: distance %v, want [%v, %v]", i, d0, test.min, test.max)
+		}
+	}
+}
+
+func TestDistanceRandom(t *testing.T) {
+	target, rs, iters := initTest(t)
+	ct := target.BuildChoiceTable(nil, nil)
+	for i := 0; i < iters; i++ {
+		p0 := target.Generate(rs, 10, ct)
+		p1 := p0.Clone()
+		p1.Mutate(rs, 10, ct, nil)
+		if d := p0.Distance(p0.Clone()); d != 0 {
+			t.Fatalf("distance to a clone is %v:\n%s", d, p0.Serialize())
+		}
+		d0, d1 := p0.Distance(p1), p1.Distance(p0)
+		if d0 != d1 || d0 < 0 || d0 > 1 || math.IsNaN(d0) {
+			t.Fatalf("bad distance %v/%v:\n%s\n\n%s", d0, d1, p0.Serialize(), p1.Serialize())
+		}
+	}
+}
@@ -24,6 +24,49 @@ func (p *Prog) String() string {
 	return buf.String()
 }
 
+// ProgVersion is the current version of the program text format.
+// Programs are stored for a long time (e.g. in corpus databases), so Deserialize
+// reads all versions that were ever produced and translates them to the current in-memory form.
+// SerializeVersioned prefixes the program with a version header line:
+//
+//	# syz-prog-version: 1
+//
+// The header is a comment for deserializers that don't know about versions,
+// so they can still read programs of newer versions as long as the rest of the syntax is the same.
+// Serialize omits the header (its output is used in logs and reproducers),
+// programs that are stored persistently (corpus databases) are written with the header.
+//
+// Migration points (changes of the format in each version):
+//
+//	0: programs without the header, stored before the format was versioned.
+//	1: the version header, the rest of the syntax is the same as in version 0.
+//
+// Syntax that is not produced anymore, but is still accepted to read old programs
+// (e.g. address offsets &(0x7f0000000000+0x10)) is accepted in all versions.
+// When the format changes, bump ProgVersion, describe the change above, keep parsing
+// of the previous versions depending on parser.version and add a fixture to testdata/versions.
+const ProgVersion = 1
+
+const progVersionHeader = "syz-prog-version:"
+
+// SerializeVersioned is the same as Serialize, but prefixes the program with the version header.
+// It should be used for programs that are stored persistently.
+func (p *Prog) SerializeVersioned() []byte {
+	return AddVersionHeader(p.Serialize())
+}
+
+// AddVersionHeader prefixes data produced by Serialize with the version header of the current format.
+// Data that already has the header is returned as is.
+func AddVersionHeader(data []byte) []byte {
+	if bytes.HasPrefix(data, []byte("# "+progVersionHeader)) {
+		return data
+	}
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "# %v %v\n", progVersionHeader, ProgVersion)
+	buf.Write(data)
+	return buf.Bytes()
+}
+
 func (p *Prog) Serialize() []byte {
 	p.debugValidate()
 	ctx := &serializer{
@@ -216,6 +259,15 @@ func (p *parser) parseProg() (*Prog, error) {
 		Target: p.target,
 	}
 	for p.Scan() {
+		if p.l == 1 && !p.EOF() && p.Char() == '#' {
+			header, err := p.parseVersion()
+			if err != nil {
+				return nil, err
+			}
+			if header {
+				continue
+			}
+		}
 		if p.EOF() {
 			if p.comment != "" {
 				prog.Comments = append(prog.Comments, p.comment)
@@ -278,13 +330,19 @@ func (p *parser) parseProg() (*Prog, error) {
 			}
 			c.Comment = strings.TrimSpace(p.s[p.i+1:])
 		}
-		for i := len(c.Args); i < len(meta.Args); i++ {
+		for i := len(c.Args); i < len(meta.Args)-meta.OmittableArgs; i++ {
 			p.strictFailf("missing syscall args")
 			c.Args = append(c.Args, meta.Args[i].DefaultArg())
 		}
-		if len(c.Args) != len(meta.Args) {
+		if len(c.Args) > len(meta.Args) || len(c.Args) < len(meta.Args)-meta.OmittableArgs {
 			return nil, fmt.Errorf("wrong call arg count: %v, want %v", len(c.Args), len(meta.Args))
 		}
+		if assignRequestMasks(c.Args) {
+			p.strictFailf("request mask of %v requests fields beyond the struct", name)
+		}
+		if assignIntRelations(c.Args) {
+			p.strictFailf("args of %v violate int relations", name)
+		}
 		if r != "" && c.Ret != nil {
 			p.vars[r] = c.Ret
 		}
@@ -296,6 +354,29 @@ func (p *parser) parseProg() (*Prog, error) {
 	return prog, nil
 }
 
+// parseVersion parses the version header if the current line is the header.
+func (p *parser) parseVersion() (bool, error) {
+	line := strings.TrimSpace(p.s[p.i+1:])
+	if !strings.HasPrefix(line, progVersionHeader) {
+		return false, nil
+	}
+	str := strings.TrimSpace(line[len(progVersionHeader):])
+	ver, err := strconv.Atoi(str)
+	if err != nil || ver < 1 {
+		return true, fmt.Errorf("bad program version %q", str)
+	}
+	if ver > ProgVersion {
+		// Newer versions are parsed as the current one, this works
+		// if the program does not use any new syntax.
+		if p.strict {
+			return true, fmt.Errorf("program version %v is newer than supported version %v", ver, ProgVersion)
+		}
+		ver = ProgVersion
+	}
+	p.version = ver
+	return true, nil
+}
+
 func (p *parser) parseArg(typ Type) (Arg, error) {
 	r := ""
 	if p.Char() == '<' {
@@ -377,9 +458,14 @@ func (p *parser) parseArgInt(typ Type) (Arg, error) {
 }
 
 func (p *parser) parseAuto(typ Type) (Arg, error) {
-	switch typ.(type) {
+	switch t := typ.(type) {
 	case *ConstType, *LenType, *CsumType:
 		return p.auto(MakeConstArg(typ, 0)), nil
+	case *IntType:
+		if t.Kind == IntRelPtr || t.Kind == IntArrayIndex {
+			return p.auto(MakeConstArg(typ, 0)), nil
+		}
+		return nil, fmt.Errorf("wrong type %T for AUTO", typ)
 	default:
 		return nil, fmt.Errorf("wrong type %T for AUTO", typ)
 	}
@@ -908,6 +994,7 @@ func fromHexChar(v byte) byte {
 type parser struct {
 	target  *Target
 	strict  bool
+	version int
 	vars    map[string]*ResultArg
 	autos   map[Arg]bool
 	comment string
@@ -953,7 +1040,11 @@ func (p *parser) fixupAutos(prog *Prog) {
 				_ = s
 			case *PtrType:
 				a := arg.(*PointerArg)
-				a.Address = s.ma.alloc(nil, a.Res.Size())
+				if typ.Guard {
+					a.Address = s.ma.allocGuarded(nil, a.Res.Size(), p.target.PageSize)
+				} else {
+					a.Address = s.ma.alloc(nil, a.Res.Size())
+				}
 			default:
 				panic(fmt.Sprintf("unsupported auto type %T", typ))
 
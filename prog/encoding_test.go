@@ -6,7 +6,9 @@ package prog
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
+	"path/filepath"
 	"reflect"
 	"regexp"
 	"sort"
@@ -323,6 +325,18 @@ func TestSerializeDeserialize(t *testing.T) {
 			`serialize1(&(0x7f0000000000)="0000000000000000", 0x8)`,
 			`serialize1(&(0x7f0000000000)=""/8, 0x8)`,
 		},
+		{
+			`test$tagged(0x3, &(0x7f0000000000)=[@f0={0x1, 0x42}, @f2={0x3, {0x1, 0x2, "aabb"}}, @f1={0x2, "01"}])`,
+			`test$tagged(0x3, &(0x7f0000000000)=[@f0={0x1, 0x42}, @f2={0x3, {0x1, 0x2, "aabb"}}, @f1={0x2, "01"}])`,
+		},
+		{
+			`test$sockaddr(&(0x7f0000000000)=@in={0x2, {0x4e20, 0x7f000001, [0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0]}}, 0x10)`,
+			`test$sockaddr(&(0x7f0000000000)=@in={0x2, {0x4e20, 0x7f000001}}, 0x10)`,
+		},
+		{
+			`test$sockaddr(&(0x7f0000000000)=@in6={0xa, {0x4e20, 0x0, "fe800000000000000000000000000001", 0x1}}, 0x1c)`,
+			`test$sockaddr(&(0x7f0000000000)=@in6={0xa, {0x4e20, 0x0, "fe800000000000000000000000000001", 0x1}}, 0x1c)`,
+		},
 	}
 	for _, test := range tests {
 		p, err := target.Deserialize([]byte(test[0]), Strict)
@@ -430,3 +444,84 @@ serialize0(0x0)
 		t.Errorf("bad program comments %q\nwant: %q", p.Comments, wantComments)
 	}
 }
+
+func TestDeserializeVersions(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	want, err := ioutil.ReadFile(filepath.Join("testdata", "versions", "current.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Programs of all versions must be translated to the same current form.
+	for ver := 0; ver <= ProgVersion; ver++ {
+		file := filepath.Join("testdata", "versions", fmt.Sprintf("%v.txt", ver))
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatalf("no fixture for version %v: %v", ver, err)
+		}
+		p, err := target.Deserialize(data, Strict)
+		if err != nil {
+			t.Fatalf("failed to deserialize %v: %v", file, err)
+		}
+		if got := p.Serialize(); !bytes.Equal(got, want) {
+			t.Errorf("wrong program %v:\n%s\nwant:\n%s", file, got, want)
+		}
+		versioned := p.SerializeVersioned()
+		if !bytes.Equal(AddVersionHeader(versioned), versioned) {
+			t.Errorf("version header is added twice:\n%s", AddVersionHeader(versioned))
+		}
+		p1, err := target.Deserialize(versioned, Strict)
+		if err != nil {
+			t.Fatalf("failed to deserialize versioned %v: %v", file, err)
+		}
+		if got := p1.Serialize(); !bytes.Equal(got, want) {
+			t.Errorf("wrong versioned program %v:\n%s\nwant:\n%s", file, got, want)
+		}
+		if len(p1.Comments) != 0 {
+			t.Errorf("version header is parsed as comment: %q", p1.Comments)
+		}
+	}
+}
+
+func TestDeserializeVersionHeader(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	tests := []struct {
+		input     string
+		strictErr string
+		err       string
+	}{
+		{
+			input:     "# syz-prog-version: 2\ntest$res1(0x0)\n",
+			strictErr: "program version 2 is newer than supported version 1",
+		},
+		{
+			input: "# syz-prog-version: foo\ntest$res1(0x0)\n",
+			err:   `bad program version "foo"`,
+		},
+		{
+			input: "# syz-prog-version: 0\ntest$res1(0x0)\n",
+			err:   `bad program version "0"`,
+		},
+		{
+			input: "# syz-prog-version: 1\ntest$blob0(&(0x7f0000000000+0x10))\n",
+		},
+		{
+			// The header is recognized only on the first line.
+			input: "test$res1(0x0)\n# syz-prog-version: foo\n",
+		},
+	}
+	for i, test := range tests {
+		for _, mode := range []DeserializeMode{Strict, NonStrict} {
+			want := test.err
+			if want == "" && mode == Strict {
+				want = test.strictErr
+			}
+			_, err := target.Deserialize([]byte(test.input), mode)
+			if want == "" && err != nil {
+				t.Errorf("#%v/%v: unexpected error: %v", i, mode, err)
+			}
+			if want != "" && (err == nil || !strings.Contains(err.Error(), want)) {
+				t.Errorf("#%v/%v: got error %v, want %q", i, mode, err, want)
+			}
+		}
+	}
+}
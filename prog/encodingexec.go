@@ -8,19 +8,22 @@
 // The sequence is terminated by a speciall call execInstrEOF.
 // Each call is (call ID, copyout index, number of arguments, arguments...).
 // Each argument is (type, size, value).
-// There are 4 types of arguments:
+// There are 5 types of arguments:
 //  - execArgConst: value is const value
 //  - execArgResult: value is copyout index we want to reference
 //  - execArgData: value is a binary blob (represented as ]size/8[ uint64's)
 //  - execArgCsum: runtime checksum calculation
-// There are 2 other special calls:
+//  - execArgChildPid: pid of a child process spawned by executor (no value)
+// There are 3 other special calls:
 //  - execInstrCopyin: copies its second argument into address specified by first argument
 //  - execInstrCopyout: reads value at address specified by first argument (result can be referenced by execArgResult)
+//  - execInstrGuard: makes page at address specified by first argument inaccessible
 
 package prog
 
 import (
 	"fmt"
+	"math/bits"
 	"sort"
 )
 
@@ -28,6 +31,7 @@ const (
 	execInstrEOF = ^uint64(iota)
 	execInstrCopyin
 	execInstrCopyout
+	execInstrGuard
 )
 
 const (
@@ -35,12 +39,17 @@ const (
 	execArgResult
 	execArgData
 	execArgCsum
+	execArgChildPid
 
 	execArgDataReadable = uint64(1 << 63)
 )
 
 const (
 	ExecArgCsumInet = uint64(iota)
+	ExecArgCsumCrc32
+	ExecArgCsumXor
+	ExecArgCsumFnv1a
+	ExecArgCsumAdd
 )
 
 const (
@@ -51,12 +60,16 @@ const (
 const (
 	ExecBufferSize = 2 << 20
 	ExecNoCopyout  = ^uint64(0)
+	// ExecGuardPoison fills the slack between pointees of guarded pointers and their guard pages.
+	ExecGuardPoison = 0xa5
 )
 
 // SerializeForExec serializes program p for execution by process pid into the provided buffer.
 // Returns number of bytes written to the buffer.
 // If the provided buffer is too small for the program an error is returned.
+// Hooks registered with RegisterCallHook are invoked before serialization on a copy of p.
 func (p *Prog) SerializeForExec(buffer []byte) (int, error) {
+	p = p.Target.runCallHooks(p)
 	p.debugValidate()
 	w := &execContext{
 		target: p.Target,
@@ -66,6 +79,8 @@ func (p *Prog) SerializeForExec(buffer []byte) (int, error) {
 	}
 	for _, c := range p.Calls {
 		w.csumMap, w.csumUses = calcChecksumsCall(c)
+		w.formats = byteOrderFormats(c)
+		w.parities = parityValues(c)
 		w.serializeCall(c)
 	}
 	w.write(execInstrEOF)
@@ -111,6 +126,8 @@ type execContext struct {
 	// Per-call state cached here to not pass it through all functions.
 	csumMap  map[Arg]CsumInfo
 	csumUses map[Arg]struct{}
+	formats  map[Arg]BinaryFormat
+	parities map[Arg]uint64
 }
 
 type argInfo struct {
@@ -121,6 +138,9 @@ type argInfo struct {
 
 func (w *execContext) writeCopyin(c *Call) {
 	ForeachArg(c, func(arg Arg, ctx *ArgCtx) {
+		if a, ok := arg.(*PointerArg); ok {
+			w.writeGuard(a)
+		}
 		if ctx.Base == nil {
 			return
 		}
@@ -142,6 +162,139 @@ func (w *execContext) writeCopyin(c *Call) {
 	})
 }
 
+// writeGuard poisons the slack between the pointee of a guarded pointer and the end of the page,
+// and makes the next page inaccessible, so that kernel reads past the pointee are detected.
+func (w *execContext) writeGuard(a *PointerArg) {
+	typ, ok := a.Type().(*PtrType)
+	if !ok || !typ.Guard || a.Res == nil || a.IsSpecial() {
+		return
+	}
+	end := a.Address + a.Res.Size()
+	page, ok := w.target.guardPage(a.Address, a.Res.Size())
+	if slack := page - end; slack != 0 {
+		poison := make([]byte, slack)
+		for i := range poison {
+			poison[i] = ExecGuardPoison
+		}
+		w.write(execInstrCopyin)
+		w.write(w.target.DataOffset + end)
+		w.writeData(poison, false)
+	}
+	if ok {
+		w.write(execInstrGuard)
+		w.write(w.target.DataOffset + page)
+	}
+}
+
+// byteOrderFormats returns formats of ints that follow byte order marks in structs of call c
+// (big-endian or native, depending on the value of the mark). Ints that have non-native
+// format are not affected, pointees of pointers are not considered to be part of the struct.
+func byteOrderFormats(c *Call) map[Arg]BinaryFormat {
+	var formats map[Arg]BinaryFormat
+	ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+		group, ok := arg.(*GroupArg)
+		if !ok {
+			return
+		}
+		for i, inner := range group.Inner {
+			typ, ok := inner.Type().(*IntType)
+			if !ok || !typ.ByteOrderMark {
+				continue
+			}
+			format := FormatNative
+			if inner.(*ConstArg).Val == typ.BigEndianMark {
+				format = FormatBigEndian
+			}
+			if formats == nil {
+				formats = make(map[Arg]BinaryFormat)
+			}
+			// Structs are visited before their fields, so marks of inner structs
+			// override formats set by marks of outer structs.
+			for _, field := range group.Inner[i+1:] {
+				ForeachSubArg(field, func(arg1 Arg, ctx1 *ArgCtx) {
+					switch arg1.(type) {
+					case *PointerArg:
+						ctx1.Stop = true
+					case *ConstArg, *ResultArg:
+						if arg1.Type().Format() == FormatNative {
+							formats[arg1] = format
+						}
+					}
+				})
+			}
+			break
+		}
+	})
+	return formats
+}
+
+// parityValues returns values of parity bitfields in structs of call c (see IntParity).
+// Parities are computed after all other bitfields of the backing integer are known,
+// so earlier parity bits of the same backing integer are included into later parities.
+func parityValues(c *Call) map[Arg]uint64 {
+	var parities map[Arg]uint64
+	ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+		if _, ok := arg.Type().(*StructType); !ok {
+			return
+		}
+		var unit []Arg
+		for _, field := range arg.(*GroupArg).Inner {
+			if field.Type().BitfieldLength() == 0 {
+				continue
+			}
+			unit = append(unit, field)
+			if field.Type().BitfieldMiddle() {
+				continue
+			}
+			if parities == nil {
+				parities = make(map[Arg]uint64)
+			}
+			assignParities(unit, parities)
+			unit = nil
+		}
+	})
+	return parities
+}
+
+// assignParities computes parity bits of the bitfields unit that share the same backing integer.
+func assignParities(unit []Arg, parities map[Arg]uint64) {
+	var value uint64
+	var pending []*ConstArg
+	for _, field := range unit {
+		a, ok := field.(*ConstArg)
+		if !ok {
+			continue
+		}
+		if typ, ok := a.Type().(*IntType); ok && typ.Kind == IntParity {
+			pending = append(pending, a)
+			continue
+		}
+		typ := a.Type()
+		val, _ := a.Value()
+		value |= (val & (1<<typ.BitfieldLength() - 1)) << typ.BitfieldOffset()
+	}
+	for _, a := range pending {
+		typ := a.Type().(*IntType)
+		src := value >> typ.ParityBegin
+		if n := typ.ParityEnd - typ.ParityBegin + 1; n < 64 {
+			src &= 1<<n - 1
+		}
+		parity := uint64(bits.OnesCount64(src) & 1)
+		if typ.ParityOdd {
+			parity ^= 1
+		}
+		parities[a] = parity
+		value |= parity << typ.BitfieldOffset()
+	}
+}
+
+func (w *execContext) format(arg Arg) BinaryFormat {
+	if format, ok := w.formats[arg]; ok {
+		return format
+	}
+	return arg.Type().Format()
+}
+
 func (w *execContext) willBeUsed(arg Arg) bool {
 	if res, ok := arg.(*ResultArg); ok && len(res.uses) != 0 {
 		return true
@@ -175,24 +328,32 @@ func (w *execContext) writeChecksums() {
 		switch info.Kind {
 		case CsumInet:
 			w.write(ExecArgCsumInet)
-			w.write(uint64(len(info.Chunks)))
-			for _, chunk := range info.Chunks {
-				switch chunk.Kind {
-				case CsumChunkArg:
-					w.write(ExecArgCsumChunkData)
-					w.write(w.args[chunk.Arg].Addr)
-					w.write(chunk.Arg.Size())
-				case CsumChunkConst:
-					w.write(ExecArgCsumChunkConst)
-					w.write(chunk.Value)
-					w.write(chunk.Size)
-				default:
-					panic(fmt.Sprintf("csum chunk has unknown kind %v", chunk.Kind))
-				}
-			}
+		case CsumCrc32:
+			w.write(ExecArgCsumCrc32)
+		case CsumXor:
+			w.write(ExecArgCsumXor)
+		case CsumFnv1a:
+			w.write(ExecArgCsumFnv1a)
+		case CsumAdd:
+			w.write(ExecArgCsumAdd)
 		default:
 			panic(fmt.Sprintf("csum arg has unknown kind %v", info.Kind))
 		}
+		w.write(uint64(len(info.Chunks)))
+		for _, chunk := range info.Chunks {
+			switch chunk.Kind {
+			case CsumChunkArg:
+				w.write(ExecArgCsumChunkData)
+				w.write(w.args[chunk.Arg].Addr)
+				w.write(chunk.Arg.Size())
+			case CsumChunkConst:
+				w.write(ExecArgCsumChunkConst)
+				w.write(chunk.Value)
+				w.write(chunk.Size)
+			default:
+				panic(fmt.Sprintf("csum chunk has unknown kind %v", chunk.Kind))
+			}
+		}
 	}
 }
 
@@ -210,7 +371,7 @@ func (w *execContext) writeCopyout(c *Call) {
 			w.write(execInstrCopyout)
 			w.write(info.Idx)
 			w.write(info.Addr)
-			w.write(arg.Size())
+			w.write(arg.Size() | uint64(w.format(arg))<<8)
 		}
 	})
 }
@@ -234,19 +395,27 @@ func (w *execContext) write(v uint64) {
 func (w *execContext) writeArg(arg Arg) {
 	switch a := arg.(type) {
 	case *ConstArg:
+		if typ, ok := a.Type().(*IntType); ok && typ.Kind == IntChildPid {
+			w.write(execArgChildPid)
+			w.write(a.Size() | uint64(w.format(a))<<8)
+			return
+		}
 		val, pidStride := a.Value()
+		if parity, ok := w.parities[a]; ok {
+			val = parity
+		}
 		typ := a.Type()
-		w.writeConstArg(a.Size(), val, typ.BitfieldOffset(), typ.BitfieldLength(), pidStride, typ.Format())
+		w.writeConstArg(a.Size(), val, typ.BitfieldOffset(), typ.BitfieldLength(), pidStride, w.format(a))
 	case *ResultArg:
 		if a.Res == nil {
-			w.writeConstArg(a.Size(), a.Val, 0, 0, 0, a.Type().Format())
+			w.writeConstArg(a.Size(), a.Val, 0, 0, 0, w.format(a))
 		} else {
 			info, ok := w.args[a.Res]
 			if !ok {
 				panic("no copyout index")
 			}
 			w.write(execArgResult)
-			meta := a.Size() | uint64(a.Type().Format())<<8
+			meta := a.Size() | uint64(w.format(a))<<8
 			w.write(meta)
 			w.write(info.Idx)
 			w.write(a.OpDiv)
@@ -260,22 +429,7 @@ func (w *execContext) writeArg(arg Arg) {
 		if len(data) == 0 {
 			return
 		}
-		w.write(execArgData)
-		flags := uint64(len(data))
-		if isReadableDataType(a.Type().(*BufferType)) {
-			flags |= execArgDataReadable
-		}
-		w.write(flags)
-		padded := len(data)
-		if pad := 8 - len(data)%8; pad != 8 {
-			padded += pad
-		}
-		if len(w.buf) < padded {
-			w.eof = true
-		} else {
-			copy(w.buf, data)
-			w.buf = w.buf[padded:]
-		}
+		w.writeData(data, isReadableDataType(a.Type().(*BufferType)))
 	case *UnionArg:
 		w.writeArg(a.Option)
 	default:
@@ -283,6 +437,29 @@ func (w *execContext) writeArg(arg Arg) {
 	}
 }
 
+func (w *execContext) writeData(data []byte, readable bool) {
+	w.write(execArgData)
+	flags := uint64(len(data))
+	if readable {
+		flags |= execArgDataReadable
+	}
+	w.write(flags)
+	padded := len(data)
+	if pad := 8 - len(data)%8; pad != 8 {
+		padded += pad
+	}
+	if len(w.buf) < padded {
+		w.eof = true
+	} else {
+		copy(w.buf, data)
+		// Zero the padding, the buffer can contain leftovers of a previous program.
+		for i := len(data); i < padded; i++ {
+			w.buf[i] = 0
+		}
+		w.buf = w.buf[padded:]
+	}
+}
+
 func (w *execContext) writeConstArg(size, val, bfOffset, bfLength, pidStride uint64, bf BinaryFormat) {
 	w.write(execArgConst)
 	meta := size | uint64(bf)<<8 | bfOffset<<16 | bfLength<<24 | pidStride<<32
@@ -242,6 +242,40 @@ func TestSerializeForExec(t *testing.T) {
 			},
 			nil,
 		},
+		{
+			// Native ints after the byte order mark become big-endian, except for pointees.
+			"test$end2(&(0x7f0000000000)={0x1, 0xfeff, 0x1e, 0x3, {0x42, 0x1}, &(0x7f0000000100)=0x5})",
+			[]uint64{
+				execInstrCopyin, dataOffset + 0, execArgConst, 2, 0x1,
+				execInstrCopyin, dataOffset + 2, execArgConst, 2, 0xfeff,
+				execInstrCopyin, dataOffset + 4, execArgConst, 2 | 1<<8, 0x1e,
+				execInstrCopyin, dataOffset + 6, execArgConst, 4 | 1<<8, 0x3,
+				execInstrCopyin, dataOffset + 10, execArgConst, 4 | 1<<8, 0x42,
+				execInstrCopyin, dataOffset + 14, execArgConst, 8 | 1<<8, 0x1,
+				execInstrCopyin, dataOffset + 22, execArgConst, ptrSize, dataOffset + 0x100,
+				execInstrCopyin, dataOffset + 0x100, execArgConst, 4, 0x5,
+				callID("test$end2"), ExecNoCopyout, 1, execArgConst, ptrSize, dataOffset,
+				execInstrEOF,
+			},
+			nil,
+		},
+		{
+			// Any other value of the mark leaves the ints native.
+			"test$end2(&(0x7f0000000000)={0x1, 0xfffe, 0x1e, 0x3, {0x42, 0x1}, &(0x7f0000000100)=0x5})",
+			[]uint64{
+				execInstrCopyin, dataOffset + 0, execArgConst, 2, 0x1,
+				execInstrCopyin, dataOffset + 2, execArgConst, 2, 0xfffe,
+				execInstrCopyin, dataOffset + 4, execArgConst, 2, 0x1e,
+				execInstrCopyin, dataOffset + 6, execArgConst, 4 | 1<<8, 0x3,
+				execInstrCopyin, dataOffset + 10, execArgConst, 4, 0x42,
+				execInstrCopyin, dataOffset + 14, execArgConst, 8, 0x1,
+				execInstrCopyin, dataOffset + 22, execArgConst, ptrSize, dataOffset + 0x100,
+				execInstrCopyin, dataOffset + 0x100, execArgConst, 4, 0x5,
+				callID("test$end2"), ExecNoCopyout, 1, execArgConst, ptrSize, dataOffset,
+				execInstrEOF,
+			},
+			nil,
+		},
 		{
 			"test$bf0(&(0x7f0000000000)={0x42, 0x42, 0x42, 0x42, 0x42, 0x42, 0x42, 0x42})",
 			[]uint64{
@@ -362,6 +396,110 @@ func TestSerializeForExec(t *testing.T) {
 			},
 			nil,
 		},
+		{
+			// Created resources get the valid bit added at runtime, special values don't have it.
+			"r0 = test$res0()\ntest$res9(r0+2147483648, &(0x7f0000000000)={0xffffffffbfffffff})",
+			[]uint64{
+				callID("test$res0"), 0, 0,
+				execInstrCopyin, dataOffset, execArgConst, 4, 0xffffffffbfffffff,
+				callID("test$res9"), ExecNoCopyout, 2,
+				execArgResult, 4, 0, 0, 0x80000000, 0xffff,
+				execArgConst, ptrSize, dataOffset,
+				execInstrEOF,
+			},
+			nil,
+		},
+		{
+			// Fields typed as big-endian resources inherit the resource byte order
+			// both when the resource is copied out and when it is copied in.
+			"test$be_res0(&(0x7f0000000000)={0x0, <r0=>0x0})\n" +
+				"test$be_res1(&(0x7f0000000040)={0x1, r0})\n" +
+				"test$be_res1(&(0x7f0000000080)={0x1, 0x1234})",
+			[]uint64{
+				callID("test$be_res0"), ExecNoCopyout, 1, execArgConst, ptrSize, dataOffset,
+				execInstrCopyout, 0, dataOffset + 4, 4 | uint64(FormatBigEndian)<<8,
+				execInstrCopyin, dataOffset + 0x40, execArgConst, 1, 0x1,
+				execInstrCopyin, dataOffset + 0x44, execArgResult, 4 | uint64(FormatBigEndian)<<8, 0, 0, 0, 0x1234,
+				callID("test$be_res1"), ExecNoCopyout, 1, execArgConst, ptrSize, dataOffset + 0x40,
+				execInstrCopyin, dataOffset + 0x80, execArgConst, 1, 0x1,
+				execInstrCopyin, dataOffset + 0x84, execArgConst, 4 | uint64(FormatBigEndian)<<8, 0x1234,
+				callID("test$be_res1"), ExecNoCopyout, 1, execArgConst, ptrSize, dataOffset + 0x80,
+				execInstrEOF,
+			},
+			&ExecProg{
+				Calls: []ExecCall{
+					{
+						Meta:  target.SyscallMap["test$be_res0"],
+						Index: ExecNoCopyout,
+						Args:  []ExecArg{ExecArgConst{Size: ptrSize, Value: dataOffset}},
+						Copyout: []ExecCopyout{
+							{Index: 0, Addr: dataOffset + 4, Size: 4, Format: FormatBigEndian},
+						},
+					},
+					{
+						Meta:  target.SyscallMap["test$be_res1"],
+						Index: ExecNoCopyout,
+						Args:  []ExecArg{ExecArgConst{Size: ptrSize, Value: dataOffset + 0x40}},
+						Copyin: []ExecCopyin{
+							{
+								Addr: dataOffset + 0x40,
+								Arg:  ExecArgConst{Size: 1, Value: 0x1},
+							},
+							{
+								Addr: dataOffset + 0x44,
+								Arg: ExecArgResult{Size: 4, Format: FormatBigEndian,
+									Index: 0, Default: 0x1234},
+							},
+						},
+					},
+					{
+						Meta:  target.SyscallMap["test$be_res1"],
+						Index: ExecNoCopyout,
+						Args:  []ExecArg{ExecArgConst{Size: ptrSize, Value: dataOffset + 0x80}},
+						Copyin: []ExecCopyin{
+							{
+								Addr: dataOffset + 0x80,
+								Arg:  ExecArgConst{Size: 1, Value: 0x1},
+							},
+							{
+								Addr: dataOffset + 0x84,
+								Arg:  ExecArgConst{Size: 4, Format: FormatBigEndian, Value: 0x1234},
+							},
+						},
+					},
+				},
+				Vars: []uint64{0x1234},
+			},
+		},
+		{
+			// Fields with init attribute are written even in output structs.
+			"test$length34(&(0x7f0000000000)={0x10, 0x0, \"\"/8})",
+			[]uint64{
+				execInstrCopyin, dataOffset, execArgConst, 4, 0x10,
+				callID("test$length34"), ExecNoCopyout, 1, execArgConst, ptrSize, dataOffset,
+				execInstrEOF,
+			},
+			nil,
+		},
+		{
+			// Size of choice arguments is determined by the chosen alternative.
+			"test$choice0(@intptr=0x5)",
+			[]uint64{
+				callID("test$choice0"), ExecNoCopyout, 1, execArgConst, 8, 0x5,
+				execInstrEOF,
+			},
+			nil,
+		},
+		{
+			"test$choice1(0xffffffffffffffff, @int8=0x7)",
+			[]uint64{
+				callID("test$choice1"), ExecNoCopyout, 2,
+				execArgConst, 4, 0xffffffffffffffff,
+				execArgConst, 1, 0x7,
+				execInstrEOF,
+			},
+			nil,
+		},
 		{
 			"test$opt3(0x0)",
 			[]uint64{
@@ -449,6 +587,139 @@ func TestSerializeForExec(t *testing.T) {
 			},
 			nil,
 		},
+		{
+			"test$csum_crc32(&(0x7f0000000000)={0x1, 0x0, \"01020304\"})",
+			[]uint64{
+				execInstrCopyin, dataOffset + 0, execArgConst, 4, 0x1,
+				execInstrCopyin, dataOffset + 4, execArgConst, 4, 0x0,
+				execInstrCopyin, dataOffset + 8, execArgData, 4, 0x04030201,
+				execInstrCopyin, dataOffset + 4, execArgCsum, 4, ExecArgCsumCrc32, 1,
+				ExecArgCsumChunkData, dataOffset + 0, 12,
+				callID("test$csum_crc32"), ExecNoCopyout, 1, execArgConst, ptrSize, dataOffset,
+				execInstrEOF,
+			},
+			nil,
+		},
+		{
+			"test$footer(&(0x7f0000000000)={0xe, 0x6, \"01020304\", 0xfeedface}, 0xe)",
+			[]uint64{
+				execInstrCopyin, dataOffset + 0, execArgConst, 4, 0xe,
+				execInstrCopyin, dataOffset + 4, execArgConst, 2, 0x6,
+				execInstrCopyin, dataOffset + 6, execArgData, 4, 0x04030201,
+				execInstrCopyin, dataOffset + 10, execArgConst, 4, 0xfeedface,
+				callID("test$footer"), ExecNoCopyout, 2, execArgConst, ptrSize, dataOffset, execArgConst, 8, 0xe,
+				execInstrEOF,
+			},
+			nil,
+		},
+		{
+			"test$child_pid(0x0, &(0x7f0000000000)={0x1, 0x0, [0x2]})",
+			[]uint64{
+				execInstrCopyin, dataOffset + 0, execArgConst, 4, 0x1,
+				execInstrCopyin, dataOffset + 4, execArgChildPid, 4,
+				execInstrCopyin, dataOffset + 8, execArgConst, 4, 0x2,
+				callID("test$child_pid"), ExecNoCopyout, 2, execArgChildPid, 4, execArgConst, ptrSize, dataOffset,
+				execInstrEOF,
+			},
+			nil,
+		},
+		{
+			"test$csum_xor(&(0x7f0000000000)={0x1, 0x0, \"aabbccdd\"})",
+			[]uint64{
+				execInstrCopyin, dataOffset + 0, execArgConst, 2, 0x1,
+				execInstrCopyin, dataOffset + 2, execArgConst, 2, 0x0,
+				execInstrCopyin, dataOffset + 4, execArgData, 4, 0xddccbbaa,
+				execInstrCopyin, dataOffset + 2, execArgCsum, 2, ExecArgCsumXor, 1,
+				ExecArgCsumChunkData, dataOffset + 4, 4,
+				callID("test$csum_xor"), ExecNoCopyout, 1, execArgConst, ptrSize, dataOffset,
+				execInstrEOF,
+			},
+			nil,
+		},
+		{
+			"test$hash_fnv1a(&(0x7f0000000000)={0x1, 0x0, \"01020304\"})",
+			[]uint64{
+				execInstrCopyin, dataOffset + 0, execArgConst, 4, 0x1,
+				execInstrCopyin, dataOffset + 4, execArgConst, 8, 0x0,
+				execInstrCopyin, dataOffset + 12, execArgData, 4, 0x04030201,
+				execInstrCopyin, dataOffset + 4, execArgCsum, 8, ExecArgCsumFnv1a, 1,
+				ExecArgCsumChunkData, dataOffset + 0, 16,
+				callID("test$hash_fnv1a"), ExecNoCopyout, 1, execArgConst, ptrSize, dataOffset,
+				execInstrEOF,
+			},
+			nil,
+		},
+		{
+			"test$hash_add(&(0x7f0000000000)={0x0, \"aabbccdd\"})",
+			[]uint64{
+				execInstrCopyin, dataOffset + 0, execArgConst, 2, 0x0,
+				execInstrCopyin, dataOffset + 2, execArgData, 4, 0xddccbbaa,
+				execInstrCopyin, dataOffset + 0, execArgCsum, 2, ExecArgCsumAdd, 1,
+				ExecArgCsumChunkData, dataOffset + 2, 4,
+				callID("test$hash_add"), ExecNoCopyout, 1, execArgConst, ptrSize, dataOffset,
+				execInstrEOF,
+			},
+			nil,
+		},
+		{
+			"test$length31(&(0x7f0000000000)={0x1, '\\x00', 0x2, 0xc, 0x3})",
+			[]uint64{
+				execInstrCopyin, dataOffset + 0, execArgConst, 1, 0x1,
+				execInstrCopyin, dataOffset + 1, execArgData, 3 | execArgDataReadable, 0x0,
+				execInstrCopyin, dataOffset + 4, execArgConst, 4, 0x2,
+				execInstrCopyin, dataOffset + 8, execArgConst, 1, 0xc,
+				execInstrCopyin, dataOffset + 9, execArgConst, 1, 0x3,
+				callID("test$length31"), ExecNoCopyout, 1, execArgConst, ptrSize, dataOffset,
+				execInstrEOF,
+			},
+			nil,
+		},
+		{
+			// The pointee ends at the page end, so there is nothing to poison.
+			"test$guard0(&(0x7f0000000ffb)=\"0102030405\", 0x5)",
+			[]uint64{
+				execInstrGuard, dataOffset + 0x1000,
+				execInstrCopyin, dataOffset + 0xffb, execArgData, 5, 0x0504030201,
+				callID("test$guard0"), ExecNoCopyout, 2, execArgConst, ptrSize, dataOffset + 0xffb,
+				execArgConst, ptrSize, 0x5,
+				execInstrEOF,
+			},
+			nil,
+		},
+		{
+			"test$guard0(&(0x7f0000001ff8)=\"010203\", 0x3)",
+			[]uint64{
+				execInstrCopyin, dataOffset + 0x1ffb, execArgData, 5, 0xa5a5a5a5a5,
+				execInstrGuard, dataOffset + 0x2000,
+				execInstrCopyin, dataOffset + 0x1ff8, execArgData, 3, 0x030201,
+				callID("test$guard0"), ExecNoCopyout, 2, execArgConst, ptrSize, dataOffset + 0x1ff8,
+				execArgConst, ptrSize, 0x3,
+				execInstrEOF,
+			},
+			&ExecProg{
+				Calls: []ExecCall{
+					{
+						Meta:  target.SyscallMap["test$guard0"],
+						Index: ExecNoCopyout,
+						Args: []ExecArg{
+							ExecArgConst{Size: ptrSize, Value: dataOffset + 0x1ff8},
+							ExecArgConst{Size: ptrSize, Value: 0x3},
+						},
+						Copyin: []ExecCopyin{
+							{
+								Addr: dataOffset + 0x1ffb,
+								Arg:  ExecArgData{Data: []byte{0xa5, 0xa5, 0xa5, 0xa5, 0xa5}},
+							},
+							{
+								Addr: dataOffset + 0x1ff8,
+								Arg:  ExecArgData{Data: []byte{0x1, 0x2, 0x3}},
+							},
+						},
+						Guards: []uint64{dataOffset + 0x2000},
+					},
+				},
+			},
+		},
 	}
 
 	buf := make([]byte, ExecBufferSize)
@@ -485,3 +756,97 @@ func TestSerializeForExec(t *testing.T) {
 		})
 	}
 }
+
+func TestSerializeForExecByteOrder(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{
+		target.SyscallMap["test$end2"]: true,
+	})
+	buf := make([]byte, ExecBufferSize)
+	seen := make(map[uint64]bool)
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 3, ct)
+		p.Mutate(rs, 5, ct, nil)
+		data := p.Serialize()
+		p1, err := target.Deserialize(data, NonStrict)
+		if err != nil {
+			t.Fatalf("failed to deserialize: %v\n%s", err, data)
+		}
+		if data1 := p1.Serialize(); !bytes.Equal(data, data1) {
+			t.Fatalf("program changed after round-trip\n%s\nvs\n%s", data, data1)
+		}
+		n, err := p.SerializeForExec(buf)
+		if err != nil {
+			t.Fatalf("failed to serialize: %v", err)
+		}
+		decoded, err := target.DeserializeExec(buf[:n])
+		if err != nil {
+			t.Fatal(err)
+		}
+		for ci, c := range p.Calls {
+			ptr := c.Args[0].(*PointerArg)
+			if ptr.Res == nil {
+				continue
+			}
+			s := ptr.Res.(*GroupArg)
+			bom := s.Inner[1].(*ConstArg).Val
+			seen[bom] = true
+			want := FormatNative
+			if bom == 0xfeff {
+				want = FormatBigEndian
+			}
+			addr := target.PhysicalAddr(ptr)
+			for _, copyin := range decoded.Calls[ci].Copyin {
+				if copyin.Addr == addr+4 || copyin.Addr == addr+10 {
+					if got := copyin.Arg.(ExecArgConst).Format; got != want {
+						t.Fatalf("field at offset %v has format %v with mark 0x%x, want %v\n%s",
+							copyin.Addr-addr, got, bom, want, data)
+					}
+				}
+			}
+		}
+	}
+	if !seen[0xfeff] || !seen[0xfffe] || len(seen) != 2 {
+		t.Fatalf("generated byte order marks: %v", seen)
+	}
+}
+
+func TestSerializeParity(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	tests := []struct {
+		prog    string
+		parity0 uint64
+		parity1 uint64
+	}{
+		// 0x1234 has 5 set bits, 0x5 has 2 set bits.
+		{"test$parity(&(0x7f0000000000)={0x1234, 0x0, 0x5, 0x0, 0x7})", 1, 1},
+		// 0x3 has 2 set bits, 0xe has 3 set bits.
+		{"test$parity(&(0x7f0000000000)={0x3, 0x1, 0xe, 0x1, 0x0})", 0, 0},
+		// Bits above the bitfield width don't affect parity.
+		{"test$parity(&(0x7f0000000000)={0x8000, 0x0, 0x10, 0x0, 0x0})", 0, 1},
+	}
+	buf := make([]byte, ExecBufferSize)
+	for i, test := range tests {
+		p, err := target.Deserialize([]byte(test.prog), Strict)
+		if err != nil {
+			t.Fatalf("failed to deserialize prog %v: %v", i, err)
+		}
+		n, err := p.SerializeForExec(buf)
+		if err != nil {
+			t.Fatalf("failed to serialize prog %v: %v", i, err)
+		}
+		exec, err := target.DeserializeExec(buf[:n])
+		if err != nil {
+			t.Fatalf("failed to decode prog %v: %v", i, err)
+		}
+		var parities []uint64
+		for _, copyin := range exec.Calls[0].Copyin {
+			if arg, ok := copyin.Arg.(ExecArgConst); ok && arg.BitfieldLength == 1 {
+				parities = append(parities, arg.Value)
+			}
+		}
+		if want := []uint64{test.parity0, test.parity1}; !reflect.DeepEqual(parities, want) {
+			t.Errorf("prog %v: got parities %v, want %v", i, parities, want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"sync"
+)
+
+// enumCycler hands out values of exhaustive flags (see FlagsType.Exhaustive) in round-robin order,
+// so that all values of a flags field are covered evenly across programs generated
+// with the same ChoiceTable. Values are tracked separately for each field type.
+type enumCycler struct {
+	mu   sync.Mutex
+	next map[*FlagsType]int
+}
+
+func newEnumCycler() *enumCycler {
+	return &enumCycler{
+		next: make(map[*FlagsType]int),
+	}
+}
+
+func (ec *enumCycler) value(t *FlagsType) uint64 {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	i := ec.next[t]
+	ec.next[t] = (i + 1) % len(t.Vals)
+	return t.Vals[i]
+}
+
+// exhaustiveValue returns the next value of exhaustive flags t, or false if the value
+// should be chosen randomly.
+func (s *state) exhaustiveValue(t *FlagsType) (uint64, bool) {
+	if !t.Exhaustive || s.ct == nil || len(t.Vals) == 0 {
+		return 0, false
+	}
+	return s.ct.enums.value(t), true
+}
@@ -0,0 +1,128 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+// Event resources (see ResourceDesc.Event) are signaled by some calls and waited on by other calls.
+// Generation precedes waits on resources that don't have pending signals with signaling calls
+// (if there are any enabled), and markNonblockingEvents makes resources that are still waited on
+// without a pending signal non-blocking, so that programs don't block forever.
+
+// generateEventSignals returns calls that signal event resources waited on by c
+// and don't have pending signals. prev are calls generated before c that are not yet analyzed.
+func (r *randGen) generateEventSignals(s *state, c *Call, prev []*Call) []*Call {
+	pending := make(map[*ResultArg]int)
+	for _, c1 := range prev {
+		applyEvents(c1, pending)
+	}
+	var calls []*Call
+	ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+		typ, ok := arg.Type().(*ResourceType)
+		if !ok || typ.Event != EventWait || arg.(*ResultArg).Res == nil {
+			return
+		}
+		res := arg.(*ResultArg).Res
+		if s.signals[res]+pending[res] > 0 {
+			pending[res]--
+			return
+		}
+		calls = append(calls, r.generateEventSignal(s, res)...)
+	})
+	return calls
+}
+
+// generateEventSignal returns a random enabled call that signals event resource res
+// preceded by calls that create its other resources, or nil if there are no such calls.
+func (r *randGen) generateEventSignal(s *state, res *ResultArg) []*Call {
+	type signal struct {
+		meta *Syscall
+		arg  int
+	}
+	var signals []signal
+	kind := res.Type().(*ResourceType).Desc.Name
+	for _, meta := range r.target.Syscalls {
+		if s.ct != nil && !s.ct.enabled[meta] {
+			continue
+		}
+		for i, typ := range meta.Args {
+			if t, ok := typ.(*ResourceType); ok && t.Event == EventSignal &&
+				r.target.isCompatibleResource(t.Desc.Name, kind) {
+				signals = append(signals, signal{meta, i})
+			}
+		}
+	}
+	if len(signals) == 0 {
+		return nil
+	}
+	sig := signals[r.Intn(len(signals))]
+	calls := r.generateCallWithArgs(s, sig.meta, nil)
+	c := calls[len(calls)-1]
+	if sig.arg >= len(c.Args) {
+		// The argument was omitted.
+		return calls
+	}
+	arg := c.Args[sig.arg].(*ResultArg)
+	replaceResultArg(arg, MakeResultArg(arg.Type(), res, 0))
+	return calls
+}
+
+// markNonblockingEvents sets non-blocking flags (see Syscall.NonblockFlag) of calls that create
+// event resources that are waited on without a pending signal.
+func (p *Prog) markNonblockingEvents() {
+	pending := make(map[*ResultArg]int)
+	creators := make(map[*ResultArg]*Call)
+	for _, c := range p.Calls {
+		ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+			typ, ok := arg.Type().(*ResourceType)
+			if !ok || typ.Event != EventWait || arg.(*ResultArg).Res == nil {
+				return
+			}
+			res := arg.(*ResultArg).Res
+			if pending[res] > 0 {
+				pending[res]--
+				return
+			}
+			if creator := creators[res]; creator != nil {
+				setNonblockFlag(creator)
+			}
+		})
+		applySignals(c, pending)
+		if c.Meta.NonblockFlag != 0 && c.Ret != nil {
+			creators[c.Ret] = c
+		}
+	}
+}
+
+func setNonblockFlag(c *Call) {
+	if c.Meta.NonblockArg >= len(c.Args) {
+		return
+	}
+	arg := c.Args[c.Meta.NonblockArg]
+	switch arg.Type().(type) {
+	case *IntType, *FlagsType:
+		arg.(*ConstArg).Val |= c.Meta.NonblockFlag
+	}
+}
+
+// applyEvents updates pending signals of event resources with signals and waits of call c.
+func applyEvents(c *Call, pending map[*ResultArg]int) {
+	ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+		if typ, ok := arg.Type().(*ResourceType); ok && typ.Event == EventWait {
+			if res := arg.(*ResultArg).Res; res != nil && pending[res] > 0 {
+				pending[res]--
+			}
+		}
+	})
+	applySignals(c, pending)
+}
+
+// applySignals updates pending signals of event resources with signals of call c.
+func applySignals(c *Call, pending map[*ResultArg]int) {
+	ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+		if typ, ok := arg.Type().(*ResourceType); ok && typ.Event == EventSignal {
+			if res := arg.(*ResultArg).Res; res != nil {
+				pending[res]++
+			}
+		}
+	})
+}
@@ -4,10 +4,13 @@
 package prog
 
 import (
+	"fmt"
 	"math/rand"
+	"sort"
 )
 
 // Generate generates a random program of length ~ncalls.
+// If ct limits the number of calls (see ChoiceTable.SetMaxCalls), the program is not longer than the limit.
 // calls is a set of allowed syscalls, if nil all syscalls are used.
 func (target *Target) Generate(rs rand.Source, ncalls int, ct *ChoiceTable) *Prog {
 	p := &Prog{
@@ -15,13 +18,221 @@ func (target *Target) Generate(rs rand.Source, ncalls int, ct *ChoiceTable) *Pro
 	}
 	r := newRand(target, rs)
 	s := newState(target, ct)
-	for len(p.Calls) < ncalls {
-		calls := r.generateCall(s, p)
-		for _, c := range calls {
+	if ct != nil && ct.prefix != nil {
+		for _, c := range ct.prefix.Clone().Calls {
 			s.analyze(c)
 			p.Calls = append(p.Calls, c)
 		}
 	}
+	if ct != nil && ct.maxCalls != 0 {
+		r.generateCapped(s, p, ncalls, ct.maxCalls)
+	} else {
+		for len(p.Calls) < ncalls {
+			calls := r.generateCall(s, p)
+			for _, c := range calls {
+				s.analyze(c)
+				p.Calls = append(p.Calls, c)
+			}
+		}
+	}
+	p.markNonblockingEvents()
+	p.placeSharedRegions()
+	p.enforceScopes()
+	p.enforceNamespaces()
 	p.debugValidate()
 	return p
 }
+
+// generateCapped appends calls to p until it has ncalls calls, but never more than maxCalls
+// (see ChoiceTable.SetMaxCalls). Calls whose dependencies can't fit into the remaining calls
+// are skipped, and calls that don't need new dependencies are preferred.
+// If nothing fits after a number of attempts, p is left shorter than ncalls.
+func (r *randGen) generateCapped(s *state, p *Prog, ncalls, maxCalls int) {
+	if ncalls > maxCalls {
+		ncalls = maxCalls
+	}
+	for failed := 0; len(p.Calls) < ncalls && failed < 100; {
+		left := maxCalls - len(p.Calls)
+		var meta *Syscall
+		for i := 0; i < 10; i++ {
+			meta1 := r.chooseCall(s, p)
+			deps := s.minDeps(meta1)
+			if deps >= left {
+				continue
+			}
+			meta = meta1
+			if deps == 0 {
+				break
+			}
+		}
+		if meta == nil {
+			failed++
+			continue
+		}
+		// minDeps is a lower bound, generation can still produce more calls
+		// (e.g. when it decides to create a new resource instead of reusing an existing one).
+		calls := r.generateChosenCall(s, meta)
+		if len(calls) > left {
+			discardCalls(calls)
+			failed++
+			continue
+		}
+		for _, c := range calls {
+			s.analyze(c)
+			p.Calls = append(p.Calls, c)
+		}
+	}
+}
+
+// minDeps returns the minimal number of calls that need to be generated before meta:
+// required calls that are not present in the program yet and constructors of input resources
+// that are not present in the program yet (together with their own dependencies).
+// Resources that no enabled call can create are not counted, since generation uses
+// default values for them.
+func (s *state) minDeps(meta *Syscall) int {
+	return s.minDepsImpl(meta, make(map[*Syscall]bool), make(map[*Syscall]int))
+}
+
+func (s *state) minDepsImpl(meta *Syscall, visiting map[*Syscall]bool, memo map[*Syscall]int) int {
+	if n, ok := memo[meta]; ok {
+		return n
+	}
+	visiting[meta] = true
+	defer delete(visiting, meta)
+	n := 0
+	for _, name := range meta.Requires {
+		req := s.target.SyscallMap[name]
+		if !s.calls[req] && !visiting[req] {
+			n += 1 + s.minDepsImpl(req, visiting, memo)
+		}
+	}
+	for _, res := range s.target.inputResources(meta) {
+		if res == timespecRes || s.hasResource(res) {
+			// Timespecs are created with special generation code, not with calls.
+			continue
+		}
+		best := 0
+		for _, ctor := range s.target.resourceCtors[res.Name] {
+			if visiting[ctor] || s.ct == nil || s.ct.run[ctor.ID] == nil {
+				continue
+			}
+			if deps := 1 + s.minDepsImpl(ctor, visiting, memo); best == 0 || deps < best {
+				best = deps
+			}
+		}
+		n += best
+	}
+	memo[meta] = n
+	return n
+}
+
+// hasResource returns true if the program already contains a resource that can be used as res.
+func (s *state) hasResource(res *ResourceDesc) bool {
+	for kind, res1 := range s.resources {
+		if len(res1) != 0 && s.target.isCompatibleResource(res.Name, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateCovering generates a set of programs of length ~ncalls that together contain
+// every enabled syscall (all syscalls if ct is nil) at least once.
+// The set is built greedily: calls with more input resources are generated first,
+// and syscalls generated as their dependencies (e.g. resource constructors)
+// are not generated again, so the set is small but not necessarily minimal.
+func (target *Target) GenerateCovering(rs rand.Source, ncalls int, ct *ChoiceTable) []*Prog {
+	r := newRand(target, rs)
+	var calls []*Syscall
+	if ct != nil {
+		calls = append(calls, ct.enabledCalls...)
+	} else {
+		calls = append(calls, target.Syscalls...)
+	}
+	inputs := make(map[*Syscall]int)
+	for _, meta := range calls {
+		inputs[meta] = len(target.inputResources(meta))
+	}
+	sort.SliceStable(calls, func(i, j int) bool {
+		return inputs[calls[i]] > inputs[calls[j]]
+	})
+	covered := make(map[*Syscall]bool)
+	var progs []*Prog
+	var p *Prog
+	var s *state
+	for _, meta := range calls {
+		if covered[meta] {
+			continue
+		}
+		if p == nil || len(p.Calls) >= ncalls {
+			p = &Prog{
+				Target: target,
+			}
+			s = newState(target, ct)
+			if ct != nil && ct.prefix != nil {
+				for _, c := range ct.prefix.Clone().Calls {
+					s.analyze(c)
+					p.Calls = append(p.Calls, c)
+					covered[c.Meta] = true
+				}
+			}
+			progs = append(progs, p)
+		}
+		for _, c := range r.generateParticularCall(s, meta) {
+			s.analyze(c)
+			p.Calls = append(p.Calls, c)
+			covered[c.Meta] = true
+		}
+	}
+	for _, p := range progs {
+		p.markNonblockingEvents()
+		p.placeSharedRegions()
+		p.enforceScopes()
+		p.enforceNamespaces()
+		p.debugValidate()
+	}
+	return progs
+}
+
+// CheckGeneration is a smoke test for freshly compiled descriptions: it generates a program
+// with each syscall of the target (preceded by calls that create its resources) and serializes
+// the program in text form and for execution. It returns errors of the calls for which generation
+// panics, the program is invalid or fails to serialize, keyed by call name.
+func (target *Target) CheckGeneration(rs rand.Source) map[string]error {
+	errs := make(map[string]error)
+	buf := make([]byte, ExecBufferSize)
+	for _, meta := range target.Syscalls {
+		if err := target.checkGeneration(rs, meta, buf); err != nil {
+			errs[meta.Name] = err
+		}
+	}
+	return errs
+}
+
+func (target *Target) checkGeneration(rs rand.Source, meta *Syscall, buf []byte) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("panic: %v", e)
+		}
+	}()
+	p := &Prog{
+		Target: target,
+	}
+	r := newRand(target, rs)
+	s := newState(target, nil)
+	for _, c := range r.generateParticularCall(s, meta) {
+		s.analyze(c)
+		p.Calls = append(p.Calls, c)
+	}
+	if err := p.validate(); err != nil {
+		return fmt.Errorf("generated invalid program: %v", err)
+	}
+	data := p.Serialize()
+	if _, err := target.Deserialize(data, NonStrict); err != nil {
+		return fmt.Errorf("failed to deserialize program: %v\n%s", err, data)
+	}
+	if _, err := p.SerializeForExec(buf); err != nil {
+		return fmt.Errorf("failed to serialize program for execution: %v\n%s", err, data)
+	}
+	return nil
+}
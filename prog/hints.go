@@ -77,7 +77,7 @@ func (p *Prog) MutateWithHints(callIndex int, comps CompMap, exec func(p *Prog))
 
 func generateHints(compMap CompMap, arg Arg, exec func()) {
 	typ := arg.Type()
-	if typ == nil || typ.Dir() == DirOut {
+	if typ == nil || typ.Dir() == DirOut || typ.MutationWeight() == 0 {
 		return
 	}
 	switch t := typ.(type) {
@@ -88,6 +88,15 @@ func generateHints(compMap CompMap, arg Arg, exec func()) {
 	case *CsumType:
 		// Csum will not pass validation and is always computed.
 		return
+	case *IntType:
+		if t.Kind == IntRingTail {
+			// Tail is fixed up relative to head, arbitrary values would break the ring.
+			return
+		}
+		if t.Kind == IntChildPid {
+			// The value is substituted by executor.
+			return
+		}
 	case *BufferType:
 		if t.Kind == BufferFilename {
 			// This can generate escaping paths and is probably not too useful anyway.
@@ -196,7 +196,7 @@ func TestHintsCheckDataArg(t *testing.T) {
 			res := make(map[string]bool)
 			// Whatever type here. It's just needed to pass the
 			// dataArg.Type().Dir() == DirIn check.
-			typ := &ArrayType{TypeCommon{"", "", 0, DirIn, false, true}, nil, 0, 0, 0}
+			typ := &ArrayType{TypeCommon{ArgDir: DirIn, IsVarlen: true}, nil, 0, 0, 0, "", false}
 			dataArg := MakeDataArg(typ, []byte(test.in))
 			checkDataArg(dataArg, test.comps, func() {
 				res[string(dataArg.Data())] = true
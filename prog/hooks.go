@@ -0,0 +1,83 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"fmt"
+)
+
+// CallHook post-processes arguments of a call right before the program is serialized
+// for execution. It can change any argument values in place (e.g. ConstArg.Val or
+// DataArg.SetData), but must keep the argument tree consistent with the call types.
+type CallHook func(c *Call)
+
+// RegisterCallHook registers hook for all calls with the given name (e.g. "ioctl$KVM_RUN").
+// Several hooks for the same call are invoked in the order of registration.
+// Hooks are invoked by SerializeForExec for every call of the program in order on a copy
+// of the program, the program itself is not changed. Len fields of the call are recalculated
+// after the hooks (so hooks don't need to update them), checksums are calculated during
+// serialization after that.
+// Registration is synchronized with serialization, but hooks registered (unregistered) while
+// a program is being serialized may not (may still) be invoked for it. Hooks themselves can be
+// invoked concurrently for different programs.
+// The returned function unregisters the hook, it can be called several times.
+func (target *Target) RegisterCallHook(callName string, hook CallHook) (unregister func()) {
+	if target.SyscallMap[callName] == nil {
+		panic(fmt.Sprintf("registering hook for unknown call %v", callName))
+	}
+	target.hooksMu.Lock()
+	defer target.hooksMu.Unlock()
+	if target.callHooks == nil {
+		target.callHooks = make(map[string][]*CallHook)
+	}
+	h := &hook
+	target.callHooks[callName] = append(target.callHooks[callName], h)
+	return func() {
+		target.hooksMu.Lock()
+		defer target.hooksMu.Unlock()
+		var hooks []*CallHook
+		for _, h1 := range target.callHooks[callName] {
+			if h1 != h {
+				hooks = append(hooks, h1)
+			}
+		}
+		if len(hooks) == 0 {
+			delete(target.callHooks, callName)
+		} else {
+			target.callHooks[callName] = hooks
+		}
+	}
+}
+
+// runCallHooks returns p with hooks invoked for its calls. If there are hooks for any
+// of the calls, they are invoked on a copy of p.
+func (target *Target) runCallHooks(p *Prog) *Prog {
+	var hooks [][]*CallHook
+	target.hooksMu.RLock()
+	if len(target.callHooks) != 0 {
+		for i, c := range p.Calls {
+			if callHooks := target.callHooks[c.Meta.Name]; len(callHooks) != 0 {
+				if hooks == nil {
+					hooks = make([][]*CallHook, len(p.Calls))
+				}
+				hooks[i] = callHooks
+			}
+		}
+	}
+	target.hooksMu.RUnlock()
+	if hooks == nil {
+		return p
+	}
+	p = p.Clone()
+	for i, c := range p.Calls {
+		if len(hooks[i]) == 0 {
+			continue
+		}
+		for _, hook := range hooks[i] {
+			(*hook)(c)
+		}
+		target.assignSizesCall(c)
+	}
+	return p
+}
@@ -0,0 +1,85 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Note: the test is not parallel because the hooks would be invoked for random programs
+// in other tests. The hooks are unregistered at the end for the same reason.
+func TestCallHooks(t *testing.T) {
+	target, err := GetTarget("test", "64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var order []string
+	unregister1 := target.RegisterCallHook("test$hook", func(c *Call) {
+		order = append(order, "first")
+		s := c.Args[0].(*PointerArg).Res.(*GroupArg)
+		s.Inner[0].(*ConstArg).Val = 0x1234
+		s.Inner[2].(*DataArg).SetData([]byte("hook"))
+	})
+	defer unregister1()
+	unregister2 := target.RegisterCallHook("test$hook", func(c *Call) {
+		order = append(order, "second")
+		s := c.Args[0].(*PointerArg).Res.(*GroupArg)
+		s.Inner[2].(*DataArg).SetData(append(s.Inner[2].(*DataArg).Data(), '2'))
+	})
+	defer unregister2()
+	const (
+		orig = "test()\ntest$hook(&(0x7f0000000000)={0x0, 0x0, \"\"})\n"
+		want = "test()\ntest$hook(&(0x7f0000000000)={0x1234, 0x5, 'hook2'})\n"
+	)
+	p, err := target.Deserialize([]byte(orig), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := p.Serialize()
+	exec := make([]byte, ExecBufferSize)
+	n, err := p.SerializeForExec(exec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+		t.Fatalf("bad hooks invocation order: %v", order)
+	}
+	// Serialization must not change the program.
+	if got := p.Serialize(); !bytes.Equal(got, data) {
+		t.Fatalf("program changed by serialization:\n%s\nwant:\n%s", got, data)
+	}
+	// The hooks don't change want (the values are already set and len is recalculated),
+	// so it must execute exactly as the hooked program.
+	p1, err := target.Deserialize([]byte(want), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec1 := make([]byte, ExecBufferSize)
+	n1, err := p1.SerializeForExec(exec1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(exec[:n], exec1[:n1]) {
+		t.Fatalf("hooked program serialized differently from:\n%v", want)
+	}
+	// Unregistered hooks are not invoked anymore, unregistering twice is fine.
+	unregister1()
+	unregister1()
+	order = nil
+	if _, err := p.SerializeForExec(exec); err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != 1 || order[0] != "second" {
+		t.Fatalf("bad hooks invocation after unregistration: %v", order)
+	}
+	unregister2()
+	order = nil
+	if _, err := p.SerializeForExec(exec); err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != 0 {
+		t.Fatalf("hooks invoked after unregistration: %v", order)
+	}
+}
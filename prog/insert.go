@@ -0,0 +1,84 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"math/rand"
+)
+
+// InsertCall inserts a call to meta into p at the earliest position where all of its
+// dependencies are satisfied and returns index of the inserted call in p.
+// A position satisfies the dependencies if the preceding calls produce resources
+// for all non-optional resource arguments of meta, contain all calls it requires
+// (see Syscall.Requires) and a pending submission if meta is a completion call
+// (see Syscall.Completes). If there is no such position, the call is inserted at the beginning
+// of the program preceded by calls that produce the necessary resources.
+// Arguments of the call (and of the producers) are generated randomly using ct (which can be nil),
+// resources produced by the preceding calls are reused as during generation.
+// If p starts with the prefix of ct, the call is inserted after the prefix.
+func (p *Prog) InsertCall(rs rand.Source, meta *Syscall, ct *ChoiceTable) int {
+	r := newRand(p.Target, rs)
+	npre := ct.prefixLen(p)
+	idx := p.dependenciesIndex(meta, ct, npre)
+	var c *Call
+	if idx < len(p.Calls) {
+		c = p.Calls[idx]
+	}
+	s := analyze(ct, p, c)
+	calls := r.generateParticularCall(s, meta)
+	p.insertBefore(c, calls)
+	p.markNonblockingEvents()
+	p.placeSharedRegions()
+	p.enforceScopes()
+	p.enforceNamespaces()
+	p.debugValidate()
+	return idx + len(calls) - 1
+}
+
+// dependenciesIndex returns the first index not less than npre such that calls before it
+// satisfy dependencies of meta, or npre if there is no such index.
+func (p *Prog) dependenciesIndex(meta *Syscall, ct *ChoiceTable, npre int) int {
+	var inputs []*ResourceDesc
+	for _, res := range p.Target.inputResources(meta) {
+		// Timespecs are generated without any preceding calls.
+		if res != timespecRes {
+			inputs = append(inputs, res)
+		}
+	}
+	s := newState(p.Target, ct)
+	for idx, c := range p.Calls {
+		if idx >= npre && s.satisfies(meta, inputs) {
+			return idx
+		}
+		s.analyze(c)
+	}
+	if s.satisfies(meta, inputs) {
+		return len(p.Calls)
+	}
+	return npre
+}
+
+// satisfies returns true if the analyzed calls contain all calls required by meta,
+// a pending submission for meta if it's a completion call, and produce resources
+// compatible with inputs.
+func (s *state) satisfies(meta *Syscall, inputs []*ResourceDesc) bool {
+	for _, name := range meta.Requires {
+		if !s.calls[s.target.SyscallMap[name]] {
+			return false
+		}
+	}
+	if len(meta.Completes) != 0 && s.target.pendingSubmission(meta, s.submits) == nil {
+		return false
+	}
+nextInput:
+	for _, res := range inputs {
+		for name, all := range s.resources {
+			if len(all) != 0 && s.target.isCompatibleResource(res.Name, name) {
+				continue nextInput
+			}
+		}
+		return false
+	}
+	return true
+}
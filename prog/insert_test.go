@@ -0,0 +1,101 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestInsertCall(t *testing.T) {
+	target, rs, _ := initRandomTargetTest(t, "test", "64")
+	ct := target.BuildChoiceTable(nil, nil)
+	ct.SetResourceReuse(1)
+	tests := []struct {
+		prog string
+		call string
+		idx  int
+	}{
+		// Calls without dependencies are inserted at the beginning.
+		{
+			prog: "test$res2()\ntest$res2()\n",
+			call: "test$res0",
+			idx:  0,
+		},
+		// Calls are inserted right after the first producer of their resources.
+		{
+			prog: "test$res2()\nr0 = test$res0()\ntest$res2()\n",
+			call: "test$res1",
+			idx:  2,
+		},
+		// Calls are inserted right after the calls they require.
+		{
+			prog: "test$res2()\ntest$requires0(0x0)\ntest$requires1(0x0)\ntest$res2()\n",
+			call: "test$requires2",
+			idx:  3,
+		},
+	}
+	for i, test := range tests {
+		p, err := target.Deserialize([]byte(test.prog), Strict)
+		if err != nil {
+			t.Fatalf("test #%v: failed to deserialize: %v", i, err)
+		}
+		idx := p.InsertCall(rs, target.SyscallMap[test.call], ct)
+		if err := p.validate(); err != nil {
+			t.Fatalf("test #%v: invalid program after insertion: %v", i, err)
+		}
+		if idx != test.idx || p.Calls[idx].Meta.Name != test.call {
+			t.Fatalf("test #%v: call is inserted at %v, want %v:\n%s", i, idx, test.idx, p.Serialize())
+		}
+		if want := strings.Count(test.prog, "\n") + 1; len(p.Calls) != want {
+			t.Fatalf("test #%v: got %v calls, want %v:\n%s", i, len(p.Calls), want, p.Serialize())
+		}
+	}
+}
+
+func TestInsertCallProducers(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	ct := target.BuildChoiceTable(nil, nil)
+	meta := target.SyscallMap["test$requires2"]
+	for i := 0; i < iters; i++ {
+		p, err := target.Deserialize([]byte("test$res2()\n"), Strict)
+		if err != nil {
+			t.Fatal(err)
+		}
+		idx := p.InsertCall(rs, meta, ct)
+		if err := p.validate(); err != nil {
+			t.Fatalf("invalid program after insertion: %v", err)
+		}
+		if p.Calls[idx].Meta != meta || idx+2 != len(p.Calls) ||
+			p.Calls[len(p.Calls)-1].Meta.Name != "test$res2" {
+			t.Fatalf("call is inserted at %v:\n%s", idx, p.Serialize())
+		}
+		seen := make(map[string]bool)
+		for _, c := range p.Calls[:idx] {
+			seen[c.Meta.Name] = true
+		}
+		if !seen["test$requires0"] || !seen["test$requires1"] {
+			t.Fatalf("required calls are not inserted:\n%s", p.Serialize())
+		}
+	}
+}
+
+func TestInsertCallRandom(t *testing.T) {
+	target, rs, iters := initTest(t)
+	r := rand.New(rs)
+	ct := target.BuildChoiceTable(nil, nil)
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 10, ct)
+		meta := ct.enabledCalls[r.Intn(len(ct.enabledCalls))]
+		ncalls := len(p.Calls)
+		idx := p.InsertCall(rs, meta, ct)
+		if err := p.validate(); err != nil {
+			t.Fatalf("invalid program after insertion of %v: %v\n%s", meta.Name, err, p.Serialize())
+		}
+		if idx >= len(p.Calls) || p.Calls[idx].Meta != meta || len(p.Calls) <= ncalls {
+			t.Fatalf("%v is inserted at %v into %v calls:\n%s", meta.Name, idx, ncalls, p.Serialize())
+		}
+	}
+}
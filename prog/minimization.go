@@ -5,6 +5,7 @@ package prog
 
 import (
 	"fmt"
+	"sort"
 )
 
 // Minimize minimizes program p into an equivalent program using the equivalence
@@ -12,13 +13,7 @@ import (
 // whether it is equal to the original program or not. If it is equivalent then
 // the simplification attempt is committed and the process continues.
 func Minimize(p0 *Prog, callIndex0 int, crash bool, pred0 func(*Prog, int) bool) (*Prog, int) {
-	pred := func(p *Prog, callIndex int) bool {
-		for _, call := range p.Calls {
-			p.Target.SanitizeCall(call)
-		}
-		p.debugValidate()
-		return pred0(p, callIndex)
-	}
+	pred := sanitizingPred(pred0)
 	name0 := ""
 	if callIndex0 != -1 {
 		if callIndex0 < 0 || callIndex0 >= len(p0.Calls) {
@@ -30,6 +25,11 @@ func Minimize(p0 *Prog, callIndex0 int, crash bool, pred0 func(*Prog, int) bool)
 	// Try to remove all calls except the last one one-by-one.
 	p0, callIndex0 = removeCalls(p0, callIndex0, crash, pred)
 
+	// Try to omit trailing omittable args.
+	for i := 0; i < len(p0.Calls); i++ {
+		p0 = omitArgs(p0, callIndex0, i, pred)
+	}
+
 	// Try to minimize individual args.
 	for i := 0; i < len(p0.Calls); i++ {
 		ctx := &minimizeArgsCtx{
@@ -59,24 +59,217 @@ func Minimize(p0 *Prog, callIndex0 int, crash bool, pred0 func(*Prog, int) bool)
 	return p0, callIndex0
 }
 
+// MinimizePC is a variant of Minimize focused on a target PC (e.g. the crash site).
+// cover is per-call coverage of p0 and relevant says if a PC belongs to the function
+// of the target PC. Calls that don't cover any relevant PCs (except for callIndex0)
+// are removed first: all at once, or one-by-one if the predicate does not hold
+// without all of them. Then the program is minimized with Minimize as usual.
+// If coverage is not available (cover does not match p0 or no call covers relevant PCs),
+// MinimizePC is equivalent to Minimize.
+func MinimizePC(p0 *Prog, callIndex0 int, crash bool, cover [][]uint32, relevant func(pc uint32) bool,
+	pred0 func(*Prog, int) bool) (*Prog, int) {
+	if len(cover) != len(p0.Calls) || relevant == nil {
+		return Minimize(p0, callIndex0, crash, pred0)
+	}
+	var irrelevant []int
+	covered := false
+	for i, pcs := range cover {
+		if coversAny(pcs, relevant) {
+			covered = true
+		} else if i != callIndex0 {
+			irrelevant = append(irrelevant, i)
+		}
+	}
+	if covered && len(irrelevant) != 0 {
+		pred := sanitizingPred(pred0)
+		remove := make(map[int]bool)
+		for _, i := range irrelevant {
+			for _, idx := range callsToRemove(p0, callIndex0, i) {
+				remove[idx] = true
+			}
+		}
+		var indices []int
+		for idx := range remove {
+			indices = append(indices, idx)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(indices)))
+		p, callIndex := p0.Clone(), callIndex0
+		for _, idx := range indices {
+			p.removeCall(idx)
+			if idx < callIndex {
+				callIndex--
+			}
+		}
+		if !breaksRequires(p0, indices) && pred(p, callIndex) {
+			p0, callIndex0 = p, callIndex
+		} else {
+			for i := len(irrelevant) - 1; i >= 0; i-- {
+				p0, callIndex0 = removeCall(p0, callIndex0, irrelevant[i], pred)
+			}
+		}
+	}
+	return Minimize(p0, callIndex0, crash, pred0)
+}
+
+// omitArgs tries to omit as many trailing omittable args of call i as possible.
+func omitArgs(p0 *Prog, callIndex0, i int, pred func(*Prog, int) bool) *Prog {
+	c0 := p0.Calls[i]
+	for n := len(c0.Meta.Args) - c0.Meta.OmittableArgs; n < len(c0.Args); n++ {
+		p := p0.Clone()
+		p.Calls[i].omitArgs(n)
+		if pred(p, callIndex0) {
+			return p
+		}
+	}
+	return p0
+}
+
+func coversAny(pcs []uint32, relevant func(pc uint32) bool) bool {
+	for _, pc := range pcs {
+		if relevant(pc) {
+			return true
+		}
+	}
+	return false
+}
+
+func sanitizingPred(pred0 func(*Prog, int) bool) func(*Prog, int) bool {
+	return func(p *Prog, callIndex int) bool {
+		for _, call := range p.Calls {
+			p.Target.SanitizeCall(call)
+		}
+		p.markNonblockingEvents()
+		p.placeSharedRegions()
+		p.enforceScopes()
+		p.enforceNamespaces()
+		p.debugValidate()
+		return pred0(p, callIndex)
+	}
+}
+
 func removeCalls(p0 *Prog, callIndex0 int, crash bool, pred func(*Prog, int) bool) (*Prog, int) {
 	for i := len(p0.Calls) - 1; i >= 0; i-- {
 		if i == callIndex0 {
 			continue
 		}
-		callIndex := callIndex0
-		if i < callIndex {
+		p0, callIndex0 = removeCall(p0, callIndex0, i, pred)
+	}
+	return p0, callIndex0
+}
+
+// removeCall tries to remove call i from p0 and returns the resulting program and call index
+// if the predicate holds, or p0 and callIndex0 otherwise.
+func removeCall(p0 *Prog, callIndex0, i int, pred func(*Prog, int) bool) (*Prog, int) {
+	remove := callsToRemove(p0, callIndex0, i)
+	if len(remove) == 0 {
+		return p0, callIndex0
+	}
+	callIndex := callIndex0
+	p := p0.Clone()
+	for _, idx := range remove {
+		p.removeCall(idx)
+		if idx < callIndex {
 			callIndex--
 		}
-		p := p0.Clone()
-		p.removeCall(i)
-		if !pred(p, callIndex) {
+	}
+	if !pred(p, callIndex) {
+		return p0, callIndex0
+	}
+	return p, callIndex
+}
+
+// callsToRemove returns indices of calls (in decreasing order) that need to be removed
+// to remove call i from p. If call i acquires references to refcounted resources,
+// the matching releases (the last releases of the resources after call i) are removed as well,
+// so that resources are not released more times than acquired. Similarly, if call i begins
+// a scope (see Syscall.ScopeBegin), the call that ends the scope is removed as well.
+// Returns nil if this would remove call callIndex or a call required by a remaining call
+// (see Syscall.Requires).
+func callsToRemove(p *Prog, callIndex, i int) []int {
+	remove := []int{i}
+	for _, arg := range p.Calls[i].Args {
+		a, ok := arg.(*ResultArg)
+		if !ok || a.Res == nil || a.Type().(*ResourceType).Effect != ResourceAcquire {
 			continue
 		}
-		p0 = p
-		callIndex0 = callIndex
+	nextCall:
+		for j := len(p.Calls) - 1; j > i; j-- {
+			for _, idx := range remove {
+				if idx == j {
+					continue nextCall
+				}
+			}
+			if releasesResource(p.Calls[j], a.Res) {
+				remove = append(remove, j)
+				break
+			}
+		}
 	}
-	return p0, callIndex0
+	if p.Calls[i].Meta.ScopeBegin != "" {
+		end := scopeEndIndex(p, i)
+		for _, idx := range remove {
+			if idx == end {
+				end = -1
+			}
+		}
+		if end != -1 {
+			remove = append(remove, end)
+		}
+	}
+	for _, idx := range remove {
+		if idx == callIndex {
+			return nil
+		}
+	}
+	if breaksRequires(p, remove) {
+		return nil
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(remove)))
+	return remove
+}
+
+// breaksRequires returns true if removing calls with indices remove from p leaves
+// a call without a preceding call that it requires (see Syscall.Requires),
+// or a completion call without a pending submission (see Syscall.Completes).
+// Requirements that are not satisfied in p in the first place are ignored.
+func breaksRequires(p *Prog, remove []int) bool {
+	removed := make(map[int]bool)
+	for _, idx := range remove {
+		removed[idx] = true
+	}
+	before := make(map[*Syscall]bool) // calls preceding the current one in p
+	after := make(map[*Syscall]bool)  // calls preceding the current one after the removal
+	submitsBefore := make(map[*Syscall]int)
+	submitsAfter := make(map[*Syscall]int)
+	for i, c := range p.Calls {
+		if !removed[i] {
+			for _, name := range c.Meta.Requires {
+				req := p.Target.SyscallMap[name]
+				if before[req] && !after[req] {
+					return true
+				}
+			}
+			if p.Target.pendingSubmission(c.Meta, submitsBefore) != nil &&
+				p.Target.pendingSubmission(c.Meta, submitsAfter) == nil {
+				return true
+			}
+			after[c.Meta] = true
+			p.Target.applyCompletion(c.Meta, submitsAfter)
+		}
+		before[c.Meta] = true
+		p.Target.applyCompletion(c.Meta, submitsBefore)
+	}
+	return false
+}
+
+func releasesResource(c *Call, res *ResultArg) bool {
+	for _, arg := range c.Args {
+		if a, ok := arg.(*ResultArg); ok && a.Res == res &&
+			a.Type().(*ResourceType).Effect == ResourceRelease {
+			return true
+		}
+	}
+	return false
 }
 
 type minimizeArgsCtx struct {
@@ -176,6 +369,14 @@ func (typ *ProcType) minimize(ctx *minimizeArgsCtx, arg Arg, path string) bool {
 	return minimizeInt(ctx, arg, path)
 }
 
+func (typ *ConstType) minimize(ctx *minimizeArgsCtx, arg Arg, path string) bool {
+	if !typ.Promotable {
+		return false
+	}
+	// Try to restore the value from descriptions (see ChoiceTable.SetConstPromotion).
+	return minimizeInt(ctx, arg, path)
+}
+
 func minimizeInt(ctx *minimizeArgsCtx, arg Arg, path string) bool {
 	// TODO: try to reset bits in ints
 	// TODO: try to set separate flags
@@ -191,8 +392,22 @@ func minimizeInt(ctx *minimizeArgsCtx, arg Arg, path string) bool {
 	a.Val = def.Val
 	if ctx.pred(ctx.p, ctx.callIndex0) {
 		*ctx.p0 = ctx.p
-	} else {
-		a.Val = v0
+		return false
+	}
+	a.Val = v0
+	if requestMaskField(arg.Type()) != "" {
+		// Bits of request masks request separate output fields, try to drop unnecessary ones.
+		for bit := uint64(1); bit != 0 && bit <= v0; bit <<= 1 {
+			if a.Val&bit == 0 {
+				continue
+			}
+			a.Val &^= bit
+			if ctx.pred(ctx.p, ctx.callIndex0) {
+				*ctx.p0 = ctx.p
+			} else {
+				a.Val |= bit
+			}
+		}
 	}
 	return false
 }
@@ -5,6 +5,7 @@ package prog
 
 import (
 	"math/rand"
+	"strings"
 	"testing"
 )
 
@@ -139,6 +140,248 @@ func TestMinimize(t *testing.T) {
 	}
 }
 
+func TestMinimizeRefcounted(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	const orig = "r0 = test$refcnt0()\n" +
+		"test$refcnt1(r0)\n" +
+		"test$refcnt1(r0)\n" +
+		"test$refcnt3(r0)\n" +
+		"test$refcnt2(r0)\n" +
+		"test$refcnt2(r0)\n"
+	p, err := target.Deserialize([]byte(orig), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, ci := Minimize(p, 3, false, func(p *Prog, callIndex int) bool {
+		if p.Calls[callIndex].Meta.Name != "test$refcnt3" {
+			t.Fatalf("bad call index %v:\n%s", callIndex, p.Serialize())
+		}
+		if p.Calls[callIndex].Args[0].(*ResultArg).Res == nil {
+			return false
+		}
+		// Programs must stay balanced: one release per acquire.
+		refs := 0
+		for _, c := range p.Calls {
+			switch c.Meta.Name {
+			case "test$refcnt1":
+				refs++
+			case "test$refcnt2":
+				refs--
+			}
+		}
+		return refs == 0
+	})
+	const want = "r0 = test$refcnt0()\n" +
+		"test$refcnt3(r0)\n"
+	if res := string(p1.Serialize()); res != want || ci != 1 {
+		t.Fatalf("minimized to (call index %v):\n%v\nwant:\n%v", ci, res, want)
+	}
+}
+
+func TestMinimizeScoped(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	const orig = "test$txn_begin()\n" +
+		"test$txn_begin()\n" +
+		"test$txn_end()\n" +
+		"r0 = test$txn_obj0()\n" +
+		"test$txn_obj1(r0)\n" +
+		"test$txn_end()\n"
+	p, err := target.Deserialize([]byte(orig), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, ci := Minimize(p, 4, false, func(p *Prog, callIndex int) bool {
+		if p.Calls[callIndex].Meta.Name != "test$txn_obj1" {
+			t.Fatalf("bad call index %v:\n%s", callIndex, p.Serialize())
+		}
+		if p.Calls[callIndex].Args[0].(*ResultArg).Res == nil {
+			return false
+		}
+		// Programs must stay balanced: one end per begin.
+		scopes := 0
+		for _, c := range p.Calls {
+			switch c.Meta.Name {
+			case "test$txn_begin":
+				scopes++
+			case "test$txn_end":
+				scopes--
+			}
+		}
+		return scopes == 0
+	})
+	// The inner scope is removed together with its end, the outer scope can't be removed
+	// since the resource is not valid outside of it.
+	const want = "test$txn_begin()\n" +
+		"r0 = test$txn_obj0()\n" +
+		"test$txn_obj1(r0)\n" +
+		"test$txn_end()\n"
+	if res := string(p1.Serialize()); res != want || ci != 2 {
+		t.Fatalf("minimized to (call index %v):\n%v\nwant:\n%v", ci, res, want)
+	}
+}
+
+func TestMinimizeDerived(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	const orig = "r0 = test$res0()\n" +
+		"r1 = test$derive0(r0)\n" +
+		"test$derive1(r1)\n"
+	p, err := target.Deserialize([]byte(orig), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, ci := Minimize(p, 2, false, func(p *Prog, callIndex int) bool {
+		if p.Calls[callIndex].Meta.Name != "test$derive1" {
+			t.Fatalf("bad call index %v:\n%s", callIndex, p.Serialize())
+		}
+		return p.Calls[callIndex].Args[0].(*ResultArg).Res != nil
+	})
+	// The derived resource is replaced with the source resource plus the offset.
+	const want = "r0 = test$res0()\n" +
+		"test$derive1(r0+256)\n"
+	if res := string(p1.Serialize()); res != want || ci != 1 {
+		t.Fatalf("minimized to (call index %v):\n%v\nwant:\n%v", ci, res, want)
+	}
+}
+
+func TestMinimizeRequiredCalls(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	const orig = "test$requires0(0x0)\n" +
+		"test$requires0(0x0)\n" +
+		"test$requires1(0x0)\n" +
+		"test$requires2(0x0)\n"
+	p, err := target.Deserialize([]byte(orig), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, ci := Minimize(p, 3, false, func(p *Prog, callIndex int) bool {
+		return true
+	})
+	// Only the duplicate call can be removed, the rest is required by the last call.
+	const want = "test$requires0(0x0)\n" +
+		"test$requires1(0x0)\n" +
+		"test$requires2(0x0)\n"
+	if res := string(p1.Serialize()); res != want || ci != 2 {
+		t.Fatalf("minimized to (call index %v):\n%v\nwant:\n%v", ci, res, want)
+	}
+}
+
+func TestMinimizeCompletions(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	const orig = "r0 = test$cq_setup()\n" +
+		"test$cq_submit0(r0, 0x0)\n" +
+		"test$cq_submit0(r0, 0x0)\n" +
+		"test$cq_reap(r0, &(0x7f0000000000)={<r1=>0x0, 0x0})\n" +
+		"test$cq_reap(r0, &(0x7f0000000040)={<r2=>0x0, 0x0})\n" +
+		"test$cq_use(r2)\n"
+	p, err := target.Deserialize([]byte(orig), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, ci := Minimize(p, 5, false, func(p *Prog, callIndex int) bool {
+		return p.Calls[callIndex].Args[0].(*ResultArg).Res != nil
+	})
+	// One of the reaps and one of the submissions can be removed, but the remaining reap
+	// still needs a pending submission.
+	const want = "test$cq_submit0(0x0, 0x0)\n" +
+		"test$cq_reap(0x0, &(0x7f0000000040)={<r0=>0x0})\n" +
+		"test$cq_use(r0)\n"
+	if res := string(p1.Serialize()); res != want || ci != 2 {
+		t.Fatalf("minimized to (call index %v):\n%v\nwant:\n%v", ci, res, want)
+	}
+}
+
+func TestMinimizeRequestMask(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	const orig = "test$request_mask1(0xd, &(0x7f0000000000))\n"
+	p, err := target.Deserialize([]byte(orig), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, _ := Minimize(p, 0, false, func(p *Prog, callIndex int) bool {
+		return p.Calls[0].Args[0].(*ConstArg).Val&0x4 != 0
+	})
+	// Bits of the mask that are not needed are dropped one by one.
+	const want = "test$request_mask1(0x4, 0x0)\n"
+	if res := string(p1.Serialize()); res != want {
+		t.Fatalf("minimized to:\n%v\nwant:\n%v", res, want)
+	}
+}
+
+func TestMinimizeOmittableArgs(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	const orig = "test$omittable(&(0x7f0000000000)=\"01\", 0x1, 0x5, 0xffffffffffffffff)\n"
+	p, err := target.Deserialize([]byte(orig), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, _ := Minimize(p, 0, false, func(p *Prog, callIndex int) bool {
+		return len(p.Calls[0].Args) >= 3
+	})
+	const want = "test$omittable(0x0, 0x0, 0x0)\n"
+	if res := string(p1.Serialize()); res != want {
+		t.Fatalf("minimized to:\n%v\nwant:\n%v", res, want)
+	}
+}
+
+func TestMinimizePC(t *testing.T) {
+	target, _, _ := initTest(t)
+	const orig = "mmap(&(0x7f0000000000/0x1000)=nil, 0x1000, 0x3, 0x32, 0xffffffffffffffff, 0x0)\n" +
+		"sched_yield()\n" +
+		"getpid()\n" +
+		"pipe2(&(0x7f0000000000), 0x0)\n"
+	// Only getpid and pipe2 cover the target function.
+	cover := [][]uint32{{0x1}, {0x1, 0x3}, {0x2, 0x3}, {0x2}}
+	relevant := func(pc uint32) bool { return pc == 0x2 }
+	callNames := func(p *Prog) string {
+		var names []string
+		for _, c := range p.Calls {
+			names = append(names, c.Meta.Name)
+		}
+		return strings.Join(names, " ")
+	}
+	tests := []struct {
+		cover  [][]uint32
+		need   []string
+		first  string
+		result string
+	}{
+		// Irrelevant calls are removed together with the first attempt.
+		{cover, []string{"getpid", "pipe2"}, "getpid pipe2", "getpid pipe2"},
+		// Irrelevant calls can't be removed all at once, so they are removed one-by-one first.
+		{cover, []string{"mmap", "pipe2"}, "getpid pipe2", "mmap pipe2"},
+		// No coverage, generic minimization starts from the last call.
+		{nil, []string{"getpid", "pipe2"}, "mmap sched_yield pipe2", "getpid pipe2"},
+	}
+	for ti, test := range tests {
+		p, err := target.Deserialize([]byte(orig), Strict)
+		if err != nil {
+			t.Fatal(err)
+		}
+		first := ""
+		p1, ci := MinimizePC(p, 3, false, test.cover, relevant, func(p *Prog, callIndex int) bool {
+			names := callNames(p)
+			if first == "" {
+				first = names
+			}
+			if p.Calls[callIndex].Meta.Name != "pipe2" {
+				t.Fatalf("#%v: bad call index %v in %v", ti, callIndex, names)
+			}
+			for _, name := range test.need {
+				if !strings.Contains(names, name) {
+					return false
+				}
+			}
+			return true
+		})
+		if first != test.first {
+			t.Errorf("#%v: first minimization attempt %q, want %q", ti, first, test.first)
+		}
+		if res := callNames(p1); res != test.result || p1.Calls[ci].Meta.Name != "pipe2" {
+			t.Errorf("#%v: minimized to %q (call index %v), want %q", ti, res, ci, test.result)
+		}
+	}
+}
+
 func TestMinimizeRandom(t *testing.T) {
 	target, rs, iters := initTest(t)
 	iters /= 10 // Long test.
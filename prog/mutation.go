@@ -19,15 +19,20 @@ func (p *Prog) Mutate(rs rand.Source, ncalls int, ct *ChoiceTable, corpus []*Pro
 		ncalls: ncalls,
 		ct:     ct,
 		corpus: corpus,
+		npre:   ct.prefixLen(p),
 	}
 	for stop, ok := false, false; !stop; stop = ok && r.oneOf(3) {
 		switch {
+		case ct != nil && ct.operands != nil && r.oneOf(5):
+			ok = ctx.substituteOperands()
 		case r.oneOf(5):
 			// Not all calls have anything squashable,
 			// so this has lower priority in reality.
 			ok = ctx.squashAny()
 		case r.nOutOf(1, 100):
 			ok = ctx.splice()
+		case r.oneOf(20):
+			ok = ctx.swapCalls()
 		case r.nOutOf(20, 31):
 			ok = ctx.insertCall()
 		case r.nOutOf(10, 11):
@@ -39,6 +44,10 @@ func (p *Prog) Mutate(rs rand.Source, ncalls int, ct *ChoiceTable, corpus []*Pro
 	for _, c := range p.Calls {
 		p.Target.SanitizeCall(c)
 	}
+	p.markNonblockingEvents()
+	p.placeSharedRegions()
+	p.enforceScopes()
+	p.enforceNamespaces()
 	p.debugValidate()
 }
 
@@ -48,18 +57,19 @@ type mutator struct {
 	ncalls int
 	ct     *ChoiceTable
 	corpus []*Prog
+	npre   int // number of leading calls that belong to the prefix and must be preserved
 }
 
 func (ctx *mutator) splice() bool {
 	p, r := ctx.p, ctx.r
-	if len(ctx.corpus) == 0 || len(p.Calls) == 0 {
+	if len(ctx.corpus) == 0 || len(p.Calls) <= ctx.npre {
 		return false
 	}
 	p0 := ctx.corpus[r.Intn(len(ctx.corpus))]
 	p0c := p0.Clone()
-	idx := r.Intn(len(p.Calls))
+	idx := ctx.npre + r.Intn(len(p.Calls)-ctx.npre)
 	p.Calls = append(p.Calls[:idx], append(p0c.Calls, p.Calls[idx:]...)...)
-	for i := len(p.Calls) - 1; i >= ctx.ncalls; i-- {
+	for i := len(p.Calls) - 1; i >= ctx.ncalls && i >= ctx.npre; i-- {
 		p.removeCall(i)
 	}
 	return true
@@ -67,7 +77,8 @@ func (ctx *mutator) splice() bool {
 
 func (ctx *mutator) squashAny() bool {
 	p, r := ctx.p, ctx.r
-	complexPtrs := p.complexPtrs()
+	// Pointers of the prefix calls are not squashed.
+	complexPtrs := (&Prog{Target: p.Target, Calls: p.Calls[ctx.npre:]}).complexPtrs()
 	if len(complexPtrs) == 0 {
 		return false
 	}
@@ -109,7 +120,7 @@ func (ctx *mutator) insertCall() bool {
 	if len(p.Calls) >= ctx.ncalls {
 		return false
 	}
-	idx := r.biasedRand(len(p.Calls)+1, 5)
+	idx := ctx.npre + r.biasedRand(len(p.Calls)-ctx.npre+1, 5)
 	var c *Call
 	if idx < len(p.Calls) {
 		c = p.Calls[idx]
@@ -122,20 +133,82 @@ func (ctx *mutator) insertCall() bool {
 
 func (ctx *mutator) removeCall() bool {
 	p, r := ctx.p, ctx.r
-	if len(p.Calls) == 0 {
+	if len(p.Calls) <= ctx.npre {
+		return false
+	}
+	idx := ctx.npre + r.Intn(len(p.Calls)-ctx.npre)
+	if breaksRequires(p, []int{idx}) {
 		return false
 	}
-	idx := r.Intn(len(p.Calls))
 	p.removeCall(idx)
 	return true
 }
 
+// swapCalls swaps a random pair of adjacent calls if they don't depend on each other
+// (see dependsOn), otherwise it leaves the program intact.
+func (ctx *mutator) swapCalls() bool {
+	p, r := ctx.p, ctx.r
+	if len(p.Calls)-ctx.npre < 2 {
+		return false
+	}
+	idx := ctx.npre + r.Intn(len(p.Calls)-ctx.npre-1)
+	if dependsOn(p.Calls[idx+1], p.Calls[idx]) {
+		return false
+	}
+	p.Calls[idx], p.Calls[idx+1] = p.Calls[idx+1], p.Calls[idx]
+	return true
+}
+
+// dependsOn returns true if call c must follow call prev: c uses a resource produced by prev,
+// both calls use the same resource and one of them changes its lifetime or signals/waits on it,
+// or c requires or completes prev (see Syscall.Requires and Syscall.Completes).
+func dependsOn(c, prev *Call) bool {
+	for _, name := range append(append([]string{}, c.Meta.Requires...), c.Meta.Completes...) {
+		if name == prev.Meta.Name {
+			return true
+		}
+	}
+	produced := make(map[*ResultArg]bool)
+	uses := make(map[*ResultArg]bool)
+	orderedUses := make(map[*ResultArg]bool)
+	ForeachArg(prev, func(arg Arg, _ *ArgCtx) {
+		a, ok := arg.(*ResultArg)
+		if !ok {
+			return
+		}
+		produced[a] = true
+		if a.Res != nil {
+			uses[a.Res] = true
+			orderedUses[a.Res] = orderedUses[a.Res] || orderedUse(a)
+		}
+	})
+	dep := false
+	ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+		if a, ok := arg.(*ResultArg); ok && a.Res != nil &&
+			(produced[a.Res] || orderedUses[a.Res] || uses[a.Res] && orderedUse(a)) {
+			dep = true
+		}
+	})
+	return dep
+}
+
+// orderedUse returns true if arg is a resource use that can't be reordered with other uses
+// of the same resource.
+func orderedUse(arg *ResultArg) bool {
+	typ := arg.Type().(*ResourceType)
+	return typ.Effect != ResourceUse || typ.Event != EventNone
+}
+
 func (ctx *mutator) mutateArg() bool {
 	p, r := ctx.p, ctx.r
-	if len(p.Calls) == 0 {
+	if len(p.Calls) <= ctx.npre {
 		return false
 	}
-	c := p.Calls[r.Intn(len(p.Calls))]
+	c := p.Calls[ctx.npre+r.Intn(len(p.Calls)-ctx.npre)]
+	if c.Meta.OmittableArgs != 0 && (len(c.Args) == 0 || r.oneOf(10)) {
+		ctx.mutateArgCount(c)
+		return true
+	}
 	if len(c.Args) == 0 {
 		return false
 	}
@@ -144,11 +217,14 @@ func (ctx *mutator) mutateArg() bool {
 	for stop, ok := false, false; !stop; stop = ok && r.oneOf(3) {
 		ok = true
 		ma := &mutationArgs{target: p.Target}
+		if ctx.ct != nil && ctx.ct.constPromote != 0 {
+			ma.promoteConsts = r.Float64() < ctx.ct.constPromote
+		}
 		ForeachArg(c, ma.collectArg)
 		if len(ma.args) == 0 {
 			return false
 		}
-		idx := r.Intn(len(ma.args))
+		idx := ma.chooseArg(r)
 		arg, ctx := ma.args[idx], ma.ctxes[idx]
 		calls, ok1 := p.Target.mutateArg(r, s, arg, ctx, &updateSizes)
 		if !ok1 {
@@ -158,12 +234,36 @@ func (ctx *mutator) mutateArg() bool {
 		p.insertBefore(c, calls)
 		if updateSizes {
 			p.Target.assignSizesCall(c)
+		} else {
+			// Versions and array indices must match the mutated layout even if sizes are preserved.
+			assignUnionVersions(c.Args)
+			assignArrayIndices(c.Args, nil)
 		}
 		p.Target.SanitizeCall(c)
 	}
 	return true
 }
 
+// mutateArgCount changes the number of omitted trailing arguments of call c.
+func (ctx *mutator) mutateArgCount(c *Call) {
+	p, r := ctx.p, ctx.r
+	min := len(c.Meta.Args) - c.Meta.OmittableArgs
+	n := min + r.Intn(c.Meta.OmittableArgs)
+	if n >= len(c.Args) {
+		n++
+	}
+	if n < len(c.Args) {
+		c.omitArgs(n)
+	} else {
+		s := analyze(ctx.ct, p, c)
+		args, calls := r.generateArgs(s, c.Meta.Args[len(c.Args):n])
+		c.Args = append(c.Args, args...)
+		p.insertBefore(c, calls)
+	}
+	p.Target.assignSizesCall(c)
+	p.Target.SanitizeCall(c)
+}
+
 func (target *Target) mutateArg(r *randGen, s *state, arg Arg, ctx ArgCtx, updateSizes *bool) ([]*Call, bool) {
 	var baseSize uint64
 	if ctx.Base != nil {
@@ -211,10 +311,51 @@ func mutateInt(r *randGen, s *state, arg Arg) (calls []*Call, retry, preserve bo
 }
 
 func (t *IntType) mutate(r *randGen, s *state, arg Arg, ctx ArgCtx) (calls []*Call, retry, preserve bool) {
+	if t.Kind == IntRelPtr {
+		// Offsets are recomputed from the layout after mutation,
+		// preserve small adjustments to point into the middle of fields.
+		a := arg.(*ConstArg)
+		if r.bin() {
+			a.Val += uint64(r.Intn(4)) + 1
+		} else {
+			a.Val -= uint64(r.Intn(4)) + 1
+		}
+		preserve = true
+		return
+	}
+	if t.Cookie != "" {
+		// Small adjustments can collide with cookies of other requests.
+		return regenerate(r, s, arg)
+	}
+	if t.Kind == IntPow2 {
+		// Move to an adjacent power of 2 within the range.
+		a := arg.(*ConstArg)
+		down := a.Val > t.RangeBegin && a.Val <= t.RangeEnd && (a.Val >= t.RangeEnd || r.bin())
+		up := a.Val >= t.RangeBegin && a.Val < t.RangeEnd && !down
+		if a.Val&(a.Val-1) != 0 || !down && !up {
+			return regenerate(r, s, arg)
+		}
+		if down {
+			a.Val >>= 1
+		} else {
+			a.Val <<= 1
+		}
+		return
+	}
+	if len(t.Buckets) != 0 || t.Kind == IntFuncPtr || t.Kind == IntFlagIndex {
+		// Small adjustments can move the value out of all buckets,
+		// make a function pointer point into the middle of a stub
+		// or turn a flag index into an index of a non-existent flag.
+		return regenerate(r, s, arg)
+	}
 	return mutateInt(r, s, arg)
 }
 
 func (t *FlagsType) mutate(r *randGen, s *state, arg Arg, ctx ArgCtx) (calls []*Call, retry, preserve bool) {
+	if v, ok := s.exhaustiveValue(t); ok {
+		arg.(*ConstArg).Val = v
+		return
+	}
 	return mutateInt(r, s, arg)
 }
 
@@ -265,6 +406,13 @@ func (t *BufferType) mutate(r *randGen, s *state, arg Arg, ctx ArgCtx) (calls []
 	case BufferText:
 		data := append([]byte{}, a.Data()...)
 		a.data = r.mutateText(t.Text, data)
+	case BufferSerialized:
+		if r.oneOf(3) {
+			data := append([]byte{}, a.Data()...)
+			a.data = mutateData(r, data, 0, maxBlobLen)
+		} else {
+			a.data = r.generateSerialized(s, t.Elem)
+		}
 	default:
 		panic("unknown buffer kind")
 	}
@@ -288,6 +436,11 @@ func (t *ArrayType) mutate(r *randGen, s *state, arg Arg, ctx ArgCtx) (calls []*
 			count = r.randRange(t.RangeBegin, t.RangeEnd)
 		}
 	}
+	if s.useShape(r) {
+		if n, ok := s.ct.shapes.arrayLen(r, t, len(a.Inner)); ok {
+			count = n
+		}
+	}
 	if count > uint64(len(a.Inner)) {
 		for count > uint64(len(a.Inner)) {
 			newArg, newCalls := r.generateArg(s, t.Type)
@@ -351,32 +504,87 @@ func (t *UnionType) mutate(r *randGen, s *state, arg Arg, ctx ArgCtx) (calls []*
 		if current == -1 {
 			panic("can't find current option in union")
 		}
-		newIdx := r.Intn(len(t.Fields) - 1)
-		if newIdx >= current {
-			newIdx++
+		idx := r.unionOption(t, current)
+		if s.useShape(r) {
+			if i, ok := s.ct.shapes.unionOption(r, t, current); ok {
+				idx = i
+			}
 		}
-		optType := t.Fields[newIdx]
+		optType := t.Fields[idx]
 		removeArg(a.Option)
 		var newOpt Arg
 		newOpt, calls = r.generateArg(s, optType)
+		if t.Overlay && r.bin() {
+			// Keep the backing integer and look at it through the new view.
+			setOverlayValue(newOpt.(*GroupArg), overlayValue(a.Option.(*GroupArg)))
+		}
 		replaceArg(arg, MakeUnionArg(t, newOpt))
 	}
 	return
 }
 
+// overlayValue returns the backing integer of a view of an overlay union.
+func overlayValue(view *GroupArg) uint64 {
+	var val uint64
+	for _, arg := range view.Inner {
+		if a, ok := arg.(*ConstArg); ok {
+			typ := arg.Type()
+			val |= (a.Val & (1<<typ.BitfieldLength() - 1)) << typ.BitfieldOffset()
+		}
+	}
+	return val
+}
+
+// setOverlayValue sets integer fields of a view of an overlay union to the bits of the backing integer val.
+// Constant, length and proc fields are left intact as their values are fixed by descriptions.
+func setOverlayValue(view *GroupArg, val uint64) {
+	for _, arg := range view.Inner {
+		switch arg.Type().(type) {
+		case *IntType, *FlagsType:
+			typ := arg.Type()
+			arg.(*ConstArg).Val = val >> typ.BitfieldOffset() & (1<<typ.BitfieldLength() - 1)
+		}
+	}
+}
+
 func (t *CsumType) mutate(r *randGen, s *state, arg Arg, ctx ArgCtx) (calls []*Call, retry, preserve bool) {
 	panic("CsumType can't be mutated")
 }
 
 func (t *ConstType) mutate(r *randGen, s *state, arg Arg, ctx ArgCtx) (calls []*Call, retry, preserve bool) {
-	panic("ConstType can't be mutated")
+	if !t.Promotable {
+		panic("ConstType can't be mutated")
+	}
+	a := arg.(*ConstArg)
+	if a.Val != t.Val && r.bin() {
+		a.Val = t.Val
+		return
+	}
+	// Only the low bits of the field reach the kernel, so the new value must differ in them.
+	mask := ^uint64(0)
+	width := t.BitfieldLength()
+	if width == 0 {
+		width = t.Size() * 8
+	}
+	if width < 64 {
+		mask = 1<<width - 1
+	}
+	for {
+		if v := r.randInt(); v&mask != t.Val&mask {
+			a.Val = v
+			return
+		}
+	}
 }
 
 type mutationArgs struct {
 	target        *Target
 	args          []Arg
 	ctxes         []ArgCtx
+	weights       []uint64
+	weighted      bool // some args have non-default mutation weight
 	ignoreSpecial bool
+	promoteConsts bool // collect promotable consts (see ChoiceTable.SetConstPromotion)
 }
 
 func (ma *mutationArgs) collectArg(arg Arg, ctx *ArgCtx) {
@@ -402,7 +610,19 @@ func (ma *mutationArgs) collectArg(arg Arg, ctx *ArgCtx) {
 	case *CsumType:
 		return // Checksum is updated when the checksummed data changes.
 	case *ConstType:
-		return // Well, this is const.
+		if !ma.promoteConsts || !typ.Promotable {
+			return // Well, this is const.
+		}
+	case *IntType:
+		if typ.Kind == IntChildPid {
+			return // Executor substitutes pid of the child process.
+		}
+		if typ.Kind == IntArrayIndex {
+			return // Index is updated when the array changes.
+		}
+		if typ.Kind == IntParity {
+			return // Parity is computed during serialization.
+		}
 	case *BufferType:
 		if typ.Kind == BufferString && len(typ.Values) == 1 {
 			return // string const
@@ -417,8 +637,36 @@ func (ma *mutationArgs) collectArg(arg Arg, ctx *ArgCtx) {
 	if typ == nil || typ.Dir() == DirOut || !typ.Varlen() && typ.Size() == 0 {
 		return
 	}
+	weight := typ.MutationWeight()
+	if weight == 0 {
+		return
+	}
+	if weight != 1 {
+		ma.weighted = true
+	}
 	ma.args = append(ma.args, arg)
 	ma.ctxes = append(ma.ctxes, *ctx)
+	ma.weights = append(ma.weights, weight)
+}
+
+// chooseArg returns index of the arg to mutate.
+// Args are chosen uniformly unless descriptions specify mutation weights for some of them.
+func (ma *mutationArgs) chooseArg(r *randGen) int {
+	if !ma.weighted {
+		return r.Intn(len(ma.args))
+	}
+	var sum uint64
+	for _, w := range ma.weights {
+		sum += w
+	}
+	x := uint64(r.Int63n(int64(sum)))
+	for i, w := range ma.weights {
+		if x < w {
+			return i
+		}
+		x -= w
+	}
+	panic("bad mutation weights")
 }
 
 func mutateData(r *randGen, data []byte, minLen, maxLen uint64) []byte {
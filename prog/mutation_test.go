@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"fmt"
 	"math/rand"
+	"strings"
 	"sync"
 	"testing"
 )
@@ -237,3 +238,324 @@ func linuxAmd64ChoiceTable(target *Target) *ChoiceTable {
 	})
 	return linuxCT
 }
+
+func TestMutateTaggedRecords(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	meta := target.SyscallMap["test$tagged"]
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{meta: true})
+	check := func(p *Prog) {
+		for _, c := range p.Calls {
+			ptr := c.Args[1].(*PointerArg)
+			if ptr.Res == nil || target.isAnyPtr(ptr.Type()) {
+				// Squashed records are not checked.
+				continue
+			}
+			records := ptr.Res.(*GroupArg).Inner
+			if count := c.Args[0].(*ConstArg).Val; count != uint64(len(records)) {
+				t.Fatalf("record count %v, want %v:\n%s", count, len(records), p.Serialize())
+			}
+			for _, arg := range records {
+				union := arg.(*UnionArg)
+				tag := union.Option.(*GroupArg).Inner[0].(*ConstArg).Val
+				for i, opt := range union.Type().(*UnionType).Fields {
+					if opt.FieldName() == union.Option.Type().FieldName() && tag != uint64(i+1) {
+						t.Fatalf("option %v has tag %v, want %v:\n%s",
+							opt.FieldName(), tag, i+1, p.Serialize())
+					}
+				}
+			}
+		}
+	}
+	r := newRand(target, rs)
+	for i := 0; i < iters/10; i++ {
+		p := &Prog{Target: target}
+		p.Calls = r.generateParticularCall(newState(target, ct), meta)
+		check(p)
+		for j := 0; j < 10; j++ {
+			p.Mutate(rs, 3, ct, nil)
+			check(p)
+		}
+	}
+}
+
+func TestMutateSockaddr(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	meta := target.SyscallMap["test$sockaddr"]
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{meta: true})
+	families := map[string]uint64{"un": 1, "in": 2, "in6": 10}
+	sizes := map[string]uint64{"un": 110, "in": 16, "in6": 28}
+	check := func(p *Prog, checkSize bool) {
+		for _, c := range p.Calls {
+			ptr := c.Args[0].(*PointerArg)
+			if ptr.Res == nil || target.isAnyPtr(ptr.Type()) {
+				continue
+			}
+			union := ptr.Res.(*UnionArg)
+			name := union.Option.Type().FieldName()
+			family := union.Option.(*GroupArg).Inner[0].(*ConstArg).Val
+			if family != families[name] {
+				t.Fatalf("option %v has family %v, want %v:\n%s", name, family, families[name], p.Serialize())
+			}
+			// Mutation can intentionally set wrong lengths.
+			if size := c.Args[1].(*ConstArg).Val; checkSize && size != sizes[name] {
+				t.Fatalf("option %v has size %v, want %v:\n%s", name, size, sizes[name], p.Serialize())
+			}
+		}
+	}
+	r := newRand(target, rs)
+	for i := 0; i < iters/10; i++ {
+		p := &Prog{Target: target}
+		p.Calls = r.generateParticularCall(newState(target, ct), meta)
+		check(p, true)
+		for j := 0; j < 10; j++ {
+			p.Mutate(rs, 3, ct, nil)
+			check(p, false)
+		}
+	}
+}
+
+func TestMutateOverlay(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	p0, err := target.Deserialize([]byte("test$overlay(&(0x7f0000000000)=@split={0x5, 0xabc, 0x1234})"), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newRand(target, rs)
+	reinterpreted := false
+	for i := 0; i < iters && !reinterpreted; i++ {
+		p := p0.Clone()
+		union := p.Calls[0].Args[0].(*PointerArg).Res.(*UnionArg)
+		union.Type().mutate(r, newState(target, nil), union, ArgCtx{})
+		if union.Option.Type().FieldName() != "mode1" {
+			continue
+		}
+		fields := union.Option.(*GroupArg).Inner
+		if mode := fields[0].(*ConstArg).Val; mode != 1 {
+			t.Fatalf("mode1 view has mode %v, want 1", mode)
+		}
+		// The backing integer of the split view is 0x1234abc5.
+		reinterpreted = fields[1].(*ConstArg).Val == 0x1234abc
+	}
+	if !reinterpreted {
+		t.Fatalf("mutation never reinterpreted the backing integer through another view")
+	}
+}
+
+func TestMutateConstPromotion(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	meta := target.SyscallMap["test$promote"]
+	p0, err := target.Deserialize([]byte("test$promote(0x1234, &(0x7f0000000000)={0xabcd, 0x1, 0x0})"), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// values returns values of the promotable arg, the promotable field and the not promotable field.
+	values := func(p *Prog) (uint64, uint64, uint64) {
+		c := p.Calls[0]
+		hdr, ok := c.Args[1].(*PointerArg).Res.(*GroupArg)
+		if !ok {
+			return c.Args[0].(*ConstArg).Val, 0xabcd, 1
+		}
+		return c.Args[0].(*ConstArg).Val, hdr.Inner[0].(*ConstArg).Val, hdr.Inner[1].(*ConstArg).Val
+	}
+	r := newRand(target, rs)
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{meta: true})
+	for i := 0; i < iters; i++ {
+		p := p0.Clone()
+		ctx := &mutator{p: p, r: r, ct: ct}
+		ctx.mutateArg()
+		if arg, magic, version := values(p); arg != 0x1234 || magic != 0xabcd || version != 1 {
+			t.Fatalf("consts are mutated by default:\n%s", p.Serialize())
+		}
+	}
+	ct.SetConstPromotion(1)
+	promotedArg, promotedField, restored := false, false, false
+	for i := 0; i < iters && !(promotedArg && promotedField && restored); i++ {
+		p := p0.Clone()
+		ctx := &mutator{p: p, r: r, ct: ct}
+		for j := 0; j < 10; j++ {
+			prev, _, _ := values(p)
+			ctx.mutateArg()
+			arg, magic, version := values(p)
+			if version != 1 {
+				t.Fatalf("not promotable const is mutated:\n%s", p.Serialize())
+			}
+			promotedArg = promotedArg || arg != 0x1234
+			promotedField = promotedField || magic&0xffff != 0xabcd
+			restored = restored || prev != 0x1234 && arg == 0x1234
+		}
+	}
+	if !promotedArg || !promotedField || !restored {
+		t.Fatalf("promoted arg: %v, promoted field: %v, restored: %v", promotedArg, promotedField, restored)
+	}
+}
+
+func TestMutationWeights(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	p, err := target.Deserialize([]byte("test$mutate_weight(0x0, &(0x7f0000000000)={0x0, 0x0, 0x0})"), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := p.Calls[0]
+	ma := &mutationArgs{target: target}
+	ForeachArg(c, ma.collectArg)
+	counts := make(map[string]int)
+	for _, arg := range ma.args {
+		counts[arg.Type().FieldName()] = 0
+	}
+	for _, name := range []string{"a0", "pinned"} {
+		if _, ok := counts[name]; ok {
+			t.Fatalf("pinned arg %v is collected for mutation", name)
+		}
+	}
+	r := newRand(target, rs)
+	for i := 0; i < iters*10; i++ {
+		counts[ma.args[ma.chooseArg(r)].Type().FieldName()]++
+	}
+	if counts["hot"] <= counts["cold"] || counts["hot"] <= counts["a1"] {
+		t.Fatalf("hot field is not preferred for mutation: %v", counts)
+	}
+	p.MutateWithHints(0, CompMap{0: {42: true}}, func(p1 *Prog) {
+		data := string(p1.Serialize())
+		if !strings.Contains(data, "test$mutate_weight(0x0, &(0x7f0000000000)={0x0,") {
+			t.Fatalf("pinned arg is mutated with hints:\n%s", data)
+		}
+	})
+}
+
+func TestSubstituteOperands(t *testing.T) {
+	target, rs, _ := initRandomTargetTest(t, "test", "64")
+	ops := NewCompOperands()
+	ops.Add(0xabcd, 2)
+	ops.Add(0x1122334455667788, 8)
+	ops.Add(0x42, 3) // ignored
+	if ops.Len() != 2 {
+		t.Fatalf("got %v operands, want 2", ops.Len())
+	}
+	ct := target.BuildChoiceTable(nil, nil)
+	ct.SetCompOperands(ops)
+	p0, err := target.Deserialize([]byte(`test$int(0x1, 0x2, 0x3, 0x4, 0x5)
+test$blob0(&(0x7f0000000000)="00000000000000000000")
+`), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newRand(target, rs)
+	seen := make(map[string]bool)
+	// Not using iters, we need enough iterations to see all possible substitutions.
+	for i := 0; i < 1000; i++ {
+		p := p0.Clone()
+		ctx := &mutator{p: p, r: r, ncalls: 10, ct: ct}
+		if !ctx.substituteOperands() {
+			t.Fatalf("substitution failed")
+		}
+		seen[string(p.Serialize())] = true
+	}
+	// intptr and int64 arguments can get the 8-byte operand, int16 the 2-byte one and the blob both.
+	want := []string{
+		"test$int(0x1122334455667788, 0x2, 0x3, 0x4, 0x5)",
+		"test$int(0x1, 0x2, 0xabcd, 0x4, 0x5)",
+		"test$int(0x1, 0x2, 0x3, 0x4, 0x1122334455667788)",
+		`test$blob0(&(0x7f0000000000)="0000cdab000000000000")`,
+		`test$blob0(&(0x7f0000000000)="00000000abcd00000000")`,
+		`test$blob0(&(0x7f0000000000)="00887766554433221100")`,
+	}
+	for _, w := range want {
+		found := false
+		for p := range seen {
+			if strings.Contains(p, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("no program contains %v", w)
+		}
+	}
+	for p := range seen {
+		if strings.Contains(p, "0x42") {
+			t.Fatalf("program uses operand of unsupported size:\n%v", p)
+		}
+	}
+}
+
+func TestSwapCalls(t *testing.T) {
+	target, rs, _ := initRandomTargetTest(t, "test", "64")
+	tests := []struct {
+		prog string
+		swap bool
+	}{
+		{"test$res0()\ntest$res2()\n", true},
+		{"r0 = test$res0()\ntest$res1(r0)\n", false},
+		{"r0 = test$res0()\ntest$res1(r0)\ntest$res1(r0)\n", true},
+		{"r0 = test$res0()\ntest$res1(r0)\ntest$res4(r0)\n", false},
+		{"r0 = test$event0(0x0, 0x0)\ntest$event1(r0, 0x0)\ntest$event2(r0)\n", false},
+		{"test$requires0(0x0)\ntest$requires1(0x0)\n", false},
+		{"test$cq_submit0(0x0, 0x0)\ntest$cq_reap(0x0, 0x0)\n", false},
+	}
+	r := newRand(target, rs)
+	for i, test := range tests {
+		p, err := target.Deserialize([]byte(test.prog), Strict)
+		if err != nil {
+			t.Fatalf("#%v: failed to deserialize: %v", i, err)
+		}
+		// Swap only the last two calls.
+		ctx := &mutator{p: p, r: r, ncalls: 10, npre: len(p.Calls) - 2}
+		calls := append([]*Call{}, p.Calls...)
+		ok := ctx.swapCalls()
+		if ok != test.swap {
+			t.Fatalf("#%v: swapped %v, want %v:\n%s", i, ok, test.swap, test.prog)
+		}
+		if !ok {
+			if got := string(p.Serialize()); got != test.prog {
+				t.Fatalf("#%v: program changed without swap:\n%s", i, got)
+			}
+			continue
+		}
+		n := len(calls)
+		if p.Calls[n-2] != calls[n-1] || p.Calls[n-1] != calls[n-2] {
+			t.Fatalf("#%v: calls are not swapped:\n%s", i, p.Serialize())
+		}
+		if err := p.validate(); err != nil {
+			t.Fatalf("#%v: swapped program is invalid: %v\n%s", i, err, p.Serialize())
+		}
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	prefix, err := target.Deserialize([]byte(`r0 = mutate5(&(0x7f0000000000)='./file0\x00', 0x0)
+mutate6(r0, &(0x7f0000001000)="00", 0x1)
+`), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct := target.BuildChoiceTable(nil, nil)
+	ct.SetPrefix(prefix)
+	want := string(prefix.Serialize())
+	checkPrefix := func(p *Prog) {
+		if data := p.Serialize(); !strings.HasPrefix(string(data), want) {
+			t.Fatalf("program does not start with the prefix:\n%s", data)
+		}
+	}
+	used := false
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 10, ct)
+		checkPrefix(p)
+		for j := 0; j < 10; j++ {
+			p.Mutate(rs, 10, ct, nil)
+			checkPrefix(p)
+		}
+		for use := range p.Calls[0].Ret.uses {
+			for _, c := range p.Calls[len(prefix.Calls):] {
+				ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+					if arg == use {
+						used = true
+					}
+				})
+			}
+		}
+	}
+	if !used {
+		t.Fatalf("resource created by the prefix is never used")
+	}
+}
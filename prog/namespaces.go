@@ -0,0 +1,65 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+// Namespaced resources (see ResourceDesc.Namespace) are valid only in the namespace context they were
+// created in, e.g. the same pid means different processes in different pid namespaces.
+// Calls that switch the namespace (see Syscall.SetNamespace, e.g. setns or unshare) begin a new context
+// of the namespace, resources of the namespace created before the switch are not used after it
+// (resources created by the switching call itself belong to the new context). Every switch begins
+// a new context, switching back to a previous context is not tracked. Resources created before
+// the first switch belong to the initial (global) context, and resources without a namespace are
+// valid in all contexts. Generation doesn't use resources of other contexts, and enforceNamespaces
+// replaces such uses with default values after programs are changed (e.g. when a switching call
+// is inserted between creation and use of a resource).
+
+// switchNamespace begins a new context of namespace name in s: resources of the namespace
+// created in the previous contexts are not used anymore.
+func (s *state) switchNamespace(name string) {
+	for kind, all := range s.resources {
+		var keep []*ResultArg
+		for _, res := range all {
+			if res.Type().(*ResourceType).Desc.Namespace != name {
+				keep = append(keep, res)
+			}
+		}
+		if len(keep) == 0 {
+			// Resource lists in s.resources are expected to be non-empty.
+			delete(s.resources, kind)
+		} else {
+			s.resources[kind] = keep
+		}
+	}
+}
+
+// enforceNamespaces replaces uses of namespaced resources in other contexts with default values.
+func (p *Prog) enforceNamespaces() {
+	contexts := make(map[string]int)    // current context of each namespace
+	created := make(map[*ResultArg]int) // context each namespaced resource was created in
+	for _, c := range p.Calls {
+		ns := c.Meta.SetNamespace
+		ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+			typ, ok := arg.Type().(*ResourceType)
+			if !ok {
+				return
+			}
+			a := arg.(*ResultArg)
+			if a.Res != nil {
+				name := a.Res.Type().(*ResourceType).Desc.Namespace
+				if name != "" && created[a.Res] != contexts[name] {
+					replaceResultArg(a, MakeResultArg(typ, nil, typ.Default()))
+				}
+			}
+			if name := typ.Desc.Namespace; name != "" && typ.Dir() != DirIn {
+				created[a] = contexts[name]
+				if name == ns {
+					created[a]++
+				}
+			}
+		})
+		if ns != "" {
+			contexts[ns]++
+		}
+	}
+}
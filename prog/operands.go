@@ -0,0 +1,122 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"encoding/binary"
+)
+
+// CompOperands is a set of comparison operands observed during execution (e.g. in KCOV_CMP traces),
+// each operand is recorded together with the size of the comparison in bytes (1, 2, 4 or 8).
+// Unlike CompMap used by MutateWithHints, the operands are not tied to the values
+// they were compared with, so the mutator substitutes them into any integer argument
+// of the same size or into any buffer argument (see ChoiceTable.SetCompOperands).
+type CompOperands struct {
+	vals [9][]uint64 // operands indexed by size
+	seen map[compOperand]bool
+}
+
+type compOperand struct {
+	size uint64
+	val  uint64
+}
+
+func NewCompOperands() *CompOperands {
+	return &CompOperands{
+		seen: make(map[compOperand]bool),
+	}
+}
+
+// Add adds operand val of a comparison of size bytes, operands of other sizes are ignored.
+func (ops *CompOperands) Add(val, size uint64) {
+	switch size {
+	case 1, 2, 4, 8:
+	default:
+		return
+	}
+	val &= 1<<(size*8) - 1 // 1<<64 is 0, so for size 8 all bits are preserved
+	op := compOperand{size, val}
+	if ops.seen[op] {
+		return
+	}
+	ops.seen[op] = true
+	ops.vals[size] = append(ops.vals[size], val)
+}
+
+// Len returns the number of unique operands.
+func (ops *CompOperands) Len() int {
+	return len(ops.seen)
+}
+
+// substituteOperands replaces value of a random integer argument of a random call
+// with a comparison operand of the same size, or a part of a buffer argument with an operand.
+func (ctx *mutator) substituteOperands() bool {
+	p, r, ops := ctx.p, ctx.r, ctx.ct.operands
+	if len(p.Calls) <= ctx.npre {
+		return false
+	}
+	c := p.Calls[ctx.npre+r.Intn(len(p.Calls)-ctx.npre)]
+	var args []Arg
+	ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+		if canSubstituteOperand(ops, arg) {
+			args = append(args, arg)
+		}
+	})
+	if len(args) == 0 {
+		return false
+	}
+	switch a := args[r.Intn(len(args))].(type) {
+	case *ConstArg:
+		vals := ops.vals[a.Size()]
+		a.Val = vals[r.Intn(len(vals))]
+	case *DataArg:
+		data := a.Data()
+		var sizes []uint64
+		for size := uint64(1); size <= 8; size *= 2 {
+			if len(ops.vals[size]) != 0 && size <= uint64(len(data)) {
+				sizes = append(sizes, size)
+			}
+		}
+		size := sizes[r.Intn(len(sizes))]
+		val := ops.vals[size][r.Intn(len(ops.vals[size]))]
+		if size != 1 && r.bin() {
+			// Kernel code frequently compares data with converted constants (e.g. htons(ETH_P_IP)).
+			val = swapInt(val, int(size))
+		}
+		buf := make([]byte, 8)
+		binary.LittleEndian.PutUint64(buf, val)
+		off := r.Intn(len(data) - int(size) + 1)
+		copy(data[off:], buf[:size])
+	}
+	p.Target.assignSizesCall(c)
+	return true
+}
+
+func canSubstituteOperand(ops *CompOperands, arg Arg) bool {
+	typ := arg.Type()
+	if typ == nil || typ.Dir() == DirOut || typ.MutationWeight() == 0 {
+		return false
+	}
+	switch t := typ.(type) {
+	case *IntType:
+		if t.Kind == IntRingTail || t.BitfieldLength() != 0 {
+			return false
+		}
+		return len(ops.vals[t.Size()]) != 0
+	case *FlagsType:
+		return t.BitfieldLength() == 0 && len(ops.vals[t.Size()]) != 0
+	case *BufferType:
+		if t.Kind == BufferFilename {
+			// This can generate escaping paths.
+			return false
+		}
+		data := arg.(*DataArg).Data()
+		for size := uint64(1); size <= 8; size *= 2 {
+			if len(ops.vals[size]) != 0 && size <= uint64(len(data)) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -101,7 +101,7 @@ func (target *Target) calcResourceUsage() map[string]map[int]float32 {
 				}
 			case *BufferType:
 				switch a.Kind {
-				case BufferBlobRand, BufferBlobRange, BufferText:
+				case BufferBlobRand, BufferBlobRange, BufferText, BufferSerialized:
 				case BufferString:
 					if a.SubKind != "" {
 						noteUsage(uses, c, 0.2, fmt.Sprintf("str-%v", a.SubKind))
@@ -115,7 +115,11 @@ func (target *Target) calcResourceUsage() map[string]map[int]float32 {
 				noteUsage(uses, c, 0.5, "vma")
 			case *IntType:
 				switch a.Kind {
-				case IntPlain, IntFileoff, IntRange:
+				case IntPlain, IntFileoff, IntRange, IntRingHead, IntRingTail, IntRelPtr, IntFlagIndex, IntPow2, IntArrayIndex, IntParity:
+				case IntChildPid:
+					noteUsage(uses, c, 0.5, "child_pid")
+				case IntFuncPtr:
+					noteUsage(uses, c, 0.5, "funcptr")
 				default:
 					panic("unknown int kind")
 				}
@@ -198,12 +202,32 @@ func normalizePrio(prios [][]float32) {
 // ChooseTable allows to do a weighted choice of a syscall for a given syscall
 // based on call-to-call priorities and a set of enabled syscalls.
 type ChoiceTable struct {
-	target       *Target
-	run          [][]int
-	enabledCalls []*Syscall
-	enabled      map[*Syscall]bool
+	target        *Target
+	prios         [][]float32
+	run           [][]int
+	anyRun        []int // cumulative weights of enabledCalls, nil if all calls have the same weight
+	weights       []float64
+	enabledCalls  []*Syscall
+	enabled       map[*Syscall]bool
+	resourceReuse float64
+	errorPaths    float64
+	constPromote  float64
+	dict          *Dictionary
+	shapes        *Shapes
+	operands      *CompOperands
+	prefix        *Prog
+	maxCalls      int
+	enums         *enumCycler
 }
 
+// Default probability of using an existing resource for a resource argument.
+const defaultResourceReuse = 1000.0 / 1011
+
+// DefaultErrorPaths is the fraction of generated calls with an invalid argument
+// that fuzzing tools use by default (see ChoiceTable.SetErrorPaths).
+// It is low to not lose coverage of normal paths.
+const DefaultErrorPaths = 0.02
+
 func (target *Target) BuildChoiceTable(prios [][]float32, enabled map[*Syscall]bool) *ChoiceTable {
 	if enabled == nil {
 		enabled = make(map[*Syscall]bool)
@@ -218,35 +242,225 @@ func (target *Target) BuildChoiceTable(prios [][]float32, enabled map[*Syscall]b
 	if len(enabledCalls) == 0 {
 		panic(fmt.Sprintf("empty enabledCalls, len(target.Syscalls)=%v", len(target.Syscalls)))
 	}
-	run := make([][]int, len(target.Syscalls))
-	for i := range run {
-		if !enabled[target.Syscalls[i]] {
+	ct := &ChoiceTable{
+		target:        target,
+		prios:         prios,
+		enabledCalls:  enabledCalls,
+		enabled:       enabled,
+		resourceReuse: defaultResourceReuse,
+		enums:         newEnumCycler(),
+	}
+	ct.buildRun()
+	return ct
+}
+
+func (ct *ChoiceTable) buildRun() {
+	target := ct.target
+	weight := func(c int) float64 {
+		if ct.weights == nil {
+			return 1
+		}
+		return ct.weights[c]
+	}
+	ct.run = make([][]int, len(target.Syscalls))
+	for i := range ct.run {
+		if !ct.enabled[target.Syscalls[i]] {
 			continue
 		}
-		run[i] = make([]int, len(target.Syscalls))
+		ct.run[i] = make([]int, len(target.Syscalls))
 		sum := 0
-		for j := range run[i] {
-			if enabled[target.Syscalls[j]] {
+		for j := range ct.run[i] {
+			if ct.enabled[target.Syscalls[j]] {
 				w := 1
-				if prios != nil {
-					w = int(prios[i][j] * 1000)
+				if ct.prios != nil {
+					w = int(ct.prios[i][j] * 1000)
+				} else if ct.weights != nil {
+					w = 1000
+				}
+				if ct.weights != nil {
+					w = int(float64(w) * weight(j))
 				}
 				sum += w
 			}
-			run[i][j] = sum
+			ct.run[i][j] = sum
+		}
+	}
+	ct.anyRun = nil
+	if ct.weights != nil {
+		sum := 0
+		for _, c := range ct.enabledCalls {
+			// Scale weights, so that fractional weights are still distinguishable.
+			sum += int(weight(c.ID) * 1000)
+			ct.anyRun = append(ct.anyRun, sum)
+		}
+	}
+}
+
+// SetCallWeights biases the choice of calls: probability of choosing a call is multiplied
+// by its weight (calls that are not present in weights have weight 1). Weights are also
+// propagated to calls that create resources consumed by the weighted calls (transitively),
+// so that the calls are oversampled together with their prerequisites.
+// E.g. weights of 10 for recently changed calls focus fuzzing on them.
+// nil weights restore the default choice.
+func (ct *ChoiceTable) SetCallWeights(weights map[*Syscall]float64) {
+	if weights == nil {
+		ct.weights = nil
+		ct.buildRun()
+		return
+	}
+	target := ct.target
+	ct.weights = make([]float64, len(target.Syscalls))
+	for i := range ct.weights {
+		ct.weights[i] = 1
+	}
+	var queue []*Syscall
+	for c, w := range weights {
+		if !(w > 0) {
+			panic(fmt.Sprintf("bad weight %v for call %v", w, c.Name))
+		}
+		ct.weights[c.ID] = w
+		queue = append(queue, c)
+	}
+	for len(queue) != 0 {
+		c := queue[0]
+		queue = queue[1:]
+		w := ct.weights[c.ID]
+		for _, res := range target.inputResources(c) {
+			for _, ctor := range target.calcResourceCtors(res.Kind, true) {
+				if _, ok := weights[ctor]; ok || ct.weights[ctor.ID] >= w {
+					continue
+				}
+				ct.weights[ctor.ID] = w
+				queue = append(queue, ctor)
+			}
+		}
+	}
+	ct.buildRun()
+}
+
+// SetResourceFocus biases the choice of calls towards programs that heavily use resource res:
+// calls that produce or consume res (see Target.ResourceCalls) are chosen weight times more
+// frequently, together with calls that create their other input resources.
+// It replaces weights set with SetCallWeights. nil res restores the default choice.
+func (ct *ChoiceTable) SetResourceFocus(res *ResourceDesc, weight float64) {
+	if res == nil {
+		ct.SetCallWeights(nil)
+		return
+	}
+	producers, consumers := ct.target.ResourceCalls(res)
+	weights := make(map[*Syscall]float64)
+	for _, c := range append(producers, consumers...) {
+		if ct.enabled[c] {
+			weights[c] = weight
+		}
+	}
+	ct.SetCallWeights(weights)
+}
+
+// SetResourceReuse sets probability (from 0 to 1) of passing an already created resource
+// of a compatible kind to a resource argument, instead of creating a new resource or using
+// a special value. Higher values make programs operate on the same resources more often.
+func (ct *ChoiceTable) SetResourceReuse(prob float64) {
+	if prob < 0 || prob > 1 {
+		panic(fmt.Sprintf("bad resource reuse probability %v", prob))
+	}
+	ct.resourceReuse = prob
+}
+
+// SetErrorPaths sets fraction (from 0 to 1) of generated calls that get one argument
+// with a value that is likely to fail validation in the kernel (an invalid resource,
+// NULL instead of a non-optional pointer, a wrong length or an out-of-range integer).
+// The rest of the program is generated as usual, so it exercises error handling paths
+// and what follows them. 0 (the default) disables injection of invalid values.
+func (ct *ChoiceTable) SetErrorPaths(frac float64) {
+	if frac < 0 || frac > 1 {
+		panic(fmt.Sprintf("bad error paths fraction %v", frac))
+	}
+	ct.errorPaths = frac
+}
+
+// SetConstPromotion sets fraction (from 0 to 1) of argument mutations that can also choose
+// promotable const fields (see promotable attribute in descriptions). Mutation of such field
+// replaces its value with a random one, or restores the value from descriptions if it is already
+// replaced, so programs probe validation of magic values. 0 (the default) keeps consts immutable.
+func (ct *ChoiceTable) SetConstPromotion(frac float64) {
+	if frac < 0 || frac > 1 {
+		panic(fmt.Sprintf("bad const promotion fraction %v", frac))
+	}
+	ct.constPromote = frac
+}
+
+// SetDictionary sets dictionary of magic values that are used for integer and buffer arguments
+// in addition to values derived from types (nil disables use of the dictionary).
+func (ct *ChoiceTable) SetDictionary(dict *Dictionary) {
+	ct.dict = dict
+}
+
+// SetShapes sets argument shapes (union options and array lengths) that generation and mutation
+// are biased toward, e.g. shapes of crash reproducers (nil disables use of the shapes).
+func (ct *ChoiceTable) SetShapes(shapes *Shapes) {
+	ct.shapes = shapes
+}
+
+// SetCompOperands sets comparison operands that are substituted into arguments during mutation
+// (nil or an empty set disables the mutation).
+func (ct *ChoiceTable) SetCompOperands(ops *CompOperands) {
+	if ops != nil && ops.Len() == 0 {
+		ops = nil
+	}
+	ct.operands = ops
+}
+
+// SetPrefix sets a program that all generated programs start with (e.g. a mandatory
+// initialization sequence of a stateful interface), resources created by the prefix
+// are available to the rest of the program. Mutation doesn't remove, reorder or change
+// calls of the prefix in programs that start with it. nil (or an empty program) disables the prefix.
+func (ct *ChoiceTable) SetPrefix(p *Prog) {
+	if p == nil || len(p.Calls) == 0 {
+		ct.prefix = nil
+		return
+	}
+	if p.Target != ct.target {
+		panic("prefix program is for a different target")
+	}
+	ct.prefix = p.Clone()
+}
+
+// SetMaxCalls sets a hard limit on the number of calls in generated programs,
+// including the prefix and calls generated to create resources for other calls.
+// Generation skips calls whose dependencies (resource constructors and required calls)
+// don't fit into the limit and prefers calls that can use resources already present in the program,
+// so programs may be shorter than requested. The prefix is never truncated.
+// 0 (the default) disables the limit.
+func (ct *ChoiceTable) SetMaxCalls(n int) {
+	if n < 0 {
+		panic(fmt.Sprintf("bad max calls %v", n))
+	}
+	ct.maxCalls = n
+}
+
+// prefixLen returns the number of calls of the prefix if p starts with it, or 0 otherwise.
+func (ct *ChoiceTable) prefixLen(p *Prog) int {
+	if ct == nil || ct.prefix == nil || len(p.Calls) < len(ct.prefix.Calls) {
+		return 0
+	}
+	for i, c := range ct.prefix.Calls {
+		if p.Calls[i].Meta != c.Meta {
+			return 0
 		}
 	}
-	return &ChoiceTable{target, run, enabledCalls, enabled}
+	return len(ct.prefix.Calls)
 }
 
 func (ct *ChoiceTable) Choose(r *rand.Rand, call int) int {
-	if call < 0 {
+	if call < 0 || ct.run[call] == nil {
+		if ct.anyRun != nil {
+			x := r.Intn(ct.anyRun[len(ct.anyRun)-1]) + 1
+			return ct.enabledCalls[sort.SearchInts(ct.anyRun, x)].ID
+		}
 		return ct.enabledCalls[r.Intn(len(ct.enabledCalls))].ID
 	}
 	run := ct.run[call]
-	if run == nil {
-		return ct.enabledCalls[r.Intn(len(ct.enabledCalls))].ID
-	}
 	for {
 		x := r.Intn(run[len(run)-1]) + 1
 		i := sort.SearchInts(run, x)
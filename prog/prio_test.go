@@ -4,8 +4,10 @@
 package prog
 
 import (
+	"fmt"
 	"math/rand"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -59,3 +61,110 @@ func TestPrioChoice(t *testing.T) {
 		t.Fatal(diff)
 	}
 }
+
+func TestCallWeights(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	ct := target.BuildChoiceTable(nil, nil)
+	res1 := target.SyscallMap["test$res1"]
+	res0 := target.SyscallMap["test$res0"]
+	ct.SetCallWeights(map[*Syscall]float64{res1: 100})
+	if ct.weights[res0.ID] != 100 {
+		t.Fatalf("weight of resource ctor %v is %v, want 100", res0.Name, ct.weights[res0.ID])
+	}
+	count := func(call int) map[int]int {
+		r := rand.New(rand.NewSource(0))
+		res := make(map[int]int)
+		for i := 0; i < 10000; i++ {
+			res[ct.Choose(r, call)]++
+		}
+		return res
+	}
+	weighted := []int{count(-1)[res1.ID], count(res0.ID)[res1.ID]}
+	ct.SetCallWeights(nil)
+	uniform := []int{count(-1)[res1.ID], count(res0.ID)[res1.ID]}
+	for i := range weighted {
+		if weighted[i] < 10*uniform[i] || weighted[i] == 0 {
+			t.Errorf("#%v: test$res1 was chosen %v times with weights, %v times without",
+				i, weighted[i], uniform[i])
+		}
+	}
+}
+
+func TestResourceFocus(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	callNames := func(calls []*Syscall) string {
+		var names []string
+		for _, c := range calls {
+			names = append(names, c.Name)
+		}
+		return strings.Join(names, " ")
+	}
+	for _, test := range []struct {
+		res       string
+		producers string
+		consumers string
+	}{
+		{"syz_obj", "test$subkind0 test$subkind1", "test$subkind2"},
+		{"syz_obj_file", "test$subkind0", ""},
+		{"syz_compat1", "test$compat1", "test$compat2"},
+	} {
+		producers, consumers := target.ResourceCalls(target.resourceMap[test.res])
+		if got := callNames(producers); got != test.producers {
+			t.Errorf("%v: producers %q, want %q", test.res, got, test.producers)
+		}
+		if got := callNames(consumers); got != test.consumers {
+			t.Errorf("%v: consumers %q, want %q", test.res, got, test.consumers)
+		}
+	}
+	ct := target.BuildChoiceTable(nil, nil)
+	count := func() map[string]int {
+		r := rand.New(rand.NewSource(0))
+		res := make(map[string]int)
+		for i := 0; i < 10000; i++ {
+			res[target.Syscalls[ct.Choose(r, -1)].Name]++
+		}
+		return res
+	}
+	ct.SetResourceFocus(target.resourceMap["syz_obj"], 100)
+	focused := count()
+	ct.SetResourceFocus(nil, 0)
+	uniform := count()
+	for _, name := range []string{"test$subkind0", "test$subkind1", "test$subkind2"} {
+		if focused[name] < 10*uniform[name] || focused[name] == 0 {
+			t.Errorf("%v was chosen %v times with focus, %v times without",
+				name, focused[name], uniform[name])
+		}
+	}
+}
+
+func TestResourceInvalidators(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	for _, test := range []struct {
+		res  string
+		want string
+	}{
+		{"syz_res", "test$res4:0 test$res5:0/transform"},
+		{"syz_refcnt", "test$refcnt2:0/release/conditional"},
+		{"syz_inv", "test$inv1:0"},
+		{"syz_inv_sub", "test$inv1:0 test$inv2:0/transform test$inv3:1/conditional"},
+		{"fd", ""},
+	} {
+		var got []string
+		for _, inv := range target.ResourceInvalidators(target.resourceMap[test.res]) {
+			desc := fmt.Sprintf("%v:%v", inv.Call.Name, inv.Arg)
+			switch inv.Effect {
+			case ResourceTransform:
+				desc += "/transform"
+			case ResourceRelease:
+				desc += "/release"
+			}
+			if inv.Conditional {
+				desc += "/conditional"
+			}
+			got = append(got, desc)
+		}
+		if strings.Join(got, " ") != test.want {
+			t.Errorf("%v: got invalidators %q, want %q", test.res, strings.Join(got, " "), test.want)
+		}
+	}
+}
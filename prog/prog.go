@@ -377,9 +377,18 @@ func removeArg(arg0 Arg) {
 	})
 }
 
+// omitArgs removes trailing arguments of the call so that only n arguments remain.
+func (c *Call) omitArgs(n int) {
+	for _, arg := range c.Args[n:] {
+		removeArg(arg)
+	}
+	c.Args = c.Args[:n]
+}
+
 // removeCall removes call idx from p.
 func (p *Prog) removeCall(idx int) {
 	c := p.Calls[idx]
+	c.redirectDerivedUses()
 	for _, arg := range c.Args {
 		removeArg(arg)
 	}
@@ -389,3 +398,36 @@ func (p *Prog) removeCall(idx int) {
 	copy(p.Calls[idx:], p.Calls[idx+1:])
 	p.Calls = p.Calls[:len(p.Calls)-1]
 }
+
+// derivedFrom returns the argument of c that the resource returned by c is derived from
+// and the offset that is added to it (see ResourceType.Derives), or nil.
+func (c *Call) derivedFrom() (*ResultArg, uint64) {
+	for _, arg := range c.Args {
+		a, ok := arg.(*ResultArg)
+		if !ok {
+			continue
+		}
+		if typ := a.Type().(*ResourceType); typ.Derives {
+			return a, typ.DeriveOffset
+		}
+	}
+	return nil, 0
+}
+
+// redirectDerivedUses makes args that use the resource returned by c use the resource
+// it is derived from plus the offset instead, so that c can be removed without
+// losing the value. Args with arithmetic operations are left intact.
+func (c *Call) redirectDerivedUses() {
+	src, offset := c.derivedFrom()
+	if src == nil || src.Res == nil || src.OpDiv != 0 || src.OpAdd != 0 || c.Ret == nil {
+		return
+	}
+	for use := range c.Ret.uses {
+		if use.OpDiv != 0 || use.OpAdd != 0 {
+			continue
+		}
+		arg := MakeResultArg(use.Type(), src.Res, 0)
+		arg.OpAdd = offset
+		replaceResultArg(use, arg)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"fmt"
 	"math/rand"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -233,6 +234,69 @@ func TestEscapingPaths(t *testing.T) {
 	}
 }
 
+func TestFlagLookup(t *testing.T) {
+	target := initTargetTest(t, "linux", "amd64")
+	for _, name := range []string{"O_RDWR", "open_flags.O_RDWR"} {
+		if v, ok := target.FlagValue(name); !ok || v != 2 {
+			t.Errorf("FlagValue(%q) = %v, %v, want 2, true", name, v, ok)
+		}
+	}
+	for _, name := range []string{"mmap_prot.O_RDWR", "no_such_flags.O_RDWR", "NO_SUCH_FLAG"} {
+		if v, ok := target.FlagValue(name); ok {
+			t.Errorf("FlagValue(%q) = %v, want not found", name, v)
+		}
+	}
+	if names := target.FlagNames("open_flags", 2); !reflect.DeepEqual(names, []string{"O_RDWR"}) {
+		t.Errorf("FlagNames(open_flags, 2) = %q, want [O_RDWR]", names)
+	}
+	names := make(map[string]bool)
+	for _, name := range target.FlagNames("", 2) {
+		names[name] = true
+	}
+	if !names["open_flags.O_RDWR"] || !names["mmap_prot.PROT_WRITE"] {
+		t.Errorf("FlagNames(\"\", 2) does not contain open_flags.O_RDWR and mmap_prot.PROT_WRITE: %v", names)
+	}
+}
+
+func TestFlagLookupDuplicates(t *testing.T) {
+	target := &Target{
+		Consts: []ConstValue{{"FOO_A", 1}, {"FOO_B", 2}, {"FOO_ALIAS", 2}, {"BAR_A", 2}},
+		Flags: []FlagDesc{
+			{Name: "foo", Values: []string{"FOO_B", "FOO_A", "FOO_ALIAS", "FOO_B", "FOO_MISSING"}},
+			{Name: "bar", Values: []string{"BAR_A", "FOO_B", "BAR_MISSING"}},
+		},
+	}
+	tests := []struct {
+		group string
+		val   uint64
+		names []string
+	}{
+		{"foo", 1, []string{"FOO_A"}},
+		{"foo", 2, []string{"FOO_ALIAS", "FOO_B"}},
+		{"bar", 2, []string{"BAR_A", "FOO_B"}},
+		{"bar", 1, nil},
+		{"bar", 0, nil},
+		{"", 2, []string{"bar.BAR_A", "bar.FOO_B", "foo.FOO_ALIAS", "foo.FOO_B"}},
+		{"baz", 2, nil},
+	}
+	for _, test := range tests {
+		if names := target.FlagNames(test.group, test.val); !reflect.DeepEqual(names, test.names) {
+			t.Errorf("FlagNames(%q, %v) = %q, want %q", test.group, test.val, names, test.names)
+		}
+	}
+	for _, name := range []string{"FOO_B", "FOO_ALIAS", "foo.FOO_ALIAS", "bar.FOO_B"} {
+		if v, ok := target.FlagValue(name); !ok || v != 2 {
+			t.Errorf("FlagValue(%q) = %v, %v, want 2, true", name, v, ok)
+		}
+	}
+	// Flags that are not present in consts are not supported on the arch.
+	for _, name := range []string{"bar.FOO_A", "FOO_MISSING", "foo.FOO_MISSING", "bar.BAR_MISSING"} {
+		if v, ok := target.FlagValue(name); ok {
+			t.Errorf("FlagValue(%q) = %v, want not found", name, v)
+		}
+	}
+}
+
 func TestFallbackSignal(t *testing.T) {
 	type desc struct {
 		prog string
@@ -386,6 +450,254 @@ fallback$0()
 	}
 }
 
+func TestResourceFootprint(t *testing.T) {
+	tests := []struct {
+		prog string
+		want map[string]int
+	}{
+		{
+			`
+r0 = test$res0()
+r1 = test$res2()
+test$res1(r0)
+`,
+			map[string]int{"syz_res": 1, "fd": 1},
+		},
+		// Invalidating the same resource twice counts only once.
+		{
+			`
+r0 = test$res0()
+r1 = test$res0()
+test$res4(r0)
+test$res4(r0)
+`,
+			map[string]int{"syz_res": 1},
+		},
+		// Transformed resource is replaced with the new one, special values are not counted.
+		{
+			`
+r0 = test$res0()
+r1 = test$res5(r0)
+test$res4(0xffff)
+`,
+			map[string]int{"syz_res": 1},
+		},
+		{
+			`
+r0 = test$res0()
+r1 = test$res5(r0)
+test$res4(r1)
+`,
+			map[string]int{},
+		},
+		// Output resources in structs are produced as well.
+		{
+			`
+test$res3(&(0x7f0000000000)={<r0=>0x0, 0x0, {<r1=>0x0, @fd=<r2=>0x0}, [<r3=>0x0, <r4=>0x0], 0x0, 0x0})
+test$res4(r1)
+`,
+			map[string]int{"syz_res": 2, "fd": 2},
+		},
+	}
+	target, err := GetTarget("test", "64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			p, err := target.Deserialize([]byte(test.prog), Strict)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.ResourceFootprint(); !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("got footprint %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSimulateResources(t *testing.T) {
+	target, err := GetTarget("test", "64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := target.Deserialize([]byte(`
+r0 = test$res0()
+r1 = test$res5(r0)
+test$res1(r0)
+r2 = test$refcnt0()
+test$refcnt1(r2)
+test$refcnt2(r2)
+test$refcnt3(r2)
+test$refcnt2(r2)
+test$refcnt3(r2)
+test$res4(0xffff)
+`), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"produced=[r0] used=[] invalidated=[] stale=[] live=[r0]",
+		"produced=[r1] used=[r0] invalidated=[r0] stale=[] live=[r1]",
+		"produced=[] used=[r0] invalidated=[] stale=[r0] live=[r1]",
+		"produced=[r2] used=[] invalidated=[] stale=[] live=[r1 r2]",
+		"produced=[] used=[r2] invalidated=[] stale=[] live=[r1 r2]",
+		// Release with an outstanding acquired reference does not invalidate the resource,
+		// but the resource is not known to be live either.
+		"produced=[] used=[r2] invalidated=[] stale=[] live=[r1]",
+		"produced=[] used=[r2] invalidated=[] stale=[] live=[r1]",
+		"produced=[] used=[r2] invalidated=[r2] stale=[] live=[r1]",
+		"produced=[] used=[r2] invalidated=[] stale=[r2] live=[r1]",
+		"produced=[] used=[] invalidated=[] stale=[] live=[r1]",
+	}
+	names := make(map[*ResultArg]string)
+	format := func(list []*ResultArg) string {
+		var res []string
+		for _, arg := range list {
+			res = append(res, names[arg])
+		}
+		return fmt.Sprintf("[%v]", strings.Join(res, " "))
+	}
+	snapshots := p.SimulateResources()
+	if len(snapshots) != len(want) {
+		t.Fatalf("got %v snapshots, want %v", len(snapshots), len(want))
+	}
+	for i, snapshot := range snapshots {
+		for _, res := range snapshot.Produced {
+			names[res] = fmt.Sprintf("r%v", len(names))
+		}
+		got := fmt.Sprintf("produced=%v used=%v invalidated=%v stale=%v live=%v",
+			format(snapshot.Produced), format(snapshot.Used), format(snapshot.Invalidated),
+			format(snapshot.Stale), format(snapshot.Live()))
+		if got != want[i] {
+			t.Errorf("call #%v: got %v, want %v", i, got, want[i])
+		}
+	}
+	if status := snapshots[5].Status[p.Calls[3].Ret]; status != ResourceMaybeInvalid {
+		t.Errorf("released resource has status %v, want %v", status, ResourceMaybeInvalid)
+	}
+}
+
+func TestResourceReleaseWithRefs(t *testing.T) {
+	target, err := GetTarget("test", "64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := target.Deserialize([]byte(`
+r0 = test$refcnt0()
+test$refcnt1(r0)
+test$refcnt2(r0)
+test$refcnt2(r0)
+`), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := p.Calls[0].Ret
+	// Release with an outstanding acquired reference drops the reference, the resource
+	// may be invalid, but it is still counted and available for subsequent calls.
+	// Release of the last reference invalidates the resource.
+	tests := []struct {
+		ncalls    int
+		status    ResourceStatus
+		footprint map[string]int
+		available bool
+		refs      int
+	}{
+		{2, ResourceLive, map[string]int{"syz_refcnt": 1}, true, 1},
+		{3, ResourceMaybeInvalid, map[string]int{"syz_refcnt": 1}, true, 0},
+		{4, ResourceInvalid, map[string]int{}, false, 0},
+	}
+	for _, test := range tests {
+		p1 := &Prog{Target: target, Calls: p.Calls[:test.ncalls]}
+		snapshots := p1.SimulateResources()
+		if status := snapshots[len(snapshots)-1].Status[res]; status != test.status {
+			t.Errorf("after %v calls: status %v, want %v", test.ncalls, status, test.status)
+		}
+		if footprint := p1.ResourceFootprint(); !reflect.DeepEqual(footprint, test.footprint) {
+			t.Errorf("after %v calls: footprint %v, want %v", test.ncalls, footprint, test.footprint)
+		}
+		s := newState(target, nil)
+		for _, c := range p1.Calls {
+			s.analyze(c)
+		}
+		if available := len(s.resources["syz_refcnt"]) != 0; available != test.available {
+			t.Errorf("after %v calls: available %v, want %v", test.ncalls, available, test.available)
+		}
+		if refs := s.refs[res]; refs != test.refs {
+			t.Errorf("after %v calls: %v outstanding references, want %v", test.ncalls, refs, test.refs)
+		}
+	}
+}
+
+func TestExtractCall(t *testing.T) {
+	target, err := GetTarget("test", "64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := target.Deserialize([]byte(`
+r0 = test$res0()
+r1 = test$res2()
+r2 = test$res5(r0)
+test$res1(r2)
+r3 = test$refcnt0()
+test$refcnt1(r3)
+test$res4(0xffff)
+test$refcnt3(r3)
+`), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		idx  int
+		want string
+	}{
+		{
+			3,
+			"r0 = test$res0()\nr1 = test$res5(r0)\ntest$res1(r1)\n",
+		},
+		{
+			7,
+			"r0 = test$refcnt0()\ntest$refcnt3(r0)\n",
+		},
+		{
+			// The call does not need any producers.
+			6,
+			"test$res4(0xffff)\n",
+		},
+		{
+			0,
+			"test$res0()\n",
+		},
+	}
+	for _, test := range tests {
+		p1 := p.ExtractCall(test.idx)
+		if got := string(p1.Serialize()); got != test.want {
+			t.Errorf("call #%v: got:\n%v\nwant:\n%v", test.idx, got, test.want)
+		}
+	}
+	// The original program is not changed.
+	if len(p.Calls) != 8 {
+		t.Fatalf("original program has %v calls", len(p.Calls))
+	}
+}
+
+func TestExtractCallRandom(t *testing.T) {
+	testEachTargetRandom(t, func(t *testing.T, target *Target, rs rand.Source, iters int) {
+		for i := 0; i < iters; i++ {
+			p := target.Generate(rs, 10, nil)
+			for idx, c := range p.Calls {
+				p1 := p.ExtractCall(idx)
+				if last := p1.Calls[len(p1.Calls)-1]; last.Meta != c.Meta {
+					t.Fatalf("extracted call %v is not the last call:\n%s", c.Meta.Name, p1.Serialize())
+				}
+				if _, err := p1.SerializeForExec(make([]byte, ExecBufferSize)); err != nil {
+					t.Fatalf("failed to serialize extracted call %v: %v\n%s", c.Meta.Name, err, p1.Serialize())
+				}
+			}
+		}
+	})
+}
+
 func TestSanitizeRandom(t *testing.T) {
 	testEachTargetRandom(t, func(t *testing.T, target *Target, rs rand.Source, iters int) {
 		for i := 0; i < iters; i++ {
@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"fmt"
 	"math"
+	"math/bits"
 	"math/rand"
 	"path/filepath"
 	"sort"
@@ -21,6 +22,7 @@ type randGen struct {
 	target           *Target
 	inCreateResource bool
 	recDepth         map[string]int
+	serializedDepth  int
 }
 
 func newRand(target *Target, rs rand.Source) *randGen {
@@ -99,6 +101,69 @@ func (r *randGen) randRangeInt(begin uint64, end uint64) uint64 {
 	return begin + (r.Uint64() % (end - begin + 1))
 }
 
+// randPow2 returns a random power of 2 within [begin, end] (both are powers of 2).
+func (r *randGen) randPow2(begin, end uint64) uint64 {
+	lo, hi := bits.TrailingZeros64(begin), bits.TrailingZeros64(end)
+	return 1 << uint(lo+r.Intn(hi-lo+1))
+}
+
+// randBucketInt chooses one of the buckets according to their weights
+// and returns a random value within the bucket.
+func (r *randGen) randBucketInt(buckets []IntBucket) uint64 {
+	var total uint64
+	for _, b := range buckets {
+		total += b.Weight
+	}
+	x := r.Uint64() % total
+	for _, b := range buckets {
+		if x >= b.Weight {
+			x -= b.Weight
+			continue
+		}
+		if b.End-b.Begin == ^uint64(0) {
+			return r.Uint64()
+		}
+		return b.Begin + r.Uint64()%(b.End-b.Begin+1)
+	}
+	panic("bad int buckets")
+}
+
+// unionOption chooses index of a union option according to the option weights.
+// If current is not -1, the option with this index is not chosen, unless all other
+// options have zero weight.
+func (r *randGen) unionOption(t *UnionType, current int) int {
+	if t.OptionWeights == nil {
+		if current == -1 {
+			return r.Intn(len(t.Fields))
+		}
+		idx := r.Intn(len(t.Fields) - 1)
+		if idx >= current {
+			idx++
+		}
+		return idx
+	}
+	var total uint64
+	for i, w := range t.OptionWeights {
+		if i != current {
+			total += w
+		}
+	}
+	if total == 0 {
+		return current
+	}
+	x := r.Uint64() % total
+	for i, w := range t.OptionWeights {
+		if i == current {
+			continue
+		}
+		if x < w {
+			return i
+		}
+		x -= w
+	}
+	panic("bad union option weights")
+}
+
 // biasedRand returns a random int in range [0..n),
 // probability of n-1 is k times higher than probability of 0.
 func (r *randGen) biasedRand(n, k int) int {
@@ -231,6 +296,12 @@ func (r *randGen) randString(s *state, t *BufferType) []byte {
 	for r.nOutOf(3, 4) {
 		switch {
 		case r.nOutOf(10, 21):
+			if s.useDict(r) {
+				if blob := s.ct.dict.blobValue(r, 0, maxBlobLen); blob != nil {
+					buf.Write(blob)
+					break
+				}
+			}
 			dict := r.target.StringDictionary
 			if len(dict) != 0 {
 				buf.WriteString(dict[r.Intn(len(dict))])
@@ -248,6 +319,9 @@ func (r *randGen) randString(s *state, t *BufferType) []byte {
 }
 
 func (r *randGen) allocAddr(s *state, typ Type, size uint64, data Arg) *PointerArg {
+	if ptr, ok := typ.(*PtrType); ok && ptr.Guard {
+		return MakePointerArg(typ, s.ma.allocGuarded(r, size, r.target.PageSize), data)
+	}
 	return MakePointerArg(typ, s.ma.alloc(r, size), data)
 }
 
@@ -258,8 +332,7 @@ func (r *randGen) allocVMA(s *state, typ Type, numPages uint64) *PointerArg {
 
 func (r *randGen) createResource(s *state, res *ResourceType) (arg Arg, calls []*Call) {
 	if r.inCreateResource {
-		special := res.SpecialValues()
-		return MakeResultArg(res, nil, special[r.Intn(len(special))]), nil
+		return MakeResultArg(res, nil, r.resourceValue(res)), nil
 	}
 	r.inCreateResource = true
 	defer func() { r.inCreateResource = false }()
@@ -274,7 +347,10 @@ func (r *randGen) createResource(s *state, res *ResourceType) (arg Arg, calls []
 			}
 		}
 		sort.Strings(all)
-		kind = all[r.Intn(len(all))]
+		if len(all) != 0 {
+			// Targets without resources have none to spoof for ANY resources.
+			kind = all[r.Intn(len(all))]
+		}
 	}
 	// Find calls that produce the necessary resources.
 	metas0 := r.target.resourceCtors[kind]
@@ -286,7 +362,18 @@ func (r *randGen) createResource(s *state, res *ResourceType) (arg Arg, calls []
 		}
 		metas = append(metas, meta)
 	}
+	// Scoped resources can be created only within their scope.
+	var scopeCalls []*Call
+	if desc := r.target.resourceMap[kind]; len(metas) != 0 && desc != nil &&
+		desc.Scope != "" && len(s.scopes[desc.Scope]) == 0 {
+		if scopeCalls = r.generateScopeBegin(s, desc.Scope); scopeCalls == nil {
+			metas = nil
+		}
+	}
 	if len(metas) == 0 {
+		if res.Desc.HasRange {
+			return MakeResultArg(res, nil, r.resourceValue(res)), nil
+		}
 		return res.DefaultArg(), nil
 	}
 
@@ -296,6 +383,10 @@ func (r *randGen) createResource(s *state, res *ResourceType) (arg Arg, calls []
 		meta := metas[r.Intn(len(metas))]
 		calls := r.generateParticularCall(s, meta)
 		s1 := newState(r.target, s.ct)
+		s1.openScopes(s)
+		for _, c := range scopeCalls {
+			s1.analyze(c)
+		}
 		s1.analyze(calls[len(calls)-1])
 		// Now see if we have what we want.
 		var allres []*ResultArg
@@ -307,19 +398,9 @@ func (r *randGen) createResource(s *state, res *ResourceType) (arg Arg, calls []
 		if len(allres) != 0 {
 			// Bingo!
 			arg := MakeResultArg(res, allres[r.Intn(len(allres))], 0)
-			return arg, calls
-		}
-		// Discard unsuccessful calls.
-		// Note: s.ma/va have already noted allocations of the new objects
-		// in discarded syscalls, ideally we should recreate state
-		// by analyzing the program again.
-		for _, c := range calls {
-			ForeachArg(c, func(arg Arg, _ *ArgCtx) {
-				if a, ok := arg.(*ResultArg); ok && a.Res != nil {
-					delete(a.Res.uses, a)
-				}
-			})
+			return arg, append(scopeCalls, calls...)
 		}
+		discardCalls(calls)
 	}
 	// Generally we can loop several times, e.g. when we choose a call that returns
 	// the resource in an array, but then generateArg generated that array of zero length.
@@ -332,6 +413,30 @@ func (r *randGen) createResource(s *state, res *ResourceType) (arg Arg, calls []
 		res.Desc.Kind[0], strings.Join(ctors, ", ")))
 }
 
+// discardCalls unlinks generated calls that are not added to the program from resources they use.
+// Note: s.ma/va have already noted allocations of the new objects
+// in discarded syscalls, ideally we should recreate state
+// by analyzing the program again.
+func discardCalls(calls []*Call) {
+	for _, c := range calls {
+		ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+			if a, ok := arg.(*ResultArg); ok && a.Res != nil {
+				delete(a.Res.uses, a)
+			}
+		})
+	}
+}
+
+// resourceValue returns a value for a resource that is not produced by any call:
+// one of the special values or, for resources with a range, mostly a value from the range.
+func (r *randGen) resourceValue(res *ResourceType) uint64 {
+	if desc := res.Desc; desc.HasRange && !r.oneOf(10) {
+		return desc.RangeBegin + r.Uint64()%(desc.RangeEnd-desc.RangeBegin+1)
+	}
+	special := res.SpecialValues()
+	return special[r.Intn(len(special))]
+}
+
 func (r *randGen) generateText(kind TextKind) []byte {
 	switch kind {
 	case TextTarget:
@@ -439,32 +544,135 @@ func (r *randGen) nOutOf(n, outOf int) bool {
 }
 
 func (r *randGen) generateCall(s *state, p *Prog) []*Call {
-	idx := 0
+	return r.generateChosenCall(s, r.chooseCall(s, p))
+}
+
+// chooseCall chooses a syscall to append to p according to the choice table.
+func (r *randGen) chooseCall(s *state, p *Prog) *Syscall {
 	if s.ct == nil {
-		idx = r.Intn(len(r.target.Syscalls))
-	} else {
-		call := -1
-		if len(p.Calls) != 0 {
-			call = p.Calls[r.Intn(len(p.Calls))].Meta.ID
+		return r.target.Syscalls[r.Intn(len(r.target.Syscalls))]
+	}
+	call := -1
+	if len(p.Calls) != 0 {
+		call = p.Calls[r.Intn(len(p.Calls))].Meta.ID
+	}
+	return r.target.Syscalls[s.ct.Choose(r.Rand, call)]
+}
+
+func (r *randGen) generateChosenCall(s *state, meta *Syscall) []*Call {
+	calls := r.generateParticularCall(s, meta)
+	if s.ct != nil && s.ct.errorPaths != 0 && r.Float64() < s.ct.errorPaths {
+		r.injectInvalidArg(calls[len(calls)-1])
+	}
+	return calls
+}
+
+// injectInvalidArg replaces a random argument of c with a value that is likely to fail
+// validation in the kernel: an invalid resource, NULL instead of a non-optional pointer,
+// a wrong length or an out-of-range integer (see ChoiceTable.SetErrorPaths).
+// Calls that were generated to create resources for c are kept.
+func (r *randGen) injectInvalidArg(c *Call) {
+	var args []Arg
+	ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+		typ := arg.Type()
+		if typ.Dir() == DirOut {
+			return
+		}
+		switch t := typ.(type) {
+		case *ResourceType, *LenType:
+			args = append(args, arg)
+		case *PtrType:
+			if !t.Optional() && !arg.(*PointerArg).IsSpecial() {
+				args = append(args, arg)
+			}
+		case *IntType:
+			if t.Kind == IntRange || t.Kind == IntPow2 {
+				args = append(args, arg)
+			}
+		}
+	})
+	if len(args) == 0 {
+		return
+	}
+	switch arg := args[r.Intn(len(args))].(type) {
+	case *ResultArg:
+		typ := arg.Type().(*ResourceType)
+		v := r.randInt()
+		if special := typ.SpecialValues(); len(special) != 0 && r.bin() {
+			v = special[r.Intn(len(special))]
+		}
+		replaceArg(arg, MakeResultArg(typ, nil, v))
+	case *PointerArg:
+		removeArg(arg)
+		replaceArg(arg, MakeSpecialPointerArg(arg.Type(), 0))
+	case *ConstArg:
+		switch typ := arg.Type().(type) {
+		case *LenType:
+			switch {
+			case r.nOutOf(1, 3):
+				arg.Val += r.rand(16) + 1
+			case r.nOutOf(1, 2):
+				arg.Val = 1<<uint(typ.Size()*8-1) + r.rand(16)
+			default:
+				arg.Val = ^uint64(0)
+			}
+		case *IntType:
+			if typ.RangeBegin == 0 || r.bin() {
+				arg.Val = typ.RangeEnd + r.rand(16) + 1
+			} else {
+				arg.Val = typ.RangeBegin - r.rand(16) - 1
+			}
 		}
-		idx = s.ct.Choose(r.Rand, call)
 	}
-	meta := r.target.Syscalls[idx]
-	return r.generateParticularCall(s, meta)
 }
 
-func (r *randGen) generateParticularCall(s *state, meta *Syscall) (calls []*Call) {
+func (r *randGen) generateParticularCall(s *state, meta *Syscall) []*Call {
+	calls := r.generateRequiredCalls(s, meta, nil)
+	calls = r.generateSubmission(s, meta, calls)
+	return r.generateCallWithArgs(s, meta, calls)
+}
+
+// generateRequiredCalls appends to calls the calls that must precede meta (see Syscall.Requires)
+// and are not present in the program or in calls yet.
+func (r *randGen) generateRequiredCalls(s *state, meta *Syscall, calls []*Call) []*Call {
+nextRequired:
+	for _, name := range meta.Requires {
+		req := r.target.SyscallMap[name]
+		if s.calls[req] {
+			continue
+		}
+		for _, c := range calls {
+			if c.Meta == req {
+				continue nextRequired
+			}
+		}
+		calls = r.generateRequiredCalls(s, req, calls)
+		calls = r.generateCallWithArgs(s, req, calls)
+	}
+	return calls
+}
+
+// generateCallWithArgs appends to calls a call to meta preceded by calls that create its resources
+// and calls that signal event resources it waits on.
+func (r *randGen) generateCallWithArgs(s *state, meta *Syscall, calls []*Call) []*Call {
 	c := &Call{
 		Meta: meta,
 		Ret:  MakeReturnArg(meta.Ret),
 	}
-	c.Args, calls = r.generateArgs(s, meta.Args)
+	nargs := len(meta.Args)
+	if meta.OmittableArgs != 0 && r.oneOf(3) {
+		nargs -= 1 + r.Intn(meta.OmittableArgs)
+	}
+	args, argCalls := r.generateArgs(s, meta.Args[:nargs])
+	c.Args = args
 	r.target.assignSizesCall(c)
-	calls = append(calls, c)
-	for _, c1 := range calls {
+	argCalls = append(argCalls, c)
+	for _, c1 := range argCalls {
 		r.target.SanitizeCall(c1)
 	}
-	return calls
+	calls = append(calls, argCalls[:len(argCalls)-1]...)
+	calls = append(calls, r.generateEventSignals(s, c, calls)...)
+	return append(calls, c)
 }
 
 // GenerateAllSyzProg generates a program that contains all pseudo syz_ calls for testing.
@@ -542,10 +750,17 @@ func (r *randGen) generateArgImpl(s *state, typ Type, ignoreSpecial bool) (arg A
 		}
 	}
 
+	// Pointer chains with depth attribute are always allocated (see generatePtrChain).
+	if pt, ok := typ.(*PtrType); ok && pt.Depth != 0 {
+		return pt.generate(r, s)
+	}
+
 	if typ.Optional() && r.oneOf(5) {
 		if res, ok := typ.(*ResourceType); ok {
 			v := res.Desc.Values[r.Intn(len(res.Desc.Values))]
-			return MakeResultArg(typ, nil, v), nil
+			arg := MakeResultArg(typ, nil, v)
+			res.setValidBit(arg)
+			return arg, nil
 		}
 		return typ.DefaultArg(), nil
 	}
@@ -581,8 +796,15 @@ func (r *randGen) generateArgImpl(s *state, typ Type, ignoreSpecial bool) (arg A
 }
 
 func (a *ResourceType) generate(r *randGen, s *state) (arg Arg, calls []*Call) {
+	if a.InvalidPercent != 0 && r.rand(100) < a.InvalidPercent {
+		return r.invalidResource(s, a), nil
+	}
+	reuse := defaultResourceReuse
+	if s.ct != nil {
+		reuse = s.ct.resourceReuse
+	}
 	switch {
-	case r.nOutOf(1000, 1011):
+	case r.Float64() < reuse:
 		// Get an existing resource.
 		alltypes := make([][]*ResultArg, 0, len(s.resources))
 		for _, res1 := range s.resources {
@@ -611,9 +833,33 @@ func (a *ResourceType) generate(r *randGen, s *state) (arg Arg, calls []*Call) {
 		special := a.SpecialValues()
 		arg = MakeResultArg(a, nil, special[r.Intn(len(special))])
 	}
+	a.setValidBit(arg.(*ResultArg))
 	return arg, calls
 }
 
+// invalidResource returns a plausible but (most likely) invalid value for resource a
+// that is looked up by value: a live resource shifted by a small offset (a neighbour handle),
+// or a small guessed value.
+func (r *randGen) invalidResource(s *state, a *ResourceType) Arg {
+	var names []string
+	for name := range s.resources {
+		if r.target.isCompatibleResource(a.Desc.Name, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var live []*ResultArg
+	for _, name := range names {
+		live = append(live, s.resources[name]...)
+	}
+	if len(live) != 0 && r.bin() {
+		arg := MakeResultArg(a, live[r.Intn(len(live))], 0)
+		arg.OpAdd = 1 + r.rand(4)
+		return arg
+	}
+	return MakeResultArg(a, nil, r.rand(64))
+}
+
 func (a *BufferType) generate(r *randGen, s *state) (arg Arg, calls []*Call) {
 	switch a.Kind {
 	case BufferBlobRand, BufferBlobRange:
@@ -624,6 +870,19 @@ func (a *BufferType) generate(r *randGen, s *state) (arg Arg, calls []*Call) {
 		if a.Dir() == DirOut {
 			return MakeOutDataArg(a, sz), nil
 		}
+		if s.useDict(r) {
+			maxLen := maxBlobLen
+			if a.Kind == BufferBlobRange {
+				maxLen = a.RangeEnd
+			}
+			if blob := s.ct.dict.blobValue(r, 0, maxLen); blob != nil {
+				// Pad the dictionary value to the minimal length with random data.
+				for uint64(len(blob)) < a.RangeBegin {
+					blob = append(blob, byte(r.Intn(256)))
+				}
+				return MakeDataArg(a, blob), nil
+			}
+		}
 		data := make([]byte, sz)
 		for i := range data {
 			data[i] = byte(r.Intn(256))
@@ -656,6 +915,11 @@ func (a *BufferType) generate(r *randGen, s *state) (arg Arg, calls []*Call) {
 			return MakeOutDataArg(a, uint64(r.Intn(100))), nil
 		}
 		return MakeDataArg(a, r.generateText(a.Text)), nil
+	case BufferSerialized:
+		if a.Dir() == DirOut {
+			return MakeOutDataArg(a, r.randBufLen()), nil
+		}
+		return MakeDataArg(a, r.generateSerialized(s, a.Elem)), nil
 	default:
 		panic("unknown buffer kind")
 	}
@@ -670,6 +934,9 @@ func (a *VmaType) generate(r *randGen, s *state) (arg Arg, calls []*Call) {
 }
 
 func (a *FlagsType) generate(r *randGen, s *state) (arg Arg, calls []*Call) {
+	if v, ok := s.exhaustiveValue(a); ok {
+		return MakeConstArg(a, v), nil
+	}
 	return MakeConstArg(a, r.flags(a.Vals)), nil
 }
 
@@ -678,6 +945,17 @@ func (a *ConstType) generate(r *randGen, s *state) (arg Arg, calls []*Call) {
 }
 
 func (a *IntType) generate(r *randGen, s *state) (arg Arg, calls []*Call) {
+	if a.Cookie != "" {
+		return MakeConstArg(a, r.cookie(s, a)), nil
+	}
+	if len(a.Buckets) != 0 {
+		return MakeConstArg(a, r.randBucketInt(a.Buckets)), nil
+	}
+	if (a.Kind == IntPlain || a.Kind == IntRange) && s.useDict(r) {
+		if v, ok := s.ct.dict.intValue(r, a); ok {
+			return MakeConstArg(a, v), nil
+		}
+	}
 	v := r.randInt()
 	switch a.Kind {
 	case IntFileoff:
@@ -691,6 +969,35 @@ func (a *IntType) generate(r *randGen, s *state) (arg Arg, calls []*Call) {
 		}
 	case IntRange:
 		v = r.randRangeInt(a.RangeBegin, a.RangeEnd)
+	case IntPow2:
+		v = r.randPow2(a.RangeBegin, a.RangeEnd)
+	case IntRingHead:
+		// Ring indices are free-running counters, so prefer values
+		// right before the wraparound point. Tail is fixed up relative
+		// to head in assignSizesCall.
+		switch {
+		case r.nOutOf(1, 3):
+			v = 0
+		case r.nOutOf(1, 2):
+			v = -(r.rand(16) + 1)
+		}
+		v &= ringIndexMask(a)
+	case IntRingTail:
+		v &= ringIndexMask(a)
+	case IntRelPtr, IntArrayIndex:
+		v = 0 // filled in by assignSizes
+	case IntParity:
+		v = 0 // computed during serialization for execution
+	case IntFlagIndex:
+		v = a.FlagIndices[r.Intn(len(a.FlagIndices))]
+	case IntChildPid:
+		v = 0 // substituted by executor
+	case IntFuncPtr:
+		if r.nOutOf(9, 10) {
+			v = r.target.FuncPtr(r.Intn(NumFuncPtrs))
+		} else {
+			v = r.target.SpecialPointers[r.Intn(len(r.target.SpecialPointers))]
+		}
 	}
 	return MakeConstArg(a, v), nil
 }
@@ -707,6 +1014,11 @@ func (a *ArrayType) generate(r *randGen, s *state) (arg Arg, calls []*Call) {
 	case ArrayRangeLen:
 		count = r.randRange(a.RangeBegin, a.RangeEnd)
 	}
+	if s.useShape(r) {
+		if n, ok := s.ct.shapes.arrayLen(r, a, -1); ok {
+			count = n
+		}
+	}
 	var inner []Arg
 	for i := uint64(0); i < count; i++ {
 		arg1, calls1 := r.generateArg(s, a.Type)
@@ -723,12 +1035,20 @@ func (a *StructType) generate(r *randGen, s *state) (arg Arg, calls []*Call) {
 }
 
 func (a *UnionType) generate(r *randGen, s *state) (arg Arg, calls []*Call) {
-	optType := a.Fields[r.Intn(len(a.Fields))]
-	opt, calls := r.generateArg(s, optType)
+	idx := r.unionOption(a, -1)
+	if s.useShape(r) {
+		if i, ok := s.ct.shapes.unionOption(r, a, -1); ok {
+			idx = i
+		}
+	}
+	opt, calls := r.generateArg(s, a.Fields[idx])
 	return MakeUnionArg(a, opt), calls
 }
 
 func (a *PtrType) generate(r *randGen, s *state) (arg Arg, calls []*Call) {
+	if a.Depth != 0 {
+		return r.generatePtrChain(s, a)
+	}
 	if r.oneOf(1000) {
 		index := r.rand(len(r.target.SpecialPointers))
 		return MakeSpecialPointerArg(a, index), nil
@@ -738,6 +1058,27 @@ func (a *PtrType) generate(r *randGen, s *state) (arg Arg, calls []*Call) {
 	return arg, calls
 }
 
+// generatePtrChain generates a.Depth levels of nested pointers starting at a (see PtrType.Depth).
+// All levels are allocated regardless of opt, but occasionally one of the intermediate
+// pointers is NULL, which is a valid edge case for such APIs.
+func (r *randGen) generatePtrChain(s *state, a *PtrType) (arg Arg, calls []*Call) {
+	chain := []*PtrType{a}
+	for len(chain) < a.Depth {
+		chain = append(chain, chain[len(chain)-1].Type.(*PtrType))
+	}
+	n := len(chain)
+	if n > 1 && r.oneOf(10) {
+		n = 1 + r.Intn(n-1)
+		arg = MakeSpecialPointerArg(chain[n], 0)
+	} else {
+		arg, calls = r.generateArg(s, chain[n-1].Type)
+	}
+	for i := n - 1; i >= 0; i-- {
+		arg = r.allocAddr(s, chain[i], arg.Size(), arg)
+	}
+	return arg, calls
+}
+
 func (a *LenType) generate(r *randGen, s *state) (arg Arg, calls []*Call) {
 	// Updated later in assignSizesCall.
 	return MakeConstArg(a, 0), nil
@@ -4,6 +4,8 @@
 package prog
 
 import (
+	"bytes"
+	"math/bits"
 	"math/rand"
 	"testing"
 )
@@ -70,3 +72,924 @@ func generateProg(t *testing.T, target *Target, rs rand.Source) *Prog {
 	}
 	return p
 }
+
+func TestGenerateCovering(t *testing.T) {
+	for _, test := range []struct {
+		os, arch string
+	}{{"test", "64"}, {"linux", "amd64"}} {
+		test := test
+		t.Run(test.os+"/"+test.arch, func(t *testing.T) {
+			target, rs, _ := initRandomTargetTest(t, test.os, test.arch)
+			calls := make(map[*Syscall]bool)
+			for _, meta := range target.Syscalls {
+				calls[meta] = true
+			}
+			enabled, _ := target.TransitivelyEnabledCalls(calls)
+			ct := target.BuildChoiceTable(nil, enabled)
+			const ncalls = 20
+			progs := target.GenerateCovering(rs, ncalls, ct)
+			covered := make(map[*Syscall]bool)
+			total := 0
+			for _, p := range progs {
+				if err := p.validate(); err != nil {
+					t.Fatalf("invalid program: %v\n%s", err, p.Serialize())
+				}
+				for _, c := range p.Calls {
+					covered[c.Meta] = true
+				}
+				total += len(p.Calls)
+			}
+			for meta := range enabled {
+				if !covered[meta] {
+					t.Fatalf("syscall %v is not covered", meta.Name)
+				}
+			}
+			// All programs except for the last one are filled up to ncalls.
+			if len(progs) > total/ncalls+1 {
+				t.Fatalf("%v programs for %v calls", len(progs), total)
+			}
+			t.Logf("%v programs with %v calls cover %v syscalls", len(progs), total, len(enabled))
+		})
+	}
+}
+
+func TestCheckGeneration(t *testing.T) {
+	for _, test := range []struct {
+		os, arch string
+	}{{"test", "64"}, {"linux", "amd64"}} {
+		test := test
+		t.Run(test.os+"/"+test.arch, func(t *testing.T) {
+			target, rs, _ := initRandomTargetTest(t, test.os, test.arch)
+			for name, err := range target.CheckGeneration(rs) {
+				t.Errorf("%v: %v", name, err)
+			}
+		})
+	}
+}
+
+func TestResourceReuse(t *testing.T) {
+	target, rs, iters := initTest(t)
+	iters /= 10
+	ct := target.BuildChoiceTable(nil, nil)
+	// Counts resources that are used by more than one argument.
+	// Signals of event resources are paired with waits on purpose, they are not counted.
+	aliased := func(p *Prog) int {
+		n := 0
+		for _, c := range p.Calls {
+			ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+				a, ok := arg.(*ResultArg)
+				if !ok {
+					return
+				}
+				uses := 0
+				for use := range a.uses {
+					if use.Type().(*ResourceType).Event != EventSignal {
+						uses++
+					}
+				}
+				if uses > 1 {
+					n++
+				}
+			})
+		}
+		return n
+	}
+	ct.SetResourceReuse(0)
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 10, ct)
+		if n := aliased(p); n != 0 {
+			t.Fatalf("%v resources are reused with reuse probability 0:\n%s", n, p.Serialize())
+		}
+	}
+	ct.SetResourceReuse(1)
+	total := 0
+	for i := 0; i < iters; i++ {
+		total += aliased(target.Generate(rs, 10, ct))
+	}
+	if total == 0 {
+		t.Fatalf("no resources are reused with reuse probability 1")
+	}
+}
+
+func TestResourceEffects(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	enabled := make(map[*Syscall]bool)
+	for _, name := range []string{"test$res0", "test$res1", "test$res4", "test$res5"} {
+		enabled[target.SyscallMap[name]] = true
+	}
+	ct := target.BuildChoiceTable(nil, enabled)
+	invalidations := 0
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 10, ct)
+		invalid := make(map[*ResultArg]bool)
+		for _, c := range p.Calls {
+			for _, arg := range c.Args {
+				a, ok := arg.(*ResultArg)
+				if !ok || a.Res == nil {
+					continue
+				}
+				if invalid[a.Res] {
+					t.Fatalf("invalidated resource is used by %v:\n%s", c.Meta.Name, p.Serialize())
+				}
+				if a.Type().(*ResourceType).Effect != ResourceUse {
+					invalid[a.Res] = true
+					invalidations++
+				}
+			}
+		}
+	}
+	if invalidations == 0 {
+		t.Fatalf("no resources were invalidated")
+	}
+}
+
+func TestRefcountedResources(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	enabled := make(map[*Syscall]bool)
+	for _, name := range []string{"test$refcnt0", "test$refcnt1", "test$refcnt2", "test$refcnt3"} {
+		enabled[target.SyscallMap[name]] = true
+	}
+	ct := target.BuildChoiceTable(nil, enabled)
+	releases := 0
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 10, ct)
+		refs := make(map[*ResultArg]int)
+		invalid := make(map[*ResultArg]bool)
+		for _, c := range p.Calls {
+			for _, arg := range c.Args {
+				a, ok := arg.(*ResultArg)
+				if !ok || a.Res == nil {
+					continue
+				}
+				if invalid[a.Res] {
+					t.Fatalf("released resource is used by %v:\n%s", c.Meta.Name, p.Serialize())
+				}
+				switch a.Type().(*ResourceType).Effect {
+				case ResourceAcquire:
+					refs[a.Res]++
+				case ResourceRelease:
+					if refs[a.Res] != 0 {
+						refs[a.Res]--
+						releases++
+					} else {
+						invalid[a.Res] = true
+					}
+				}
+			}
+		}
+	}
+	if releases == 0 {
+		t.Fatalf("no acquired references were released")
+	}
+}
+
+func TestEventResources(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	enabled := make(map[*Syscall]bool)
+	for _, name := range []string{"test$event0", "test$event1", "test$event2"} {
+		enabled[target.SyscallMap[name]] = true
+	}
+	ct := target.BuildChoiceTable(nil, enabled)
+	signaled := 0
+	check := func(p *Prog) {
+		pending := make(map[*ResultArg]int)
+		creators := make(map[*ResultArg]*Call)
+		for _, c := range p.Calls {
+			switch c.Meta.Name {
+			case "test$event0":
+				creators[c.Ret] = c
+			case "test$event1":
+				if res := c.Args[0].(*ResultArg).Res; res != nil {
+					pending[res]++
+				}
+			case "test$event2":
+				res := c.Args[0].(*ResultArg).Res
+				if res == nil {
+					continue
+				}
+				if pending[res] > 0 {
+					pending[res]--
+					signaled++
+					continue
+				}
+				if creator := creators[res]; creator != nil && creator.Args[1].(*ConstArg).Val&0x800 == 0 {
+					t.Fatalf("blocking wait without a pending signal:\n%s", p.Serialize())
+				}
+			}
+		}
+	}
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 10, ct)
+		check(p)
+		p.Mutate(rs, 10, ct, nil)
+		check(p)
+	}
+	if signaled == 0 {
+		t.Fatalf("no waits were preceded by signals")
+	}
+}
+
+func TestMarkNonblockingEvents(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{
+			"r0 = test$event0(0x0, 0x1)\ntest$event2(r0)\n",
+			"r0 = test$event0(0x0, 0x801)\ntest$event2(r0)\n",
+		},
+		{
+			"r0 = test$event0(0x0, 0x1)\ntest$event1(r0, 0x0)\ntest$event2(r0)\n",
+			"r0 = test$event0(0x0, 0x1)\ntest$event1(r0, 0x0)\ntest$event2(r0)\n",
+		},
+		{
+			"r0 = test$event0(0x0, 0x1)\ntest$event1(r0, 0x0)\ntest$event2(r0)\ntest$event2(r0)\n",
+			"r0 = test$event0(0x0, 0x801)\ntest$event1(r0, 0x0)\ntest$event2(r0)\ntest$event2(r0)\n",
+		},
+		{
+			"r0 = test$event0(0x0, 0x1)\ntest$event2(r0)\ntest$event1(r0, 0x0)\n",
+			"r0 = test$event0(0x0, 0x801)\ntest$event2(r0)\ntest$event1(r0, 0x0)\n",
+		},
+	}
+	for i, test := range tests {
+		p, err := target.Deserialize([]byte(test.in), Strict)
+		if err != nil {
+			t.Fatalf("#%v: failed to deserialize: %v", i, err)
+		}
+		p.markNonblockingEvents()
+		if got := string(p.Serialize()); got != test.out {
+			t.Errorf("#%v: wrong program:\n%s\nwant:\n%s", i, got, test.out)
+		}
+	}
+}
+
+func TestPlaceSharedRegions(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{
+			"test$shared_setup(&(0x7f0000001000/0x2000)=nil, 0x2000)\n" +
+				"test$shared_use(&(0x7f0000000000)={0x1, 0x2})\n",
+			"test$shared_setup(&(0x7f0000001000/0x2000)=nil, 0x2000)\n" +
+				"test$shared_use(&(0x7f0000001000)={0x1, 0x2})\n",
+		},
+		{
+			"test$shared_use(&(0x7f0000000000)={0x1, 0x2})\n" +
+				"test$shared_setup(&(0x7f0000001000/0x2000)=nil, 0x2000)\n",
+			"test$shared_use(&(0x7f0000000000)={0x1, 0x2})\n" +
+				"test$shared_setup(&(0x7f0000001000/0x2000)=nil, 0x2000)\n",
+		},
+		{
+			"test$shared_setup(&(0x7f0000001000/0x1000)=nil, 0x1000)\n" +
+				"test$shared_setup(&(0x7f0000004000/0x1000)=nil, 0x1000)\n" +
+				"test$shared_use(&(0x7f0000000000)={0x1, 0x2})\n",
+			"test$shared_setup(&(0x7f0000001000/0x1000)=nil, 0x1000)\n" +
+				"test$shared_setup(&(0x7f0000004000/0x1000)=nil, 0x1000)\n" +
+				"test$shared_use(&(0x7f0000004000)={0x1, 0x2})\n",
+		},
+		{
+			"test$shared_setup(0x0, 0x0)\n" +
+				"test$shared_use(&(0x7f0000000000)={0x1, 0x2})\n",
+			"test$shared_setup(0x0, 0x0)\n" +
+				"test$shared_use(&(0x7f0000000000)={0x1, 0x2})\n",
+		},
+	}
+	for i, test := range tests {
+		p, err := target.Deserialize([]byte(test.in), Strict)
+		if err != nil {
+			t.Fatalf("#%v: failed to deserialize: %v", i, err)
+		}
+		p.placeSharedRegions()
+		if got := string(p.Serialize()); got != test.out {
+			t.Errorf("#%v: wrong program:\n%s\nwant:\n%s", i, got, test.out)
+		}
+	}
+}
+
+func TestIntBuckets(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	r := newRand(target, rs)
+	buckets := []IntBucket{
+		{Begin: 1, End: 64, Weight: 90},
+		{Begin: 65, End: 1<<32 - 1, Weight: 10},
+		{Begin: 0, End: ^uint64(0), Weight: 0},
+	}
+	const n = 10000
+	small := 0
+	for i := 0; i < n; i++ {
+		v := r.randBucketInt(buckets)
+		if v == 0 || v >= 1<<32 {
+			t.Fatalf("value 0x%x is outside of all buckets with non-zero weight", v)
+		}
+		if v <= 64 {
+			small++
+		}
+	}
+	if small < n*85/100 || small > n*95/100 {
+		t.Fatalf("%v out of %v values are in the small bucket, expect ~90%%", small, n)
+	}
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{target.SyscallMap["test$int_buckets"]: true})
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 3, ct)
+		p.Mutate(rs, 10, ct, nil)
+		for _, c := range p.Calls {
+			ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+				typ, ok := arg.Type().(*IntType)
+				if !ok || len(typ.Buckets) == 0 {
+					return
+				}
+				v := arg.(*ConstArg).Val
+				for _, b := range typ.Buckets {
+					if v >= b.Begin && v <= b.End {
+						return
+					}
+				}
+				t.Fatalf("%v value 0x%x is outside of all buckets\n%s", typ.FieldName(), v, p.Serialize())
+			})
+		}
+	}
+}
+
+func TestResourceRange(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{target.SyscallMap["test$slot0"]: true})
+	inRange := 0
+	total := 0
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 3, ct)
+		p.Mutate(rs, 10, ct, nil)
+		for _, c := range p.Calls {
+			v := c.Args[0].(*ResultArg).Val
+			total++
+			if v <= 15 {
+				inRange++
+			} else if v != 0xff {
+				t.Fatalf("value 0x%x is neither in range nor special\n%s", v, p.Serialize())
+			}
+		}
+	}
+	if inRange < total/2 {
+		t.Fatalf("only %v out of %v values are in range", inRange, total)
+	}
+}
+
+func TestResourceValidBit(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{
+		target.SyscallMap["test$res0"]: true,
+		target.SyscallMap["test$res2"]: true,
+		target.SyscallMap["test$res9"]: true,
+	})
+	valid, invalid := 0, 0
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 5, ct)
+		p.Mutate(rs, 10, ct, nil)
+		for _, c := range p.Calls {
+			ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+				typ, ok := arg.Type().(*ResourceType)
+				if !ok || typ.ValidMask == 0 {
+					return
+				}
+				a := arg.(*ResultArg)
+				switch {
+				case a.Res != nil && a.OpAdd == typ.ValidMask:
+					valid++
+				case a.Res == nil && a.Val&typ.ValidMask == 0:
+					invalid++
+				default:
+					t.Fatalf("inconsistent valid bit 0x%x of %v\n%s", typ.ValidMask, typ.FieldName(), p.Serialize())
+				}
+			})
+		}
+	}
+	if valid == 0 || invalid == 0 {
+		t.Fatalf("got %v valid and %v invalid handles", valid, invalid)
+	}
+}
+
+func TestResourceByValue(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{
+		target.SyscallMap["test$res0"]:  true,
+		target.SyscallMap["test$res1"]:  true,
+		target.SyscallMap["test$res10"]: true,
+	})
+	live, neighbours, guessed := 0, 0, 0
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 5, ct)
+		p.Mutate(rs, 10, ct, nil)
+		for _, c := range p.Calls {
+			ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+				typ, ok := arg.Type().(*ResourceType)
+				if !ok || typ.Dir() == DirOut {
+					return
+				}
+				a := arg.(*ResultArg)
+				if typ.InvalidPercent == 0 {
+					if a.OpAdd != 0 {
+						t.Fatalf("invalid value of %v without by_value\n%s", c.Meta.Name, p.Serialize())
+					}
+					return
+				}
+				switch {
+				case a.Res != nil && a.OpAdd == 0:
+					live++
+				case a.Res != nil:
+					neighbours++
+				default:
+					guessed++
+				}
+			})
+		}
+	}
+	if live == 0 || neighbours == 0 || guessed == 0 {
+		t.Fatalf("got %v live, %v neighbour and %v guessed values", live, neighbours, guessed)
+	}
+}
+
+func TestExhaustiveFlags(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{target.SyscallMap["test$exhaustive0"]: true})
+	counts := make(map[*FlagsType]map[uint64]int)
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 3, ct)
+		for _, c := range p.Calls {
+			ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+				typ, ok := arg.Type().(*FlagsType)
+				if !ok || !typ.Exhaustive {
+					return
+				}
+				if counts[typ] == nil {
+					counts[typ] = make(map[uint64]int)
+				}
+				counts[typ][arg.(*ConstArg).Val]++
+			})
+		}
+	}
+	if len(counts) != 2 {
+		t.Fatalf("got %v exhaustive flags types, want 2", len(counts))
+	}
+	for typ, vals := range counts {
+		min, max := iters, 0
+		for _, v := range typ.Vals {
+			if vals[v] < min {
+				min = vals[v]
+			}
+			if vals[v] > max {
+				max = vals[v]
+			}
+		}
+		if len(vals) != len(typ.Vals) || max-min > 1 {
+			t.Fatalf("%v values are not covered evenly: %v", typ.FieldName(), vals)
+		}
+	}
+}
+
+func TestFuncPtr(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{target.SyscallMap["test$funcptr"]: true})
+	stubs := make(map[uint64]bool)
+	for i := 0; i < NumFuncPtrs; i++ {
+		stubs[target.FuncPtr(i)] = true
+	}
+	special := make(map[uint64]bool)
+	for _, v := range target.SpecialPointers {
+		special[v] = true
+	}
+	seen := make(map[uint64]bool)
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 3, ct)
+		p.Mutate(rs, 10, ct, nil)
+		for _, c := range p.Calls {
+			ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+				typ, ok := arg.Type().(*IntType)
+				if !ok || typ.Kind != IntFuncPtr {
+					return
+				}
+				v := arg.(*ConstArg).Val
+				if !stubs[v] && !special[v] {
+					t.Fatalf("%v value 0x%x is neither a stub nor a special pointer\n%s",
+						typ.FieldName(), v, p.Serialize())
+				}
+				seen[v] = true
+			})
+		}
+	}
+	for v := range stubs {
+		if !seen[v] {
+			t.Errorf("stub 0x%x was never generated", v)
+		}
+	}
+}
+
+func TestChildPid(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{target.SyscallMap["test$child_pid"]: true})
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 3, ct)
+		p.Mutate(rs, 10, ct, nil)
+		seen := 0
+		for _, c := range p.Calls {
+			ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+				typ, ok := arg.Type().(*IntType)
+				if !ok || typ.Kind != IntChildPid {
+					return
+				}
+				if v := arg.(*ConstArg).Val; v != 0 {
+					t.Fatalf("%v value 0x%x is not 0\n%s", typ.FieldName(), v, p.Serialize())
+				}
+				seen++
+			})
+		}
+		if _, _, childPids := RequiredFeatures(p); childPids != (seen != 0) {
+			t.Fatalf("RequiredFeatures returned %v for %v child pids\n%s", childPids, seen, p.Serialize())
+		}
+	}
+}
+
+func TestRequiredCalls(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{target.SyscallMap["test$requires2"]: true})
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 5, ct)
+		p.Mutate(rs, 10, ct, nil)
+		seen := make(map[*Syscall]bool)
+		for _, c := range p.Calls {
+			for _, name := range c.Meta.Requires {
+				if !seen[target.SyscallMap[name]] {
+					t.Fatalf("%v is not preceded by required %v\n%s", c.Meta.Name, name, p.Serialize())
+				}
+			}
+			seen[c.Meta] = true
+		}
+	}
+}
+
+func TestErrorPaths(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{target.SyscallMap["test$align0"]: true})
+	for _, frac := range []float64{0, 1} {
+		ct.SetErrorPaths(frac)
+		special := 0
+		for i := 0; i < iters; i++ {
+			p := target.Generate(rs, 1, ct)
+			if p.Calls[0].Args[0].(*PointerArg).IsSpecial() {
+				special++
+			}
+		}
+		if frac == 0 && special > iters/10 || frac == 1 && special != iters {
+			t.Fatalf("error paths fraction %v: got %v special pointers out of %v", frac, special, iters)
+		}
+	}
+	// Invalid values must not break programs.
+	ct = target.BuildChoiceTable(nil, nil)
+	ct.SetErrorPaths(1)
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 10, ct)
+		if err := p.validate(); err != nil {
+			t.Fatalf("invalid program: %v\n%s", err, p.Serialize())
+		}
+		p.Mutate(rs, 10, ct, nil)
+		if err := p.validate(); err != nil {
+			t.Fatalf("invalid program after mutation: %v\n%s", err, p.Serialize())
+		}
+	}
+}
+
+func TestScopedResources(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	enabled := make(map[*Syscall]bool)
+	for _, name := range []string{"test$txn_begin", "test$txn_end", "test$txn_obj0", "test$txn_obj1"} {
+		enabled[target.SyscallMap[name]] = true
+	}
+	ct := target.BuildChoiceTable(nil, enabled)
+	used := 0
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 10, ct)
+		p.Mutate(rs, 10, ct, nil)
+		// Resources are used only while the scope they were created in is open.
+		var scopes []map[*ResultArg]bool
+		for _, c := range p.Calls {
+			switch c.Meta.Name {
+			case "test$txn_begin":
+				scopes = append(scopes, make(map[*ResultArg]bool))
+			case "test$txn_end":
+				if len(scopes) != 0 {
+					scopes = scopes[:len(scopes)-1]
+				}
+			case "test$txn_obj0":
+				if len(scopes) != 0 {
+					scopes[len(scopes)-1][c.Ret] = true
+				}
+			case "test$txn_obj1":
+				res := c.Args[0].(*ResultArg).Res
+				if res == nil {
+					continue
+				}
+				valid := false
+				for _, scope := range scopes {
+					valid = valid || scope[res]
+				}
+				if !valid {
+					t.Fatalf("resource is used outside of its scope:\n%s", p.Serialize())
+				}
+				used++
+			}
+		}
+	}
+	if used == 0 {
+		t.Fatalf("scoped resources are never used")
+	}
+}
+
+func TestNamespacedResources(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	enabled := make(map[*Syscall]bool)
+	for _, name := range []string{"test$ns_switch", "test$ns_obj0", "test$ns_obj1"} {
+		enabled[target.SyscallMap[name]] = true
+	}
+	ct := target.BuildChoiceTable(nil, enabled)
+	used := 0
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 10, ct)
+		p.Mutate(rs, 10, ct, nil)
+		// Resources are used only in the namespace context they were created in.
+		valid := make(map[*ResultArg]bool)
+		for _, c := range p.Calls {
+			switch c.Meta.Name {
+			case "test$ns_switch":
+				valid = make(map[*ResultArg]bool)
+			case "test$ns_obj0":
+				valid[c.Ret] = true
+			case "test$ns_obj1":
+				res := c.Args[0].(*ResultArg).Res
+				if res == nil {
+					continue
+				}
+				if !valid[res] {
+					t.Fatalf("resource is used in another namespace context:\n%s", p.Serialize())
+				}
+				used++
+			}
+		}
+	}
+	if used == 0 {
+		t.Fatalf("namespaced resources are never used")
+	}
+}
+
+func TestEnforceNamespaces(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	p, err := target.Deserialize([]byte(`
+r0 = test$ns_obj0()
+test$ns_obj1(r0)
+test$ns_switch()
+test$ns_obj1(r0)
+r1 = test$ns_obj0()
+test$ns_obj1(r1)
+`), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.enforceNamespaces()
+	want := `r0 = test$ns_obj0()
+test$ns_obj1(r0)
+test$ns_switch()
+test$ns_obj1(0x0)
+r1 = test$ns_obj0()
+test$ns_obj1(r1)
+`
+	if got := string(p.Serialize()); got != want {
+		t.Fatalf("got:\n%v\nwant:\n%v", got, want)
+	}
+}
+
+func TestMaxCalls(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	res0, res1 := target.SyscallMap["test$res0"], target.SyscallMap["test$res1"]
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{res0: true, res1: true})
+	if deps := newState(target, ct).minDeps(res1); deps != 1 {
+		t.Fatalf("test$res1 has %v dependencies, want 1", deps)
+	}
+	// test$res1 needs test$res0 to create its resource, so it does not fit into a single call.
+	ct.SetMaxCalls(1)
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 5, ct)
+		if len(p.Calls) != 1 || p.Calls[0].Meta != res0 {
+			t.Fatalf("unexpected program with 1 max call:\n%s", p.Serialize())
+		}
+	}
+	for _, test := range []struct {
+		os, arch string
+	}{{"test", "64"}, {"linux", "amd64"}} {
+		test := test
+		t.Run(test.os+"/"+test.arch, func(t *testing.T) {
+			target, rs, iters := initRandomTargetTest(t, test.os, test.arch)
+			ct := target.BuildChoiceTable(nil, nil)
+			for _, max := range []int{1, 3, 10} {
+				ct.SetMaxCalls(max)
+				for i := 0; i < iters/10; i++ {
+					p := target.Generate(rs, 20, ct)
+					if len(p.Calls) == 0 || len(p.Calls) > max {
+						t.Fatalf("program with %v calls, max calls %v:\n%s", len(p.Calls), max, p.Serialize())
+					}
+					if err := p.validate(); err != nil {
+						t.Fatalf("invalid program: %v\n%s", err, p.Serialize())
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestFlagIndex(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{target.SyscallMap["test$flagindex"]: true})
+	seen := make(map[*IntType]map[uint64]bool)
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 3, ct)
+		p.Mutate(rs, 10, ct, nil)
+		for _, c := range p.Calls {
+			ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+				typ, ok := arg.Type().(*IntType)
+				if !ok || typ.Kind != IntFlagIndex {
+					return
+				}
+				v := arg.(*ConstArg).Val
+				found := false
+				for _, idx := range typ.FlagIndices {
+					found = found || v == idx
+				}
+				if !found {
+					t.Fatalf("%v value %v is not an index of %v flags %v\n%s",
+						typ.FieldName(), v, typ.Flags, typ.FlagIndices, p.Serialize())
+				}
+				if seen[typ] == nil {
+					seen[typ] = make(map[uint64]bool)
+				}
+				seen[typ][v] = true
+			})
+		}
+	}
+	if len(seen) != 3 {
+		t.Fatalf("got %v flagindex types, want 3", len(seen))
+	}
+	for typ, vals := range seen {
+		if len(vals) != len(typ.FlagIndices) {
+			t.Errorf("%v: generated indices %v, want all of %v", typ.FieldName(), vals, typ.FlagIndices)
+		}
+	}
+}
+
+func TestIntPow2(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{target.SyscallMap["test$int_pow2"]: true})
+	seen := make(map[*IntType]map[uint64]bool)
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 3, ct)
+		p.Mutate(rs, 10, ct, nil)
+		for _, c := range p.Calls {
+			ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+				typ, ok := arg.Type().(*IntType)
+				if !ok || typ.Kind != IntPow2 {
+					return
+				}
+				v := arg.(*ConstArg).Val
+				if v&(v-1) != 0 || v < typ.RangeBegin || v > typ.RangeEnd {
+					t.Fatalf("%v value 0x%x is not a power of 2 in [0x%x:0x%x]\n%s",
+						typ.FieldName(), v, typ.RangeBegin, typ.RangeEnd, p.Serialize())
+				}
+				if seen[typ] == nil {
+					seen[typ] = make(map[uint64]bool)
+				}
+				seen[typ][v] = true
+			})
+		}
+	}
+	if len(seen) != 4 {
+		t.Fatalf("got %v int_pow2 types, want 4", len(seen))
+	}
+	for typ, vals := range seen {
+		want := bits.TrailingZeros64(typ.RangeEnd) - bits.TrailingZeros64(typ.RangeBegin) + 1
+		if len(vals) != want {
+			t.Errorf("%v: generated %v values, want %v", typ.FieldName(), len(vals), want)
+		}
+	}
+}
+
+func TestUnionWeights(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{target.SyscallMap["test$union_weights"]: true})
+	counts := make(map[string]int)
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 3, ct)
+		p.Mutate(rs, 10, ct, nil)
+		for _, c := range p.Calls {
+			ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+				if a, ok := arg.(*UnionArg); ok && a.Type().Name() == "syz_union_weights" {
+					counts[a.Option.Type().FieldName()]++
+				}
+			})
+		}
+	}
+	if counts["f0"] != 0 {
+		t.Fatalf("option with zero weight was chosen %v times", counts["f0"])
+	}
+	if counts["f1"] == 0 || counts["f2"] < 3*counts["f1"] {
+		t.Fatalf("options are not chosen according to weights: %v", counts)
+	}
+}
+
+func TestOmittableArgs(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	meta := target.SyscallMap["test$omittable"]
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{meta: true})
+	counts := make(map[int]int)
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 3, ct)
+		p.Mutate(rs, 10, ct, nil)
+		for _, c := range p.Calls {
+			if c.Meta == meta {
+				counts[len(c.Args)]++
+			}
+		}
+		data := p.Serialize()
+		p1, err := target.Deserialize(data, Strict)
+		if err != nil {
+			t.Fatalf("failed to deserialize: %v\n%s", err, data)
+		}
+		if data1 := p1.Serialize(); !bytes.Equal(data, data1) {
+			t.Fatalf("program changed after deserialization:\n%s\n%s", data, data1)
+		}
+	}
+	for n := len(meta.Args) - meta.OmittableArgs; n <= len(meta.Args); n++ {
+		if counts[n] == 0 {
+			t.Errorf("no calls with %v args: %v", n, counts)
+		}
+	}
+}
+
+func TestVersionedUnion(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	meta := target.SyscallMap["test$versioned"]
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{meta: true})
+	counts := make(map[string]int)
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 3, ct)
+		p.Mutate(rs, 10, ct, nil)
+		for _, c := range p.Calls {
+			ptr := c.Args[0].(*PointerArg)
+			if ptr.Res == nil {
+				continue
+			}
+			opt := ptr.Res.(*UnionArg).Option
+			ver := opt.(*GroupArg).Inner[0].(*ConstArg).Val
+			counts[opt.Type().FieldName()]++
+			if want := map[string]uint64{"v1": 1, "v2": 2}[opt.Type().FieldName()]; ver != want {
+				t.Fatalf("option %v has version %v, want %v\n%s",
+					opt.Type().FieldName(), ver, want, p.Serialize())
+			}
+		}
+	}
+	if counts["v1"] == 0 || counts["v2"] == 0 {
+		t.Fatalf("not all versions were generated: %v", counts)
+	}
+}
+
+func TestPtrChain(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	meta := target.SyscallMap["test$ptr_chain"]
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{meta: true})
+	full, null := 0, 0
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 3, ct)
+		for _, c := range p.Calls {
+			// All 3 levels are allocated, except for occasional NULL at an intermediate level.
+			arg := c.Args[0]
+			levels := 0
+			for ; levels < 3; levels++ {
+				ptr := arg.(*PointerArg)
+				if ptr.IsSpecial() {
+					if levels == 0 || ptr.Address != 0 {
+						t.Fatalf("bad pointer at level %v:\n%s", levels, p.Serialize())
+					}
+					null++
+					break
+				}
+				arg = ptr.Res
+			}
+			if levels == 3 {
+				if _, ok := arg.(*ConstArg); !ok {
+					t.Fatalf("bad chain pointee %#v:\n%s", arg, p.Serialize())
+				}
+				full++
+			}
+		}
+	}
+	if full == 0 || null == 0 || full < null {
+		t.Fatalf("generated %v full chains and %v chains with NULL", full, null)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+// Int relations (see IntType.Relation) describe arguments that must be ordered relative to each other,
+// e.g. start and end of a range. Values are generated and mutated independently and then
+// assignIntRelations moves the value of the field with the relation into the range of values
+// satisfying the relation (preserving as much of the random value as possible). The referenced
+// field is changed only if no value of the field with the relation satisfies it. Relations are
+// fixed up in field order, so chains of relations are not guaranteed to hold.
+
+// assignIntRelations fixes up values of int fields in args and in structs nested in args
+// that violate relations with their sibling fields. Returns true if any of the values has changed.
+func assignIntRelations(args []Arg) bool {
+	changed := assignIntRelationsArray(args)
+	for _, arg := range args {
+		ForeachSubArg(arg, func(arg Arg, _ *ArgCtx) {
+			if _, ok := arg.Type().(*StructType); ok {
+				changed = assignIntRelationsArray(arg.(*GroupArg).Inner) || changed
+			}
+		})
+	}
+	return changed
+}
+
+func assignIntRelationsArray(args []Arg) bool {
+	argsMap := make(map[string]Arg)
+	for _, arg := range args {
+		if !IsPad(arg.Type()) {
+			argsMap[arg.Type().FieldName()] = arg
+		}
+	}
+	changed := false
+	for _, arg := range args {
+		typ, ok := arg.Type().(*IntType)
+		if !ok || typ.Relation == IntRelNone {
+			continue
+		}
+		ref, ok := argsMap[typ.RelationField].(*ConstArg)
+		if !ok {
+			// The referenced syscall argument was omitted.
+			continue
+		}
+		a := arg.(*ConstArg)
+		refTyp := ref.Type().(*IntType)
+		// Values are compared as they are executed, executor stores only the bits of the field width.
+		val, refVal := a.Val&ringIndexMask(typ), ref.Val&ringIndexMask(refTyp)
+		if typ.Relation.holds(val, refVal) {
+			continue
+		}
+		begin, end, ok := typ.Relation.satisfying(typ, refVal)
+		if !ok {
+			// No value of the field satisfies the relation, so move the referenced field
+			// to a value that some values of the field are in relation with.
+			refBegin, refEnd := refTyp.valueRange()
+			switch typ.Relation {
+			case IntRelLess, IntRelLessEqual:
+				refVal = refEnd
+			case IntRelEqual:
+				refVal, _ = typ.valueRange()
+				if refVal < refBegin {
+					refVal = refBegin
+				}
+			case IntRelNotEqual:
+				refVal = nextInRange(refVal, refBegin, refEnd)
+			}
+			ref.Val = refVal
+			begin, end, _ = typ.Relation.satisfying(typ, refVal)
+		}
+		if typ.Relation == IntRelNotEqual {
+			if val == refVal {
+				val = nextInRange(val, begin, end)
+			}
+		} else if val < begin || val > end {
+			val = begin + (val-begin)%(end-begin+1)
+		}
+		a.Val = val
+		changed = true
+	}
+	return changed
+}
+
+// holds returns true if values a and b are in relation rel.
+func (rel IntRelation) holds(a, b uint64) bool {
+	switch rel {
+	case IntRelLess:
+		return a < b
+	case IntRelLessEqual:
+		return a <= b
+	case IntRelEqual:
+		return a == b
+	case IntRelNotEqual:
+		return a != b
+	}
+	return true
+}
+
+// satisfying returns the range of values of typ that are in relation rel with value b
+// (for IntRelNotEqual the whole range of typ, the range excludes only b itself).
+// Returns false if no values of typ satisfy the relation.
+func (rel IntRelation) satisfying(typ *IntType, b uint64) (uint64, uint64, bool) {
+	begin, end := typ.valueRange()
+	switch rel {
+	case IntRelLess:
+		if b == 0 {
+			return 0, 0, false
+		}
+		if end > b-1 {
+			end = b - 1
+		}
+	case IntRelLessEqual:
+		if end > b {
+			end = b
+		}
+	case IntRelEqual:
+		if b < begin || b > end {
+			return 0, 0, false
+		}
+		begin, end = b, b
+	case IntRelNotEqual:
+		if begin == end && begin == b {
+			return 0, 0, false
+		}
+	}
+	return begin, end, begin <= end
+}
+
+// valueRange returns the range of values of the int type: the range for IntRange
+// or all values that fit into the type.
+func (t *IntType) valueRange() (uint64, uint64) {
+	if t.Kind == IntRange {
+		return t.RangeBegin, t.RangeEnd
+	}
+	return 0, ringIndexMask(t)
+}
+
+// nextInRange returns the value following v in range [begin, end] wrapping around at the end.
+func nextInRange(v, begin, end uint64) uint64 {
+	if v < begin || v >= end {
+		return begin
+	}
+	return v + 1
+}
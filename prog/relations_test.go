@@ -0,0 +1,87 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIntRelations(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{
+		target.SyscallMap["test$int_relations0"]: true,
+		target.SyscallMap["test$int_relations1"]: true,
+	})
+	check := func(p *Prog) {
+		for _, c := range p.Calls {
+			ForeachArg(c, func(arg Arg, ctx *ArgCtx) {
+				typ, ok := arg.Type().(*IntType)
+				if !ok || typ.Relation == IntRelNone {
+					return
+				}
+				fields := c.Args
+				if ctx.Parent != nil {
+					fields = *ctx.Parent
+				}
+				for _, ref := range fields {
+					if ref.Type().FieldName() != typ.RelationField {
+						continue
+					}
+					a := arg.(*ConstArg).Val & ringIndexMask(typ)
+					b := ref.(*ConstArg).Val & ringIndexMask(ref.Type().(*IntType))
+					if !typ.Relation.holds(a, b) {
+						t.Fatalf("%v=%v violates relation %v to %v=%v:\n%s",
+							typ.FieldName(), a, typ.Relation, typ.RelationField, b, p.Serialize())
+					}
+				}
+			})
+		}
+	}
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 10, ct)
+		check(p)
+		for j := 0; j < 10; j++ {
+			p.Mutate(rs, 20, ct, nil)
+			check(p)
+		}
+	}
+}
+
+func TestIntRelationsDeserialize(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{
+			input:  `test$int_relations0(0x10, 0x5, 0x0)`,
+			output: `test$int_relations0(0x1, 0x5, 0x0)`,
+		},
+		{
+			input:  `test$int_relations0(0x7, 0x0, 0x0)`,
+			output: `test$int_relations0(0x7, 0x64, 0x0)`,
+		},
+		{
+			input:  `test$int_relations1(0xa, 0xa)`,
+			output: `test$int_relations1(0x0, 0xa)`,
+		},
+		{
+			input:  `test$int_relations0(0x0, 0x1, &(0x7f0000000000)={0x40, 0x20, 0x3, 0x8, 0x0, 0x0})`,
+			output: `test$int_relations0(0x0, 0x1, &(0x7f0000000000)={0x1f, 0x20, 0x8, 0x8, 0x1})`,
+		},
+	}
+	for i, test := range tests {
+		if _, err := target.Deserialize([]byte(test.input), Strict); err == nil {
+			t.Errorf("#%v: strict deserialization of %q did not fail", i, test.input)
+		}
+		p, err := target.Deserialize([]byte(test.input), NonStrict)
+		if err != nil {
+			t.Fatalf("#%v: failed to deserialize %q: %v", i, test.input, err)
+		}
+		if got := strings.TrimSpace(string(p.Serialize())); got != test.output {
+			t.Errorf("#%v: got %q, want %q", i, got, test.output)
+		}
+	}
+}
@@ -43,6 +43,40 @@ func (target *Target) calcResourceCtors(kind []string, precise bool) []*Syscall
 	return metas
 }
 
+// ForeachStructResource calls f for every resource field of the struct or union desc,
+// including resources nested in structs, unions, arrays and pointers.
+// path contains names of the fields leading to the resource, array elements are denoted
+// with "[]" and pointer indirections with "*". Recursive structs are not entered again.
+func ForeachStructResource(desc *StructDesc, f func(path []string, typ *ResourceType)) {
+	onPath := make(map[*StructDesc]bool)
+	var rec func(t Type, path []string)
+	recFields := func(desc *StructDesc, path []string) {
+		if onPath[desc] {
+			return
+		}
+		onPath[desc] = true
+		for _, fld := range desc.Fields {
+			rec(fld, append(path, fld.FieldName()))
+		}
+		delete(onPath, desc)
+	}
+	rec = func(t Type, path []string) {
+		switch a := t.(type) {
+		case *ResourceType:
+			f(append([]string{}, path...), a)
+		case *PtrType:
+			rec(a.Type, append(path, "*"))
+		case *ArrayType:
+			rec(a.Type, append(path, "[]"))
+		case *StructType:
+			recFields(a.StructDesc, path)
+		case *UnionType:
+			recFields(a.StructDesc, path)
+		}
+	}
+	recFields(desc, nil)
+}
+
 // isCompatibleResource returns true if resource of kind src can be passed as an argument of kind dst.
 func (target *Target) isCompatibleResource(dst, src string) bool {
 	if dst == target.any.res16.TypeName ||
@@ -61,7 +95,16 @@ func (target *Target) isCompatibleResource(dst, src string) bool {
 	if srcRes == nil {
 		panic(fmt.Sprintf("unknown resource '%v'", src))
 	}
-	return isCompatibleResourceImpl(dstRes.Kind, srcRes.Kind, false)
+	if isCompatibleResourceImpl(dstRes.Kind, srcRes.Kind, false) {
+		return true
+	}
+	// Resources declared as compatible_with can be substituted for one another.
+	for _, name := range dstRes.Compatible {
+		if isCompatibleResourceImpl(target.resourceMap[name].Kind, srcRes.Kind, false) {
+			return true
+		}
+	}
+	return false
 }
 
 // isCompatibleResourceImpl returns true if resource of kind src can be passed as an argument of kind dst.
@@ -95,7 +138,8 @@ func (target *Target) inputResources(c *Syscall) []*ResourceDesc {
 		}
 		switch typ1 := typ.(type) {
 		case *ResourceType:
-			if !typ1.IsOptional {
+			// Resources with a value range can always be used without creating them.
+			if !typ1.IsOptional && !typ1.Desc.HasRange {
 				resources = append(resources, typ1.Desc)
 			}
 		case *StructType:
@@ -141,9 +185,15 @@ func (target *Target) TransitivelyEnabledCalls(enabled map[*Syscall]bool) (map[*
 			if supported[c] {
 				continue
 			}
-			ready := true
+			ready := target.canSubmit(c, supported)
+			for _, name := range c.Requires {
+				if !supported[target.SyscallMap[name]] {
+					ready = false
+					break
+				}
+			}
 			for _, res := range inputResources[c] {
-				if !canCreate[res.Name] {
+				if !canCreateResource(canCreate, res) {
 					ready = false
 					break
 				}
@@ -166,8 +216,20 @@ func (target *Target) TransitivelyEnabledCalls(enabled map[*Syscall]bool) (map[*
 		if supported[c] {
 			continue
 		}
+		for _, name := range c.Requires {
+			if !supported[target.SyscallMap[name]] {
+				disabled[c] = fmt.Sprintf("required syscall %v is not enabled or supported", name)
+				break
+			}
+		}
+		if disabled[c] == "" && !target.canSubmit(c, supported) {
+			disabled[c] = fmt.Sprintf("none of completed syscalls %v is enabled or supported", c.Completes)
+		}
+		if disabled[c] != "" {
+			continue
+		}
 		for _, res := range inputResources[c] {
-			if canCreate[res.Name] {
+			if canCreateResource(canCreate, res) {
 				continue
 			}
 			if ctors[res.Name] == nil {
@@ -188,3 +250,145 @@ func (target *Target) TransitivelyEnabledCalls(enabled map[*Syscall]bool) (map[*
 	}
 	return supported, disabled
 }
+
+// canSubmit returns true if c is not a completion call (see Syscall.Completes),
+// or at least one of the calls it completes is supported.
+func (target *Target) canSubmit(c *Syscall, supported map[*Syscall]bool) bool {
+	if len(c.Completes) == 0 {
+		return true
+	}
+	for _, name := range c.Completes {
+		if supported[target.SyscallMap[name]] {
+			return true
+		}
+	}
+	return false
+}
+
+func canCreateResource(canCreate map[string]bool, res *ResourceDesc) bool {
+	if canCreate[res.Name] {
+		return true
+	}
+	for _, name := range res.Compatible {
+		if canCreate[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// ReachableCalls returns all syscalls that become usable once a resource res is available:
+// calls that consume res, plus calls that consume resources produced by those calls, and so on.
+// If maxDepth is positive, it bounds the number of consumer hops (1 means only direct consumers).
+// The result is sorted by syscall ID.
+func (target *Target) ReachableCalls(res *ResourceDesc, maxDepth int) []*Syscall {
+	consumes := make(map[*Syscall][]*ResourceDesc)
+	for _, c := range target.Syscalls {
+		ForeachType(c, func(typ Type) {
+			if typ1, ok := typ.(*ResourceType); ok && typ1.Dir() != DirOut {
+				consumes[c] = append(consumes[c], typ1.Desc)
+			}
+		})
+	}
+	reached := make(map[*Syscall]bool)
+	seen := map[string]bool{res.Name: true}
+	frontier := []*ResourceDesc{res}
+	for depth := 0; len(frontier) != 0 && (maxDepth <= 0 || depth < maxDepth); depth++ {
+		var next []*ResourceDesc
+		for _, c := range target.Syscalls {
+			if reached[c] || !consumesAny(consumes[c], frontier) {
+				continue
+			}
+			reached[c] = true
+			for _, out := range target.outputResources(c) {
+				if !seen[out.Name] {
+					seen[out.Name] = true
+					next = append(next, out)
+				}
+			}
+		}
+		frontier = next
+	}
+	var calls []*Syscall
+	for _, c := range target.Syscalls {
+		if reached[c] {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// ResourceCalls returns calls that produce resource res (producers) and calls that accept it
+// as an argument (consumers). Only calls that work with res or a more specialized resource
+// are returned, e.g. for a socket resource calls that accept a generic fd are not consumers.
+// Both lists are sorted by syscall ID, a call can be both a producer and a consumer.
+func (target *Target) ResourceCalls(res *ResourceDesc) (producers, consumers []*Syscall) {
+	producers = target.calcResourceCtors(res.Kind, true)
+	for _, c := range target.Syscalls {
+		consumer := false
+		ForeachType(c, func(typ Type) {
+			if typ1, ok := typ.(*ResourceType); ok && typ1.Dir() != DirOut &&
+				isCompatibleResourceImpl(res.Kind, typ1.Desc.Kind, true) {
+				consumer = true
+			}
+		})
+		if consumer {
+			consumers = append(consumers, c)
+		}
+	}
+	return
+}
+
+// ResourceInvalidator is a call that invalidates a resource passed as one of its arguments.
+type ResourceInvalidator struct {
+	Call   *Syscall
+	Arg    int            // index of the argument that accepts the resource
+	Effect ResourceEffect // ResourceInvalidate, ResourceTransform or ResourceRelease
+	// Conditional is set if the call invalidates the resource only in some cases:
+	// it drops a reference to a refcounted resource (the resource is invalidated only
+	// when the last reference is dropped), or the argument is omittable.
+	Conditional bool
+}
+
+// ResourceInvalidators returns calls that invalidate resource res: calls with lifetime attributes
+// (consumes_and_invalidates, transforms, releases) of arguments that accept res
+// (including arguments of a less specialized resource, e.g. close invalidates sockets).
+// The result is sorted by syscall ID and argument index.
+func (target *Target) ResourceInvalidators(res *ResourceDesc) []ResourceInvalidator {
+	return target.resourceInvalidators[res.Name]
+}
+
+func (target *Target) calcResourceInvalidators() map[string][]ResourceInvalidator {
+	index := make(map[string][]ResourceInvalidator)
+	for _, c := range target.Syscalls {
+		for i, arg := range c.Args {
+			typ, ok := arg.(*ResourceType)
+			if !ok || typ.Effect == ResourceUse || typ.Effect == ResourceAcquire {
+				continue
+			}
+			inv := ResourceInvalidator{
+				Call:        c,
+				Arg:         i,
+				Effect:      typ.Effect,
+				Conditional: typ.Effect == ResourceRelease || i >= len(c.Args)-c.OmittableArgs,
+			}
+			for _, res := range target.Resources {
+				if isCompatibleResourceImpl(typ.Desc.Kind, res.Kind, true) {
+					index[res.Name] = append(index[res.Name], inv)
+				}
+			}
+		}
+	}
+	return index
+}
+
+func consumesAny(inputs, available []*ResourceDesc) bool {
+	for _, in := range inputs {
+		for _, res := range available {
+			if isCompatibleResourceImpl(in.Kind, res.Kind, true) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+// Scoped resources (see ResourceDesc.Scope) are valid only between a call that begins their scope
+// (see Syscall.ScopeBegin) and a call that ends it (see Syscall.ScopeEnd), e.g. handles of objects
+// created within a transaction become invalid on commit or abort of the transaction.
+// Scopes nest: a resource belongs to the innermost scope that is open when the resource is created,
+// and the end of a scope ends the innermost open scope with the same name and invalidates
+// resources that belong to it. Resources created outside of their scope are never valid.
+// Generation begins a scope before creating a scoped resource if the scope is not open yet,
+// and enforceScopes replaces uses of invalid scoped resources with default values
+// after programs are changed (e.g. when a call that begins the scope is removed).
+
+// scope holds resources that belong to an open scope.
+type scope []*ResultArg
+
+// noteScopedResource records resource res created by a call in the innermost open scope of res,
+// and returns false if res is scoped and its scope is not open (then res is never valid).
+func (s *state) noteScopedResource(res *ResultArg) bool {
+	name := res.Type().(*ResourceType).Desc.Scope
+	if name == "" {
+		return true
+	}
+	scopes := s.scopes[name]
+	if len(scopes) == 0 {
+		return false
+	}
+	scopes[len(scopes)-1] = append(scopes[len(scopes)-1], res)
+	return true
+}
+
+// openScopes opens scopes that are open in s0 (without their resources) in s.
+func (s *state) openScopes(s0 *state) {
+	for name, scopes := range s0.scopes {
+		if len(scopes) != 0 {
+			s.scopes[name] = []scope{nil}
+		}
+	}
+}
+
+// endScope ends the innermost open scope with the given name and invalidates its resources.
+func (s *state) endScope(name string) {
+	scopes := s.scopes[name]
+	if len(scopes) == 0 {
+		return
+	}
+	for _, res := range scopes[len(scopes)-1] {
+		s.invalidateResource(res)
+	}
+	s.scopes[name] = scopes[:len(scopes)-1]
+}
+
+// generateScopeBegin returns a random enabled call that begins scope name
+// preceded by calls that create its resources, or nil if there are no such calls.
+func (r *randGen) generateScopeBegin(s *state, name string) []*Call {
+	var metas []*Syscall
+	for _, meta := range r.target.Syscalls {
+		if meta.ScopeBegin == name && (s.ct == nil || s.ct.enabled[meta]) {
+			metas = append(metas, meta)
+		}
+	}
+	if len(metas) == 0 {
+		return nil
+	}
+	return r.generateParticularCall(s, metas[r.Intn(len(metas))])
+}
+
+// enforceScopes replaces uses of scoped resources outside of their scopes with default values.
+func (p *Prog) enforceScopes() {
+	valid := make(map[*ResultArg]bool)
+	scopes := make(map[string][]scope)
+	for _, c := range p.Calls {
+		if name := c.Meta.ScopeBegin; name != "" {
+			scopes[name] = append(scopes[name], nil)
+		}
+		ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+			typ, ok := arg.Type().(*ResourceType)
+			if !ok {
+				return
+			}
+			a := arg.(*ResultArg)
+			if a.Res != nil && a.Res.Type().(*ResourceType).Desc.Scope != "" && !valid[a.Res] {
+				replaceResultArg(a, MakeResultArg(typ, nil, typ.Default()))
+			}
+			if name := typ.Desc.Scope; name != "" && typ.Dir() != DirIn && len(scopes[name]) != 0 {
+				top := len(scopes[name]) - 1
+				scopes[name][top] = append(scopes[name][top], a)
+				valid[a] = true
+			}
+		})
+		if name := c.Meta.ScopeEnd; name != "" && len(scopes[name]) != 0 {
+			top := len(scopes[name]) - 1
+			for _, res := range scopes[name][top] {
+				delete(valid, res)
+			}
+			scopes[name] = scopes[name][:top]
+		}
+	}
+}
+
+// scopeEndIndex returns index of the call that ends the scope begun by call i of p,
+// or -1 if the scope is not ended in p.
+func scopeEndIndex(p *Prog, i int) int {
+	name := p.Calls[i].Meta.ScopeBegin
+	depth := 0
+	for j := i + 1; j < len(p.Calls); j++ {
+		meta := p.Calls[j].Meta
+		if meta.ScopeEnd == name {
+			if depth == 0 {
+				return j
+			}
+			depth--
+		}
+		if meta.ScopeBegin == name {
+			depth++
+		}
+	}
+	return -1
+}
@@ -0,0 +1,112 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// This file implements serialized buffers: buffers that contain a value of the format type
+// in the same binary form as the executor would lay it out in memory.
+
+package prog
+
+import (
+	"fmt"
+)
+
+// maxSerializedDepth bounds nesting of serialized buffers during generation.
+// Buffers nested deeper than that are generated empty.
+const maxSerializedDepth = 3
+
+func (r *randGen) generateSerialized(s *state, format Type) []byte {
+	if r.serializedDepth >= maxSerializedDepth {
+		return nil
+	}
+	r.serializedDepth++
+	defer func() {
+		r.serializedDepth--
+	}()
+	arg, calls := r.generateArg(s, format)
+	if len(calls) != 0 {
+		panic(fmt.Sprintf("serialized format %v requires calls", format.Name()))
+	}
+	r.target.assignSizesArray([]Arg{arg}, nil)
+	return serializeArg(arg)
+}
+
+// serializeArg returns memory contents of arg as it would be copied in by the executor.
+// Arg must not contain pointers, resources and other values that are known only during execution,
+// the compiler ensures that formats of serialized buffers don't contain them.
+func serializeArg(arg Arg) []byte {
+	data := make([]byte, arg.Size())
+	serializeArgImpl(data, arg, arg.Type().Format())
+	return data
+}
+
+func serializeArgImpl(data []byte, arg Arg, format BinaryFormat) {
+	switch a := arg.(type) {
+	case *GroupArg:
+		offset := uint64(0)
+		for _, inner := range a.Inner {
+			innerFormat := inner.Type().Format()
+			if innerFormat == FormatNative {
+				innerFormat = format
+			}
+			serializeArgImpl(data[offset:], inner, innerFormat)
+			// Byte order marks switch format of the subsequent fields (see encodingexec.go).
+			if typ, ok := inner.Type().(*IntType); ok && typ.ByteOrderMark {
+				format = FormatNative
+				if inner.(*ConstArg).Val == typ.BigEndianMark {
+					format = FormatBigEndian
+				}
+			}
+			if !inner.Type().BitfieldMiddle() {
+				offset += inner.Size()
+			}
+		}
+	case *UnionArg:
+		innerFormat := a.Option.Type().Format()
+		if innerFormat == FormatNative {
+			innerFormat = format
+		}
+		serializeArgImpl(data, a.Option, innerFormat)
+	case *DataArg:
+		if a.Type().Dir() != DirOut {
+			copy(data, a.Data())
+		}
+	case *ConstArg:
+		serializeConst(data, a, format)
+	default:
+		panic(fmt.Sprintf("can't serialize arg %#v", arg))
+	}
+}
+
+func serializeConst(data []byte, arg *ConstArg, format BinaryFormat) {
+	typ := arg.Type()
+	size := arg.Size()
+	if typ.Dir() == DirOut || IsPad(typ) || size == 0 {
+		return
+	}
+	val, _ := arg.Value()
+	switch format {
+	case FormatNative, FormatBigEndian:
+		bigEndian := format == FormatBigEndian
+		if bfLen := typ.BitfieldLength(); bfLen != 0 {
+			// Mirrors STORE_BY_BITMASK in the executor.
+			mask := (uint64(1)<<bfLen - 1) << typ.BitfieldOffset()
+			old := loadInt(data, int(size))
+			if bigEndian {
+				old = swapInt(old, int(size))
+			}
+			val = old&^mask | val<<typ.BitfieldOffset()&mask
+		}
+		if bigEndian {
+			val = swapInt(val, int(size))
+		}
+		storeInt(data, val, int(size))
+	case FormatStrDec:
+		copy(data, fmt.Sprintf("%020d", val))
+	case FormatStrHex:
+		copy(data, fmt.Sprintf("0x%016x", val))
+	case FormatStrOct:
+		copy(data, fmt.Sprintf("%023o", val))
+	default:
+		panic(fmt.Sprintf("unknown binary format %v", format))
+	}
+}
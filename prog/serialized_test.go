@@ -0,0 +1,83 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestSerializeArg(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	typ := target.SyscallMap["test$serialized"].Args[0].(*PtrType).Type.(*BufferType).Elem
+	arg := typ.DefaultArg().(*GroupArg)
+	arg.Inner[0].(*ConstArg).Val = 32
+	arg.Inner[1].(*ConstArg).Val = 0x1234
+	arg.Inner[2].(*ConstArg).Val = 0x5
+	arg.Inner[3].(*ConstArg).Val = 0x1f
+	arg.Inner[5].(*ConstArg).Val = 0x0102030405060708
+	arg.Inner[6].(*DataArg).SetData([]byte("ab"))
+	arg.Inner[8].(*UnionArg).Option.(*ConstArg).Val = 0x11
+	want := []byte{
+		0x20, 0x00, 0x00, 0x00, // len
+		0x12, 0x34, // int16be
+		0xfd,                                           // int8:3 and int8:5
+		0x00,                                           // pad
+		0x08, 0x07, 0x06, 0x05, 0x04, 0x03, 0x02, 0x01, // int64
+		'a', 'b', // array
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // pad
+		0x11, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // union
+	}
+	if got := serializeArg(arg); !bytes.Equal(got, want) {
+		t.Fatalf("serialized wrong data:\ngot:  %x\nwant: %x", got, want)
+	}
+}
+
+func TestSerializedGeneration(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{target.SyscallMap["test$serialized"]: true})
+	// The test layout of syz_serialized_struct: fields up to the union take 24 bytes,
+	// the union is either an int64 or a nested serialized syz_serialized_struct.
+	var check func(data []byte) int
+	check = func(data []byte) int {
+		if len(data) == 0 {
+			return 0
+		}
+		if len(data) < 24 {
+			t.Fatalf("serialized data is too short: %x", data)
+		}
+		if size := binary.LittleEndian.Uint32(data); size != uint32(len(data)) {
+			t.Fatalf("serialized len is %v, want %v: %x", size, len(data), data)
+		}
+		if len(data) == 24+8 {
+			return 1
+		}
+		return 1 + check(data[24:])
+	}
+	maxDepth := 0
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 3, ct)
+		for _, c := range p.Calls {
+			ptr := c.Args[0].(*PointerArg)
+			if ptr.Res == nil {
+				continue
+			}
+			data := ptr.Res.(*DataArg).Data()
+			if c.Args[1].(*ConstArg).Val != uint64(len(data)) {
+				t.Fatalf("bad buffer len %v, want %v:\n%s", c.Args[1].(*ConstArg).Val, len(data), p.Serialize())
+			}
+			depth := check(data)
+			if depth > maxSerializedDepth {
+				t.Fatalf("serialized nesting depth %v exceeds %v:\n%s", depth, maxSerializedDepth, p.Serialize())
+			}
+			if maxDepth < depth {
+				maxDepth = depth
+			}
+		}
+	}
+	if maxDepth < 2 {
+		t.Fatalf("no nested serialized buffers were generated")
+	}
+}
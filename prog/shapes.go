@@ -0,0 +1,100 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"fmt"
+)
+
+// Shapes is a set of argument shapes extracted from interesting programs (e.g. crash reproducers):
+// options chosen for unions and lengths of arrays. Shapes are keyed by types rather than by calls,
+// so a shape seen in one call is reproduced in all other calls that use the same union
+// or array of the same elements. Set it for generation and mutation with ChoiceTable.SetShapes.
+type Shapes struct {
+	unions map[string][]string // union name -> names of chosen options
+	arrays map[string][]uint64 // array key (see arrayShapeKey) -> lengths
+}
+
+// A shape is used for one out of shapeProb suitable arguments (if there is a shape for the type).
+const shapeProb = 5
+
+// AddProg adds shapes of all arguments of p to the set.
+func (shapes *Shapes) AddProg(p *Prog) {
+	if shapes.unions == nil {
+		shapes.unions = make(map[string][]string)
+		shapes.arrays = make(map[string][]uint64)
+	}
+	for _, c := range p.Calls {
+		ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+			switch t := arg.Type().(type) {
+			case *UnionType:
+				opt := arg.(*UnionArg).Option.Type().FieldName()
+				shapes.unions[t.Name()] = append(shapes.unions[t.Name()], opt)
+			case *ArrayType:
+				if t.Kind == ArrayRangeLen && t.RangeBegin == t.RangeEnd {
+					return
+				}
+				key := arrayShapeKey(t)
+				shapes.arrays[key] = append(shapes.arrays[key], uint64(len(arg.(*GroupArg).Inner)))
+			}
+		})
+	}
+}
+
+// Len returns number of shapes in the set.
+func (shapes *Shapes) Len() int {
+	n := 0
+	for _, opts := range shapes.unions {
+		n += len(opts)
+	}
+	for _, lens := range shapes.arrays {
+		n += len(lens)
+	}
+	return n
+}
+
+func arrayShapeKey(t *ArrayType) string {
+	return fmt.Sprintf("array[%v]", t.Type.Name())
+}
+
+// useShape decides if the current argument should be generated from the choice table shapes.
+func (s *state) useShape(r *randGen) bool {
+	return s.ct != nil && s.ct.shapes != nil && r.oneOf(shapeProb)
+}
+
+// unionOption returns index of a random option of union t from the set other than current
+// (-1 for none), or false if there are no such options. Options with zero weight are not returned.
+func (shapes *Shapes) unionOption(r *randGen, t *UnionType, current int) (int, bool) {
+	var idxs []int
+	for _, opt := range shapes.unions[t.Name()] {
+		for i, f := range t.Fields {
+			if i == current || t.OptionWeights != nil && t.OptionWeights[i] == 0 {
+				continue
+			}
+			if f.FieldName() == opt {
+				idxs = append(idxs, i)
+			}
+		}
+	}
+	if len(idxs) == 0 {
+		return 0, false
+	}
+	return idxs[r.Intn(len(idxs))], true
+}
+
+// arrayLen returns a random length of array t from the set other than current (-1 for none)
+// that fits into range of t, or false if there are no such lengths.
+func (shapes *Shapes) arrayLen(r *randGen, t *ArrayType, current int) (uint64, bool) {
+	var lens []uint64
+	for _, n := range shapes.arrays[arrayShapeKey(t)] {
+		if int(n) == current || t.Kind == ArrayRangeLen && (n < t.RangeBegin || n > t.RangeEnd) {
+			continue
+		}
+		lens = append(lens, n)
+	}
+	if len(lens) == 0 {
+		return 0, false
+	}
+	return lens[r.Intn(len(lens))], true
+}
@@ -0,0 +1,55 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"testing"
+)
+
+func TestShapes(t *testing.T) {
+	target, rs, _ := initRandomTargetTest(t, "test", "64")
+	crash, err := target.Deserialize([]byte(
+		"test$union0(&(0x7f0000000000)={0x0, @f2=0x5})\n"+
+			"test$arrayindex0(0x7, &(0x7f0000000000)=[{}, {}, {}, {}, {}, {}, {}])\n"), NonStrict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shapes := new(Shapes)
+	shapes.AddProg(crash)
+	if shapes.Len() != 2 {
+		t.Fatalf("got %v shapes, want 2", shapes.Len())
+	}
+	// Count generated calls with the same shapes as in the crash.
+	// Shapes are used for 1/shapeProb of arguments, so we need enough iterations to see the bias.
+	const iters = 1000
+	count := func(ct *ChoiceTable) (unions, arrays int) {
+		r := newRand(target, rs)
+		for i := 0; i < iters; i++ {
+			for _, c := range r.generateParticularCall(newState(target, ct), target.SyscallMap["test$union0"]) {
+				ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+					if a, ok := arg.(*UnionArg); ok && a.Option.Type().FieldName() == "f2" {
+						unions++
+					}
+				})
+			}
+			for _, c := range r.generateParticularCall(newState(target, ct), target.SyscallMap["test$arrayindex0"]) {
+				ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+					if _, ok := arg.Type().(*ArrayType); ok && len(arg.(*GroupArg).Inner) == 7 {
+						arrays++
+					}
+				})
+			}
+		}
+		return
+	}
+	ct := target.BuildChoiceTable(nil, nil)
+	unions0, arrays0 := count(ct)
+	ct.SetShapes(shapes)
+	unions1, arrays1 := count(ct)
+	t.Logf("unions: %v -> %v, arrays: %v -> %v (out of %v)", unions0, unions1, arrays0, arrays1, iters)
+	if unions1 <= unions0 || arrays1 <= arrays0 {
+		t.Fatalf("generation is not biased toward the shapes: unions %v -> %v, arrays %v -> %v",
+			unions0, unions1, arrays0, arrays1)
+	}
+}
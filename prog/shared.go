@@ -0,0 +1,39 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+// Shared regions are memory regions set up by one call at the address of a vma argument
+// (e.g. mmap of a ring buffer shared with the kernel) and accessed by subsequent calls
+// through pointer arguments (see VmaType.Shared and PtrType.Shared). The size of a region
+// is the size of the vma, so it's determined by the arguments of the setup call.
+// placeSharedRegions places pointees of such pointers at the beginning of the region
+// set up by the last preceding setup call, so that the calls access the same memory.
+
+// placeSharedRegions points shared pointers to the regions set up by preceding calls.
+// Pointers without a preceding setup call, and pointers with pointees that don't fit
+// into the region, keep their addresses.
+func (p *Prog) placeSharedRegions() {
+	regions := make(map[string]*PointerArg)
+	for _, c := range p.Calls {
+		ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+			a, ok := arg.(*PointerArg)
+			if !ok || a.IsSpecial() {
+				return
+			}
+			switch typ := a.Type().(type) {
+			case *VmaType:
+				if typ.Shared != "" {
+					regions[typ.Shared] = a
+				}
+			case *PtrType:
+				if typ.Shared == "" || a.Res == nil {
+					return
+				}
+				if region := regions[typ.Shared]; region != nil && a.Res.Size() <= region.VmaSize {
+					a.Address = region.Address
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Signature returns syzlang description of the call signature, e.g.
+// "open(file ptr[in, filename], flags flags[open_flags], mode flags[open_mode]) fd".
+// The signature is restored from the compiled types, so it can differ from the original
+// description: typedefs and templates are expanded, structs and unions are referred to by name,
+// string literals include size if it is not implied by the value.
+// Works for both attached and detached (as produced by the compiler) struct types.
+func (c *Syscall) Signature() string {
+	var args []string
+	for _, arg := range c.Args {
+		args = append(args, fmt.Sprintf("%v %v", arg.FieldName(), typeSignature(arg, true)))
+	}
+	sig := fmt.Sprintf("%v(%v)", c.Name, strings.Join(args, ", "))
+	if c.Ret != nil {
+		sig += " " + typeSignature(c.Ret, true)
+	}
+	return sig
+}
+
+// TypeSignature returns syzlang description of the type as it would be written
+// for a struct field, e.g. "ptr[in, array[int32, 4]]" or "len[data, int16be]".
+func TypeSignature(t Type) string {
+	return typeSignature(t, false)
+}
+
+// ArgSignature returns syzlang description of the type as it would be written
+// for a syscall argument or return value, e.g. "len[buf]" or "fd".
+func ArgSignature(t Type) string {
+	return typeSignature(t, true)
+}
+
+func typeSignature(t0 Type, isArg bool) string {
+	// Structs and unions are referred to by name (Format can't be used for them,
+	// because detached types don't have StructDesc).
+	switch t := t0.(type) {
+	case *StructType:
+		return t.Key.Name
+	case *UnionType:
+		return t.Key.Name
+	}
+	var name string
+	var args []string
+	// Syscall arguments and fmt values don't have explicit base types.
+	noBase := isArg || t0.Format() != FormatNative && t0.Format() != FormatBigEndian
+	base := func(t Type) {
+		if !noBase {
+			args = append(args, intBaseSignature(t))
+		}
+	}
+	switch t := t0.(type) {
+	case *ResourceType:
+		name = t.TypeName
+	case *ConstType:
+		if t.IsPad {
+			return fmt.Sprintf("pad[%v]", t.Size())
+		}
+		name = "const"
+		if t.IsFooter {
+			name = "footer"
+		}
+		args = append(args, fmt.Sprintf("0x%x", t.Val))
+		base(t)
+	case *IntType:
+		switch t.Kind {
+		case IntPlain, IntRange:
+			name = intBaseSignature(t)
+			if t.Kind == IntRange {
+				args = append(args, fmt.Sprintf("%v:%v", int64(t.RangeBegin), int64(t.RangeEnd)))
+			}
+		case IntFileoff:
+			name = "fileoff"
+			base(t)
+		case IntRingHead, IntRingTail:
+			name = t.TypeName
+			args = append(args, t.Ring)
+			base(t)
+		case IntFuncPtr, IntChildPid:
+			name = t.TypeName
+		case IntArrayIndex:
+			name = t.TypeName
+			base(t)
+		case IntParity:
+			name = t.TypeName
+			kind := "even"
+			if t.ParityOdd {
+				kind = "odd"
+			}
+			args = append(args, kind, fmt.Sprintf("%v:%v", t.ParityBegin, t.ParityEnd))
+			base(t)
+		case IntFlagIndex:
+			name = t.TypeName
+			args = append(args, t.Flags)
+			base(t)
+		case IntPow2:
+			name = t.TypeName
+			args = append(args, fmt.Sprintf("%v:%v", t.RangeBegin, t.RangeEnd))
+			base(t)
+		case IntRelPtr:
+			name = t.TypeName
+			args = append(args, t.RelPtr)
+			if t.RelSelf {
+				args = append(args, "self")
+			}
+			base(t)
+		}
+	case *FlagsType:
+		name = "flags"
+		args = append(args, t.TypeName)
+		base(t)
+	case *LenType:
+		name = t.TypeName
+		if t.Expr != nil {
+			args = append(args, t.Expr.String())
+		} else {
+			args = append(args, t.Buf)
+		}
+		base(t)
+	case *ProcType:
+		name = "proc"
+		args = append(args, fmt.Sprintf("0x%x", t.ValuesStart), fmt.Sprint(t.ValuesPerProc))
+		base(t)
+	case *CsumType:
+		name = t.String()
+		kinds := []string{"inet", "pseudo", "crc32", "xor", "fnv1a", "add"}
+		if name == "hash" {
+			args = append(args, kinds[t.Kind], t.Buf)
+		} else {
+			args = append(args, t.Buf, kinds[t.Kind])
+		}
+		if t.Kind == CsumPseudo {
+			args = append(args, fmt.Sprintf("0x%x", t.Protocol))
+		}
+		base(t)
+	case *VmaType:
+		name = t.TypeName
+		if t.RangeBegin != 0 || t.RangeEnd != 0 {
+			args = append(args, rangeSignature(t.RangeBegin, t.RangeEnd))
+		}
+	case *BufferType:
+		name, args = bufferSignature(t)
+	case *ArrayType:
+		name = "array"
+		args = append(args, typeSignature(t.Type, false))
+		if t.Kind == ArrayRangeLen {
+			args = append(args, rangeSignature(t.RangeBegin, t.RangeEnd))
+		}
+	case *PtrType:
+		name = t.TypeName
+		if name == "buffer" {
+			args = append(args, typeDir(t.Type).String())
+		} else {
+			args = append(args, typeDir(t.Type).String(), typeSignature(t.Type, false))
+		}
+	default:
+		panic(fmt.Sprintf("unknown type %#v", t0))
+	}
+	if t0.Optional() {
+		args = append(args, "opt")
+	}
+	sig := name
+	if len(args) != 0 {
+		sig += "[" + strings.Join(args, ", ") + "]"
+	}
+	switch t0.Format() {
+	case FormatStrDec:
+		sig = "fmt[dec, " + sig + "]"
+	case FormatStrHex:
+		sig = "fmt[hex, " + sig + "]"
+	case FormatStrOct:
+		sig = "fmt[oct, " + sig + "]"
+	}
+	return sig
+}
+
+// intBaseSignature returns name of the base integer type of t (e.g. "int16be" or "int32:5").
+func intBaseSignature(t Type) string {
+	var name string
+	if it, ok := t.(*IntType); ok && strings.HasPrefix(it.TypeName, "int") {
+		// Plain ints preserve the original name (e.g. intptr).
+		name = it.TypeName
+	} else {
+		switch t.Format() {
+		case FormatNative, FormatBigEndian:
+			name = fmt.Sprintf("int%v", t.Size()*8)
+		default:
+			// Size of fmt values is the size of the string representation,
+			// the original base type is not preserved.
+			name = "intptr"
+		}
+		if t.Format() == FormatBigEndian {
+			name += "be"
+		}
+	}
+	if t.BitfieldLength() != 0 {
+		name += fmt.Sprintf(":%v", t.BitfieldLength())
+	}
+	return name
+}
+
+func bufferSignature(t *BufferType) (string, []string) {
+	switch t.Kind {
+	case BufferBlobRand:
+		return "array", []string{"int8"}
+	case BufferBlobRange:
+		if t.RangeBegin == 0 && t.RangeEnd == 0 {
+			return "void", nil
+		}
+		return "array", []string{"int8", rangeSignature(t.RangeBegin, t.RangeEnd)}
+	case BufferFilename:
+		if t.NoZ {
+			return "stringnoz", []string{"filename"}
+		}
+		if !t.Varlen() {
+			return "string", []string{"filename", fmt.Sprint(t.Size())}
+		}
+		return "filename", nil
+	case BufferText:
+		kinds := []string{"target", "x86_real", "x86_16", "x86_32", "x86_64", "arm64"}
+		return "text", []string{kinds[t.Text]}
+	case BufferSerialized:
+		return "serialized", []string{typeSignature(t.Elem, false)}
+	case BufferString:
+		if t.TypeName == "reserved" {
+			return "reserved", []string{fmt.Sprint(t.Size())}
+		}
+		name := "string"
+		if t.NoZ {
+			name = "stringnoz"
+		}
+		var args []string
+		switch {
+		case t.SubKind != "":
+			args = append(args, t.SubKind)
+		case len(t.Values) == 1:
+			args = append(args, fmt.Sprintf("%q", trimString(t, t.Values[0])))
+		default:
+			return name, nil
+		}
+		if !t.Varlen() {
+			// Size is implied if it is equal to the size of all values.
+			implied := true
+			for _, val := range t.Values {
+				size := uint64(len(trimString(t, val)))
+				if !t.NoZ {
+					size++
+				}
+				if size != t.Size() {
+					implied = false
+				}
+			}
+			if !implied {
+				args = append(args, fmt.Sprint(t.Size()))
+			}
+		}
+		return name, args
+	default:
+		panic(fmt.Sprintf("unknown buffer kind %v", t.Kind))
+	}
+}
+
+// trimString strips zero terminator and padding added by the compiler to string values.
+func trimString(t *BufferType, val string) string {
+	if t.NoZ {
+		return val
+	}
+	return strings.TrimRight(val, "\x00")
+}
+
+func rangeSignature(begin, end uint64) string {
+	if begin == end {
+		return fmt.Sprint(begin)
+	}
+	return fmt.Sprintf("%v:%v", begin, end)
+}
+
+// typeDir returns direction of t, struct types can be detached.
+func typeDir(t Type) Dir {
+	switch a := t.(type) {
+	case *StructType:
+		return a.Key.Dir
+	case *UnionType:
+		return a.Key.Dir
+	default:
+		return t.Dir()
+	}
+}
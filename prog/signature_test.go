@@ -0,0 +1,85 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSignature(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	calls := []string{
+		"foo$fmt0(a ptr[in, fmt[dec, int32[1:10]]]) r_any",
+		"foo$fmt1(a ptr[in, fmt[hex, flags[flags_any]]])",
+		"foo$fmt3(a ptr[in, fmt[dec, proc[0xa, 20]]])",
+		"mutate5(filename ptr[in, filename], flags flags[open_flags]) fd",
+		"syz_compare_int$2(n const[0x2], v0 intptr, v1 intptr)",
+		"test$length12(a0 ptr[in, syz_length_large_struct, opt], a1 len[a0])",
+		"test$length14(a0 ptr[inout, syz_length_large_struct], a1 ptr[inout, len[a0, int64], opt])",
+		"test$length22(a0 ptr[in, array[int8]], a1 bitsize[a0])",
+		"test$opt0(a0 intptr[opt])",
+		"test$opt3(a0 proc[0x64, 4, opt])",
+		"test$regression2(a1 ptr[in, array[int32, 4]])",
+		"test$syz_union4(a0 union_arg)",
+		"test$text_x86_16(a0 ptr[in, text[x86_16]], a1 len[a0])",
+		"test$vma0(v0 vma, l0 len[v0], v1 vma[5], l1 len[v1], v2 vma[7:9], l2 len[v2])",
+	}
+	for _, want := range calls {
+		name := want[:strings.IndexByte(want, '(')]
+		meta := target.SyscallMap[name]
+		if meta == nil {
+			t.Fatalf("unknown call %v", name)
+		}
+		if got := meta.Signature(); got != want {
+			t.Errorf("bad signature:\ngot:  %v\nwant: %v", got, want)
+		}
+	}
+	fields := map[string][]string{
+		"syz_bf_struct0": {
+			"flags[syz_bf_flags, int16:10]", "pad[6]", "int64", "const[0x42, int16:5]", "int16:6",
+			"pad[2]", "const[0x42, int32:15]", "len[parent, int16:11]", "len[parent, int16be:11]",
+			"int8", "pad[3]",
+		},
+		"syz_csum_tcp_header":        {"csum[syz_csum_tcp_packet, pseudo, 0x6, int16]"},
+		"syz_hash_fnv1a_struct":      {"int32", "hash[fnv1a, parent, int64]", "array[int8, 4]"},
+		"static_filename":            {"string[filename, 10]", "string[filename, 20]", "bytesize[f1, int8]", "bytesize[f2, int8]", "bytesize[parent, int8]"},
+		"syz_length_reserved_struct": {"int8", "reserved[3]", "int32", "bytesize[parent, int8]", "bytesize[f1, int8]", "pad[2]"},
+		"ring_struct": {
+			"ringhead[ring, int32]", "ringtail[ring, int32]", "ringhead[small_ring, int8]",
+			"ringtail[small_ring, int8]", "array[int8, 4]", "pad[2]", "array[int32, 0:8]",
+		},
+		"serialize0_struct": {"string[serialize_strings, 10]", "string[serialize_strings, 5]"},
+		"syz_union0":        {"int64", "array[int64, 10]", "int8"},
+	}
+	seen := make(map[string]bool)
+	for _, meta := range target.Syscalls {
+		ForeachType(meta, func(typ Type) {
+			var desc *StructDesc
+			var name string
+			switch a := typ.(type) {
+			case *StructType:
+				desc, name = a.StructDesc, a.Key.Name
+			case *UnionType:
+				desc, name = a.StructDesc, a.Key.Name
+			}
+			want, ok := fields[name]
+			if !ok || seen[name] {
+				return
+			}
+			seen[name] = true
+			if len(desc.Fields) != len(want) {
+				t.Fatalf("%v: got %v fields, want %v", name, len(desc.Fields), len(want))
+			}
+			for i, f := range desc.Fields {
+				if got := TypeSignature(f); got != want[i] {
+					t.Errorf("%v: bad field %v signature: got %v, want %v", name, i, got, want[i])
+				}
+			}
+		})
+	}
+	if len(seen) != len(fields) {
+		t.Fatalf("found only %v structs out of %v", len(seen), len(fields))
+	}
+}
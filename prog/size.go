@@ -5,6 +5,7 @@ package prog
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -14,6 +15,9 @@ func (target *Target) generateSize(arg Arg, lenType *LenType) uint64 {
 		return 0
 	}
 
+	if lenType.Dim != 0 {
+		return arrayDimLen(arg, lenType.Dim)
+	}
 	bitSize := lenType.BitSize
 	if bitSize == 0 {
 		bitSize = 8
@@ -44,7 +48,6 @@ func (target *Target) assignSizes(args []Arg, parentsMap map[Arg]Arg, autos map[
 	}
 
 	// Fill in size arguments.
-nextArg:
 	for _, arg := range args {
 		if arg = InnerArg(arg); arg == nil {
 			continue // Pointer to optional len field, no need to fill in value.
@@ -60,52 +63,793 @@ nextArg:
 			delete(autos, arg)
 		}
 		a := arg.(*ConstArg)
+		a.Val = target.generateLen(arg, typ, argsMap, parentsMap)
+		if typ.Inclusive {
+			a.Val += typ.Size()
+		}
+	}
 
-		buf, ok := argsMap[typ.Buf]
-		if ok {
-			a.Val = target.generateSize(InnerArg(buf), typ)
+	assignRelPtrs(args, autos)
+	if autos == nil {
+		assignRingIndices(args, argsMap)
+	}
+}
+
+// generateLen returns value of the len field arg according to its target,
+// not counting the len field itself for inclusive lengths.
+func (target *Target) generateLen(arg Arg, typ *LenType, argsMap map[string]Arg,
+	parentsMap map[Arg]Arg) uint64 {
+	if typ.Expr != nil {
+		return target.generateLenExpr(arg, typ, typ.Expr, argsMap, parentsMap)
+	}
+
+	if buf, ok := argsMap[typ.Buf]; ok {
+		return target.generateSize(InnerArg(buf), typ)
+	}
+
+	if typ.Buf == "parent" {
+		size := parentStruct(arg, parentsMap).Size()
+		if typ.BitSize != 0 {
+			size = size * 8 / typ.BitSize
+		}
+		return size
+	}
+
+	if strings.IndexByte(typ.Buf, '.') != -1 {
+		return target.generatePathSize(arg, typ, parentsMap)
+	}
+
+	for parent := parentsMap[arg]; parent != nil; parent = parentsMap[parent] {
+		parentName := parent.Type().Name()
+		if pos := strings.IndexByte(parentName, '['); pos != -1 {
+			// For template parents, strip arguments.
+			parentName = parentName[:pos]
+		}
+		if typ.Buf != parentName {
+			continue
+		}
+		size := parent.Size()
+		if typ.BitSize != 0 {
+			size = size * 8 / typ.BitSize
+		}
+		return size
+	}
+	panic(fmt.Sprintf("len field '%v' references non existent field '%v', argsMap: %+v",
+		typ.FieldName(), typ.Buf, argsMap))
+}
+
+// generateLenExpr evaluates len expression e of the len field arg.
+func (target *Target) generateLenExpr(arg Arg, typ *LenType, e *LenExpr, argsMap map[string]Arg,
+	parentsMap map[Arg]Arg) uint64 {
+	switch e.Op {
+	case LenExprTarget:
+		targetTyp := *typ
+		targetTyp.Buf, targetTyp.Expr = e.Buf, nil
+		return target.generateLen(arg, &targetTyp, argsMap, parentsMap)
+	case LenExprConst:
+		return e.Val
+	case LenExprMax, LenExprMin:
+		res := target.generateLenExpr(arg, typ, e.Args[0], argsMap, parentsMap)
+		for _, e1 := range e.Args[1:] {
+			v := target.generateLenExpr(arg, typ, e1, argsMap, parentsMap)
+			if e.Op == LenExprMax && v > res || e.Op == LenExprMin && v < res {
+				res = v
+			}
+		}
+		return res
+	default:
+		panic(fmt.Sprintf("unknown len expression op %v", e.Op))
+	}
+}
+
+// assignRelPtrs sets relptr fields to the offset of the pointee field from the beginning
+// of the struct, or from the relptr field itself for self-relative pointers.
+// Pointees of zero size are denoted by null (0) offset.
+func assignRelPtrs(args []Arg, autos map[Arg]bool) {
+	offsets := make(map[string]uint64)
+	sizes := make(map[string]uint64)
+	offset := uint64(0)
+	for _, arg := range args {
+		if !IsPad(arg.Type()) {
+			offsets[arg.Type().FieldName()] = offset
+			sizes[arg.Type().FieldName()] = arg.Size()
+		}
+		if !arg.Type().BitfieldMiddle() {
+			offset += arg.Size()
+		}
+	}
+	for _, arg := range args {
+		typ, ok := arg.Type().(*IntType)
+		if !ok || typ.Kind != IntRelPtr {
+			continue
+		}
+		if autos != nil {
+			if !autos[arg] {
+				continue
+			}
+			delete(autos, arg)
+		}
+		if typ.Dir() == DirOut {
+			continue // output args must have the default value
+		}
+		target, ok := offsets[typ.RelPtr]
+		if !ok {
+			panic(fmt.Sprintf("relptr field '%v' references non existent field '%v'",
+				typ.FieldName(), typ.RelPtr))
+		}
+		a := arg.(*ConstArg)
+		switch {
+		case sizes[typ.RelPtr] == 0:
+			a.Val = 0
+		case typ.RelSelf:
+			// Backward offsets wrap around the same way as ring indices.
+			a.Val = (target - offsets[typ.FieldName()]) & ringIndexMask(typ)
+		default:
+			a.Val = target
+		}
+	}
+}
+
+// generatePathSize returns size for len targets of the form parent.parent.field.
+// Each parent element moves one struct up (unions and arrays are skipped),
+// the last element names a field of the reached struct or the struct itself.
+func (target *Target) generatePathSize(arg Arg, typ *LenType, parentsMap map[Arg]Arg) uint64 {
+	elems := strings.Split(typ.Buf, ".")
+	parent := parentStruct(arg, parentsMap)
+	for i := 1; i < len(elems) && parent != nil; i++ {
+		parent = parentStruct(parent, parentsMap)
+	}
+	if parent == nil {
+		panic(fmt.Sprintf("len field '%v' references non existent parent in '%v'",
+			typ.FieldName(), typ.Buf))
+	}
+	last := elems[len(elems)-1]
+	if last == "parent" {
+		size := parent.Size()
+		if typ.BitSize != 0 {
+			size = size * 8 / typ.BitSize
+		}
+		return size
+	}
+	for _, field := range parent.(*GroupArg).Inner {
+		if !IsPad(field.Type()) && field.Type().FieldName() == last {
+			return target.generateSize(InnerArg(field), typ)
+		}
+	}
+	panic(fmt.Sprintf("len field '%v' references non existent field '%v' in '%v'",
+		typ.FieldName(), typ.Buf, parent.Type().Name()))
+}
+
+// parentStruct returns the closest struct enclosing arg, skipping unions and arrays.
+func parentStruct(arg Arg, parentsMap map[Arg]Arg) Arg {
+	for parent := parentsMap[arg]; parent != nil; parent = parentsMap[parent] {
+		if _, ok := parent.Type().(*StructType); ok {
+			return parent
+		}
+	}
+	return nil
+}
+
+// assignRingIndices makes tail index consistent with head index and the ring size:
+// the number of pending entries (tail - head with wraparound) must not exceed
+// the number of elements in the ring.
+func assignRingIndices(args []Arg, argsMap map[string]Arg) {
+	for _, arg := range args {
+		typ, ok := arg.Type().(*IntType)
+		if !ok || typ.Kind != IntRingTail {
+			continue
+		}
+		var head *ConstArg
+		for _, arg1 := range args {
+			if typ1, ok := arg1.Type().(*IntType); ok && typ1.Kind == IntRingHead && typ1.Ring == typ.Ring {
+				head = arg1.(*ConstArg)
+				break
+			}
+		}
+		// Arrays of int8 are represented as data args, for them size is the number of elements.
+		ring, ok := argsMap[typ.Ring]
+		if head == nil || !ok {
+			panic(fmt.Sprintf("ring index field '%v' references non existent ring '%v', argsMap: %+v",
+				typ.FieldName(), typ.Ring, argsMap))
+		}
+		size := ring.Size()
+		if group, ok := ring.(*GroupArg); ok {
+			size = uint64(len(group.Inner))
+		}
+		tail := arg.(*ConstArg)
+		mask := ringIndexMask(typ)
+		if pending := (tail.Val - head.Val) & mask; pending > size {
+			tail.Val = (head.Val + pending%(size+1)) & mask
+		}
+	}
+}
+
+func ringIndexMask(typ *IntType) uint64 {
+	bits := typ.BitfieldLength()
+	if bits == 0 {
+		bits = typ.Size() * 8
+	}
+	if bits >= 64 {
+		return ^uint64(0)
+	}
+	return 1<<bits - 1
+}
+
+// Maximum number of elements in arrays with count attribute.
+const maxCountedArrayLen = 10
+
+// assignCountedArrays sets the number of elements in arrays with count attribute
+// to the value of the count resource. If the value is not known in advance
+// (the resource is produced by a previous call), the generated length is left as is.
+func assignCountedArrays(args []Arg) {
+	argsMap := make(map[string]Arg)
+	for _, arg := range args {
+		if !IsPad(arg.Type()) {
+			argsMap[arg.Type().FieldName()] = arg
+		}
+	}
+	for _, arg := range args {
+		arr, ok := InnerArg(arg).(*GroupArg)
+		if !ok {
+			continue
+		}
+		typ, ok := arr.Type().(*ArrayType)
+		if !ok || typ.CountField == "" {
+			continue
+		}
+		res, ok := argsMap[typ.CountField].(*ResultArg)
+		if !ok {
+			panic(fmt.Sprintf("count of array '%v' references non existent resource '%v', argsMap: %+v",
+				typ.FieldName(), typ.CountField, argsMap))
+		}
+		if res.Res != nil {
+			continue
+		}
+		count := res.Val
+		if count > maxCountedArrayLen {
+			count = maxCountedArrayLen
+		}
+		for uint64(len(arr.Inner)) > count {
+			removeArg(arr.Inner[len(arr.Inner)-1])
+			arr.Inner = arr.Inner[:len(arr.Inner)-1]
+		}
+		for uint64(len(arr.Inner)) < count {
+			arr.Inner = append(arr.Inner, typ.Type.DefaultArg())
+		}
+	}
+}
+
+// assignSubkindFlags restricts values of flags with subkind attribute to the values
+// of the subkind of the referenced resource. The values are not restricted
+// if the resource is not of any of the listed subkinds.
+func assignSubkindFlags(args []Arg) {
+	argsMap := make(map[string]Arg)
+	for _, arg := range args {
+		if !IsPad(arg.Type()) {
+			argsMap[arg.Type().FieldName()] = arg
+		}
+	}
+	for _, arg := range args {
+		typ, ok := arg.Type().(*FlagsType)
+		if !ok || typ.SubkindField == "" {
+			continue
+		}
+		res, ok := argsMap[typ.SubkindField].(*ResultArg)
+		if !ok {
+			panic(fmt.Sprintf("subkind of flags '%v' references non existent resource '%v', argsMap: %+v",
+				typ.FieldName(), typ.SubkindField, argsMap))
+		}
+		vals := subkindVals(typ, res)
+		if len(vals) == 0 {
+			continue
+		}
+		flags := arg.(*ConstArg)
+		if typ.BitMask {
+			var mask uint64
+			for _, v := range vals {
+				mask |= v
+			}
+			flags.Val &= mask
+			continue
+		}
+		valid := false
+		for _, v := range vals {
+			if flags.Val == v {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			flags.Val = vals[flags.Val%uint64(len(vals))]
+		}
+	}
+}
+
+// assignRequestMasks clears bits of request masks in args and in structs nested in args
+// that don't request any field of the referenced struct (see IntTypeCommon.RequestMaskField).
+// Returns true if any of the masks has changed.
+func assignRequestMasks(args []Arg) bool {
+	changed := assignRequestMasksArray(args)
+	for _, arg := range args {
+		ForeachSubArg(arg, func(arg Arg, _ *ArgCtx) {
+			if _, ok := arg.Type().(*StructType); ok {
+				changed = assignRequestMasksArray(arg.(*GroupArg).Inner) || changed
+			}
+		})
+	}
+	return changed
+}
+
+func assignRequestMasksArray(args []Arg) bool {
+	argsMap := make(map[string]Arg)
+	for _, arg := range args {
+		if !IsPad(arg.Type()) {
+			argsMap[arg.Type().FieldName()] = arg
+		}
+	}
+	changed := false
+	for _, arg := range args {
+		field := requestMaskField(arg.Type())
+		if field == "" {
+			continue
+		}
+		ref, ok := argsMap[field]
+		if !ok {
+			// The referenced syscall argument was omitted.
+			continue
+		}
+		mask := requestedMask(ref.Type())
+		if a := arg.(*ConstArg); a.Val&^mask != 0 {
+			a.Val &= mask
+			changed = true
+		}
+	}
+	return changed
+}
+
+// requestMaskField returns the field referenced by typ if it's a request mask, or "".
+func requestMaskField(typ Type) string {
+	switch t := typ.(type) {
+	case *IntType:
+		return t.RequestMaskField
+	case *FlagsType:
+		return t.RequestMaskField
+	}
+	return ""
+}
+
+// requestedMask returns the mask of all bits that request fields of the struct typ
+// (or of the struct typ points to).
+func requestedMask(typ Type) uint64 {
+	if ptr, ok := typ.(*PtrType); ok {
+		typ = ptr.Type
+	}
+	var mask uint64
+	for _, bits := range typ.(*StructType).RequestedBy {
+		mask |= bits
+	}
+	return mask
+}
+
+// subkindVals returns values of typ for the most specific subkind of resource res,
+// if the resource is produced by a previous call, the subkind of the produced resource is used.
+func subkindVals(typ *FlagsType, res *ResultArg) []uint64 {
+	desc := res.Type().(*ResourceType).Desc
+	if res.Res != nil {
+		desc = res.Res.Type().(*ResourceType).Desc
+	}
+	for i := len(desc.Kind) - 1; i >= 0; i-- {
+		for _, s := range typ.Subkinds {
+			if s.Kind == desc.Kind[i] {
+				return s.Vals
+			}
+		}
+	}
+	return nil
+}
+
+// assignFormatStrings sets contents of format strings with format_args attribute
+// to the specifiers of the chosen options of the elements of the referenced array
+// (see BufferType.FormatArgs), so that the format always matches the arguments.
+func assignFormatStrings(args []Arg) {
+	argsMap := make(map[string]Arg)
+	for _, arg := range args {
+		if !IsPad(arg.Type()) {
+			argsMap[arg.Type().FieldName()] = arg
+		}
+	}
+	for _, arg := range args {
+		data, ok := InnerArg(arg).(*DataArg)
+		if !ok {
+			continue
+		}
+		typ, ok := data.Type().(*BufferType)
+		if !ok || typ.FormatArgs == "" || typ.Dir() == DirOut {
 			continue
 		}
+		ref, ok := argsMap[typ.FormatArgs]
+		if !ok {
+			// The referenced syscall argument was omitted.
+			continue
+		}
+		var specs []string
+		if arr, ok := InnerArg(ref).(*GroupArg); ok {
+			for _, elem := range arr.Inner {
+				specs = append(specs, formatSpecifier(elem.(*UnionArg)))
+			}
+		}
+		format := strings.Join(specs, " ")
+		if !typ.NoZ {
+			format += "\x00"
+		}
+		data.SetData([]byte(format))
+	}
+}
+
+func formatSpecifier(arg *UnionArg) string {
+	typ := arg.Type().(*UnionType)
+	name := arg.Option.Type().FieldName()
+	for i, opt := range typ.Fields {
+		if opt.FieldName() == name {
+			return typ.Specifiers[i]
+		}
+	}
+	panic(fmt.Sprintf("union %v does not have option %v", typ.Name(), name))
+}
+
+// assignUnionVersions sets the version field of the chosen option of all versioned unions
+// to the version of the option, so that the field always matches the chosen layout.
+func assignUnionVersions(args []Arg) {
+	for _, arg := range args {
+		ForeachSubArg(arg, func(arg Arg, _ *ArgCtx) {
+			if typ, ok := arg.Type().(*UnionType); ok && typ.VersionField != "" {
+				assignUnionVersion(arg.(*UnionArg))
+			}
+		})
+	}
+}
 
-		if typ.Buf == "parent" {
-			a.Val = parentsMap[arg].Size()
-			if typ.BitSize != 0 {
-				a.Val = a.Val * 8 / typ.BitSize
+func assignUnionVersion(arg *UnionArg) {
+	typ := arg.Type().(*UnionType)
+	name := arg.Option.Type().FieldName()
+	for i, opt := range typ.Fields {
+		if opt.FieldName() != name {
+			continue
+		}
+		for _, fld := range arg.Option.(*GroupArg).Inner {
+			if fld.Type().FieldName() == typ.VersionField {
+				fld.(*ConstArg).Val = typ.Versions[i]
+				return
 			}
+		}
+		panic(fmt.Sprintf("option %v of union %v does not have version field %v",
+			name, typ.Name(), typ.VersionField))
+	}
+	panic(fmt.Sprintf("union %v does not have option %v", typ.Name(), name))
+}
+
+// assignOverlappingPointers sets addresses of pointers with overlap attribute
+// to the address of the referenced pointer plus the offset. Nil and special pointers
+// are left as is, as well as pointers that would not fit into the data area.
+func (target *Target) assignOverlappingPointers(args []Arg) {
+	argsMap := make(map[string]Arg)
+	for _, arg := range args {
+		if !IsPad(arg.Type()) {
+			argsMap[arg.Type().FieldName()] = arg
+		}
+	}
+	for _, arg := range args {
+		ptr, ok := arg.(*PointerArg)
+		if !ok || ptr.Res == nil {
+			continue
+		}
+		typ, ok := ptr.Type().(*PtrType)
+		if !ok || typ.OverlapField == "" {
+			continue
+		}
+		ref, ok := argsMap[typ.OverlapField].(*PointerArg)
+		if !ok {
+			panic(fmt.Sprintf("overlap of pointer '%v' references non existent pointer '%v', argsMap: %+v",
+				typ.FieldName(), typ.OverlapField, argsMap))
+		}
+		if ref.Res == nil {
+			continue
+		}
+		addr := ref.Address + typ.OverlapOffset
+		if addr+ptr.Res.Size() > target.NumPages*target.PageSize {
+			continue
+		}
+		ptr.Address = addr
+	}
+}
+
+// arrayDimLen returns length of the first inner array of dimension dim of nested array arg.
+// Arrays of int8 are represented as data args, for them length is the data size.
+func arrayDimLen(arg Arg, dim uint64) uint64 {
+	for i := uint64(0); i < dim; i++ {
+		a := arg.(*GroupArg)
+		if len(a.Inner) == 0 {
+			return 0
+		}
+		arg = a.Inner[0]
+	}
+	if a, ok := arg.(*GroupArg); ok {
+		return uint64(len(a.Inner))
+	}
+	return arg.Size()
+}
+
+// assignArrayDims makes all inner arrays of nested arrays referenced by len fields
+// with dim attribute as long as the first inner array of the same dimension,
+// so that a single len value describes all of them. Otherwise lengths of inner arrays
+// of variable size are generated independently.
+func assignArrayDims(args []Arg) {
+	argsMap := make(map[string]Arg)
+	for _, arg := range args {
+		if !IsPad(arg.Type()) {
+			argsMap[arg.Type().FieldName()] = arg
+		}
+	}
+	for _, arg := range args {
+		arg = InnerArg(arg)
+		if arg == nil {
+			continue
+		}
+		typ, ok := arg.Type().(*LenType)
+		if !ok || typ.Dim == 0 {
 			continue
 		}
+		buf, ok := argsMap[typ.Buf]
+		if !ok {
+			panic(fmt.Sprintf("len field '%v' references non existent field '%v', argsMap: %+v",
+				typ.FieldName(), typ.Buf, argsMap))
+		}
+		level := []Arg{InnerArg(buf)}
+		if level[0] == nil {
+			continue
+		}
+		for d := uint64(0); d < typ.Dim; d++ {
+			var next []Arg
+			for _, arr := range level {
+				next = append(next, arr.(*GroupArg).Inner...)
+			}
+			if len(next) == 0 {
+				break
+			}
+			n := arrayDimLen(next[0], 0)
+			for _, arr := range next[1:] {
+				resizeArray(arr, n)
+			}
+			level = next
+		}
+	}
+}
 
-		for parent := parentsMap[arg]; parent != nil; parent = parentsMap[parent] {
-			parentName := parent.Type().Name()
-			if pos := strings.IndexByte(parentName, '['); pos != -1 {
-				// For template parents, strip arguments.
-				parentName = parentName[:pos]
+// assignSparseIndices makes indices of sparse array arr unique and ascending.
+// Entries are sorted by index, duplicate indices are moved to the nearest free
+// index of the index type range, entries that don't fit into the range are removed.
+func assignSparseIndices(arr *GroupArg) {
+	if len(arr.Inner) == 0 {
+		return
+	}
+	lo, hi := sparseIndexRange(arr.Type().(*ArrayType).Type.(*StructType).Fields[0].(*IntType))
+	if n := hi - lo; n < uint64(len(arr.Inner))-1 {
+		for _, elem := range arr.Inner[n+1:] {
+			removeArg(elem)
+		}
+		arr.Inner = arr.Inner[:n+1]
+	}
+	index := func(i int) *ConstArg {
+		return arr.Inner[i].(*GroupArg).Inner[0].(*ConstArg)
+	}
+	for i := range arr.Inner {
+		if idx := index(i); idx.Val < lo {
+			idx.Val = lo
+		} else if idx.Val > hi {
+			idx.Val = hi
+		}
+	}
+	sort.SliceStable(arr.Inner, func(i, j int) bool {
+		return index(i).Val < index(j).Val
+	})
+	for i := 1; i < len(arr.Inner); i++ {
+		if idx, prev := index(i), index(i-1).Val; idx.Val <= prev {
+			idx.Val = prev
+			if prev != hi {
+				idx.Val++
+			}
+		}
+	}
+	for i := len(arr.Inner) - 1; i >= 0; i-- {
+		limit := hi
+		if i != len(arr.Inner)-1 {
+			limit = index(i+1).Val - 1
+		}
+		if idx := index(i); idx.Val > limit {
+			idx.Val = limit
+		}
+	}
+}
+
+func sparseIndexRange(typ *IntType) (uint64, uint64) {
+	if typ.Kind == IntRange {
+		return typ.RangeBegin, typ.RangeEnd
+	}
+	bits := typ.Size() * 8
+	if bits >= 64 {
+		return 0, ^uint64(0)
+	}
+	return 0, 1<<bits - 1
+}
+
+// assignUniqueFields makes values of unique fields (see StructDesc.UniqueFields)
+// distinct in all elements of array arr. Duplicate values are moved to the nearest
+// following free value of the field type range (wrapping around), elements that
+// don't fit into the range are removed.
+func assignUniqueFields(arr *GroupArg) {
+	if len(arr.Inner) == 0 {
+		return
+	}
+	typ := arr.Type().(*ArrayType).Type.(*StructType)
+	for _, name := range typ.UniqueFields {
+		idx := -1
+		for i, f := range typ.Fields {
+			if f.FieldName() == name {
+				idx = i
+				break
+			}
+		}
+		if idx == -1 {
+			panic(fmt.Sprintf("struct %v does not have unique field %v", typ.Name(), name))
+		}
+		lo, hi := uniqueValueRange(typ.Fields[idx].(*IntType))
+		if n := hi - lo; n < uint64(len(arr.Inner))-1 {
+			for _, elem := range arr.Inner[n+1:] {
+				removeArg(elem)
+			}
+			arr.Inner = arr.Inner[:n+1]
+		}
+		used := make(map[uint64]bool)
+		for _, elem := range arr.Inner {
+			val := elem.(*GroupArg).Inner[idx].(*ConstArg)
+			if val.Val < lo || val.Val > hi {
+				val.Val = lo
+			}
+			for used[val.Val] {
+				if val.Val == hi {
+					val.Val = lo
+				} else {
+					val.Val++
+				}
 			}
-			if typ.Buf != parentName {
+			used[val.Val] = true
+		}
+	}
+}
+
+// uniqueValueRange is the same as sparseIndexRange, but takes bitfields into account.
+func uniqueValueRange(typ *IntType) (uint64, uint64) {
+	if bits := typ.BitfieldLength(); bits != 0 && typ.Kind != IntRange {
+		return 0, 1<<bits - 1
+	}
+	return sparseIndexRange(typ)
+}
+
+// assignArrayIndices sets arrayindex fields of elements of all arrays in args
+// to the index of the element (wrapped around the field type range).
+func assignArrayIndices(args []Arg, autos map[Arg]bool) {
+	for _, arg := range args {
+		ForeachSubArg(arg, func(arg Arg, _ *ArgCtx) {
+			if _, ok := arg.Type().(*ArrayType); ok {
+				assignElemIndices(arg.(*GroupArg), autos)
+			}
+		})
+	}
+}
+
+func assignElemIndices(arr *GroupArg, autos map[Arg]bool) {
+	for i, elem := range arr.Inner {
+		group, ok := elem.(*GroupArg)
+		if !ok {
+			continue
+		}
+		if _, ok := group.Type().(*StructType); !ok {
+			continue
+		}
+		for _, field := range group.Inner {
+			typ, ok := field.Type().(*IntType)
+			if !ok || typ.Kind != IntArrayIndex {
 				continue
 			}
-			a.Val = parent.Size()
-			if typ.BitSize != 0 {
-				a.Val = a.Val * 8 / typ.BitSize
+			if autos != nil {
+				if !autos[field] {
+					continue
+				}
+				delete(autos, field)
+			}
+			if typ.Dir() == DirOut {
+				continue // output args must have the default value
 			}
-			continue nextArg
+			field.(*ConstArg).Val = uint64(i) & ringIndexMask(typ)
+		}
+	}
+}
+
+// resizeArray truncates or extends array arg to n elements.
+func resizeArray(arg Arg, n uint64) {
+	switch a := arg.(type) {
+	case *GroupArg:
+		typ := a.Type().(*ArrayType)
+		for uint64(len(a.Inner)) > n {
+			removeArg(a.Inner[len(a.Inner)-1])
+			a.Inner = a.Inner[:len(a.Inner)-1]
+		}
+		for uint64(len(a.Inner)) < n {
+			a.Inner = append(a.Inner, typ.Type.DefaultArg())
+		}
+	case *DataArg:
+		if a.Type().Dir() == DirOut {
+			a.size = n
+			return
 		}
-		panic(fmt.Sprintf("len field '%v' references non existent field '%v', argsMap: %+v",
-			typ.FieldName(), typ.Buf, argsMap))
+		data := a.Data()
+		if uint64(len(data)) > n {
+			data = data[:n]
+		}
+		for uint64(len(data)) < n {
+			data = append(data, 0)
+		}
+		a.SetData(data)
 	}
 }
 
 func (target *Target) assignSizesArray(args []Arg, autos map[Arg]bool) {
+	// Unlike lengths, versions are fixed up in deserialized programs as well,
+	// a version that does not match the layout makes the argument meaningless.
+	assignUnionVersions(args)
+	if autos == nil {
+		// Array lengths affect sizes, so they need to be fixed up first.
+		assignCountedArrays(args)
+		assignSubkindFlags(args)
+		assignArrayDims(args)
+		target.assignOverlappingPointers(args)
+		assignRequestMasks(args)
+		assignIntRelations(args)
+		assignFormatStrings(args)
+		for _, arg := range args {
+			ForeachSubArg(arg, func(arg Arg, _ *ArgCtx) {
+				switch typ := arg.Type().(type) {
+				case *StructType:
+					assignCountedArrays(arg.(*GroupArg).Inner)
+					assignSubkindFlags(arg.(*GroupArg).Inner)
+					assignArrayDims(arg.(*GroupArg).Inner)
+					target.assignOverlappingPointers(arg.(*GroupArg).Inner)
+					assignFormatStrings(arg.(*GroupArg).Inner)
+				case *ArrayType:
+					if typ.Sparse {
+						assignSparseIndices(arg.(*GroupArg))
+					}
+					if elem, ok := typ.Type.(*StructType); ok && len(elem.UniqueFields) != 0 {
+						assignUniqueFields(arg.(*GroupArg))
+					}
+				}
+			})
+		}
+	}
 	parentsMap := make(map[Arg]Arg)
 	for _, arg := range args {
 		ForeachSubArg(arg, func(arg Arg, _ *ArgCtx) {
-			if _, ok := arg.Type().(*StructType); ok {
+			switch arg.Type().(type) {
+			case *StructType, *ArrayType:
 				for _, field := range arg.(*GroupArg).Inner {
 					parentsMap[InnerArg(field)] = arg
 				}
+			case *UnionType:
+				parentsMap[InnerArg(arg.(*UnionArg).Option)] = arg
 			}
 		})
 	}
+	assignArrayIndices(args, autos)
 	target.assignSizes(args, parentsMap, autos)
 	for _, arg := range args {
 		ForeachSubArg(arg, func(arg Arg, _ *ArgCtx) {
@@ -151,6 +151,51 @@ func TestAssignSize(t *testing.T) {
 			"test$length29(&(0x7f0000000000)={'./a\\x00', './b/c\\x00', 0x0, 0x0, 0x0})",
 			"test$length29(&(0x7f0000000000)={'./a\\x00', './b/c\\x00', 0xa, 0x14, 0x21})",
 		},
+		{
+			"test$length30(&(0x7f0000000000)={{0x0, {0x0, 0x0, 0x0}, 0x0}, [0x1, 0x2, 0x3], 0x0})",
+			"test$length30(&(0x7f0000000000)={{0x0, {0x3, 0x4, 0x8}, 0x8}, [0x1, 0x2, 0x3]})",
+		},
+		{
+			"test$length31(&(0x7f0000000000)={0x1, '\\x00', 0x2, 0x0, 0x0})",
+			"test$length31(&(0x7f0000000000)={0x1, '\\x00', 0x2, 0xc, 0x3})",
+		},
+		{
+			"test$length32(&(0x7f0000000000)={0x0, 0x0, [\"0102\", \"03\", \"040506\"]})",
+			"test$length32(&(0x7f0000000000)={0x3, 0x2, [\"0102\", \"0300\", \"0405\"]})",
+		},
+		{
+			"test$length33(&(0x7f0000000000)=[[[0x1, 0x2], [0x3]], [[0x4]]], 0x0, 0x0, 0x0)",
+			"test$length33(&(0x7f0000000000)=[[[0x1, 0x2], [0x3, 0x0]], [[0x4, 0x0], [0x0, 0x0]]], 0x2, 0x2, 0x2)",
+		},
+		{
+			"test$length35(&(0x7f0000000000)={0x1, 0x0, \"010203\"}, 0x0)",
+			"test$length35(&(0x7f0000000000)={0x1, 0x5, \"010203\"}, 0xe)",
+		},
+		{
+			"test$length36(&(0x7f0000000000)={0x0, 0x0, \"010203\", \"01\"}, &(0x7f0000000100)=[0x1], 0x0)",
+			"test$length36(&(0x7f0000000000)={0x3, 0x2, \"010203\", \"01\"}, &(0x7f0000000100)=[0x1], 0x6)",
+		},
+		{
+			"test$length36(&(0x7f0000000000)={0x0, 0x0, \"01\", \"01020304\"}, &(0x7f0000000100)=[0x1, 0x2, 0x3, 0x4, 0x5], 0x0)",
+			"test$length36(&(0x7f0000000000)={0x4, 0x1, \"01\", \"01020304\"}, &(0x7f0000000100)=[0x1, 0x2, 0x3, 0x4, 0x5], 0xa)",
+		},
+		{
+			// Sizes that are not a multiple of the unit are rounded down.
+			"test$length37(&(0x7f0000000000)={0x0, \"\"/1024}, &(0x7f0000000400)=\"0102030405\", 0x0)",
+			"test$length37(&(0x7f0000000000)={0x2}, &(0x7f0000000400)=\"0102030405\", 0x1)",
+		},
+		{
+			"test$versioned(&(0x7f0000000000)=@v1={0x0, 0x5}, 0x0)",
+			"test$versioned(&(0x7f0000000000)=@v1={0x1, 0x5}, 0x8)",
+		},
+		{
+			"test$versioned(&(0x7f0000000000)=@v2={0x1, 0x5, 0x6}, 0x0)",
+			"test$versioned(&(0x7f0000000000)=@v2={0x2, 0x5, 0x6}, 0x18)",
+		},
+		{
+			"test$footer(&(0x7f0000000000)={0x0, 0x0, \"010203\", 0xfeedface}, 0x0)",
+			"test$footer(&(0x7f0000000000)={0xd, 0x5, \"010203\"}, 0xd)",
+		},
 	}
 
 	for i, test := range tests {
@@ -167,3 +212,705 @@ func TestAssignSize(t *testing.T) {
 		}
 	}
 }
+
+func TestAssignSizeNetlink(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	// Attributes of RTM_NEWLINK request for "ip link add name gr0 mtu 1400 type gre remote 10.0.0.1"
+	// (IFLA_IFNAME, IFLA_LINKINFO{IFLA_INFO_KIND, IFLA_INFO_DATA{IFLA_GRE_REMOTE}}, IFLA_MTU)
+	// as the kernel lays them out, and an u8 attribute that needs padding.
+	// nla_len covers only the header and the payload, padding of the u8 attribute is not included.
+	// nolint: lll
+	const (
+		unsized = `test$nlattr(&(0x7f0000000000)=[@ifname={0x0, {0x3, 'gr0\x00'}}, @linkinfo={0x0, {0x12, 0x0, 0x1, [@kind={0x0, {0x1, 'gre\x00'}}, @data={0x0, {0x2, 0x0, 0x1, [{0x0, {0x7, 0xa000001}}]}}]}}, @mtu={0x0, {0x4, 0x578}}, @u8={0x0, {0x5, 0x1}}])`
+		sized   = `test$nlattr(&(0x7f0000000000)=[@ifname={0x8, {0x3, 'gr0\x00'}}, @linkinfo={0x18, {0x12, 0x0, 0x1, [@kind={0x8, {0x1, 'gre\x00'}}, @data={0xc, {0x2, 0x0, 0x1, [{0x8, {0x7, 0xa000001}}]}}]}}, @mtu={0x8, {0x4, 0x578}}, @u8={0x5, {0x5, 0x1}}])`
+	)
+	msg := []byte{
+		0x08, 0x00, 0x03, 0x00, 'g', 'r', '0', 0x00,
+		0x18, 0x00, 0x12, 0x80,
+		0x08, 0x00, 0x01, 0x00, 'g', 'r', 'e', 0x00,
+		0x0c, 0x00, 0x02, 0x80,
+		0x08, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x00, 0x01,
+		0x08, 0x00, 0x04, 0x00, 0x78, 0x05, 0x00, 0x00,
+		0x05, 0x00, 0x05, 0x00, 0x01, 0x00, 0x00, 0x00,
+	}
+	p, err := target.Deserialize([]byte(unsized), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target.assignSizesCall(p.Calls[0])
+	if got := strings.TrimSpace(string(p.Serialize())); got != sized {
+		t.Fatalf("wrong netlink attribute lengths\ngot  %v\nwant %v", got, sized)
+	}
+	if got := execMemory(t, p, len(msg)); !bytes.Equal(got, msg) {
+		t.Fatalf("wrong netlink attributes\ngot  %x\nwant %x", got, msg)
+	}
+	p1, err := target.Deserialize(p.Serialize(), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := execMemory(t, p1, len(msg)); !bytes.Equal(got, msg) {
+		t.Fatalf("netlink attributes changed after round-trip\ngot  %x\nwant %x", got, msg)
+	}
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{target.SyscallMap["test$nlattr"]: true})
+	for i := 0; i < iters; i++ {
+		p.Mutate(rs, 10, ct, nil)
+		// Mutation may deliberately corrupt lengths, so check what the size assignment produces
+		// for the mutated shape of the attributes.
+		p1 := p.Clone()
+		for _, c := range p1.Calls {
+			target.assignSizesCall(c)
+			ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+				name := arg.Type().Name()
+				if !strings.HasPrefix(name, "nlattr_t[") && !strings.HasPrefix(name, "nlattr_nested_t[") {
+					return
+				}
+				inner := arg.(*GroupArg).Inner
+				body := inner[1].(*GroupArg)
+				nlaLen := inner[0].(*ConstArg).Val
+				if size := arg.Size(); size%4 != 0 || nlaLen != 2+body.Size() || size-nlaLen >= 4 {
+					t.Fatalf("bad attribute: size %v, nla_len %v\n%s", size, nlaLen, p1.Serialize())
+				}
+				if strings.HasPrefix(name, "nlattr_nested_t[") && body.Inner[2].(*ConstArg).Val != 1 {
+					t.Fatalf("NLA_F_NESTED is not set\n%s", p1.Serialize())
+				}
+			})
+		}
+	}
+}
+
+// execMemory returns size bytes of memory the executor writes for the first call of p
+// starting from the lowest copyin address (memory is zeroed, padding is not copied in).
+func execMemory(t *testing.T, p *Prog, size int) []byte {
+	buf := make([]byte, ExecBufferSize)
+	n, err := p.SerializeForExec(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec, err := p.Target.DeserializeExec(buf[:n])
+	if err != nil {
+		t.Fatal(err)
+	}
+	copyins := exec.Calls[0].Copyin
+	base := ^uint64(0)
+	for _, copyin := range copyins {
+		if copyin.Addr < base {
+			base = copyin.Addr
+		}
+	}
+	mem := make([]byte, size)
+	for _, copyin := range copyins {
+		off := copyin.Addr - base
+		var data []byte
+		switch arg := copyin.Arg.(type) {
+		case ExecArgConst:
+			if off+arg.Size > uint64(size) {
+				t.Fatalf("copyin at offset %v size %v is out of bounds", off, arg.Size)
+			}
+			old := uint64(0)
+			for i := uint64(0); i < arg.Size; i++ {
+				old |= uint64(mem[off+i]) << (8 * i)
+			}
+			val := arg.Value
+			if arg.Format == FormatBigEndian {
+				val = swapInt(val, int(arg.Size))
+			}
+			if arg.BitfieldLength != 0 {
+				mask := uint64(1)<<arg.BitfieldLength - 1
+				val = old&^(mask<<arg.BitfieldOffset) | (val&mask)<<arg.BitfieldOffset
+			}
+			data = make([]byte, arg.Size)
+			for i := range data {
+				data[i] = byte(val >> (8 * uint(i)))
+			}
+		case ExecArgData:
+			data = arg.Data
+		default:
+			t.Fatalf("unexpected copyin arg %#v", arg)
+		}
+		if off+uint64(len(data)) > uint64(size) {
+			t.Fatalf("copyin at offset %v size %v is out of bounds", off, len(data))
+		}
+		copy(mem[off:], data)
+	}
+	return mem
+}
+
+func TestAssignRingIndices(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	// nolint: lll
+	tests := []struct {
+		prog string
+		want string
+	}{
+		{
+			// Pending entries cross the wrap boundary, but fit into the ring.
+			"test$ring(&(0x7f0000000000)={0xfffffffe, 0x1, 0xff, 0x3, \"00010203\", [0x0, 0x0, 0x0, 0x0]})",
+			"test$ring(&(0x7f0000000000)={0xfffffffe, 0x1, 0xff, 0x3, \"00010203\", [0x0, 0x0, 0x0, 0x0]})",
+		},
+		{
+			// Too many pending entries across the wrap boundary.
+			"test$ring(&(0x7f0000000000)={0xfffffffe, 0x3, 0xfd, 0x2, \"00010203\", [0x0, 0x0, 0x0, 0x0]})",
+			"test$ring(&(0x7f0000000000)={0xfffffffe, 0xfffffffe, 0xfd, 0xfd, \"00010203\", [0x0, 0x0, 0x0, 0x0]})",
+		},
+		{
+			// Tail behind head.
+			"test$ring(&(0x7f0000000000)={0x5, 0x2, 0x0, 0xff, \"00010203\", [0x0, 0x0, 0x0, 0x0]})",
+			"test$ring(&(0x7f0000000000)={0x5, 0x8, 0x0, 0x0, \"00010203\", [0x0, 0x0, 0x0, 0x0]})",
+		},
+		{
+			// Empty ring right at the wrap boundary.
+			"test$ring(&(0x7f0000000000)={0xffffffff, 0x0, 0xff, 0xff, \"00010203\", []})",
+			"test$ring(&(0x7f0000000000)={0xffffffff, 0xffffffff, 0xff, 0xff, \"00010203\"})",
+		},
+	}
+	for i, test := range tests {
+		p, err := target.Deserialize([]byte(test.prog), Strict)
+		if err != nil {
+			t.Fatalf("failed to deserialize prog %v: %v", i, err)
+		}
+		target.assignSizesCall(p.Calls[0])
+		if got := strings.TrimSpace(string(p.Serialize())); got != test.want {
+			t.Fatalf("wrong ring indices in prog %v\ngot  %v\nwant %v", i, got, test.want)
+		}
+	}
+	enabled := map[*Syscall]bool{target.SyscallMap["test$ring"]: true}
+	ct := target.BuildChoiceTable(nil, enabled)
+	wrapped := 0
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 5, ct)
+		for j := 0; j < 10; j++ {
+			p.Mutate(rs, 10, ct, nil)
+		}
+		for _, c := range p.Calls {
+			ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+				if arg.Type().Name() != "ring_struct" {
+					return
+				}
+				inner := arg.(*GroupArg).Inner
+				check := func(head, tail Arg, size, mask uint64) {
+					h, tl := head.(*ConstArg).Val, tail.(*ConstArg).Val
+					if pending := (tl - h) & mask; pending > size {
+						t.Fatalf("ring has %v pending entries, but only %v elements\n%s",
+							pending, size, p.Serialize())
+					}
+					if tl&mask < h&mask {
+						wrapped++
+					}
+				}
+				check(inner[0], inner[1], uint64(len(inner[len(inner)-1].(*GroupArg).Inner)), 1<<32-1)
+				check(inner[2], inner[3], inner[4].Size(), 1<<8-1)
+			})
+		}
+	}
+	if wrapped == 0 {
+		t.Fatalf("ring indices never wrapped around")
+	}
+}
+
+func TestAssignCountedArrays(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	// nolint: lll
+	tests := []struct {
+		prog string
+		want string
+	}{
+		{
+			"test$res6(0x3, &(0x7f0000000000)=[0x0])",
+			"test$res6(0x3, &(0x7f0000000000)=[0x0, 0x0, 0x0])",
+		},
+		{
+			"test$res6(0x1, &(0x7f0000000000)=[0x0, 0x0, 0x0])",
+			"test$res6(0x1, &(0x7f0000000000)=[0x0])",
+		},
+		{
+			// Large counts are bounded.
+			"test$res6(0xffff, &(0x7f0000000000))",
+			"test$res6(0xffff, &(0x7f0000000000)=[0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0])",
+		},
+		{
+			// The count is not known in advance, the array is left as is.
+			"r0 = test$res0()\ntest$res6(r0, &(0x7f0000000000)=[0x0, 0x0])",
+			"r0 = test$res0()\ntest$res6(r0, &(0x7f0000000000)=[0x0, 0x0])",
+		},
+		{
+			"test$res7(&(0x7f0000000000)={0x2, []})",
+			"test$res7(&(0x7f0000000000)={0x2, [0x0, 0x0]})",
+		},
+	}
+	for i, test := range tests {
+		p, err := target.Deserialize([]byte(test.prog), Strict)
+		if err != nil {
+			t.Fatalf("failed to deserialize prog %v: %v", i, err)
+		}
+		target.assignSizesCall(p.Calls[len(p.Calls)-1])
+		if got := strings.TrimSpace(string(p.Serialize())); got != test.want {
+			t.Fatalf("wrong array in prog %v\ngot  %v\nwant %v", i, got, test.want)
+		}
+	}
+	enabled := map[*Syscall]bool{
+		target.SyscallMap["test$res0"]: true,
+		target.SyscallMap["test$res6"]: true,
+	}
+	ct := target.BuildChoiceTable(nil, enabled)
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 5, ct)
+		for _, c := range p.Calls {
+			if c.Meta.Name != "test$res6" {
+				continue
+			}
+			res := c.Args[0].(*ResultArg)
+			arr := InnerArg(c.Args[1])
+			if res.Res != nil || arr == nil {
+				continue
+			}
+			want := res.Val
+			if want > maxCountedArrayLen {
+				want = maxCountedArrayLen
+			}
+			if got := uint64(len(arr.(*GroupArg).Inner)); got != want {
+				t.Fatalf("array has %v elements, want %v\n%s", got, want, p.Serialize())
+			}
+		}
+	}
+}
+
+func TestAssignOverlappingPointers(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	// nolint: lll
+	tests := []struct {
+		prog string
+		want string
+	}{
+		{
+			`test$overlap0(&(0x7f0000000000)="aa00", &(0x7f0000001000)="bb00")`,
+			`test$overlap0(&(0x7f0000000000)="aa00", &(0x7f000000000c)="bb00")`,
+		},
+		{
+			`test$overlap1(&(0x7f0000000000)={&(0x7f0000001000), &(0x7f0000002000)=0x1})`,
+			`test$overlap1(&(0x7f0000000000)={&(0x7f0000001000), &(0x7f0000001000)=0x1})`,
+		},
+		{
+			// Nil pointers are left as is.
+			`test$overlap0(0x0, &(0x7f0000001000)="bb00")`,
+			`test$overlap0(0x0, &(0x7f0000001000)="bb00")`,
+		},
+		{
+			// The overlapping pointer must fit into the data area.
+			`test$overlap0(&(0x7f0000fffff0)="aa00", &(0x7f0000001000)="bb00")`,
+			`test$overlap0(&(0x7f0000fffff0)="aa00", &(0x7f0000001000)="bb00")`,
+		},
+	}
+	for i, test := range tests {
+		p, err := target.Deserialize([]byte(test.prog), Strict)
+		if err != nil {
+			t.Fatalf("failed to deserialize prog %v: %v", i, err)
+		}
+		target.assignSizesCall(p.Calls[len(p.Calls)-1])
+		if got := strings.TrimSpace(string(p.Serialize())); got != test.want {
+			t.Fatalf("wrong pointers in prog %v\ngot  %v\nwant %v", i, got, test.want)
+		}
+	}
+	enabled := map[*Syscall]bool{
+		target.SyscallMap["test$overlap0"]: true,
+	}
+	ct := target.BuildChoiceTable(nil, enabled)
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 5, ct)
+		p.Mutate(rs, 5, ct, nil)
+		for _, c := range p.Calls {
+			ptr0, ptr1 := c.Args[0].(*PointerArg), c.Args[1].(*PointerArg)
+			if ptr0.Res == nil || ptr1.Res == nil || ptr0.Address+12+ptr1.Res.Size() > target.NumPages*target.PageSize {
+				continue
+			}
+			if ptr1.Address != ptr0.Address+12 {
+				t.Fatalf("pointers don't overlap\n%s", p.Serialize())
+			}
+		}
+	}
+}
+
+func TestAssignSubkindFlags(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	tests := []struct {
+		prog string
+		want string
+	}{
+		{
+			"r0 = test$subkind0()\ntest$subkind2(r0, 0x4)",
+			"r0 = test$subkind0()\ntest$subkind2(r0, 0x1)",
+		},
+		{
+			"r0 = test$subkind1()\ntest$subkind2(r0, 0x1)",
+			"r0 = test$subkind1()\ntest$subkind2(r0, 0x4)",
+		},
+		{
+			"r0 = test$subkind1()\ntest$subkind2(r0, 0x3)",
+			"r0 = test$subkind1()\ntest$subkind2(r0, 0x3)",
+		},
+		{
+			// The resource is not of any subkind, the value is not restricted.
+			"test$subkind2(0x0, 0x5)",
+			"test$subkind2(0x0, 0x5)",
+		},
+	}
+	for i, test := range tests {
+		p, err := target.Deserialize([]byte(test.prog), Strict)
+		if err != nil {
+			t.Fatalf("failed to deserialize prog %v: %v", i, err)
+		}
+		target.assignSizesCall(p.Calls[len(p.Calls)-1])
+		if got := strings.TrimSpace(string(p.Serialize())); got != test.want {
+			t.Fatalf("wrong flags in prog %v\ngot  %v\nwant %v", i, got, test.want)
+		}
+	}
+	enabled := map[*Syscall]bool{
+		target.SyscallMap["test$subkind0"]: true,
+		target.SyscallMap["test$subkind1"]: true,
+		target.SyscallMap["test$subkind2"]: true,
+	}
+	ct := target.BuildChoiceTable(nil, enabled)
+	want := map[string]map[uint64]bool{
+		"syz_obj_file": {1: true, 2: true},
+		"syz_obj_dir":  {3: true, 4: true},
+	}
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 10, ct)
+		p.Mutate(rs, 10, ct, nil)
+		for _, c := range p.Calls {
+			if c.Meta.Name != "test$subkind2" {
+				continue
+			}
+			res := c.Args[0].(*ResultArg)
+			if res.Res == nil {
+				continue
+			}
+			kind := res.Res.Type().(*ResourceType).Desc.Name
+			if val := c.Args[1].(*ConstArg).Val; !want[kind][val] {
+				t.Fatalf("flags value %v is not valid for %v\n%s", val, kind, p.Serialize())
+			}
+		}
+	}
+}
+
+func TestAssignRequestMasks(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	tests := []struct {
+		prog string
+		want string
+	}{
+		{
+			"test$request_mask1(0x5, &(0x7f0000000000))",
+			"test$request_mask1(0x5, &(0x7f0000000000))",
+		},
+		{
+			"test$request_mask1(0xff, &(0x7f0000000000))",
+			"test$request_mask1(0xf, &(0x7f0000000000))",
+		},
+		{
+			"test$request_mask2(&(0x7f0000000000)={0x31, {0x0, 0x0, 0x0, 0x0}})",
+			"test$request_mask2(&(0x7f0000000000)={0x1})",
+		},
+	}
+	for i, test := range tests {
+		p, err := target.Deserialize([]byte(test.prog), NonStrict)
+		if err != nil {
+			t.Fatalf("failed to deserialize prog %v: %v", i, err)
+		}
+		if got := strings.TrimSpace(string(p.Serialize())); got != test.want {
+			t.Fatalf("wrong mask in prog %v\ngot  %v\nwant %v", i, got, test.want)
+		}
+		if _, err := target.Deserialize([]byte(test.prog), Strict); (err != nil) != (i != 0) {
+			t.Fatalf("strict deserialization of prog %v: %v", i, err)
+		}
+	}
+	enabled := map[*Syscall]bool{
+		target.SyscallMap["test$request_mask0"]: true,
+		target.SyscallMap["test$request_mask1"]: true,
+		target.SyscallMap["test$request_mask2"]: true,
+	}
+	ct := target.BuildChoiceTable(nil, enabled)
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 10, ct)
+		p.Mutate(rs, 10, ct, nil)
+		for _, c := range p.Calls {
+			mask := c.Args[0]
+			if ptr, ok := mask.(*PointerArg); ok {
+				if ptr.Res == nil {
+					continue
+				}
+				mask = ptr.Res.(*GroupArg).Inner[0]
+			}
+			if val := mask.(*ConstArg).Val; val&^0xf != 0 {
+				t.Fatalf("request mask 0x%x requests unknown fields\n%s", val, p.Serialize())
+			}
+		}
+	}
+}
+
+func TestAssignSparseIndices(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	tests := []struct {
+		prog string
+		want string
+	}{
+		{
+			"test$sparse0(&(0x7f0000000000)=[{0x3, 0x1}, {0x1, 0x2}, {0x1, 0x3}], 0x0)",
+			"test$sparse0(&(0x7f0000000000)=[{0x1, 0x2}, {0x2, 0x3}, {0x3, 0x1}], 0x3)",
+		},
+		{
+			"test$sparse0(&(0x7f0000000000)=[{0xf, 0x1}, {0x20, 0x2}], 0x2)",
+			"test$sparse0(&(0x7f0000000000)=[{0xe, 0x1}, {0xf, 0x2}], 0x2)",
+		},
+		{
+			// The index range fits only 4 entries.
+			"test$sparse1(&(0x7f0000000000)=[{0x3, 0x1}, {0x3, 0x2}, {0x3, 0x3}, {0x0, 0x4}, {0x2, 0x5}])",
+			"test$sparse1(&(0x7f0000000000)=[{0x0, 0x4}, {0x1, 0x1}, {0x2, 0x2}, {0x3, 0x3}])",
+		},
+	}
+	for i, test := range tests {
+		p, err := target.Deserialize([]byte(test.prog), NonStrict)
+		if err != nil {
+			t.Fatalf("failed to deserialize prog %v: %v", i, err)
+		}
+		target.assignSizesCall(p.Calls[0])
+		if got := strings.TrimSpace(string(p.Serialize())); got != test.want {
+			t.Fatalf("wrong sparse array in prog %v\ngot  %v\nwant %v", i, got, test.want)
+		}
+	}
+	enabled := map[*Syscall]bool{
+		target.SyscallMap["test$sparse0"]: true,
+		target.SyscallMap["test$sparse1"]: true,
+	}
+	ct := target.BuildChoiceTable(nil, enabled)
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 5, ct)
+		for _, c := range p.Calls {
+			ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+				typ, ok := arg.Type().(*ArrayType)
+				if !ok || !typ.Sparse {
+					return
+				}
+				lo, hi := sparseIndexRange(typ.Type.(*StructType).Fields[0].(*IntType))
+				for j, elem := range arg.(*GroupArg).Inner {
+					idx := elem.(*GroupArg).Inner[0].(*ConstArg).Val
+					if idx < lo || idx > hi || j != 0 &&
+						idx <= arg.(*GroupArg).Inner[j-1].(*GroupArg).Inner[0].(*ConstArg).Val {
+						t.Fatalf("bad index 0x%x of entry %v\n%s", idx, j, p.Serialize())
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestAssignUniqueFields(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	tests := []struct {
+		prog string
+		want string
+	}{
+		{
+			"test$unique(&(0x7f0000000000)=[{0x1, 0x0}, {0x1, 0x1}, {0x2, 0x2}], &(0x7f0000000100)=[{0x5, 0xa}, {0x1, 0xa}], 0x0)",
+			"test$unique(&(0x7f0000000000)=[{0x1}, {0x2, 0x1}, {0x3, 0x2}], &(0x7f0000000100)=[{0x1, 0xa}, {0x2, 0xb}], 0x0)",
+		},
+		{
+			// The largest value wraps around to the smallest one.
+			"test$unique(0x0, &(0x7f0000000100)=[{0x4, 0x14}, {0x4, 0x14}, {0x1, 0xa}], 0x0)",
+			"test$unique(0x0, &(0x7f0000000100)=[{0x4, 0x14}, {0x1, 0xa}, {0x2, 0xb}], 0x0)",
+		},
+		{
+			// The 2-bit field fits only 4 elements.
+			"test$unique(0x0, 0x0, &(0x7f0000000200)=[{0x3, 0x1}, {0x3, 0x2}, {0x3, 0x3}, {0x0, 0x4}, {0x2, 0x5}])",
+			"test$unique(0x0, 0x0, &(0x7f0000000200)=[{0x3, 0x1}, {0x0, 0x2}, {0x1, 0x3}, {0x2, 0x4}])",
+		},
+	}
+	for i, test := range tests {
+		p, err := target.Deserialize([]byte(test.prog), NonStrict)
+		if err != nil {
+			t.Fatalf("failed to deserialize prog %v: %v", i, err)
+		}
+		target.assignSizesCall(p.Calls[0])
+		if got := strings.TrimSpace(string(p.Serialize())); got != test.want {
+			t.Fatalf("wrong unique fields in prog %v\ngot  %v\nwant %v", i, got, test.want)
+		}
+	}
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{target.SyscallMap["test$unique"]: true})
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 5, ct)
+		p.Mutate(rs, 10, ct, nil)
+		for _, c := range p.Calls {
+			ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+				typ, ok := arg.Type().(*ArrayType)
+				if !ok {
+					return
+				}
+				elem := typ.Type.(*StructType)
+				for _, name := range elem.UniqueFields {
+					used := make(map[uint64]bool)
+					for _, inner := range arg.(*GroupArg).Inner {
+						for _, f := range inner.(*GroupArg).Inner {
+							if f.Type().FieldName() != name {
+								continue
+							}
+							val := f.(*ConstArg).Val
+							if used[val] {
+								t.Fatalf("duplicate value 0x%x of unique field %v\n%s", val, name, p.Serialize())
+							}
+							used[val] = true
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestAssignRelPtrs(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	tests := []struct {
+		prog string
+		want string
+	}{
+		{
+			// Forward offsets from the struct base and from the field, backward offset wraps around.
+			"test$relptr(&(0x7f0000000000)={0x0, 0x0, 0x0, 0x0, \"0102\"})",
+			"test$relptr(&(0x7f0000000000)={0x0, 0xb, 0xfff8, 0x1, \"0102\"})",
+		},
+		{
+			// Pointee of zero size gives null offset.
+			"test$relptr(&(0x7f0000000000)={0x0, 0x5, 0x0, 0x5, \"\"})",
+			"test$relptr(&(0x7f0000000000)={0x0, 0x0, 0xfff8})",
+		},
+		{
+			// Explicit values are preserved, AUTO values are computed.
+			"test$relptr(&(0x7f0000000000)={0x0, 0x5, AUTO, AUTO, \"01\"})",
+			"test$relptr(&(0x7f0000000000)={0x0, 0x5, 0xfff8, 0x1, \"01\"})",
+		},
+	}
+	for i, test := range tests {
+		p, err := target.Deserialize([]byte(test.prog), Strict)
+		if err != nil {
+			t.Fatalf("failed to deserialize prog %v: %v", i, err)
+		}
+		if i != len(tests)-1 {
+			target.assignSizesCall(p.Calls[0])
+		}
+		if got := strings.TrimSpace(string(p.Serialize())); got != test.want {
+			t.Fatalf("wrong relative pointers in prog %v\ngot  %v\nwant %v", i, got, test.want)
+		}
+	}
+}
+
+func TestAssignArrayIndices(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	tests := []struct {
+		prog string
+		want string
+	}{
+		{
+			"test$arrayindex0(0x3, &(0x7f0000000000)=[{0x1, 0x5, 0x1}, {0x2, 0x0, 0x2}, {0x3, 0x7, 0x3}])",
+			"test$arrayindex0(0x3, &(0x7f0000000000)=[{0x1, 0x0, 0x1}, {0x2, 0x1, 0x2}, {0x3, 0x2, 0x3}])",
+		},
+		{
+			"test$arrayindex1(&(0x7f0000000000)=[{0x3, 0x1}, {0x3, 0x2}, {0x0, 0x3}, {0x1, 0x4}])",
+			"test$arrayindex1(&(0x7f0000000000)=[{0x0, 0x1}, {0x1, 0x2}, {0x2, 0x3}, {0x3, 0x4}])",
+		},
+		{
+			// Explicit values are preserved, AUTO values are computed.
+			"test$arrayindex0(0x3, &(0x7f0000000000)=[{0x1, 0x5, 0x1}, {0x2, AUTO, 0x2}, {0x3, AUTO, 0x3}])",
+			"test$arrayindex0(0x3, &(0x7f0000000000)=[{0x1, 0x5, 0x1}, {0x2, 0x1, 0x2}, {0x3, 0x2, 0x3}])",
+		},
+	}
+	for i, test := range tests {
+		p, err := target.Deserialize([]byte(test.prog), Strict)
+		if err != nil {
+			t.Fatalf("failed to deserialize prog %v: %v", i, err)
+		}
+		if i != len(tests)-1 {
+			target.assignSizesCall(p.Calls[0])
+		}
+		if got := strings.TrimSpace(string(p.Serialize())); got != test.want {
+			t.Fatalf("wrong array indices in prog %v\ngot  %v\nwant %v", i, got, test.want)
+		}
+	}
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{
+		target.SyscallMap["test$arrayindex0"]: true,
+		target.SyscallMap["test$arrayindex1"]: true,
+	})
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 5, ct)
+		p.Mutate(rs, 10, ct, nil)
+		for _, c := range p.Calls {
+			ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+				if _, ok := arg.Type().(*ArrayType); !ok {
+					return
+				}
+				for idx, elem := range arg.(*GroupArg).Inner {
+					for _, f := range elem.(*GroupArg).Inner {
+						typ, ok := f.Type().(*IntType)
+						if !ok || typ.Kind != IntArrayIndex {
+							continue
+						}
+						if val := f.(*ConstArg).Val; val != uint64(idx)&ringIndexMask(typ) {
+							t.Fatalf("element %v has index %v\n%s", idx, val, p.Serialize())
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestAssignFormatStrings(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	tests := []struct {
+		prog string
+		want string
+	}{
+		{
+			"test$format(&(0x7f0000000000)='foo\\x00', &(0x7f0000000100)=[@d=0x1, @x=0x2])",
+			"test$format(&(0x7f0000000000)='%lld %08llx\\x00', &(0x7f0000000100)=[@d=0x1, @x=0x2])",
+		},
+		{
+			"test$format(&(0x7f0000000000)='%d\\x00', &(0x7f0000000100)=[@s=&(0x7f0000000200)='bar\\x00'])",
+			"test$format(&(0x7f0000000000)='%.16s\\x00', &(0x7f0000000100)=[@s=&(0x7f0000000200)='bar\\x00'])",
+		},
+		{
+			"test$format(&(0x7f0000000000)='%d\\x00', 0x0)",
+			"test$format(&(0x7f0000000000)='\\x00', 0x0)",
+		},
+	}
+	for i, test := range tests {
+		p, err := target.Deserialize([]byte(test.prog), Strict)
+		if err != nil {
+			t.Fatalf("failed to deserialize prog %v: %v", i, err)
+		}
+		target.assignSizesCall(p.Calls[0])
+		if got := strings.TrimSpace(string(p.Serialize())); got != test.want {
+			t.Fatalf("wrong format string in prog %v\ngot  %v\nwant %v", i, got, test.want)
+		}
+	}
+	ct := target.BuildChoiceTable(nil, map[*Syscall]bool{
+		target.SyscallMap["test$format"]: true,
+	})
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 5, ct)
+		p.Mutate(rs, 10, ct, nil)
+		for _, c := range p.Calls {
+			ptr := c.Args[0].(*PointerArg)
+			if ptr.Res == nil {
+				continue
+			}
+			format := ptr.Res.(*DataArg).Data()
+			var specs []string
+			if arr, ok := c.Args[1].(*PointerArg).Res.(*GroupArg); ok {
+				for _, elem := range arr.Inner {
+					specs = append(specs, formatSpecifier(elem.(*UnionArg)))
+				}
+			}
+			if want := strings.Join(specs, " ") + "\x00"; string(format) != want {
+				t.Fatalf("format string %q does not match arguments %q\n%s", format, want, p.Serialize())
+			}
+		}
+	}
+}
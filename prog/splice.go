@@ -0,0 +1,108 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"fmt"
+)
+
+// Splice inserts calls [from, to) of donor into p before call idx and returns
+// the number of inserted calls. Donor is not modified.
+// Spliced calls can use resources produced by donor calls outside of the range.
+// Such uses are redirected to compatible resources produced by p calls before idx.
+// If there are no such resources, the donor calls that produce them are spliced
+// as well (they are inserted before the calls that need them).
+// If ct is not nil, calls that are not enabled in ct are not spliced,
+// and calls that need resources that can't be obtained otherwise are dropped.
+func (p *Prog) Splice(donor *Prog, from, to, idx int, ct *ChoiceTable) int {
+	if p.Target != donor.Target {
+		panic(fmt.Sprintf("splicing %v/%v program into %v/%v program",
+			donor.Target.OS, donor.Target.Arch, p.Target.OS, p.Target.Arch))
+	}
+	if from < 0 || from > to || to > len(donor.Calls) || idx < 0 || idx > len(p.Calls) {
+		panic(fmt.Sprintf("bad splice range [%v, %v) of %v calls into %v of %v calls",
+			from, to, len(donor.Calls), idx, len(p.Calls)))
+	}
+	var hostRes []*ResultArg
+	for _, c := range p.Calls[:idx] {
+		ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+			if a, ok := arg.(*ResultArg); ok && a.Type().Dir() != DirIn {
+				hostRes = append(hostRes, a)
+			}
+		})
+	}
+	d := donor.Clone()
+	producer := make(map[*ResultArg]*Call)
+	for _, c := range d.Calls {
+		ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+			if a, ok := arg.(*ResultArg); ok {
+				producer[a] = c
+			}
+		})
+	}
+	enabled := func(c *Call) bool {
+		return ct == nil || ct.run[c.Meta.ID] != nil
+	}
+	selected := make(map[*Call]bool)
+	for _, c := range d.Calls[from:to] {
+		selected[c] = enabled(c)
+	}
+	// Resources are used only by subsequent calls, so walking backwards
+	// we see all users of a call before the call itself.
+	for i := to - 1; i >= 0; i-- {
+		c := d.Calls[i]
+		if !selected[c] {
+			continue
+		}
+		redirect := make(map[*ResultArg]*ResultArg)
+		var needed []*Call
+		ok := true
+		ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+			a, isRes := arg.(*ResultArg)
+			if !isRes || a.Res == nil || selected[producer[a.Res]] {
+				return
+			}
+			if res := p.Target.findCompatibleResult(hostRes, a); res != nil {
+				redirect[a] = res
+				return
+			}
+			if prod := producer[a.Res]; enabled(prod) {
+				needed = append(needed, prod)
+				return
+			}
+			ok = false
+		})
+		if !ok {
+			selected[c] = false
+			continue
+		}
+		for a, res := range redirect {
+			arg := MakeResultArg(a.Type(), res, 0)
+			arg.OpDiv, arg.OpAdd = a.OpDiv, a.OpAdd
+			replaceResultArg(a, arg)
+		}
+		for _, prod := range needed {
+			selected[prod] = true
+		}
+	}
+	// Removal replaces uses of results of removed calls with default values.
+	for i := len(d.Calls) - 1; i >= 0; i-- {
+		if !selected[d.Calls[i]] {
+			d.removeCall(i)
+		}
+	}
+	p.Calls = append(p.Calls[:idx], append(d.Calls, p.Calls[idx:]...)...)
+	return len(d.Calls)
+}
+
+// findCompatibleResult returns the last of results that can be used instead of arg.Res.
+func (target *Target) findCompatibleResult(results []*ResultArg, arg *ResultArg) *ResultArg {
+	dst := arg.Type().(*ResourceType).Desc.Name
+	for i := len(results) - 1; i >= 0; i-- {
+		if target.isCompatibleResource(dst, results[i].Type().(*ResourceType).Desc.Name) {
+			return results[i]
+		}
+	}
+	return nil
+}
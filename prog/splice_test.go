@@ -0,0 +1,131 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestSplice(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	enabled := make(map[*Syscall]bool)
+	for _, c := range target.Syscalls {
+		if c.Name != "test$res0" {
+			enabled[c] = true
+		}
+	}
+	noRes0 := target.BuildChoiceTable(nil, enabled)
+	tests := []struct {
+		host   string
+		donor  string
+		from   int
+		to     int
+		idx    int
+		ct     *ChoiceTable
+		result string
+	}{
+		// Self-contained range is inserted as is.
+		{
+			host:   "test$res2()\n",
+			donor:  "r0 = test$res0()\ntest$res1(r0)\n",
+			from:   0,
+			to:     2,
+			idx:    0,
+			result: "r0 = test$res0()\ntest$res1(r0)\ntest$res2()\n",
+		},
+		// Missing producer is taken from donor.
+		{
+			host:   "test$res2()\n",
+			donor:  "r0 = test$res0()\ntest$res2()\ntest$res1(r0)\n",
+			from:   2,
+			to:     3,
+			idx:    1,
+			result: "test$res2()\nr0 = test$res0()\ntest$res1(r0)\n",
+		},
+		// Uses are redirected to resources of the host program.
+		{
+			host:   "r0 = test$res0()\ntest$res2()\n",
+			donor:  "r0 = test$res0()\ntest$res2()\ntest$res1(r0)\n",
+			from:   2,
+			to:     3,
+			idx:    2,
+			result: "r0 = test$res0()\ntest$res2()\ntest$res1(r0)\n",
+		},
+		// Host resources after the insertion point can't be used.
+		{
+			host:   "r0 = test$res0()\n",
+			donor:  "r0 = test$res0()\ntest$res1(r0)\n",
+			from:   1,
+			to:     2,
+			idx:    0,
+			result: "r0 = test$res0()\ntest$res1(r0)\ntest$res0()\n",
+		},
+		// Uses of results of calls outside of the range get default values.
+		{
+			host:   "test$res2()\n",
+			donor:  "r0 = test$res0()\ntest$res1(r0)\ntest$res1(r0)\n",
+			from:   0,
+			to:     1,
+			idx:    1,
+			result: "test$res2()\ntest$res0()\n",
+		},
+		// Calls that need disabled producers are dropped.
+		{
+			host:   "test$res2()\n",
+			donor:  "r0 = test$res0()\ntest$res1(r0)\ntest$res2()\n",
+			from:   1,
+			to:     3,
+			idx:    1,
+			ct:     noRes0,
+			result: "test$res2()\ntest$res2()\n",
+		},
+	}
+	for i, test := range tests {
+		host, err := target.Deserialize([]byte(test.host), Strict)
+		if err != nil {
+			t.Fatalf("test #%v: failed to deserialize host: %v", i, err)
+		}
+		donor, err := target.Deserialize([]byte(test.donor), Strict)
+		if err != nil {
+			t.Fatalf("test #%v: failed to deserialize donor: %v", i, err)
+		}
+		n := host.Splice(donor, test.from, test.to, test.idx, test.ct)
+		if err := host.validate(); err != nil {
+			t.Fatalf("test #%v: invalid program after splice: %v", i, err)
+		}
+		if got := string(host.Serialize()); got != test.result {
+			t.Fatalf("test #%v: got:\n%v\nwant:\n%v", i, got, test.result)
+		}
+		if want := strings.Count(test.result, "\n") - strings.Count(test.host, "\n"); n != want {
+			t.Fatalf("test #%v: inserted %v calls, want %v", i, n, want)
+		}
+		if got := string(donor.Serialize()); got != test.donor {
+			t.Fatalf("test #%v: donor was modified:\n%v", i, got)
+		}
+	}
+}
+
+func TestSpliceRandom(t *testing.T) {
+	target, rs, iters := initTest(t)
+	r := rand.New(rs)
+	ct := target.BuildChoiceTable(nil, nil)
+	for i := 0; i < iters; i++ {
+		host := target.Generate(rs, 10, ct)
+		donor := target.Generate(rs, 10, ct)
+		from := r.Intn(len(donor.Calls) + 1)
+		to := from + r.Intn(len(donor.Calls)-from+1)
+		idx := r.Intn(len(host.Calls) + 1)
+		ncalls := len(host.Calls)
+		n := host.Splice(donor, from, to, idx, ct)
+		if n < to-from || len(host.Calls) != ncalls+n {
+			t.Fatalf("spliced %v calls of [%v, %v) into %v calls, got %v calls",
+				n, from, to, ncalls, len(host.Calls))
+		}
+		if err := host.validate(); err != nil {
+			t.Fatalf("invalid program after splice: %v\n%s", err, host.Serialize())
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+)
+
+// StructureHash returns hash of the program structure that can be used to bucket
+// structurally-equivalent programs (e.g. for corpus deduplication).
+// The structure includes the sequence of calls, chosen union options, number of array elements,
+// nil/non-nil state of pointers and resource topology (which resource is passed to which argument).
+// Concrete values of integers, flags, lengths, data, addresses and special resource values
+// are ignored. The hash is stable across runs and processes.
+func (p *Prog) StructureHash() string {
+	return p.structureHash(false)
+}
+
+// CoverageFingerprint returns hash of the program that predicts identical coverage,
+// so that execution results of known programs can be reused instead of re-executing them.
+// On top of the program structure (see StructureHash) the fingerprint includes input values
+// that are likely to select different code paths according to their types: flags, ints with small
+// value domains, byte order marks, special resource values, contents of strings and file names
+// and sizes of other data. Output values, addresses, lengths, checksums and plain ints are ignored.
+// This is heuristic, but programs with different structure always get different fingerprints.
+// The fingerprint is stable across runs and processes.
+func (p *Prog) CoverageFingerprint() string {
+	return p.structureHash(true)
+}
+
+func (p *Prog) structureHash(values bool) string {
+	h := &structureHasher{
+		buf:    new(bytes.Buffer),
+		ids:    make(map[*ResultArg]int),
+		values: values,
+	}
+	for _, c := range p.Calls {
+		fmt.Fprintf(h.buf, "%v(", c.Meta.Name)
+		for _, arg := range c.Args {
+			h.arg(arg)
+		}
+		h.buf.WriteByte(')')
+		if c.Ret != nil {
+			h.result(c.Ret)
+		}
+		h.buf.WriteByte('\n')
+	}
+	sum := sha1.Sum(h.buf.Bytes())
+	return hex.EncodeToString(sum[:])
+}
+
+type structureHasher struct {
+	buf    *bytes.Buffer
+	ids    map[*ResultArg]int
+	values bool
+}
+
+func (h *structureHasher) arg(arg Arg) {
+	switch a := arg.(type) {
+	case *ConstArg:
+		h.buf.WriteByte('c')
+		if h.values && constValueMatters(a.Type()) {
+			fmt.Fprintf(h.buf, "=%x;", a.Val)
+		}
+	case *PointerArg:
+		switch {
+		case a.IsSpecial():
+			h.buf.WriteByte('n')
+		case a.Res == nil:
+			h.buf.WriteByte('v')
+		default:
+			h.buf.WriteByte('&')
+			h.arg(a.Res)
+		}
+	case *DataArg:
+		h.buf.WriteByte('d')
+		if h.values {
+			h.data(a)
+		}
+	case *GroupArg:
+		fmt.Fprintf(h.buf, "{%v:", len(a.Inner))
+		for _, inner := range a.Inner {
+			h.arg(inner)
+		}
+		h.buf.WriteByte('}')
+	case *UnionArg:
+		fmt.Fprintf(h.buf, "@%v=", a.Option.Type().FieldName())
+		h.arg(a.Option)
+	case *ResultArg:
+		h.result(a)
+	default:
+		panic(fmt.Sprintf("unknown arg %#v", arg))
+	}
+}
+
+// result writes resource topology: references to earlier resources are written
+// as sequential ids of the referenced resources, resources that are used later get the next id.
+func (h *structureHasher) result(a *ResultArg) {
+	h.buf.WriteByte('r')
+	if a.Res != nil {
+		fmt.Fprintf(h.buf, "<%v", h.ids[a.Res])
+	} else if h.values && a.Type().Dir() != DirOut {
+		fmt.Fprintf(h.buf, "=%x;", a.Val)
+	}
+	if len(a.uses) != 0 {
+		id := len(h.ids)
+		h.ids[a] = id
+		fmt.Fprintf(h.buf, ">%v", id)
+	}
+}
+
+// data writes contents of input strings and file names, and sizes of all other data.
+func (h *structureHasher) data(a *DataArg) {
+	typ := a.Type().(*BufferType)
+	if typ.Dir() != DirOut && (typ.Kind == BufferString || typ.Kind == BufferFilename) {
+		fmt.Fprintf(h.buf, "=%x;", a.Data())
+		return
+	}
+	fmt.Fprintf(h.buf, "#%v;", a.Size())
+}
+
+// constValueMatters returns if the value of an input arg of type typ is likely to affect coverage.
+func constValueMatters(typ Type) bool {
+	if typ.Dir() == DirOut {
+		return false
+	}
+	switch t := typ.(type) {
+	case *FlagsType:
+		return true
+	case *IntType:
+		switch t.Kind {
+		case IntPlain:
+			return t.ByteOrderMark
+		case IntRange:
+			return t.RangeEnd-t.RangeBegin < 256
+		case IntPow2, IntFlagIndex:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+// Copyright 2019 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"testing"
+)
+
+func TestStructureHash(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	tests := []struct {
+		prog0 string
+		prog1 string
+		equal bool
+	}{
+		// Scalar values, data and addresses are ignored.
+		{
+			"test$int(0x1, 0x2, 0x3, 0x4, 0x5)",
+			"test$int(0x0, 0xff, 0x0, 0x0, 0xffffffffffffffff)",
+			true,
+		},
+		{
+			`test$array2(&(0x7f0000000000)={0x42, "0102", 0x1})`,
+			`test$array2(&(0x7f0000001000)={0x1, "aabbccddee", 0x2})`,
+			true,
+		},
+		{
+			"r0 = test$res0()\ntest$res1(r0)",
+			"r0 = test$res0()\ntest$res1(r0)\n",
+			true,
+		},
+		{
+			"test$res1(0xffff)",
+			"test$res1(0x1)",
+			true,
+		},
+		// Different calls.
+		{
+			"test$res0()",
+			"test$res2()",
+			false,
+		},
+		// Resource topology.
+		{
+			"r0 = test$res0()\ntest$res1(r0)",
+			"r0 = test$res0()\ntest$res1(0xffff)",
+			false,
+		},
+		{
+			"r0 = test$res0()\nr1 = test$res0()\ntest$res1(r0)",
+			"r0 = test$res0()\nr1 = test$res0()\ntest$res1(r1)",
+			false,
+		},
+		// Union options.
+		{
+			"test$syz_union4(@f1=0x1)",
+			"test$syz_union4(@f2=0x1)",
+			false,
+		},
+		// Array lengths.
+		{
+			"test$array0(&(0x7f0000000000)={0x1, [@f0=0x2], 0x3})",
+			"test$array0(&(0x7f0000000000)={0x1, [@f0=0x2, @f0=0x2], 0x3})",
+			false,
+		},
+		// Nil pointers.
+		{
+			"test$opt1(&(0x7f0000000000)=0x1)",
+			"test$opt1(0x0)",
+			false,
+		},
+	}
+	for i, test := range tests {
+		p0, err := target.Deserialize([]byte(test.prog0), Strict)
+		if err != nil {
+			t.Fatalf("#%v: failed to deserialize %q: %v", i, test.prog0, err)
+		}
+		p1, err := target.Deserialize([]byte(test.prog1), Strict)
+		if err != nil {
+			t.Fatalf("#%v: failed to deserialize %q: %v", i, test.prog1, err)
+		}
+		h0, h1 := p0.StructureHash(), p1.StructureHash()
+		if h0 != p0.Clone().StructureHash() {
+			t.Fatalf("#%v: hash of the program clone differs", i)
+		}
+		if (h0 == h1) != test.equal {
+			t.Errorf("#%v: got equal=%v, want %v\n%s\n%s", i, h0 == h1, test.equal, test.prog0, test.prog1)
+		}
+	}
+}
+
+func TestStructureHashStable(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	p, err := target.Deserialize([]byte("r0 = test$res0()\ntest$res1(r0)\ntest$syz_union4(@f1=0x1)\n"), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The hash must not change across runs, otherwise persisted buckets become useless.
+	const want = "087f667f069283111c1fd692f5662197c835c284"
+	if got := p.StructureHash(); got != want {
+		t.Fatalf("got hash %v, want %v", got, want)
+	}
+}
+
+func TestCoverageFingerprint(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	tests := []struct {
+		prog0 string
+		prog1 string
+		equal bool
+	}{
+		// Plain ints, addresses, blob contents and output values are ignored.
+		{
+			"test$int(0x1, 0x2, 0x3, 0x4, 0x5)",
+			"test$int(0x0, 0xff, 0x0, 0x0, 0xffffffffffffffff)",
+			true,
+		},
+		{
+			`test$blob0(&(0x7f0000000000)="0102")`,
+			`test$blob0(&(0x7f0000001000)="aabb")`,
+			true,
+		},
+		{
+			"test$be_res0(&(0x7f0000000000)={0x0, 0x0})",
+			"test$be_res0(&(0x7f0000000000)={0x0, 0x1234})",
+			true,
+		},
+		// Sizes of blobs.
+		{
+			`test$blob0(&(0x7f0000000000)="0102")`,
+			`test$blob0(&(0x7f0000000000)="010203")`,
+			false,
+		},
+		// Contents of strings.
+		{
+			`test$str0(&(0x7f0000000000)='foo\x00')`,
+			`test$str0(&(0x7f0000000000)='bar\x00')`,
+			false,
+		},
+		// Flags.
+		{
+			"test$exhaustive0(0x1, &(0x7f0000000000)={0x1})",
+			"test$exhaustive0(0x2, &(0x7f0000000000)={0x1})",
+			false,
+		},
+		{
+			"test$exhaustive0(0x1, &(0x7f0000000000)={0x1})",
+			"test$exhaustive0(0x1, &(0x7f0000000000)={0x3})",
+			false,
+		},
+		// Special resource values.
+		{
+			"test$res1(0xffff)",
+			"test$res1(0x1)",
+			false,
+		},
+		// Structure.
+		{
+			"r0 = test$res0()\ntest$res1(r0)",
+			"r0 = test$res0()\ntest$res1(0xffff)",
+			false,
+		},
+		{
+			"test$opt1(&(0x7f0000000000)=0x1)",
+			"test$opt1(0x0)",
+			false,
+		},
+	}
+	for i, test := range tests {
+		p0, err := target.Deserialize([]byte(test.prog0), Strict)
+		if err != nil {
+			t.Fatalf("#%v: failed to deserialize %q: %v", i, test.prog0, err)
+		}
+		p1, err := target.Deserialize([]byte(test.prog1), Strict)
+		if err != nil {
+			t.Fatalf("#%v: failed to deserialize %q: %v", i, test.prog1, err)
+		}
+		h0, h1 := p0.CoverageFingerprint(), p1.CoverageFingerprint()
+		if h0 != p0.Clone().CoverageFingerprint() {
+			t.Fatalf("#%v: fingerprint of the program clone differs", i)
+		}
+		if (h0 == h1) != test.equal {
+			t.Errorf("#%v: got equal=%v, want %v\n%s\n%s", i, h0 == h1, test.equal, test.prog0, test.prog1)
+		}
+	}
+}
+
+func TestCoverageFingerprintStable(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	p, err := target.Deserialize([]byte("r0 = test$res0()\ntest$res1(r0)\n"+
+		"test$exhaustive0(0x3, &(0x7f0000000000)={0x1})\ntest$str0(&(0x7f0000000000)='foo\\x00')\n"), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The fingerprint must not change across runs, otherwise cached results become useless.
+	const want = "ea8b1ff59479f884a0539adcdd0cb8f97141ac02"
+	if got := p.CoverageFingerprint(); got != want {
+		t.Fatalf("got fingerprint %v, want %v", got, want)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"math/rand"
 	"sort"
+	"strings"
 	"sync"
 )
 
@@ -24,6 +25,7 @@ type Target struct {
 	Resources []*ResourceDesc
 	Structs   []*KeyedStruct
 	Consts    []ConstValue
+	Flags     []FlagDesc
 
 	// MakeMmap creates call that maps [addr, addr+size) memory range.
 	MakeMmap func(addr, size uint64) *Call
@@ -55,11 +57,34 @@ type Target struct {
 	resourceMap map[string]*ResourceDesc
 	// Maps resource name to a list of calls that can create the resource.
 	resourceCtors map[string][]*Syscall
-	any           anyTypes
+	// Maps resource name to a list of calls that can invalidate the resource.
+	resourceInvalidators map[string][]ResourceInvalidator
+	// Maps call name to hooks registered with RegisterCallHook.
+	callHooks map[string][]*CallHook
+	hooksMu   sync.RWMutex
+	// Maps flags name to lookup tables of the flags (see FlagValue/FlagNames).
+	// Built lazily on first use, since most users never look up flags.
+	flagsOnce sync.Once
+	flags     map[string]*flagsIndex
+	// Maps flag name to value of the flag across all flags.
+	flagValues map[string]uint64
+	any        anyTypes
 }
 
 const maxSpecialPointers = 16
 
+const (
+	// NumFuncPtrs is the number of function stubs that executor sets up in the page
+	// right after the data area. The stubs return 0, 1 and -1, respectively.
+	NumFuncPtrs   = 3
+	funcPtrStride = 16
+)
+
+// FuncPtr returns address of the i-th function stub (values of funcptr type in descriptions).
+func (target *Target) FuncPtr(i int) uint64 {
+	return target.DataOffset + target.NumPages*target.PageSize + uint64(i)*funcPtrStride
+}
+
 var targets = make(map[string]*Target)
 
 func RegisterTarget(target *Target, initArch func(target *Target)) {
@@ -71,6 +96,13 @@ func RegisterTarget(target *Target, initArch func(target *Target)) {
 	targets[key] = target
 }
 
+// InitUnregisteredTarget initializes target that is not registered with RegisterTarget
+// (e.g. created from freshly compiled descriptions) with the arch-specific initArch.
+func InitUnregisteredTarget(target *Target, initArch func(target *Target)) {
+	target.initArch = initArch
+	target.init.Do(target.lazyInit)
+}
+
 func GetTarget(OS, arch string) (*Target, error) {
 	if OS == "android" {
 		OS = "linux"
@@ -164,8 +196,22 @@ func (target *Target) initTarget() {
 
 	target.resourceCtors = make(map[string][]*Syscall)
 	for _, res := range target.Resources {
-		target.resourceCtors[res.Name] = target.calcResourceCtors(res.Kind, false)
+		ctors := target.calcResourceCtors(res.Kind, false)
+		seen := make(map[*Syscall]bool)
+		for _, meta := range ctors {
+			seen[meta] = true
+		}
+		for _, name := range res.Compatible {
+			for _, meta := range target.calcResourceCtors(target.resourceMap[name].Kind, false) {
+				if !seen[meta] {
+					seen[meta] = true
+					ctors = append(ctors, meta)
+				}
+			}
+		}
+		target.resourceCtors[res.Name] = ctors
 	}
+	target.resourceInvalidators = target.calcResourceInvalidators()
 	initAnyTypes(target)
 }
 
@@ -180,6 +226,94 @@ func (target *Target) GetConst(name string) uint64 {
 	return v
 }
 
+// flagsIndex contains lookup tables for a named set of flags.
+type flagsIndex struct {
+	values map[string]uint64   // flag name -> value
+	names  map[uint64][]string // value -> sorted unique names of flags with the value
+}
+
+func (target *Target) initFlags() {
+	target.flagsOnce.Do(func() {
+		consts := make(map[string]uint64, len(target.Consts))
+		for _, c := range target.Consts {
+			consts[c.Name] = c.Value
+		}
+		target.flags, target.flagValues = buildFlagsIndex(target.Flags, consts)
+	})
+}
+
+// buildFlagsIndex builds lookup tables for each set of flags and the map of flag names
+// to values across all sets. Flags listed several times in a set are deduplicated.
+// Flags that are not present in consts (not supported on the arch) are skipped.
+func buildFlagsIndex(flags []FlagDesc, consts map[string]uint64) (map[string]*flagsIndex, map[string]uint64) {
+	index := make(map[string]*flagsIndex, len(flags))
+	values := make(map[string]uint64)
+	for _, f := range flags {
+		idx := &flagsIndex{
+			values: make(map[string]uint64, len(f.Values)),
+			names:  make(map[uint64][]string),
+		}
+		for _, name := range f.Values {
+			if _, dup := idx.values[name]; dup {
+				continue
+			}
+			val, ok := consts[name]
+			if !ok {
+				continue
+			}
+			idx.values[name] = val
+			idx.names[val] = append(idx.names[val], name)
+			values[name] = val
+		}
+		for _, names := range idx.names {
+			sort.Strings(names)
+		}
+		index[f.Name] = idx
+	}
+	return index, values
+}
+
+// FlagValue returns value of the named flag. The name is either a plain const name
+// (e.g. "O_RDONLY") or a name qualified with the flags set (e.g. "open_flags.O_RDONLY").
+// A plain name is looked up in all flags sets, a qualified name only in the given set.
+func (target *Target) FlagValue(name string) (uint64, bool) {
+	target.initFlags()
+	if pos := strings.IndexByte(name, '.'); pos != -1 {
+		idx := target.flags[name[:pos]]
+		if idx == nil {
+			return 0, false
+		}
+		val, ok := idx.values[name[pos+1:]]
+		return val, ok
+	}
+	val, ok := target.flagValues[name]
+	return val, ok
+}
+
+// FlagNames returns sorted names of flags with value val. Several flags can have the same value,
+// then all of them are returned (each name once).
+// If group is not empty, only the given flags set is considered and plain names are returned.
+// Otherwise all flags sets are considered and names are qualified with the flags set name
+// (e.g. "open_flags.O_RDONLY"), since different sets can use different names for the same value.
+func (target *Target) FlagNames(group string, val uint64) []string {
+	target.initFlags()
+	if group != "" {
+		idx := target.flags[group]
+		if idx == nil {
+			return nil
+		}
+		return append([]string(nil), idx.names[val]...)
+	}
+	var res []string
+	for flagsName, idx := range target.flags {
+		for _, name := range idx.names[val] {
+			res = append(res, flagsName+"."+name)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
 type Gen struct {
 	r *randGen
 	s *state
@@ -226,7 +360,7 @@ func (g *Gen) MutateArg(arg0 Arg) (calls []*Call) {
 			// and updateSizes to caller so that Mutate can act accordingly.
 			return
 		}
-		idx := g.r.Intn(len(ma.args))
+		idx := ma.chooseArg(g.r)
 		arg, ctx := ma.args[idx], ma.ctxes[idx]
 		newCalls, ok := g.r.target.mutateArg(g.r, g.s, arg, ctx, &updateSizes)
 		if !ok {
@@ -5,6 +5,8 @@ package prog
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 )
 
 type Syscall struct {
@@ -15,6 +17,27 @@ type Syscall struct {
 	MissingArgs int // number of trailing args that should be zero-filled
 	Args        []Type
 	Ret         Type
+	Retries     int    // number of times executor re-executes the call if it fails
+	Group       string // atomic group, adjacent calls of the same group are executed back-to-back
+	Compat      bool   // arguments use 32-bit compat layout (pointers and longs are 4 bytes)
+	NoCover     bool   // coverage of the call is not used as fuzzing signal
+	// Names of calls that must precede the call in programs (they are generated before it
+	// if not present yet and are not removed while the call depends on them).
+	Requires []string
+	// Names of calls whose asynchronous results the call delivers (e.g. reaping of a completion
+	// queue entry of an operation submitted by another call), see completions.go.
+	Completes []string
+	// Names of scopes (see ResourceDesc.Scope) that the call begins and ends, if any.
+	ScopeBegin string
+	ScopeEnd   string
+	// Name of the namespace (see ResourceDesc.Namespace) that the call switches, if any.
+	SetNamespace string
+	// Number of trailing args that can be omitted from calls (see Call.Args).
+	OmittableArgs int
+	// NonblockFlag is set for calls that create event resources (see ResourceDesc.Event)
+	// if setting the flag in argument NonblockArg makes the created resource non-blocking.
+	NonblockArg  int
+	NonblockFlag uint64
 }
 
 type Dir int
@@ -60,6 +83,7 @@ type Type interface {
 	BitfieldOffset() uint64
 	BitfieldLength() uint64
 	BitfieldMiddle() bool // returns true for all but last bitfield in a group
+	MutationWeight() uint64
 
 	DefaultArg() Arg
 	isDefaultArg(arg Arg) bool
@@ -82,8 +106,13 @@ type TypeCommon struct {
 	ArgDir     Dir
 	IsOptional bool
 	IsVarlen   bool
+	// Relative probability of choosing this arg for mutation (see MutationWeight).
+	MutateWeight uint64
 }
 
+// MutateWeightNone is MutateWeight value for args that must never be mutated.
+const MutateWeightNone = ^uint64(0)
+
 func (t *TypeCommon) Name() string {
 	return t.TypeName
 }
@@ -107,6 +136,19 @@ func (t *TypeCommon) Varlen() bool {
 	return t.IsVarlen
 }
 
+// MutationWeight returns relative probability of choosing the arg for mutation
+// among other args of the same call: 1 by default, 0 if the arg must never be mutated.
+func (t *TypeCommon) MutationWeight() uint64 {
+	switch t.MutateWeight {
+	case 0:
+		return 1
+	case MutateWeightNone:
+		return 0
+	default:
+		return t.MutateWeight
+	}
+}
+
 func (t *TypeCommon) Format() BinaryFormat {
 	return FormatNative
 }
@@ -132,13 +174,80 @@ type ResourceDesc struct {
 	Type   Type
 	Kind   []string
 	Values []uint64
+	// Names of ABI-identical resources that can be used interchangeably with this one.
+	Compatible []string
+	// Index-like resources can have a range of valid values (declared as range of the base type),
+	// any value in [RangeBegin, RangeEnd] can be used when the resource can't be created.
+	HasRange   bool
+	RangeBegin uint64
+	RangeEnd   uint64
+	// Refcounted resources can be acquired several times (see ResourceAcquire),
+	// and stay valid until they are released the same number of times.
+	Refcounted bool
+	// Event resources (e.g. eventfd) are signaled by some calls and waited on by other calls
+	// (see ResourceEvent). Waiting on a resource that was not signaled blocks.
+	Event bool
+	// Scoped resources are valid only within a scope between a call that begins the scope
+	// and a call that ends it (e.g. handles of objects of a transaction), see scopes.go.
+	Scope string
+	// Namespaced resources are valid only in the namespace context they were created in,
+	// calls that switch the namespace (see Syscall.SetNamespace) invalidate them, see namespaces.go.
+	Namespace string
 }
 
 type ResourceType struct {
 	TypeCommon
 	ArgFormat BinaryFormat
 	Desc      *ResourceDesc
-}
+	Effect    ResourceEffect // set only for syscall arguments
+	// ValidMask is a bit that is set in the value iff a resource created by a previous call
+	// is passed (as opposed to a special value), some ABIs mark valid handles this way.
+	ValidMask uint64
+	// Derives is set for a syscall argument if the resource returned by the syscall
+	// is the value of the argument plus DeriveOffset (e.g. a remapped handle).
+	Derives      bool
+	DeriveOffset uint64
+	Event        ResourceEvent // set only for syscall arguments
+	// InvalidPercent is set if the resource is looked up by value (by_value attribute in descriptions):
+	// it is percentage of generated values that are plausible but invalid (see invalidResource).
+	InvalidPercent uint64
+}
+
+// ResourceEffect describes effect of a syscall on lifetime of a resource passed as an argument.
+// The effect is assumed to take place regardless of the call result: the call can fail and leave
+// the resource intact, but programs still don't use the resource after the call (using it would
+// fail with EBADF or similar most of the time, and it's not known during generation if the call
+// will succeed).
+type ResourceEffect int
+
+const (
+	// The resource is used by the call and stays valid.
+	ResourceUse ResourceEffect = iota
+	// The call consumes and invalidates the resource (e.g. close).
+	ResourceInvalidate
+	// The call consumes the resource and returns a new resource that replaces it,
+	// the returned resource is tracked separately and the passed resource is invalidated.
+	ResourceTransform
+	// The call takes an additional reference to a refcounted resource (e.g. get).
+	ResourceAcquire
+	// The call drops a reference to a refcounted resource (e.g. put),
+	// the resource is invalidated when the last reference is dropped.
+	ResourceRelease
+)
+
+// ResourceEvent describes role of a syscall argument in semantics of an event resource.
+// Programs are kept consistent so that a wait on a resource follows a signal of the resource,
+// a wait without a preceding signal makes the resource non-blocking (see Syscall.NonblockFlag).
+// Each wait consumes one signal (e.g. read of an eventfd resets its counter).
+type ResourceEvent int
+
+const (
+	EventNone ResourceEvent = iota
+	// The call signals the resource (e.g. write to an eventfd).
+	EventSignal
+	// The call waits for the resource to be signaled (e.g. read from an eventfd).
+	EventWait
+)
 
 func (t *ResourceType) String() string {
 	return t.Name()
@@ -155,7 +264,20 @@ func (t *ResourceType) isDefaultArg(arg Arg) bool {
 }
 
 func (t *ResourceType) Default() uint64 {
-	return t.Desc.Values[0]
+	return t.Desc.Values[0] &^ t.ValidMask
+}
+
+// setValidBit makes ValidMask bit of arg consistent with whether arg refers to a created resource:
+// for created resources the bit is added to the result at runtime, special values get the bit cleared.
+func (t *ResourceType) setValidBit(arg *ResultArg) {
+	if t.ValidMask == 0 {
+		return
+	}
+	if arg.Res != nil {
+		arg.OpAdd = t.ValidMask
+	} else {
+		arg.Val &^= t.ValidMask
+	}
 }
 
 func (t *ResourceType) SpecialValues() []uint64 {
@@ -172,6 +294,10 @@ type IntTypeCommon struct {
 	BitfieldOff uint64
 	BitfieldLen uint64
 	BitfieldMdl bool
+	// RequestMaskField is the name of a sibling field with a struct (or a pointer to it)
+	// that has fields requested by the value (request_mask attribute in descriptions),
+	// the value is a mask of bits of StructDesc.RequestedBy.
+	RequestMaskField string
 }
 
 func (t *IntTypeCommon) String() string {
@@ -196,8 +322,11 @@ func (t *IntTypeCommon) BitfieldMiddle() bool {
 
 type ConstType struct {
 	IntTypeCommon
-	Val   uint64
-	IsPad bool
+	Val      uint64
+	IsPad    bool
+	IsFooter bool // the last field of a struct placed right after the previous field
+	// Mutation can replace the value with a random one and back (see ChoiceTable.SetConstPromotion).
+	Promotable bool
 }
 
 func (t *ConstType) DefaultArg() Arg {
@@ -212,6 +341,9 @@ func (t *ConstType) String() string {
 	if t.IsPad {
 		return fmt.Sprintf("pad[%v]", t.Size())
 	}
+	if t.IsFooter {
+		return fmt.Sprintf("footer[%v, %v]", t.Val, t.IntTypeCommon.String())
+	}
 	return fmt.Sprintf("const[%v, %v]", t.Val, t.IntTypeCommon.String())
 }
 
@@ -221,14 +353,67 @@ const (
 	IntPlain   IntKind = iota
 	IntFileoff         // offset within a file
 	IntRange
+	IntRingHead   // consumer index into ring array
+	IntRingTail   // producer index into ring array
+	IntFuncPtr    // address of a function stub set up by executor, see Target.FuncPtr
+	IntRelPtr     // offset of a sibling field from the struct base or from this field
+	IntFlagIndex  // index of a single bit of a flags group
+	IntChildPid   // pid of a child process spawned by executor, the value is ignored
+	IntPow2       // power of 2 within [RangeBegin, RangeEnd]
+	IntArrayIndex // index of the array element containing the struct with this field
+	IntParity     // parity bit of [ParityBegin, ParityEnd] bits of the backing integer
 )
 
 type IntType struct {
 	IntTypeCommon
-	Kind       IntKind
-	RangeBegin uint64
-	RangeEnd   uint64
-}
+	Kind        IntKind
+	RangeBegin  uint64
+	RangeEnd    uint64
+	Ring        string      // name of the ring array field for IntRingHead/IntRingTail
+	RelPtr      string      // name of the pointee field for IntRelPtr
+	RelSelf     bool        // IntRelPtr offset is relative to the field itself rather than to the struct base
+	Flags       string      // name of the flags group for IntFlagIndex
+	FlagIndices []uint64    // valid values of IntFlagIndex: indices of bits of the flags group values
+	Buckets     []IntBucket // for IntPlain, if set values are sampled from the buckets
+	// Value of a byte order mark field determines format of native ints in the following
+	// fields of the struct: BigEndianMark means big-endian, any other value means native.
+	ByteOrderMark bool
+	BigEndianMark uint64
+	// For IntParity: the bit is set so that the number of set bits in [ParityBegin, ParityEnd]
+	// bits of the backing integer plus the parity bit is even (or odd if ParityOdd).
+	// The value is computed during serialization for execution.
+	ParityOdd   bool
+	ParityBegin uint64
+	ParityEnd   uint64
+	// Cookie is set for request fields that are echoed back in completions of asynchronous
+	// operations (cookie attribute in descriptions), it is the completion struct field
+	// in the STRUCT.FIELD form. Generated values are distinct in all requests of a program.
+	Cookie string
+	// Relation of the value to the value of sibling int field RelationField
+	// (lt, le, eq and ne attributes in descriptions), values are compared as unsigned.
+	// Generation and mutation preserve the relation.
+	Relation      IntRelation
+	RelationField string
+}
+
+// IntBucket is a range of values [Begin, End] that is chosen with probability
+// proportional to Weight among all buckets of the type.
+type IntBucket struct {
+	Begin  uint64
+	End    uint64
+	Weight uint64
+}
+
+// IntRelation is a relation between values of an int field and a sibling int field.
+type IntRelation int
+
+const (
+	IntRelNone      IntRelation = iota
+	IntRelLess                  // <
+	IntRelLessEqual             // <=
+	IntRelEqual                 // ==
+	IntRelNotEqual              // !=
+)
 
 func (t *IntType) DefaultArg() Arg {
 	return MakeConstArg(t, 0)
@@ -242,6 +427,20 @@ type FlagsType struct {
 	IntTypeCommon
 	Vals    []uint64
 	BitMask bool
+	// SubkindField is the name of a sibling resource field, if the resource is of one
+	// of the Subkinds, the field is restricted to the values of the subkind
+	// (subkind attribute in descriptions).
+	SubkindField string
+	Subkinds     []SubkindVals
+	// Exhaustive flags are generated in round-robin order of Vals
+	// to cover all values evenly (exhaustive attribute in descriptions).
+	Exhaustive bool
+}
+
+// SubkindVals are values of a flags field that are valid for the resource subkind Kind.
+type SubkindVals struct {
+	Kind string
+	Vals []uint64
 }
 
 func (t *FlagsType) DefaultArg() Arg {
@@ -254,8 +453,68 @@ func (t *FlagsType) isDefaultArg(arg Arg) bool {
 
 type LenType struct {
 	IntTypeCommon
-	BitSize uint64 // want size in multiple of bits instead of array size
-	Buf     string
+	BitSize   uint64 // want size in multiple of bits instead of array size
+	Buf       string
+	Expr      *LenExpr // if set, the value is computed from several len targets (Buf is empty)
+	Dim       uint64   // for nested arrays: length of the inner arrays of this dimension (0 is the outer array)
+	Inclusive bool     // size additionally includes size of the len field itself
+}
+
+// LenExprOp is operation of a LenExpr node.
+type LenExprOp int
+
+const (
+	LenExprTarget LenExprOp = iota // length of the len target Buf
+	LenExprConst                   // constant Val
+	LenExprMax                     // maximum of Args
+	LenExprMin                     // minimum of Args
+)
+
+// LenExpr is an expression over lengths of len targets, e.g. max[a, b].
+// Length of every target is computed the same way as for LenType with Buf set to the target.
+type LenExpr struct {
+	Op   LenExprOp
+	Buf  string     // for LenExprTarget
+	Val  uint64     // for LenExprConst
+	Args []*LenExpr // for LenExprMax and LenExprMin
+}
+
+func (e *LenExpr) String() string {
+	switch e.Op {
+	case LenExprTarget:
+		return e.Buf
+	case LenExprConst:
+		return fmt.Sprint(e.Val)
+	default:
+		var args []string
+		for _, arg := range e.Args {
+			args = append(args, arg.String())
+		}
+		name := "max"
+		if e.Op == LenExprMin {
+			name = "min"
+		}
+		return fmt.Sprintf("%v[%v]", name, strings.Join(args, ", "))
+	}
+}
+
+// Targets returns names of all len targets referenced by the len type.
+func (t *LenType) Targets() []string {
+	if t.Expr == nil {
+		return []string{t.Buf}
+	}
+	var targets []string
+	var walk func(e *LenExpr)
+	walk = func(e *LenExpr) {
+		if e.Op == LenExprTarget {
+			targets = append(targets, e.Buf)
+		}
+		for _, arg := range e.Args {
+			walk(arg)
+		}
+	}
+	walk(t.Expr)
+	return targets
 }
 
 func (t *LenType) DefaultArg() Arg {
@@ -290,6 +549,10 @@ type CsumKind int
 const (
 	CsumInet CsumKind = iota
 	CsumPseudo
+	CsumCrc32
+	CsumXor
+	CsumFnv1a
+	CsumAdd
 )
 
 type CsumType struct {
@@ -300,6 +563,9 @@ type CsumType struct {
 }
 
 func (t *CsumType) String() string {
+	if t.Kind == CsumFnv1a || t.Kind == CsumAdd {
+		return "hash"
+	}
 	return "csum"
 }
 
@@ -315,6 +581,10 @@ type VmaType struct {
 	TypeCommon
 	RangeBegin uint64 // in pages
 	RangeEnd   uint64
+	// Shared is the name of the shared region set up by the call at the address of this vma
+	// (shared attribute in descriptions), pointers with the same Shared name passed to
+	// subsequent calls point to the region (see placeSharedRegions).
+	Shared string
 }
 
 func (t *VmaType) String() string {
@@ -338,6 +608,7 @@ const (
 	BufferString
 	BufferFilename
 	BufferText
+	BufferSerialized
 )
 
 type TextKind int
@@ -360,6 +631,10 @@ type BufferType struct {
 	SubKind    string
 	Values     []string // possible values for BufferString kind
 	NoZ        bool     // non-zero terminated BufferString/BufferFilename
+	Elem       Type     // type of serialized values for BufferSerialized
+	// FormatArgs is the name of the array of arguments for format strings (format_args attribute
+	// in descriptions), the string consists of specifiers of the chosen options of the array elements.
+	FormatArgs string
 }
 
 func (t *BufferType) String() string {
@@ -413,6 +688,12 @@ type ArrayType struct {
 	Kind       ArrayKind
 	RangeBegin uint64
 	RangeEnd   uint64
+	// CountField is the name of a sibling resource field that holds the number
+	// of elements at runtime (count attribute in descriptions).
+	CountField string
+	// Sparse arrays consist of structs with an integer index as the first field
+	// (sparse type in descriptions), the indices are unique and in ascending order.
+	Sparse bool
 }
 
 func (t *ArrayType) String() string {
@@ -445,6 +726,20 @@ func (t *ArrayType) isDefaultArg(arg Arg) bool {
 type PtrType struct {
 	TypeCommon
 	Type Type
+	// OverlapField is the name of a sibling pointer field, this pointer points
+	// to the pointee of that field plus OverlapOffset bytes (overlap attribute in descriptions).
+	OverlapField  string
+	OverlapOffset uint64
+	// Guard means that the pointee is allocated at the end of a page followed by an inaccessible
+	// guard page, and the slack between the pointee and the guard page is filled with poison
+	// (guard attribute in descriptions).
+	Guard bool
+	// Depth is the number of levels of the pointer chain starting at this pointer
+	// (ptr[dir, ptr[dir, ...]]) that are always allocated during generation
+	// (depth attribute in descriptions).
+	Depth int
+	// Shared is the name of the shared region (see VmaType.Shared) that the pointee is placed in.
+	Shared string
 }
 
 func (t *PtrType) String() string {
@@ -525,6 +820,26 @@ type StructDesc struct {
 	TypeCommon
 	Fields    []Type
 	AlignAttr uint64
+	// Relative probabilities of choosing union options (unions only),
+	// nil if all options are equally likely.
+	OptionWeights []uint64
+	// For versioned unions: name of the version field of the option structs
+	// and versions of the options, the field of the chosen option is set to its version.
+	VersionField string
+	Versions     []uint64
+	// Names of integer fields (structs only) that have distinct values in all elements
+	// of arrays of the struct (unique attribute in descriptions).
+	UniqueFields []string
+	// Names of fields (structs only) that are filled only if a request mask contains
+	// any of the corresponding RequestedBy bits (requested_by attribute in descriptions).
+	RequestedFields []string
+	RequestedBy     []uint64
+	// Format specifiers of union options (unions only) that are arguments of format strings
+	// (specifier attribute in descriptions), see BufferType.FormatArgs.
+	Specifiers []string
+	// Options of overlay unions are alternative bitfield views of the same backing integer
+	// (overlay attribute in descriptions).
+	Overlay bool
 }
 
 func (t *StructDesc) FieldName() string {
@@ -546,6 +861,15 @@ type ConstValue struct {
 	Value uint64
 }
 
+// FlagDesc describes a named set of flags: Values are names of consts
+// that constitute the set (values given as integer literals are not included).
+// FlagsType contains only flag values, so this is the only place where flag names
+// are preserved. Values of the names are taken from Target.Consts.
+type FlagDesc struct {
+	Name   string
+	Values []string
+}
+
 func ForeachType(meta *Syscall, f func(Type)) {
 	seen := make(map[*StructDesc]bool)
 	var rec func(t Type)
@@ -572,7 +896,11 @@ func ForeachType(meta *Syscall, f func(Type)) {
 			for _, opt := range a.Fields {
 				rec(opt)
 			}
-		case *ResourceType, *BufferType, *VmaType, *LenType,
+		case *BufferType:
+			if a.Elem != nil {
+				rec(a.Elem)
+			}
+		case *ResourceType, *VmaType, *LenType,
 			*FlagsType, *ConstType, *IntType, *ProcType, *CsumType:
 		default:
 			panic("unknown type")
@@ -585,3 +913,116 @@ func ForeachType(meta *Syscall, f func(Type)) {
 		rec(meta.Ret)
 	}
 }
+
+// CallStructs returns keys of all structs and unions reachable from arguments and return value
+// of each call (directly or via pointers, arrays and other structs/unions), sorted by name and dir.
+// Variants of the same struct with different directions are different keys.
+// This can be used to find structs that are used only by few (or rarely generated) calls.
+func (target *Target) CallStructs() map[*Syscall][]StructKey {
+	res := make(map[*Syscall][]StructKey)
+	for _, c := range target.Syscalls {
+		seen := make(map[StructKey]bool)
+		var keys []StructKey
+		ForeachType(c, func(t Type) {
+			var key StructKey
+			switch a := t.(type) {
+			case *StructType:
+				key = a.Key
+			case *UnionType:
+				key = a.Key
+			default:
+				return
+			}
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		})
+		sort.Slice(keys, func(i, j int) bool {
+			if keys[i].Name != keys[j].Name {
+				return keys[i].Name < keys[j].Name
+			}
+			return keys[i].Dir < keys[j].Dir
+		})
+		res[c] = keys
+	}
+	return res
+}
+
+// StructCalls is the reverse of CallStructs: it returns all calls that reference each struct
+// or union (directly or via pointers, arrays and other structs/unions) in the order of
+// target.Syscalls. This can be used to assess impact of changes to a struct.
+func (target *Target) StructCalls() map[StructKey][]*Syscall {
+	res := make(map[StructKey][]*Syscall)
+	callStructs := target.CallStructs()
+	for _, c := range target.Syscalls {
+		for _, key := range callStructs[c] {
+			res[key] = append(res[key], c)
+		}
+	}
+	return res
+}
+
+// BitfieldGroup is a group of adjacent struct fields packed into the same backing integer.
+type BitfieldGroup struct {
+	Size    uint64 // size of the backing integer in bytes
+	Members []Bitfield
+}
+
+type Bitfield struct {
+	Name   string
+	Offset uint64 // offset in bits in the backing integer (BitfieldOffset)
+	Length uint64 // width in bits (BitfieldLength)
+	Last   bool   // the last member of the group (not BitfieldMiddle)
+}
+
+// Bitfields returns bitfield groups of the struct in field order.
+func (t *StructType) Bitfields() []BitfieldGroup {
+	var groups []BitfieldGroup
+	var cur *BitfieldGroup
+	for _, f := range t.Fields {
+		if f.BitfieldLength() == 0 {
+			continue
+		}
+		if cur == nil {
+			groups = append(groups, BitfieldGroup{Size: f.Size()})
+			cur = &groups[len(groups)-1]
+		}
+		cur.Members = append(cur.Members, Bitfield{
+			Name:   f.FieldName(),
+			Offset: f.BitfieldOffset(),
+			Length: f.BitfieldLength(),
+			Last:   !f.BitfieldMiddle(),
+		})
+		if !f.BitfieldMiddle() {
+			cur = nil
+		}
+	}
+	return groups
+}
+
+// StructBitfields returns bitfield groups of all structs reachable from calls
+// that have bitfields, keyed by struct name.
+// The layout does not depend on direction, so variants of a struct with different
+// directions are reported once.
+func (target *Target) StructBitfields() map[string][]BitfieldGroup {
+	res := make(map[string][]BitfieldGroup)
+	for _, c := range target.Syscalls {
+		ForeachType(c, func(t Type) {
+			s, ok := t.(*StructType)
+			if !ok {
+				return
+			}
+			if _, ok := res[s.Key.Name]; ok {
+				return
+			}
+			res[s.Key.Name] = s.Bitfields()
+		})
+	}
+	for name, groups := range res {
+		if len(groups) == 0 {
+			delete(res, name)
+		}
+	}
+	return res
+}
@@ -46,7 +46,7 @@ func (p *Prog) validate() error {
 }
 
 func (ctx *validCtx) validateCall(c *Call) error {
-	if len(c.Args) != len(c.Meta.Args) {
+	if len(c.Args) > len(c.Meta.Args) || len(c.Args) < len(c.Meta.Args)-c.Meta.OmittableArgs {
 		return fmt.Errorf("wrong number of arguments, want %v, got %v",
 			len(c.Meta.Args), len(c.Args))
 	}
@@ -7,7 +7,7 @@ import . "github.com/google/syzkaller/prog"
 import . "github.com/google/syzkaller/sys/akaros"
 
 func init() {
-	RegisterTarget(&Target{OS: "akaros", Arch: "amd64", Revision: revision_amd64, PtrSize: 8, PageSize: 4096, NumPages: 4096, DataOffset: 536870912, Syscalls: syscalls_amd64, Resources: resources_amd64, Structs: structDescs_amd64, Consts: consts_amd64}, InitTarget)
+	RegisterTarget(&Target{OS: "akaros", Arch: "amd64", Revision: revision_amd64, PtrSize: 8, PageSize: 4096, NumPages: 4096, DataOffset: 536870912, Syscalls: syscalls_amd64, Resources: resources_amd64, Structs: structDescs_amd64, Consts: consts_amd64, Flags: flags_amd64}, InitTarget)
 }
 
 var resources_amd64 = []*ResourceDesc{
@@ -1529,4 +1529,21 @@ var consts_amd64 = []ConstValue{
 	{Name: "WUNTRACED", Value: 2},
 }
 
-const revision_amd64 = "1b67f82f23d2ba92433b4f6bc3458ce1478d960c"
+var flags_amd64 = []FlagDesc{
+	{Name: "bind_flags", Values: []string{"MREPL", "MBEFORE", "MAFTER", "MCREATE", "MCACHE"}},
+	{Name: "event_type", Values: []string{"EV_NONE", "EV_PREEMPT_PENDING", "EV_GANG_PREMPT_PENDING", "EV_VCORE_PREEMPT", "EV_GANG_RETURN", "EV_USER_IPI", "EV_PAGE_FAULT", "EV_ALARM", "EV_EVENT", "EV_FREE_APPLE_PIE", "EV_SYSCALL", "EV_CHECK_MSGS", "EV_POSIX_SIGNAL", "NR_EVENT_TYPES", "MAX_NR_EVENT"}},
+	{Name: "fcntl_flags", Values: []string{"FD_CLOEXEC"}},
+	{Name: "fcntl_status", Values: []string{"O_APPEND", "O_NONBLOCK", "O_CLOEXEC", "O_REMCLO", "O_PATH"}},
+	{Name: "fdtap_commands", Values: []string{"FDTAP_CMD_ADD", "FDTAP_CMD_REM", "FDTAP_CMD_MOD"}},
+	{Name: "fdtap_filters", Values: []string{"FDTAP_FILT_READABLE", "FDTAP_FILT_WRITABLE", "FDTAP_FILT_WRITTEN", "FDTAP_FILT_DELETED", "FDTAP_FILT_ERROR", "FDTAP_FILT_RENAME", "FDTAP_FILT_TRUNCATE", "FDTAP_FILT_ATTRIB", "FDTAP_FILT_PRIORITY", "FDTAP_FILT_HANGUP", "FDTAP_FILT_RDHUP"}},
+	{Name: "mmap_flags", Values: []string{"MAP_SHARED", "MAP_PRIVATE", "MAP_ANONYMOUS", "MAP_DENYWRITE", "MAP_EXECUTABLE", "MAP_FIXED", "MAP_GROWSDOWN", "MAP_LOCKED", "MAP_NONBLOCK", "MAP_NORESERVE", "MAP_POPULATE", "MAP_STACK"}},
+	{Name: "mmap_prot", Values: []string{"PROT_EXEC", "PROT_READ", "PROT_WRITE", "PROT_GROWSDOWN", "PROT_GROWSUP"}},
+	{Name: "open_flags", Values: []string{"O_RDONLY", "O_WRONLY", "O_RDWR", "O_APPEND", "O_CLOEXEC", "O_CREAT", "O_DIRECTORY", "O_EXCL", "O_NOCTTY", "O_NOFOLLOW", "O_NONBLOCK", "O_SYNC", "O_TRUNC", "O_REMCLO", "O_PATH"}},
+	{Name: "open_mode", Values: []string{"S_IRUSR", "S_IWUSR", "S_IXUSR", "S_IRGRP", "S_IWGRP", "S_IXGRP", "S_IROTH", "S_IWOTH", "S_IXOTH"}},
+	{Name: "seek_whence", Values: []string{"SEEK_SET", "SEEK_CUR", "SEEK_END"}},
+	{Name: "vmm_exits", Values: []string{"VMM_CTL_FL_KERN_PRINTC"}},
+	{Name: "vmm_flags", Values: []string{"VMM_CTL_EXIT_HALT", "VMM_CTL_EXIT_PAUSE", "VMM_CTL_EXIT_MWAIT"}},
+	{Name: "wait_options", Values: []string{"WNOHANG", "WUNTRACED"}},
+}
+
+const revision_amd64 = "36d91780ba2457e80f03ed044e607a8bbd3f6afa"
@@ -7,7 +7,7 @@ import . "github.com/google/syzkaller/prog"
 import . "github.com/google/syzkaller/sys/freebsd"
 
 func init() {
-	RegisterTarget(&Target{OS: "freebsd", Arch: "amd64", Revision: revision_amd64, PtrSize: 8, PageSize: 4096, NumPages: 4096, DataOffset: 536870912, Syscalls: syscalls_amd64, Resources: resources_amd64, Structs: structDescs_amd64, Consts: consts_amd64}, InitTarget)
+	RegisterTarget(&Target{OS: "freebsd", Arch: "amd64", Revision: revision_amd64, PtrSize: 8, PageSize: 4096, NumPages: 4096, DataOffset: 536870912, Syscalls: syscalls_amd64, Resources: resources_amd64, Structs: structDescs_amd64, Consts: consts_amd64, Flags: flags_amd64}, InitTarget)
 }
 
 var resources_amd64 = []*ResourceDesc{
@@ -4208,4 +4208,73 @@ var consts_amd64 = []ConstValue{
 	{Name: "WUNTRACED", Value: 2},
 }
 
-const revision_amd64 = "873616249e74ae100e93afb6a14e4fef92b3acc4"
+var flags_amd64 = []FlagDesc{
+	{Name: "accept_flags", Values: []string{"SOCK_NONBLOCK", "SOCK_CLOEXEC"}},
+	{Name: "at_flags", Values: []string{"AT_SYMLINK_NOFOLLOW", "AT_SYMLINK_FOLLOW"}},
+	{Name: "clock_id", Values: []string{"CLOCK_REALTIME", "CLOCK_MONOTONIC", "CLOCK_PROCESS_CPUTIME_ID", "CLOCK_THREAD_CPUTIME_ID"}},
+	{Name: "cmsg_levels", Values: []string{"SOL_SOCKET", "IPPROTO_IP", "IPPROTO_IPV6", "IPPROTO_ICMP", "IPPROTO_ICMPV6", "IPPROTO_SCTP", "IPPROTO_TCP", "IPPROTO_UDP", "IPPROTO_UDPLITE"}},
+	{Name: "fcntl_dupfd", Values: []string{"F_DUPFD", "F_DUPFD_CLOEXEC"}},
+	{Name: "fcntl_flags", Values: []string{"FD_CLOEXEC"}},
+	{Name: "fcntl_getflags", Values: []string{"F_GETFD", "F_GETFL"}},
+	{Name: "fcntl_lock", Values: []string{"F_SETLK", "F_SETLKW", "F_GETLK"}},
+	{Name: "fcntl_status", Values: []string{"O_APPEND", "FASYNC", "O_DIRECT", "O_NONBLOCK"}},
+	{Name: "flock_op", Values: []string{"LOCK_SH", "LOCK_EX", "LOCK_UN", "LOCK_NB"}},
+	{Name: "flock_type", Values: []string{"F_RDLCK", "F_WRLCK", "F_UNLCK"}},
+	{Name: "getitimer_which", Values: []string{"ITIMER_REAL", "ITIMER_VIRTUAL", "ITIMER_PROF"}},
+	{Name: "inet6_option_types_buf", Values: []string{"IPV6_2292PKTOPTIONS", "IPV6_IPSEC_POLICY", "MCAST_JOIN_GROUP", "MCAST_BLOCK_SOURCE", "MCAST_UNBLOCK_SOURCE", "MCAST_LEAVE_GROUP", "MCAST_JOIN_SOURCE_GROUP", "MCAST_LEAVE_SOURCE_GROUP", "IPV6_PKTINFO", "IPV6_HOPOPTS", "IPV6_RTHDRDSTOPTS", "IPV6_RTHDR", "IPV6_DSTOPTS", "IPV6_PATHMTU", "MRT6_ADD_MIF", "MRT6_ADD_MFC", "MRT6_DEL_MFC"}},
+	{Name: "inet6_option_types_int", Values: []string{"IPV6_2292PKTINFO", "IPV6_2292HOPOPTS", "IPV6_2292DSTOPTS", "IPV6_2292RTHDR", "IPV6_CHECKSUM", "IPV6_2292HOPLIMIT", "IPV6_NEXTHOP", "IPV6_UNICAST_HOPS", "IPV6_MULTICAST_IF", "IPV6_MULTICAST_HOPS", "IPV6_MULTICAST_LOOP", "IPV6_V6ONLY", "IPV6_RECVPKTINFO", "IPV6_RECVHOPLIMIT", "IPV6_HOPLIMIT", "IPV6_RECVHOPOPTS", "IPV6_RECVRTHDR", "IPV6_RECVDSTOPTS", "IPV6_RECVPATHMTU", "IPV6_DONTFRAG", "IPV6_RECVTCLASS", "IPV6_TCLASS", "IPV6_AUTOFLOWLABEL", "IPV6_RECVORIGDSTADDR"}},
+	{Name: "inet_option_types_buf", Values: []string{"IP_OPTIONS", "IP_IPSEC_POLICY", "IP_MULTICAST_IF", "IP_ADD_MEMBERSHIP", "IP_DROP_MEMBERSHIP", "IP_UNBLOCK_SOURCE", "IP_BLOCK_SOURCE", "IP_ADD_SOURCE_MEMBERSHIP", "IP_DROP_SOURCE_MEMBERSHIP", "IP_MSFILTER", "MCAST_JOIN_GROUP", "MCAST_BLOCK_SOURCE", "MCAST_UNBLOCK_SOURCE", "MCAST_LEAVE_GROUP", "MCAST_JOIN_SOURCE_GROUP", "MCAST_LEAVE_SOURCE_GROUP"}},
+	{Name: "inet_option_types_int", Values: []string{"IP_TOS", "IP_TTL", "IP_HDRINCL", "IP_RECVOPTS", "IP_RETOPTS", "IP_RECVTTL", "IP_RECVTOS", "IP_RECVORIGDSTADDR", "IP_MINTTL"}},
+	{Name: "ip_msfilter_mode", Values: []string{"MCAST_INCLUDE", "MCAST_EXCLUDE"}},
+	{Name: "linkat_flags", Values: []string{"AT_SYMLINK_FOLLOW"}},
+	{Name: "madvise_flags", Values: []string{"MADV_NORMAL", "MADV_RANDOM", "MADV_SEQUENTIAL", "MADV_WILLNEED", "MADV_DONTNEED"}},
+	{Name: "mif6c_flags", Values: []string{"MIFF_REGISTER"}},
+	{Name: "mknod_mode", Values: []string{"S_IFREG", "S_IFCHR", "S_IFBLK", "S_IFIFO", "S_IFSOCK", "S_IRUSR", "S_IWUSR", "S_IXUSR", "S_IRGRP", "S_IWGRP", "S_IXGRP", "S_IROTH", "S_IWOTH", "S_IXOTH"}},
+	{Name: "mlockall_flags", Values: []string{"MCL_CURRENT", "MCL_FUTURE"}},
+	{Name: "mmap_flags", Values: []string{"MAP_SHARED", "MAP_PRIVATE", "MAP_32BIT", "MAP_ANONYMOUS", "MAP_FILE", "MAP_FIXED", "MAP_STACK"}},
+	{Name: "mmap_prot", Values: []string{"PROT_EXEC", "PROT_READ", "PROT_WRITE"}},
+	{Name: "msgget_flags", Values: []string{"IPC_CREAT", "IPC_EXCL", "S_IRUSR", "S_IWUSR", "S_IXUSR", "S_IRGRP", "S_IWGRP", "S_IXGRP", "S_IROTH", "S_IWOTH", "S_IXOTH"}},
+	{Name: "msgrcv_flags", Values: []string{"IPC_NOWAIT", "MSG_NOERROR"}},
+	{Name: "msgsnd_flags", Values: []string{"IPC_NOWAIT"}},
+	{Name: "msync_flags", Values: []string{"MS_ASYNC", "MS_SYNC", "MS_INVALIDATE"}},
+	{Name: "open_flags", Values: []string{"O_RDONLY", "O_WRONLY", "O_RDWR", "O_APPEND", "FASYNC", "O_CLOEXEC", "O_CREAT", "O_DIRECT", "O_DIRECTORY", "O_EXCL", "O_NOCTTY", "O_NOFOLLOW", "O_NONBLOCK", "O_SYNC", "O_TRUNC"}},
+	{Name: "open_mode", Values: []string{"S_IRUSR", "S_IWUSR", "S_IXUSR", "S_IRGRP", "S_IWGRP", "S_IXGRP", "S_IROTH", "S_IWOTH", "S_IXOTH"}},
+	{Name: "pipe_flags", Values: []string{"O_NONBLOCK", "O_CLOEXEC"}},
+	{Name: "pollfd_events", Values: []string{"POLLIN", "POLLPRI", "POLLOUT", "POLLERR", "POLLHUP", "POLLNVAL", "POLLRDNORM", "POLLRDBAND", "POLLWRNORM", "POLLWRBAND", "POLLINIGNEOF"}},
+	{Name: "recv_flags", Values: []string{"MSG_OOB", "MSG_PEEK", "MSG_WAITALL", "MSG_DONTWAIT", "MSG_CMSG_CLOEXEC"}},
+	{Name: "rlimit_type", Values: []string{"RLIMIT_AS", "RLIMIT_CORE", "RLIMIT_CPU", "RLIMIT_DATA", "RLIMIT_FSIZE", "RLIMIT_MEMLOCK", "RLIMIT_NOFILE", "RLIMIT_NPROC", "RLIMIT_RSS", "RLIMIT_STACK"}},
+	{Name: "rusage_who", Values: []string{"RUSAGE_SELF", "RUSAGE_CHILDREN", "RUSAGE_THREAD"}},
+	{Name: "sctp_event_types", Values: []string{"SCTP_ASSOC_CHANGE", "SCTP_PEER_ADDR_CHANGE", "SCTP_REMOTE_ERROR", "SCTP_SEND_FAILED", "SCTP_SHUTDOWN_EVENT", "SCTP_ADAPTATION_INDICATION", "SCTP_PARTIAL_DELIVERY_EVENT", "SCTP_AUTHENTICATION_EVENT", "SCTP_STREAM_RESET_EVENT", "SCTP_SENDER_DRY_EVENT", "SCTP_NOTIFICATIONS_STOPPED_EVENT", "SCTP_ASSOC_RESET_EVENT", "SCTP_STREAM_CHANGE_EVENT", "SCTP_SEND_FAILED_EVENT"}},
+	{Name: "sctp_pr_policies", Values: []string{"SCTP_PR_SCTP_NONE", "SCTP_PR_SCTP_TTL", "SCTP_PR_SCTP_PRIO", "SCTP_PR_SCTP_RTX", "SCTP_PR_SCTP_ALL"}},
+	{Name: "sctp_sndrcv_flags", Values: []string{"SCTP_NOTIFICATION", "SCTP_COMPLETE", "SCTP_EOF", "SCTP_ABORT", "SCTP_UNORDERED", "SCTP_ADDR_OVER", "SCTP_SENDALL", "SCTP_EOR", "SCTP_SACK_IMMEDIATELY"}},
+	{Name: "sctp_socket_type", Values: []string{"SOCK_STREAM", "SOCK_SEQPACKET"}},
+	{Name: "sctp_spp_flags", Values: []string{"SPP_HB_ENABLE", "SPP_HB_DISABLE", "SPP_HB_DEMAND", "SPP_PMTUD_ENABLE", "SPP_PMTUD_DISABLE", "SPP_HB_TIME_IS_ZERO", "SPP_IPV6_FLOWLABEL", "SPP_DSCP"}},
+	{Name: "seek_whence", Values: []string{"SEEK_SET", "SEEK_CUR", "SEEK_END", "SEEK_DATA", "SEEK_HOLE"}},
+	{Name: "semget_flags", Values: []string{"IPC_CREAT", "IPC_EXCL", "S_IRUSR", "S_IWUSR", "S_IXUSR", "S_IRGRP", "S_IWGRP", "S_IXGRP", "S_IROTH", "S_IWOTH", "S_IXOTH"}},
+	{Name: "semop_flags", Values: []string{"IPC_NOWAIT", "SEM_UNDO"}},
+	{Name: "send_flags", Values: []string{"MSG_OOB", "MSG_DONTROUTE", "MSG_EOR", "MSG_DONTWAIT", "MSG_EOF", "MSG_NOSIGNAL"}},
+	{Name: "shmat_flags", Values: []string{"SHM_RND", "SHM_RDONLY"}},
+	{Name: "shmget_flags", Values: []string{"IPC_CREAT", "IPC_EXCL", "S_IRUSR", "S_IWUSR", "S_IXUSR", "S_IRGRP", "S_IWGRP", "S_IXGRP", "S_IROTH", "S_IWOTH", "S_IXOTH"}},
+	{Name: "shutdown_flags", Values: []string{"SHUT_RD", "SHUT_WR"}},
+	{Name: "socket_domain", Values: []string{"AF_UNIX", "AF_INET", "AF_APPLETALK", "AF_IPX", "AF_INET6"}},
+	{Name: "socket_type", Values: []string{"SOCK_STREAM", "SOCK_DGRAM", "SOCK_RAW", "SOCK_RDM", "SOCK_SEQPACKET", "SOCK_NONBLOCK", "SOCK_CLOEXEC"}},
+	{Name: "sockopt_opt_ip_group_source_req", Values: []string{"MCAST_JOIN_SOURCE_GROUP", "MCAST_LEAVE_SOURCE_GROUP", "MCAST_BLOCK_SOURCE", "MCAST_UNBLOCK_SOURCE"}},
+	{Name: "sockopt_opt_ip_mreq", Values: []string{"IP_ADD_MEMBERSHIP", "IP_DROP_MEMBERSHIP", "IP_MULTICAST_IF"}},
+	{Name: "sockopt_opt_ip_mreqsrc", Values: []string{"IP_ADD_SOURCE_MEMBERSHIP", "IP_BLOCK_SOURCE", "IP_DROP_SOURCE_MEMBERSHIP", "IP_UNBLOCK_SOURCE"}},
+	{Name: "sockopt_opt_ip_opts", Values: []string{"IP_OPTIONS"}},
+	{Name: "sockopt_opt_ipv6_group_source_req", Values: []string{"MCAST_JOIN_SOURCE_GROUP", "MCAST_LEAVE_SOURCE_GROUP", "MCAST_BLOCK_SOURCE", "MCAST_UNBLOCK_SOURCE"}},
+	{Name: "sockopt_opt_sock_int", Values: []string{"SO_DEBUG", "SO_ACCEPTCONN", "SO_REUSEADDR", "SO_KEEPALIVE", "SO_DONTROUTE", "SO_BROADCAST", "SO_USELOOPBACK", "SO_OOBINLINE", "SO_REUSEPORT", "SO_TIMESTAMP", "SO_NOSIGPIPE", "SO_BINTIME", "SO_NO_OFFLOAD", "SO_NO_DDP", "SO_REUSEPORT_LB", "SO_SNDBUF", "SO_RCVBUF", "SO_SNDLOWAT", "SO_RCVLOWAT", "SO_ERROR", "SO_TYPE", "SO_LISTENQLIMIT", "SO_LISTENQLEN", "SO_LISTENINCQLEN", "SO_SETFIB", "SO_USER_COOKIE", "SO_PROTOCOL", "SO_PROTOTYPE", "SO_TS_CLOCK", "SO_MAX_PACING_RATE", "SO_DOMAIN"}},
+	{Name: "sockopt_opt_sock_timeval", Values: []string{"SO_RCVTIMEO", "SO_SNDTIMEO"}},
+	{Name: "tcp_option_types_buf", Values: []string{"TCP_INFO", "TCP_LOGBUF", "TCP_LOGID", "TCP_LOGDUMP", "TCP_LOGDUMPID", "TCP_CONGESTION", "TCP_CCALGOOPT", "TCP_FASTOPEN", "TCP_FUNCTION_BLK"}},
+	{Name: "tcp_option_types_int", Values: []string{"TCP_NODELAY", "TCP_MAXSEG", "TCP_NOPUSH", "TCP_NOOPT", "TCP_MD5SIG", "TCP_LOG", "TCP_DELACK", "TCP_KEEPINIT", "TCP_KEEPIDLE", "TCP_KEEPINTVL", "TCP_KEEPCNT", "TCP_PCAP_OUT", "TCP_PCAP_IN"}},
+	{Name: "timer_flags", Values: []string{"TIMER_ABSTIME"}},
+	{Name: "udp_option_types", Values: []string{"UDP_ENCAP"}},
+	{Name: "udplite_option_types", Values: []string{"UDPLITE_RECV_CSCOV", "UDPLITE_SEND_CSCOV"}},
+	{Name: "unix_socket_family", Values: []string{"AF_UNIX", "AF_UNSPEC"}},
+	{Name: "unix_socket_type", Values: []string{"SOCK_STREAM", "SOCK_DGRAM", "SOCK_SEQPACKET"}},
+	{Name: "unlinkat_flags", Values: []string{"AT_REMOVEDIR"}},
+	{Name: "utimensat_flags", Values: []string{"AT_SYMLINK_NOFOLLOW"}},
+	{Name: "wait_options", Values: []string{"WNOHANG", "WUNTRACED", "WCONTINUED", "WEXITED", "WSTOPPED", "WCONTINUED", "WNOHANG", "WNOWAIT"}},
+}
+
+const revision_amd64 = "bea096a9d6c11f042e2c66c1ac8b9e89fe085216"
@@ -7,7 +7,7 @@ import . "github.com/google/syzkaller/prog"
 import . "github.com/google/syzkaller/sys/fuchsia"
 
 func init() {
-	RegisterTarget(&Target{OS: "fuchsia", Arch: "amd64", Revision: revision_amd64, PtrSize: 8, PageSize: 4096, NumPages: 4096, DataOffset: 536870912, Syscalls: syscalls_amd64, Resources: resources_amd64, Structs: structDescs_amd64, Consts: consts_amd64}, InitTarget)
+	RegisterTarget(&Target{OS: "fuchsia", Arch: "amd64", Revision: revision_amd64, PtrSize: 8, PageSize: 4096, NumPages: 4096, DataOffset: 536870912, Syscalls: syscalls_amd64, Resources: resources_amd64, Structs: structDescs_amd64, Consts: consts_amd64, Flags: flags_amd64}, InitTarget)
 }
 
 var resources_amd64 = []*ResourceDesc{
@@ -7549,4 +7549,42 @@ var consts_amd64 = []ConstValue{
 	{Name: "fuchsia_power_Status_OK"},
 }
 
-const revision_amd64 = "020338cc7e797e47ecbbe68691c0954e831070f4"
+var flags_amd64 = []FlagDesc{
+	{Name: "at_flags", Values: []string{"AT_EMPTY_PATH", "AT_SYMLINK_NOFOLLOW", "AT_SYMLINK_FOLLOW", "AT_NO_AUTOMOUNT", "AT_EMPTY_PATH"}},
+	{Name: "cache_flush_flags", Values: []string{"ZX_CACHE_FLUSH_DATA", "ZX_CACHE_FLUSH_INVALIDATE", "ZX_CACHE_FLUSH_INSN"}},
+	{Name: "chan_read_options", Values: []string{"ZX_CHANNEL_READ_MAY_DISCARD"}},
+	{Name: "clock_id", Values: []string{"ZX_CLOCK_MONOTONIC", "ZX_CLOCK_UTC", "ZX_CLOCK_THREAD"}},
+	{Name: "dup_flags", Values: []string{"O_CLOEXEC"}},
+	{Name: "exception_port_options", Values: []string{"ZX_EXCEPTION_PORT_DEBUGGER"}},
+	{Name: "fidl_alloc_presence", Values: []string{"FIDL_ALLOC_ABSENT", "FIDL_ALLOC_PRESENT", "FIDL_ALLOC_PRESENT", "FIDL_ALLOC_PRESENT", "FIDL_ALLOC_PRESENT", "FIDL_ALLOC_PRESENT"}},
+	{Name: "fidl_handle_presence", Values: []string{"FIDL_HANDLE_ABSENT", "FIDL_HANDLE_PRESENT", "FIDL_HANDLE_PRESENT", "FIDL_HANDLE_PRESENT", "FIDL_HANDLE_PRESENT", "FIDL_HANDLE_PRESENT"}},
+	{Name: "fuchsia_cobalt_ReleaseStage", Values: []string{"fuchsia_cobalt_ReleaseStage_GA", "fuchsia_cobalt_ReleaseStage_DOGFOOD", "fuchsia_cobalt_ReleaseStage_FISHFOOD", "fuchsia_cobalt_ReleaseStage_DEBUG"}},
+	{Name: "fuchsia_devicesettings_ValueType", Values: []string{"fuchsia_devicesettings_ValueType_number", "fuchsia_devicesettings_ValueType_text"}},
+	{Name: "fuchsia_io_SeekOrigin", Values: []string{"fuchsia_io_SeekOrigin_START", "fuchsia_io_SeekOrigin_CURRENT", "fuchsia_io_SeekOrigin_END"}},
+	{Name: "fuchsia_power_Status", Values: []string{"fuchsia_power_Status_OK", "fuchsia_power_Status_NotAvailable"}},
+	{Name: "job_policy_options", Values: []string{"ZX_JOB_POL_RELATIVE", "ZX_JOB_POL_ABSOLUTE"}},
+	{Name: "linkat_flags", Values: []string{"AT_EMPTY_PATH", "AT_SYMLINK_FOLLOW"}},
+	{Name: "log_create_options", Values: []string{"ZX_LOG_FLAG_READABLE"}},
+	{Name: "open_flags", Values: []string{"O_RDONLY", "O_WRONLY", "O_RDWR", "O_APPEND", "FASYNC", "O_CLOEXEC", "O_CREAT", "O_DIRECT", "O_DIRECTORY", "O_EXCL", "O_LARGEFILE", "O_NOATIME", "O_NOCTTY", "O_NOFOLLOW", "O_NONBLOCK", "O_PATH", "O_SYNC", "O_TRUNC"}},
+	{Name: "open_mode", Values: []string{"S_IRUSR", "S_IWUSR", "S_IXUSR", "S_IRGRP", "S_IWGRP", "S_IXGRP", "S_IROTH", "S_IWOTH", "S_IXOTH"}},
+	{Name: "pollfd_events", Values: []string{"POLLIN", "POLLPRI", "POLLOUT", "POLLERR", "POLLHUP", "POLLNVAL", "POLLRDNORM", "POLLRDBAND", "POLLWRNORM", "POLLWRBAND", "POLLMSG", "POLLRDHUP"}},
+	{Name: "seek_whence", Values: []string{"SEEK_SET", "SEEK_CUR", "SEEK_END"}},
+	{Name: "socket_create_options", Values: []string{"ZX_SOCKET_STREAM", "ZX_SOCKET_DATAGRAM", "ZX_SOCKET_HAS_CONTROL"}},
+	{Name: "socket_read_options", Values: []string{"ZX_SOCKET_CONTROL"}},
+	{Name: "socket_write_options", Values: []string{"ZX_SOCKET_CONTROL", "ZX_SOCKET_SHUTDOWN_READ", "ZX_SOCKET_SHUTDOWN_WRITE"}},
+	{Name: "timer_options", Values: []string{"ZX_TIMER_SLACK_CENTER", "ZX_TIMER_SLACK_EARLY", "ZX_TIMER_SLACK_LATE"}},
+	{Name: "unlinkat_flags", Values: []string{"AT_REMOVEDIR"}},
+	{Name: "utimensat_flags", Values: []string{"AT_SYMLINK_NOFOLLOW"}},
+	{Name: "vmar_allocate_options", Values: []string{"ZX_VM_FLAG_COMPACT", "ZX_VM_FLAG_SPECIFIC", "ZX_VM_FLAG_CAN_MAP_SPECIFIC", "ZX_VM_FLAG_CAN_MAP_READ", "ZX_VM_FLAG_CAN_MAP_WRITE", "ZX_VM_FLAG_CAN_MAP_EXECUTE"}},
+	{Name: "vmar_map_options", Values: []string{"ZX_VM_FLAG_SPECIFIC", "ZX_VM_FLAG_SPECIFIC_OVERWRITE", "ZX_VM_FLAG_PERM_READ", "ZX_VM_FLAG_PERM_WRITE", "ZX_VM_FLAG_PERM_EXECUTE", "ZX_VM_FLAG_MAP_RANGE"}},
+	{Name: "vmar_protect_options", Values: []string{"ZX_VM_FLAG_PERM_READ", "ZX_VM_FLAG_PERM_WRITE", "ZX_VM_FLAG_PERM_EXECUTE"}},
+	{Name: "vmo_cache_policy", Values: []string{"ZX_CACHE_POLICY_CACHED", "ZX_CACHE_POLICY_UNCACHED", "ZX_CACHE_POLICY_UNCACHED_DEVICE", "ZX_CACHE_POLICY_WRITE_COMBINING"}},
+	{Name: "vmo_clone_options", Values: []string{"ZX_VMO_CLONE_COPY_ON_WRITE"}},
+	{Name: "vmo_create_options", Values: []string{"ZX_VMO_NON_RESIZABLE"}},
+	{Name: "wait_async_options", Values: []string{"ZX_WAIT_ASYNC_ONCE", "ZX_WAIT_ASYNC_REPEATING"}},
+	{Name: "zx_policy_condition", Values: []string{"ZX_POL_BAD_HANDLE", "ZX_POL_WRONG_OBJECT", "ZX_POL_VMAR_WX", "ZX_POL_NEW_VMO", "ZX_POL_NEW_CHANNEL", "ZX_POL_NEW_EVENT", "ZX_POL_NEW_PORT", "ZX_POL_NEW_SOCKET", "ZX_POL_NEW_FIFO", "ZX_POL_NEW_TIMER", "ZX_POL_NEW_ANY"}},
+	{Name: "zx_policy_policy", Values: []string{"ZX_POL_ACTION_ALLOW", "ZX_POL_ACTION_DENY", "ZX_POL_ACTION_EXCEPTION", "ZX_POL_ACTION_KILL"}},
+	{Name: "zx_rights", Values: []string{"ZX_RIGHT_NONE", "ZX_RIGHT_DUPLICATE", "ZX_RIGHT_TRANSFER", "ZX_RIGHT_READ", "ZX_RIGHT_WRITE", "ZX_RIGHT_EXECUTE", "ZX_RIGHT_MAP", "ZX_RIGHT_GET_PROPERTY", "ZX_RIGHT_SET_PROPERTY", "ZX_RIGHT_ENUMERATE", "ZX_RIGHT_DESTROY", "ZX_RIGHT_SET_POLICY", "ZX_RIGHT_GET_POLICY", "ZX_RIGHT_SIGNAL", "ZX_RIGHT_SIGNAL_PEER", "ZX_RIGHT_SAME_RIGHTS"}},
+}
+
+const revision_amd64 = "17afa527dfcf285df4859e73af02d87e1e6e2b9c"
@@ -7,7 +7,7 @@ import . "github.com/google/syzkaller/prog"
 import . "github.com/google/syzkaller/sys/netbsd"
 
 func init() {
-	RegisterTarget(&Target{OS: "netbsd", Arch: "amd64", Revision: revision_amd64, PtrSize: 8, PageSize: 4096, NumPages: 4096, DataOffset: 536870912, Syscalls: syscalls_amd64, Resources: resources_amd64, Structs: structDescs_amd64, Consts: consts_amd64}, InitTarget)
+	RegisterTarget(&Target{OS: "netbsd", Arch: "amd64", Revision: revision_amd64, PtrSize: 8, PageSize: 4096, NumPages: 4096, DataOffset: 536870912, Syscalls: syscalls_amd64, Resources: resources_amd64, Structs: structDescs_amd64, Consts: consts_amd64, Flags: flags_amd64}, InitTarget)
 }
 
 var resources_amd64 = []*ResourceDesc{
@@ -1640,4 +1640,55 @@ var consts_amd64 = []ConstValue{
 	{Name: "WUNTRACED", Value: 2},
 }
 
-const revision_amd64 = "ac2fb81fe359721cebd06da0bf1c893a787fc700"
+var flags_amd64 = []FlagDesc{
+	{Name: "accept_flags", Values: []string{"SOCK_NONBLOCK", "SOCK_CLOEXEC", "SOCK_NOSIGPIPE"}},
+	{Name: "at_flags", Values: []string{"AT_SYMLINK_NOFOLLOW", "AT_SYMLINK_FOLLOW"}},
+	{Name: "clock_id", Values: []string{"CLOCK_REALTIME", "CLOCK_MONOTONIC", "CLOCK_PROCESS_CPUTIME_ID", "CLOCK_THREAD_CPUTIME_ID", "CLOCK_VIRTUAL", "CLOCK_PROF"}},
+	{Name: "cmsg_levels", Values: []string{"SOL_SOCKET", "IPPROTO_ICMP", "LINUX_SOL_IP", "LINUX_SOL_TCP", "LINUX_SOL_UDP", "LINUX_SOL_IPV6", "LINUX_SOL_IPX", "LINUX_SOL_AX25"}},
+	{Name: "faccessat_flags", Values: []string{"AT_EACCESS", "AT_SYMLINK_NOFOLLOW"}},
+	{Name: "fcntl_dupfd", Values: []string{"F_DUPFD", "F_DUPFD_CLOEXEC"}},
+	{Name: "fcntl_flags", Values: []string{"FD_CLOEXEC"}},
+	{Name: "fcntl_getflags", Values: []string{"F_GETFD", "F_GETFL"}},
+	{Name: "fcntl_lock", Values: []string{"F_SETLK", "F_SETLKW", "F_GETLK"}},
+	{Name: "fcntl_status", Values: []string{"O_APPEND", "O_ASYNC", "O_NONBLOCK", "O_DSYNC", "O_RSYNC", "O_ALT_IO", "O_DIRECT", "O_NOSIGPIPE"}},
+	{Name: "flock_op", Values: []string{"LOCK_SH", "LOCK_EX", "LOCK_UN", "LOCK_NB"}},
+	{Name: "flock_type", Values: []string{"F_RDLCK", "F_WRLCK", "F_UNLCK"}},
+	{Name: "getitimer_which", Values: []string{"ITIMER_REAL", "ITIMER_VIRTUAL", "ITIMER_PROF", "ITIMER_MONOTONIC"}},
+	{Name: "linkat_flags", Values: []string{"AT_SYMLINK_FOLLOW"}},
+	{Name: "madvise_flags", Values: []string{"MADV_NORMAL", "MADV_RANDOM", "MADV_SEQUENTIAL", "MADV_WILLNEED", "MADV_DONTNEED", "MADV_FREE"}},
+	{Name: "mif6c_flags", Values: []string{"MIFF_REGISTER"}},
+	{Name: "mknod_mode", Values: []string{"S_IFREG", "S_IFCHR", "S_IFBLK", "S_IFIFO", "S_IFSOCK", "S_IRUSR", "S_IWUSR", "S_IXUSR", "S_IRGRP", "S_IWGRP", "S_IXGRP", "S_IROTH", "S_IWOTH", "S_IXOTH"}},
+	{Name: "mlockall_flags", Values: []string{"MCL_CURRENT", "MCL_FUTURE"}},
+	{Name: "mmap_flags", Values: []string{"MAP_ANON", "MAP_FILE", "MAP_FIXED", "MAP_HASSEMAPHORE", "MAP_INHERIT", "MAP_TRYFIXED", "MAP_WIRED", "MAP_PRIVATE", "MAP_SHARED"}},
+	{Name: "mmap_prot", Values: []string{"PROT_EXEC", "PROT_READ", "PROT_WRITE", "PROT_NONE"}},
+	{Name: "msgget_flags", Values: []string{"IPC_CREAT", "IPC_EXCL", "S_IRUSR", "S_IWUSR", "S_IXUSR", "S_IRGRP", "S_IWGRP", "S_IXGRP", "S_IROTH", "S_IWOTH", "S_IXOTH"}},
+	{Name: "msgrcv_flags", Values: []string{"IPC_NOWAIT", "MSG_NOERROR"}},
+	{Name: "msgsnd_flags", Values: []string{"IPC_NOWAIT"}},
+	{Name: "open_flags", Values: []string{"O_RDONLY", "O_WRONLY", "O_RDWR", "O_APPEND", "O_CREAT", "O_TRUNC", "O_EXCL", "O_SHLOCK", "O_EXLOCK", "O_NOFOLLOW", "O_CLOEXEC", "O_NOSIGPIPE", "O_DSYNC", "O_SYNC", "O_RSYNC", "O_ALT_IO", "O_NOCTTY", "O_DIRECT", "O_DIRECTORY", "O_ASYNC"}},
+	{Name: "open_mode", Values: []string{"S_IRUSR", "S_IWUSR", "S_IXUSR", "S_IRGRP", "S_IWGRP", "S_IXGRP", "S_IROTH", "S_IWOTH", "S_IXOTH"}},
+	{Name: "pipe_flags", Values: []string{"O_NONBLOCK", "O_CLOEXEC", "O_NOSIGPIPE"}},
+	{Name: "pollfd_events", Values: []string{"POLLIN", "POLLPRI", "POLLOUT", "POLLERR", "POLLHUP", "POLLNVAL", "POLLRDNORM", "POLLRDBAND", "POLLWRNORM", "POLLWRBAND"}},
+	{Name: "recv_flags", Values: []string{"MSG_CMSG_CLOEXEC", "MSG_OOB", "MSG_PEEK", "MSG_WAITALL"}},
+	{Name: "rlimit_type", Values: []string{"RLIMIT_AS", "RLIMIT_CORE", "RLIMIT_CPU", "RLIMIT_DATA", "RLIMIT_FSIZE", "RLIMIT_MEMLOCK", "RLIMIT_NOFILE", "RLIMIT_NPROC", "RLIMIT_RSS", "RLIMIT_STACK", "RLIMIT_NTHR", "RLIMIT_STACK"}},
+	{Name: "rusage_who", Values: []string{"RUSAGE_SELF", "RUSAGE_CHILDREN"}},
+	{Name: "seek_whence", Values: []string{"SEEK_SET", "SEEK_CUR", "SEEK_END"}},
+	{Name: "semget_flags", Values: []string{"IPC_CREAT", "IPC_EXCL", "S_IRUSR", "S_IWUSR", "S_IXUSR", "S_IRGRP", "S_IWGRP", "S_IXGRP", "S_IROTH", "S_IWOTH", "S_IXOTH"}},
+	{Name: "semop_flags", Values: []string{"IPC_NOWAIT", "SEM_UNDO"}},
+	{Name: "send_flags", Values: []string{"MSG_OOB", "MSG_PEEK", "MSG_DONTROUTE", "MSG_EOR", "MSG_NOSIGNAL"}},
+	{Name: "shmat_flags", Values: []string{"SHM_RND", "SHM_RDONLY"}},
+	{Name: "shmget_flags", Values: []string{"IPC_CREAT", "IPC_EXCL", "S_IRUSR", "S_IWUSR", "S_IXUSR", "S_IRGRP", "S_IWGRP", "S_IXGRP", "S_IROTH", "S_IWOTH", "S_IXOTH"}},
+	{Name: "shutdown_flags", Values: []string{"SHUT_RD", "SHUT_WR", "SHUT_RDWR"}},
+	{Name: "socket_domain", Values: []string{"AF_LOCAL", "AF_INET", "AF_INET6", "AF_NS", "AF_BLUETOOTH", "AF_APPLETALK"}},
+	{Name: "socket_type", Values: []string{"SOCK_STREAM", "SOCK_DGRAM", "SOCK_RAW", "SOCK_RDM", "SOCK_SEQPACKET", "SOCK_NONBLOCK", "SOCK_CLOEXEC", "SOCK_NOSIGPIPE"}},
+	{Name: "sockopt_opt_ip_opts", Values: []string{"IP_OPTIONS"}},
+	{Name: "sockopt_opt_sock_int", Values: []string{"SO_DEBUG", "SO_REUSEADDR", "SO_REUSEPORT", "SO_KEEPALIVE", "SO_DONTROUTE", "SO_LINGER", "SO_BROADCAST", "SO_OOBINLINE", "SO_SNDBUF", "SO_RCVBUF", "SO_SNDLOWAT", "SO_RCVLOWAT", "SO_TIMESTAMP", "SO_ACCEPTFILTER", "SO_NOSIGPIPE", "SO_TYPE", "SO_ERROR"}},
+	{Name: "sockopt_opt_sock_timeval", Values: []string{"SO_RCVTIMEO", "SO_SNDTIMEO"}},
+	{Name: "timer_flags", Values: []string{"TIMER_ABSTIME"}},
+	{Name: "unix_socket_family", Values: []string{"AF_UNIX", "AF_UNSPEC"}},
+	{Name: "unix_socket_type", Values: []string{"SOCK_STREAM", "SOCK_DGRAM", "SOCK_SEQPACKET"}},
+	{Name: "unlinkat_flags", Values: []string{"AT_REMOVEDIR"}},
+	{Name: "utimensat_flags", Values: []string{"AT_SYMLINK_NOFOLLOW"}},
+	{Name: "wait_options", Values: []string{"WALLSIG", "WALTSIG", "WCONTINUED", "WEXITED", "WNOHANG", "WNOZOMBIE", "WSTOPPED", "WTRAPPED", "WUNTRACED"}},
+}
+
+const revision_amd64 = "486bbadce6e6583040acb81dd258538d1e7b619f"
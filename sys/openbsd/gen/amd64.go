@@ -7,7 +7,7 @@ import . "github.com/google/syzkaller/prog"
 import . "github.com/google/syzkaller/sys/openbsd"
 
 func init() {
-	RegisterTarget(&Target{OS: "openbsd", Arch: "amd64", Revision: revision_amd64, PtrSize: 8, PageSize: 4096, NumPages: 4096, DataOffset: 536870912, Syscalls: syscalls_amd64, Resources: resources_amd64, Structs: structDescs_amd64, Consts: consts_amd64}, InitTarget)
+	RegisterTarget(&Target{OS: "openbsd", Arch: "amd64", Revision: revision_amd64, PtrSize: 8, PageSize: 4096, NumPages: 4096, DataOffset: 536870912, Syscalls: syscalls_amd64, Resources: resources_amd64, Structs: structDescs_amd64, Consts: consts_amd64, Flags: flags_amd64}, InitTarget)
 }
 
 var resources_amd64 = []*ResourceDesc{
@@ -2355,4 +2355,71 @@ var consts_amd64 = []ConstValue{
 	{Name: "__MAP_NOREPLACE", Value: 2048},
 }
 
-const revision_amd64 = "9323c36f6354363bbc8628c16d604d46362805d5"
+var flags_amd64 = []FlagDesc{
+	{Name: "at_flags", Values: []string{"AT_SYMLINK_NOFOLLOW", "AT_SYMLINK_FOLLOW"}},
+	{Name: "clock_id", Values: []string{"CLOCK_REALTIME", "CLOCK_MONOTONIC", "CLOCK_PROCESS_CPUTIME_ID", "CLOCK_THREAD_CPUTIME_ID"}},
+	{Name: "cmsg_levels", Values: []string{"SOL_SOCKET", "IPPROTO_ICMP"}},
+	{Name: "faccessat_flags", Values: []string{"AT_EACCESS", "AT_SYMLINK_NOFOLLOW"}},
+	{Name: "fcntl_dupfd", Values: []string{"F_DUPFD", "F_DUPFD_CLOEXEC"}},
+	{Name: "fcntl_flags", Values: []string{"FD_CLOEXEC"}},
+	{Name: "fcntl_getflags", Values: []string{"F_GETFD", "F_GETFL"}},
+	{Name: "fcntl_lock", Values: []string{"F_SETLK", "F_SETLKW", "F_GETLK"}},
+	{Name: "fcntl_status", Values: []string{"O_APPEND", "O_ASYNC", "O_NONBLOCK", "O_DSYNC", "O_RSYNC"}},
+	{Name: "flock_op", Values: []string{"LOCK_SH", "LOCK_EX", "LOCK_UN", "LOCK_NB"}},
+	{Name: "flock_type", Values: []string{"F_RDLCK", "F_WRLCK", "F_UNLCK"}},
+	{Name: "getitimer_which", Values: []string{"ITIMER_REAL", "ITIMER_VIRTUAL", "ITIMER_PROF"}},
+	{Name: "inherit_flags", Values: []string{"MAP_INHERIT_NONE", "MAP_INHERIT_COPY", "MAP_INHERIT_SHARE", "MAP_INHERIT_ZERO"}},
+	{Name: "kd_getled_flags", Values: []string{"K_RAW", "K_XLATE"}},
+	{Name: "kd_mode_flags", Values: []string{"KD_GRAPHICS", "KD_TEXT"}},
+	{Name: "kd_setled_flags", Values: []string{"LED_CAP", "LED_NUM", "LED_SCR"}},
+	{Name: "kevent_fflags", Values: []string{"NOTE_LOWAT", "NOTE_EOF", "NOTE_DELETE", "NOTE_WRITE", "NOTE_EXTEND", "NOTE_ATTRIB", "NOTE_LINK", "NOTE_RENAME", "NOTE_REVOKE", "NOTE_TRUNCATE", "NOTE_EXIT", "NOTE_FORK", "NOTE_EXEC", "NOTE_PCTRLMASK", "NOTE_PDATAMASK", "NOTE_TRACK", "NOTE_TRACKERR", "NOTE_CHILD", "NOTE_CHANGE"}},
+	{Name: "kevent_filter", Values: []string{"EVFILT_READ", "EVFILT_WRITE", "EVFILT_AIO", "EVFILT_VNODE", "EVFILT_PROC", "EVFILT_SIGNAL", "EVFILT_TIMER", "EVFILT_DEVICE"}},
+	{Name: "kevent_flags", Values: []string{"EV_ADD", "EV_DELETE", "EV_ENABLE", "EV_DISABLE", "EV_ONESHOT", "EV_CLEAR", "EV_RECEIPT", "EV_DISPATCH"}},
+	{Name: "linkat_flags", Values: []string{"AT_SYMLINK_FOLLOW"}},
+	{Name: "madvise_flags", Values: []string{"MADV_NORMAL", "MADV_RANDOM", "MADV_SEQUENTIAL", "MADV_WILLNEED", "MADV_DONTNEED", "MADV_SPACEAVAIL", "MADV_FREE"}},
+	{Name: "mif6c_flags", Values: []string{"MIFF_REGISTER"}},
+	{Name: "mknod_mode", Values: []string{"S_IFMT", "S_IFREG", "S_IFCHR", "S_IFBLK", "S_IFIFO", "S_IFSOCK", "S_IRUSR", "S_IWUSR", "S_IXUSR", "S_IRGRP", "S_IWGRP", "S_IXGRP", "S_IROTH", "S_IWOTH", "S_IXOTH"}},
+	{Name: "mlockall_flags", Values: []string{"MCL_CURRENT", "MCL_FUTURE"}},
+	{Name: "mmap_flags", Values: []string{"MAP_SHARED", "MAP_PRIVATE", "MAP_FIXED", "MAP_ANONYMOUS", "MAP_STACK", "__MAP_NOFAULT", "__MAP_NOREPLACE"}},
+	{Name: "mmap_prot", Values: []string{"PROT_EXEC", "PROT_READ", "PROT_WRITE", "PROT_NONE"}},
+	{Name: "msgget_flags", Values: []string{"IPC_CREAT", "IPC_EXCL", "S_IRUSR", "S_IWUSR", "S_IXUSR", "S_IRGRP", "S_IWGRP", "S_IXGRP", "S_IROTH", "S_IWOTH", "S_IXOTH"}},
+	{Name: "msgrcv_flags", Values: []string{"IPC_NOWAIT", "MSG_NOERROR"}},
+	{Name: "msgsnd_flags", Values: []string{"IPC_NOWAIT"}},
+	{Name: "open_flags", Values: []string{"O_RDONLY", "O_WRONLY", "O_RDWR", "O_APPEND", "O_CREAT", "O_TRUNC", "O_EXCL", "O_SHLOCK", "O_EXLOCK", "O_NOFOLLOW", "O_CLOEXEC", "O_DSYNC", "O_SYNC", "O_RSYNC", "O_NOCTTY", "O_DIRECTORY", "O_ASYNC"}},
+	{Name: "open_mode", Values: []string{"S_IRUSR", "S_IWUSR", "S_IXUSR", "S_IRGRP", "S_IWGRP", "S_IXGRP", "S_IROTH", "S_IWOTH", "S_IXOTH"}},
+	{Name: "pipe_flags", Values: []string{"O_NONBLOCK", "O_CLOEXEC"}},
+	{Name: "pollfd_events", Values: []string{"POLLIN", "POLLPRI", "POLLOUT", "POLLERR", "POLLHUP", "POLLNVAL", "POLLRDNORM", "POLLRDBAND", "POLLWRNORM", "POLLWRBAND"}},
+	{Name: "recv_flags", Values: []string{"MSG_CMSG_CLOEXEC", "MSG_OOB", "MSG_PEEK", "MSG_WAITALL"}},
+	{Name: "rlimit_type", Values: []string{"RLIMIT_CORE", "RLIMIT_CPU", "RLIMIT_DATA", "RLIMIT_FSIZE", "RLIMIT_MEMLOCK", "RLIMIT_NOFILE", "RLIMIT_NPROC", "RLIMIT_RSS", "RLIMIT_STACK", "RLIMIT_STACK"}},
+	{Name: "rusage_who", Values: []string{"RUSAGE_SELF", "RUSAGE_CHILDREN"}},
+	{Name: "seek_whence", Values: []string{"SEEK_SET", "SEEK_CUR", "SEEK_END"}},
+	{Name: "semget_flags", Values: []string{"IPC_CREAT", "IPC_EXCL", "S_IRUSR", "S_IWUSR", "S_IXUSR", "S_IRGRP", "S_IWGRP", "S_IXGRP", "S_IROTH", "S_IWOTH", "S_IXOTH"}},
+	{Name: "semop_flags", Values: []string{"IPC_NOWAIT", "SEM_UNDO"}},
+	{Name: "send_flags", Values: []string{"MSG_OOB", "MSG_PEEK", "MSG_DONTROUTE", "MSG_EOR", "MSG_NOSIGNAL"}},
+	{Name: "shmat_flags", Values: []string{"SHM_RND", "SHM_RDONLY"}},
+	{Name: "shmget_flags", Values: []string{"IPC_CREAT", "IPC_EXCL", "S_IRUSR", "S_IWUSR", "S_IXUSR", "S_IRGRP", "S_IWGRP", "S_IXGRP", "S_IROTH", "S_IWOTH", "S_IXOTH"}},
+	{Name: "shutdown_flags", Values: []string{"SHUT_RD", "SHUT_WR", "SHUT_RDWR"}},
+	{Name: "socket_domain", Values: []string{"AF_LOCAL", "AF_INET", "AF_INET6", "AF_NS", "AF_BLUETOOTH", "AF_APPLETALK"}},
+	{Name: "socket_type", Values: []string{"SOCK_STREAM", "SOCK_DGRAM", "SOCK_RAW", "SOCK_RDM", "SOCK_SEQPACKET", "SOCK_NONBLOCK", "SOCK_CLOEXEC"}},
+	{Name: "sockopt_opt_ip_opts", Values: []string{"IP_OPTIONS"}},
+	{Name: "sockopt_opt_sock_int", Values: []string{"SO_DEBUG", "SO_REUSEADDR", "SO_REUSEPORT", "SO_KEEPALIVE", "SO_DONTROUTE", "SO_LINGER", "SO_BROADCAST", "SO_OOBINLINE", "SO_SNDBUF", "SO_RCVBUF", "SO_SNDLOWAT", "SO_RCVLOWAT", "SO_TIMESTAMP", "SO_TYPE", "SO_ERROR"}},
+	{Name: "sockopt_opt_sock_timeval", Values: []string{"SO_RCVTIMEO", "SO_SNDTIMEO"}},
+	{Name: "unix_socket_family", Values: []string{"AF_UNIX", "AF_UNSPEC"}},
+	{Name: "unix_socket_type", Values: []string{"SOCK_STREAM", "SOCK_DGRAM", "SOCK_SEQPACKET"}},
+	{Name: "unlinkat_flags", Values: []string{"AT_REMOVEDIR"}},
+	{Name: "utimensat_flags", Values: []string{"AT_SYMLINK_NOFOLLOW"}},
+	{Name: "vt_mode_flags", Values: []string{"VT_AUTO", "VT_PROCESS"}},
+	{Name: "vt_reldisp_flags", Values: []string{"VT_FALSE", "VT_TRUE", "VT_ACKACQ"}},
+	{Name: "wait_options", Values: []string{"WCONTINUED", "WNOHANG", "WUNTRACED"}},
+	{Name: "wsdisplay_burner_flags", Values: []string{"WSDISPLAY_BURN_VBLANK", "WSDISPLAY_BURN_KBD", "WSDISPLAY_BURN_MOUSE", "WSDISPLAY_BURN_OUTPUT"}},
+	{Name: "wsdisplay_delscreendata_flags", Values: []string{"WSDISPLAY_DELSCR_FORCE", "WSDISPLAY_DELSCR_QUIET"}},
+	{Name: "wsdisplay_font_encoding_flags", Values: []string{"WSDISPLAY_FONTENC_ISO", "WSDISPLAY_FONTENC_IBM"}},
+	{Name: "wsdisplay_font_order_flags", Values: []string{"WSDISPLAY_FONTORDER_KNOWN", "WSDISPLAY_FONTORDER_L2R", "WSDISPLAY_FONTORDER_R2L"}},
+	{Name: "wsdisplay_mode_flags", Values: []string{"WSDISPLAYIO_MODE_EMUL", "WSDISPLAYIO_MODE_MAPPED", "WSDISPLAYIO_MODE_DUMBFB"}},
+	{Name: "wsdisplay_svideo_flags", Values: []string{"WSDISPLAYIO_VIDEO_OFF", "WSDISPLAYIO_VIDEO_ON"}},
+	{Name: "wskbd_mode_flags", Values: []string{"WSKBD_RAW"}},
+	{Name: "wsmousecfg_flags", Values: []string{"WSMOUSECFG_DX_SCALE", "WSMOUSECFG_DY_SCALE", "WSMOUSECFG_PRESSURE_LO", "WSMOUSECFG_PRESSURE_HI", "WSMOUSECFG_TRKMAXDIST", "WSMOUSECFG_SWAPXY", "WSMOUSECFG_X_INV", "WSMOUSECFG_Y_INV", "WSMOUSECFG_DX_MAX", "WSMOUSECFG_DY_MAX", "WSMOUSECFG_X_HYSTERESIS", "WSMOUSECFG_Y_HYSTERESIS", "WSMOUSECFG_DECELERATION", "WSMOUSECFG_STRONG_HYSTERESIS", "WSMOUSECFG_SMOOTHING", "WSMOUSECFG_SOFTBUTTONS", "WSMOUSECFG_SOFTMBTN", "WSMOUSECFG_TOPBUTTONS", "WSMOUSECFG_TWOFINGERSCROLL", "WSMOUSECFG_EDGESCROLL", "WSMOUSECFG_HORIZSCROLL", "WSMOUSECFG_SWAPSIDES", "WSMOUSECFG_DISABLE", "WSMOUSECFG_TAPPING", "WSMOUSECFG_LEFT_EDGE", "WSMOUSECFG_RIGHT_EDGE", "WSMOUSECFG_TOP_EDGE", "WSMOUSECFG_BOTTOM_EDGE", "WSMOUSECFG_CENTERWIDTH", "WSMOUSECFG_HORIZSCROLLDIST", "WSMOUSECFG_VERTSCROLLDIST", "WSMOUSECFG_F2WIDTH", "WSMOUSECFG_F2PRESSURE", "WSMOUSECFG_TAP_MAXTIME", "WSMOUSECFG_TAP_CLICKTIME", "WSMOUSECFG_TAP_LOCKTIME", "WSMOUSECFG_LOG_INPUT", "WSMOUSECFG_LOG_EVENTS"}},
+	{Name: "wsmux_device_flags", Values: []string{"WSMUX_MOUSE", "WSMUX_KBD", "WSMUX_MUX"}},
+}
+
+const revision_amd64 = "a47589bd771beea2100f76ff985f91117d78a270"
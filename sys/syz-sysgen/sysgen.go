@@ -5,11 +5,13 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"go/format"
 	"io"
 	"io/ioutil"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -25,18 +27,58 @@ import (
 	"github.com/google/syzkaller/pkg/osutil"
 	"github.com/google/syzkaller/pkg/serializer"
 	"github.com/google/syzkaller/prog"
+	"github.com/google/syzkaller/sys/akaros"
+	"github.com/google/syzkaller/sys/freebsd"
+	"github.com/google/syzkaller/sys/fuchsia"
+	"github.com/google/syzkaller/sys/linux"
+	"github.com/google/syzkaller/sys/netbsd"
+	"github.com/google/syzkaller/sys/openbsd"
 	"github.com/google/syzkaller/sys/targets"
+	"github.com/google/syzkaller/sys/test"
+	"github.com/google/syzkaller/sys/trusty"
+	"github.com/google/syzkaller/sys/windows"
 )
 
 var (
 	flagMemProfile = flag.String("memprofile", "", "write a memory profile to the file")
+	flagStats      = flag.String("stats", "", "write description statistics in JSON format to the file")
+	flagMetadata   = flag.String("metadata", "", "write per-call metadata in JSON format to the file")
+	flagComplexity = flag.String("complexity", "", "write per-subsystem complexity metrics in JSON format to the file")
+	flagSubsystems = flag.String("subsystems", "", "comma-separated list of call name prefixes that define subsystems"+
+		" for -complexity (by default subsystems are description files)")
+	flagHash       = flag.String("hash", "", "write hashes of compiled descriptions in JSON format to the file")
+	flagBinary     = flag.String("binary", "", "write compact binary descriptions to OS_ARCH.bin files in the dir")
+	flagCtypes     = flag.String("ctypes", "", "write Python ctypes definitions of structs to OS_ARCH.py files in the dir")
+	flagTypeDump   = flag.String("typedump", "", "write type descriptors of declarations to OS_ARCH.txt files in the dir")
+	flagSourceMap  = flag.String("sourcemap", "", "write source maps of compiled types to OS_ARCH.json files in the dir")
+	flagRetained   = flag.String("retained", "", "comma-separated list of intentionally unused consts to not warn about")
+	flagInclude    = flag.String("include", "", "comma-separated list of call name globs to compile (e.g. ioctl$KVM*)")
+	flagExclude    = flag.String("exclude", "", "comma-separated list of call name globs to not compile")
+	flagDiag       = flag.String("diagnostics", "", "write all errors and warnings in JSON format to the file")
+	flagIncomplete = flag.Bool("forbid-incomplete", false, "fail on declarations marked with incomplete attribute")
+	flagStrictRes  = flag.Bool("strict-resources", false, "fail on resources without producers or consumers")
+	flagGeneric    = flag.Float64("generic-buffers", 0, "warn about calls with at least this fraction of generic input bytes")
+	flagSmoke      = flag.Bool("smoke", false, "generate and serialize a program with each call, fail on calls that break")
+	flagInterface  = flag.String("interface", "", "write only resources/consts interface in JSON format "+
+		"to OS_ARCH.json files in the dir, descriptions are not generated")
 )
 
+// TargetDiagnostic is a compiler diagnostic for a particular OS/arch
+// (or only for OS, if it happened during parsing).
+type TargetDiagnostic struct {
+	Target string `json:"target"`
+	*compiler.Diagnostic
+}
+
+var diagnostics []TargetDiagnostic
+
 type SyscallData struct {
 	Name     string
 	CallName string
 	NR       int32
 	NeedCall bool
+	Retries  int
+	Group    int
 }
 
 type ArchData struct {
@@ -58,11 +100,30 @@ type OSData struct {
 func main() {
 	flag.Parse()
 
+	retained := make(map[string]bool)
+	for _, name := range splitList(*flagRetained) {
+		retained[name] = true
+	}
+
 	var oses []OSData
+	stats := make(map[string]*compiler.Stats)
+	metadata := make(map[string][]*compiler.CallMetadata)
+	complexity := make(map[string][]*compiler.SubsystemComplexity)
+	hashes := make(map[string]string)
 	for OS, archs := range targets.List {
-		top := ast.ParseGlob(filepath.Join("sys", OS, "*.txt"), nil)
+		top := ast.ParseGlob(filepath.Join("sys", OS, "*.txt"), func(pos ast.Pos, msg string) {
+			ast.LoggingHandler(pos, msg)
+			diagnostics = append(diagnostics, TargetDiagnostic{OS, &compiler.Diagnostic{
+				Severity: compiler.SeverityError,
+				Category: compiler.PhaseParse,
+				File:     pos.File,
+				Line:     pos.Line,
+				Col:      pos.Col,
+				Message:  msg,
+			}})
+		})
 		if top == nil {
-			os.Exit(1)
+			exit(1)
 		}
 		osutil.MkdirAll(filepath.Join("sys", OS, "gen"))
 
@@ -70,7 +131,12 @@ func main() {
 			Target      *targets.Target
 			OK          bool
 			Errors      []string
+			Diagnostics []*compiler.Diagnostic
 			Unsupported map[string]bool
+			Stats       *compiler.Stats
+			Metadata    []*compiler.CallMetadata
+			Complexity  []*compiler.SubsystemComplexity
+			Hash        string
 			ArchData    ArchData
 		}
 		var jobs []*Job
@@ -92,15 +158,62 @@ func main() {
 				eh := func(pos ast.Pos, msg string) {
 					job.Errors = append(job.Errors, fmt.Sprintf("%v: %v\n", pos, msg))
 				}
-				consts := compiler.DeserializeConstsGlob(filepath.Join("sys", OS, "*_"+job.Target.Arch+".const"), eh)
+				consts, constPos := compiler.DeserializeConstsGlobPos(
+					filepath.Join("sys", OS, "*_"+job.Target.Arch+".const"),
+					func(pos ast.Pos, msg string) {
+						eh(pos, msg)
+						job.Diagnostics = append(job.Diagnostics, &compiler.Diagnostic{
+							Severity: compiler.SeverityError,
+							Category: compiler.PhaseConsts,
+							File:     pos.File,
+							Line:     pos.Line,
+							Col:      pos.Col,
+							Message:  msg,
+						})
+					})
 				if consts == nil {
 					return
 				}
-				prog := compiler.Compile(top, consts, job.Target, eh)
+				opts := compiler.Options{
+					Stats:            *flagStats != "",
+					Metadata:         *flagMetadata != "",
+					Complexity:       *flagComplexity != "",
+					Subsystems:       splitList(*flagSubsystems),
+					Hash:             *flagHash != "",
+					Ctypes:           *flagCtypes != "",
+					TypeDump:         *flagTypeDump != "",
+					SourceMap:        *flagSourceMap != "",
+					IncludeCalls:     splitList(*flagInclude),
+					ExcludeCalls:     splitList(*flagExclude),
+					RetainedConsts:   retained,
+					ConstPositions:   constPos,
+					ForbidIncomplete: *flagIncomplete,
+					StrictResources:  *flagStrictRes,
+					GenericBuffers:   *flagGeneric,
+					InterfaceOnly:    *flagInterface != "",
+					Diagnostics: func(d *compiler.Diagnostic) {
+						job.Diagnostics = append(job.Diagnostics, d)
+					},
+				}
+				prog := compiler.CompileOpts(top, consts, job.Target, eh, opts)
 				if prog == nil {
 					return
 				}
 				job.Unsupported = prog.Unsupported
+				job.Stats = prog.Stats
+				job.Metadata = prog.Metadata
+				job.Complexity = prog.Complexity
+				job.Hash = prog.Hash
+
+				if *flagInterface != "" {
+					file := filepath.Join(*flagInterface, OS+"_"+job.Target.Arch+".json")
+					if err := osutil.WriteFile(file, prog.Interface.Serialize()); err != nil {
+						job.Errors = append(job.Errors, fmt.Sprintf("failed to write interface: %v\n", err))
+						return
+					}
+					job.OK = true
+					return
+				}
 
 				sysFile := filepath.Join("sys", OS, "gen", job.Target.Arch+".go")
 				out := new(bytes.Buffer)
@@ -111,10 +224,60 @@ func main() {
 
 				job.ArchData = generateExecutorSyscalls(job.Target, prog.Syscalls, rev)
 
+				if *flagBinary != "" {
+					if err := prog.CheckRoundTrip(); err != nil {
+						job.Errors = append(job.Errors, fmt.Sprintf("binary descriptions don't round-trip: %v\n", err))
+						return
+					}
+					binFile := filepath.Join(*flagBinary, OS+"_"+job.Target.Arch+".bin")
+					if err := osutil.WriteFile(binFile, prog.SerializeBinary()); err != nil {
+						job.Errors = append(job.Errors, fmt.Sprintf("failed to write binary descriptions: %v\n", err))
+						return
+					}
+				}
+
+				if *flagCtypes != "" {
+					pyFile := filepath.Join(*flagCtypes, OS+"_"+job.Target.Arch+".py")
+					if err := osutil.WriteFile(pyFile, prog.Ctypes); err != nil {
+						job.Errors = append(job.Errors, fmt.Sprintf("failed to write ctypes definitions: %v\n", err))
+						return
+					}
+				}
+
+				if *flagTypeDump != "" {
+					dumpFile := filepath.Join(*flagTypeDump, OS+"_"+job.Target.Arch+".txt")
+					if err := osutil.WriteFile(dumpFile, prog.TypeDump); err != nil {
+						job.Errors = append(job.Errors, fmt.Sprintf("failed to write type dump: %v\n", err))
+						return
+					}
+				}
+
+				if *flagSourceMap != "" {
+					data, err := json.MarshalIndent(prog.SourceMap, "", "\t")
+					if err != nil {
+						job.Errors = append(job.Errors, fmt.Sprintf("failed to marshal source map: %v\n", err))
+						return
+					}
+					mapFile := filepath.Join(*flagSourceMap, OS+"_"+job.Target.Arch+".json")
+					if err := osutil.WriteFile(mapFile, data); err != nil {
+						job.Errors = append(job.Errors, fmt.Sprintf("failed to write source map: %v\n", err))
+						return
+					}
+				}
+
+				if *flagSmoke {
+					if errs := checkGeneration(job.Target, prog, consts); len(errs) != 0 {
+						job.Errors = append(job.Errors, errs...)
+						return
+					}
+				}
+
 				job.OK = true
 			}()
 		}
-		writeEmpty(OS)
+		if *flagInterface == "" {
+			writeEmpty(OS)
+		}
 		wg.Wait()
 
 		var syscallArchs []ArchData
@@ -124,13 +287,28 @@ func main() {
 			for _, msg := range job.Errors {
 				fmt.Print(msg)
 			}
+			for _, d := range job.Diagnostics {
+				diagnostics = append(diagnostics, TargetDiagnostic{job.Target.OS + "/" + job.Target.Arch, d})
+			}
 			if !job.OK {
-				os.Exit(1)
+				exit(1)
 			}
 			syscallArchs = append(syscallArchs, job.ArchData)
 			for u := range job.Unsupported {
 				unsupported[u]++
 			}
+			if job.Stats != nil {
+				stats[job.Target.OS+"/"+job.Target.Arch] = job.Stats
+			}
+			if job.Metadata != nil {
+				metadata[job.Target.OS+"/"+job.Target.Arch] = job.Metadata
+			}
+			if job.Complexity != nil {
+				complexity[job.Target.OS+"/"+job.Target.Arch] = job.Complexity
+			}
+			if job.Hash != "" {
+				hashes[job.Target.OS+"/"+job.Target.Arch] = job.Hash
+			}
 			fmt.Printf("\n")
 		}
 		oses = append(oses, OSData{
@@ -145,7 +323,51 @@ func main() {
 		}
 	}
 
-	writeExecutorSyscalls(oses)
+	if *flagInterface == "" {
+		writeExecutorSyscalls(oses)
+	}
+
+	if *flagStats != "" {
+		data, err := json.MarshalIndent(stats, "", "\t")
+		if err != nil {
+			failf("failed to marshal stats: %v", err)
+		}
+		if err := osutil.WriteFile(*flagStats, data); err != nil {
+			failf("failed to write stats: %v", err)
+		}
+	}
+
+	if *flagMetadata != "" {
+		data, err := json.MarshalIndent(metadata, "", "\t")
+		if err != nil {
+			failf("failed to marshal metadata: %v", err)
+		}
+		if err := osutil.WriteFile(*flagMetadata, data); err != nil {
+			failf("failed to write metadata: %v", err)
+		}
+	}
+
+	if *flagComplexity != "" {
+		data, err := json.MarshalIndent(complexity, "", "\t")
+		if err != nil {
+			failf("failed to marshal complexity: %v", err)
+		}
+		if err := osutil.WriteFile(*flagComplexity, data); err != nil {
+			failf("failed to write complexity: %v", err)
+		}
+	}
+
+	if *flagHash != "" {
+		data, err := json.MarshalIndent(hashes, "", "\t")
+		if err != nil {
+			failf("failed to marshal hashes: %v", err)
+		}
+		if err := osutil.WriteFile(*flagHash, data); err != nil {
+			failf("failed to write hashes: %v", err)
+		}
+	}
+
+	writeDiagnostics()
 
 	if *flagMemProfile != "" {
 		f, err := os.Create(*flagMemProfile)
@@ -160,6 +382,16 @@ func main() {
 	}
 }
 
+func splitList(list string) []string {
+	var res []string
+	for _, elem := range strings.Split(list, ",") {
+		if elem != "" {
+			res = append(res, elem)
+		}
+	}
+	return res
+}
+
 func generate(target *targets.Target, prg *compiler.Prog, consts map[string]uint64, out io.Writer) {
 	tag := fmt.Sprintf("syz_target,syz_os_%v,syz_arch_%v", target.OS, target.Arch)
 	if target.VMArch != "" {
@@ -175,11 +407,11 @@ func generate(target *targets.Target, prg *compiler.Prog, consts map[string]uint
 	fmt.Fprintf(out, "\tRegisterTarget(&Target{"+
 		"OS: %q, Arch: %q, Revision: revision_%v, PtrSize: %v, "+
 		"PageSize: %v, NumPages: %v, DataOffset: %v, Syscalls: syscalls_%v, "+
-		"Resources: resources_%v, Structs: structDescs_%v, Consts: consts_%v}, "+
+		"Resources: resources_%v, Structs: structDescs_%v, Consts: consts_%v, Flags: flags_%v}, "+
 		"InitTarget)\n}\n\n",
 		target.OS, target.Arch, target.Arch, target.PtrSize,
 		target.PageSize, target.NumPages, target.DataOffset,
-		target.Arch, target.Arch, target.Arch, target.Arch)
+		target.Arch, target.Arch, target.Arch, target.Arch, target.Arch)
 
 	fmt.Fprintf(out, "var resources_%v = ", target.Arch)
 	serializer.Write(out, prg.Resources)
@@ -193,6 +425,18 @@ func generate(target *targets.Target, prg *compiler.Prog, consts map[string]uint
 	serializer.Write(out, prg.Syscalls)
 	fmt.Fprintf(out, "\n\n")
 
+	fmt.Fprintf(out, "var consts_%v = ", target.Arch)
+	serializer.Write(out, constValues(consts))
+	fmt.Fprintf(out, "\n\n")
+
+	// Flag names are not preserved in the types, so they are emitted separately
+	// (values are looked up in consts). Only flags used by the syscalls are included.
+	fmt.Fprintf(out, "var flags_%v = ", target.Arch)
+	serializer.Write(out, prg.Flags)
+	fmt.Fprintf(out, "\n\n")
+}
+
+func constValues(consts map[string]uint64) []prog.ConstValue {
 	constArr := make([]prog.ConstValue, 0, len(consts))
 	for name, val := range consts {
 		constArr = append(constArr, prog.ConstValue{Name: name, Value: val})
@@ -200,9 +444,54 @@ func generate(target *targets.Target, prg *compiler.Prog, consts map[string]uint
 	sort.Slice(constArr, func(i, j int) bool {
 		return constArr[i].Name < constArr[j].Name
 	})
-	fmt.Fprintf(out, "var consts_%v = ", target.Arch)
-	serializer.Write(out, constArr)
-	fmt.Fprintf(out, "\n\n")
+	return constArr
+}
+
+var initArchs = map[string]func(target *prog.Target){
+	"akaros":  akaros.InitTarget,
+	"freebsd": freebsd.InitTarget,
+	"fuchsia": fuchsia.InitTarget,
+	"linux":   linux.InitTarget,
+	"netbsd":  netbsd.InitTarget,
+	"openbsd": openbsd.InitTarget,
+	"test":    test.InitTarget,
+	"trusty":  trusty.InitTarget,
+	"windows": windows.InitTarget,
+}
+
+// checkGeneration generates and serializes a program with each call of the compiled descriptions
+// (see prog.Target.CheckGeneration) and returns error messages for the calls that break.
+// It must be called last, because initialization of the target links the compiled types.
+func checkGeneration(target *targets.Target, prg *compiler.Prog, consts map[string]uint64) (errs []string) {
+	t := &prog.Target{
+		OS:         target.OS,
+		Arch:       target.Arch,
+		PtrSize:    target.PtrSize,
+		PageSize:   target.PageSize,
+		NumPages:   target.NumPages,
+		DataOffset: target.DataOffset,
+		Syscalls:   prg.Syscalls,
+		Resources:  prg.Resources,
+		Structs:    prg.StructDescs,
+		Consts:     constValues(consts),
+		Flags:      prg.Flags,
+	}
+	defer func() {
+		if err := recover(); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to initialize target: %v\n", err))
+		}
+	}()
+	prog.InitUnregisteredTarget(t, initArchs[target.OS])
+	failed := t.CheckGeneration(rand.NewSource(0))
+	var names []string
+	for name := range failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		errs = append(errs, fmt.Sprintf("call %v is broken: %v\n", name, failed[name]))
+	}
+	return errs
 }
 
 func writeEmpty(OS string) {
@@ -227,12 +516,27 @@ func generateExecutorSyscalls(target *targets.Target, syscalls []*prog.Syscall,
 	if target.ExecutorUsesShmem {
 		data.Shmem = 1
 	}
+	// Executor identifies atomic groups by numbers starting from 1 (0 means no group).
+	groups := make(map[string]int)
+	var groupNames []string
+	for _, c := range syscalls {
+		if c.Group != "" && groups[c.Group] == 0 {
+			groups[c.Group] = -1
+			groupNames = append(groupNames, c.Group)
+		}
+	}
+	sort.Strings(groupNames)
+	for i, name := range groupNames {
+		groups[name] = i + 1
+	}
 	for _, c := range syscalls {
 		data.Calls = append(data.Calls, SyscallData{
 			Name:     c.Name,
 			CallName: c.CallName,
 			NR:       int32(c.NR),
 			NeedCall: !target.SyscallNumbers || strings.HasPrefix(c.CallName, "syz_"),
+			Retries:  c.Retries,
+			Group:    groups[c.Group],
 		})
 	}
 	sort.Slice(data.Calls, func(i, j int) bool {
@@ -278,9 +582,33 @@ func writeFile(file string, data []byte) {
 	outf.Write(data)
 }
 
+// writeDiagnostics writes diagnostics collected so far to the -diagnostics file (if requested).
+// It's called on all exit paths, so that diagnostics are available even if generation fails.
+func writeDiagnostics() {
+	if *flagDiag == "" {
+		return
+	}
+	if diagnostics == nil {
+		diagnostics = []TargetDiagnostic{}
+	}
+	data, err := json.MarshalIndent(diagnostics, "", "\t")
+	if err == nil {
+		err = osutil.WriteFile(*flagDiag, data)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write diagnostics: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func exit(code int) {
+	writeDiagnostics()
+	os.Exit(code)
+}
+
 func failf(msg string, args ...interface{}) {
 	fmt.Fprintf(os.Stderr, msg+"\n", args...)
-	os.Exit(1)
+	exit(1)
 }
 
 var defsTempl = template.Must(template.New("").Parse(`// AUTOGENERATED FILE
@@ -308,7 +636,7 @@ var syscallsTempl = template.Must(template.New("").Parse(`// AUTOGENERATED FILE
 {{range $arch := $os.Archs}}
 #if GOARCH_{{$arch.GOARCH}}
 const call_t syscalls[] = {
-{{range $c := $arch.Calls}}	{"{{$c.Name}}", {{$c.NR}}{{if $c.NeedCall}}, (syscall_t){{$c.CallName}}{{end}}},
+{{range $c := $arch.Calls}}	{"{{$c.Name}}", {{$c.NR}}{{if $c.NeedCall}}, (syscall_t){{$c.CallName}}{{else if or $c.Retries $c.Group}}, 0{{end}}{{if or $c.Retries $c.Group}}, {{$c.Retries}}{{end}}{{if $c.Group}}, {{$c.Group}}{{end}}},
 {{end}}
 };
 #endif
@@ -30,6 +30,9 @@ type Target struct {
 	KernelHeaderArch string
 	// NeedSyscallDefine is used by csource package to decide when to emit __NR_* defines.
 	NeedSyscallDefine func(nr uint64) bool
+	// MaxArgSize is the maximum total size in bytes of data referenced by a single syscall argument
+	// (including pointees) that is enforced by the descriptions compiler, 0 means no limit.
+	MaxArgSize uint64
 }
 
 type osCommon struct {
@@ -404,6 +407,10 @@ func initTarget(target *Target, OS, arch string) {
 	}
 	target.DataOffset = 512 << 20
 	target.NumPages = (16 << 20) / target.PageSize
+	if target.MaxArgSize == 0 {
+		// Programs are passed to executor in a 2MB buffer (kMaxInput).
+		target.MaxArgSize = 2 << 20
+	}
 	if OS == "linux" && arch == runtime.GOARCH {
 		// Don't use cross-compiler for native compilation, there are cases when this does not work:
 		// https://github.com/google/syzkaller/pull/619
@@ -7,7 +7,7 @@ import . "github.com/google/syzkaller/prog"
 import . "github.com/google/syzkaller/sys/test"
 
 func init() {
-	RegisterTarget(&Target{OS: "test", Arch: "32_fork_shmem", Revision: revision_32_fork_shmem, PtrSize: 4, PageSize: 4096, NumPages: 4096, DataOffset: 536870912, Syscalls: syscalls_32_fork_shmem, Resources: resources_32_fork_shmem, Structs: structDescs_32_fork_shmem, Consts: consts_32_fork_shmem}, InitTarget)
+	RegisterTarget(&Target{OS: "test", Arch: "32_fork_shmem", Revision: revision_32_fork_shmem, PtrSize: 4, PageSize: 4096, NumPages: 4096, DataOffset: 536870912, Syscalls: syscalls_32_fork_shmem, Resources: resources_32_fork_shmem, Structs: structDescs_32_fork_shmem, Consts: consts_32_fork_shmem, Flags: flags_32_fork_shmem}, InitTarget)
 }
 
 var resources_32_fork_shmem = []*ResourceDesc(nil)
@@ -29,6 +29,8 @@ var structDescs_32_fork_shmem = []*KeyedStruct{
 		&StructType{Key: StructKey{Name: "syz_bf_struct1"}, FldName: "bf1"},
 		&StructType{Key: StructKey{Name: "syz_bf_struct2"}, FldName: "bf2"},
 		&StructType{Key: StructKey{Name: "syz_bf_struct3"}, FldName: "bf3"},
+		&StructType{Key: StructKey{Name: "syz_bf_struct4"}, FldName: "bf4"},
+		&StructType{Key: StructKey{Name: "syz_bf_struct5"}, FldName: "bf5"},
 		&BufferType{TypeCommon: TypeCommon{TypeName: "string", FldName: "str", IsVarlen: true}, Kind: 2},
 		&BufferType{TypeCommon: TypeCommon{TypeName: "array", FldName: "blob", IsVarlen: true}},
 		&ArrayType{TypeCommon: TypeCommon{TypeName: "array", FldName: "arr16be", IsVarlen: true}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16be", TypeSize: 2}, ArgFormat: 1}}},
@@ -70,6 +72,16 @@ var structDescs_32_fork_shmem = []*KeyedStruct{
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64be", FldName: "f3", TypeSize: 8}, ArgFormat: 1, BitfieldOff: 24, BitfieldLen: 20, BitfieldMdl: true}},
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64be", FldName: "f4", TypeSize: 8}, ArgFormat: 1, BitfieldOff: 44, BitfieldLen: 16}},
 	}}},
+	{Key: StructKey{Name: "syz_bf_struct4"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_bf_struct4", TypeSize: 4}, Fields: []Type{
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "syz_bf_flags", FldName: "mode", TypeSize: 4}, BitfieldLen: 3, BitfieldMdl: true}, Vals: []uint64{0, 1, 2}, BitMask: true},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "count", TypeSize: 4}, BitfieldOff: 3, BitfieldLen: 5, BitfieldMdl: true}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "syz_bf_flags24", FldName: "region", TypeSize: 4}, BitfieldOff: 8, BitfieldLen: 24}, Vals: []uint64{1, 256, 8388608}, BitMask: true},
+	}}},
+	{Key: StructKey{Name: "syz_bf_struct5"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_bf_struct5", TypeSize: 4}, Fields: []Type{
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "syz_bf_flags", FldName: "mode", TypeSize: 4}, ArgFormat: 1, BitfieldLen: 3, BitfieldMdl: true}, Vals: []uint64{0, 1, 2}, BitMask: true},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32be", FldName: "count", TypeSize: 4}, ArgFormat: 1, BitfieldOff: 3, BitfieldLen: 5, BitfieldMdl: true}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "syz_bf_flags24", FldName: "region", TypeSize: 4}, ArgFormat: 1, BitfieldOff: 8, BitfieldLen: 24}, Vals: []uint64{1, 256, 8388608}, BitMask: true},
+	}}},
 }
 
 var syscalls_32_fork_shmem = []*Syscall{
@@ -119,4 +131,6 @@ var consts_32_fork_shmem = []ConstValue{
 	{Name: "ONLY_32BITS_CONST", Value: 1},
 }
 
-const revision_32_fork_shmem = "002e760d34d155bf4b1506dd1876d07e80626bca"
+var flags_32_fork_shmem = []FlagDesc(nil)
+
+const revision_32_fork_shmem = "0e55b61e6b29a351deb0ac19dfe25e8737223ff2"
@@ -7,16 +7,32 @@ import . "github.com/google/syzkaller/prog"
 import . "github.com/google/syzkaller/sys/test"
 
 func init() {
-	RegisterTarget(&Target{OS: "test", Arch: "64", Revision: revision_64, PtrSize: 8, PageSize: 4096, NumPages: 4096, DataOffset: 536870912, Syscalls: syscalls_64, Resources: resources_64, Structs: structDescs_64, Consts: consts_64}, InitTarget)
+	RegisterTarget(&Target{OS: "test", Arch: "64", Revision: revision_64, PtrSize: 8, PageSize: 4096, NumPages: 4096, DataOffset: 536870912, Syscalls: syscalls_64, Resources: resources_64, Structs: structDescs_64, Consts: consts_64, Flags: flags_64}, InitTarget)
 }
 
 var resources_64 = []*ResourceDesc{
 	{Name: "anyres32", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}, Kind: []string{"anyres32"}, Values: []uint64{0}},
 	{Name: "anyres64", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", TypeSize: 8}}}, Kind: []string{"anyres64"}, Values: []uint64{0}},
+	{Name: "cq_result", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}, Kind: []string{"cq_result"}, Values: []uint64{0}},
+	{Name: "cq_ring", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}, Kind: []string{"cq_ring"}, Values: []uint64{0}},
 	{Name: "fd", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}, Kind: []string{"fd"}, Values: []uint64{18446744073709551615, 999}},
 	{Name: "r_any", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}, Kind: []string{"r_any"}, Values: []uint64{0}},
+	{Name: "syz_be_res", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32be", TypeSize: 4}, ArgFormat: 1}}, Kind: []string{"syz_be_res"}, Values: []uint64{4660}},
+	{Name: "syz_compat0", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}, Kind: []string{"syz_compat0"}, Values: []uint64{0}, Compatible: []string{"syz_compat1"}},
+	{Name: "syz_compat1", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}, Kind: []string{"syz_compat1"}, Values: []uint64{0}, Compatible: []string{"syz_compat0"}},
+	{Name: "syz_derived_res", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}, Kind: []string{"syz_derived_res"}, Values: []uint64{0}},
+	{Name: "syz_event", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}, Kind: []string{"syz_event"}, Values: []uint64{0}, Event: true},
+	{Name: "syz_inv", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}, Kind: []string{"syz_inv"}, Values: []uint64{0}},
+	{Name: "syz_inv_sub", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}, Kind: []string{"syz_inv", "syz_inv_sub"}, Values: []uint64{0}},
 	{Name: "syz_missing_const_res", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}, Kind: []string{"syz_missing_const_res"}, Values: []uint64{0}},
+	{Name: "syz_ns_obj", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}, Kind: []string{"syz_ns_obj"}, Values: []uint64{0}, Namespace: "syz_ns"},
+	{Name: "syz_obj", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}, Kind: []string{"syz_obj"}, Values: []uint64{0}},
+	{Name: "syz_obj_dir", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}, Kind: []string{"syz_obj", "syz_obj_dir"}, Values: []uint64{0}},
+	{Name: "syz_obj_file", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}, Kind: []string{"syz_obj", "syz_obj_file"}, Values: []uint64{0}},
+	{Name: "syz_refcnt", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}, Kind: []string{"syz_refcnt"}, Values: []uint64{0}, Refcounted: true},
 	{Name: "syz_res", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}, Kind: []string{"syz_res"}, Values: []uint64{65535}},
+	{Name: "syz_slot", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", TypeSize: 1}}, Kind: 2, RangeEnd: 15}, Kind: []string{"syz_slot"}, Values: []uint64{255}, HasRange: true, RangeEnd: 15},
+	{Name: "syz_txn_obj", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}, Kind: []string{"syz_txn_obj"}, Values: []uint64{0}, Scope: "syz_txn"},
 	{Name: "unsupported", Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}, Kind: []string{"unsupported"}, Values: []uint64{0}},
 }
 
@@ -70,19 +86,53 @@ var structDescs_64 = []*KeyedStruct{
 		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "f1", TypeSize: 4}}, Val: 67},
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "f2", TypeSize: 4}}},
 	}}},
+	{Key: StructKey{Name: "child_pid_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "child_pid_struct", IsVarlen: true}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "f0", TypeSize: 4}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "child_pid", FldName: "f1", TypeSize: 4}}, Kind: 8},
+		&ArrayType{TypeCommon: TypeCommon{TypeName: "array", FldName: "f2", IsVarlen: true}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}},
+	}}},
+	{Key: StructKey{Name: "choice[fd, intptr, ptr[in, int64]]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "choice[fd, intptr, ptr[in, int64]]", IsVarlen: true}, Fields: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "fd", FldName: "fd", TypeSize: 4}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", FldName: "intptr", TypeSize: 8}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "ptr", TypeSize: 8}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", TypeSize: 8}}}},
+	}}},
+	{Key: StructKey{Name: "choice[int8, syz_res, ptr[out, syz_res]]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "choice[int8, syz_res, ptr[out, syz_res]]", IsVarlen: true}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "int8", TypeSize: 1}}},
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_res", FldName: "syz_res", TypeSize: 4}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "ptr", TypeSize: 8}, Type: &ResourceType{TypeCommon: TypeCommon{TypeName: "syz_res", TypeSize: 4, ArgDir: 1}}},
+	}}},
 	{Key: StructKey{Name: "compare_data"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "compare_data", IsVarlen: true}, Fields: []Type{
 		&StructType{Key: StructKey{Name: "align0"}, FldName: "align0"},
 		&StructType{Key: StructKey{Name: "syz_bf_struct0"}, FldName: "bf0"},
 		&StructType{Key: StructKey{Name: "syz_bf_struct1"}, FldName: "bf1"},
 		&StructType{Key: StructKey{Name: "syz_bf_struct2"}, FldName: "bf2"},
 		&StructType{Key: StructKey{Name: "syz_bf_struct3"}, FldName: "bf3"},
+		&StructType{Key: StructKey{Name: "syz_bf_struct4"}, FldName: "bf4"},
+		&StructType{Key: StructKey{Name: "syz_bf_struct5"}, FldName: "bf5"},
 		&BufferType{TypeCommon: TypeCommon{TypeName: "string", FldName: "str", IsVarlen: true}, Kind: 2},
 		&BufferType{TypeCommon: TypeCommon{TypeName: "array", FldName: "blob", IsVarlen: true}},
 		&ArrayType{TypeCommon: TypeCommon{TypeName: "array", FldName: "arr16be", IsVarlen: true}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16be", TypeSize: 2}, ArgFormat: 1}}},
 	}}},
+	{Key: StructKey{Name: "cq_cookie_entry", Dir: 1}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "cq_cookie_entry", TypeSize: 16, ArgDir: 1}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "res", TypeSize: 4, ArgDir: 1}}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 4}}, IsPad: true},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", FldName: "user_data", TypeSize: 8, ArgDir: 1}}},
+	}}},
+	{Key: StructKey{Name: "cq_entry", Dir: 1}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "cq_entry", TypeSize: 8, ArgDir: 1}, Fields: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "cq_result", FldName: "res", TypeSize: 4, ArgDir: 1}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "flags", TypeSize: 4, ArgDir: 1}}},
+	}}},
+	{Key: StructKey{Name: "cq_request"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "cq_request", TypeSize: 16}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "op", TypeSize: 4}}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 4}}, IsPad: true},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", FldName: "user_data", TypeSize: 8}}, Cookie: "cq_cookie_entry.user_data"},
+	}}},
 	{Key: StructKey{Name: "excessive_fields"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "excessive_fields", TypeSize: 1}, Fields: []Type{
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "f1", TypeSize: 1}}},
 	}}},
+	{Key: StructKey{Name: "exhaustive_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "exhaustive_struct", TypeSize: 1}, Fields: []Type{
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "exhaustive_flags", FldName: "f0", TypeSize: 1}}, Vals: []uint64{1, 2, 3, 4, 5}, Exhaustive: true},
+	}}},
 	{Key: StructKey{Name: "explicitly_sized"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "explicitly_sized", TypeSize: 42}, Fields: []Type{
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "f1", TypeSize: 1}}},
 		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 41}}, IsPad: true},
@@ -90,6 +140,39 @@ var structDescs_64 = []*KeyedStruct{
 	{Key: StructKey{Name: "explicitly_sized_union"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "explicitly_sized_union", TypeSize: 42}, Fields: []Type{
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "f1", TypeSize: 1}}},
 	}}},
+	{Key: StructKey{Name: "funcptr_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "funcptr_struct", TypeSize: 16}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "funcptr", FldName: "f0", TypeSize: 8}}, Kind: 5},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "funcptr64", FldName: "f1", TypeSize: 8}}, Kind: 5},
+	}}},
+	{Key: StructKey{Name: "guard_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "guard_struct", TypeSize: 16}, Fields: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "f0", TypeSize: 8}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", TypeSize: 5}, Kind: 1, RangeBegin: 5, RangeEnd: 5}, Guard: true},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "f1", TypeSize: 8}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4, ArgDir: 1}}}},
+	}}},
+	{Key: StructKey{Name: "hook_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "hook_struct", IsVarlen: true}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "f0", TypeSize: 4}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize", FldName: "f1", TypeSize: 4}}, BitSize: 8, Buf: "f2"},
+		&BufferType{TypeCommon: TypeCommon{TypeName: "array", FldName: "f2", IsVarlen: true}},
+	}}},
+	{Key: StructKey{Name: "int_buckets_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "int_buckets_struct", TypeSize: 4}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16", FldName: "f0", TypeSize: 2}}, Buckets: []IntBucket{
+			{Weight: 1},
+			{Begin: 65535, End: 65535, Weight: 3},
+		}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "f1", TypeSize: 1}, BitfieldLen: 3}, Buckets: []IntBucket{
+			{Begin: 7, End: 7, Weight: 1},
+		}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 1}}, IsPad: true},
+	}}},
+	{Key: StructKey{Name: "int_relations"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "int_relations", TypeSize: 16}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "lo", TypeSize: 1}}, Relation: 2, RelationField: "hi"},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "hi", TypeSize: 1}}, Kind: 2, RangeEnd: 50},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 2}}, IsPad: true},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "x", TypeSize: 4}}, Kind: 2, RangeEnd: 10, Relation: 3, RelationField: "y"},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "y", TypeSize: 4}}, Kind: 2, RangeBegin: 5, RangeEnd: 20},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16", FldName: "z", TypeSize: 2}, BitfieldLen: 3, BitfieldMdl: true}, Relation: 4, RelationField: "w"},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16", FldName: "w", TypeSize: 2}, BitfieldOff: 3, BitfieldLen: 3}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 2}}, IsPad: true},
+	}}},
 	{Key: StructKey{Name: "len_nontemp4"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "len_nontemp4", TypeSize: 4}, Fields: []Type{
 		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "f1", TypeSize: 4}}, Buf: "len_temp3"},
 	}}},
@@ -122,10 +205,114 @@ var structDescs_64 = []*KeyedStruct{
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "f2", TypeSize: 1}}},
 		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 3}}, IsPad: true},
 	}}},
+	{Key: StructKey{Name: "mutate_weight_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "mutate_weight_struct", TypeSize: 12}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "pinned", TypeSize: 4, MutateWeight: 18446744073709551615}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "hot", TypeSize: 4, MutateWeight: 20}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "cold", TypeSize: 4}}},
+	}}},
+	{Key: StructKey{Name: "nlattr_body_t[const[1, int16], int32]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "nlattr_body_t[const[1, int16], int32]", TypeSize: 6}, Fields: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "nla_type", TypeSize: 2}}, Val: 1},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "payload", TypeSize: 4}}},
+	}}},
+	{Key: StructKey{Name: "nlattr_body_t[const[1, int16], string]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "nlattr_body_t[const[1, int16], string]", IsVarlen: true}, Fields: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "nla_type", TypeSize: 2}}, Val: 1},
+		&BufferType{TypeCommon: TypeCommon{TypeName: "string", FldName: "payload", IsVarlen: true}, Kind: 2},
+	}}},
+	{Key: StructKey{Name: "nlattr_body_t[const[3, int16], string]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "nlattr_body_t[const[3, int16], string]", IsVarlen: true}, Fields: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "nla_type", TypeSize: 2}}, Val: 3},
+		&BufferType{TypeCommon: TypeCommon{TypeName: "string", FldName: "payload", IsVarlen: true}, Kind: 2},
+	}}},
+	{Key: StructKey{Name: "nlattr_body_t[const[4, int16], int32]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "nlattr_body_t[const[4, int16], int32]", TypeSize: 6}, Fields: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "nla_type", TypeSize: 2}}, Val: 4},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "payload", TypeSize: 4}}},
+	}}},
+	{Key: StructKey{Name: "nlattr_body_t[const[5, int16], int8]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "nlattr_body_t[const[5, int16], int8]", TypeSize: 3}, Fields: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "nla_type", TypeSize: 2}}, Val: 5},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "payload", TypeSize: 1}}},
+	}}},
+	{Key: StructKey{Name: "nlattr_body_t[const[7, int16], int32be]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "nlattr_body_t[const[7, int16], int32be]", TypeSize: 6}, Fields: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "nla_type", TypeSize: 2}}, Val: 7},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32be", FldName: "payload", TypeSize: 4}, ArgFormat: 1}},
+	}}},
+	{Key: StructKey{Name: "nlattr_nested_body_t[const[18, int16:14], array[test_nlattr_linkinfo]]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "nlattr_nested_body_t[const[18, int16:14], array[test_nlattr_linkinfo]]", IsVarlen: true}, Fields: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "nla_type", TypeSize: 2}, BitfieldLen: 14, BitfieldMdl: true}, Val: 18},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "NLA_F_NET_BYTEORDER", TypeSize: 2}, BitfieldOff: 14, BitfieldLen: 1, BitfieldMdl: true}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "NLA_F_NESTED", TypeSize: 2}, BitfieldOff: 15, BitfieldLen: 1}, Val: 1},
+		&ArrayType{TypeCommon: TypeCommon{TypeName: "array", FldName: "payload", IsVarlen: true}, Type: &UnionType{Key: StructKey{Name: "test_nlattr_linkinfo"}}},
+	}}},
+	{Key: StructKey{Name: "nlattr_nested_body_t[const[2, int16:14], array[nlattr[7, int32be]]]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "nlattr_nested_body_t[const[2, int16:14], array[nlattr[7, int32be]]]", IsVarlen: true}, Fields: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "nla_type", TypeSize: 2}, BitfieldLen: 14, BitfieldMdl: true}, Val: 2},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "NLA_F_NET_BYTEORDER", TypeSize: 2}, BitfieldOff: 14, BitfieldLen: 1, BitfieldMdl: true}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "NLA_F_NESTED", TypeSize: 2}, BitfieldOff: 15, BitfieldLen: 1}, Val: 1},
+		&ArrayType{TypeCommon: TypeCommon{TypeName: "array", FldName: "payload", IsVarlen: true}, Type: &StructType{Key: StructKey{Name: "nlattr_t[const[7, int16], int32be]"}}},
+	}}},
+	{Key: StructKey{Name: "nlattr_nested_t[const[18, int16:14], array[test_nlattr_linkinfo]]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "nlattr_nested_t[const[18, int16:14], array[test_nlattr_linkinfo]]", IsVarlen: true}, Fields: []Type{
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize_inclusive", FldName: "nla_len", TypeSize: 2}}, BitSize: 8, Buf: "body", Inclusive: true},
+		&StructType{Key: StructKey{Name: "nlattr_nested_body_t[const[18, int16:14], array[test_nlattr_linkinfo]]"}, FldName: "body"},
+	}, AlignAttr: 4}},
+	{Key: StructKey{Name: "nlattr_nested_t[const[2, int16:14], array[nlattr[7, int32be]]]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "nlattr_nested_t[const[2, int16:14], array[nlattr[7, int32be]]]", IsVarlen: true}, Fields: []Type{
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize_inclusive", FldName: "nla_len", TypeSize: 2}}, BitSize: 8, Buf: "body", Inclusive: true},
+		&StructType{Key: StructKey{Name: "nlattr_nested_body_t[const[2, int16:14], array[nlattr[7, int32be]]]"}, FldName: "body"},
+	}, AlignAttr: 4}},
+	{Key: StructKey{Name: "nlattr_t[const[1, int16], int32]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "nlattr_t[const[1, int16], int32]", TypeSize: 8}, Fields: []Type{
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize_inclusive", FldName: "nla_len", TypeSize: 2}}, BitSize: 8, Buf: "body", Inclusive: true},
+		&StructType{Key: StructKey{Name: "nlattr_body_t[const[1, int16], int32]"}, FldName: "body"},
+	}, AlignAttr: 4}},
+	{Key: StructKey{Name: "nlattr_t[const[1, int16], string]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "nlattr_t[const[1, int16], string]", IsVarlen: true}, Fields: []Type{
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize_inclusive", FldName: "nla_len", TypeSize: 2}}, BitSize: 8, Buf: "body", Inclusive: true},
+		&StructType{Key: StructKey{Name: "nlattr_body_t[const[1, int16], string]"}, FldName: "body"},
+	}, AlignAttr: 4}},
+	{Key: StructKey{Name: "nlattr_t[const[3, int16], string]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "nlattr_t[const[3, int16], string]", IsVarlen: true}, Fields: []Type{
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize_inclusive", FldName: "nla_len", TypeSize: 2}}, BitSize: 8, Buf: "body", Inclusive: true},
+		&StructType{Key: StructKey{Name: "nlattr_body_t[const[3, int16], string]"}, FldName: "body"},
+	}, AlignAttr: 4}},
+	{Key: StructKey{Name: "nlattr_t[const[4, int16], int32]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "nlattr_t[const[4, int16], int32]", TypeSize: 8}, Fields: []Type{
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize_inclusive", FldName: "nla_len", TypeSize: 2}}, BitSize: 8, Buf: "body", Inclusive: true},
+		&StructType{Key: StructKey{Name: "nlattr_body_t[const[4, int16], int32]"}, FldName: "body"},
+	}, AlignAttr: 4}},
+	{Key: StructKey{Name: "nlattr_t[const[5, int16], int8]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "nlattr_t[const[5, int16], int8]", TypeSize: 8}, Fields: []Type{
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize_inclusive", FldName: "nla_len", TypeSize: 2}}, BitSize: 8, Buf: "body", Inclusive: true},
+		&StructType{Key: StructKey{Name: "nlattr_body_t[const[5, int16], int8]"}, FldName: "body"},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 3}}, IsPad: true},
+	}, AlignAttr: 4}},
+	{Key: StructKey{Name: "nlattr_t[const[7, int16], int32be]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "nlattr_t[const[7, int16], int32be]", TypeSize: 8}, Fields: []Type{
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize_inclusive", FldName: "nla_len", TypeSize: 2}}, BitSize: 8, Buf: "body", Inclusive: true},
+		&StructType{Key: StructKey{Name: "nlattr_body_t[const[7, int16], int32be]"}, FldName: "body"},
+	}, AlignAttr: 4}},
+	{Key: StructKey{Name: "overlap_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "overlap_struct", TypeSize: 16}, Fields: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "f0", TypeSize: 8}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", TypeSize: 8, ArgDir: 1}}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "f1", TypeSize: 8}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}, OverlapField: "f0"},
+	}}},
+	{Key: StructKey{Name: "relptr_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "relptr_struct", IsVarlen: true}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "hdr", TypeSize: 4}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "relptr", FldName: "fwd", TypeSize: 4}}, Kind: 6, RelPtr: "data"},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "relptr", FldName: "back", TypeSize: 2}}, Kind: 6, RelPtr: "hdr", RelSelf: true},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "relptr", FldName: "rel", TypeSize: 1}}, Kind: 6, RelPtr: "data", RelSelf: true},
+		&BufferType{TypeCommon: TypeCommon{TypeName: "array", FldName: "data", IsVarlen: true}},
+	}}},
+	{Key: StructKey{Name: "ring_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "ring_struct", IsVarlen: true}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "ringhead", FldName: "head", TypeSize: 4}}, Kind: 3, Ring: "ring"},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "ringtail", FldName: "tail", TypeSize: 4}}, Kind: 4, Ring: "ring"},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "ringhead", FldName: "small_head", TypeSize: 1}}, Kind: 3, Ring: "small_ring"},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "ringtail", FldName: "small_tail", TypeSize: 1}}, Kind: 4, Ring: "small_ring"},
+		&BufferType{TypeCommon: TypeCommon{TypeName: "array", FldName: "small_ring", TypeSize: 4}, Kind: 1, RangeBegin: 4, RangeEnd: 4},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 2}}, IsPad: true},
+		&ArrayType{TypeCommon: TypeCommon{TypeName: "array", FldName: "ring", IsVarlen: true}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}, Kind: 1, RangeEnd: 8},
+	}}},
 	{Key: StructKey{Name: "serialize0_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "serialize0_struct", TypeSize: 15}, Fields: []Type{
 		&BufferType{TypeCommon: TypeCommon{TypeName: "string", FldName: "a", TypeSize: 10}, Kind: 2, SubKind: "serialize_strings", Values: []string{"aaa\x00\x00\x00\x00\x00\x00\x00", "bbb\x00\x00\x00\x00\x00\x00\x00"}},
 		&BufferType{TypeCommon: TypeCommon{TypeName: "string", FldName: "b", TypeSize: 5}, Kind: 2, SubKind: "serialize_strings", Values: []string{"aaa\x00\x00", "bbb\x00\x00"}},
 	}}},
+	{Key: StructKey{Name: "sparse[int32[0:15], int64]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "sparse[int32[0:15], int64]", TypeSize: 16}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "index", TypeSize: 4}}, Kind: 2, RangeEnd: 15},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 4}}, IsPad: true},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", FldName: "value", TypeSize: 8}}},
+	}}},
+	{Key: StructKey{Name: "sparse[int8[0:3], int16]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "sparse[int8[0:3], int16]", TypeSize: 4}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "index", TypeSize: 1}}, Kind: 2, RangeEnd: 3},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 1}}, IsPad: true},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16", FldName: "value", TypeSize: 2}}},
+	}}},
 	{Key: StructKey{Name: "static_filename"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "static_filename", TypeSize: 33}, Fields: []Type{
 		&BufferType{TypeCommon: TypeCommon{TypeName: "filename", FldName: "f1", TypeSize: 10}, Kind: 3},
 		&BufferType{TypeCommon: TypeCommon{TypeName: "filename", FldName: "f2", TypeSize: 20}, Kind: 3},
@@ -234,6 +421,16 @@ var structDescs_64 = []*KeyedStruct{
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16", FldName: "f0", TypeSize: 2}}},
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", FldName: "f1", TypeSize: 8}}},
 	}}},
+	{Key: StructKey{Name: "syz_be_res_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_be_res_struct", TypeSize: 8}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "f0", TypeSize: 1}}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 3}}, IsPad: true},
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_be_res", FldName: "f1", TypeSize: 4}, ArgFormat: 1},
+	}}},
+	{Key: StructKey{Name: "syz_be_res_struct", Dir: 1}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_be_res_struct", TypeSize: 8, ArgDir: 1}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "f0", TypeSize: 1, ArgDir: 1}}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 3}}, IsPad: true},
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_be_res", FldName: "f1", TypeSize: 4, ArgDir: 1}, ArgFormat: 1},
+	}}},
 	{Key: StructKey{Name: "syz_bf_struct0"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_bf_struct0", TypeSize: 32}, Fields: []Type{
 		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "syz_bf_flags", FldName: "f0", TypeSize: 2}, BitfieldLen: 10}, Vals: []uint64{0, 1, 2}, BitMask: true},
 		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 6}}, IsPad: true},
@@ -271,6 +468,28 @@ var structDescs_64 = []*KeyedStruct{
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64be", FldName: "f3", TypeSize: 8}, ArgFormat: 1, BitfieldOff: 24, BitfieldLen: 20, BitfieldMdl: true}},
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64be", FldName: "f4", TypeSize: 8}, ArgFormat: 1, BitfieldOff: 44, BitfieldLen: 16}},
 	}}},
+	{Key: StructKey{Name: "syz_bf_struct4"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_bf_struct4", TypeSize: 4}, Fields: []Type{
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "syz_bf_flags", FldName: "mode", TypeSize: 4}, BitfieldLen: 3, BitfieldMdl: true}, Vals: []uint64{0, 1, 2}, BitMask: true},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "count", TypeSize: 4}, BitfieldOff: 3, BitfieldLen: 5, BitfieldMdl: true}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "syz_bf_flags24", FldName: "region", TypeSize: 4}, BitfieldOff: 8, BitfieldLen: 24}, Vals: []uint64{1, 256, 8388608}, BitMask: true},
+	}}},
+	{Key: StructKey{Name: "syz_bf_struct5"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_bf_struct5", TypeSize: 4}, Fields: []Type{
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "syz_bf_flags", FldName: "mode", TypeSize: 4}, ArgFormat: 1, BitfieldLen: 3, BitfieldMdl: true}, Vals: []uint64{0, 1, 2}, BitMask: true},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32be", FldName: "count", TypeSize: 4}, ArgFormat: 1, BitfieldOff: 3, BitfieldLen: 5, BitfieldMdl: true}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "syz_bf_flags24", FldName: "region", TypeSize: 4}, ArgFormat: 1, BitfieldOff: 8, BitfieldLen: 24}, Vals: []uint64{1, 256, 8388608}, BitMask: true},
+	}}},
+	{Key: StructKey{Name: "syz_bf_struct6"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_bf_struct6", IsVarlen: true}, Fields: []Type{
+		&StructType{Key: StructKey{Name: "syz_bf_struct4"}, FldName: "f0"},
+		&StructType{Key: StructKey{Name: "syz_bf_struct5"}, FldName: "f1"},
+		&ProcType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "proc", FldName: "f2", TypeSize: 2}, BitfieldLen: 8}, ValuesStart: 16, ValuesPerProc: 4},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16be", FldName: "f3", TypeSize: 2}, ArgFormat: 1, BitfieldLen: 8}},
+		&BufferType{TypeCommon: TypeCommon{TypeName: "array", FldName: "f4", IsVarlen: true}},
+	}}},
+	{Key: StructKey{Name: "syz_csum_crc32_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_csum_crc32_struct", TypeSize: 12}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "f0", TypeSize: 4}}},
+		&CsumType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "csum", FldName: "csum", TypeSize: 4}}, Kind: 2, Buf: "parent"},
+		&BufferType{TypeCommon: TypeCommon{TypeName: "array", FldName: "f1", TypeSize: 4}, Kind: 1, RangeBegin: 4, RangeEnd: 4},
+	}}},
 	{Key: StructKey{Name: "syz_csum_encode"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_csum_encode", IsVarlen: true}, Fields: []Type{
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16", FldName: "f0", TypeSize: 2}}},
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16be", FldName: "f1", TypeSize: 2}, ArgFormat: 1}},
@@ -323,6 +542,26 @@ var structDescs_64 = []*KeyedStruct{
 		&CsumType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "csum", FldName: "csum", TypeSize: 2}}, Kind: 1, Buf: "parent", Protocol: 17},
 		&BufferType{TypeCommon: TypeCommon{TypeName: "array", FldName: "payload", IsVarlen: true}},
 	}}},
+	{Key: StructKey{Name: "syz_csum_xor_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_csum_xor_struct", IsVarlen: true}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16", FldName: "f0", TypeSize: 2}}},
+		&CsumType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "csum", FldName: "csum", TypeSize: 2}}, Kind: 3, Buf: "data"},
+		&BufferType{TypeCommon: TypeCommon{TypeName: "array", FldName: "data", IsVarlen: true}, Kind: 1, RangeEnd: 8},
+	}}},
+	{Key: StructKey{Name: "syz_end_bom_inner"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_end_bom_inner", TypeSize: 12}, Fields: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "f0", TypeSize: 4}}, Val: 66},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "syz_end_flags", FldName: "f1", TypeSize: 8}}, Vals: []uint64{0, 1}, BitMask: true},
+	}}},
+	{Key: StructKey{Name: "syz_end_bom_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_end_bom_struct", TypeSize: 30}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16", FldName: "f0", TypeSize: 2}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16", FldName: "bom", TypeSize: 2}}, Buckets: []IntBucket{
+			{Begin: 65279, End: 65279, Weight: 1},
+			{Begin: 65534, End: 65534, Weight: 1},
+		}, ByteOrderMark: true, BigEndianMark: 65279},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "f1", TypeSize: 2}}, Buf: "parent"},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32be", FldName: "f2", TypeSize: 4}, ArgFormat: 1}},
+		&StructType{Key: StructKey{Name: "syz_end_bom_inner"}, FldName: "f3"},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "f4", TypeSize: 8}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}},
+	}}},
 	{Key: StructKey{Name: "syz_end_int_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_end_int_struct", TypeSize: 15}, Fields: []Type{
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "f0", TypeSize: 1}}},
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16be", FldName: "f1", TypeSize: 2}, ArgFormat: 1}},
@@ -334,6 +573,48 @@ var structDescs_64 = []*KeyedStruct{
 		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "f1", TypeSize: 4}, ArgFormat: 1}, Val: 66},
 		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "syz_end_flags", FldName: "f2", TypeSize: 8}, ArgFormat: 1}, Vals: []uint64{0, 1}, BitMask: true},
 	}}},
+	{Key: StructKey{Name: "syz_flagindex_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_flagindex_struct", TypeSize: 4}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "flagindex", FldName: "f0", TypeSize: 2}}, Kind: 7, Flags: "syz_flagindex_flags", FlagIndices: []uint64{1, 3, 8}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "flagindex", FldName: "f1", TypeSize: 1}, BitfieldLen: 3, BitfieldMdl: true}, Kind: 7, Flags: "syz_flagindex_small_flags", FlagIndices: []uint64{0, 5}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "f2", TypeSize: 1}, BitfieldOff: 3, BitfieldLen: 5}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 1}}, IsPad: true},
+	}}},
+	{Key: StructKey{Name: "syz_footer_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_footer_struct", IsVarlen: true}, Fields: []Type{
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize", FldName: "f0", TypeSize: 4}}, BitSize: 8, Buf: "parent"},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize_inclusive", FldName: "f1", TypeSize: 2}}, BitSize: 8, Buf: "f2", Inclusive: true},
+		&BufferType{TypeCommon: TypeCommon{TypeName: "array", FldName: "f2", IsVarlen: true}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "footer", FldName: "f3", TypeSize: 4}}, Val: 4277009102, IsFooter: true},
+	}}},
+	{Key: StructKey{Name: "syz_format_arg"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_format_arg", TypeSize: 8}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", FldName: "d", TypeSize: 8}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", FldName: "x", TypeSize: 8}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr64", FldName: "s", TypeSize: 8}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "string", IsVarlen: true}, Kind: 2}},
+	}, Specifiers: []string{"%lld", "%08llx", "%.16s"}}},
+	{Key: StructKey{Name: "syz_hash_add_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_hash_add_struct", IsVarlen: true}, Fields: []Type{
+		&CsumType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "hash", FldName: "hash", TypeSize: 2}}, Kind: 5, Buf: "data"},
+		&BufferType{TypeCommon: TypeCommon{TypeName: "array", FldName: "data", IsVarlen: true}, Kind: 1, RangeEnd: 8},
+	}}},
+	{Key: StructKey{Name: "syz_hash_fnv1a_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_hash_fnv1a_struct", TypeSize: 16}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "f0", TypeSize: 4}}},
+		&CsumType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "hash", FldName: "hash", TypeSize: 8}}, Kind: 4, Buf: "parent"},
+		&BufferType{TypeCommon: TypeCommon{TypeName: "array", FldName: "f1", TypeSize: 4}, Kind: 1, RangeBegin: 4, RangeEnd: 4},
+	}}},
+	{Key: StructKey{Name: "syz_indexed_bits"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_indexed_bits", TypeSize: 1}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "arrayindex", FldName: "idx", TypeSize: 1}, BitfieldLen: 2, BitfieldMdl: true}, Kind: 10},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "f0", TypeSize: 1}, BitfieldOff: 2, BitfieldLen: 6}},
+	}}},
+	{Key: StructKey{Name: "syz_indexed_record"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_indexed_record", TypeSize: 8}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "f0", TypeSize: 4}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "arrayindex", FldName: "idx", TypeSize: 2}}, Kind: 10},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16", FldName: "f1", TypeSize: 2}}},
+	}}},
+	{Key: StructKey{Name: "syz_int_pow2_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_int_pow2_struct", TypeSize: 8}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int_pow2", FldName: "f0", TypeSize: 2}}, Kind: 9, RangeBegin: 8, RangeEnd: 8},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int_pow2", FldName: "f1", TypeSize: 1}, BitfieldLen: 7, BitfieldMdl: true}, Kind: 9, RangeBegin: 1, RangeEnd: 64},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "f2", TypeSize: 1}, BitfieldOff: 7, BitfieldLen: 1}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 1}}, IsPad: true},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int_pow2", FldName: "f3", TypeSize: 4}, ArgFormat: 1}, Kind: 9, RangeBegin: 4096, RangeEnd: 2147483648},
+	}}},
 	{Key: StructKey{Name: "syz_length_array2_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_length_array2_struct", TypeSize: 10}, Fields: []Type{
 		&ArrayType{TypeCommon: TypeCommon{TypeName: "array", FldName: "f0", TypeSize: 8}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16", TypeSize: 2}}}, Kind: 1, RangeBegin: 4, RangeEnd: 4},
 		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize", FldName: "f1", TypeSize: 2}}, BitSize: 8, Buf: "f0"},
@@ -401,10 +682,37 @@ var structDescs_64 = []*KeyedStruct{
 		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "f0", TypeSize: 4}}},
 		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "f1", TypeSize: 4}}, Buf: "f0"},
 	}}},
+	{Key: StructKey{Name: "syz_length_dims_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_length_dims_struct", IsVarlen: true}, Fields: []Type{
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "h", TypeSize: 1}}, Buf: "pixels"},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "w", TypeSize: 1}}, Buf: "pixels", Dim: 1},
+		&ArrayType{TypeCommon: TypeCommon{TypeName: "array", FldName: "pixels", IsVarlen: true}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}, Kind: 1, RangeBegin: 1, RangeEnd: 4}, Kind: 1, RangeBegin: 1, RangeEnd: 4},
+	}}},
+	{Key: StructKey{Name: "syz_length_expr_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_length_expr_struct", IsVarlen: true}, Fields: []Type{
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize", FldName: "f0", TypeSize: 1}}, BitSize: 8, Expr: &LenExpr{Op: 2, Args: []*LenExpr{
+			{Buf: "f2"},
+			{Buf: "f3"},
+		}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "f1", TypeSize: 1}}, Expr: &LenExpr{Op: 3, Args: []*LenExpr{
+			{Buf: "f2"},
+			{Op: 1, Val: 2},
+		}}},
+		&BufferType{TypeCommon: TypeCommon{TypeName: "array", FldName: "f2", IsVarlen: true}},
+		&BufferType{TypeCommon: TypeCommon{TypeName: "array", FldName: "f3", IsVarlen: true}},
+	}}},
 	{Key: StructKey{Name: "syz_length_flags_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_length_flags_struct", TypeSize: 16}, Fields: []Type{
 		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "syz_length_flags", FldName: "f0", TypeSize: 8}}, Vals: []uint64{0, 1}, BitMask: true},
 		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "f1", TypeSize: 8}}, Buf: "f0"},
 	}}},
+	{Key: StructKey{Name: "syz_length_inclusive_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_length_inclusive_struct", IsVarlen: true}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "f0", TypeSize: 1}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize_inclusive", FldName: "f1", TypeSize: 2}}, BitSize: 8, Buf: "f2", Inclusive: true},
+		&BufferType{TypeCommon: TypeCommon{TypeName: "array", FldName: "f2", IsVarlen: true}},
+	}}},
+	{Key: StructKey{Name: "syz_length_init_struct", Dir: 1}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_length_init_struct", TypeSize: 16, ArgDir: 1}, Fields: []Type{
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize", FldName: "size", TypeSize: 4, ArgDir: 2}}, BitSize: 8, Buf: "parent"},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "f0", TypeSize: 4, ArgDir: 1}}},
+		&BufferType{TypeCommon: TypeCommon{TypeName: "array", FldName: "f1", TypeSize: 8, ArgDir: 1}, Kind: 1, RangeBegin: 8, RangeEnd: 8},
+	}}},
 	{Key: StructKey{Name: "syz_length_int_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_length_int_struct", TypeSize: 4}, Fields: []Type{
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16", FldName: "f0", TypeSize: 2}}},
 		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "f1", TypeSize: 2}}, Buf: "f0"},
@@ -449,6 +757,34 @@ var structDescs_64 = []*KeyedStruct{
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16", FldName: "f0", TypeSize: 2}}},
 		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "f1", TypeSize: 2}}, Buf: "parent"},
 	}}},
+	{Key: StructKey{Name: "syz_length_path_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_length_path_struct", TypeSize: 24}, Fields: []Type{
+		&StructType{Key: StructKey{Name: "syz_length_path_struct_inner"}, FldName: "f0"},
+		&ArrayType{TypeCommon: TypeCommon{TypeName: "array", FldName: "f1", TypeSize: 6}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16", TypeSize: 2}}}, Kind: 1, RangeBegin: 3, RangeEnd: 3},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 2}}, IsPad: true},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", FldName: "f2", TypeSize: 8}}},
+	}}},
+	{Key: StructKey{Name: "syz_length_path_struct_inner"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_length_path_struct_inner", TypeSize: 8}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "f0", TypeSize: 4}}},
+		&StructType{Key: StructKey{Name: "syz_length_path_struct_inner_inner"}, FldName: "f1"},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize", FldName: "f2", TypeSize: 1}}, BitSize: 8, Buf: "parent.f2"},
+	}}},
+	{Key: StructKey{Name: "syz_length_path_struct_inner_inner"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_length_path_struct_inner_inner", TypeSize: 3}, Fields: []Type{
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "f0", TypeSize: 1}}, Buf: "parent.parent.f1"},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize", FldName: "f1", TypeSize: 1}}, BitSize: 8, Buf: "parent.f0"},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "f2", TypeSize: 1}}, Buf: "parent.parent"},
+	}}},
+	{Key: StructKey{Name: "syz_length_reserved_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_length_reserved_struct", TypeSize: 12}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "f0", TypeSize: 1}}},
+		&BufferType{TypeCommon: TypeCommon{TypeName: "reserved", FldName: "f1", TypeSize: 3}, Kind: 2, Values: []string{"\x00\x00\x00"}, NoZ: true},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "f2", TypeSize: 4}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize", FldName: "f3", TypeSize: 1}}, BitSize: 8, Buf: "parent"},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize", FldName: "f4", TypeSize: 1}}, BitSize: 8, Buf: "f1"},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 2}}, IsPad: true},
+	}}},
+	{Key: StructKey{Name: "syz_length_sectors_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_length_sectors_struct", TypeSize: 1026}, Fields: []Type{
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "unitsize", FldName: "sectors", TypeSize: 2}}, BitSize: 4096, Buf: "data"},
+		&BufferType{TypeCommon: TypeCommon{TypeName: "array", FldName: "data", TypeSize: 1024}, Kind: 1, RangeBegin: 1024, RangeEnd: 1024},
+	}}},
 	{Key: StructKey{Name: "syz_length_vma_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_length_vma_struct", TypeSize: 16}, Fields: []Type{
 		&VmaType{TypeCommon: TypeCommon{TypeName: "vma", FldName: "f0", TypeSize: 8}},
 		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "f1", TypeSize: 8}}, Buf: "f0"},
@@ -456,6 +792,32 @@ var structDescs_64 = []*KeyedStruct{
 	{Key: StructKey{Name: "syz_missing_const_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_missing_const_struct", TypeSize: 4}, Fields: []Type{
 		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "a0", TypeSize: 4}}},
 	}}},
+	{Key: StructKey{Name: "syz_overlay_mode1"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_overlay_mode1", TypeSize: 4}, Fields: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "mode", TypeSize: 4}, BitfieldLen: 4, BitfieldMdl: true}, Val: 1},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "data", TypeSize: 4}, BitfieldOff: 4, BitfieldLen: 28}},
+	}}},
+	{Key: StructKey{Name: "syz_overlay_reg"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_overlay_reg", TypeSize: 4}, Fields: []Type{
+		&StructType{Key: StructKey{Name: "syz_overlay_split"}, FldName: "split"},
+		&StructType{Key: StructKey{Name: "syz_overlay_mode1"}, FldName: "mode1"},
+	}, Overlay: true}},
+	{Key: StructKey{Name: "syz_overlay_split"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_overlay_split", TypeSize: 4}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "lo", TypeSize: 4}, BitfieldLen: 4, BitfieldMdl: true}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "mid", TypeSize: 4}, BitfieldOff: 4, BitfieldLen: 12, BitfieldMdl: true}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "hi", TypeSize: 4}, BitfieldOff: 16, BitfieldLen: 16}},
+	}}},
+	{Key: StructKey{Name: "syz_parity_reg"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_parity_reg", TypeSize: 4}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16", FldName: "data", TypeSize: 2}, BitfieldLen: 15, BitfieldMdl: true}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "parity", FldName: "parity", TypeSize: 2}, BitfieldOff: 15, BitfieldLen: 1}, Kind: 11, ParityEnd: 14},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "lo", TypeSize: 1}, BitfieldLen: 4, BitfieldMdl: true}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "parity", FldName: "odd", TypeSize: 1}, BitfieldOff: 4, BitfieldLen: 1, BitfieldMdl: true}, Kind: 11, ParityOdd: true, ParityEnd: 3},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "hi", TypeSize: 1}, BitfieldOff: 5, BitfieldLen: 3}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 1}}, IsPad: true},
+	}}},
+	{Key: StructKey{Name: "syz_promote_hdr"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_promote_hdr", TypeSize: 8}, Fields: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "magic", TypeSize: 2}}, Val: 43981, Promotable: true},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "version", TypeSize: 2}}, Val: 1},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "len", TypeSize: 4}}},
+	}}},
 	{Key: StructKey{Name: "syz_recur_0"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_recur_0", TypeSize: 8}, Fields: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8, IsOptional: true}, Type: &StructType{Key: StructKey{Name: "syz_recur_0"}}},
 	}}},
@@ -498,6 +860,110 @@ var structDescs_64 = []*KeyedStruct{
 	{Key: StructKey{Name: "syz_regression1_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_regression1_struct", TypeSize: 4}, Fields: []Type{
 		&BufferType{TypeCommon: TypeCommon{TypeName: "array", FldName: "f0", TypeSize: 4}, Kind: 1, RangeBegin: 4, RangeEnd: 4},
 	}}},
+	{Key: StructKey{Name: "syz_request_mask_query", Dir: 2}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_request_mask_query", TypeSize: 32, ArgDir: 2}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", FldName: "mask", TypeSize: 8, ArgDir: 2}, RequestMaskField: "res"}},
+		&StructType{Key: StructKey{Name: "syz_requested", Dir: 2}, FldName: "res"},
+	}}},
+	{Key: StructKey{Name: "syz_requested", Dir: 1}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_requested", TypeSize: 24, ArgDir: 1}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "mask", TypeSize: 4, ArgDir: 1}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "f0", TypeSize: 4, ArgDir: 1}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", FldName: "f1", TypeSize: 8, ArgDir: 1}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "f2", TypeSize: 4, ArgDir: 1}}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 4}}, IsPad: true},
+	}, RequestedFields: []string{"f0", "f1", "f2"}, RequestedBy: []uint64{1, 2, 12}}},
+	{Key: StructKey{Name: "syz_requested", Dir: 2}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_requested", TypeSize: 24, ArgDir: 2}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "mask", TypeSize: 4, ArgDir: 2}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "f0", TypeSize: 4, ArgDir: 2}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", FldName: "f1", TypeSize: 8, ArgDir: 2}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "f2", TypeSize: 4, ArgDir: 2}}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 4}}, IsPad: true},
+	}, RequestedFields: []string{"f0", "f1", "f2"}, RequestedBy: []uint64{1, 2, 12}}},
+	{Key: StructKey{Name: "syz_res_counted"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_res_counted", IsVarlen: true}, Fields: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_res", FldName: "n", TypeSize: 4}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 4}}, IsPad: true},
+		&ArrayType{TypeCommon: TypeCommon{TypeName: "array", FldName: "a", IsVarlen: true}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", TypeSize: 8}}}, CountField: "n"},
+	}}},
+	{Key: StructKey{Name: "syz_res_fields"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_res_fields", TypeSize: 40}, Fields: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "fd", FldName: "f0", TypeSize: 4}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "f1", TypeSize: 4}}},
+		&StructType{Key: StructKey{Name: "syz_res_fields_inner"}, FldName: "f2"},
+		&ArrayType{TypeCommon: TypeCommon{TypeName: "array", FldName: "f3", TypeSize: 8}, Type: &ResourceType{TypeCommon: TypeCommon{TypeName: "syz_res", TypeSize: 4}}, Kind: 1, RangeBegin: 2, RangeEnd: 2},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "f4", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_res_fields_inner", Dir: 1}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "f5", TypeSize: 8, IsOptional: true}, Type: &StructType{Key: StructKey{Name: "syz_res_fields"}}},
+	}}},
+	{Key: StructKey{Name: "syz_res_fields", Dir: 2}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_res_fields", TypeSize: 40, ArgDir: 2}, Fields: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "fd", FldName: "f0", TypeSize: 4, ArgDir: 2}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "f1", TypeSize: 4, ArgDir: 2}}},
+		&StructType{Key: StructKey{Name: "syz_res_fields_inner", Dir: 2}, FldName: "f2"},
+		&ArrayType{TypeCommon: TypeCommon{TypeName: "array", FldName: "f3", TypeSize: 8, ArgDir: 2}, Type: &ResourceType{TypeCommon: TypeCommon{TypeName: "syz_res", TypeSize: 4, ArgDir: 2}}, Kind: 1, RangeBegin: 2, RangeEnd: 2},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "f4", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_res_fields_inner", Dir: 1}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "f5", TypeSize: 8, IsOptional: true}, Type: &StructType{Key: StructKey{Name: "syz_res_fields"}}},
+	}}},
+	{Key: StructKey{Name: "syz_res_fields_inner"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_res_fields_inner", TypeSize: 8}, Fields: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_res", FldName: "r0", TypeSize: 4}},
+		&UnionType{Key: StructKey{Name: "syz_res_fields_union"}, FldName: "u0"},
+	}}},
+	{Key: StructKey{Name: "syz_res_fields_inner", Dir: 1}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_res_fields_inner", TypeSize: 8, ArgDir: 1}, Fields: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_res", FldName: "r0", TypeSize: 4, ArgDir: 1}},
+		&UnionType{Key: StructKey{Name: "syz_res_fields_union", Dir: 1}, FldName: "u0"},
+	}}},
+	{Key: StructKey{Name: "syz_res_fields_inner", Dir: 2}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_res_fields_inner", TypeSize: 8, ArgDir: 2}, Fields: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_res", FldName: "r0", TypeSize: 4, ArgDir: 2}},
+		&UnionType{Key: StructKey{Name: "syz_res_fields_union", Dir: 2}, FldName: "u0"},
+	}}},
+	{Key: StructKey{Name: "syz_res_fields_union"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_res_fields_union", TypeSize: 4}, Fields: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "fd", FldName: "fd", TypeSize: 4}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "i", TypeSize: 4}}},
+	}}},
+	{Key: StructKey{Name: "syz_res_fields_union", Dir: 1}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_res_fields_union", TypeSize: 4, ArgDir: 1}, Fields: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "fd", FldName: "fd", TypeSize: 4, ArgDir: 1}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "i", TypeSize: 4, ArgDir: 1}}},
+	}}},
+	{Key: StructKey{Name: "syz_res_fields_union", Dir: 2}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_res_fields_union", TypeSize: 4, ArgDir: 2}, Fields: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "fd", FldName: "fd", TypeSize: 4, ArgDir: 2}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "i", TypeSize: 4, ArgDir: 2}}},
+	}}},
+	{Key: StructKey{Name: "syz_res_handle"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_res_handle", TypeSize: 4}, Fields: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "fd", FldName: "h", TypeSize: 4}, ValidMask: 1073741824},
+	}}},
+	{Key: StructKey{Name: "syz_serialized_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_serialized_struct", IsVarlen: true}, Fields: []Type{
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "f0", TypeSize: 4}}, Buf: "parent"},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16be", FldName: "f1", TypeSize: 2}, ArgFormat: 1}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "f2", TypeSize: 1}, BitfieldLen: 3, BitfieldMdl: true}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "f3", TypeSize: 1}, BitfieldOff: 3, BitfieldLen: 5}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 1}}, IsPad: true},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", FldName: "f4", TypeSize: 8}}},
+		&BufferType{TypeCommon: TypeCommon{TypeName: "array", FldName: "f5", TypeSize: 2}, Kind: 1, RangeBegin: 2, RangeEnd: 2},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 6}}, IsPad: true},
+		&UnionType{Key: StructKey{Name: "syz_serialized_union"}, FldName: "f6"},
+	}}},
+	{Key: StructKey{Name: "syz_serialized_union"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_serialized_union", IsVarlen: true}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", FldName: "f0", TypeSize: 8}}},
+		&BufferType{TypeCommon: TypeCommon{TypeName: "serialized", FldName: "f1", IsVarlen: true}, Kind: 5, Elem: &StructType{Key: StructKey{Name: "syz_serialized_struct"}}},
+	}}},
+	{Key: StructKey{Name: "syz_shared_ring", Dir: 2}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_shared_ring", TypeSize: 8, ArgDir: 2}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "head", TypeSize: 4, ArgDir: 2}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "tail", TypeSize: 4, ArgDir: 2}}},
+	}}},
+	{Key: StructKey{Name: "syz_sockaddr"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_sockaddr", IsVarlen: true}, Fields: []Type{
+		&StructType{Key: StructKey{Name: "tagged_record[1, int16, syz_sockaddr_un]"}, FldName: "un"},
+		&StructType{Key: StructKey{Name: "tagged_record[2, int16, syz_sockaddr_in]"}, FldName: "in"},
+		&StructType{Key: StructKey{Name: "tagged_record[10, int16, syz_sockaddr_in6]"}, FldName: "in6"},
+	}}},
+	{Key: StructKey{Name: "syz_sockaddr_in"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_sockaddr_in", TypeSize: 14}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16be", FldName: "port", TypeSize: 2}, ArgFormat: 1}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32be", FldName: "addr", TypeSize: 4}, ArgFormat: 1}},
+		&ArrayType{TypeCommon: TypeCommon{TypeName: "array", FldName: "pad", TypeSize: 8}, Type: &ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", TypeSize: 1}}}, Kind: 1, RangeBegin: 8, RangeEnd: 8},
+	}}},
+	{Key: StructKey{Name: "syz_sockaddr_in6"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_sockaddr_in6", TypeSize: 26}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16be", FldName: "port", TypeSize: 2}, ArgFormat: 1}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32be", FldName: "flowinfo", TypeSize: 4}, ArgFormat: 1}},
+		&BufferType{TypeCommon: TypeCommon{TypeName: "array", FldName: "addr", TypeSize: 16}, Kind: 1, RangeBegin: 16, RangeEnd: 16},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "scope_id", TypeSize: 4}}},
+	}}},
+	{Key: StructKey{Name: "syz_sockaddr_un"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_sockaddr_un", TypeSize: 108}, Fields: []Type{
+		&BufferType{TypeCommon: TypeCommon{TypeName: "array", FldName: "path", TypeSize: 108}, Kind: 1, RangeBegin: 108, RangeEnd: 108},
+	}}},
 	{Key: StructKey{Name: "syz_struct0"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_struct0", TypeSize: 16}, Fields: []Type{
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", FldName: "f0", TypeSize: 8}}},
 		&StructType{Key: StructKey{Name: "syz_struct1"}, FldName: "f1"},
@@ -534,10 +1000,76 @@ var structDescs_64 = []*KeyedStruct{
 	{Key: StructKey{Name: "syz_union3"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_union3", TypeSize: 4}, Fields: []Type{
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "f0", TypeSize: 4}}},
 	}}},
+	{Key: StructKey{Name: "syz_union_weights"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_union_weights", TypeSize: 4}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "f0", TypeSize: 1}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16", FldName: "f1", TypeSize: 2}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "f2", TypeSize: 4}}},
+	}, OptionWeights: []uint64{0, 1, 9}}},
 	{Key: StructKey{Name: "syz_use_missing"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_use_missing", TypeSize: 8}, Fields: []Type{
 		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_missing_const_res", FldName: "a0", TypeSize: 4}},
 		&StructType{Key: StructKey{Name: "syz_missing_const_struct"}, FldName: "a1"},
 	}}},
+	{Key: StructKey{Name: "syz_versioned"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_versioned", IsVarlen: true}, Fields: []Type{
+		&StructType{Key: StructKey{Name: "syz_versioned_v1"}, FldName: "v1"},
+		&StructType{Key: StructKey{Name: "syz_versioned_v2"}, FldName: "v2"},
+	}, VersionField: "ver", Versions: []uint64{1, 2}}},
+	{Key: StructKey{Name: "syz_versioned_v1"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_versioned_v1", TypeSize: 8}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "ver", TypeSize: 4}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "f0", TypeSize: 4}}},
+	}}},
+	{Key: StructKey{Name: "syz_versioned_v2"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "syz_versioned_v2", TypeSize: 24}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "ver", TypeSize: 4}}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 4}}, IsPad: true},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", FldName: "f0", TypeSize: 8}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16", FldName: "f1", TypeSize: 2}}},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 6}}, IsPad: true},
+	}}},
+	{Key: StructKey{Name: "tagged_payload"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "tagged_payload", IsVarlen: true}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16", FldName: "f0", TypeSize: 2}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize", FldName: "f1", TypeSize: 2}}, BitSize: 8, Buf: "f2"},
+		&BufferType{TypeCommon: TypeCommon{TypeName: "array", FldName: "f2", IsVarlen: true}, Kind: 1, RangeEnd: 8},
+	}}},
+	{Key: StructKey{Name: "tagged_record[1, int16, syz_sockaddr_un]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "tagged_record[1, int16, syz_sockaddr_un]", TypeSize: 110}, Fields: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "tag", TypeSize: 2}}, Val: 1},
+		&StructType{Key: StructKey{Name: "syz_sockaddr_un"}, FldName: "payload"},
+	}}},
+	{Key: StructKey{Name: "tagged_record[1, int32, int64]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "tagged_record[1, int32, int64]", TypeSize: 16}, Fields: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "tag", TypeSize: 4}}, Val: 1},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 4}}, IsPad: true},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", FldName: "payload", TypeSize: 8}}},
+	}}},
+	{Key: StructKey{Name: "tagged_record[10, int16, syz_sockaddr_in6]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "tagged_record[10, int16, syz_sockaddr_in6]", TypeSize: 28}, Fields: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "tag", TypeSize: 2}}, Val: 10},
+		&StructType{Key: StructKey{Name: "syz_sockaddr_in6"}, FldName: "payload"},
+	}}},
+	{Key: StructKey{Name: "tagged_record[2, int16, syz_sockaddr_in]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "tagged_record[2, int16, syz_sockaddr_in]", TypeSize: 16}, Fields: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "tag", TypeSize: 2}}, Val: 2},
+		&StructType{Key: StructKey{Name: "syz_sockaddr_in"}, FldName: "payload"},
+	}}},
+	{Key: StructKey{Name: "tagged_record[2, int32, array[int8, 0:4]]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "tagged_record[2, int32, array[int8, 0:4]]", IsVarlen: true}, Fields: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "tag", TypeSize: 4}}, Val: 2},
+		&BufferType{TypeCommon: TypeCommon{TypeName: "array", FldName: "payload", IsVarlen: true}, Kind: 1, RangeEnd: 4},
+	}}},
+	{Key: StructKey{Name: "tagged_record[3, int32, tagged_payload]"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "tagged_record[3, int32, tagged_payload]", IsVarlen: true}, Fields: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "tag", TypeSize: 4}}, Val: 3},
+		&StructType{Key: StructKey{Name: "tagged_payload"}, FldName: "payload"},
+	}}},
+	{Key: StructKey{Name: "tagged_union"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "tagged_union", IsVarlen: true}, Fields: []Type{
+		&StructType{Key: StructKey{Name: "tagged_record[1, int32, int64]"}, FldName: "f0"},
+		&StructType{Key: StructKey{Name: "tagged_record[2, int32, array[int8, 0:4]]"}, FldName: "f1"},
+		&StructType{Key: StructKey{Name: "tagged_record[3, int32, tagged_payload]"}, FldName: "f2"},
+	}}},
+	{Key: StructKey{Name: "test_nlattr"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "test_nlattr", IsVarlen: true}, Fields: []Type{
+		&StructType{Key: StructKey{Name: "nlattr_t[const[1, int16], int32]"}, FldName: "link"},
+		&StructType{Key: StructKey{Name: "nlattr_t[const[3, int16], string]"}, FldName: "ifname"},
+		&StructType{Key: StructKey{Name: "nlattr_t[const[4, int16], int32]"}, FldName: "mtu"},
+		&StructType{Key: StructKey{Name: "nlattr_nested_t[const[18, int16:14], array[test_nlattr_linkinfo]]"}, FldName: "linkinfo"},
+		&StructType{Key: StructKey{Name: "nlattr_t[const[5, int16], int8]"}, FldName: "u8"},
+	}}},
+	{Key: StructKey{Name: "test_nlattr_linkinfo"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "test_nlattr_linkinfo", IsVarlen: true}, Fields: []Type{
+		&StructType{Key: StructKey{Name: "nlattr_t[const[1, int16], string]"}, FldName: "kind"},
+		&StructType{Key: StructKey{Name: "nlattr_nested_t[const[2, int16:14], array[nlattr[7, int32be]]]"}, FldName: "data"},
+	}}},
 	{Key: StructKey{Name: "type_confusion"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "type_confusion", TypeSize: 1}, Fields: []Type{
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "f1", TypeSize: 1}}},
 	}}},
@@ -550,6 +1082,19 @@ var structDescs_64 = []*KeyedStruct{
 		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "syz_length_flags", FldName: "f6", TypeSize: 4}}, Vals: []uint64{0, 1}, BitMask: true},
 		&ProcType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "proc", FldName: "f7", TypeSize: 2}}, ValuesPerProc: 1},
 	}}},
+	{Key: StructKey{Name: "unique_bitfield"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "unique_bitfield", TypeSize: 1}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "f0", TypeSize: 1}, BitfieldLen: 2, BitfieldMdl: true}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "f1", TypeSize: 1}, BitfieldOff: 2, BitfieldLen: 6}},
+	}, UniqueFields: []string{"f0"}}},
+	{Key: StructKey{Name: "unique_small"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "unique_small", TypeSize: 4}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "id", TypeSize: 1}}, Kind: 2, RangeBegin: 1, RangeEnd: 4},
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "pad", TypeSize: 1}}, IsPad: true},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16", FldName: "id2", TypeSize: 2}}, Kind: 2, RangeBegin: 10, RangeEnd: 20},
+	}, UniqueFields: []string{"id", "id2"}}},
+	{Key: StructKey{Name: "unique_struct"}, Desc: &StructDesc{TypeCommon: TypeCommon{TypeName: "unique_struct", TypeSize: 8}, Fields: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "id", TypeSize: 4}}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "val", TypeSize: 4}}},
+	}, UniqueFields: []string{"id"}}},
 }
 
 var syscalls_64 = []*Syscall{
@@ -689,21 +1234,84 @@ var syscalls_64 = []*Syscall{
 	{Name: "test$array2", CallName: "test", MissingArgs: 5, Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_array_blob"}}},
 	}},
+	{Name: "test$arrayindex0", CallName: "test", MissingArgs: 4, Args: []Type{
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "a0", TypeSize: 8}}, Buf: "a1"},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a1", TypeSize: 8}, Type: &ArrayType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}, Type: &StructType{Key: StructKey{Name: "syz_indexed_record"}}}},
+	}},
+	{Name: "test$arrayindex1", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &ArrayType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}, Type: &StructType{Key: StructKey{Name: "syz_indexed_bits"}}, Kind: 1, RangeEnd: 4}},
+	}},
+	{Name: "test$atomic0", CallName: "test", MissingArgs: 6, Ret: &ResourceType{TypeCommon: TypeCommon{TypeName: "syz_res", FldName: "ret", TypeSize: 4, ArgDir: 1}}, Group: "syz_atomic_group"},
+	{Name: "test$atomic1", CallName: "test", MissingArgs: 5, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_res", FldName: "a0", TypeSize: 4}},
+	}, Group: "syz_atomic_group"},
 	{Name: "test$auto0", CallName: "test", MissingArgs: 2, Args: []Type{
 		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "a", TypeSize: 8}}, Val: 66},
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "b", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "auto_struct0"}}},
 		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "c", TypeSize: 8}}, Buf: "b"},
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "d", TypeSize: 4}}},
 	}},
+	{Name: "test$be_res0", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_be_res_struct", Dir: 1}}},
+	}},
+	{Name: "test$be_res1", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_be_res_struct"}}},
+	}},
 	{Name: "test$bf0", CallName: "test", MissingArgs: 5, Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_bf_struct0"}}},
 	}},
 	{Name: "test$bf1", CallName: "test", MissingArgs: 5, Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_bf_struct1"}}},
 	}},
+	{Name: "test$bf2", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_bf_struct6"}}},
+	}},
 	{Name: "test$blob0", CallName: "test", MissingArgs: 5, Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a", TypeSize: 8}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}}},
 	}},
+	{Name: "test$child_pid", CallName: "test", MissingArgs: 4, Args: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "child_pid", FldName: "a0", TypeSize: 4}}, Kind: 8},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a1", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "child_pid_struct"}}},
+	}},
+	{Name: "test$choice0", CallName: "test", MissingArgs: 5, Args: []Type{
+		&UnionType{Key: StructKey{Name: "choice[fd, intptr, ptr[in, int64]]"}, FldName: "a0"},
+	}},
+	{Name: "test$choice1", CallName: "test", MissingArgs: 4, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "fd", FldName: "a0", TypeSize: 4}},
+		&UnionType{Key: StructKey{Name: "choice[int8, syz_res, ptr[out, syz_res]]"}, FldName: "a1"},
+	}},
+	{Name: "test$compat0", CallName: "test", MissingArgs: 6, Ret: &ResourceType{TypeCommon: TypeCommon{TypeName: "syz_compat0", FldName: "ret", TypeSize: 4, ArgDir: 1}}},
+	{Name: "test$compat1", CallName: "test", MissingArgs: 6, Ret: &ResourceType{TypeCommon: TypeCommon{TypeName: "syz_compat1", FldName: "ret", TypeSize: 4, ArgDir: 1}}},
+	{Name: "test$compat2", CallName: "test", MissingArgs: 4, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_compat0", FldName: "a0", TypeSize: 4}},
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_compat1", FldName: "a1", TypeSize: 4}},
+	}},
+	{Name: "test$cq_reap", CallName: "test", MissingArgs: 4, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "cq_ring", FldName: "a0", TypeSize: 4}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a1", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "cq_entry", Dir: 1}}},
+	}, Completes: []string{"test$cq_submit0", "test$cq_submit1"}},
+	{Name: "test$cq_reap2", CallName: "test", MissingArgs: 4, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "cq_ring", FldName: "a0", TypeSize: 4}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a1", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "cq_cookie_entry", Dir: 1}}},
+	}, Completes: []string{"test$cq_submit2"}},
+	{Name: "test$cq_setup", CallName: "test", MissingArgs: 6, Ret: &ResourceType{TypeCommon: TypeCommon{TypeName: "cq_ring", FldName: "ret", TypeSize: 4, ArgDir: 1}}},
+	{Name: "test$cq_submit0", CallName: "test", MissingArgs: 4, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "cq_ring", FldName: "a0", TypeSize: 4}},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "a1", TypeSize: 4}}},
+	}},
+	{Name: "test$cq_submit1", CallName: "test", MissingArgs: 5, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "cq_ring", FldName: "a0", TypeSize: 4}},
+	}, Requires: []string{"test$requires0"}},
+	{Name: "test$cq_submit2", CallName: "test", MissingArgs: 4, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "cq_ring", FldName: "a0", TypeSize: 4}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a1", TypeSize: 8}, Type: &ArrayType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}, Type: &StructType{Key: StructKey{Name: "cq_request"}}, Kind: 1, RangeBegin: 1, RangeEnd: 4}},
+	}},
+	{Name: "test$cq_use", CallName: "test", MissingArgs: 5, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "cq_result", FldName: "a0", TypeSize: 4}},
+	}},
+	{Name: "test$csum_crc32", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_csum_crc32_struct"}}},
+	}},
 	{Name: "test$csum_encode", CallName: "test", MissingArgs: 5, Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_csum_encode"}}},
 	}},
@@ -725,12 +1333,35 @@ var syscalls_64 = []*Syscall{
 	{Name: "test$csum_ipv6_udp", CallName: "test", MissingArgs: 5, Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_csum_ipv6_udp_packet"}}},
 	}},
+	{Name: "test$csum_xor", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_csum_xor_struct"}}},
+	}},
+	{Name: "test$derive0", CallName: "test", MissingArgs: 5, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_res", FldName: "a0", TypeSize: 4}, Derives: true, DeriveOffset: 256},
+	}, Ret: &ResourceType{TypeCommon: TypeCommon{TypeName: "syz_derived_res", FldName: "ret", TypeSize: 4, ArgDir: 1}}},
+	{Name: "test$derive1", CallName: "test", MissingArgs: 5, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_derived_res", FldName: "a0", TypeSize: 4}},
+	}},
 	{Name: "test$end0", CallName: "test", MissingArgs: 5, Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_end_int_struct"}}},
 	}},
 	{Name: "test$end1", CallName: "test", MissingArgs: 5, Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_end_var_struct"}}},
 	}},
+	{Name: "test$end2", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_end_bom_struct"}}},
+	}},
+	{Name: "test$event0", CallName: "test", MissingArgs: 4, Args: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "a0", TypeSize: 4}}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "syz_event_flags", FldName: "a1", TypeSize: 8}}, Vals: []uint64{1, 2048}, BitMask: true},
+	}, Ret: &ResourceType{TypeCommon: TypeCommon{TypeName: "syz_event", FldName: "ret", TypeSize: 4, ArgDir: 1}}, NonblockArg: 1, NonblockFlag: 2048},
+	{Name: "test$event1", CallName: "test", MissingArgs: 4, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_event", FldName: "a0", TypeSize: 4}, Event: 1},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", FldName: "a1", TypeSize: 8}}},
+	}},
+	{Name: "test$event2", CallName: "test", MissingArgs: 5, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_event", FldName: "a0", TypeSize: 4}, Event: 2},
+	}},
 	{Name: "test$excessive_args1", CallName: "test", MissingArgs: 6},
 	{Name: "test$excessive_args2", CallName: "test", MissingArgs: 5, Args: []Type{
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "a1", TypeSize: 1}}},
@@ -738,9 +1369,45 @@ var syscalls_64 = []*Syscall{
 	{Name: "test$excessive_fields1", CallName: "test", MissingArgs: 5, Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a1", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "excessive_fields"}}},
 	}},
+	{Name: "test$exhaustive0", CallName: "test", MissingArgs: 4, Args: []Type{
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "exhaustive_flags", FldName: "a0", TypeSize: 8}}, Vals: []uint64{1, 2, 3, 4, 5}, Exhaustive: true},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a1", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "exhaustive_struct"}}},
+	}},
+	{Name: "test$flagindex", CallName: "test", MissingArgs: 4, Args: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "flagindex", FldName: "a0", TypeSize: 8}}, Kind: 7, Flags: "syz_flagindex_flags", FlagIndices: []uint64{1, 3, 8}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a1", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_flagindex_struct"}}},
+	}},
+	{Name: "test$footer", CallName: "test", MissingArgs: 4, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_footer_struct"}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize", FldName: "a1", TypeSize: 8}}, BitSize: 8, Buf: "a0"},
+	}},
+	{Name: "test$format", CallName: "test", MissingArgs: 4, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "fmt", TypeSize: 8}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "string", IsVarlen: true}, Kind: 2, FormatArgs: "args"}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "args", TypeSize: 8}, Type: &ArrayType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}, Type: &UnionType{Key: StructKey{Name: "syz_format_arg"}}, Kind: 1, RangeEnd: 4}},
+	}},
+	{Name: "test$funcptr", CallName: "test", MissingArgs: 4, Args: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "funcptr", FldName: "a0", TypeSize: 8}}, Kind: 5},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a1", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "funcptr_struct"}}},
+	}},
+	{Name: "test$guard0", CallName: "test", MissingArgs: 4, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}, Kind: 1, RangeEnd: 64}, Guard: true},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "a1", TypeSize: 8}}, Buf: "a0"},
+	}},
+	{Name: "test$guard1", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "guard_struct"}}},
+	}},
+	{Name: "test$hash_add", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_hash_add_struct"}}},
+	}},
+	{Name: "test$hash_fnv1a", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_hash_fnv1a_struct"}}},
+	}},
 	{Name: "test$hint_data", CallName: "test", MissingArgs: 5, Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}}},
 	}},
+	{Name: "test$hook", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "hook_struct"}}},
+	}},
 	{Name: "test$int", CallName: "test", MissingArgs: 1, Args: []Type{
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", FldName: "a0", TypeSize: 8}}},
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int8", FldName: "a1", TypeSize: 1}}},
@@ -748,6 +1415,37 @@ var syscalls_64 = []*Syscall{
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "a3", TypeSize: 4}}},
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int64", FldName: "a4", TypeSize: 8}}},
 	}},
+	{Name: "test$int_buckets", CallName: "test", MissingArgs: 4, Args: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "a0", TypeSize: 4}}, Buckets: []IntBucket{
+			{Begin: 1, End: 64, Weight: 90},
+			{Begin: 65, End: 4294967295, Weight: 10},
+		}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a1", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "int_buckets_struct"}}},
+	}},
+	{Name: "test$int_pow2", CallName: "test", MissingArgs: 4, Args: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int_pow2", FldName: "a0", TypeSize: 8}}, Kind: 9, RangeBegin: 1, RangeEnd: 4096},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a1", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_int_pow2_struct"}}},
+	}},
+	{Name: "test$int_relations0", CallName: "test", MissingArgs: 3, Args: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", FldName: "start", TypeSize: 8}}, Relation: 1, RelationField: "end"},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", FldName: "end", TypeSize: 8}}, Kind: 2, RangeEnd: 100},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a2", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "int_relations"}}},
+	}},
+	{Name: "test$int_relations1", CallName: "test", MissingArgs: 4, Args: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "a0", TypeSize: 4}}, Kind: 2, RangeEnd: 10, Relation: 4, RelationField: "a1"},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "a1", TypeSize: 4}}, Kind: 2, RangeEnd: 10},
+	}},
+	{Name: "test$inv0", CallName: "test", MissingArgs: 6, Ret: &ResourceType{TypeCommon: TypeCommon{TypeName: "syz_inv_sub", FldName: "ret", TypeSize: 4, ArgDir: 1}}},
+	{Name: "test$inv1", CallName: "test", MissingArgs: 5, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_inv", FldName: "a0", TypeSize: 4}, Effect: 1},
+	}},
+	{Name: "test$inv2", CallName: "test", MissingArgs: 5, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_inv_sub", FldName: "a0", TypeSize: 4}, Effect: 2},
+	}, Ret: &ResourceType{TypeCommon: TypeCommon{TypeName: "syz_inv_sub", FldName: "ret", TypeSize: 4, ArgDir: 1}}},
+	{Name: "test$inv3", CallName: "test", MissingArgs: 4, Args: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "a0", TypeSize: 4}}},
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_inv_sub", FldName: "a1", TypeSize: 4}, Effect: 1},
+	}, OmittableArgs: 1},
 	{Name: "test$length0", CallName: "test", MissingArgs: 5, Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_length_int_struct"}}},
 	}},
@@ -835,6 +1533,41 @@ var syscalls_64 = []*Syscall{
 	{Name: "test$length3", CallName: "test", MissingArgs: 5, Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_length_len_struct"}}},
 	}},
+	{Name: "test$length30", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_length_path_struct"}}},
+	}},
+	{Name: "test$length31", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_length_reserved_struct"}}},
+	}},
+	{Name: "test$length32", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_length_dims_struct"}}},
+	}},
+	{Name: "test$length33", CallName: "test", MissingArgs: 2, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &ArrayType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}, Type: &ArrayType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}, Type: &ArrayType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16", TypeSize: 2}}}, Kind: 1, RangeEnd: 3}}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "a1", TypeSize: 8}}, Buf: "a0"},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "a2", TypeSize: 8}}, Buf: "a0", Dim: 1},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "a3", TypeSize: 8}}, Buf: "a0", Dim: 2},
+	}},
+	{Name: "test$length34", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_length_init_struct", Dir: 1}}},
+	}},
+	{Name: "test$length35", CallName: "test", MissingArgs: 4, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_length_inclusive_struct"}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize_inclusive", FldName: "a1", TypeSize: 8}}, BitSize: 8, Buf: "a0", Inclusive: true},
+	}},
+	{Name: "test$length36", CallName: "test", MissingArgs: 3, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_length_expr_struct"}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a1", TypeSize: 8}, Type: &ArrayType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int16", TypeSize: 2}}}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize", FldName: "a2", TypeSize: 8}}, BitSize: 8, Expr: &LenExpr{Op: 2, Args: []*LenExpr{
+			{Buf: "a0"},
+			{Buf: "a1"},
+		}}},
+	}},
+	{Name: "test$length37", CallName: "test", MissingArgs: 3, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_length_sectors_struct"}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a1", TypeSize: 8}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "unitsize", FldName: "a2", TypeSize: 8}}, BitSize: 32, Buf: "a1"},
+	}},
 	{Name: "test$length4", CallName: "test", MissingArgs: 5, Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_length_len2_struct"}}},
 	}},
@@ -857,6 +1590,24 @@ var syscalls_64 = []*Syscall{
 	{Name: "test$missing_struct", CallName: "test", MissingArgs: 5, Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_use_missing"}}},
 	}},
+	{Name: "test$mutate_weight", CallName: "test", MissingArgs: 4, Args: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "a0", TypeSize: 4, MutateWeight: 18446744073709551615}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a1", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "mutate_weight_struct"}}},
+	}},
+	{Name: "test$nlattr", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &ArrayType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}, Type: &UnionType{Key: StructKey{Name: "test_nlattr"}}}},
+	}},
+	{Name: "test$ns_obj0", CallName: "test", MissingArgs: 6, Ret: &ResourceType{TypeCommon: TypeCommon{TypeName: "syz_ns_obj", FldName: "ret", TypeSize: 4, ArgDir: 1}}},
+	{Name: "test$ns_obj1", CallName: "test", MissingArgs: 5, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_ns_obj", FldName: "a0", TypeSize: 4}},
+	}},
+	{Name: "test$ns_switch", CallName: "test", MissingArgs: 6, SetNamespace: "syz_ns"},
+	{Name: "test$omittable", CallName: "test", MissingArgs: 2, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "a1", TypeSize: 8}}, Buf: "a0"},
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "a2", TypeSize: 4}}},
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "fd", FldName: "a3", TypeSize: 4}},
+	}, OmittableArgs: 2},
 	{Name: "test$opt0", CallName: "test", MissingArgs: 5, Args: []Type{
 		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "intptr", FldName: "a0", TypeSize: 8, IsOptional: true}}},
 	}},
@@ -869,6 +1620,26 @@ var syscalls_64 = []*Syscall{
 	{Name: "test$opt3", CallName: "test", MissingArgs: 5, Args: []Type{
 		&ProcType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "proc", FldName: "a0", TypeSize: 8, IsOptional: true}}, ValuesStart: 100, ValuesPerProc: 4},
 	}},
+	{Name: "test$overlap0", CallName: "test", MissingArgs: 4, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", TypeSize: 16}, Kind: 1, RangeBegin: 16, RangeEnd: 16}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a1", TypeSize: 8}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "array", TypeSize: 8}, Kind: 1, RangeBegin: 8, RangeEnd: 8}, OverlapField: "a0", OverlapOffset: 12},
+	}},
+	{Name: "test$overlap1", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "overlap_struct"}}},
+	}},
+	{Name: "test$overlay", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &UnionType{Key: StructKey{Name: "syz_overlay_reg"}}},
+	}},
+	{Name: "test$parity", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_parity_reg"}}},
+	}},
+	{Name: "test$promote", CallName: "test", MissingArgs: 4, Args: []Type{
+		&ConstType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "const", FldName: "a0", TypeSize: 8}}, Val: 4660, Promotable: true},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a1", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_promote_hdr"}}},
+	}},
+	{Name: "test$ptr_chain", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8, IsOptional: true}, Type: &PtrType{TypeCommon: TypeCommon{TypeName: "ptr", TypeSize: 8, IsOptional: true}, Type: &PtrType{TypeCommon: TypeCommon{TypeName: "ptr", TypeSize: 8, IsOptional: true}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}}}, Depth: 3},
+	}},
 	{Name: "test$recur0", CallName: "test", MissingArgs: 5, Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_recur_0", Dir: 2}}},
 	}},
@@ -878,6 +1649,16 @@ var syscalls_64 = []*Syscall{
 	{Name: "test$recur2", CallName: "test", MissingArgs: 5, Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_recur_2", Dir: 2}}},
 	}},
+	{Name: "test$refcnt0", CallName: "test", MissingArgs: 6, Ret: &ResourceType{TypeCommon: TypeCommon{TypeName: "syz_refcnt", FldName: "ret", TypeSize: 4, ArgDir: 1}}},
+	{Name: "test$refcnt1", CallName: "test", MissingArgs: 5, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_refcnt", FldName: "a0", TypeSize: 4}, Effect: 3},
+	}},
+	{Name: "test$refcnt2", CallName: "test", MissingArgs: 5, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_refcnt", FldName: "a0", TypeSize: 4}, Effect: 4},
+	}},
+	{Name: "test$refcnt3", CallName: "test", MissingArgs: 5, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_refcnt", FldName: "a0", TypeSize: 4}},
+	}},
 	{Name: "test$regression0", CallName: "test", MissingArgs: 5, Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_regression0_struct", Dir: 2}}},
 	}},
@@ -887,23 +1668,111 @@ var syscalls_64 = []*Syscall{
 	{Name: "test$regression2", CallName: "test", MissingArgs: 5, Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a1", TypeSize: 8}, Type: &ArrayType{TypeCommon: TypeCommon{TypeName: "array", TypeSize: 16}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4}}}, Kind: 1, RangeBegin: 4, RangeEnd: 4}},
 	}},
+	{Name: "test$relptr", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "relptr_struct"}}},
+	}},
+	{Name: "test$request_mask0", CallName: "test", MissingArgs: 4, Args: []Type{
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "syz_request_mask_flags", FldName: "a0", TypeSize: 8}, RequestMaskField: "a1"}, Vals: []uint64{1, 2, 4, 5}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a1", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_requested", Dir: 1}}},
+	}},
+	{Name: "test$request_mask1", CallName: "test", MissingArgs: 4, Args: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "a0", TypeSize: 4}, RequestMaskField: "a1"}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a1", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_requested", Dir: 1}}},
+	}},
+	{Name: "test$request_mask2", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_request_mask_query", Dir: 2}}},
+	}},
+	{Name: "test$requires0", CallName: "test", MissingArgs: 5, Args: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "a0", TypeSize: 4}}},
+	}},
+	{Name: "test$requires1", CallName: "test", MissingArgs: 5, Args: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "a0", TypeSize: 4}}},
+	}, Requires: []string{"test$requires0"}},
+	{Name: "test$requires2", CallName: "test", MissingArgs: 5, Args: []Type{
+		&IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", FldName: "a0", TypeSize: 4}}},
+	}, Requires: []string{"test$requires0", "test$requires1"}},
 	{Name: "test$res0", CallName: "test", MissingArgs: 6, Ret: &ResourceType{TypeCommon: TypeCommon{TypeName: "syz_res", FldName: "ret", TypeSize: 4, ArgDir: 1}}},
 	{Name: "test$res1", CallName: "test", MissingArgs: 5, Args: []Type{
 		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_res", FldName: "a0", TypeSize: 4}},
 	}},
+	{Name: "test$res10", CallName: "test", MissingArgs: 5, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_res", FldName: "a0", TypeSize: 4}, InvalidPercent: 50},
+	}},
 	{Name: "test$res2", CallName: "test", MissingArgs: 6, Ret: &ResourceType{TypeCommon: TypeCommon{TypeName: "fd", FldName: "ret", TypeSize: 4, ArgDir: 1}}},
+	{Name: "test$res3", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_res_fields", Dir: 2}}},
+	}},
+	{Name: "test$res4", CallName: "test", MissingArgs: 5, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_res", FldName: "a0", TypeSize: 4}, Effect: 1},
+	}},
+	{Name: "test$res5", CallName: "test", MissingArgs: 5, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_res", FldName: "a0", TypeSize: 4}, Effect: 2},
+	}, Ret: &ResourceType{TypeCommon: TypeCommon{TypeName: "syz_res", FldName: "ret", TypeSize: 4, ArgDir: 1}}},
+	{Name: "test$res6", CallName: "test", MissingArgs: 4, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_res", FldName: "a0", TypeSize: 4}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a1", TypeSize: 8}, Type: &ArrayType{TypeCommon: TypeCommon{TypeName: "array", ArgDir: 1, IsVarlen: true}, Type: &IntType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "int32", TypeSize: 4, ArgDir: 1}}}, CountField: "a0"}},
+	}},
+	{Name: "test$res7", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_res_counted"}}},
+	}},
+	{Name: "test$res8", CallName: "test", MissingArgs: 6, Ret: &ResourceType{TypeCommon: TypeCommon{TypeName: "syz_res", FldName: "ret", TypeSize: 4, ArgDir: 1}}, Retries: 3},
+	{Name: "test$res9", CallName: "test", MissingArgs: 4, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_res", FldName: "a0", TypeSize: 4}, ValidMask: 2147483648},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a1", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_res_handle"}}},
+	}},
+	{Name: "test$ring", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "ring_struct"}}},
+	}},
+	{Name: "test$serialized", CallName: "test", MissingArgs: 4, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "serialized", IsVarlen: true}, Kind: 5, Elem: &StructType{Key: StructKey{Name: "syz_serialized_struct"}}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "a1", TypeSize: 8}}, Buf: "a0"},
+	}},
+	{Name: "test$shared_setup", CallName: "test", MissingArgs: 4, Args: []Type{
+		&VmaType{TypeCommon: TypeCommon{TypeName: "vma", FldName: "a0", TypeSize: 8}, Shared: "syz_region"},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "a1", TypeSize: 8}}, Buf: "a0"},
+	}},
+	{Name: "test$shared_use", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_shared_ring", Dir: 2}}, Shared: "syz_region"},
+	}},
+	{Name: "test$slot0", CallName: "test", MissingArgs: 5, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_slot", FldName: "a0", TypeSize: 1}},
+	}},
+	{Name: "test$sockaddr", CallName: "test", MissingArgs: 4, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &UnionType{Key: StructKey{Name: "syz_sockaddr"}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "a1", TypeSize: 8}}, Buf: "a0"},
+	}},
+	{Name: "test$sparse0", CallName: "test", MissingArgs: 4, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &ArrayType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}, Type: &StructType{Key: StructKey{Name: "sparse[int32[0:15], int64]"}}, Kind: 1, RangeEnd: 16, Sparse: true}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "a1", TypeSize: 8}}, Buf: "a0"},
+	}},
+	{Name: "test$sparse1", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &ArrayType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}, Type: &StructType{Key: StructKey{Name: "sparse[int8[0:3], int16]"}}, Sparse: true}},
+	}},
 	{Name: "test$str0", CallName: "test", MissingArgs: 5, Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a", TypeSize: 8}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "string", IsVarlen: true}, Kind: 2}},
 	}},
 	{Name: "test$struct", CallName: "test", MissingArgs: 5, Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_struct0"}}},
 	}},
+	{Name: "test$subkind0", CallName: "test", MissingArgs: 6, Ret: &ResourceType{TypeCommon: TypeCommon{TypeName: "syz_obj_file", FldName: "ret", TypeSize: 4, ArgDir: 1}}},
+	{Name: "test$subkind1", CallName: "test", MissingArgs: 6, Ret: &ResourceType{TypeCommon: TypeCommon{TypeName: "syz_obj_dir", FldName: "ret", TypeSize: 4, ArgDir: 1}}},
+	{Name: "test$subkind2", CallName: "test", MissingArgs: 4, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_obj", FldName: "a0", TypeSize: 4}},
+		&FlagsType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "syz_obj_cmds", FldName: "a1", TypeSize: 8}}, Vals: []uint64{1, 2, 3, 4, 5}, SubkindField: "a0", Subkinds: []SubkindVals{
+			{Kind: "syz_obj_file", Vals: []uint64{1, 2}},
+			{Kind: "syz_obj_dir", Vals: []uint64{3, 4}},
+		}},
+	}},
 	{Name: "test$syz_union3", CallName: "test", MissingArgs: 5, Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &UnionType{Key: StructKey{Name: "syz_union3"}}},
 	}},
 	{Name: "test$syz_union4", CallName: "test", MissingArgs: 5, Args: []Type{
 		&UnionType{Key: StructKey{Name: "union_arg"}, FldName: "a0"},
 	}},
+	{Name: "test$tagged", CallName: "test", MissingArgs: 4, Args: []Type{
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "a0", TypeSize: 8}}, Buf: "a1"},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a1", TypeSize: 8}, Type: &ArrayType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}, Type: &UnionType{Key: StructKey{Name: "tagged_union"}}}},
+	}},
 	{Name: "test$text_x86_16", CallName: "test", MissingArgs: 4, Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "text", IsVarlen: true}, Kind: 4, Text: 2}},
 		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "a1", TypeSize: 8}}, Buf: "a0"},
@@ -920,6 +1789,12 @@ var syscalls_64 = []*Syscall{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &BufferType{TypeCommon: TypeCommon{TypeName: "text", IsVarlen: true}, Kind: 4, Text: 1}},
 		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "a1", TypeSize: 8}}, Buf: "a0"},
 	}},
+	{Name: "test$txn_begin", CallName: "test", MissingArgs: 6, ScopeBegin: "syz_txn"},
+	{Name: "test$txn_end", CallName: "test", MissingArgs: 6, ScopeEnd: "syz_txn"},
+	{Name: "test$txn_obj0", CallName: "test", MissingArgs: 6, Ret: &ResourceType{TypeCommon: TypeCommon{TypeName: "syz_txn_obj", FldName: "ret", TypeSize: 4, ArgDir: 1}}},
+	{Name: "test$txn_obj1", CallName: "test", MissingArgs: 5, Args: []Type{
+		&ResourceType{TypeCommon: TypeCommon{TypeName: "syz_txn_obj", FldName: "a0", TypeSize: 4}},
+	}},
 	{Name: "test$type_confusion1", CallName: "test", MissingArgs: 5, Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a1", TypeSize: 8}, Type: &UnionType{Key: StructKey{Name: "type_confusion"}}},
 	}},
@@ -932,6 +1807,18 @@ var syscalls_64 = []*Syscall{
 	{Name: "test$union2", CallName: "test", MissingArgs: 5, Args: []Type{
 		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &StructType{Key: StructKey{Name: "syz_union2_struct"}}},
 	}},
+	{Name: "test$union_weights", CallName: "test", MissingArgs: 5, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &UnionType{Key: StructKey{Name: "syz_union_weights"}}},
+	}},
+	{Name: "test$unique", CallName: "test", MissingArgs: 3, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &ArrayType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}, Type: &StructType{Key: StructKey{Name: "unique_struct"}}}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a1", TypeSize: 8}, Type: &ArrayType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}, Type: &StructType{Key: StructKey{Name: "unique_small"}}, Kind: 1, RangeBegin: 1, RangeEnd: 4}},
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a2", TypeSize: 8}, Type: &ArrayType{TypeCommon: TypeCommon{TypeName: "array", IsVarlen: true}, Type: &StructType{Key: StructKey{Name: "unique_bitfield"}}}},
+	}},
+	{Name: "test$versioned", CallName: "test", MissingArgs: 4, Args: []Type{
+		&PtrType{TypeCommon: TypeCommon{TypeName: "ptr", FldName: "a0", TypeSize: 8}, Type: &UnionType{Key: StructKey{Name: "syz_versioned"}}},
+		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "bytesize", FldName: "a1", TypeSize: 8}}, BitSize: 8, Buf: "a0"},
+	}},
 	{Name: "test$vma0", CallName: "test", Args: []Type{
 		&VmaType{TypeCommon: TypeCommon{TypeName: "vma", FldName: "v0", TypeSize: 8}},
 		&LenType{IntTypeCommon: IntTypeCommon{TypeCommon: TypeCommon{TypeName: "len", FldName: "l0", TypeSize: 8}}, Buf: "v0"},
@@ -970,4 +1857,6 @@ var consts_64 = []ConstValue{
 	{Name: "SYS_unsupported"},
 }
 
-const revision_64 = "f2ea17f08041673e28f3c64109373c828a27d585"
+var flags_64 = []FlagDesc(nil)
+
+const revision_64 = "ac6ea8e6d4fa6b8524a6aac84b6a4a4824784244"
@@ -7,7 +7,7 @@ import . "github.com/google/syzkaller/prog"
 import . "github.com/google/syzkaller/sys/trusty"
 
 func init() {
-	RegisterTarget(&Target{OS: "trusty", Arch: "arm", Revision: revision_arm, PtrSize: 4, PageSize: 4096, NumPages: 4096, DataOffset: 536870912, Syscalls: syscalls_arm, Resources: resources_arm, Structs: structDescs_arm, Consts: consts_arm}, InitTarget)
+	RegisterTarget(&Target{OS: "trusty", Arch: "arm", Revision: revision_arm, PtrSize: 4, PageSize: 4096, NumPages: 4096, DataOffset: 536870912, Syscalls: syscalls_arm, Resources: resources_arm, Structs: structDescs_arm, Consts: consts_arm, Flags: flags_arm}, InitTarget)
 }
 
 var resources_arm = []*ResourceDesc(nil)
@@ -173,4 +173,6 @@ var consts_arm = []ConstValue{
 	{Name: "__NR_write", Value: 1},
 }
 
-const revision_arm = "e310afc1c6f271074c53522dc18d6a86e8f2553f"
+var flags_arm = []FlagDesc(nil)
+
+const revision_arm = "0977dd080deb413de35cb9551074eac406be9b0d"
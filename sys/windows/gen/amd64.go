@@ -7,7 +7,7 @@ import . "github.com/google/syzkaller/prog"
 import . "github.com/google/syzkaller/sys/windows"
 
 func init() {
-	RegisterTarget(&Target{OS: "windows", Arch: "amd64", Revision: revision_amd64, PtrSize: 8, PageSize: 4096, NumPages: 4096, DataOffset: 536870912, Syscalls: syscalls_amd64, Resources: resources_amd64, Structs: structDescs_amd64, Consts: consts_amd64}, InitTarget)
+	RegisterTarget(&Target{OS: "windows", Arch: "amd64", Revision: revision_amd64, PtrSize: 8, PageSize: 4096, NumPages: 4096, DataOffset: 536870912, Syscalls: syscalls_amd64, Resources: resources_amd64, Structs: structDescs_amd64, Consts: consts_amd64, Flags: flags_amd64}, InitTarget)
 }
 
 var resources_amd64 = []*ResourceDesc{
@@ -14618,4 +14618,13 @@ var consts_amd64 = []ConstValue{
 	{Name: "WRITE_OWNER", Value: 524288},
 }
 
-const revision_amd64 = "7f58e6832b5d8674b8a77505e6eb0fa213781c23"
+var flags_amd64 = []FlagDesc{
+	{Name: "allocation_type", Values: []string{"MEM_COMMIT", "MEM_RESERVE", "MEM_RESET", "MEM_RESET_UNDO", "MEM_LARGE_PAGES", "MEM_PHYSICAL", "MEM_TOP_DOWN", "MEM_WRITE_WATCH"}},
+	{Name: "file_access_rights", Values: []string{"DELETE", "READ_CONTROL", "SYNCHRONIZE", "WRITE_DAC", "WRITE_OWNER", "FILE_ADD_FILE", "FILE_ADD_SUBDIRECTORY", "FILE_ALL_ACCESS", "FILE_APPEND_DATA", "FILE_CREATE_PIPE_INSTANCE", "FILE_DELETE_CHILD", "FILE_EXECUTE", "FILE_LIST_DIRECTORY", "FILE_READ_ATTRIBUTES", "FILE_READ_DATA", "FILE_READ_EA", "FILE_TRAVERSE", "FILE_WRITE_ATTRIBUTES", "FILE_WRITE_DATA", "FILE_WRITE_EA"}},
+	{Name: "file_attributes", Values: []string{"FILE_ATTRIBUTE_ARCHIVE", "FILE_ATTRIBUTE_ENCRYPTED", "FILE_ATTRIBUTE_HIDDEN", "FILE_ATTRIBUTE_NORMAL", "FILE_ATTRIBUTE_OFFLINE", "FILE_ATTRIBUTE_READONLY", "FILE_ATTRIBUTE_SYSTEM", "FILE_ATTRIBUTE_TEMPORARY", "FILE_FLAG_BACKUP_SEMANTICS", "FILE_FLAG_DELETE_ON_CLOSE", "FILE_FLAG_NO_BUFFERING", "FILE_FLAG_OPEN_NO_RECALL", "FILE_FLAG_OPEN_REPARSE_POINT", "FILE_FLAG_OVERLAPPED", "FILE_FLAG_POSIX_SEMANTICS", "FILE_FLAG_RANDOM_ACCESS", "FILE_FLAG_SESSION_AWARE", "FILE_FLAG_SEQUENTIAL_SCAN", "FILE_FLAG_WRITE_THROUGH", "SECURITY_ANONYMOUS", "SECURITY_CONTEXT_TRACKING", "SECURITY_DELEGATION", "SECURITY_EFFECTIVE_ONLY", "SECURITY_IDENTIFICATION", "SECURITY_IMPERSONATION"}},
+	{Name: "file_create_disposition", Values: []string{"CREATE_ALWAYS", "CREATE_NEW", "OPEN_ALWAYS", "OPEN_EXISTING", "TRUNCATE_EXISTING"}},
+	{Name: "file_share_mode", Values: []string{"FILE_SHARE_DELETE", "FILE_SHARE_READ", "FILE_SHARE_WRITE"}},
+	{Name: "protect_flags", Values: []string{"PAGE_EXECUTE", "PAGE_EXECUTE_READ", "PAGE_EXECUTE_READWRITE", "PAGE_EXECUTE_WRITECOPY", "PAGE_NOACCESS", "PAGE_READONLY", "PAGE_READWRITE", "PAGE_WRITECOPY", "PAGE_TARGETS_INVALID", "PAGE_TARGETS_NO_UPDATE", "PAGE_GUARD", "PAGE_NOCACHE", "PAGE_WRITECOMBINE", "PAGE_ENCLAVE_THREAD_CONTROL", "PAGE_ENCLAVE_UNVALIDATED"}},
+}
+
+const revision_amd64 = "a476f4c77acb8ed6f4c9f866616afdf1c2f8975e"
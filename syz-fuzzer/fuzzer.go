@@ -52,6 +52,9 @@ type Fuzzer struct {
 	maxSignal    signal.Signal // max signal ever observed including flakes
 	newSignal    signal.Signal // diff of maxSignal since last sync with master
 
+	profileMu   sync.Mutex
+	callProfile ipc.CallProfile // execution time of calls since last sync with master
+
 	logMu sync.Mutex
 }
 
@@ -102,6 +105,10 @@ func main() {
 		flagPprof   = flag.String("pprof", "", "address to serve pprof profiles")
 		flagTest    = flag.Bool("test", false, "enable image testing mode")      // used by syz-ci
 		flagRunTest = flag.Bool("runtest", false, "enable program testing mode") // used by pkg/runtest
+		flagErrors  = flag.Float64("error_paths", prog.DefaultErrorPaths,
+			"fraction of generated syscalls with an invalid argument")
+		flagPromote = flag.Float64("const_promotion", 0,
+			"fraction of argument mutations that can change promotable consts")
 	)
 	flag.Parse()
 	outputType := parseOutputType(*flagOutput)
@@ -202,6 +209,9 @@ func main() {
 	config.Flags |= ipc.FlagEnableNetReset
 	config.Flags |= ipc.FlagEnableCgroups
 	config.Flags |= ipc.FlagEnableBinfmtMisc
+	if r.ProfileCalls {
+		execOpts.Flags |= ipc.FlagProfileCalls
+	}
 
 	if *flagRunTest {
 		runTest(target, manager, *flagName, config.Executor)
@@ -232,6 +242,8 @@ func main() {
 	}
 	prios := target.CalculatePriorities(fuzzer.corpus)
 	fuzzer.choiceTable = target.BuildChoiceTable(prios, calls)
+	fuzzer.choiceTable.SetErrorPaths(*flagErrors)
+	fuzzer.choiceTable.SetConstPromotion(*flagPromote)
 
 	for pid := 0; pid < *flagProcs; pid++ {
 		proc, err := newProc(fuzzer, pid)
@@ -290,6 +302,7 @@ func (fuzzer *Fuzzer) poll(needCandidates bool, stats map[string]uint64) bool {
 		NeedCandidates: needCandidates,
 		MaxSignal:      fuzzer.grabNewSignal().Serialize(),
 		Stats:          stats,
+		CallProfile:    fuzzer.grabCallProfile(),
 	}
 	r := &rpctype.PollRes{}
 	if err := fuzzer.manager.Call("Manager.Poll", a, r); err != nil {
@@ -384,6 +397,23 @@ func (fuzzer *Fuzzer) grabNewSignal() signal.Signal {
 	return sign
 }
 
+func (fuzzer *Fuzzer) addCallProfile(p *prog.Prog, info *ipc.ProgInfo) {
+	fuzzer.profileMu.Lock()
+	defer fuzzer.profileMu.Unlock()
+	if fuzzer.callProfile == nil {
+		fuzzer.callProfile = make(ipc.CallProfile)
+	}
+	fuzzer.callProfile.Add(p, info)
+}
+
+func (fuzzer *Fuzzer) grabCallProfile() ipc.CallProfile {
+	fuzzer.profileMu.Lock()
+	defer fuzzer.profileMu.Unlock()
+	prof := fuzzer.callProfile
+	fuzzer.callProfile = nil
+	return prof
+}
+
 func (fuzzer *Fuzzer) corpusSignalDiff(sign signal.Signal) signal.Signal {
 	fuzzer.signalMu.RLock()
 	defer fuzzer.signalMu.RUnlock()
@@ -394,6 +424,10 @@ func (fuzzer *Fuzzer) checkNewSignal(p *prog.Prog, info *ipc.ProgInfo) (calls []
 	fuzzer.signalMu.RLock()
 	defer fuzzer.signalMu.RUnlock()
 	for i, inf := range info.Calls {
+		if p.Calls[i].Meta.NoCover {
+			// Coverage of such calls is noise (e.g. sleeps/yields on unrelated paths).
+			continue
+		}
 		if fuzzer.checkNewCallSignal(p, &inf, i) {
 			calls = append(calls, i)
 		}
@@ -300,6 +300,9 @@ func (proc *Proc) executeRaw(opts *ipc.ExecOpts, p *prog.Prog, stat Stat) *ipc.P
 			continue
 		}
 		log.Logf(2, "result hanged=%v: %s", hanged, output)
+		if info != nil && opts.Flags&ipc.FlagProfileCalls != 0 {
+			proc.fuzzer.addCallProfile(p, info)
+		}
 		return info
 	}
 }
@@ -22,6 +22,7 @@ import (
 
 	"github.com/google/syzkaller/pkg/cover"
 	"github.com/google/syzkaller/pkg/html"
+	"github.com/google/syzkaller/pkg/ipc"
 	"github.com/google/syzkaller/pkg/log"
 	"github.com/google/syzkaller/pkg/osutil"
 	"github.com/google/syzkaller/pkg/signal"
@@ -34,6 +35,7 @@ func (mgr *Manager) initHTTP() {
 	http.HandleFunc("/", mgr.httpSummary)
 	http.HandleFunc("/config", mgr.httpConfig)
 	http.HandleFunc("/syscalls", mgr.httpSyscalls)
+	http.HandleFunc("/profile", mgr.httpProfile)
 	http.HandleFunc("/corpus", mgr.httpCorpus)
 	http.HandleFunc("/crash", mgr.httpCrash)
 	http.HandleFunc("/cover", mgr.httpCover)
@@ -107,6 +109,55 @@ func (mgr *Manager) httpSyscalls(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (mgr *Manager) httpProfile(w http.ResponseWriter, r *http.Request) {
+	data := &UIProfileData{
+		Name: mgr.cfg.Name,
+	}
+	for name, hist := range mgr.stats.allCallProfile() {
+		count := hist.Count()
+		if count == 0 {
+			continue
+		}
+		call := &UICallProfile{
+			Name:   name,
+			Count:  count,
+			Total:  hist.Total,
+			Mean:   (hist.Total / time.Duration(count)).Round(time.Microsecond),
+			Median: profileLimitString(hist.Quantile(0.5)),
+			P99:    profileLimitString(hist.Quantile(0.99)),
+		}
+		for i, n := range hist.Buckets {
+			if n != 0 {
+				call.Buckets = append(call.Buckets, UIProfileBucket{
+					Limit: profileLimitString(ipc.ProfileBucketLimit(i)),
+					Count: n,
+				})
+			}
+		}
+		data.Calls = append(data.Calls, call)
+	}
+	// Calls that consume most of the execution time go first.
+	sort.Slice(data.Calls, func(i, j int) bool {
+		if data.Calls[i].Total != data.Calls[j].Total {
+			return data.Calls[i].Total > data.Calls[j].Total
+		}
+		return data.Calls[i].Name < data.Calls[j].Name
+	})
+	if err := profileTemplate.Execute(w, data); err != nil {
+		http.Error(w, fmt.Sprintf("failed to execute template: %v", err),
+			http.StatusInternalServerError)
+		return
+	}
+}
+
+// profileLimitString formats upper bound of a call profile histogram bucket.
+func profileLimitString(limit time.Duration) string {
+	if limit == 0 {
+		return fmt.Sprintf(">=%v", ipc.ProfileBucketLimit(ipc.ProfileBuckets-2))
+	}
+	return fmt.Sprintf("<%v", limit)
+}
+
 type CallCov struct {
 	count int
 	cov   cover.Cover
@@ -137,6 +188,13 @@ func (mgr *Manager) collectStats() []UIStat {
 			Link:  "/syscalls",
 		})
 	}
+	if mgr.cfg.ProfileCalls {
+		stats = append(stats, UIStat{
+			Name:  "call profile",
+			Value: "execution time",
+			Link:  "/profile",
+		})
+	}
 
 	secs := uint64(1)
 	if !mgr.firstConnect.IsZero() {
@@ -586,6 +644,26 @@ type UISyscallsData struct {
 	Calls []UICallType
 }
 
+type UIProfileData struct {
+	Name  string
+	Calls []*UICallProfile
+}
+
+type UICallProfile struct {
+	Name    string
+	Count   uint64
+	Total   time.Duration
+	Mean    time.Duration
+	Median  string
+	P99     string
+	Buckets []UIProfileBucket
+}
+
+type UIProfileBucket struct {
+	Limit string
+	Count uint64
+}
+
 type UICrashType struct {
 	Description string
 	LastTime    time.Time
@@ -718,6 +796,41 @@ var syscallsTemplate = html.CreatePage(`
 </body></html>
 `)
 
+var profileTemplate = html.CreatePage(`
+<!doctype html>
+<html>
+<head>
+	<title>{{.Name }} syzkaller</title>
+	{{HEAD}}
+</head>
+<body>
+
+<table class="list_table">
+	<caption>Per-syscall execution time:</caption>
+	<tr>
+		<th><a onclick="return sortTable(this, 'Syscall', textSort)" href="#">Syscall</a></th>
+		<th><a onclick="return sortTable(this, 'Calls', numSort)" href="#">Calls</a></th>
+		<th>Total</th>
+		<th>Mean</th>
+		<th>Median</th>
+		<th>99%</th>
+		<th>Histogram</th>
+	</tr>
+	{{range $c := $.Calls}}
+	<tr>
+		<td>{{$c.Name}}</td>
+		<td>{{$c.Count}}</td>
+		<td>{{$c.Total}}</td>
+		<td>{{$c.Mean}}</td>
+		<td>{{$c.Median}}</td>
+		<td>{{$c.P99}}</td>
+		<td>{{range $b := $c.Buckets}}{{$b.Limit}}: {{$b.Count}} {{end}}</td>
+	</tr>
+	{{end}}
+</table>
+</body></html>
+`)
+
 var crashTemplate = html.CreatePage(`
 <!doctype html>
 <html>
@@ -475,7 +475,7 @@ func (mgr *Manager) loadCorpus() {
 			// This program contains a disabled syscall.
 			// We won't execute it, but remember its hash so
 			// it is not deleted during minimization.
-			mgr.disabledHashes[hash.String(rec.Val)] = struct{}{}
+			mgr.disabledHashes[key] = struct{}{}
 			continue
 		}
 		mgr.candidates = append(mgr.candidates, rpctype.RPCCandidate{
@@ -933,6 +933,8 @@ func (mgr *Manager) machineChecked(a *rpctype.CheckArgs) {
 func (mgr *Manager) newInput(inp rpctype.RPCInput, sign signal.Signal) {
 	mgr.mu.Lock()
 	defer mgr.mu.Unlock()
+	// Fuzzers send programs without the version header, but the corpus is stored persistently.
+	inp.Prog = prog.AddVersionHeader(inp.Prog)
 	sig := hash.String(inp.Prog)
 	if old, ok := mgr.corpus[sig]; ok {
 		// The input is already present, but possibly with diffent signal/coverage/call.
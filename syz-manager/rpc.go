@@ -21,6 +21,7 @@ type RPCServer struct {
 	enabledSyscalls []int
 	stats           *Stats
 	batchSize       int
+	profileCalls    bool
 
 	mu           sync.Mutex
 	fuzzers      map[string]*Fuzzer
@@ -50,6 +51,7 @@ func startRPCServer(mgr *Manager) (int, error) {
 		target:          mgr.target,
 		enabledSyscalls: mgr.enabledSyscalls,
 		stats:           mgr.stats,
+		profileCalls:    mgr.cfg.ProfileCalls,
 		fuzzers:         make(map[string]*Fuzzer),
 	}
 	serv.batchSize = 5
@@ -81,6 +83,7 @@ func (serv *RPCServer) Connect(a *rpctype.ConnectArgs, r *rpctype.ConnectRes) er
 		newMaxSignal: serv.maxSignal.Copy(),
 	}
 	r.MemoryLeakFrames = memoryLeakFrames
+	r.ProfileCalls = serv.profileCalls
 	r.EnabledCalls = serv.enabledSyscalls
 	r.CheckResult = serv.checkResult
 	r.GitRevision = sys.GitRevision
@@ -136,6 +139,7 @@ func (serv *RPCServer) NewInput(a *rpctype.NewInputArgs, r *int) error {
 
 func (serv *RPCServer) Poll(a *rpctype.PollArgs, r *rpctype.PollRes) error {
 	serv.stats.mergeNamed(a.Stats)
+	serv.stats.mergeCallProfile(a.CallProfile)
 
 	serv.mu.Lock()
 	defer serv.mu.Unlock()
@@ -6,6 +6,8 @@ package main
 import (
 	"sync"
 	"sync/atomic"
+
+	"github.com/google/syzkaller/pkg/ipc"
 )
 
 type Stat uint64
@@ -27,8 +29,9 @@ type Stats struct {
 	corpusCover      Stat
 	corpusSignal     Stat
 
-	mu         sync.Mutex
-	namedStats map[string]uint64
+	mu          sync.Mutex
+	namedStats  map[string]uint64
+	callProfile ipc.CallProfile
 }
 
 func (stats *Stats) all() map[string]uint64 {
@@ -73,6 +76,26 @@ func (stats *Stats) mergeNamed(named map[string]uint64) {
 	}
 }
 
+func (stats *Stats) mergeCallProfile(prof ipc.CallProfile) {
+	if len(prof) == 0 {
+		return
+	}
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	if stats.callProfile == nil {
+		stats.callProfile = make(ipc.CallProfile)
+	}
+	stats.callProfile.Merge(prof)
+}
+
+func (stats *Stats) allCallProfile() ipc.CallProfile {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	prof := make(ipc.CallProfile)
+	prof.Merge(stats.callProfile)
+	return prof
+}
+
 func (s *Stat) get() uint64 {
 	return atomic.LoadUint64((*uint64)(s))
 }
@@ -27,7 +27,7 @@ func main() {
 	)
 	flag.Parse()
 	args := flag.Args()
-	if len(args) != 3 {
+	if len(args) == 0 || len(args) != 3 && !(args[0] == "canonicalize" && len(args) == 2) {
 		usage()
 	}
 	var target *prog.Target
@@ -43,6 +43,11 @@ func main() {
 		pack(args[1], args[2], target, *flagVersion)
 	case "unpack":
 		unpack(args[1], args[2])
+	case "canonicalize":
+		if target == nil {
+			failf("canonicalize requires -os and -arch")
+		}
+		canonicalize(args[1], target)
 	default:
 		usage()
 	}
@@ -52,6 +57,7 @@ func usage() {
 	fmt.Fprintf(os.Stderr, "usage:\n")
 	fmt.Fprintf(os.Stderr, "  syz-db pack dir corpus.db\n")
 	fmt.Fprintf(os.Stderr, "  syz-db unpack corpus.db dir\n")
+	fmt.Fprintf(os.Stderr, "  syz-db -os=OS -arch=ARCH canonicalize corpus.db\n")
 	os.Exit(1)
 }
 
@@ -75,16 +81,15 @@ func pack(dir, file string, target *prog.Target, version uint64) {
 			}
 		}
 		if sig := hash.String(data); key != sig {
-			if target != nil {
-				p, err := target.Deserialize(data, prog.NonStrict)
-				if err != nil {
-					failf("failed to deserialize %v: %v", file.Name(), err)
-				}
-				data = p.Serialize()
-				sig = hash.String(data)
-			}
 			fmt.Fprintf(os.Stderr, "fixing hash %v -> %v\n", key, sig)
-			key = sig
+		}
+		if target != nil {
+			// Programs are stored with the version header of the current format.
+			p, err := target.Deserialize(data, prog.NonStrict)
+			if err != nil {
+				failf("failed to deserialize %v: %v", file.Name(), err)
+			}
+			data = p.SerializeVersioned()
 		}
 		records = append(records, db.Record{
 			Val: data,
@@ -113,6 +118,16 @@ func unpack(file, dir string) {
 	}
 }
 
+func canonicalize(file string, target *prog.Target) {
+	stats, err := db.CanonicalizeCorpus(file, target)
+	if err != nil {
+		failf("%v", err)
+	}
+	fmt.Printf("programs: %v -> %v (%v duplicates, %v broken)\n",
+		stats.Records, stats.Records-stats.Duplicates, stats.Duplicates, stats.Broken)
+	fmt.Printf("size: %v -> %v bytes\n", stats.Size, stats.NewSize)
+}
+
 func failf(msg string, args ...interface{}) {
 	fmt.Fprintf(os.Stderr, msg+"\n", args...)
 	os.Exit(1)
@@ -25,6 +25,7 @@ var (
 	flagSeed   = flag.Int("seed", -1, "prng seed")
 	flagLen    = flag.Int("len", 30, "number of calls in programs")
 	flagEnable = flag.String("enable", "", "comma-separated list of enabled syscalls")
+	flagCover  = flag.Bool("cover", false, "generate a set of programs that contain every enabled syscall")
 )
 
 func main() {
@@ -59,6 +60,12 @@ func main() {
 	rs := rand.NewSource(seed)
 	prios := target.CalculatePriorities(nil)
 	ct := target.BuildChoiceTable(prios, syscalls)
+	if *flagCover {
+		for _, p := range target.GenerateCovering(rs, *flagLen, ct) {
+			fmt.Printf("%s\n", p.Serialize())
+		}
+		return
+	}
 	var p *prog.Prog
 	if flag.NArg() == 0 {
 		p = target.Generate(rs, *flagLen, ct)
@@ -6,6 +6,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
 	"os"
 	"runtime"
@@ -36,6 +37,15 @@ var (
 	flagSyscalls = flag.String("syscalls", "", "comma-separated list of enabled syscalls")
 	flagEnable   = flag.String("enable", "none", "enable only listed additional features")
 	flagDisable  = flag.String("disable", "none", "enable all additional features except listed")
+	flagDict     = flag.String("dict", "", "dictionary of magic values for generation (AFL format)")
+	flagDescDict = flag.Bool("desc_dict", false, "add const and flags values from descriptions to the dictionary")
+	flagFocus    = flag.String("focus", "", "comma-separated list of syscalls to oversample (e.g. recently changed)")
+	flagWeight   = flag.Float64("focus_weight", 10, "how many times more frequently -focus and -focus_resource syscalls are chosen")
+	flagFocusRes = flag.String("focus_resource", "", "resource to stress (its producers and consumers are oversampled)")
+	flagPrefix   = flag.String("prefix", "", "file with a program that all generated programs start with")
+	flagErrors   = flag.Float64("error_paths", prog.DefaultErrorPaths, "fraction of generated syscalls with an invalid argument")
+	flagPromote  = flag.Float64("const_promotion", 0, "fraction of argument mutations that can change promotable consts")
+	flagShapes   = flag.String("shapes", "", "file with crash reproducers or logs whose argument shapes generation is biased toward")
 
 	statExec uint64
 	gate     *ipc.Gate
@@ -74,6 +84,75 @@ func main() {
 	calls := buildCallList(target, strings.Split(*flagSyscalls, ","))
 	prios := target.CalculatePriorities(corpus)
 	ct := target.BuildChoiceTable(prios, calls)
+	if *flagDict != "" || *flagDescDict {
+		dict := new(prog.Dictionary)
+		if *flagDict != "" {
+			data, err := ioutil.ReadFile(*flagDict)
+			if err != nil {
+				log.Fatalf("failed to read dictionary: %v", err)
+			}
+			dict, err = prog.ParseDictionary(data)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+			log.Logf(0, "parsed %v dictionary entries", dict.Len())
+		}
+		if *flagDescDict {
+			consts := target.ConstDictionary()
+			log.Logf(0, "extracted %v dictionary entries from descriptions", consts.Len())
+			dict.Merge(consts)
+		}
+		ct.SetDictionary(dict)
+	}
+	if *flagFocus != "" {
+		weights := make(map[*prog.Syscall]float64)
+		for _, name := range strings.Split(*flagFocus, ",") {
+			c := target.SyscallMap[name]
+			if c == nil {
+				log.Fatalf("unknown syscall %v in -focus", name)
+			}
+			weights[c] = *flagWeight
+		}
+		ct.SetCallWeights(weights)
+	}
+	if *flagFocusRes != "" {
+		var focus *prog.ResourceDesc
+		for _, res := range target.Resources {
+			if res.Name == *flagFocusRes {
+				focus = res
+			}
+		}
+		if focus == nil {
+			log.Fatalf("unknown resource %v in -focus_resource", *flagFocusRes)
+		}
+		ct.SetResourceFocus(focus, *flagWeight)
+	}
+	ct.SetErrorPaths(*flagErrors)
+	ct.SetConstPromotion(*flagPromote)
+	if *flagPrefix != "" {
+		data, err := ioutil.ReadFile(*flagPrefix)
+		if err != nil {
+			log.Fatalf("failed to read prefix program: %v", err)
+		}
+		p, err := target.Deserialize(data, prog.NonStrict)
+		if err != nil {
+			log.Fatalf("failed to parse prefix program: %v", err)
+		}
+		ct.SetPrefix(p)
+	}
+	if *flagShapes != "" {
+		data, err := ioutil.ReadFile(*flagShapes)
+		if err != nil {
+			log.Fatalf("failed to read shapes: %v", err)
+		}
+		shapes := new(prog.Shapes)
+		entries := target.ParseLog(data)
+		for _, ent := range entries {
+			shapes.AddProg(ent.P)
+		}
+		log.Logf(0, "extracted %v argument shapes from %v programs", shapes.Len(), len(entries))
+		ct.SetShapes(shapes)
+	}
 
 	config, execOpts, err := ipcconfig.Default(target)
 	if err != nil {